@@ -1,270 +0,0 @@
-package main
-
-import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"strings"
-)
-
-/* ───── Helpers ──────────────────────────────────────────────────────── */
-
-func normalize(s string) string {
-	s = strings.ToLower(s)
-	for _, r := range []struct{ old, new string }{
-		{".", ""}, {",", ""}, {"-", ""}, {"–", ""}, {"—", ""},
-		{"(", ""}, {")", ""}, {"division", ""},
-		{"  ", " "},
-	} {
-		s = strings.ReplaceAll(s, r.old, r.new)
-	}
-	return strings.TrimSpace(s)
-}
-
-/* ───── Estruturas esperadas pelo main.go ───────────────────────────── */
-
-type CrimeTypeData struct {
-	Type  string `json:"type"`
-	Count int    `json:"count"`
-}
-type CrimeStats struct {
-	Total     int             `json:"total"`
-	PerCapita float64         `json:"perCapita"`
-	Breakdown []CrimeTypeData `json:"breakdown"`
-}
-
-/* ───── JSON-stat genérico ──────────────────────────────────────────── */
-
-type PxStatResp struct {
-	Dataset struct {
-		Dimension map[string]struct {
-			Label    string `json:"label"`
-			Category struct {
-				Index []string          `json:"index"`
-				Label map[string]string `json:"label"`
-			} `json:"category"`
-		} `json:"dimension"`
-		Value []float64 `json:"value"`
-	} `json:"dataset"`
-}
-
-/* ───── População aproximada por divisão (ajuste se quiser) ─────────── */
-
-func pop(div string) int {
-	if v, ok := map[string]int{
-		"D.M.R. Northern Division":      180000,
-		"D.M.R. North Central Division": 300000,
-		"D.M.R. Southern Division":      200000,
-		"D.M.R. South Central Division": 280000,
-		"D.M.R. Eastern Division":       260000,
-		"D.M.R. Western Division":       220000,
-	}[div]; ok {
-		return v
-	}
-	return 100000
-}
-
-/* ───── ArcGIS → Nome da Divisão ────────────────────────────────────── */
-
-type gardaResp struct {
-	Features []struct{ Attributes struct{ Division string } }
-}
-
-func getGardaDivision(lat, lng float64) (string, error) {
-	base := "https://services1.arcgis.com/eNO7HHeQ3rUcBllm/arcgis/rest/services/" +
-		"GardaDistricts/FeatureServer/0/query"
-	q := url.Values{
-		"geometry":     {fmt.Sprintf("%f,%f", lng, lat)},
-		"geometryType": {"esriGeometryPoint"},
-		"inSR":         {"4326"},
-		"outFields":    {"Division"},
-		"f":            {"json"},
-	}
-	resp, err := http.Get(base + "?" + q.Encode())
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	var gr gardaResp
-	if err := json.NewDecoder(resp.Body).Decode(&gr); err != nil {
-		return "", err
-	}
-	if len(gr.Features) == 0 {
-		return "", fmt.Errorf("coordenadas fora de qualquer divisão Garda")
-	}
-	return gr.Features[0].Attributes.Division, nil
-}
-
-/* ───── Função pública usada no main.go ─────────────────────────────── */
-
-func GetCrimeStats(lat, lng float64) (*CrimeStats, error) {
-	div, err := getGardaDivision(lat, lng)
-	if err != nil {
-		return nil, err
-	}
-	return fetchStats(div, "2024")
-}
-
-/* ───── Core: consulta CSO e devolve CrimeStats ─────────────────────── */
-
-func fetchStats(division, year string) (*CrimeStats, error) {
-	const urlCSO = "https://ws.cso.ie/public/api.restful/PxStat.Data.Cube_API.ReadDataset/CJA07/JSON-stat/2.0/en?format=jsonstat2"
-	resp, err := http.Get(urlCSO)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch CSO data: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("CSO API returned status code: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var px PxStatResp
-	if err := json.Unmarshal(body, &px); err != nil {
-		return nil, fmt.Errorf("decoding CSO JSON: %w", err)
-	}
-
-	// Check if we have the expected dimension data
-	if len(px.Dataset.Dimension) == 0 {
-		// API format has changed - provide a fallback with estimated data
-		// This is a temporary solution until we can update to the new API format
-		estimatedTotal := 500 // Conservative estimate for total crimes
-		population := pop(division)
-		perCapita := float64(estimatedTotal) / float64(population)
-
-		return &CrimeStats{
-			Total:     estimatedTotal,
-			PerCapita: perCapita,
-			Breakdown: []CrimeTypeData{
-				{Type: "Property Crime", Count: 300},
-				{Type: "Violent Crime", Count: 100},
-				{Type: "Other Crime", Count: 100},
-			},
-		}, nil
-	}
-
-	/* ─── 1. Identificar chaves da dimensão Região e Ano ─── */
-	var regionKey, yearKey string
-
-	// Debug: Print available dimensions
-	fmt.Printf("Available dimensions: %v\n", px.Dataset.Dimension)
-
-	// 1a) tenta pelo label descritivo
-	for k, v := range px.Dataset.Dimension {
-		l := strings.ToLower(v.Label)
-		if regionKey == "" && (strings.Contains(l, "garda") ||
-			strings.Contains(l, "division") || strings.Contains(l, "station") ||
-			strings.Contains(l, "area") || strings.Contains(l, "region")) {
-			regionKey = k
-		}
-		if yearKey == "" && (strings.Contains(l, "year") ||
-			strings.Contains(l, "time") || strings.Contains(l, "period")) {
-			yearKey = k
-		}
-	}
-
-	// 1b) se falhou, tenta pelo nome da chave
-	if regionKey == "" {
-		for k := range px.Dataset.Dimension {
-			if strings.HasPrefix(k, "C0") || strings.HasPrefix(k, "STATISTIC") ||
-				strings.HasPrefix(k, "REGION") || strings.HasPrefix(k, "AREA") {
-				regionKey = k
-				break
-			}
-		}
-	}
-	if yearKey == "" {
-		for k := range px.Dataset.Dimension {
-			if strings.HasPrefix(strings.ToUpper(k), "TLIST") || strings.HasPrefix(k, "TIME") ||
-				strings.HasPrefix(k, "YEAR") || strings.HasPrefix(k, "PERIOD") {
-				yearKey = k
-				break
-			}
-		}
-	}
-
-	// 1c) último fallback: assume 1ª dimensão = região, 2ª = ano
-	if regionKey == "" || yearKey == "" {
-		keys := make([]string, 0, len(px.Dataset.Dimension))
-		for k := range px.Dataset.Dimension {
-			keys = append(keys, k)
-		}
-		if len(keys) >= 2 {
-			if regionKey == "" {
-				regionKey = keys[0]
-			}
-			if yearKey == "" {
-				yearKey = keys[1]
-			}
-		}
-	}
-
-	// Confirma existência
-	regDim, okR := px.Dataset.Dimension[regionKey]
-	yrDim, okY := px.Dataset.Dimension[yearKey]
-	if !okR || !okY {
-		all := make([]string, 0, len(px.Dataset.Dimension))
-		for k := range px.Dataset.Dimension {
-			all = append(all, k)
-		}
-		return nil, fmt.Errorf("dimensões não encontradas (reg: %s / ano: %s). chaves disponíveis: %v",
-			regionKey, yearKey, all)
-	}
-
-	/* ─── 2. Match da divisão ─── */
-	target := normalize(division)
-	regIdx := -1
-	var regLabel string
-	for idx, code := range regDim.Category.Index {
-		lbl := regDim.Category.Label[code]
-		if normalize(lbl) == target || strings.Contains(normalize(lbl), target) {
-			regIdx = idx
-			regLabel = lbl
-			break
-		}
-	}
-	if regIdx == -1 {
-		return nil, fmt.Errorf("divisão '%s' não encontrada no CSO", division)
-	}
-
-	/* ─── 3. Índice do ano ─── */
-	yrIdx := -1
-	for idx, code := range yrDim.Category.Index {
-		if code == year {
-			yrIdx = idx
-			break
-		}
-	}
-	if yrIdx == -1 {
-		return nil, fmt.Errorf("ano %s não disponível", year)
-	}
-
-	/* ─── 4. Total de incidentes ─── */
-	nYr := len(yrDim.Category.Index)
-	pos := regIdx*nYr + yrIdx
-	if pos >= len(px.Dataset.Value) {
-		return nil, fmt.Errorf("posição fora do vetor Value")
-	}
-	total := int(px.Dataset.Value[pos])
-
-	/* ─── 5. Per-capita ─── */
-	perCap := 0.0
-	if p := pop(regLabel); p > 0 {
-		perCap = float64(total) / float64(p)
-	}
-
-	/* ─── 6. Retorno ─── */
-	return &CrimeStats{
-		Total:     total,
-		PerCapita: perCap,
-		Breakdown: []CrimeTypeData{}, // cubo não inclui tipos de crime
-	}, nil
-}
@@ -0,0 +1,101 @@
+package enrich
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── "Aja rápido": procura pelo anúncio e urgência para aplicar ──── */
+
+// actFastAdvice combina visualizações por dia, tempo no ar, preço relativo à área e a
+// oferta recente na área (proxy da demanda) numa nota de urgência; supply é quantos
+// anúncios parecidos o bairro teve nas últimas semanas (ver History.AreaSupply)
+func actFastAdvice(p *scraper.PropertyInfo, supply int, now time.Time) *scraper.ActFastAdvice {
+	advice := &scraper.ActFastAdvice{Reasons: []string{}}
+	score := 0
+
+	days := -1
+	if p.PublishedAt != nil {
+		days = int(now.Sub(*p.PublishedAt).Hours() / 24)
+		if days < 0 {
+			days = 0
+		}
+		advice.DaysOnMarket = &days
+	}
+
+	if p.Views > 0 {
+		elapsed := 1.0
+		if days > 0 {
+			elapsed = float64(days)
+		}
+		advice.ViewsPerDay = math.Round(float64(p.Views)/elapsed*10) / 10
+		score += int(math.Min(advice.ViewsPerDay/10, 40))
+		switch {
+		case advice.ViewsPerDay >= 200:
+			advice.Reasons = append(advice.Reasons, fmt.Sprintf("Very high interest: about %.0f views a day", advice.ViewsPerDay))
+		case advice.ViewsPerDay >= 50:
+			advice.Reasons = append(advice.Reasons, fmt.Sprintf("Steady interest: about %.0f views a day", advice.ViewsPerDay))
+		}
+	}
+
+	switch {
+	case days < 0:
+	case days <= 1:
+		score += 20
+		advice.Reasons = append(advice.Reasons, "Listed in the last day; most enquiries arrive in the first 48 hours")
+	case days <= 3:
+		score += 15
+	case days <= 7:
+		score += 8
+	case days > 21:
+		score -= 10
+		advice.Reasons = append(advice.Reasons, fmt.Sprintf("On the market for %d days", days))
+	}
+
+	switch rating := p.ValueAnalysis.PriceRating; {
+	case rating >= 8:
+		score += 20
+		advice.Reasons = append(advice.Reasons, "Priced below similar listings in the area")
+	case rating >= 6:
+		score += 10
+	case rating > 0 && rating <= 3:
+		score -= 10
+		advice.Reasons = append(advice.Reasons, "Priced above similar listings in the area")
+	}
+
+	// zero anúncios parecidos quase sempre é falta de dados, não falta de oferta
+	if n := supply; n > 0 {
+		advice.AreaListings = n
+		switch {
+		case n <= 2:
+			score += 15
+			advice.Reasons = append(advice.Reasons, "Very few similar listings in the area recently")
+		case n <= 5:
+			score += 8
+		case n >= 15:
+			score -= 5
+			advice.Reasons = append(advice.Reasons, "Plenty of similar listings in the area")
+		}
+	}
+
+	if p.ListingType == "rent" || p.ListingType == "share" {
+		score += 10
+	}
+
+	advice.Score = min(max(score, 0), 100)
+	switch {
+	case advice.Score >= 60:
+		advice.Level = "apply_now"
+		advice.Advice = "Apply now, before arranging a viewing if you can; this listing is likely to go quickly."
+	case advice.Score >= 35:
+		advice.Level = "apply_soon"
+		advice.Advice = "Register interest today and book the earliest viewing available."
+	default:
+		advice.Level = "time_to_view"
+		advice.Advice = "There is likely time to view it before applying."
+	}
+	return advice
+}
@@ -0,0 +1,42 @@
+package enrich
+
+import (
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestActFastAdvice(t *testing.T) {
+	now := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	published := now.Add(-20 * time.Hour)
+	hot := &scraper.PropertyInfo{
+		URL:         "https://www.daft.ie/for-rent/apartment-1-main-street/1",
+		Address:     "1 Main Street, Rathmines, Dublin 6",
+		ListingType: "rent",
+		PublishedAt: &published,
+		Views:       900,
+	}
+	hot.ValueAnalysis.PriceRating = 8
+	advice := actFastAdvice(hot, 0, now)
+	if advice.Level != "apply_now" {
+		t.Errorf("expected apply_now, got %s (score %d)", advice.Level, advice.Score)
+	}
+	if advice.DaysOnMarket == nil || *advice.DaysOnMarket != 0 || advice.ViewsPerDay != 900 {
+		t.Errorf("unexpected stats: %+v", advice)
+	}
+
+	old := now.Add(-30 * 24 * time.Hour)
+	stale := &scraper.PropertyInfo{
+		URL:         "https://www.daft.ie/for-sale/house-2-main-street/2",
+		Address:     "2 Main Street, Rathmines, Dublin 6",
+		ListingType: "sale",
+		PublishedAt: &old,
+		Views:       600,
+	}
+	stale.ValueAnalysis.PriceRating = 3
+	if advice := actFastAdvice(stale, 0, now); advice.Level != "time_to_view" {
+		t.Errorf("expected time_to_view, got %s (score %d)", advice.Level, advice.Score)
+	}
+}
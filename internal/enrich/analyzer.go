@@ -0,0 +1,232 @@
+package enrich
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/env"
+	"daft-scraper-api/internal/safety"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/value"
+
+	"googlemaps.github.io/maps"
+)
+
+/* ───── Analyzer: os clientes externos do enriquecimento ────────────── */
+
+// Analyzer reúne os clientes externos e os ajustes usados no enriquecimento da
+// análise. Os campos são preenchidos uma vez (NewAnalyzerFromEnv em produção, à mão
+// nos testes) e só lidos depois, então o mesmo Analyzer serve requisições
+// concorrentes; para trocar um provedor, crie outro Analyzer em vez de alterar o que
+// está em uso. Os handlers e os jobs o recebem pelo contexto (ver analyzerFor em
+// internal/server).
+type Analyzer struct {
+	HTTP      *http.Client            // Overpass, Nominatim, Foursquare, CSO, ArcGIS e RSA, com retry e circuit breaker
+	Maps      *maps.Client            // Google Maps; nil sem GOOGLE_MAPS_API_KEY
+	Budget    *MapsBudget             // orçamento debitado pelo Maps; nil = sem limite
+	Places    PlacesProvider          // lugares próximos, na ordem de PLACES_PROVIDER
+	Geocoders []NamedGeocoder         // na ordem de GEOCODERS
+	Overpass  overpassClient          // iluminação pública
+	Crime     safety.Client           // estatísticas de crime
+	RSA       safety.RoadSafetyClient // colisões de trânsito (segurança viária)
+	Elevation elevationClient         // relevo para o bike score
+	Reviews   ReviewsProvider         // nota das agências no Google; nil sem Google Maps
+	RTB       rtbClient               // registro de locações do RTB
+	Upstream  scraper.UpstreamClient  // instância consultada antes de raspar; valor zero = desligado
+	Value     value.Analyzer          // comparáveis, preço por m², custos e prestação
+	History   History                 // anúncios e fotos já vistos; nil = esses módulos são pulados
+
+	// Ajustes lidos do ambiente uma vez, em NewAnalyzer; num Analyzer montado à mão os
+	// campos zerados usam os padrões
+	FuelPrice          float64                  // FUEL_PRICE em €/L; 0 = defaultFuelPrice
+	FuelConsumption    float64                  // FUEL_CONSUMPTION em L/100 km; 0 = defaultFuelConsumption
+	CommuteDays        int                      // COMMUTE_DAYS, quando o pedido não diz; 0 = defaultCommuteDays
+	Radii              map[string]uint          // raio de cada busca em metros (<BUSCA>_RADIUS); faltando, o de searchRadii
+	AmenityTypes       []string                 // AMENITY_TYPES; nil = DefaultAmenityTypes
+	EntertainmentTypes []string                 // ENTERTAINMENT_TYPES; nil = DefaultEntertainmentTypes
+	POIMaxPerType      int                      // POI_MAX_PER_TYPE; 0 = defaultPOIMaxPerType
+	ScoreWeights       ScoreWeights             // SCORE_WEIGHT_*; o valor zero pesa tudo igual
+	Timeouts           map[string]time.Duration // <ETAPA>_TIMEOUT; faltando, o de scraper.StageTimeouts
+	Circuit            scraper.CircuitSettings  // CIRCUIT_FAILURES e CIRCUIT_COOLDOWN
+}
+
+// History é o que a análise lê e registra entre um anúncio e outro: as anotações dos
+// usuários, o índice de fotos, os anúncios já vistos e a oferta recente do bairro. O
+// servidor guarda tudo no Store; sem History (a biblioteca), as fotos repetidas, o
+// tempo no mercado e as anotações ficam de fora da análise.
+type History interface {
+	Annotations(lat, lng float64) []scraper.Annotation
+	DuplicatePhotos(ctx context.Context, property *scraper.PropertyInfo) error
+	MarketHistory(property *scraper.PropertyInfo, now time.Time) (*scraper.MarketHistory, error)
+	AreaSupply(property *scraper.PropertyInfo, now time.Time) int
+}
+
+type analyzerKey struct{}
+
+// WithAnalyzer anota no contexto o Analyzer do deployment, usado por analyzerFor (em
+// internal/server) quando o tenant não tem um próprio
+func WithAnalyzer(ctx context.Context, a *Analyzer) context.Context {
+	return context.WithValue(scraper.WithFetchSettings(ctx, a.FetchSettings()), analyzerKey{}, a)
+}
+
+// AnalyzerFrom devolve o Analyzer anotado por WithAnalyzer, ou nil
+func AnalyzerFrom(ctx context.Context) *Analyzer {
+	a, _ := ctx.Value(analyzerKey{}).(*Analyzer)
+	return a
+}
+
+// ServeAnalyzer passa a aos handlers no contexto de cada requisição
+func ServeAnalyzer(a *Analyzer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithAnalyzer(r.Context(), a)))
+	})
+}
+
+// analyzerHTTPTimeout limita cada chamada às APIs externas, somando as tentativas
+const analyzerHTTPTimeout = 30 * time.Second
+
+// NewAnalyzerFromEnv monta o Analyzer a partir das variáveis de ambiente
+func NewAnalyzerFromEnv() *Analyzer {
+	return NewAnalyzer(os.Getenv("GOOGLE_MAPS_API_KEY"), nil)
+}
+
+// NewAnalyzer monta o Analyzer com a chave do Google Maps mapsKey (vazia = sem
+// Google), cujas chamadas são debitadas de budget (nil = o de MAPS_DAILY_BUDGET); o
+// resto vem do ambiente
+func NewAnalyzer(mapsKey string, budget *MapsBudget) *Analyzer {
+	if budget == nil {
+		budget = NewMapsBudgetFromEnv()
+	}
+	a := &Analyzer{
+		Budget:             budget,
+		Radii:              searchRadiiFromEnv(),
+		AmenityTypes:       env.List("AMENITY_TYPES", DefaultAmenityTypes),
+		EntertainmentTypes: env.List("ENTERTAINMENT_TYPES", DefaultEntertainmentTypes),
+		POIMaxPerType:      env.Int("POI_MAX_PER_TYPE", defaultPOIMaxPerType),
+		ScoreWeights:       scoreWeightsFromEnv(),
+		Timeouts:           scraper.StageTimeoutsFromEnv(),
+		Circuit:            scraper.CircuitSettingsFromEnv(),
+	}
+	a.HTTP = &http.Client{
+		Timeout:   analyzerHTTPTimeout,
+		Transport: scraper.TracingTransport{Base: scraper.CircuitTransport{Base: scraper.NewRetryTransport(http.DefaultTransport), Settings: a.Circuit}},
+	}
+
+	if mapsKey != "" {
+		client, err := newMapsClient(mapsKey, budget, a.Circuit)
+		if err != nil {
+			slog.Warn("Could not create the Google Maps client", "error", err)
+		} else {
+			a.Maps = client
+			a.Reviews = googleReviews{client: client}
+		}
+	}
+
+	a.Overpass = overpassClient{client: a.HTTP, endpoints: scraper.OverpassEndpoints()}
+	a.Crime = safety.Client{HTTP: a.HTTP}
+	a.RSA = safety.RoadSafetyClient{HTTP: a.HTTP, Endpoint: os.Getenv("RSA_COLLISIONS_URL"), Radius: a.Radius("collisions"),
+		Years: env.Int("COLLISIONS_YEARS", safety.DefaultCollisionYears), Timeout: a.stageTimeout("rsa")}
+	a.RTB = rtbClient{client: a.HTTP, endpoint: os.Getenv("RTB_REGISTER_URL"), timeout: a.stageTimeout("upstream")}
+	a.Value.CRO = value.CROClient{Email: os.Getenv("CRO_API_EMAIL"), Key: os.Getenv("CRO_API_KEY")}
+	a.Upstream = scraper.NewUpstreamClientFromEnv(a.stageTimeout("upstream"))
+	a.Value.Tesseract = env.Or("TESSERACT_PATH", "tesseract")
+	a.Value.MortgageRate = env.Float("MORTGAGE_RATE", value.DefaultMortgageRate)
+	a.Value.EnergyPrice = env.Float("ENERGY_PRICE_KWH", value.DefaultEnergyPrice)
+	a.FuelPrice = env.Float("FUEL_PRICE", defaultFuelPrice)
+	a.FuelConsumption = env.Float("FUEL_CONSUMPTION", defaultFuelConsumption)
+	a.CommuteDays = env.Int("COMMUTE_DAYS", defaultCommuteDays)
+	a.Elevation = elevationClient{client: a.HTTP, endpoint: env.Or("ELEVATION_URL", "https://api.open-meteo.com/v1/elevation")}
+	a.Places = tracedPlaces{newPlacesFromEnv(a, mapsKey)}
+	a.Geocoders = newGeocodersFromEnv(a)
+	return a
+}
+
+// Spend é o orçamento do Maps debitado pelas chamadas deste Analyzer; um Analyzer
+// montado à mão, sem Budget, não tem limite
+func (a *Analyzer) Spend() *MapsBudget {
+	if a.Budget != nil {
+		return a.Budget
+	}
+	return &MapsBudget{}
+}
+
+// searchRadii são os raios padrão, em metros, de cada busca por lugares próximos
+var searchRadii = map[string]uint{
+	"train":         2000,
+	"bus":           1000,
+	"amenities":     1500,
+	"entertainment": 2000,
+	"gardai":        5000,
+	"lighting":      500,
+	"bike":          1000,
+	"family":        1500,
+	"remote_work":   1500,
+	"collisions":    500,
+}
+
+// searchRadiiFromEnv lê <BUSCA>_RADIUS (TRAIN_RADIUS, AMENITIES_RADIUS...) para cada
+// busca de searchRadii
+func searchRadiiFromEnv() map[string]uint {
+	radii := make(map[string]uint, len(searchRadii))
+	for name, def := range searchRadii {
+		radii[name] = uint(env.Int(strings.ToUpper(name)+"_RADIUS", int(def)))
+	}
+	return radii
+}
+
+// Radius é o raio da busca em metros
+func (a *Analyzer) Radius(search string) uint {
+	if r := a.Radii[search]; r > 0 {
+		return r
+	}
+	return searchRadii[search]
+}
+
+// defaultPOIMaxPerType é quantos lugares de cada tipo a análise guarda
+const defaultPOIMaxPerType = 10
+
+// amenityTypes e entertainmentTypes são os tipos de lugar buscados
+func (a *Analyzer) amenityTypes() []string {
+	if a.AmenityTypes != nil {
+		return a.AmenityTypes
+	}
+	return DefaultAmenityTypes
+}
+
+func (a *Analyzer) entertainmentTypes() []string {
+	if a.EntertainmentTypes != nil {
+		return a.EntertainmentTypes
+	}
+	return DefaultEntertainmentTypes
+}
+
+// poiMaxPerType é quantos lugares de cada tipo TidyPOIs mantém
+func (a *Analyzer) poiMaxPerType() int {
+	if a.POIMaxPerType > 0 {
+		return a.POIMaxPerType
+	}
+	return defaultPOIMaxPerType
+}
+
+// stageTimeout é o limite da etapa neste Analyzer
+func (a *Analyzer) stageTimeout(stage string) time.Duration {
+	if d := a.Timeouts[stage]; d > 0 {
+		return d
+	}
+	return scraper.StageTimeouts[stage]
+}
+
+// FetchSettings são os ajustes deste Analyzer para as páginas raspadas
+func (a *Analyzer) FetchSettings() scraper.FetchSettings {
+	return scraper.FetchSettings{Timeout: a.stageTimeout("scrape"), Circuit: a.Circuit}
+}
+
+// WithStageTimeout deriva de ctx um contexto com o limite da etapa; o cancelamento da
+// requisição (cliente desconectado) continua valendo
+func (a *Analyzer) WithStageTimeout(ctx context.Context, stage string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, a.stageTimeout(stage))
+}
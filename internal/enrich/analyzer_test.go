@@ -0,0 +1,94 @@
+package enrich
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+
+	"googlemaps.github.io/maps"
+)
+
+// mapsBackedPlaces is a fake provider that makes one counted Maps call per search,
+// like googlePlaces does through the shared client
+type mapsBackedPlaces struct {
+	client *http.Client
+	url    string
+}
+
+func (m mapsBackedPlaces) SearchNearby(ctx context.Context, location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.url+"/maps/api/place/nearbysearch/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	result := maps.PlacesSearchResult{Name: placeType, Types: []string{placeType}}
+	result.Geometry.Location = *location
+	return []maps.PlacesSearchResult{result}, nil
+}
+
+func TestAnalyzerSharedAcrossAnalyses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// one Analyzer, one client, many concurrent analyses
+	a := &Analyzer{
+		Places:    mapsBackedPlaces{client: &http.Client{Transport: countingTransport{base: http.DefaultTransport, budget: &MapsBudget{}}}, url: srv.URL},
+		Geocoders: []NamedGeocoder{{"eircode", EircodeGeocoder{}}},
+	}
+
+	modules := scraper.ModuleSet{"transport": true}
+	properties := make([]scraper.PropertyInfo, 8)
+	var wg sync.WaitGroup
+	for i := range properties {
+		wg.Add(1)
+		go func(p *scraper.PropertyInfo) {
+			defer wg.Done()
+			p.Address = "Rathmines Road, Dublin 6, D06 X2Y3"
+			if err := a.GetCoordinates(context.Background(), p); err != nil {
+				t.Error(err)
+				return
+			}
+			if err := a.GetQualityOfLife(context.Background(), p, modules); err != nil {
+				t.Error(err)
+			}
+		}(&properties[i])
+	}
+	wg.Wait()
+
+	want := properties[0].MapsCalls()
+	if want == 0 {
+		t.Fatal("the transport module should have made Maps calls")
+	}
+	for i, p := range properties {
+		// each analysis is charged only for its own calls
+		if p.MapsCalls() != want {
+			t.Errorf("property %d: %d Maps calls, want %d", i, p.MapsCalls(), want)
+		}
+		if p.Coordinates.Source != "eircode" || len(p.QualityOfLife.PublicTransport) == 0 {
+			t.Errorf("property %d was not enriched: %+v", i, p)
+		}
+	}
+}
+
+func TestStageTimeout(t *testing.T) {
+	if got := (&Analyzer{}).stageTimeout("overpass"); got != 30*time.Second {
+		t.Errorf("default overpass timeout = %v", got)
+	}
+	t.Setenv("OVERPASS_TIMEOUT", "2s")
+	if got := scraper.StageTimeoutsFromEnv()["overpass"]; got != 2*time.Second {
+		t.Errorf("OVERPASS_TIMEOUT=2s: got %v", got)
+	}
+	t.Setenv("OVERPASS_TIMEOUT", "soon")
+	if got := scraper.StageTimeoutsFromEnv()["overpass"]; got != 30*time.Second {
+		t.Errorf("an invalid value should keep the default, got %v", got)
+	}
+}
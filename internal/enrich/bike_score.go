@@ -0,0 +1,151 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Bike score: ciclovias, bicicletários e relevo ───────────────── */
+
+// Muita gente em Dublin escolhe o imóvel pela viabilidade de ir de bicicleta ao
+// trabalho. O bikeScore (1-100) soma quatro partes: a densidade de ciclovias e
+// ciclofaixas do OSM no raio BIKE_RADIUS (50 pontos), os bicicletários (15), as
+// estações de bike-share (15) e o relevo (20), medido pelo desnível entre pontos do
+// raio na API de elevação (ELEVATION_URL). Sem a elevação, a nota sai das outras
+// três partes.
+
+// cycleLaneValues são os valores de cycleway=* (e :left, :right, :both) que contam
+// como infraestrutura; shared_lane (só pintura na pista dos carros) fica de fora
+const cycleLaneValues = "^(lane|track|opposite_lane|opposite_track)$"
+
+// analyzeCycling busca a infraestrutura e o relevo em volta do imóvel e calcula o
+// bikeScore; uma falha da elevação só tira o relevo da nota
+func (a *Analyzer) analyzeCycling(ctx context.Context, property *scraper.PropertyInfo) (err error) {
+	ctx, s := telemetry.StartSpan(ctx, "overpass cycling", telemetry.SpanInternal)
+	defer func() { s.End(err) }()
+
+	radius := a.Radius("bike")
+	lat, lng := property.Coordinates.Lat, property.Coordinates.Lng
+	around := fmt.Sprintf("(around:%d,%f,%f)", radius, lat, lng)
+	query := `[out:json];(` +
+		`way["highway"="cycleway"]` + around + `;` +
+		`way["cycleway"~"` + cycleLaneValues + `"]` + around + `;` +
+		`way["cycleway:both"~"` + cycleLaneValues + `"]` + around + `;` +
+		`way["cycleway:left"~"` + cycleLaneValues + `"]` + around + `;` +
+		`way["cycleway:right"~"` + cycleLaneValues + `"]` + around + `;` +
+		`);out geom;` +
+		`node["amenity"~"^(bicycle_parking|bicycle_rental)$"]` + around + `;out tags;`
+
+	overpassCtx, cancel := a.WithStageTimeout(ctx, "overpass")
+	elements, err := a.Overpass.query(overpassCtx, query)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	info := &scraper.CyclingInfo{RadiusMeters: int(radius)}
+	laneMeters := 0.0
+	for _, el := range elements {
+		switch {
+		case el.Type == "way":
+			laneMeters += roadLengthWithin(el, lat, lng, float64(radius))
+		case el.Tags["amenity"] == "bicycle_parking":
+			info.BikeParking++
+		case el.Tags["amenity"] == "bicycle_rental":
+			info.BikeShareStations++
+		}
+	}
+	info.CycleLaneKm = math.Round(laneMeters/100) / 10
+
+	elevationCtx, cancel := a.WithStageTimeout(ctx, "elevation")
+	elevationRange, err := a.Elevation.elevationRange(elevationCtx, lat, lng, float64(radius))
+	cancel()
+	if err != nil {
+		telemetry.LogFor(ctx).Warn("Elevation lookup failed, bike score without hilliness", "error", err)
+	} else {
+		info.ElevationRange = &elevationRange
+	}
+
+	property.QualityOfLife.Cycling = info
+	property.QualityOfLife.BikeScore = bikeScore(info)
+	return nil
+}
+
+// bikeScore dá a nota (1-100) aos dados de CyclingInfo. Cada parte cresce em linha
+// reta até o ponto em que a área já é boa para bicicleta: 2,5 km de ciclovia por km²,
+// 10 bicicletários, 3 estações de bike-share e até 10 m de desnível (60 m ou mais
+// valem zero).
+func bikeScore(info *scraper.CyclingInfo) int {
+	areaKm2 := math.Pi * math.Pow(float64(info.RadiusMeters)/1000, 2)
+	share := func(value, full float64) float64 { return math.Min(value/full, 1) }
+
+	points := 50*share(info.CycleLaneKm/areaKm2, 2.5) +
+		15*share(float64(info.BikeParking), 10) +
+		15*share(float64(info.BikeShareStations), 3)
+	possible := 80.0
+	if info.ElevationRange != nil {
+		points += 20 * (1 - math.Max(0, math.Min(float64(*info.ElevationRange)-10, 50))/50)
+		possible += 20
+	}
+	return min(max(int(math.Round(points/possible*100)), 1), 100)
+}
+
+/* ───── Elevação ────────────────────────────────────────────────────── */
+
+// elevationClient consulta uma API de elevação no formato da Open-Meteo
+// (?latitude=a,b&longitude=x,y → {"elevation":[...]}); só lê os campos, é seguro
+// para uso concorrente
+type elevationClient struct {
+	client   *http.Client
+	endpoint string
+}
+
+// elevationRange é o desnível, em metros, entre o imóvel e oito pontos em volta dele
+// a radius metros (N, NE, L...)
+func (e elevationClient) elevationRange(ctx context.Context, lat, lng, radius float64) (int, error) {
+	lats, lngs := []string{fmt.Sprintf("%.5f", lat)}, []string{fmt.Sprintf("%.5f", lng)}
+	for i := 0; i < 8; i++ {
+		angle := float64(i) * math.Pi / 4
+		dLat := radius * math.Cos(angle) / 111320
+		dLng := radius * math.Sin(angle) / (111320 * math.Cos(lat*math.Pi/180))
+		lats = append(lats, fmt.Sprintf("%.5f", lat+dLat))
+		lngs = append(lngs, fmt.Sprintf("%.5f", lng+dLng))
+	}
+
+	q := url.Values{"latitude": {strings.Join(lats, ",")}, "longitude": {strings.Join(lngs, ",")}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.endpoint+"?"+q.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error querying elevation API: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("elevation API returned status code: %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Elevation []float64 `json:"elevation"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("error decoding elevation response: %w", err)
+	}
+	if len(result.Elevation) == 0 {
+		return 0, fmt.Errorf("elevation API returned no points")
+	}
+	low, high := result.Elevation[0], result.Elevation[0]
+	for _, e := range result.Elevation {
+		low, high = math.Min(low, e), math.Max(high, e)
+	}
+	return int(math.Round(high - low)), nil
+}
@@ -0,0 +1,71 @@
+package enrich
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestBikeScore(t *testing.T) {
+	flat, hilly := 5, 80
+	// 1 km radius ≈ 3.14 km²: 7.9 km of lanes is the full 2.5 km/km²
+	best := &scraper.CyclingInfo{RadiusMeters: 1000, CycleLaneKm: 7.9, BikeParking: 12, BikeShareStations: 4, ElevationRange: &flat}
+	if got := bikeScore(best); got != 100 {
+		t.Errorf("dense, flat cycling area = %d, want 100", got)
+	}
+	if got := bikeScore(&scraper.CyclingInfo{RadiusMeters: 1000}); got != 1 {
+		t.Errorf("no cycling infrastructure = %d, want the minimum 1", got)
+	}
+
+	steep := *best
+	steep.ElevationRange = &hilly
+	if got := bikeScore(&steep); got != 80 {
+		t.Errorf("same area on a steep hill = %d, want 80", got)
+	}
+	unknown := *best
+	unknown.ElevationRange = nil
+	if got := bikeScore(&unknown); got != 100 {
+		t.Errorf("without elevation the other parts decide: got %d", got)
+	}
+}
+
+func TestAnalyzeCycling(t *testing.T) {
+	overpass, query := fakeOverpass(t, `{"elements":[
+		{"type":"way","geometry":[{"lat":53.3400,"lon":-6.2600},{"lat":53.3490,"lon":-6.2600}]},
+		{"type":"node","tags":{"amenity":"bicycle_parking"}},
+		{"type":"node","tags":{"amenity":"bicycle_parking"}},
+		{"type":"node","tags":{"amenity":"bicycle_rental","network":"dublinbikes"}}
+	]}`)
+	elevation := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n := len(strings.Split(r.URL.Query().Get("latitude"), ",")); n != 9 {
+			t.Errorf("elevation sampled %d points, want the property and 8 around it", n)
+		}
+		w.Write([]byte(`{"elevation":[12,14,9,30,11,10,12,13,15]}`))
+	}))
+	defer elevation.Close()
+
+	a := &Analyzer{Overpass: overpass, Elevation: elevationClient{client: http.DefaultClient, endpoint: elevation.URL}}
+	var p scraper.PropertyInfo
+	p.Coordinates.Lat, p.Coordinates.Lng = 53.3450, -6.2600
+	if err := a.analyzeCycling(context.Background(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(*query, `"highway"="cycleway"`) || !strings.Contains(*query, "bicycle_rental") {
+		t.Errorf("query = %s", *query)
+	}
+
+	c := p.QualityOfLife.Cycling
+	if c == nil || c.CycleLaneKm != 1 || c.BikeParking != 2 || c.BikeShareStations != 1 {
+		t.Fatalf("cycling = %+v", c)
+	}
+	if c.ElevationRange == nil || *c.ElevationRange != 21 {
+		t.Errorf("elevation range = %v, want 21", c.ElevationRange)
+	}
+	if p.QualityOfLife.BikeScore != bikeScore(c) || p.QualityOfLife.BikeScore <= 1 {
+		t.Errorf("bike score = %d", p.QualityOfLife.BikeScore)
+	}
+}
@@ -0,0 +1,219 @@
+package enrich
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+
+	"googlemaps.github.io/maps"
+)
+
+/* ───── Orçamento diário do Google Maps ─────────────────────────────── */
+
+// MapsSKUPrices é o custo estimado, em USD, de uma chamada de cada SKU do Maps
+// (tabela pública de preços, sem os créditos mensais); MAPS_SKU_PRICES sobrescreve os
+// valores (ver NewMapsBudgetFromEnv)
+var MapsSKUPrices = map[string]float64{
+	"geocode":        0.005,
+	"nearbysearch":   0.032,
+	"textsearch":     0.032,
+	"details":        0.017,
+	"directions":     0.005,
+	"distancematrix": 0.005,
+	"other":          0.005,
+}
+
+// mapsSKU classifica a chamada pelo caminho da URL (/maps/api/place/nearbysearch/json)
+func mapsSKU(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if _, ok := MapsSKUPrices[parts[i]]; ok {
+			return parts[i]
+		}
+	}
+	return "other"
+}
+
+// MapsBudget acumula o gasto estimado do dia (UTC) com uma chave do Maps, somando
+// todos os clientes que a usam. Os limites são lidos uma vez, na criação; o valor
+// zero não tem limite e usa os preços de MapsSKUPrices. Seguro para uso concorrente.
+type MapsBudget struct {
+	Limit  float64            // USD por dia; 0 = sem limite
+	prices map[string]float64 // preço de cada SKU; nil = MapsSKUPrices
+	mode   string             // "degrade" (padrão) ou "refuse"
+
+	mu      sync.Mutex
+	day     string
+	spend   float64
+	calls   map[string]int
+	refused int
+}
+
+// NewMapsBudgetFromEnv lê MAPS_DAILY_BUDGET em USD (0 ou vazio = sem limite),
+// MAPS_SKU_PRICES ("nearbysearch:0.032,geocode:0.005") e MAPS_BUDGET_MODE: "degrade"
+// recusa só as chamadas ao Maps, e as análises seguintes usam o OpenStreetMap (ver
+// placesProviderNames); "refuse" recusa as análises novas que precisariam do Maps
+func NewMapsBudgetFromEnv() *MapsBudget {
+	b := &MapsBudget{prices: make(map[string]float64, len(MapsSKUPrices)), mode: "degrade"}
+	if limit, err := strconv.ParseFloat(os.Getenv("MAPS_DAILY_BUDGET"), 64); err == nil && limit > 0 {
+		b.Limit = limit
+	}
+	for sku, price := range MapsSKUPrices {
+		b.prices[sku] = price
+	}
+	for _, pair := range strings.Split(os.Getenv("MAPS_SKU_PRICES"), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			continue
+		}
+		if price, err := strconv.ParseFloat(value, 64); err == nil && price >= 0 {
+			b.prices[name] = price
+		}
+	}
+	if os.Getenv("MAPS_BUDGET_MODE") == "refuse" {
+		b.mode = "refuse"
+	}
+	return b
+}
+
+// price é o custo estimado de uma chamada do SKU
+func (b *MapsBudget) price(sku string) float64 {
+	if price, ok := b.prices[sku]; ok {
+		return price
+	}
+	return MapsSKUPrices[sku]
+}
+
+// refuses diz se o orçamento está no modo "refuse"
+func (b *MapsBudget) refuses() bool {
+	return b.mode == "refuse"
+}
+
+// rollover zera os contadores quando o dia muda; chame com mu travado
+func (b *MapsBudget) rollover(now time.Time) {
+	if day := now.UTC().Format("2006-01-02"); day != b.day {
+		b.day, b.spend, b.calls, b.refused = day, 0, map[string]int{}, 0
+	}
+}
+
+// Reserve debita uma chamada do SKU, ou devolve scraper.ErrMapsBudgetExceeded se ela
+// passaria do orçamento
+func (b *MapsBudget) Reserve(sku string, now time.Time) error {
+	price, budget := b.price(sku), b.Limit
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover(now)
+	if budget > 0 && b.spend+price > budget {
+		b.refused++
+		return scraper.ErrMapsBudgetExceeded
+	}
+	b.spend += price
+	b.calls[sku]++
+	return nil
+}
+
+// exhausted diz se o orçamento do dia já não comporta nem a chamada mais barata
+func (b *MapsBudget) exhausted(now time.Time) bool {
+	if b.Limit == 0 {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover(now)
+	return b.spend+b.price("geocode") > b.Limit
+}
+
+// MapsBudgetReport é o gasto do dia devolvido por /admin/maps-budget
+type MapsBudgetReport struct {
+	Day       string         `json:"day"`
+	Budget    float64        `json:"budget"` // USD, 0 = sem limite
+	Spend     float64        `json:"spend"`  // USD, estimado
+	Remaining *float64       `json:"remaining,omitempty"`
+	Mode      string         `json:"mode"`
+	Exceeded  bool           `json:"exceeded"`
+	Calls     map[string]int `json:"calls"` // por SKU
+	Refused   int            `json:"refused"`
+}
+
+func (b *MapsBudget) Report(now time.Time) MapsBudgetReport {
+	budget, mode := b.Limit, "degrade"
+	if b.refuses() {
+		mode = "refuse"
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover(now)
+	report := MapsBudgetReport{
+		Day:     b.day,
+		Budget:  budget,
+		Spend:   b.spend,
+		Mode:    mode,
+		Calls:   make(map[string]int, len(b.calls)),
+		Refused: b.refused,
+	}
+	for sku, n := range b.calls {
+		report.Calls[sku] = n
+	}
+	if budget > 0 {
+		remaining := budget - b.spend
+		if remaining < 0 {
+			remaining = 0
+		}
+		report.Remaining = &remaining
+		report.Exceeded = b.spend+b.price("geocode") > budget
+	}
+	return report
+}
+
+// RefuseOverBudget é a checagem do modo "refuse": antes de raspar um anúncio que
+// precisaria do Maps, recusa se o orçamento do dia (o do tenant, se ele tiver chave
+// própria) acabou
+func (a *Analyzer) RefuseOverBudget(modules scraper.ModuleSet) error {
+	budget := a.Spend()
+	if budget.refuses() && modules.NeedsLocation() && budget.exhausted(time.Now()) {
+		return scraper.ErrMapsBudgetExceeded
+	}
+	return nil
+}
+
+type mapsUsageKey struct{}
+
+// WithMapsUsage anota no contexto o contador da análise; o cliente do Maps é um só
+// (Analyzer.Maps), então é pelo contexto de cada chamada que se sabe a quem cobrar
+func WithMapsUsage(ctx context.Context, usage *scraper.MapsUsage) context.Context {
+	return context.WithValue(ctx, mapsUsageKey{}, usage)
+}
+
+// countingTransport debita cada requisição ao Maps do orçamento do dia e a conta no
+// contador da análise que veio no contexto
+type countingTransport struct {
+	base   http.RoundTripper
+	budget *MapsBudget
+}
+
+func (t countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.budget.Reserve(mapsSKU(req.URL.Path), time.Now()); err != nil {
+		return nil, err
+	}
+	if usage, _ := req.Context().Value(mapsUsageKey{}).(*scraper.MapsUsage); usage != nil {
+		atomic.AddInt32(&usage.Calls, 1)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// newMapsClient cria o cliente do Google Maps que passa por countingTransport,
+// debitando as chamadas de budget
+func newMapsClient(apiKey string, budget *MapsBudget, circuit scraper.CircuitSettings) (*maps.Client, error) {
+	httpClient := &http.Client{Transport: scraper.TracingTransport{Base: scraper.CircuitTransport{
+		Base: countingTransport{base: http.DefaultTransport, budget: budget}, Settings: circuit}}}
+	return maps.NewClient(maps.WithAPIKey(apiKey), maps.WithHTTPClient(httpClient))
+}
@@ -0,0 +1,171 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+// withBudget anexa a ctx um Analyzer com o orçamento lido do ambiente
+func withBudget(ctx context.Context) context.Context {
+	return WithAnalyzer(ctx, &Analyzer{Budget: NewMapsBudgetFromEnv()})
+}
+
+func TestMapsSKU(t *testing.T) {
+	cases := map[string]string{
+		"/maps/api/geocode/json":             "geocode",
+		"/maps/api/place/nearbysearch/json":  "nearbysearch",
+		"/maps/api/place/details/json":       "details",
+		"/maps/api/directions/json":          "directions",
+		"/maps/api/timezone/json":            "other",
+		"/maps/api/distancematrix/json/xtra": "distancematrix",
+	}
+	for path, want := range cases {
+		if got := mapsSKU(path); got != want {
+			t.Errorf("mapsSKU(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestMapsBudgetReserve(t *testing.T) {
+	t.Setenv("MAPS_DAILY_BUDGET", "0.07")
+	b := NewMapsBudgetFromEnv()
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if err := b.Reserve("nearbysearch", now); err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+	}
+	if err := b.Reserve("nearbysearch", now); !errors.Is(err, scraper.ErrMapsBudgetExceeded) {
+		t.Fatalf("third nearby search should exceed the budget, got %v", err)
+	}
+	// a cheaper call still fits in what is left
+	if err := b.Reserve("geocode", now); err != nil {
+		t.Errorf("geocode should still fit: %v", err)
+	}
+	if !b.exhausted(now) {
+		t.Error("budget should be exhausted")
+	}
+
+	report := b.Report(now)
+	if report.Calls["nearbysearch"] != 2 || report.Refused != 1 || !report.Exceeded || report.Remaining == nil {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	// the day rolls over at midnight UTC
+	if b.exhausted(now.Add(2 * time.Hour)) {
+		t.Error("budget should reset on a new day")
+	}
+}
+
+func TestMapsBudgetPriceOverride(t *testing.T) {
+	t.Setenv("MAPS_SKU_PRICES", "geocode:0.5, nearbysearch:bad")
+	b := NewMapsBudgetFromEnv()
+	if got := b.price("geocode"); got != 0.5 {
+		t.Errorf("geocode price = %v, want 0.5", got)
+	}
+	if got := b.price("nearbysearch"); got != MapsSKUPrices["nearbysearch"] {
+		t.Errorf("an invalid override should keep the default, got %v", got)
+	}
+}
+
+func TestMapsBudgetRefusesAtTransport(t *testing.T) {
+	t.Setenv("MAPS_DAILY_BUDGET", "0.005")
+	b := NewMapsBudgetFromEnv()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var p scraper.PropertyInfo
+	if err := mapsGet(srv.URL+"/maps/api/geocode/json", p.Usage(), b); err != nil {
+		t.Fatal(err)
+	}
+
+	err := mapsGet(srv.URL+"/maps/api/geocode/json", p.Usage(), b)
+	if !errors.Is(err, scraper.ErrMapsBudgetExceeded) {
+		t.Fatalf("expected the budget error, got %v", err)
+	}
+	if apiErr := scraper.ModuleError(err, "PLACES_FAILED", "transport"); apiErr.Code != "MAPS_BUDGET_EXCEEDED" || apiErr.Module != "transport" {
+		t.Errorf("module warning = %+v", apiErr)
+	}
+	if p.MapsCalls() != 1 {
+		t.Errorf("refused calls should not be counted, got %d", p.MapsCalls())
+	}
+}
+
+func TestRefuseOverBudget(t *testing.T) {
+	t.Setenv("MAPS_DAILY_BUDGET", "0.001")
+
+	if err := (&Analyzer{Budget: NewMapsBudgetFromEnv()}).RefuseOverBudget(scraper.AllModules()); err != nil {
+		t.Errorf("degrade mode should not refuse, got %v", err)
+	}
+	t.Setenv("MAPS_BUDGET_MODE", "refuse")
+	if err := (&Analyzer{}).RefuseOverBudget(scraper.AllModules()); err != nil {
+		t.Errorf("without an analyzer there is no budget to refuse on, got %v", err)
+	}
+	if err := (&Analyzer{Budget: NewMapsBudgetFromEnv()}).RefuseOverBudget(scraper.AllModules()); !errors.Is(err, scraper.ErrMapsBudgetExceeded) {
+		t.Errorf("got %v, want the budget error", err)
+	}
+}
+
+func TestDataQualityMapsBudget(t *testing.T) {
+	p := scraper.PropertyInfo{Warnings: []*scraper.APIError{scraper.ModuleError(scraper.ErrMapsBudgetExceeded, "PLACES_FAILED", "safety")}}
+	q := assessDataQuality(&p, scraper.AllModules())
+	for _, m := range q.Modules {
+		if m.Module == "safety" && (m.Status != "estimated" || m.Code != "MAPS_BUDGET_EXCEEDED") {
+			t.Errorf("safety = %+v, want estimated", m)
+		}
+	}
+
+	// a real failure of the same module wins over the budget note
+	p.Warnings = append(p.Warnings, &scraper.APIError{Code: "OVERPASS_FAILED", Module: "safety"})
+	for _, m := range assessDataQuality(&p, scraper.AllModules()).Modules {
+		if m.Module == "safety" && m.Status != "failed" {
+			t.Errorf("safety = %+v, want failed", m)
+		}
+	}
+}
+
+// mapsGet sends a GET through countingTransport on behalf of the analysis that owns usage
+func mapsGet(url string, usage *scraper.MapsUsage, budget *MapsBudget) error {
+	req, err := http.NewRequestWithContext(WithMapsUsage(context.Background(), usage), http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Transport: countingTransport{base: http.DefaultTransport, budget: budget}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+func TestCountingTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var p, other scraper.PropertyInfo
+	for i := 0; i < 2; i++ {
+		if err := mapsGet(srv.URL, p.Usage(), &MapsBudget{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mapsGet(srv.URL, other.Usage(), &MapsBudget{}); err != nil {
+		t.Fatal(err)
+	}
+	// copies of PropertyInfo share the counter; the shared client charges each analysis apart
+	copied := p
+	if copied.MapsCalls() != 2 || other.MapsCalls() != 1 {
+		t.Errorf("mapsCalls = %d and %d, want 2 and 1", copied.MapsCalls(), other.MapsCalls())
+	}
+	if (&scraper.PropertyInfo{}).MapsCalls() != 0 {
+		t.Error("a property without a counter made no calls")
+	}
+}
@@ -0,0 +1,115 @@
+package enrich
+
+import (
+	"fmt"
+	"strings"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/value"
+)
+
+/* ───── Checklist de visita sob medida ──────────────────────────────── */
+
+// listingContents são itens que o anúncio costuma mencionar; se a descrição não cita,
+// vale confirmar na visita. Cada entrada tem as palavras que contam como "citado".
+var listingContents = []struct {
+	item     string
+	keywords []string
+	rentOnly bool
+}{
+	{"washing machine", []string{"washing machine", "washer dryer", "washer-dryer", "utility room"}, true},
+	{"dishwasher", []string{"dishwasher"}, true},
+	{"heating type", []string{"heating", "heat pump", "storage heater", "radiator"}, false},
+	{"parking", []string{"parking", "car space", "driveway", "garage"}, false},
+	{"broadband", []string{"broadband", "fibre", "internet", "wifi", "wi-fi"}, false},
+}
+
+// ViewingChecklist monta a checklist a partir das lacunas e riscos da análise
+func ViewingChecklist(p *scraper.PropertyInfo) []scraper.ChecklistItem {
+	var items []scraper.ChecklistItem
+	add := func(category, item, reason string) {
+		items = append(items, scraper.ChecklistItem{Category: category, Item: item, Reason: reason})
+	}
+	isRental := p.ListingType == "rent" || p.ListingType == "share"
+
+	// Energia
+	switch band := scraper.BERBand(p.BER); {
+	case p.BER == "":
+		add("energy", "Ask to see the BER certificate", "No BER is stated in the listing, although advertising one is required")
+	case band >= scraper.BERBand("D1"):
+		add("energy", "Ask about the heating system and typical winter energy bills", fmt.Sprintf("The BER is %s", p.BER))
+		add("energy", "Check windows for draughts and condensation", fmt.Sprintf("Common in %s-rated homes", p.BER[:1]))
+	}
+
+	// Conteúdo que o anúncio não menciona
+	description := strings.ToLower(p.Description)
+	for _, c := range listingContents {
+		if c.rentOnly && !isRental {
+			continue
+		}
+		mentioned := false
+		for _, k := range c.keywords {
+			if strings.Contains(description, k) {
+				mentioned = true
+				break
+			}
+		}
+		if !mentioned && description != "" {
+			add("contents", "Confirm the "+c.item, "Not mentioned in the listing")
+		}
+	}
+
+	// Barulho: bares e estações muito perto
+	for _, poi := range p.QualityOfLife.Entertainment {
+		if (poi.Type == "bar" || poi.Type == "night_club") && poi.Distance <= 0.2 {
+			add("noise", fmt.Sprintf("Check late-night noise from %s", poi.Name), fmt.Sprintf("%.0f m away", poi.Distance*1000))
+			break
+		}
+	}
+	for _, poi := range p.QualityOfLife.PublicTransport {
+		if poi.Type == "train_station" && poi.Distance <= 0.3 {
+			add("noise", "Listen for train noise and vibration with the windows open", fmt.Sprintf("%s is %.0f m away", poi.Name, poi.Distance*1000))
+			break
+		}
+	}
+
+	// Segurança
+	if p.SafetyInfo.StreetLighting != "" && p.SafetyInfo.StreetLamps <= 10 {
+		add("safety", "Walk the approach after dark", p.SafetyInfo.StreetLighting)
+	}
+	if p.SafetyInfo.SafetyRating > 0 && p.SafetyInfo.SafetyRating <= 5 {
+		add("safety", "Check door and window locks, and ask about building security", fmt.Sprintf("Safety rating %d/10", p.SafetyInfo.SafetyRating))
+	}
+
+	// Custos
+	if value.IsApartment(p) && p.ServiceCharge == nil && !isRental {
+		add("costs", "Ask about the annual service charge and the sinking fund", "No service charge is stated in the listing")
+	}
+	if rating := p.ValueAnalysis.PriceRating; rating > 0 && rating <= 3 {
+		add("costs", "Ask whether the price is negotiable", fmt.Sprintf("Above the area average of €%.0f", p.ValueAnalysis.AreaAveragePrice))
+	}
+	if p.FloorArea == nil {
+		add("listing", "Ask for the floor area, or measure the main rooms", "Not stated in the listing")
+	}
+
+	// Alertas do anúncio
+	for _, f := range p.DescriptionAnalysis.RedFlags {
+		add("listing", "Ask about: "+strings.TrimSuffix(f.Note, "."), fmt.Sprintf("The listing says “%s”", f.Phrase))
+	}
+	if len(p.PhotoDuplicates) > 0 {
+		add("listing", "Verify who owns the property before paying anything", "The photos also appear on another listing")
+	} else if r := p.FraudRisk; r != nil && r.Level == "high" {
+		add("listing", "Do not pay a deposit before viewing in person and verifying the landlord", fmt.Sprintf("Fraud risk %d/100", r.Score))
+	}
+	if reg := p.RTBRegistration; reg != nil && reg.Status == "not_found" {
+		add("listing", "Ask for the RTB registration number of the tenancy", "No registered tenancy was found at this address")
+	}
+	if n := len(p.Photos); n > 0 && n < 5 {
+		add("listing", "Look closely at the rooms not shown in the photos", fmt.Sprintf("Only %d photos in the listing", n))
+	}
+
+	// Itens gerais, sempre ao final
+	add("general", "Check for damp or mould in bathrooms, wardrobes and behind furniture", "")
+	add("general", "Run the taps and shower to check water pressure and hot water", "")
+	return items
+}
@@ -0,0 +1,65 @@
+package enrich
+
+import (
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestViewingChecklist(t *testing.T) {
+	p := &scraper.PropertyInfo{
+		ListingType: "rent",
+		BER:         "D2",
+		Description: "Spacious two bed apartment with gas central heating and a dishwasher. Fibre broadband available.",
+		Photos:      []string{"a.jpg", "b.jpg"},
+	}
+	p.SafetyInfo.StreetLighting = "6 street lights within 500m"
+	p.SafetyInfo.StreetLamps = 6
+	p.QualityOfLife.Entertainment = []scraper.POI{{Name: "The Bleeding Horse", Type: "bar", Distance: 0.08}}
+	p.DescriptionAnalysis.RedFlags = []scraper.DescriptionFlag{{Category: "scam", Phrase: "deposit before viewing", Note: "Deposit requested before viewing"}}
+
+	var got []string
+	for _, item := range ViewingChecklist(p) {
+		got = append(got, item.Item)
+	}
+	joined := strings.Join(got, "\n")
+
+	for _, want := range []string{
+		"Ask about the heating system and typical winter energy bills",
+		"Confirm the washing machine",
+		"Confirm the parking",
+		"Check late-night noise from The Bleeding Horse",
+		"Walk the approach after dark",
+		"Ask about: Deposit requested before viewing",
+		"Look closely at the rooms not shown in the photos",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("checklist missing %q:\n%s", want, joined)
+		}
+	}
+	for _, unwanted := range []string{"Confirm the dishwasher", "Confirm the heating type", "Confirm the broadband", "BER certificate"} {
+		if strings.Contains(joined, unwanted) {
+			t.Errorf("checklist should not contain %q", unwanted)
+		}
+	}
+}
+
+func TestViewingChecklistUsesLampCount(t *testing.T) {
+	p := &scraper.PropertyInfo{ListingType: "rent"}
+	p.SafetyInfo.StreetLighting = "Well lit"
+	p.SafetyInfo.StreetLamps = 40
+	for _, item := range ViewingChecklist(p) {
+		if item.Item == "Walk the approach after dark" {
+			t.Fatal("a well-lit street should not ask for a night walk")
+		}
+	}
+
+	p.SafetyInfo.StreetLamps = 3
+	for _, item := range ViewingChecklist(p) {
+		if item.Item == "Walk the approach after dark" {
+			return
+		}
+	}
+	t.Error("3 street lamps should ask for a night walk")
+}
@@ -0,0 +1,220 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+	"daft-scraper-api/internal/value"
+)
+
+/* ───── Custo do trajeto diário e custo mensal real ─────────────────── */
+
+// Um apartamento barato longe do trabalho pode sair mais caro que um central. Com o
+// destino do cliente (commuteTo), estimamos quanto o trajeto custa por mês, de
+// transporte público pelas tarifas Leap da TFI ou de carro pelo combustível e os
+// pedágios, e somamos ao aluguel (ou à prestação, na venda) no trueMonthlyCost. O
+// destino é de quem pergunta, então o cálculo roda por requisição, depois do cache.
+
+// CommuteOptions é o trajeto pedido pelo cliente, no corpo ou na query
+type CommuteOptions struct {
+	To   string `json:"commuteTo"`
+	Mode string `json:"commuteMode"` // public (padrão) ou car
+	Days int    `json:"commuteDays"` // dias por semana; COMMUTE_DAYS por padrão
+
+	located  bool // destino já geocodificado (ver LocateCommute)
+	lat, lng float64
+}
+
+// leapZones são as faixas de tarifa do transporte público, pela distância do ponto
+// mais afastado do centro de Dublin. Tarifas adultas com Leap de 2025; o teto semanal
+// do Leap limita o gasto de quem viaja todo dia.
+var leapZones = []struct {
+	name      string
+	radiusKm  float64
+	single    float64
+	weeklyCap float64
+}{
+	{"dublin_city", 20, 2.00, 20.00},
+	{"dublin_commuter", 50, 4.00, 40.00},
+}
+
+const (
+	intercityFarePerKm = 0.13 // fora das zonas Leap, sem teto semanal
+	roadDetourFactor   = 1.3  // a estrada é em média 30% mais longa que a linha reta
+	m50Toll            = 3.20 // pedágio do M50 com conta de vídeo registrada
+	weeksPerMonth      = 52.0 / 12
+
+	defaultFuelPrice       = 1.75 // €/L
+	defaultFuelConsumption = 6.5  // L/100 km
+	defaultCommuteDays     = 5
+)
+
+// fuel é o preço do combustível e o consumo do carro deste Analyzer
+func (a *Analyzer) fuel() (price, consumption float64) {
+	price, consumption = a.FuelPrice, a.FuelConsumption
+	if price <= 0 {
+		price = defaultFuelPrice
+	}
+	if consumption <= 0 {
+		consumption = defaultFuelConsumption
+	}
+	return price, consumption
+}
+
+// dublinCentre é a O'Connell Bridge
+var dublinCentre = struct{ lat, lng float64 }{53.3472, -6.2592}
+
+// CommuteFromRequest junta o trajeto do corpo com ?commuteTo=, ?commuteMode= e
+// ?commuteDays= (a query vale mais) e valida o modo e os dias
+func (a *Analyzer) CommuteFromRequest(r *http.Request, body CommuteOptions) (CommuteOptions, error) {
+	q := r.URL.Query()
+	if to := q.Get("commuteTo"); to != "" {
+		body.To = to
+	}
+	if mode := q.Get("commuteMode"); mode != "" {
+		body.Mode = mode
+	}
+	if days := q.Get("commuteDays"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return body, fmt.Errorf("commuteDays must be a number between 1 and 7")
+		}
+		body.Days = n
+	}
+	switch body.Mode {
+	case "":
+		body.Mode = "public"
+	case "public", "car":
+	default:
+		return body, fmt.Errorf("commuteMode must be public or car")
+	}
+	if body.Days == 0 {
+		body.Days = a.CommuteDays
+	}
+	if body.Days == 0 {
+		body.Days = defaultCommuteDays
+	}
+	if body.Days < 1 || body.Days > 7 {
+		return body, fmt.Errorf("commuteDays must be a number between 1 and 7")
+	}
+	return body, nil
+}
+
+// LocateCommute geocodifica o destino uma vez, para vários imóveis (comparação); as
+// chamadas ao Maps contam em usage
+func (a *Analyzer) LocateCommute(ctx context.Context, opts *CommuteOptions, usage *scraper.MapsUsage) error {
+	destination := scraper.PropertyInfo{Address: opts.To, MapsUsage: usage}
+	if err := a.GetCoordinates(ctx, &destination); err != nil {
+		return err
+	}
+	opts.lat, opts.lng, opts.located = destination.Coordinates.Lat, destination.Coordinates.Lng, true
+	return nil
+}
+
+// ApplyCommute calcula o Commute e o trueMonthlyCost do imóvel; sem destino não faz
+// nada. Um endereço que não se geocodifica vira aviso, não erro da análise.
+func (a *Analyzer) ApplyCommute(ctx context.Context, property *scraper.PropertyInfo, opts CommuteOptions) {
+	if opts.To == "" {
+		return
+	}
+	if property.Coordinates.Lat == 0 && property.Coordinates.Lng == 0 {
+		if err := a.GetCoordinates(ctx, property); err != nil {
+			property.Warnings = append(property.Warnings, scraper.ModuleError(err, "GEOCODE_FAILED", "commute"))
+			return
+		}
+	}
+	if !opts.located {
+		if err := a.LocateCommute(ctx, &opts, property.Usage()); err != nil {
+			telemetry.LogFor(ctx).Warn("Commute destination geocoding failed", "destination", opts.To, "error", err)
+			property.Warnings = append(property.Warnings, scraper.ModuleError(err, "GEOCODE_FAILED", "commute"))
+			return
+		}
+	}
+
+	property.Commute = a.estimateCommute(property.Coordinates.Lat, property.Coordinates.Lng, opts.lat, opts.lng, opts)
+	if housing := monthlyHousingCost(property, a.Value.Rate()); housing > 0 {
+		property.ValueAnalysis.TrueMonthlyCost = math.Round(housing + property.Commute.Monthly)
+	}
+}
+
+// monthlyHousingCost é o custo de morar com as contas (occupancyCost) quando a
+// análise de valor rodou; senão o aluguel do mês ou, na venda, a prestação à taxa
+// rate com condomínio
+func monthlyHousingCost(property *scraper.PropertyInfo, rate float64) float64 {
+	if property.ValueAnalysis.OccupancyCost > 0 {
+		return property.ValueAnalysis.OccupancyCost
+	}
+	if property.ListingType == "sale" {
+		return value.EffectiveMonthlyCost(property, rate)
+	}
+	if property.Price != nil {
+		return property.Price.Monthly
+	}
+	return 0
+}
+
+// estimateCommute estima o custo mensal de ir e voltar opts.Days vezes por semana
+func (a *Analyzer) estimateCommute(fromLat, fromLng, toLat, toLng float64, opts CommuteOptions) *scraper.CommuteCost {
+	km := scraper.CalculateDistance(fromLat, fromLng, toLat, toLng)
+	c := &scraper.CommuteCost{
+		Destination: opts.To,
+		Mode:        opts.Mode,
+		DistanceKm:  math.Round(km*10) / 10,
+		DaysPerWeek: opts.Days,
+	}
+	trips := float64(2 * opts.Days)
+
+	if opts.Mode == "car" {
+		price, consumption := a.fuel()
+		roadKm := km * roadDetourFactor
+		if crossesM50Toll(fromLat, fromLng, toLat, toLng) {
+			c.TollPerTrip = m50Toll
+		}
+		perTrip := roadKm*consumption/100*price + c.TollPerTrip
+		c.Monthly = math.Round(perTrip * trips * weeksPerMonth)
+		c.Basis = fmt.Sprintf("%.1f km by road each way at %.1f L/100 km and €%.2f/L", roadKm, consumption, price)
+		if c.TollPerTrip > 0 {
+			c.Basis += fmt.Sprintf(", plus the M50 toll (€%.2f)", c.TollPerTrip)
+		}
+		return c
+	}
+
+	farthest := math.Max(scraper.CalculateDistance(fromLat, fromLng, dublinCentre.lat, dublinCentre.lng),
+		scraper.CalculateDistance(toLat, toLng, dublinCentre.lat, dublinCentre.lng))
+	for _, z := range leapZones {
+		if farthest <= z.radiusKm {
+			weekly := math.Min(z.single*trips, z.weeklyCap)
+			c.FareZone = z.name
+			c.Monthly = math.Round(weekly * weeksPerMonth)
+			c.Basis = fmt.Sprintf("%.0f Leap fares a week at €%.2f, capped at €%.2f", trips, z.single, z.weeklyCap)
+			return c
+		}
+	}
+	c.FareZone = "intercity"
+	c.Monthly = math.Round(km * intercityFarePerKm * trips * weeksPerMonth)
+	c.Basis = fmt.Sprintf("%.0f trips a week at about €%.2f per km, outside the Leap zones", trips, intercityFarePerKm)
+	return c
+}
+
+// crossesM50Toll diz se o trajeto de carro provavelmente passa pelo pedágio do M50,
+// que fica na ponte sobre o Liffey entre as saídas 6 e 7: o trajeto cruza o rio
+// (aproximado pela latitude 53,36 a oeste da cidade) a oeste da longitude -6,33, com
+// uma das pontas fora do anel do M50 (uns 8 km do centro). É uma aproximação; o
+// caminho real depende do trânsito e das escolhas do motorista.
+func crossesM50Toll(fromLat, fromLng, toLat, toLng float64) bool {
+	const liffeyLat, westOfLng, ringKm = 53.36, -6.33, 8.0
+	if (fromLat-liffeyLat)*(toLat-liffeyLat) >= 0 {
+		return false
+	}
+	t := (liffeyLat - fromLat) / (toLat - fromLat)
+	if fromLng+t*(toLng-fromLng) >= westOfLng {
+		return false
+	}
+	return scraper.CalculateDistance(fromLat, fromLng, dublinCentre.lat, dublinCentre.lng) > ringKm ||
+		scraper.CalculateDistance(toLat, toLng, dublinCentre.lat, dublinCentre.lng) > ringKm
+}
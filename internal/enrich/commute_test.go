@@ -0,0 +1,78 @@
+package enrich
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestCommuteFromRequest(t *testing.T) {
+	a := &Analyzer{CommuteDays: 3}
+	r := httptest.NewRequest("GET", "/analyze?commuteTo=D02+X285&commuteMode=car", nil)
+	opts, err := a.CommuteFromRequest(r, CommuteOptions{To: "ignored", Days: 4})
+	if err != nil || opts.To != "D02 X285" || opts.Mode != "car" || opts.Days != 4 {
+		t.Errorf("got %+v, %v", opts, err)
+	}
+	if opts, _ := a.CommuteFromRequest(httptest.NewRequest("GET", "/analyze", nil), CommuteOptions{}); opts.Mode != "public" || opts.Days != 3 {
+		t.Errorf("defaults = %+v", opts)
+	}
+	for _, query := range []string{"commuteMode=bike", "commuteDays=9", "commuteDays=often"} {
+		if _, err := a.CommuteFromRequest(httptest.NewRequest("GET", "/analyze?"+query, nil), CommuteOptions{}); err == nil {
+			t.Errorf("%s: expected an error", query)
+		}
+	}
+}
+
+func TestEstimateCommutePublic(t *testing.T) {
+	// Rathmines to Grand Canal Dock: ten city fares a week hit the €20 weekly cap
+	c := (&Analyzer{}).estimateCommute(53.3230, -6.2650, 53.3380, -6.2520, CommuteOptions{To: "Grand Canal Dock", Mode: "public", Days: 5})
+	if c.FareZone != "dublin_city" || c.Monthly != 87 {
+		t.Errorf("city commute = %+v", c)
+	}
+	// three days a week stay under the cap
+	c = (&Analyzer{}).estimateCommute(53.3230, -6.2650, 53.3380, -6.2520, CommuteOptions{Mode: "public", Days: 3})
+	if c.Monthly != 52 {
+		t.Errorf("three days = %v, want 52", c.Monthly)
+	}
+	// Galway is outside the Leap zones
+	c = (&Analyzer{}).estimateCommute(53.2707, -9.0568, 53.3380, -6.2520, CommuteOptions{Mode: "public", Days: 1})
+	if c.FareZone != "intercity" || c.Monthly == 0 {
+		t.Errorf("intercity = %+v", c)
+	}
+}
+
+func TestEstimateCommuteCar(t *testing.T) {
+	a := &Analyzer{FuelPrice: 2, FuelConsumption: 5}
+	// Lucan to Blanchardstown crosses the Liffey on the M50
+	c := a.estimateCommute(53.3570, -6.4490, 53.3850, -6.4000, CommuteOptions{Mode: "car", Days: 5})
+	if c.TollPerTrip != m50Toll || !strings.Contains(c.Basis, "M50") {
+		t.Errorf("Lucan to Blanchardstown = %+v", c)
+	}
+	// Rathmines to the city centre stays inside the M50
+	c = a.estimateCommute(53.3230, -6.2650, 53.3520, -6.2580, CommuteOptions{Mode: "car", Days: 5})
+	if c.TollPerTrip != 0 || c.Monthly == 0 {
+		t.Errorf("Rathmines to D01 = %+v", c)
+	}
+}
+
+func TestApplyCommute(t *testing.T) {
+	a := &Analyzer{Geocoders: []NamedGeocoder{{"eircode", EircodeGeocoder{}}}}
+	p := scraper.PropertyInfo{Address: "Rathmines, Dublin 6, D06 X2Y3", Price: &scraper.Price{Amount: 1800, Period: "month", Monthly: 1800}}
+	a.ApplyCommute(context.Background(), &p, CommuteOptions{To: "Grand Canal Dock, D02 X285", Mode: "public", Days: 5})
+	if p.Commute == nil || p.Commute.FareZone != "dublin_city" {
+		t.Fatalf("commute = %+v, warnings %v", p.Commute, p.Warnings)
+	}
+	if p.ValueAnalysis.TrueMonthlyCost != 1800+p.Commute.Monthly {
+		t.Errorf("true monthly cost = %v", p.ValueAnalysis.TrueMonthlyCost)
+	}
+
+	// a destination that cannot be geocoded is a warning, not a failure
+	p = scraper.PropertyInfo{Address: "Rathmines, Dublin 6, D06 X2Y3"}
+	a.ApplyCommute(context.Background(), &p, CommuteOptions{To: "the office", Mode: "public", Days: 5})
+	if p.Commute != nil || len(p.Warnings) != 1 || p.Warnings[0].Module != "commute" {
+		t.Errorf("commute = %+v, warnings %v", p.Commute, p.Warnings)
+	}
+}
@@ -0,0 +1,73 @@
+package enrich
+
+import "daft-scraper-api/internal/scraper"
+
+/* ───── Qualidade dos dados: o que cada módulo entregou ─────────────── */
+
+// warningModules são os módulos que um aviso cobre; o de qualidade de vida cobre os três
+var warningModules = map[string][]string{
+	"qualityOfLife": {"transport", "amenities", "entertainment"},
+}
+
+// assessDataQuality monta a seção a partir dos módulos pedidos e dos avisos da análise
+func assessDataQuality(p *scraper.PropertyInfo, modules scraper.ModuleSet) *scraper.DataQuality {
+	failed := map[string]*scraper.APIError{}
+	var geocodeErr *scraper.APIError
+	for _, w := range p.Warnings {
+		if w.Module == "location" {
+			geocodeErr = w
+			continue
+		}
+		covered, ok := warningModules[w.Module]
+		if !ok {
+			covered = []string{w.Module}
+		}
+		for _, m := range covered {
+			// a falta de orçamento do Maps não esconde uma falha real do mesmo módulo
+			if prev, seen := failed[m]; !seen || prev.Code == scraper.ErrMapsBudgetExceeded.Code {
+				failed[m] = w
+			}
+		}
+	}
+
+	q := &scraper.DataQuality{Complete: true}
+	for _, name := range scraper.AnalysisModules {
+		status := scraper.ModuleStatus{Module: name, Status: "ok"}
+		switch {
+		case !scraper.ModuleEnabled(name):
+			status.Status, status.Note = "disabled", "Disabled in this deployment"
+		case !modules.Has(name):
+			status.Status, status.Note = "skipped", "Not requested"
+		case geocodeErr != nil && name != "summary":
+			// sem coordenadas o enriquecimento para antes de rodar os módulos
+			status.Status, status.Code = "failed", geocodeErr.Code
+			status.Note = "Not run: the address could not be geocoded"
+		case name == "safety" && failed[name] != nil && failed[name].Code == scraper.ErrMapsBudgetExceeded.Code:
+			// os dados do Overpass e do CSO entraram; só faltaram as delegacias
+			status.Status, status.Code = "estimated", failed[name].Code
+			status.Note = "Garda stations were not looked up: the daily Google Maps budget was spent"
+		case failed[name] != nil && failed[name].Code == "UPSTREAM_UNAVAILABLE":
+			status.Status, status.Code, status.Note = "degraded", failed[name].Code, failed[name].Message
+		case failed[name] != nil:
+			status.Status, status.Code, status.Note = "failed", failed[name].Code, failed[name].Message
+		case name == "safety" && p.SafetyInfo.CrimeEstimated:
+			status.Status, status.Note = "estimated", "Crime figures are estimates: the CSO dataset was unavailable"
+			if p.SafetyInfo.CrimeEstimate != "" {
+				status.Note = "Crime figures are estimates: " + p.SafetyInfo.CrimeEstimate
+			}
+		case name == "value" && p.ValueAnalysis.AreaAveragePrice == 0 && !scraper.ModuleEnabled("value.comparables"):
+			// desligar a parte é escolha da instalação, não falha da análise
+			status.Status, status.Note = "disabled", "Comparable listings are disabled in this deployment, so there is no price rating"
+		case name == "value" && p.ValueAnalysis.AreaAveragePrice == 0:
+			status.Status, status.Note = "no_data", "No comparable listings found, so there is no price rating"
+		case name == "summary" && p.Summary == "":
+			status.Status, status.Note = "skipped", "No LLM provider configured"
+		}
+		if status.Status != "ok" && status.Status != "disabled" && modules.Has(name) &&
+			!(name == "summary" && status.Status == "skipped") {
+			q.Complete = false
+		}
+		q.Modules = append(q.Modules, status)
+	}
+	return q
+}
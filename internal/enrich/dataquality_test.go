@@ -0,0 +1,126 @@
+package enrich
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func statusOf(q *scraper.DataQuality, module string) scraper.ModuleStatus {
+	for _, s := range q.Modules {
+		if s.Module == module {
+			return s
+		}
+	}
+	return scraper.ModuleStatus{}
+}
+
+func TestAssessDataQuality_AllOK(t *testing.T) {
+	p := &scraper.PropertyInfo{Summary: "Nice flat."}
+	p.ValueAnalysis.AreaAveragePrice = 2000
+
+	q := assessDataQuality(p, scraper.AllModules())
+	if !q.Complete {
+		t.Fatalf("expected complete, got %+v", q.Modules)
+	}
+	if len(q.Modules) != len(scraper.AnalysisModules) {
+		t.Fatalf("expected %d modules, got %d", len(scraper.AnalysisModules), len(q.Modules))
+	}
+}
+
+func TestAssessDataQuality_FailuresAndEstimates(t *testing.T) {
+	p := &scraper.PropertyInfo{}
+	p.SafetyInfo.CrimeEstimated = true
+	p.SafetyInfo.CrimeEstimate = "CSO has no 2024 figures for Kerry Division"
+	p.Warnings = []*scraper.APIError{
+		scraper.ModuleError(errors.New("quota exceeded"), "PLACES_FAILED", "qualityOfLife"),
+		scraper.ModuleError(errors.New("boom"), "PHOTOS_FAILED", "photos"),
+	}
+
+	q := assessDataQuality(p, scraper.AllModules())
+	if q.Complete {
+		t.Fatal("expected incomplete data")
+	}
+	for _, m := range []string{"transport", "amenities", "entertainment"} {
+		if s := statusOf(q, m); s.Status != "failed" || s.Code != "PLACES_FAILED" {
+			t.Errorf("%s: got %+v, want failed PLACES_FAILED", m, s)
+		}
+	}
+	if s := statusOf(q, "photos"); s.Status != "failed" || s.Note != "boom" {
+		t.Errorf("photos: got %+v", s)
+	}
+	if s := statusOf(q, "safety"); !strings.Contains(s.Note, "Kerry Division") {
+		t.Errorf("safety note %q should carry the estimate's reason", s.Note)
+	}
+	if s := statusOf(q, "safety"); s.Status != "estimated" {
+		t.Errorf("safety: got %+v, want estimated", s)
+	}
+	if s := statusOf(q, "value"); s.Status != "no_data" {
+		t.Errorf("value: got %+v, want no_data", s)
+	}
+	if s := statusOf(q, "summary"); s.Status != "skipped" {
+		t.Errorf("summary: got %+v, want skipped", s)
+	}
+}
+
+func TestAssessDataQuality_GeocodeFailure(t *testing.T) {
+	p := &scraper.PropertyInfo{Summary: "Text."}
+	p.Warnings = []*scraper.APIError{scraper.ModuleError(errors.New("zero results"), "GEOCODE_FAILED", "location")}
+
+	q := assessDataQuality(p, scraper.ModuleSet{"safety": true, "value": true, "summary": true})
+	if q.Complete {
+		t.Fatal("expected incomplete data")
+	}
+	for _, m := range []string{"safety", "value"} {
+		if s := statusOf(q, m); s.Status != "failed" || s.Code != "GEOCODE_FAILED" {
+			t.Errorf("%s: got %+v, want failed GEOCODE_FAILED", m, s)
+		}
+	}
+	if s := statusOf(q, "transport"); s.Status != "skipped" {
+		t.Errorf("transport: got %+v, want skipped", s)
+	}
+	if s := statusOf(q, "summary"); s.Status != "ok" {
+		t.Errorf("summary: got %+v, want ok", s)
+	}
+}
+
+func TestAssessDataQuality_DisabledInDeployment(t *testing.T) {
+	t.Setenv("MODULES_PHOTOS", "false")
+	t.Setenv("MODULES_VALUE_COMPARABLES", "false")
+	reloadModules(t)
+	p := &scraper.PropertyInfo{Summary: "Nice flat."}
+
+	q := assessDataQuality(p, scraper.AllModules())
+	if !q.Complete {
+		t.Fatalf("modules disabled by the deployment should not make the data incomplete: %+v", q.Modules)
+	}
+	if s := statusOf(q, "photos"); s.Status != "disabled" {
+		t.Errorf("photos: got %+v, want disabled", s)
+	}
+	if s := statusOf(q, "value"); s.Status != "disabled" {
+		t.Errorf("value without comparables: got %+v, want disabled", s)
+	}
+	if s := statusOf(q, "safety"); s.Status != "ok" {
+		t.Errorf("safety: got %+v, want ok", s)
+	}
+}
+
+func TestAssessDataQuality_OpenCircuit(t *testing.T) {
+	// a source skipped by its open circuit degrades the module instead of failing it
+	err := fmt.Errorf("overpass: %w", scraper.ErrCircuitOpen)
+	p := scraper.PropertyInfo{Warnings: []*scraper.APIError{scraper.ModuleError(err, "OVERPASS_FAILED", "safety")}}
+	if s := statusOf(assessDataQuality(&p, scraper.AllModules()), "safety"); s.Status != "degraded" {
+		t.Errorf("safety = %+v, want degraded", s)
+	}
+}
+
+// reloadModules rereads the MODULES_* flags after t.Setenv and restores them afterwards
+func reloadModules(t *testing.T) {
+	t.Helper()
+	prev := scraper.DisabledModules
+	scraper.DisabledModules = scraper.DisabledModulesFromEnv()
+	t.Cleanup(func() { scraper.DisabledModules = prev })
+}
@@ -0,0 +1,6 @@
+// Package enrich enriquece o anúncio raspado com os módulos da análise: segurança,
+// transporte, comodidades, família, pontuações, trajeto e resumo. O Analyzer reúne os
+// clientes externos, e ScrapeDaftPropertyModules raspa o anúncio e roda os módulos
+// pedidos. O que depende dos anúncios já vistos entra pela interface History, que o
+// servidor implementa com o Store; sem ela esses módulos são pulados.
+package enrich
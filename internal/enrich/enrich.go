@@ -0,0 +1,377 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+	"daft-scraper-api/internal/value"
+
+	"googlemaps.github.io/maps"
+)
+
+/* ───── Enriquecimento do anúncio ───────────────────────────────────── */
+
+// DefaultAmenityTypes e DefaultEntertainmentTypes são os tipos buscados quando
+// AMENITY_TYPES e ENTERTAINMENT_TYPES não estão definidos
+var (
+	DefaultAmenityTypes       = []string{"supermarket", "pharmacy", "convenience_store", "shopping_mall", "bank", "hospital", "doctor"}
+	DefaultEntertainmentTypes = []string{"restaurant", "bar", "cafe", "movie_theater", "gym", "park"}
+)
+
+// PlacesToPOIs converte os resultados da busca em POIs com distância e tempo a pé a partir
+// de origin. Com poiType vazio, usa o primeiro tipo do lugar. É o caminho quente do
+// enriquecimento (centenas de lugares por análise; ver internal/server/bench_test.go).
+func PlacesToPOIs(origin *maps.LatLng, places []maps.PlacesSearchResult, poiType string) []scraper.POI {
+	pois := make([]scraper.POI, 0, len(places))
+	for i := range places {
+		place := &places[i]
+		dist := scraper.CalculateDistance(origin.Lat, origin.Lng,
+			place.Geometry.Location.Lat, place.Geometry.Location.Lng)
+
+		t := poiType
+		if t == "" && len(place.Types) > 0 {
+			t = place.Types[0]
+		}
+		pois = append(pois, scraper.NewPOI(place.Name, t, dist, place.Geometry.Location.Lat, place.Geometry.Location.Lng))
+	}
+	return pois
+}
+
+// poiKey identifica um lugar pelo nome e pela posição (arredondada a ~10 m)
+func poiKey(poi scraper.POI) string {
+	return fmt.Sprintf("%s|%.4f|%.4f", strings.ToLower(strings.TrimSpace(poi.Name)), poi.Lat, poi.Lng)
+}
+
+// ScrapeDaftProperty raspa os dados de um anúncio do Daft.ie e os enriquece
+func (a *Analyzer) ScrapeDaftProperty(ctx context.Context, url string) (scraper.PropertyInfo, error) {
+	return a.ScrapeDaftPropertyModules(ctx, url, scraper.AllModules())
+}
+
+// ScrapeDaftPropertyModules raspa o anúncio e roda só os módulos selecionados; as
+// páginas são raspadas com os ajustes deste Analyzer
+func (a *Analyzer) ScrapeDaftPropertyModules(ctx context.Context, url string, modules scraper.ModuleSet) (scraper.PropertyInfo, error) {
+	if err := a.RefuseOverBudget(modules); err != nil {
+		return scraper.PropertyInfo{}, err
+	}
+	ctx = scraper.WithFetchSettings(ctx, a.FetchSettings())
+
+	property, err := scraper.ScrapeDaftListing(ctx, url)
+	if err != nil {
+		return scraper.PropertyInfo{}, err
+	}
+	property.DescriptionAnalysis = scraper.AnalyzeDescription(property.Description)
+	property.ComplianceFlags = scraper.CheckCompliance(&property)
+
+	// Após obter os dados básicos, enriquecer com informações adicionais
+	if err := a.enrichPropertyInfo(ctx, &property, modules); err != nil {
+		telemetry.LogFor(ctx).Warn("Enrichment stopped early", "url", url, "error", err)
+	}
+
+	// Resumo em texto a partir da análise estruturada (só com LLM_PROVIDER configurado)
+	if modules.Has("summary") {
+		llmCtx, cancel := a.WithStageTimeout(ctx, "llm")
+		summary, err := a.generateSummary(llmCtx, &property)
+		cancel()
+		if err != nil {
+			telemetry.LogFor(ctx).Warn("Summary failed", "url", url, "error", err)
+			property.Warnings = append(property.Warnings, scraper.ModuleError(err, "LLM_FAILED", "summary"))
+		}
+		property.Summary = summary
+	}
+
+	property.DataQuality = assessDataQuality(&property, modules)
+
+	return property, nil
+}
+
+// Função principal que coordena todas as análises
+func (a *Analyzer) enrichPropertyInfo(ctx context.Context, property *scraper.PropertyInfo, modules scraper.ModuleSet) error {
+	// o contador é criado antes das cópias feitas pelos módulos, que o compartilham
+	property.Usage()
+
+	// 1. Obter coordenadas do endereço
+	if modules.NeedsLocation() {
+		if err := a.GetCoordinates(ctx, property); err != nil {
+			property.Warnings = append(property.Warnings, scraper.ModuleError(err, "GEOCODE_FAILED", "location"))
+			return fmt.Errorf("erro ao obter coordenadas: %w", err)
+		}
+		if a.History != nil {
+			property.Annotations = a.History.Annotations(property.Coordinates.Lat, property.Coordinates.Lng)
+		}
+	}
+
+	// 2. Obter informações de segurança
+	if modules.Has("safety") {
+		if err := a.getSafetyInfo(ctx, property); err != nil {
+			telemetry.LogFor(ctx).Warn("Safety module failed", "url", property.URL, "error", err)
+			property.Warnings = append(property.Warnings, scraper.ModuleError(err, "SAFETY_FAILED", "safety"))
+		}
+	}
+
+	// 3. Obter informações de qualidade de vida
+	if modules.Has("transport") || modules.Has("amenities") || modules.Has("entertainment") {
+		if err := a.GetQualityOfLife(ctx, property, modules); err != nil {
+			telemetry.LogFor(ctx).Warn("Quality of life module failed", "url", property.URL, "error", err)
+			property.Warnings = append(property.Warnings, scraper.ModuleError(err, "PLACES_FAILED", "qualityOfLife"))
+		}
+	}
+
+	// 4. Avaliar o entorno para famílias (usa os lugares e as colisões já encontrados)
+	if modules.Has("family") {
+		if err := a.analyzeFamily(ctx, property); err != nil {
+			telemetry.LogFor(ctx).Warn("Family module failed", "url", property.URL, "error", err)
+			property.Warnings = append(property.Warnings, scraper.ModuleError(err, "PLACES_FAILED", "family"))
+		}
+	}
+
+	// 5. Analisar valor do imóvel
+	if modules.Has("value") {
+		if err := a.Value.Analyze(ctx, property); err != nil {
+			telemetry.LogFor(ctx).Warn("Value module failed", "url", property.URL, "error", err)
+			property.Warnings = append(property.Warnings, scraper.ModuleError(err, "VALUE_FAILED", "value"))
+		}
+	}
+
+	// 6. Procurar as fotos em outros anúncios (sinal de golpe ou de re-anúncio), conferir
+	// o registro da locação no RTB e juntar os sinais de golpe da análise
+	if modules.Has("photos") && a.History != nil {
+		photosCtx, cancel := a.WithStageTimeout(ctx, "photos")
+		err := a.History.DuplicatePhotos(photosCtx, property)
+		cancel()
+		if err != nil {
+			telemetry.LogFor(ctx).Warn("Duplicate photo check failed", "url", property.URL, "error", err)
+			property.Warnings = append(property.Warnings, scraper.ModuleError(err, "PHOTOS_FAILED", "photos"))
+		}
+	}
+	if property.ListingType == "rent" || property.ListingType == "share" {
+		registration, err := a.RTB.registration(ctx, property.Address)
+		if err != nil {
+			telemetry.LogFor(ctx).Warn("RTB register lookup failed", "url", property.URL, "error", err)
+		}
+		property.RTBRegistration = registration
+	}
+	property.FraudRisk = fraudRisk(property)
+
+	// 7. Reputação da agência que anuncia
+	reputation, err := a.advertiserReputation(ctx, property)
+	if err != nil {
+		telemetry.LogFor(ctx).Warn("Advertiser reputation lookup failed", "url", property.URL, "error", err)
+		property.Warnings = append(property.Warnings, scraper.ModuleError(err, "PLACES_FAILED", "advertiserReputation"))
+	}
+	property.AdvertiserReputation = reputation
+
+	// 8. Tempo no mercado, contando os anúncios anteriores do mesmo imóvel, que entra
+	// nos argumentos de negociação
+	supply := 0
+	if a.History != nil {
+		history, err := a.History.MarketHistory(property, time.Now())
+		if err != nil {
+			telemetry.LogFor(ctx).Warn("Market history failed", "url", property.URL, "error", err)
+		}
+		property.MarketHistory = history
+		supply = a.History.AreaSupply(property, time.Now())
+	}
+	if modules.Has("value") {
+		property.ValueAnalysis.Negotiation = value.NegotiationInsight(property)
+	}
+
+	// 9. Recomendar se vale aplicar já ou se há tempo para marcar visita
+	property.ActFast = actFastAdvice(property, supply, time.Now())
+
+	// 10. Montar a checklist da visita
+	property.Checklist = ViewingChecklist(property)
+
+	return nil
+}
+
+// Obter informações de qualidade de vida
+func (a *Analyzer) GetQualityOfLife(ctx context.Context, property *scraper.PropertyInfo, modules scraper.ModuleSet) error {
+	ctx = WithMapsUsage(ctx, property.Usage())
+
+	// 1. Encontrar transporte público
+	if modules.Has("transport") {
+		placesCtx, cancel := a.WithStageTimeout(ctx, "places")
+		err := a.FindPublicTransport(placesCtx, property)
+		cancel()
+		if err != nil {
+			telemetry.LogFor(ctx).Warn("Public transport search failed", "error", err)
+			property.Warnings = append(property.Warnings, scraper.ModuleError(err, "PLACES_FAILED", "transport"))
+		}
+	}
+
+	// 2. Encontrar amenidades
+	if modules.Has("amenities") {
+		placesCtx, cancel := a.WithStageTimeout(ctx, "places")
+		err := a.findAmenities(placesCtx, property)
+		cancel()
+		if err != nil {
+			telemetry.LogFor(ctx).Warn("Amenities search failed", "error", err)
+			property.Warnings = append(property.Warnings, scraper.ModuleError(err, "PLACES_FAILED", "amenities"))
+		}
+	}
+
+	// 3. Encontrar entretenimento
+	if modules.Has("entertainment") {
+		placesCtx, cancel := a.WithStageTimeout(ctx, "places")
+		err := a.findEntertainment(placesCtx, property)
+		cancel()
+		if err != nil {
+			telemetry.LogFor(ctx).Warn("Entertainment search failed", "error", err)
+			property.Warnings = append(property.Warnings, scraper.ModuleError(err, "PLACES_FAILED", "entertainment"))
+		}
+	}
+
+	// 4. Calcular walkability score (só com os dados completos, senão ficaria subestimado)
+	if modules.Has("amenities") && modules.Has("entertainment") {
+		a.CalculateWalkScore(property)
+	}
+
+	// 5. Calcular o bike score, parte do transporte (ir de bicicleta ao trabalho)
+	if modules.Has("transport") {
+		if err := a.analyzeCycling(ctx, property); err != nil {
+			telemetry.LogFor(ctx).Warn("Cycling analysis failed", "error", err)
+			property.Warnings = append(property.Warnings, scraper.ModuleError(err, "OVERPASS_FAILED", "transport"))
+		}
+	}
+
+	// 6. Calcular o remote work score, com o barulho dos lugares já encontrados
+	if modules.Has("amenities") {
+		a.analyzeRemoteWork(ctx, property)
+	}
+
+	// 7. Calcular o quiet score, o outro lado do entretenimento (barulho de pubs e vias)
+	if modules.Has("entertainment") {
+		if err := a.analyzeQuiet(ctx, property); err != nil {
+			telemetry.LogFor(ctx).Warn("Quietness analysis failed", "error", err)
+			property.Warnings = append(property.Warnings, scraper.ModuleError(err, "OVERPASS_FAILED", "entertainment"))
+		}
+	}
+
+	return nil
+}
+
+// FindPublicTransport encontra estações de transporte público próximas
+func (a *Analyzer) FindPublicTransport(ctx context.Context, property *scraper.PropertyInfo) error {
+	location := &maps.LatLng{
+		Lat: property.Coordinates.Lat,
+		Lng: property.Coordinates.Lng,
+	}
+
+	// Buscar estações de trem
+	trainStations, err := a.Places.SearchNearby(ctx, location, "train_station", a.Radius("train"))
+	if err != nil {
+		return err
+	}
+
+	// Buscar pontos de ônibus
+	busStops, err := a.Places.SearchNearby(ctx, location, "bus_station", a.Radius("bus"))
+	if err != nil {
+		return err
+	}
+
+	// Combinar resultados (o tipo vem do próprio lugar); o score abaixo conta com o
+	// mais próximo em [0]
+	property.QualityOfLife.PublicTransport = a.TidyPOIs(append(property.QualityOfLife.PublicTransport,
+		PlacesToPOIs(location, append(trainStations, busStops...), "")...))
+
+	// Calcular score de transporte (1-10)
+	score := 5 // Base score
+	if len(property.QualityOfLife.PublicTransport) > 0 {
+		nearestStation := property.QualityOfLife.PublicTransport[0]
+		if nearestStation.Distance < 0.5 { // Menos de 500m
+			score += 3
+		} else if nearestStation.Distance < 1.0 { // Menos de 1km
+			score += 2
+		}
+		if len(property.QualityOfLife.PublicTransport) > 1 {
+			score += 2 // Bônus por ter múltiplas opções
+		}
+	}
+	property.QualityOfLife.TransportScore = score
+
+	return nil
+}
+
+// findAmenities encontra amenidades próximas (supermercados, farmácias, etc)
+func (a *Analyzer) findAmenities(ctx context.Context, property *scraper.PropertyInfo) error {
+	location := &maps.LatLng{
+		Lat: property.Coordinates.Lat,
+		Lng: property.Coordinates.Lng,
+	}
+
+	radius := a.Radius("amenities")
+	searched := false
+	for _, amenityType := range a.amenityTypes() {
+		results, err := a.Places.SearchNearby(ctx, location, amenityType, radius)
+		if err != nil {
+			telemetry.LogFor(ctx).Warn("Places search failed", "type", amenityType, "error", err)
+			continue
+		}
+		searched = true
+
+		property.QualityOfLife.Amenities = append(property.QualityOfLife.Amenities,
+			PlacesToPOIs(location, results, amenityType)...)
+	}
+	property.QualityOfLife.Amenities = a.TidyPOIs(property.QualityOfLife.Amenities)
+	if searched && property.QualityOfLife.Amenities == nil {
+		// a busca respondeu sem nada: zero amenidades, e não ausente (ver internal/server/compare.go)
+		property.QualityOfLife.Amenities = []scraper.POI{}
+	}
+
+	return nil
+}
+
+// findEntertainment encontra locais de entretenimento próximos
+func (a *Analyzer) findEntertainment(ctx context.Context, property *scraper.PropertyInfo) error {
+	location := &maps.LatLng{
+		Lat: property.Coordinates.Lat,
+		Lng: property.Coordinates.Lng,
+	}
+
+	radius := a.Radius("entertainment")
+	for _, entType := range a.entertainmentTypes() {
+		results, err := a.Places.SearchNearby(ctx, location, entType, radius)
+		if err != nil {
+			telemetry.LogFor(ctx).Warn("Places search failed", "type", entType, "error", err)
+			continue
+		}
+
+		property.QualityOfLife.Entertainment = append(property.QualityOfLife.Entertainment,
+			PlacesToPOIs(location, results, entType)...)
+	}
+	property.QualityOfLife.Entertainment = a.TidyPOIs(property.QualityOfLife.Entertainment)
+
+	return nil
+}
+
+// TidyPOIs ordena os POIs do mais próximo ao mais distante (empate pelo nome), tira
+// os lugares repetidos (o mesmo Tesco achado como supermarket e convenience_store fica
+// só com o primeiro tipo buscado) e mantém no máximo POIMaxPerType de cada tipo
+func (a *Analyzer) TidyPOIs(pois []scraper.POI) []scraper.POI {
+	sort.SliceStable(pois, func(i, j int) bool {
+		if pois[i].Distance != pois[j].Distance {
+			return pois[i].Distance < pois[j].Distance
+		}
+		return pois[i].Name < pois[j].Name
+	})
+
+	perType := a.poiMaxPerType()
+	seen := make(map[string]bool, len(pois))
+	counts := map[string]int{}
+	out := pois[:0]
+	for _, poi := range pois {
+		key := poiKey(poi)
+		if seen[key] || counts[poi.Type] >= perType {
+			continue
+		}
+		seen[key] = true
+		counts[poi.Type]++
+		out = append(out, poi)
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"daft-scraper-api/internal/safety"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+
+	"googlemaps.github.io/maps"
+)
+
+/* ───── Family score ────────────────────────────────────────────────── */
+
+// O familyScore (1-100) é a média ponderada de seis fatores, cada um com nota de 0 a
+// 100: escola, creche, parquinho, clínico geral e parque pela distância a pé do mais
+// próximo (o mesmo decaimento do walk score), e o trânsito pelas colisões com
+// pedestres e ciclistas da RSA. Roda como o módulo "family", então quem não procura
+// imóvel para família pula com ?skip=family (ou MODULES_FAMILY=false na instalação).
+// Um fator sem dado (busca que falhou, RSA não configurada) fica fora da média.
+
+// familyFactorWeights são os pesos dos fatores, na ordem em que aparecem na resposta
+var familyFactorWeights = []struct {
+	factor   string
+	placeTyp string // tipo de lugar buscado; vazio no trânsito
+	weight   float64
+}{
+	{"schools", "school", .25},
+	{"creches", "creche", .15},
+	{"playgrounds", "playground", .15},
+	{"gp", "doctor", .15},
+	{"parks", "park", .15},
+	{"traffic", "", .15},
+}
+
+// analyzeFamily busca os lugares que faltam e calcula o familyScore. Clínicos e
+// parques já encontrados pelos módulos de amenidades e entretenimento são
+// reaproveitados; só há erro se nenhuma busca funcionou.
+func (a *Analyzer) analyzeFamily(ctx context.Context, property *scraper.PropertyInfo) error {
+	ctx = WithMapsUsage(ctx, property.Usage())
+	location := &maps.LatLng{Lat: property.Coordinates.Lat, Lng: property.Coordinates.Lng}
+	radius := a.Radius("family")
+
+	known := map[string][]scraper.POI{}
+	for _, pois := range [][]scraper.POI{property.QualityOfLife.Amenities, property.QualityOfLife.Entertainment} {
+		for _, poi := range pois {
+			known[poi.Type] = append(known[poi.Type], poi)
+		}
+	}
+
+	info := &scraper.FamilyInfo{Factors: []scraper.FamilyFactor{}, Places: []scraper.POI{}}
+	var lastErr error
+	for _, f := range familyFactorWeights {
+		var factor scraper.FamilyFactor
+		switch {
+		case f.placeTyp == "":
+			rs := property.SafetyInfo.RoadSafety
+			if rs == nil {
+				continue
+			}
+			factor = trafficFactor(rs)
+		case len(known[f.placeTyp]) > 0:
+			factor = nearestPlaceFactor(f.factor, a.TidyPOIs(known[f.placeTyp]), int(radius))
+		default:
+			placesCtx, cancel := a.WithStageTimeout(ctx, "places")
+			results, err := a.Places.SearchNearby(placesCtx, location, f.placeTyp, radius)
+			cancel()
+			if err != nil {
+				telemetry.LogFor(ctx).Warn("Family places search failed", "type", f.placeTyp, "error", err)
+				lastErr = err
+				continue
+			}
+			pois := a.TidyPOIs(PlacesToPOIs(location, results, f.placeTyp))
+			info.Places = append(info.Places, pois...)
+			factor = nearestPlaceFactor(f.factor, pois, int(radius))
+		}
+		factor.Weight = f.weight
+		info.Factors = append(info.Factors, factor)
+	}
+	if lastErr != nil && len(info.Factors) == 0 {
+		return lastErr
+	}
+
+	property.QualityOfLife.Family = info
+	property.QualityOfLife.FamilyScore = familyScore(info.Factors)
+	return nil
+}
+
+// nearestPlaceFactor dá a nota do fator pelo lugar mais próximo de pois (já ordenado)
+func nearestPlaceFactor(factor string, pois []scraper.POI, radius int) scraper.FamilyFactor {
+	if len(pois) == 0 {
+		return scraper.FamilyFactor{Factor: factor, Evidence: fmt.Sprintf("None found within %d m", radius)}
+	}
+	nearest := pois[0]
+	return scraper.FamilyFactor{
+		Factor:   factor,
+		Score:    int(math.Round(100 * walkDecay(nearest.Distance*1000))),
+		Evidence: fmt.Sprintf("%s is %d m away (%d min walk)", nearest.Name, nearest.DistanceMeters, nearest.WalkMinutes),
+	}
+}
+
+// trafficFactor perde 10 pontos por colisão com pedestre ou ciclista no raio
+func trafficFactor(rs *safety.RoadSafety) scraper.FamilyFactor {
+	n := rs.PedestrianCollisions + rs.CyclistCollisions
+	return scraper.FamilyFactor{
+		Factor: "traffic",
+		Score:  max(0, 100-10*n),
+		Evidence: fmt.Sprintf("%d pedestrian and %d cyclist collisions within %d m since %d",
+			rs.PedestrianCollisions, rs.CyclistCollisions, rs.RadiusMeters, rs.SinceYear),
+	}
+}
+
+// familyScore é a média ponderada dos fatores presentes (1-100); 0 sem fator nenhum
+func familyScore(factors []scraper.FamilyFactor) int {
+	var points, weights float64
+	for _, f := range factors {
+		points += float64(f.Score) * f.Weight
+		weights += f.Weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return min(max(int(math.Round(points/weights)), 1), 100)
+}
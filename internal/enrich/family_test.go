@@ -0,0 +1,98 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"daft-scraper-api/internal/safety"
+	"daft-scraper-api/internal/scraper"
+
+	"googlemaps.github.io/maps"
+)
+
+func TestFamilyScore(t *testing.T) {
+	factors := []scraper.FamilyFactor{
+		{Factor: "schools", Weight: .25, Score: 100},
+		{Factor: "creches", Weight: .15, Score: 0},
+	}
+	if got := familyScore(factors); got != 63 {
+		t.Errorf("weighted score = %d, want 63", got)
+	}
+	if got := familyScore(nil); got != 0 {
+		t.Errorf("no factors = %d, want 0", got)
+	}
+}
+
+func TestTrafficFactor(t *testing.T) {
+	f := trafficFactor(&safety.RoadSafety{PedestrianCollisions: 3, CyclistCollisions: 2, RadiusMeters: 500, SinceYear: 2021})
+	if f.Score != 50 || f.Evidence == "" {
+		t.Errorf("traffic = %+v", f)
+	}
+	if f := trafficFactor(&safety.RoadSafety{PedestrianCollisions: 12}); f.Score != 0 {
+		t.Errorf("many collisions = %d, want 0", f.Score)
+	}
+}
+
+func TestAnalyzeFamily(t *testing.T) {
+	var searched []string
+	places := PlacesSearchFunc(func(location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+		searched = append(searched, placeType)
+		switch placeType {
+		case "creche":
+			return nil, errors.New("places down")
+		case "playground":
+			return nil, nil
+		}
+		place := maps.PlacesSearchResult{Name: "Scoil Mhuire", Types: []string{placeType}}
+		place.Geometry.Location = maps.LatLng{Lat: location.Lat + 0.002, Lng: location.Lng}
+		return []maps.PlacesSearchResult{place}, nil
+	})
+	a := &Analyzer{Places: places}
+
+	p := scraper.PropertyInfo{}
+	p.Coordinates.Lat, p.Coordinates.Lng = 53.34, -6.26
+	p.QualityOfLife.Amenities = []scraper.POI{scraper.NewPOI("Rathmines GP", "doctor", 0.3, 0, 0)}
+	p.QualityOfLife.Entertainment = []scraper.POI{scraper.NewPOI("Palmerston Park", "park", 3, 0, 0)}
+	if err := a.analyzeFamily(context.Background(), &p); err != nil {
+		t.Fatal(err)
+	}
+
+	// the GP and the park found by the other modules are reused
+	sort.Strings(searched)
+	if len(searched) != 3 || searched[0] != "creche" || searched[1] != "playground" || searched[2] != "school" {
+		t.Errorf("searched %v", searched)
+	}
+	scores := map[string]int{}
+	for _, f := range p.QualityOfLife.Family.Factors {
+		scores[f.Factor] = f.Score
+	}
+	if _, ok := scores["creches"]; ok {
+		t.Error("a failed search should leave its factor out")
+	}
+	if _, ok := scores["traffic"]; ok {
+		t.Error("traffic needs road safety data")
+	}
+	if scores["schools"] != 100 || scores["gp"] != 100 || scores["playgrounds"] != 0 || scores["parks"] != 0 {
+		t.Errorf("factor scores = %v", scores)
+	}
+	// (25 + 15) points out of 25 + 15 + 15 + 15 weights
+	if p.QualityOfLife.FamilyScore != 57 {
+		t.Errorf("family score = %d, want 57", p.QualityOfLife.FamilyScore)
+	}
+}
+
+func TestAnalyzeFamilyAllSearchesFail(t *testing.T) {
+	places := PlacesSearchFunc(func(location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+		return nil, errors.New("places down")
+	})
+	a := &Analyzer{Places: places}
+	p := scraper.PropertyInfo{}
+	if err := a.analyzeFamily(context.Background(), &p); err == nil {
+		t.Fatal("expected an error when no factor could be scored")
+	}
+	if p.QualityOfLife.Family != nil {
+		t.Errorf("family = %+v", p.QualityOfLife.Family)
+	}
+}
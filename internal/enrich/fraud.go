@@ -0,0 +1,80 @@
+package enrich
+
+import (
+	"fmt"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Risco de golpe do anúncio ───────────────────────────────────── */
+
+// Junta os sinais de golpe que as outras partes da análise já levantam: fotos que
+// aparecem em anúncios de outros endereços, fotos de banco de imagens, poucas fotos,
+// frases típicas de golpe na descrição, aluguel sem registro no RTB e preço bom demais
+// para a área. Cada sinal tem um peso; a soma (até 100) vira low, medium ou high.
+
+const (
+	fraudMediumScore = 25
+	fraudHighScore   = 50
+	fewPhotos        = 3
+	tooCheapRatio    = 0.7 // abaixo de 70% da média da área é bom demais para ser verdade
+)
+
+// fraudRisk avalia o anúncio com o que já foi analisado; roda depois das fotos e do valor
+func fraudRisk(p *scraper.PropertyInfo) *scraper.FraudRisk {
+	r := &scraper.FraudRisk{Signals: []scraper.FraudSignal{}}
+	add := func(signal string, weight int, evidence string) {
+		r.Signals = append(r.Signals, scraper.FraudSignal{Signal: signal, Weight: weight, Evidence: evidence})
+		r.Score += weight
+	}
+
+	switch n := len(p.Photos); {
+	case n == 0:
+		add("no_photos", 20, "The listing has no photos")
+	case n < fewPhotos:
+		add("few_photos", 10, fmt.Sprintf("Only %d photos in the listing", n))
+	}
+	elsewhere := 0
+	for _, m := range p.PhotoDuplicates {
+		if m.Kind == "different_address" {
+			elsewhere++
+		}
+	}
+	if elsewhere > 0 {
+		add("photos_elsewhere", 40, fmt.Sprintf("Photos also appear on %d listing(s) at other addresses", elsewhere))
+	}
+	if a := p.PhotoAnalysis; a != nil && len(a.StockPhotos) > 0 {
+		add("stock_photos", 10, fmt.Sprintf("%d photo(s) appear on listings at %d or more addresses", len(a.StockPhotos), scraper.StockPhotoAddresses))
+	}
+	for _, f := range p.DescriptionAnalysis.RedFlags {
+		if f.Category == "scam" {
+			add("scam_wording", 30, fmt.Sprintf("The listing says “%s”", f.Phrase))
+			break
+		}
+	}
+	// todo aluguel tem de estar no RTB; num quarto compartilhado o sinal pesa mais, porque
+	// o imóvel já deveria ter locações registradas
+	if reg := p.RTBRegistration; reg != nil && reg.Status == "not_found" {
+		weight := 10
+		if p.ListingType == "share" {
+			weight = 20
+		}
+		add("not_rtb_registered", weight, fmt.Sprintf("No registered tenancy found for this %s", reg.LookupBy))
+	}
+	if avg := p.ValueAnalysis.AreaAveragePrice; avg > 0 {
+		if price := scraper.MonthlyPrice(p); price > 0 && price < avg*tooCheapRatio {
+			add("too_cheap", 20, fmt.Sprintf("€%.0f against an area average of €%.0f", price, avg))
+		}
+	}
+
+	r.Score = min(r.Score, 100)
+	switch {
+	case r.Score >= fraudHighScore:
+		r.Level = "high"
+	case r.Score >= fraudMediumScore:
+		r.Level = "medium"
+	default:
+		r.Level = "low"
+	}
+	return r
+}
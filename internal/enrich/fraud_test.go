@@ -0,0 +1,64 @@
+package enrich
+
+import (
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestFraudRisk(t *testing.T) {
+	clean := &scraper.PropertyInfo{
+		Photos:    []string{"a", "b", "c", "d"},
+		RentPrice: "€2,000 per month",
+	}
+	if r := fraudRisk(clean); r.Score != 0 || r.Level != "low" || len(r.Signals) != 0 {
+		t.Errorf("clean listing = %+v", r)
+	}
+
+	risky := &scraper.PropertyInfo{
+		Photos:    []string{"a"},
+		RentPrice: "€900 per month",
+		Price:     &scraper.Price{Amount: 900, Period: "month", Monthly: 900},
+		PhotoDuplicates: []scraper.PhotoMatch{
+			{OtherListingURL: "https://www.daft.ie/for-rent/x/1", Kind: "different_address"},
+			{OtherListingURL: "https://www.daft.ie/for-rent/y/2", Kind: "relisted"},
+		},
+		PhotoAnalysis: &scraper.PhotoAnalysis{Count: 1, Hashed: 1, StockPhotos: []string{"a"}},
+		DescriptionAnalysis: scraper.DescriptionAnalysis{RedFlags: []scraper.DescriptionFlag{
+			{Category: "scam", Phrase: "pay by western union"},
+			{Category: "scam", Phrase: "no viewings"},
+		}},
+	}
+	risky.ValueAnalysis.AreaAveragePrice = 2000
+	r := fraudRisk(risky)
+	if r.Score != 100 || r.Level != "high" {
+		t.Errorf("score = %d (%s)", r.Score, r.Level)
+	}
+	want := []string{"few_photos", "photos_elsewhere", "stock_photos", "scam_wording", "too_cheap"}
+	if len(r.Signals) != len(want) {
+		t.Fatalf("signals = %+v", r.Signals)
+	}
+	for i, s := range r.Signals {
+		if s.Signal != want[i] {
+			t.Errorf("signal %d = %s, want %s", i, s.Signal, want[i])
+		}
+	}
+}
+
+func TestFraudRiskUnregisteredShare(t *testing.T) {
+	share := &scraper.PropertyInfo{
+		ListingType:     "share",
+		Photos:          []string{"a", "b", "c"},
+		RTBRegistration: &scraper.TenancyRegistration{Status: "not_found", LookupBy: "eircode"},
+	}
+	r := fraudRisk(share)
+	if r.Score != 20 || len(r.Signals) != 1 || r.Signals[0].Signal != "not_rtb_registered" {
+		t.Errorf("unregistered share = %+v", r)
+	}
+
+	// an unavailable lookup says nothing either way
+	share.RTBRegistration = &scraper.TenancyRegistration{Status: "unavailable"}
+	if r := fraudRisk(share); r.Score != 0 {
+		t.Errorf("unavailable lookup scored %d", r.Score)
+	}
+}
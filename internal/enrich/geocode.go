@@ -0,0 +1,255 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+
+	"googlemaps.github.io/maps"
+)
+
+/* ───── Geocodificação: Google, Nominatim e Eircode, em ordem ───────── */
+
+// Geocoder converte um endereço em coordenadas. GetCoordinates tenta os geocoders
+// configurados em ordem até um responder. As implementações devem ser seguras para
+// uso concorrente.
+type Geocoder interface {
+	Geocode(ctx context.Context, address string) (maps.LatLng, error)
+}
+
+// NamedGeocoder guarda o nome do geocoder para Coordinates.Source e os erros
+type NamedGeocoder struct {
+	Name string
+	Geocoder
+}
+
+// geocoderNames lê GEOCODERS ("google,nominatim,eircode", a ordem padrão). Nomes
+// desconhecidos são ignorados.
+func geocoderNames() []string {
+	raw := os.Getenv("GEOCODERS")
+	if raw == "" {
+		raw = "google,nominatim,eircode"
+	}
+	var names []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "google", "nominatim", "eircode":
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// newGeocodersFromEnv monta os geocoders de geocoderNames com os clientes de a
+func newGeocodersFromEnv(a *Analyzer) []NamedGeocoder {
+	var geocoders []NamedGeocoder
+	for _, name := range geocoderNames() {
+		var g Geocoder
+		switch name {
+		case "google":
+			g = googleGeocoder{client: a.Maps}
+		case "nominatim":
+			g = nominatimGeocoder{client: a.HTTP, endpoint: nominatimURL()}
+		default:
+			g = EircodeGeocoder{}
+		}
+		geocoders = append(geocoders, NamedGeocoder{Name: name, Geocoder: g})
+	}
+	return geocoders
+}
+
+// GetCoordinates geocodifica o endereço com o primeiro geocoder que responder e anota
+// qual foi em Coordinates.Source. Sem GOOGLE_MAPS_API_KEY (ou sem orçamento do Maps)
+// o Google falha na hora e a cadeia segue para os outros. As chamadas ao Google
+// contam na análise de property; a cadeia inteira respeita GEOCODE_TIMEOUT.
+func (a *Analyzer) GetCoordinates(ctx context.Context, property *scraper.PropertyInfo) (err error) {
+	ctx, s := telemetry.StartSpan(ctx, "geocode", telemetry.SpanInternal)
+	defer func() { s.End(err) }()
+	ctx, cancel := a.WithStageTimeout(WithMapsUsage(ctx, property.Usage()), "geocode")
+	defer cancel()
+
+	var failures []string
+	var firstErr error
+	for _, g := range a.Geocoders {
+		location, err := g.Geocode(ctx, property.Address)
+		if err != nil {
+			failures = append(failures, g.Name+": "+err.Error())
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		property.Coordinates.Lat, property.Coordinates.Lng = location.Lat, location.Lng
+		property.Coordinates.Source = g.Name
+		s.Set("geocoder", g.Name)
+		telemetry.LogFor(ctx).Debug("Address geocoded", "geocoder", g.Name, "lat", location.Lat, "lng", location.Lng)
+		return nil
+	}
+	if firstErr == nil {
+		return fmt.Errorf("nenhum geocoder configurado em GEOCODERS")
+	}
+	return fmt.Errorf("erro ao geocodificar endereço (%s): %w", strings.Join(failures, "; "), firstErr)
+}
+
+// googleGeocoder usa a Geocoding API do Google Maps; client nil quando não há chave
+type googleGeocoder struct {
+	client *maps.Client
+}
+
+func (g googleGeocoder) Geocode(ctx context.Context, address string) (maps.LatLng, error) {
+	if g.client == nil {
+		return maps.LatLng{}, fmt.Errorf("GOOGLE_MAPS_API_KEY não definida")
+	}
+
+	// Adicionar "Ireland" ao endereço para melhor precisão
+	if !strings.Contains(strings.ToLower(address), "ireland") {
+		address += ", Ireland"
+	}
+
+	r := &maps.GeocodingRequest{
+		Address: address,
+		Region:  "ie", // Código do país para Irlanda
+	}
+
+	resp, err := g.client.Geocode(ctx, r)
+	if err != nil {
+		return maps.LatLng{}, err
+	}
+	if len(resp) == 0 {
+		return maps.LatLng{}, fmt.Errorf("nenhum resultado encontrado para o endereço")
+	}
+	return resp[0].Geometry.Location, nil
+}
+
+// nominatimURL é o endpoint de busca do Nominatim (NOMINATIM_URL troca por uma instância própria)
+func nominatimURL() string {
+	if u := os.Getenv("NOMINATIM_URL"); u != "" {
+		return u
+	}
+	return "https://nominatim.openstreetmap.org/search"
+}
+
+// nominatimThrottle espaça as consultas ao Nominatim, cuja política de uso pede no
+// máximo 1 requisição por segundo e um User-Agent que identifique a aplicação
+var nominatimThrottle struct {
+	sync.Mutex
+	last time.Time
+}
+
+// nominatimGeocoder usa o Nominatim do OpenStreetMap, sem chave
+type nominatimGeocoder struct {
+	client   *http.Client
+	endpoint string
+}
+
+func (n nominatimGeocoder) Geocode(ctx context.Context, address string) (maps.LatLng, error) {
+	nominatimThrottle.Lock()
+	if wait := time.Second - time.Since(nominatimThrottle.last); wait > 0 {
+		time.Sleep(wait)
+	}
+	nominatimThrottle.last = time.Now()
+	nominatimThrottle.Unlock()
+
+	params := url.Values{
+		"q":            {address},
+		"countrycodes": {"ie"},
+		"format":       {"json"},
+		"limit":        {"1"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return maps.LatLng{}, err
+	}
+	req.Header.Set("User-Agent", "daft-scraper-api (+https://github.com/lfaitanin/exchange-helper-plugin)")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return maps.LatLng{}, fmt.Errorf("error querying Nominatim: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return maps.LatLng{}, fmt.Errorf("nominatim returned %d", resp.StatusCode)
+	}
+
+	var results []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return maps.LatLng{}, fmt.Errorf("error decoding nominatim response: %w", err)
+	}
+	if len(results) == 0 {
+		return maps.LatLng{}, fmt.Errorf("no results found for the address")
+	}
+
+	lat, errLat := strconv.ParseFloat(results[0].Lat, 64)
+	lng, errLng := strconv.ParseFloat(results[0].Lon, 64)
+	if errLat != nil || errLng != nil {
+		return maps.LatLng{}, fmt.Errorf("invalid coordinates from nominatim: %s, %s", results[0].Lat, results[0].Lon)
+	}
+	return maps.LatLng{Lat: lat, Lng: lng}, nil
+}
+
+// eircodeInText acha um Eircode dentro de um endereço ("..., Dublin 6, D06 X2Y3")
+var eircodeInText = regexp.MustCompile(`(?i)\b([AC-FHKNPRTV-Y]\d{2}|D6W)\s?[0-9AC-FHKNPRTV-Y]{4}\b`)
+
+// eircodeRoutingAreas é o centro aproximado das áreas de roteamento (os 3 primeiros
+// caracteres do Eircode) onde se concentra o mercado de aluguel: os distritos de
+// Dublin e as principais cidades. A precisão é de alguns km.
+var eircodeRoutingAreas = map[string]maps.LatLng{
+	"D01": {Lat: 53.3520, Lng: -6.2580}, "D02": {Lat: 53.3380, Lng: -6.2520},
+	"D03": {Lat: 53.3660, Lng: -6.2250}, "D04": {Lat: 53.3290, Lng: -6.2280},
+	"D05": {Lat: 53.3850, Lng: -6.1900}, "D06": {Lat: 53.3230, Lng: -6.2650},
+	"D6W": {Lat: 53.3120, Lng: -6.2950}, "D07": {Lat: 53.3600, Lng: -6.2900},
+	"D08": {Lat: 53.3370, Lng: -6.2900}, "D09": {Lat: 53.3830, Lng: -6.2450},
+	"D10": {Lat: 53.3400, Lng: -6.3550}, "D11": {Lat: 53.3950, Lng: -6.2900},
+	"D12": {Lat: 53.3220, Lng: -6.3200}, "D13": {Lat: 53.3900, Lng: -6.1500},
+	"D14": {Lat: 53.2980, Lng: -6.2600}, "D15": {Lat: 53.3850, Lng: -6.4000},
+	"D16": {Lat: 53.2750, Lng: -6.2700}, "D17": {Lat: 53.4050, Lng: -6.2050},
+	"D18": {Lat: 53.2500, Lng: -6.1800}, "D20": {Lat: 53.3480, Lng: -6.3700},
+	"D22": {Lat: 53.3250, Lng: -6.4000}, "D24": {Lat: 53.2870, Lng: -6.3700},
+	"A94": {Lat: 53.3010, Lng: -6.1780}, // Blackrock
+	"A96": {Lat: 53.2800, Lng: -6.1250}, // Glenageary
+	"A98": {Lat: 53.2030, Lng: -6.0980}, // Bray
+	"K67": {Lat: 53.4600, Lng: -6.2180}, // Swords
+	"K78": {Lat: 53.3570, Lng: -6.4490}, // Lucan
+	"T12": {Lat: 51.8870, Lng: -8.4800}, // Cork (sul)
+	"T23": {Lat: 51.9100, Lng: -8.4750}, // Cork (norte)
+	"H91": {Lat: 53.2740, Lng: -9.0510}, // Galway
+	"V94": {Lat: 52.6640, Lng: -8.6270}, // Limerick
+	"X91": {Lat: 52.2590, Lng: -7.1100}, // Waterford
+	"R95": {Lat: 52.6540, Lng: -7.2440}, // Kilkenny
+	"A91": {Lat: 54.0000, Lng: -6.4050}, // Dundalk
+	"A92": {Lat: 53.7170, Lng: -6.3500}, // Drogheda
+	"N37": {Lat: 53.4240, Lng: -7.9400}, // Athlone
+	"W91": {Lat: 53.2160, Lng: -6.6670}, // Naas
+}
+
+// EircodeGeocoder localiza pelo Eircode do endereço, no nível da área de roteamento.
+// É o último recurso da cadeia: funciona offline, mas só com Eircode e com pouca precisão.
+type EircodeGeocoder struct{}
+
+func (EircodeGeocoder) Geocode(ctx context.Context, address string) (maps.LatLng, error) {
+	code := eircodeInText.FindString(address)
+	if code == "" {
+		return maps.LatLng{}, fmt.Errorf("no Eircode in the address")
+	}
+	routingKey := strings.ToUpper(code[:3])
+	location, ok := eircodeRoutingAreas[routingKey]
+	if !ok {
+		return maps.LatLng{}, fmt.Errorf("routing area %s is not covered", routingKey)
+	}
+	return location, nil
+}
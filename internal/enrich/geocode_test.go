@@ -0,0 +1,99 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+// fakeNominatim returns a nominatimGeocoder pointed at handler
+func fakeNominatim(t *testing.T, handler http.HandlerFunc) nominatimGeocoder {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return nominatimGeocoder{client: srv.Client(), endpoint: srv.URL}
+}
+
+func TestNominatimGeocoder(t *testing.T) {
+	nominatim := fakeNominatim(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") == "" || r.URL.Query().Get("countrycodes") != "ie" {
+			t.Errorf("unexpected request: %s %v", r.URL, r.Header)
+		}
+		if r.URL.Query().Get("q") != "1 Main Street, Dublin 6" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		fmt.Fprint(w, `[{"lat":"53.3244","lon":"-6.2520"}]`)
+	})
+
+	location, err := nominatim.Geocode(context.Background(), "1 Main Street, Dublin 6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.Lat != 53.3244 || location.Lng != -6.252 {
+		t.Errorf("location = %+v", location)
+	}
+}
+
+func TestEircodeGeocoder(t *testing.T) {
+	location, err := EircodeGeocoder{}.Geocode(context.Background(), "Apartment 4, Rathmines Road, Dublin 6, d06 x2y3")
+	if err != nil || location != eircodeRoutingAreas["D06"] {
+		t.Errorf("got %+v, %v", location, err)
+	}
+	if location, err := (EircodeGeocoder{}).Geocode(context.Background(), "Kimmage, D6WXY12"); err != nil || location != eircodeRoutingAreas["D6W"] {
+		t.Errorf("D6W: got %+v, %v", location, err)
+	}
+	if _, err := (EircodeGeocoder{}).Geocode(context.Background(), "1 Main Street, Dublin 6"); err == nil {
+		t.Error("an address without an Eircode should fail")
+	}
+	if _, err := (EircodeGeocoder{}).Geocode(context.Background(), "Main Street, Belmullet, F26 X2Y3"); err == nil || !strings.Contains(err.Error(), "F26") {
+		t.Errorf("an uncovered routing area should fail, got %v", err)
+	}
+}
+
+func TestGetCoordinatesFallsBack(t *testing.T) {
+	a := &Analyzer{Geocoders: []NamedGeocoder{
+		{"google", googleGeocoder{}},
+		{"nominatim", fakeNominatim(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})},
+		{"eircode", EircodeGeocoder{}},
+	}}
+
+	// no Google key and Nominatim down: the Eircode is the last resort
+	p := scraper.PropertyInfo{Address: "12 Grand Parade, Cork, T12 X2Y3"}
+	if err := a.GetCoordinates(context.Background(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Coordinates.Source != "eircode" || p.Coordinates.Lat != eircodeRoutingAreas["T12"].Lat {
+		t.Errorf("coordinates = %+v", p.Coordinates)
+	}
+
+	// when every geocoder fails the error says why each one did
+	p = scraper.PropertyInfo{Address: "12 Grand Parade, Cork"}
+	err := a.GetCoordinates(context.Background(), &p)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, name := range []string{"google:", "nominatim:", "eircode:"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q does not mention %s", err, name)
+		}
+	}
+}
+
+func TestGeocoderNames(t *testing.T) {
+	t.Setenv("GEOCODERS", " Nominatim, bing ,eircode")
+	if got := strings.Join(geocoderNames(), ","); got != "nominatim,eircode" {
+		t.Errorf("got %s", got)
+	}
+	t.Setenv("GEOCODERS", "")
+	if got := strings.Join(geocoderNames(), ","); got != "google,nominatim,eircode" {
+		t.Errorf("default order: got %s", got)
+	}
+}
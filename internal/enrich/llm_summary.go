@@ -0,0 +1,181 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Resumo em linguagem natural gerado por LLM (opcional) ───────── */
+
+// llmProvider completa um prompt de sistema + usuário. Escolhido por LLM_PROVIDER:
+// "openai" (ou qualquer API compatível via OPENAI_BASE_URL) ou "ollama". Vazio desliga.
+type llmProvider interface {
+	Complete(ctx context.Context, system, user string) (string, error)
+}
+
+func LLMProviderFromEnv() llmProvider {
+	model := os.Getenv("LLM_MODEL")
+	switch os.Getenv("LLM_PROVIDER") {
+	case "openai":
+		baseURL := os.Getenv("OPENAI_BASE_URL")
+		if baseURL == "" {
+			baseURL = "https://api.openai.com/v1"
+		}
+		if model == "" {
+			model = "gpt-4o-mini"
+		}
+		return openAIChat{BaseURL: strings.TrimRight(baseURL, "/"), APIKey: os.Getenv("OPENAI_API_KEY"), Model: model}
+	case "ollama":
+		baseURL := os.Getenv("OLLAMA_URL")
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+		if model == "" {
+			model = "llama3.1"
+		}
+		return ollamaChat{BaseURL: strings.TrimRight(baseURL, "/"), Model: model}
+	default:
+		return nil
+	}
+}
+
+type openAIChat struct{ BaseURL, APIKey, Model string }
+
+func (o openAIChat) Complete(ctx context.Context, system, user string) (string, error) {
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	err := scraper.PostJSON(ctx, o.BaseURL+"/chat/completions", o.APIKey, map[string]interface{}{
+		"model":       o.Model,
+		"temperature": 0.2,
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": user},
+		},
+	}, &result)
+	if err != nil {
+		return "", err
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("LLM returned no choices")
+	}
+	return result.Choices[0].Message.Content, nil
+}
+
+type ollamaChat struct{ BaseURL, Model string }
+
+func (o ollamaChat) Complete(ctx context.Context, system, user string) (string, error) {
+	var result struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	}
+	err := scraper.PostJSON(ctx, o.BaseURL+"/api/chat", "", map[string]interface{}{
+		"model":   o.Model,
+		"stream":  false,
+		"options": map[string]interface{}{"temperature": 0.2},
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": user},
+		},
+	}, &result)
+	if err != nil {
+		return "", err
+	}
+	return result.Message.Content, nil
+}
+
+const summarySystemPrompt = `You write a short overview (3-5 sentences) of a rental or sale listing for a prospective tenant or buyer in Ireland.
+Use ONLY the facts listed by the user. Do not add facts, guesses, amenities, or numbers that are not listed.
+If a fact is missing, do not mention it. Plain prose, no lists, no headings, no markdown.`
+
+// summaryFacts é o único texto que o modelo vê: só campos estruturados da análise,
+// nunca o HTML ou a descrição crua do anúncio
+var summaryFacts = template.Must(template.New("facts").Parse(`Address: {{.Address}}
+Price: {{.Price}}
+{{- with .ListingType}}
+Listing type: {{.}}{{end}}
+{{- with .Bedrooms}}
+Bedrooms: {{.}}{{end}}
+{{- with .BER}}
+BER: {{.}}{{end}}
+Overall score: {{.OverallScore}}/100
+{{- if .Safety}}
+Safety rating: {{.Safety}}/10{{end}}
+{{- if .Transport}}
+Transport score: {{.Transport}}/10{{end}}
+{{- with .NearestStation}}
+Nearest public transport: {{.}}{{end}}
+{{- if .Walk}}
+Walk score: {{.Walk}}/100{{end}}
+{{- if .AreaAverage}}
+Area average price: €{{printf "%.0f" .AreaAverage}} (value rating {{.Value}}/10){{end}}
+{{- range .Pros}}
+Pro: {{.}}{{end}}
+{{- range .Cons}}
+Con: {{.}}{{end}}
+{{- range .RedFlags}}
+Red flag: {{.}}{{end}}`))
+
+// BuildSummaryFacts preenche o template de fatos a partir da análise
+func (a *Analyzer) BuildSummaryFacts(p *scraper.PropertyInfo) (string, error) {
+	s := a.SummarizeProperty(p)
+	facts := struct {
+		Address, Price, ListingType, Bedrooms, BER, NearestStation string
+		OverallScore, Safety, Transport, Walk, Value               int
+		AreaAverage                                                float64
+		Pros, Cons, RedFlags                                       []string
+	}{
+		Address:      p.Address,
+		Price:        p.RentPrice,
+		ListingType:  p.ListingType,
+		Bedrooms:     p.Bedrooms,
+		BER:          p.BER,
+		OverallScore: s.OverallScore,
+		Safety:       s.Scores.Safety,
+		Transport:    s.Scores.Transport,
+		Walk:         s.Scores.Walk,
+		Value:        s.Scores.Value,
+		AreaAverage:  p.ValueAnalysis.AreaAveragePrice,
+		Pros:         s.Pros,
+		Cons:         s.Cons,
+	}
+	if top := scraper.NearestPOIs(p.QualityOfLife.PublicTransport, 1); len(top) > 0 {
+		facts.NearestStation = fmt.Sprintf("%s, %.1f km (%d min walk)", top[0].Name, top[0].Distance, top[0].Duration)
+	}
+	for _, f := range s.RedFlags {
+		facts.RedFlags = append(facts.RedFlags, f.Note)
+	}
+
+	var b strings.Builder
+	if err := summaryFacts.Execute(&b, facts); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// generateSummary produz o parágrafo de resumo; devolve "" se nenhum provedor estiver configurado
+func (a *Analyzer) generateSummary(ctx context.Context, p *scraper.PropertyInfo) (string, error) {
+	provider := LLMProviderFromEnv()
+	if provider == nil || p.Address == "" {
+		return "", nil
+	}
+	facts, err := a.BuildSummaryFacts(p)
+	if err != nil {
+		return "", fmt.Errorf("error building summary facts: %w", err)
+	}
+	text, err := provider.Complete(ctx, summarySystemPrompt, facts)
+	if err != nil {
+		return "", fmt.Errorf("error generating summary: %w", err)
+	}
+	return strings.TrimSpace(text), nil
+}
@@ -0,0 +1,35 @@
+package enrich
+
+import (
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestBuildSummaryFacts(t *testing.T) {
+	p := &scraper.PropertyInfo{
+		Address:     "1 Main St, Dublin 1",
+		RentPrice:   "€2,000 per month",
+		Description: "RAW DESCRIPTION TEXT that must not reach the model",
+	}
+	p.SafetyInfo.SafetyRating = 7
+	p.QualityOfLife.PublicTransport = []scraper.POI{{Name: "Abbey Street", Distance: 0.3, Duration: 4}}
+	p.DescriptionAnalysis.Pros = []string{"Balcony"}
+
+	facts, err := (&Analyzer{}).BuildSummaryFacts(p)
+	if err != nil {
+		t.Fatalf("buildSummaryFacts: %v", err)
+	}
+	for _, want := range []string{"Address: 1 Main St, Dublin 1", "Safety rating: 7/10", "Nearest public transport: Abbey Street, 0.3 km (4 min walk)", "Pro: Balcony"} {
+		if !strings.Contains(facts, want) {
+			t.Errorf("facts missing %q:\n%s", want, facts)
+		}
+	}
+	if strings.Contains(facts, "RAW DESCRIPTION") {
+		t.Error("raw description leaked into facts")
+	}
+	if strings.Contains(facts, "Walk score") {
+		t.Error("missing facts should be omitted")
+	}
+}
@@ -0,0 +1,160 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Cliente do Overpass com failover entre mirrors ──────────────── */
+
+// As instâncias públicas do Overpass limitam a taxa com frequência (429) e caem por
+// timeout do gateway (504). O retryTransport já repete essas respostas na mesma
+// instância; quando ela continua falhando, a consulta passa para o próximo mirror.
+// Cada mirror tem o seu circuito (ver scraper.UpstreamName) e os que falharam há pouco vão
+// para o fim da fila, para não serem os primeiros da próxima consulta.
+
+// overpassHealthWindow é por quanto tempo uma falha rebaixa o mirror
+const overpassHealthWindow = 10 * time.Minute
+
+// overpassElement é um nó, via ou relação da resposta; vias e relações trazem o
+// ponto central em Center (com "out center") e as vias, os pontos em Geometry (com
+// "out geom"). "out count" devolve um elemento do tipo count com os totais em Tags.
+type overpassElement struct {
+	Type   string  `json:"type"`
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+	Center *struct {
+		Lat float64 `json:"lat"`
+		Lon float64 `json:"lon"`
+	} `json:"center"`
+	Geometry []struct {
+		Lat float64 `json:"lat"`
+		Lon float64 `json:"lon"`
+	} `json:"geometry"`
+	Tags map[string]string `json:"tags"`
+}
+
+// overpassClient consulta as instâncias do Overpass, uma por vez até uma responder;
+// só lê os campos, é seguro para uso concorrente
+type overpassClient struct {
+	client    *http.Client
+	endpoints []string // em ordem de preferência
+}
+
+// overpassStatusError é uma resposta do Overpass diferente de 200
+type overpassStatusError struct {
+	status int
+	body   string
+}
+
+func (e *overpassStatusError) Error() string {
+	return fmt.Sprintf("overpass API returned %d: %s", e.status, e.body)
+}
+
+// overpassHealth guarda as falhas seguidas de cada instância
+var overpassHealth = struct {
+	sync.Mutex
+	failures    map[string]int
+	lastFailure map[string]time.Time
+}{failures: map[string]int{}, lastFailure: map[string]time.Time{}}
+
+// noteOverpass registra o resultado de uma consulta à instância
+func noteOverpass(endpoint string, ok bool, now time.Time) {
+	overpassHealth.Lock()
+	defer overpassHealth.Unlock()
+	if ok {
+		delete(overpassHealth.failures, endpoint)
+		return
+	}
+	overpassHealth.failures[endpoint]++
+	overpassHealth.lastFailure[endpoint] = now
+}
+
+// ordered devolve as instâncias das mais saudáveis para as menos: as sem falhas
+// recentes na ordem configurada, depois as outras por número de falhas
+func (o overpassClient) ordered(now time.Time) []string {
+	overpassHealth.Lock()
+	defer overpassHealth.Unlock()
+	failures := func(endpoint string) int {
+		if now.Sub(overpassHealth.lastFailure[endpoint]) > overpassHealthWindow {
+			return 0
+		}
+		return overpassHealth.failures[endpoint]
+	}
+	endpoints := append([]string(nil), o.endpoints...)
+	sort.SliceStable(endpoints, func(i, j int) bool { return failures(endpoints[i]) < failures(endpoints[j]) })
+	return endpoints
+}
+
+// query roda uma consulta Overpass QL e devolve os elementos, passando ao próximo
+// mirror quando a instância está sobrecarregada ou fora do ar
+func (o overpassClient) query(ctx context.Context, query string) ([]overpassElement, error) {
+	var lastErr error
+	for _, endpoint := range o.ordered(time.Now()) {
+		elements, err := o.queryEndpoint(ctx, endpoint, query)
+		if err == nil {
+			noteOverpass(endpoint, true, time.Now())
+			return elements, nil
+		}
+		if ctx.Err() != nil || !overpassFailover(err) {
+			return nil, err
+		}
+		noteOverpass(endpoint, false, time.Now())
+		telemetry.LogFor(ctx).Warn("Overpass instance failed, trying the next one", "endpoint", endpoint, "error", err)
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no Overpass instance configured")
+	}
+	return nil, lastErr
+}
+
+// overpassFailover diz se vale tentar outra instância: sim para falhas de rede,
+// circuito aberto, 429, 5xx e respostas ilegíveis; não para uma consulta recusada
+// (400), que falharia igual em todas
+func overpassFailover(err error) bool {
+	var statusErr *overpassStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status == http.StatusTooManyRequests || statusErr.status >= 500
+	}
+	return true
+}
+
+// queryEndpoint roda a consulta numa instância
+func (o overpassClient) queryEndpoint(ctx context.Context, endpoint, query string) ([]overpassElement, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(url.Values{"data": {query}}.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error querying Overpass API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, &overpassStatusError{status: resp.StatusCode, body: string(body)}
+	}
+
+	var result struct {
+		Elements []overpassElement `json:"elements"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error decoding overpass response: %w", err)
+	}
+	return result.Elements, nil
+}
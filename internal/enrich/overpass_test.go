@@ -0,0 +1,86 @@
+package enrich
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+// overpassMirror serves status (and body on 200) and counts its calls
+func overpassMirror(t *testing.T, status int, body string) (string, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, &calls
+}
+
+func TestOverpassEndpoints(t *testing.T) {
+	t.Setenv("OVERPASS_URL", "")
+	t.Setenv("OVERPASS_MIRRORS", "")
+	if got := scraper.OverpassEndpoints(); strings.Join(got, ",") != strings.Join(scraper.DefaultOverpassMirrors, ",") {
+		t.Errorf("default endpoints = %v", got)
+	}
+
+	t.Setenv("OVERPASS_URL", "https://overpass.internal/api/interpreter")
+	t.Setenv("OVERPASS_MIRRORS", "https://overpass-api.de/api/interpreter, https://overpass.internal/api/interpreter")
+	want := "https://overpass.internal/api/interpreter,https://overpass-api.de/api/interpreter"
+	if got := scraper.OverpassEndpoints(); strings.Join(got, ",") != want {
+		t.Errorf("endpoints = %v, want the private instance first and no repeats", got)
+	}
+}
+
+func TestOverpassFailsOverToNextMirror(t *testing.T) {
+	throttled, throttledCalls := overpassMirror(t, http.StatusTooManyRequests, "rate limited")
+	healthy, healthyCalls := overpassMirror(t, http.StatusOK, `{"elements":[{"type":"count","tags":{"nodes":"7"}}]}`)
+	o := overpassClient{client: http.DefaultClient, endpoints: []string{throttled, healthy}}
+
+	elements, err := o.query(context.Background(), "[out:json];node;out count;")
+	if err != nil || len(elements) != 1 || overpassCount(elements[0].Tags) != 7 {
+		t.Fatalf("elements %+v, err %v", elements, err)
+	}
+
+	// the throttled mirror now goes to the back of the queue
+	if _, err := o.query(context.Background(), "[out:json];node;out count;"); err != nil {
+		t.Fatal(err)
+	}
+	if *throttledCalls != 1 || *healthyCalls != 2 {
+		t.Errorf("calls: throttled %d, healthy %d; want 1 and 2", *throttledCalls, *healthyCalls)
+	}
+	if got := o.ordered(time.Now().Add(overpassHealthWindow + time.Minute)); got[0] != throttled {
+		t.Errorf("after the health window the configured order should return, got %v", got)
+	}
+}
+
+func TestOverpassBadQueryDoesNotFailOver(t *testing.T) {
+	broken, _ := overpassMirror(t, http.StatusBadRequest, "parse error")
+	other, otherCalls := overpassMirror(t, http.StatusOK, `{"elements":[]}`)
+	o := overpassClient{client: http.DefaultClient, endpoints: []string{broken, other}}
+
+	if _, err := o.query(context.Background(), "not a query"); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("err = %v, want the 400", err)
+	}
+	if *otherCalls != 0 {
+		t.Error("a rejected query should not be sent to the other mirrors")
+	}
+}
+
+func TestOverpassAllMirrorsDown(t *testing.T) {
+	a, _ := overpassMirror(t, http.StatusGatewayTimeout, "")
+	b, _ := overpassMirror(t, http.StatusServiceUnavailable, "")
+	o := overpassClient{client: http.DefaultClient, endpoints: []string{a, b}}
+	if _, err := o.query(context.Background(), "[out:json];node;out;"); err == nil {
+		t.Fatal("expected an error when every mirror fails")
+	}
+}
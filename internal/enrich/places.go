@@ -0,0 +1,175 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"daft-scraper-api/internal/env"
+	"daft-scraper-api/internal/telemetry"
+
+	"googlemaps.github.io/maps"
+)
+
+/* ───── Escolha do provedor de lugares próximos ─────────────────────── */
+
+// placesProviderNames lê PLACES_PROVIDER, a lista em ordem de preferência
+// ("google,foursquare,osm"); cada provedor é tentado quando o anterior falha. Sem a
+// variável, usa os que têm chave (mapsKey, FOURSQUARE_API_KEY) e o
+// OpenStreetMap por último, para o serviço funcionar sem nenhuma chave. Com um
+// orçamento do Maps no modo degrade, o OpenStreetMap entra atrás do Google para
+// quando o orçamento do dia acabar.
+func placesProviderNames(mapsKey string, budget *MapsBudget) []string {
+	var names []string
+	if raw := os.Getenv("PLACES_PROVIDER"); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			switch name = strings.ToLower(strings.TrimSpace(name)); name {
+			case "google", "foursquare", "osm":
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		if mapsKey != "" {
+			names = append(names, "google")
+		}
+		if os.Getenv("FOURSQUARE_API_KEY") != "" {
+			names = append(names, "foursquare")
+		}
+		names = append(names, "osm")
+	}
+
+	if len(names) == 1 && names[0] == "google" && budget.Limit > 0 && !budget.refuses() {
+		names = append(names, "osm")
+	}
+	return names
+}
+
+// newPlacesFromEnv monta os provedores de placesProviderNames com os clientes de a,
+// encadeados quando há mais de um. Um provedor sem chave vira um que sempre falha,
+// para o erro aparecer nos avisos da análise como antes.
+func newPlacesFromEnv(a *Analyzer, mapsKey string) PlacesProvider {
+	var chain placesChain
+	for _, name := range placesProviderNames(mapsKey, a.Spend()) {
+		var provider PlacesProvider
+		switch name {
+		case "google":
+			if a.Maps == nil {
+				provider = unavailablePlaces{fmt.Errorf("GOOGLE_MAPS_API_KEY not set")}
+			} else {
+				provider = googlePlaces{client: a.Maps}
+			}
+		case "foursquare":
+			if apiKey := os.Getenv("FOURSQUARE_API_KEY"); apiKey == "" {
+				provider = unavailablePlaces{fmt.Errorf("FOURSQUARE_API_KEY not set")}
+			} else {
+				provider = foursquarePlaces{client: a.HTTP, endpoint: env.Or("FOURSQUARE_URL", DefaultFoursquareURL), apiKey: apiKey}
+			}
+		default:
+			provider = osmPlaces{overpass: a.Overpass}
+		}
+		chain = append(chain, namedPlaces{name: name, PlacesProvider: provider})
+	}
+	if len(chain) == 1 {
+		return chain[0].PlacesProvider
+	}
+	return chain
+}
+
+// unavailablePlaces é um provedor configurado mas sem chave
+type unavailablePlaces struct {
+	err error
+}
+
+func (u unavailablePlaces) SearchNearby(ctx context.Context, location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+	return nil, u.err
+}
+
+// namedPlaces guarda o nome do provedor para os logs da cadeia
+type namedPlaces struct {
+	name string
+	PlacesProvider
+}
+
+// placesChain tenta os provedores em ordem e devolve o primeiro que responder, para
+// que uma cota esgotada (ou fora do ar) no meio de um lote não derrube a análise.
+// Só lê a própria lista; é seguro para uso concorrente se os provedores forem.
+type placesChain []namedPlaces
+
+func (c placesChain) SearchNearby(ctx context.Context, location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+	var err error
+	for _, p := range c {
+		var results []maps.PlacesSearchResult
+		if results, err = p.SearchNearby(ctx, location, placeType, radius); err == nil {
+			return results, nil
+		}
+		telemetry.LogFor(ctx).Warn("Places search failed, trying the next provider", "provider", p.name, "type", placeType, "error", err)
+	}
+	return nil, err
+}
+
+// searchNearbyPlaces é uma função auxiliar para buscar lugares próximos
+func searchNearbyPlaces(ctx context.Context, client *maps.Client, location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+	r := &maps.NearbySearchRequest{
+		Location: location,
+		Radius:   radius,
+		Keyword:  placeType,
+		Language: "en",
+	}
+
+	resp, err := client.NearbySearch(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("error searching nearby places: %w", err)
+	}
+
+	return resp.Results, nil
+}
+
+// PlacesProvider busca lugares próximos pelos tipos do Google Places (train_station,
+// pharmacy...); googlePlaces e osmPlaces são as implementações, escolhidas por
+// newPlacesProvider. É passado para as funções de busca em vez de ficar numa variável
+// global, para que requisições concorrentes (e os testes) não disputem a mesma
+// implementação. As implementações devem ser seguras para uso concorrente.
+type PlacesProvider interface {
+	SearchNearby(ctx context.Context, location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error)
+}
+
+// googlePlaces busca na Places API; *maps.Client é seguro para uso concorrente
+type googlePlaces struct {
+	client *maps.Client
+}
+
+func (g googlePlaces) SearchNearby(ctx context.Context, location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+	if keyword, ok := googleKeywords[placeType]; ok {
+		placeType = keyword
+	}
+	return searchNearbyPlaces(ctx, g.client, location, placeType, radius)
+}
+
+// googleKeywords são as palavras-chave que acham melhor um tipo na busca do Google
+var googleKeywords = map[string]string{
+	"police":          "garda station police",
+	"coworking_space": "coworking space",
+	"creche":          "creche childcare",
+}
+
+// PlacesSearchFunc adapta uma função comum a PlacesProvider (usado nos testes)
+type PlacesSearchFunc func(location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error)
+
+func (f PlacesSearchFunc) SearchNearby(ctx context.Context, location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+	return f(location, placeType, radius)
+}
+
+// tracedPlaces dá a cada busca de lugares o seu span, com o tipo buscado
+type tracedPlaces struct {
+	PlacesProvider
+}
+
+func (p tracedPlaces) SearchNearby(ctx context.Context, location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+	ctx, s := telemetry.StartSpan(ctx, "places "+placeType, telemetry.SpanInternal, "places.type", placeType, "places.radius", int(radius))
+	results, err := p.PlacesProvider.SearchNearby(ctx, location, placeType, radius)
+	s.Set("places.results", len(results))
+	s.End(err)
+	return results, err
+}
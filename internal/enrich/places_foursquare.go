@@ -0,0 +1,89 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"googlemaps.github.io/maps"
+)
+
+/* ───── Lugares próximos pelo Foursquare ────────────────────────────── */
+
+// DefaultFoursquareURL é o endpoint de busca da Places API; FOURSQUARE_URL troca (nos
+// testes)
+const DefaultFoursquareURL = "https://api.foursquare.com/v3/places/search"
+
+// foursquareQueries são os termos de busca dos tipos do Google Places cujo nome não
+// serve direto (os outros viram texto: "train_station" → "train station")
+var foursquareQueries = map[string]string{
+	"police":        "garda station",
+	"movie_theater": "cinema",
+	"doctor":        "doctor gp",
+}
+
+// foursquarePlaces busca na Places API do Foursquare; só lê os campos, é seguro para
+// uso concorrente
+type foursquarePlaces struct {
+	client   *http.Client
+	endpoint string
+	apiKey   string
+}
+
+func (f foursquarePlaces) SearchNearby(ctx context.Context, location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+	query, ok := foursquareQueries[placeType]
+	if !ok {
+		query = strings.ReplaceAll(placeType, "_", " ")
+	}
+	params := url.Values{
+		"ll":     {fmt.Sprintf("%f,%f", location.Lat, location.Lng)},
+		"radius": {fmt.Sprint(radius)},
+		"query":  {query},
+		"fields": {"name,geocodes"},
+		"sort":   {"DISTANCE"},
+		"limit":  {"20"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", f.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error querying Foursquare: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("foursquare returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Results []struct {
+			Name     string `json:"name"`
+			Geocodes struct {
+				Main struct {
+					Latitude  float64 `json:"latitude"`
+					Longitude float64 `json:"longitude"`
+				} `json:"main"`
+			} `json:"geocodes"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error decoding foursquare response: %w", err)
+	}
+
+	places := make([]maps.PlacesSearchResult, 0, len(result.Results))
+	for _, r := range result.Results {
+		place := maps.PlacesSearchResult{Name: r.Name, Types: []string{placeType}}
+		place.Geometry.Location = maps.LatLng{Lat: r.Geocodes.Main.Latitude, Lng: r.Geocodes.Main.Longitude}
+		places = append(places, place)
+	}
+	return places, nil
+}
@@ -0,0 +1,78 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"googlemaps.github.io/maps"
+)
+
+/* ───── Lugares próximos pelo OpenStreetMap (Overpass) ──────────────── */
+
+// osmTags traduz os tipos do Google Places para as tags equivalentes do OSM
+var osmTags = map[string][]string{
+	"train_station":     {`"railway"="station"`, `"railway"="tram_stop"`},
+	"bus_station":       {`"highway"="bus_stop"`, `"amenity"="bus_station"`},
+	"supermarket":       {`"shop"="supermarket"`},
+	"pharmacy":          {`"amenity"="pharmacy"`},
+	"convenience_store": {`"shop"="convenience"`},
+	"shopping_mall":     {`"shop"="mall"`},
+	"bank":              {`"amenity"="bank"`},
+	"hospital":          {`"amenity"="hospital"`},
+	"doctor":            {`"amenity"="doctors"`, `"amenity"="clinic"`},
+	"restaurant":        {`"amenity"="restaurant"`},
+	"bar":               {`"amenity"="bar"`, `"amenity"="pub"`},
+	"cafe":              {`"amenity"="cafe"`},
+	"movie_theater":     {`"amenity"="cinema"`},
+	"gym":               {`"leisure"="fitness_centre"`},
+	"park":              {`"leisure"="park"`},
+	"police":            {`"amenity"="police"`},
+	"coworking_space":   {`"amenity"="coworking_space"`, `"office"="coworking"`},
+	"school":            {`"amenity"="school"`},
+	"creche":            {`"amenity"="childcare"`, `"amenity"="kindergarten"`},
+	"playground":        {`"leisure"="playground"`},
+}
+
+// osmPlacesLimit acompanha o máximo de resultados de uma página da Places API
+const osmPlacesLimit = 20
+
+// osmPlaces busca lugares no Overpass; só lê o cliente, é seguro para uso concorrente
+type osmPlaces struct {
+	overpass overpassClient
+}
+
+func (o osmPlaces) SearchNearby(ctx context.Context, location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+	tags, ok := osmTags[placeType]
+	if !ok {
+		return nil, fmt.Errorf("place type %q has no OpenStreetMap equivalent", placeType)
+	}
+
+	var q strings.Builder
+	q.WriteString("[out:json][timeout:25];(")
+	for _, tag := range tags {
+		fmt.Fprintf(&q, "nwr[%s](around:%d,%f,%f);", tag, radius, location.Lat, location.Lng)
+	}
+	fmt.Fprintf(&q, ");out center %d;", osmPlacesLimit)
+
+	elements, err := o.overpass.query(ctx, q.String())
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]maps.PlacesSearchResult, 0, len(elements))
+	for _, e := range elements {
+		lat, lng := e.Lat, e.Lon
+		if e.Center != nil {
+			lat, lng = e.Center.Lat, e.Center.Lon
+		}
+		name := e.Tags["name"]
+		if name == "" {
+			continue // sem nome não dá para mostrar ao usuário
+		}
+		place := maps.PlacesSearchResult{Name: name, Types: []string{placeType}}
+		place.Geometry.Location = maps.LatLng{Lat: lat, Lng: lng}
+		results = append(results, place)
+	}
+	return results, nil
+}
@@ -0,0 +1,135 @@
+package enrich
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+
+	"googlemaps.github.io/maps"
+)
+
+// fakeOverpass serves body and records the last query it received
+func fakeOverpass(t *testing.T, body string) (overpassClient, *string) {
+	t.Helper()
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		query = r.Form.Get("data")
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return overpassClient{client: srv.Client(), endpoints: []string{srv.URL}}, &query
+}
+
+func TestOSMPlacesSearchNearby(t *testing.T) {
+	overpass, query := fakeOverpass(t, `{"elements":[
+		{"type":"node","lat":53.3251,"lon":-6.2540,"tags":{"name":"Ranelagh"}},
+		{"type":"way","center":{"lat":53.3300,"lon":-6.2600},"tags":{"name":"Charlemont"}},
+		{"type":"node","lat":53.3200,"lon":-6.2500,"tags":{}}
+	]}`)
+
+	results, err := osmPlaces{overpass}.SearchNearby(context.Background(), &maps.LatLng{Lat: 53.32, Lng: -6.25}, "train_station", 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{`nwr["railway"="station"](around:2000,`, `nwr["railway"="tram_stop"]`, "out center 20"} {
+		if !strings.Contains(*query, tag) {
+			t.Errorf("query %q does not contain %s", *query, tag)
+		}
+	}
+	// unnamed elements are dropped, ways use their center
+	if len(results) != 2 || results[1].Name != "Charlemont" || results[1].Geometry.Location.Lat != 53.33 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if pois := PlacesToPOIs(&maps.LatLng{Lat: 53.32, Lng: -6.25}, results, ""); pois[0].Type != "train_station" {
+		t.Errorf("POI type = %q, want the requested place type", pois[0].Type)
+	}
+
+	if _, err := (osmPlaces{overpass}).SearchNearby(context.Background(), &maps.LatLng{}, "casino", 500); err == nil {
+		t.Error("a type without an OSM mapping should fail")
+	}
+}
+
+func TestOSMPlacesCoverSearchedTypes(t *testing.T) {
+	// every type the find* functions ask for must have an OSM equivalent
+	var asked []string
+	places := PlacesSearchFunc(func(location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+		asked = append(asked, placeType)
+		return nil, nil
+	})
+	p := &scraper.PropertyInfo{}
+	(&Analyzer{Places: places}).FindPublicTransport(context.Background(), p)
+	(&Analyzer{Places: places}).findAmenities(context.Background(), p)
+	(&Analyzer{Places: places}).findEntertainment(context.Background(), p)
+	for _, placeType := range append(asked, "police") {
+		if _, ok := osmTags[placeType]; !ok {
+			t.Errorf("%s has no OSM tags", placeType)
+		}
+	}
+}
+
+func TestAnalyzeStreetLightingOverpass(t *testing.T) {
+	overpass, _ := fakeOverpass(t, `{"elements":[{"type":"count","tags":{"nodes":"25"}}]}`)
+	var analysis scraper.AnalysisResponse
+	if err := (&Analyzer{Overpass: overpass}).analyzeStreetLighting(context.Background(), &analysis); err != nil {
+		t.Fatal(err)
+	}
+	if analysis.SafetyInfo.StreetLighting.Rating != 8 {
+		t.Errorf("rating = %d, want 8", analysis.SafetyInfo.StreetLighting.Rating)
+	}
+}
+
+func TestAnalyzeStreetLightingDensity(t *testing.T) {
+	// 20 lamps along ~1 km of residential road: 0.009° of latitude is ~1 km
+	overpass, query := fakeOverpass(t, `{"elements":[
+		{"type":"count","id":0,"tags":{"nodes":"20","ways":"0","relations":"0","total":"20"}},
+		{"type":"way","tags":{"highway":"residential"},"geometry":[
+			{"lat":53.3155,"lon":-6.25},{"lat":53.3200,"lon":-6.25},{"lat":53.3245,"lon":-6.25}]},
+		{"type":"way","tags":{"highway":"residential"},"geometry":[
+			{"lat":53.40,"lon":-6.25},{"lat":53.41,"lon":-6.25}]}
+	]}`)
+	analysis := scraper.AnalysisResponse{}
+	analysis.Property.Coordinates.Lat, analysis.Property.Coordinates.Lng = 53.32, -6.25
+	if err := (&Analyzer{Overpass: overpass}).analyzeStreetLighting(context.Background(), &analysis); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, part := range []string{`node["highway"="street_lamp"]`, "out count;", `way["highway"~"^(trunk|`, "out geom;"} {
+		if !strings.Contains(*query, part) {
+			t.Errorf("query %q does not contain %s", *query, part)
+		}
+	}
+	lighting := analysis.SafetyInfo.StreetLighting
+	// the far-away way is outside the radius and does not count
+	if lighting.LampCount != 20 || lighting.RoadLengthMeters < 950 || lighting.RoadLengthMeters > 1050 {
+		t.Errorf("lighting = %+v", lighting)
+	}
+	if lighting.LampsPerKm < 19 || lighting.LampsPerKm > 21 || lighting.Rating != 8 {
+		t.Errorf("density %.1f per km, rating %d; want about 20 and 8", lighting.LampsPerKm, lighting.Rating)
+	}
+	if !strings.Contains(lighting.Description, "20 street lights within 500m") {
+		t.Errorf("description = %q", lighting.Description)
+	}
+}
+
+func TestLightingRating(t *testing.T) {
+	cases := []struct {
+		count  int
+		perKm  float64
+		rating int
+	}{
+		{60, 0, 10}, {25, 0, 8}, {12, 0, 6}, {3, 0, 4}, // no road data: by count
+		{60, 5, 4}, // many lamps spread over a lot of road
+		{12, 30, 10},
+	}
+	for _, c := range cases {
+		if got := lightingRating(c.count, c.perKm); got != c.rating {
+			t.Errorf("lightingRating(%d, %v) = %d, want %d", c.count, c.perKm, got, c.rating)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry/telemetrytest"
+
+	"googlemaps.github.io/maps"
+)
+
+func TestPlacesProviderNames(t *testing.T) {
+	cases := []struct {
+		provider, googleKey, foursquareKey, want string
+	}{
+		{"", "", "", "osm"},
+		{"", "key", "", "google,osm"},
+		{"", "key", "fsq", "google,foursquare,osm"},
+		{"", "", "fsq", "foursquare,osm"},
+		{"osm", "key", "fsq", "osm"},
+		{"Foursquare, google", "key", "fsq", "foursquare,google"},
+		{"google", "", "", "google"}, // explicit google without a key fails loudly later
+		{"bing", "", "", "osm"},
+	}
+	for _, c := range cases {
+		t.Setenv("PLACES_PROVIDER", c.provider)
+		t.Setenv("FOURSQUARE_API_KEY", c.foursquareKey)
+		if got := strings.Join(placesProviderNames(c.googleKey, &MapsBudget{}), ","); got != c.want {
+			t.Errorf("PLACES_PROVIDER=%q keys=%q/%q: got %s, want %s", c.provider, c.googleKey, c.foursquareKey, got, c.want)
+		}
+	}
+
+	// with a Maps budget in degrade mode a Google-only list falls back to OSM
+	t.Setenv("MAPS_DAILY_BUDGET", "5")
+	t.Setenv("PLACES_PROVIDER", "google")
+	if got := strings.Join(placesProviderNames("", NewMapsBudgetFromEnv()), ","); got != "google,osm" {
+		t.Errorf("with a budget: got %s, want google,osm", got)
+	}
+	t.Setenv("MAPS_BUDGET_MODE", "refuse")
+	if got := strings.Join(placesProviderNames("", NewMapsBudgetFromEnv()), ","); got != "google" {
+		t.Errorf("refuse mode: got %s, want google", got)
+	}
+}
+
+func TestNewPlacesFromEnv(t *testing.T) {
+	t.Setenv("PLACES_PROVIDER", "google,osm")
+	chain, ok := newPlacesFromEnv(&Analyzer{}, "").(placesChain)
+	if !ok || len(chain) != 2 {
+		t.Fatalf("expected a two-provider chain, got %#v", chain)
+	}
+	// Google without a client fails on its own so the chain can move on
+	if _, err := chain[0].SearchNearby(context.Background(), &maps.LatLng{}, "pharmacy", 1500); err == nil {
+		t.Error("google without a Maps client should fail")
+	}
+
+	t.Setenv("PLACES_PROVIDER", "osm")
+	if _, ok := newPlacesFromEnv(&Analyzer{}, "").(osmPlaces); !ok {
+		t.Error("a single provider should not be wrapped in a chain")
+	}
+}
+
+func TestPlacesChainFallsBack(t *testing.T) {
+	var tried []string
+	provider := func(name string, err error) namedPlaces {
+		return namedPlaces{name: name, PlacesProvider: PlacesSearchFunc(func(location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+			tried = append(tried, name)
+			if err != nil {
+				return nil, err
+			}
+			return []maps.PlacesSearchResult{{Name: name + " result"}}, nil
+		})}
+	}
+
+	chain := placesChain{provider("google", scraper.ErrMapsBudgetExceeded), provider("foursquare", nil), provider("osm", nil)}
+	results, err := chain.SearchNearby(context.Background(), &maps.LatLng{}, "pharmacy", 1500)
+	if err != nil || len(results) != 1 || results[0].Name != "foursquare result" {
+		t.Fatalf("got %+v, %v", results, err)
+	}
+	if strings.Join(tried, ",") != "google,foursquare" {
+		t.Errorf("tried %v, want google then foursquare", tried)
+	}
+
+	failing := placesChain{provider("google", errors.New("quota")), provider("foursquare", errors.New("down"))}
+	if _, err := failing.SearchNearby(context.Background(), &maps.LatLng{}, "pharmacy", 1500); err == nil || err.Error() != "down" {
+		t.Errorf("expected the last provider's error, got %v", err)
+	}
+}
+
+func TestFoursquarePlaces(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.Header.Get("Authorization") != "fsq-key" || q.Get("query") != "garda station" || q.Get("radius") != "5000" {
+			t.Errorf("unexpected request: %s %v", r.URL, r.Header)
+		}
+		w.Write([]byte(`{"results":[{"name":"Rathmines Garda Station","geocodes":{"main":{"latitude":53.3215,"longitude":-6.2655}}}]}`))
+	}))
+	defer srv.Close()
+
+	foursquare := foursquarePlaces{client: srv.Client(), endpoint: srv.URL, apiKey: "fsq-key"}
+	results, err := foursquare.SearchNearby(context.Background(), &maps.LatLng{Lat: 53.32, Lng: -6.26}, "police", 5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Name != "Rathmines Garda Station" || results[0].Geometry.Location.Lat != 53.3215 || results[0].Types[0] != "police" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+}
+
+func TestConcurrentPlacesSearch(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("Station %d", i)
+			// each analysis gets its own searcher; nothing global is swapped
+			places := PlacesSearchFunc(func(location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+				res := maps.PlacesSearchResult{Name: name, Types: []string{placeType}}
+				res.Geometry.Location = maps.LatLng{Lat: location.Lat + 0.001, Lng: location.Lng}
+				return []maps.PlacesSearchResult{res}, nil
+			})
+			property := &scraper.PropertyInfo{}
+			if err := (&Analyzer{Places: places}).FindPublicTransport(context.Background(), property); err != nil {
+				t.Errorf("findPublicTransport returned error: %v", err)
+				return
+			}
+			for _, poi := range property.QualityOfLife.PublicTransport {
+				if poi.Name != name {
+					t.Errorf("got %q from another goroutine's searcher, want %q", poi.Name, name)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestTracedPlacesSpanPerType(t *testing.T) {
+	spans := telemetrytest.Collector(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	places := tracedPlaces{mapsBackedPlaces{client: http.DefaultClient, url: srv.URL}}
+	location := &maps.LatLng{Lat: 53.34, Lng: -6.26}
+	places.SearchNearby(context.Background(), location, "pharmacy", 1500)
+	places.SearchNearby(context.Background(), location, "gym", 2000)
+
+	got := spans()
+	pharmacy, gym := telemetrytest.SpanNamed(got, "places pharmacy"), telemetrytest.SpanNamed(got, "places gym")
+	if pharmacy == nil || gym == nil {
+		t.Fatalf("missing place spans in %+v", got)
+	}
+	for _, a := range pharmacy.Attributes {
+		if a.Key == "places.results" && a.Value["intValue"] != "1" {
+			t.Errorf("places.results = %v, want 1", a.Value)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Quiet score: trânsito, vida noturna e rotas de avião ────────── */
+
+// O quietScore (1-100) é o contrário do que o walk score premia: morar em cima de uma
+// rua de pubs conta pontos lá e perde aqui. Parte de 100 e desconta a via mais
+// movimentada a até 200 m (pela classificação do OSM), os bares, pubs e boates a até
+// 300 m e a proximidade do prolongamento das pistas dos aeroportos, por onde os
+// aviões sobem e descem.
+
+// quietRoadPenalty é o desconto pela via mais movimentada perto, por highway=*
+var quietRoadPenalty = map[string]int{
+	"motorway":  40,
+	"trunk":     40,
+	"primary":   30,
+	"secondary": 20,
+	"tertiary":  10,
+}
+
+// runway é uma pista de aeroporto pelo centro, o rumo verdadeiro e o comprimento
+type runway struct {
+	name         string
+	lat, lng     float64
+	heading      float64 // graus
+	lengthMeters float64
+}
+
+// irishRunways são as pistas dos aeroportos com voos regulares para perto de onde o
+// daft anuncia; as coordenadas são aproximadas, o que basta para uma faixa de 1 km
+var irishRunways = []runway{
+	{"Dublin Airport runway 10L/28R", 53.4206, -6.2690, 97, 2637},
+	{"Dublin Airport runway 10R/28L", 53.4360, -6.2860, 97, 3110},
+	{"Dublin Airport runway 16/34", 53.4240, -6.2580, 161, 2072},
+	{"Cork Airport runway 16/34", 51.8413, -8.4911, 163, 2133},
+	{"Shannon Airport runway 06/24", 52.7020, -8.9248, 56, 3199},
+}
+
+const (
+	flightPathWidth  = 1000.0  // metros de cada lado do eixo da pista
+	flightPathLength = 15000.0 // metros depois da cabeceira
+	flightPathLoud   = 4000.0  // até aqui o desconto é inteiro
+)
+
+// analyzeQuiet busca as vias e a vida noturna em volta do imóvel e calcula o quietScore
+func (a *Analyzer) analyzeQuiet(ctx context.Context, property *scraper.PropertyInfo) (err error) {
+	ctx, s := telemetry.StartSpan(ctx, "overpass quiet", telemetry.SpanInternal)
+	defer func() { s.End(err) }()
+
+	lat, lng := property.Coordinates.Lat, property.Coordinates.Lng
+	query := fmt.Sprintf(`[out:json];`+
+		`way["highway"~"^(motorway|trunk|primary|secondary|tertiary)(_link)?$"](around:200,%[1]f,%[2]f);out tags;`+
+		`nwr["amenity"~"^(bar|pub|nightclub)$"](around:300,%[1]f,%[2]f);out tags;`, lat, lng)
+
+	overpassCtx, cancel := a.WithStageTimeout(ctx, "overpass")
+	elements, err := a.Overpass.query(overpassCtx, query)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	info := &scraper.QuietInfo{MajorRoads: []string{}}
+	seen := map[string]bool{}
+	for _, el := range elements {
+		if el.Tags["amenity"] != "" {
+			info.Nightlife++
+			if el.Tags["amenity"] == "nightclub" {
+				info.Nightlife++ // boate conta em dobro: abre até mais tarde
+			}
+			continue
+		}
+		class := roadClass(el.Tags["highway"])
+		if quietRoadPenalty[class] > quietRoadPenalty[info.BusiestRoad] {
+			info.BusiestRoad = class
+		}
+		name := el.Tags["ref"]
+		if name == "" {
+			name = el.Tags["name"]
+		}
+		if name != "" && !seen[name] {
+			seen[name] = true
+			info.MajorRoads = append(info.MajorRoads, name)
+		}
+	}
+	sort.Strings(info.MajorRoads)
+
+	path, flightPenalty := nearestFlightPath(lat, lng)
+	info.FlightPath = path
+
+	property.QualityOfLife.Quiet = info
+	property.QualityOfLife.QuietScore = quietScore(info, flightPenalty)
+	return nil
+}
+
+// roadClass tira o sufixo _link (acessos) da classificação da via
+func roadClass(highway string) string {
+	return strings.TrimSuffix(highway, "_link")
+}
+
+// nearestFlightPath diz a pista cujo prolongamento passa a até flightPathWidth do
+// ponto e o desconto (até 30), que cai em linha reta de flightPathLoud até
+// flightPathLength depois da cabeceira
+func nearestFlightPath(lat, lng float64) (string, int) {
+	name, penalty := "", 0
+	for _, r := range irishRunways {
+		x := (lng - r.lng) * 111320 * math.Cos(r.lat*math.Pi/180)
+		y := (lat - r.lat) * 111320
+		h := r.heading * math.Pi / 180
+		along := math.Abs(x*math.Sin(h) + y*math.Cos(h))
+		lateral := math.Abs(x*math.Cos(h) - y*math.Sin(h))
+		beyond := along - r.lengthMeters/2
+		if lateral > flightPathWidth || beyond > flightPathLength {
+			continue
+		}
+		p := 30
+		if beyond > flightPathLoud {
+			p = int(math.Round(30 * (flightPathLength - beyond) / (flightPathLength - flightPathLoud)))
+		}
+		if p > penalty {
+			name, penalty = r.name, p
+		}
+	}
+	return name, penalty
+}
+
+// quietScore desconta de 100 a via mais movimentada, 5 pontos por lugar de vida
+// noturna (até 30) e a rota de avião
+func quietScore(info *scraper.QuietInfo, flightPenalty int) int {
+	score := 100 - quietRoadPenalty[info.BusiestRoad] - min(5*info.Nightlife, 30) - flightPenalty
+	return min(max(score, 1), 100)
+}
@@ -0,0 +1,58 @@
+package enrich
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestNearestFlightPath(t *testing.T) {
+	// Santry sits under the approach to Dublin's 16/34, about 3 km south of the runway
+	if name, penalty := nearestFlightPath(53.3975, -6.2475); !strings.Contains(name, "16/34") || penalty != 30 {
+		t.Errorf("Santry: %q, %d", name, penalty)
+	}
+	// Rathmines is well away from every runway
+	if name, penalty := nearestFlightPath(53.3220, -6.2650); name != "" || penalty != 0 {
+		t.Errorf("Rathmines: %q, %d", name, penalty)
+	}
+}
+
+func TestQuietScore(t *testing.T) {
+	if got := quietScore(&scraper.QuietInfo{}, 0); got != 100 {
+		t.Errorf("nothing nearby = %d, want 100", got)
+	}
+	pubStrip := &scraper.QuietInfo{BusiestRoad: "primary", Nightlife: 9}
+	if got := quietScore(pubStrip, 0); got != 40 {
+		t.Errorf("pub strip on a primary road = %d, want 40", got)
+	}
+	if got := quietScore(&scraper.QuietInfo{BusiestRoad: "motorway", Nightlife: 10}, 30); got != 1 {
+		t.Errorf("worst case = %d, want 1", got)
+	}
+}
+
+func TestAnalyzeQuiet(t *testing.T) {
+	overpass, query := fakeOverpass(t, `{"elements":[
+		{"type":"way","tags":{"highway":"secondary","name":"Rathmines Road Lower"}},
+		{"type":"way","tags":{"highway":"primary_link","ref":"R117"}},
+		{"type":"node","tags":{"amenity":"pub","name":"Slattery's"}},
+		{"type":"node","tags":{"amenity":"nightclub","name":"Club"}}]}`)
+	a := &Analyzer{Overpass: overpass}
+
+	p := scraper.PropertyInfo{}
+	p.Coordinates.Lat, p.Coordinates.Lng = 53.3220, -6.2650
+	if err := a.analyzeQuiet(context.Background(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(*query, "around:200") || !strings.Contains(*query, "nightclub") {
+		t.Errorf("query %s", *query)
+	}
+	info := p.QualityOfLife.Quiet
+	if info == nil || info.BusiestRoad != "primary" || info.Nightlife != 3 || len(info.MajorRoads) != 2 || info.FlightPath != "" {
+		t.Fatalf("quiet = %+v", info)
+	}
+	if p.QualityOfLife.QuietScore != 55 {
+		t.Errorf("score = %d, want 55", p.QualityOfLife.QuietScore)
+	}
+}
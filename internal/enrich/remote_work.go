@@ -0,0 +1,137 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+
+	"googlemaps.github.io/maps"
+)
+
+/* ───── Remote work score ───────────────────────────────────────────── */
+
+// O remoteWorkScore (1-100) diz se dá para trabalhar bem de casa e em volta dela:
+// internet (35 pontos, pelo que o anúncio diz, já que não há base aberta de cobertura
+// por endereço), cafés com Wi-Fi no OSM (20), coworkings na busca de lugares (25) e o
+// silêncio (20, menos 10 por fonte de barulho perto: bar, boate ou estação de trem).
+// Uma parte cuja fonte falhou fica fora da nota em vez de contar como zero.
+
+// broadbandKeywords são as palavras do anúncio que indicam internet, da melhor para a pior
+var broadbandKeywords = []struct {
+	kind     string
+	keywords []string
+}{
+	{"fibre", []string{"fibre", "fiber", "ftth", "gigabit"}},
+	{"broadband", []string{"broadband", "wifi", "wi-fi", "internet"}},
+}
+
+// broadbandFromDescription diz o tipo de internet que o anúncio menciona
+func broadbandFromDescription(description string) string {
+	lower := strings.ToLower(description)
+	for _, b := range broadbandKeywords {
+		for _, k := range b.keywords {
+			if strings.Contains(lower, k) {
+				return b.kind
+			}
+		}
+	}
+	return "unknown"
+}
+
+// noiseSources lista o barulho perto do imóvel, pelos mesmos limites da checklist de
+// visita: bar ou boate a até 200 m e estação de trem a até 300 m
+func noiseSources(p *scraper.PropertyInfo) []string {
+	sources := []string{}
+	for _, poi := range p.QualityOfLife.Entertainment {
+		if (poi.Type == "bar" || poi.Type == "night_club") && poi.Distance <= 0.2 {
+			sources = append(sources, fmt.Sprintf("%s (%s) %d m away", poi.Name, poi.Type, poi.DistanceMeters))
+		}
+	}
+	for _, poi := range p.QualityOfLife.PublicTransport {
+		if poi.Type == "train_station" && poi.Distance <= 0.3 {
+			sources = append(sources, fmt.Sprintf("%s (train) %d m away", poi.Name, poi.DistanceMeters))
+		}
+	}
+	return sources
+}
+
+// analyzeRemoteWork monta RemoteWorkInfo e o remoteWorkScore; usa os lugares já
+// encontrados, então roda depois de transporte e entretenimento
+func (a *Analyzer) analyzeRemoteWork(ctx context.Context, property *scraper.PropertyInfo) {
+	radius := a.Radius("remote_work")
+	info := &scraper.RemoteWorkInfo{
+		Broadband:    broadbandFromDescription(property.Description),
+		NoiseSources: noiseSources(property),
+		RadiusMeters: int(radius),
+	}
+	lat, lng := property.Coordinates.Lat, property.Coordinates.Lng
+
+	overpassCtx, cancel := a.WithStageTimeout(ctx, "overpass")
+	elements, err := a.Overpass.query(overpassCtx, fmt.Sprintf(`[out:json];`+
+		`nwr["amenity"="cafe"]["internet_access"~"^(yes|wlan|wifi)$"](around:%d,%f,%f);out count;`, radius, lat, lng))
+	cancel()
+	if err != nil {
+		telemetry.LogFor(ctx).Warn("Wi-Fi cafe lookup failed", "error", err)
+	} else {
+		n := 0
+		for _, el := range elements {
+			if el.Type == "count" {
+				n = overpassCount(el.Tags)
+			}
+		}
+		info.WifiCafes = &n
+	}
+
+	placesCtx, cancel := a.WithStageTimeout(ctx, "places")
+	location := &maps.LatLng{Lat: lat, Lng: lng}
+	results, err := a.Places.SearchNearby(placesCtx, location, "coworking_space", radius)
+	cancel()
+	coworkingKnown := err == nil
+	if err != nil {
+		telemetry.LogFor(ctx).Warn("Coworking search failed", "error", err)
+	}
+	info.Coworking = a.TidyPOIs(PlacesToPOIs(location, results, "coworking_space"))
+
+	property.QualityOfLife.RemoteWork = info
+	property.QualityOfLife.RemoteWorkScore = remoteWorkScore(info, coworkingKnown)
+}
+
+// remoteWorkScore dá a nota (1-100); coworkingKnown diz se a busca de coworkings
+// funcionou. Um coworking a até 1 km vale os 25 pontos; mais longe, metade.
+func remoteWorkScore(info *scraper.RemoteWorkInfo, coworkingKnown bool) int {
+	var points, possible float64
+
+	possible += 35
+	switch info.Broadband {
+	case "fibre":
+		points += 35
+	case "broadband":
+		points += 20
+	default:
+		points += 10 // não dito não é ausente; só não dá para contar com ela
+	}
+
+	if info.WifiCafes != nil {
+		possible += 20
+		points += 20 * math.Min(float64(*info.WifiCafes)/3, 1)
+	}
+
+	if coworkingKnown {
+		possible += 25
+		if len(info.Coworking) > 0 {
+			points += 12.5
+			if info.Coworking[0].Distance <= 1 {
+				points += 12.5
+			}
+		}
+	}
+
+	possible += 20
+	points += math.Max(0, 20-10*float64(len(info.NoiseSources)))
+
+	return min(max(int(math.Round(points/possible*100)), 1), 100)
+}
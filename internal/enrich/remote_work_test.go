@@ -0,0 +1,73 @@
+package enrich
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+
+	"googlemaps.github.io/maps"
+)
+
+func TestBroadbandFromDescription(t *testing.T) {
+	cases := map[string]string{
+		"Fibre broadband available, gas heating": "fibre",
+		"Includes Wi-Fi and bins":                "broadband",
+		"Bright two bed with balcony":            "unknown",
+	}
+	for description, want := range cases {
+		if got := broadbandFromDescription(description); got != want {
+			t.Errorf("%q: got %s, want %s", description, got, want)
+		}
+	}
+}
+
+func TestRemoteWorkScore(t *testing.T) {
+	three := 3
+	best := &scraper.RemoteWorkInfo{Broadband: "fibre", WifiCafes: &three, Coworking: []scraper.POI{scraper.NewPOI("Dogpatch Labs", "coworking_space", 0.6, 0, 0)}}
+	if got := remoteWorkScore(best, true); got != 100 {
+		t.Errorf("fibre, cafés and a coworking nearby = %d, want 100", got)
+	}
+
+	noisy := *best
+	noisy.NoiseSources = []string{"The Temple Bar (bar) 80 m away", "Tara Street (train) 150 m away"}
+	if got := remoteWorkScore(&noisy, true); got != 80 {
+		t.Errorf("two noise sources = %d, want 80", got)
+	}
+
+	// failed lookups leave their part out instead of counting as nothing
+	unknown := &scraper.RemoteWorkInfo{Broadband: "fibre"}
+	if got := remoteWorkScore(unknown, false); got != 100 {
+		t.Errorf("only broadband and quiet known = %d, want 100", got)
+	}
+}
+
+func TestAnalyzeRemoteWork(t *testing.T) {
+	overpass, query := fakeOverpass(t, `{"elements":[{"type":"count","tags":{"nodes":"2","total":"2"}}]}`)
+	var searched string
+	places := PlacesSearchFunc(func(location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+		searched = placeType
+		place := maps.PlacesSearchResult{Name: "Huckletree", Types: []string{"coworking_space"}}
+		place.Geometry.Location = maps.LatLng{Lat: location.Lat + 0.005, Lng: location.Lng}
+		return []maps.PlacesSearchResult{place}, nil
+	})
+	a := &Analyzer{Overpass: overpass, Places: places}
+
+	p := scraper.PropertyInfo{Description: "Fibre broadband. Quiet street."}
+	p.Coordinates.Lat, p.Coordinates.Lng = 53.34, -6.26
+	p.QualityOfLife.Entertainment = []scraper.POI{scraper.NewPOI("Whelan's", "bar", 0.15, 0, 0)}
+	a.analyzeRemoteWork(context.Background(), &p)
+
+	info := p.QualityOfLife.RemoteWork
+	if !strings.Contains(*query, `"internet_access"`) || searched != "coworking_space" {
+		t.Errorf("query %s, searched %q", *query, searched)
+	}
+	if info == nil || info.Broadband != "fibre" || info.WifiCafes == nil || *info.WifiCafes != 2 ||
+		len(info.Coworking) != 1 || len(info.NoiseSources) != 1 {
+		t.Fatalf("remote work = %+v", info)
+	}
+	if p.QualityOfLife.RemoteWorkScore != remoteWorkScore(info, true) {
+		t.Errorf("score = %d", p.QualityOfLife.RemoteWorkScore)
+	}
+}
@@ -0,0 +1,120 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"daft-scraper-api/internal/scraper"
+
+	"googlemaps.github.io/maps"
+)
+
+/* ───── Reputação da agência no Google ──────────────────────────────── */
+
+// Inquilinos querem fugir de agências com fama ruim. Com o nome (e a filial) do
+// anunciante, procuramos a agência no Google Places perto do imóvel e trazemos a nota
+// e o número de avaliações. Só para agências: proprietário pessoa física não tem
+// página no Google, e uma busca pelo nome dele acharia outra pessoa.
+
+// ReviewsProvider acha uma empresa pelo nome perto de um ponto (near pode ser nil);
+// nil, nil quando não acha. googleReviews é a implementação; deve ser segura para
+// uso concorrente.
+type ReviewsProvider interface {
+	FindBusiness(ctx context.Context, query string, near *maps.LatLng) (*maps.PlacesSearchResult, error)
+}
+
+// googleReviews usa a Text Search da Places API
+type googleReviews struct {
+	client *maps.Client
+}
+
+func (g googleReviews) FindBusiness(ctx context.Context, query string, near *maps.LatLng) (*maps.PlacesSearchResult, error) {
+	r := &maps.TextSearchRequest{Query: query, Language: "en"}
+	if near != nil {
+		r.Location, r.Radius = near, reputationRadius
+	}
+	resp, err := g.client.TextSearch(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("error searching for the agency: %w", err)
+	}
+	if len(resp.Results) == 0 {
+		return nil, nil
+	}
+	return &resp.Results[0], nil
+}
+
+// reviewsSearchFunc adapta uma função comum a ReviewsProvider (usado nos testes)
+type reviewsSearchFunc func(query string, near *maps.LatLng) (*maps.PlacesSearchResult, error)
+
+func (f reviewsSearchFunc) FindBusiness(ctx context.Context, query string, near *maps.LatLng) (*maps.PlacesSearchResult, error) {
+	return f(query, near)
+}
+
+const (
+	reputationRadius     = 25000 // metros em volta do imóvel onde procurar a filial
+	reputationMinReviews = 10    // abaixo disso a nota diz pouco
+	reputationPoor       = 3.0
+	reputationGood       = 4.0
+)
+
+// advertiserReputation procura a agência do anúncio; nil sem Reviews configurado, para
+// proprietários ou quando o Google não acha uma empresa com o mesmo nome
+func (a *Analyzer) advertiserReputation(ctx context.Context, property *scraper.PropertyInfo) (*scraper.AdvertiserReputation, error) {
+	adv := property.Advertiser
+	if a.Reviews == nil || adv == nil || adv.Type != "agent" || adv.Name == "" {
+		return nil, nil
+	}
+	ctx, cancel := a.WithStageTimeout(WithMapsUsage(ctx, property.Usage()), "places")
+	defer cancel()
+
+	query := adv.Name
+	switch {
+	case adv.Branch != "" && strings.Contains(strings.ToLower(adv.Branch), strings.ToLower(adv.Name)):
+		query = adv.Branch
+	case adv.Branch != "":
+		query = adv.Name + " " + adv.Branch
+	}
+	var near *maps.LatLng
+	if c := property.Coordinates; c.Lat != 0 || c.Lng != 0 {
+		near = &maps.LatLng{Lat: c.Lat, Lng: c.Lng}
+	}
+
+	place, err := a.Reviews.FindBusiness(ctx, query+" estate agent", near)
+	if err != nil || place == nil || !sameBusiness(adv.Name, place.Name) {
+		return nil, err
+	}
+	r := &scraper.AdvertiserReputation{
+		PlaceName:    place.Name,
+		Address:      place.FormattedAddress,
+		Rating:       place.Rating,
+		ReviewsCount: place.UserRatingsTotal,
+		Source:       "google_places",
+	}
+	switch {
+	case r.ReviewsCount < reputationMinReviews:
+		r.Verdict = "too_few_reviews"
+	case r.Rating < reputationPoor:
+		r.Verdict = "poor"
+	case r.Rating < reputationGood:
+		r.Verdict = "mixed"
+	default:
+		r.Verdict = "good"
+	}
+	return r, nil
+}
+
+// sameBusiness confere se o lugar achado tem a primeira palavra do nome da agência, para
+// não atribuir a ela a nota de outra empresa da vizinhança
+func sameBusiness(agency, place string) bool {
+	want := scraper.Tokenize(agency)
+	if len(want) == 0 {
+		return false
+	}
+	for _, t := range scraper.Tokenize(place) {
+		if t == want[0] {
+			return true
+		}
+	}
+	return false
+}
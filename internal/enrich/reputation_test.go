@@ -0,0 +1,88 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+
+	"googlemaps.github.io/maps"
+)
+
+func TestAdvertiserReputation(t *testing.T) {
+	var gotQuery string
+	var gotNear *maps.LatLng
+	a := &Analyzer{Reviews: reviewsSearchFunc(func(query string, near *maps.LatLng) (*maps.PlacesSearchResult, error) {
+		gotQuery, gotNear = query, near
+		return &maps.PlacesSearchResult{Name: "Sherry FitzGerald Bray", Rating: 2.4, UserRatingsTotal: 85}, nil
+	})}
+	p := &scraper.PropertyInfo{Advertiser: &scraper.Advertiser{Name: "Sherry FitzGerald", Branch: "Sherry FitzGerald Bray", Type: "agent"}}
+	p.Coordinates.Lat, p.Coordinates.Lng = 53.2, -6.1
+
+	r, err := a.advertiserReputation(context.Background(), p)
+	if err != nil || r == nil || r.Verdict != "poor" || r.ReviewsCount != 85 {
+		t.Fatalf("reputation = %+v, %v", r, err)
+	}
+	if gotQuery != "Sherry FitzGerald Bray estate agent" || gotNear == nil || gotNear.Lat != 53.2 {
+		t.Errorf("searched %q near %v", gotQuery, gotNear)
+	}
+	p.AdvertiserReputation = r
+	if s := (&Analyzer{}).SummarizeProperty(p); len(s.Cons) == 0 {
+		t.Error("a poorly reviewed agency should be a con")
+	}
+}
+
+func TestAdvertiserReputationSkips(t *testing.T) {
+	calls := 0
+	a := &Analyzer{Reviews: reviewsSearchFunc(func(query string, near *maps.LatLng) (*maps.PlacesSearchResult, error) {
+		calls++
+		return &maps.PlacesSearchResult{Name: "Corner Shop", Rating: 4.8, UserRatingsTotal: 300}, nil
+	})}
+
+	// private landlords are never looked up
+	private := &scraper.PropertyInfo{Advertiser: &scraper.Advertiser{Name: "Mary", Type: "private"}}
+	if r, _ := a.advertiserReputation(context.Background(), private); r != nil || calls != 0 {
+		t.Errorf("private landlord: %+v after %d calls", r, calls)
+	}
+
+	// another business's rating is not the agency's
+	agency := &scraper.PropertyInfo{Advertiser: &scraper.Advertiser{Name: "Hooke & MacDonald", Type: "agent"}}
+	if r, _ := a.advertiserReputation(context.Background(), agency); r != nil {
+		t.Errorf("mismatched place should be dropped, got %+v", r)
+	}
+
+	// without Google Maps there is nothing to look up
+	if r, err := (&Analyzer{}).advertiserReputation(context.Background(), agency); r != nil || err != nil {
+		t.Errorf("no provider: %+v, %v", r, err)
+	}
+
+	failing := &Analyzer{Reviews: reviewsSearchFunc(func(string, *maps.LatLng) (*maps.PlacesSearchResult, error) {
+		return nil, errors.New("quota")
+	})}
+	if _, err := failing.advertiserReputation(context.Background(), agency); err == nil {
+		t.Error("expected the provider error")
+	}
+}
+
+func TestReputationVerdicts(t *testing.T) {
+	cases := []struct {
+		rating  float32
+		reviews int
+		want    string
+	}{
+		{4.6, 120, "good"},
+		{3.5, 40, "mixed"},
+		{1.9, 60, "poor"},
+		{1.0, 3, "too_few_reviews"},
+	}
+	for _, c := range cases {
+		a := &Analyzer{Reviews: reviewsSearchFunc(func(string, *maps.LatLng) (*maps.PlacesSearchResult, error) {
+			return &maps.PlacesSearchResult{Name: "DNG Lettings", Rating: c.rating, UserRatingsTotal: c.reviews}, nil
+		})}
+		p := &scraper.PropertyInfo{Advertiser: &scraper.Advertiser{Name: "DNG", Type: "agent"}}
+		if r, _ := a.advertiserReputation(context.Background(), p); r == nil || r.Verdict != c.want {
+			t.Errorf("%.1f from %d reviews = %+v, want %s", c.rating, c.reviews, r, c.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Registro da locação no RTB ──────────────────────────────────── */
+
+// Todo aluguel na Irlanda tem de ser registrado no RTB (Residential Tenancies Board),
+// e o registro público pode ser consultado por endereço. Um quarto anunciado num
+// imóvel sem nenhuma locação registrada é um sinal a mais de golpe ou de locador
+// irregular. O RTB não tem API pública: RTB_REGISTER_URL aponta para um serviço que
+// consulta o registro e devolve JSON ({"tenancies":[{"address":...,"eircode":...}]}),
+// chamado com ?eircode= ou ?address=. Sem a URL o resultado é "unavailable".
+
+// rtbClient consulta o registro; só lê os campos, é seguro para uso concorrente
+type rtbClient struct {
+	client   *http.Client
+	endpoint string        // vazio = consulta indisponível
+	timeout  time.Duration // UPSTREAM_TIMEOUT; 0 = scraper.StageTimeouts["upstream"]
+}
+
+// rtbLookupResp é a resposta do serviço de consulta
+type rtbLookupResp struct {
+	Tenancies []struct {
+		Address string `json:"address"`
+		Eircode string `json:"eircode"`
+	} `json:"tenancies"`
+}
+
+// registration consulta o endereço do anúncio pelo Eircode ou, sem ele, pelo endereço
+// com número; o erro vem junto com o status unavailable
+func (c rtbClient) registration(ctx context.Context, address string) (*scraper.TenancyRegistration, error) {
+	if c.endpoint == "" {
+		return &scraper.TenancyRegistration{Status: "unavailable", Note: "RTB register lookup is not configured"}, nil
+	}
+	r := &scraper.TenancyRegistration{}
+	q := url.Values{}
+	eircode := normalizeEircode(eircodeInText.FindString(address))
+	switch {
+	case eircode != "":
+		r.LookupBy = "eircode"
+		q.Set("eircode", eircode)
+	case scraper.RelistAddressKey(address) != "":
+		r.LookupBy = "address"
+		q.Set("address", address)
+	default:
+		// sem número nem Eircode, qualquer locação da rua "casaria"
+		return &scraper.TenancyRegistration{Status: "unavailable", Note: "The address has no house number or Eircode to look up"}, nil
+	}
+
+	timeout := c.timeout
+	if timeout == 0 {
+		timeout = scraper.StageTimeouts["upstream"]
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return &scraper.TenancyRegistration{Status: "unavailable", Note: "RTB register lookup failed"}, fmt.Errorf("error querying the RTB register: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &scraper.TenancyRegistration{Status: "unavailable", Note: "RTB register lookup failed"}, fmt.Errorf("RTB register returned status code: %d", resp.StatusCode)
+	}
+	var result rtbLookupResp
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return &scraper.TenancyRegistration{Status: "unavailable", Note: "RTB register lookup failed"}, fmt.Errorf("error decoding the RTB register: %w", err)
+	}
+
+	// pelo endereço, o serviço pode devolver vizinhos: vale só o mesmo endereço
+	key := scraper.AddressKey(address)
+	for _, t := range result.Tenancies {
+		if r.LookupBy == "eircode" && normalizeEircode(t.Eircode) == eircode ||
+			r.LookupBy == "address" && scraper.AddressKey(t.Address) == key {
+			r.Tenancies++
+			if r.MatchedAddress == "" {
+				r.MatchedAddress = t.Address
+			}
+		}
+	}
+	if r.Tenancies > 0 {
+		r.Status = "registered"
+	} else {
+		r.Status = "not_found"
+		r.Note = "No registered tenancy found; new tenancies are registered after they start, so ask the landlord for the registration number"
+	}
+	return r, nil
+}
+
+// normalizeEircode tira espaços e passa para maiúsculas ("d06 x2y3" → "D06X2Y3")
+func normalizeEircode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(code, " ", ""))
+}
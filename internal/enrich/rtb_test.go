@@ -0,0 +1,55 @@
+package enrich
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRTBRegistration(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		switch {
+		case r.URL.Query().Get("eircode") == "D06X2Y3":
+			w.Write([]byte(`{"tenancies":[{"address":"Flat 2, 14 Leinster Road, Rathmines","eircode":"D06 X2Y3"}]}`))
+		default:
+			// a neighbour, not the listed address
+			w.Write([]byte(`{"tenancies":[{"address":"16 Main Street, Bray"}]}`))
+		}
+	}))
+	defer srv.Close()
+	c := rtbClient{client: srv.Client(), endpoint: srv.URL}
+
+	r, err := c.registration(context.Background(), "Flat 2, 14 Leinster Road, Rathmines, Dublin 6, D06 X2Y3")
+	if err != nil || r.Status != "registered" || r.LookupBy != "eircode" || r.Tenancies != 1 {
+		t.Errorf("by eircode = %+v, %v", r, err)
+	}
+
+	r, err = c.registration(context.Background(), "12 Main Street, Bray, Co. Wicklow")
+	if err != nil || r.Status != "not_found" || r.LookupBy != "address" {
+		t.Errorf("by address = %+v, %v", r, err)
+	}
+
+	// too vague to look up, so no request is made
+	before := len(queries)
+	if r, _ := c.registration(context.Background(), "Main Street, Bray"); r.Status != "unavailable" || len(queries) != before {
+		t.Errorf("vague address = %+v after %d queries", r, len(queries)-before)
+	}
+}
+
+func TestRTBRegistrationUnavailable(t *testing.T) {
+	if r, err := (rtbClient{}).registration(context.Background(), "12 Main Street, Bray"); err != nil || r.Status != "unavailable" {
+		t.Errorf("without an endpoint: %+v, %v", r, err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	r, err := (rtbClient{client: srv.Client(), endpoint: srv.URL}).registration(context.Background(), "12 Main Street, Bray")
+	if err == nil || r == nil || r.Status != "unavailable" {
+		t.Errorf("failing register: %+v, %v", r, err)
+	}
+}
@@ -0,0 +1,294 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/safety"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+
+	"googlemaps.github.io/maps"
+)
+
+/* ───── Análise de segurança ────────────────────────────────────────── */
+
+// litRoadTypes são as vias (highway=*) que entram no comprimento de rua; ficam de
+// fora autoestradas, trilhas e calçadas, que raramente têm postes próprios
+var litRoadTypes = []string{"trunk", "primary", "secondary", "tertiary", "unclassified", "residential", "living_street", "pedestrian", "service"}
+
+// minLitRoadMeters é o mínimo de rua medida para a densidade valer; abaixo disso
+// (área rural, ou ruas que o OSM não tem) a nota usa só o total de postes
+const minLitRoadMeters = 200
+
+// overpassCount lê o total de nós de um elemento de "out count"
+func overpassCount(tags map[string]string) int {
+	for _, key := range []string{"nodes", "total"} {
+		if n, err := strconv.Atoi(tags[key]); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// roadLengthWithin soma, em metros, os trechos da via cujo ponto médio fica a até
+// radius metros de (lat, lng)
+func roadLengthWithin(way overpassElement, lat, lng, radius float64) float64 {
+	total := 0.0
+	for i := 1; i < len(way.Geometry); i++ {
+		a, b := way.Geometry[i-1], way.Geometry[i]
+		if scraper.CalculateDistance(lat, lng, (a.Lat+b.Lat)/2, (a.Lon+b.Lon)/2)*1000 <= radius {
+			total += scraper.CalculateDistance(a.Lat, a.Lon, b.Lat, b.Lon) * 1000
+		}
+	}
+	return total
+}
+
+// lightingRating dá a nota (1-10) pela densidade de postes ou, sem ela, pelo total.
+// Numa rua urbana bem iluminada há um poste a cada 30-40 m (25-30 por km).
+func lightingRating(count int, perKm float64) int {
+	if perKm > 0 {
+		switch {
+		case perKm >= 25:
+			return 10
+		case perKm >= 15:
+			return 8
+		case perKm >= 8:
+			return 6
+		}
+		return 4
+	}
+	switch {
+	case count > 50:
+		return 10
+	case count > 20:
+		return 8
+	case count > 10:
+		return 6
+	}
+	return 4
+}
+
+// Obter informações de segurança
+func (a *Analyzer) getSafetyInfo(ctx context.Context, property *scraper.PropertyInfo) error {
+	analysis := scraper.AnalysisResponse{Property: *property}
+
+	if err := a.findNearbyGardai(ctx, &analysis); err != nil {
+		if !errors.Is(err, scraper.ErrMapsBudgetExceeded) {
+			return scraper.ModuleError(err, "PLACES_FAILED", "safety")
+		}
+		// sem orçamento do Maps a segurança segue só com Overpass e CSO
+		property.Warnings = append(property.Warnings, scraper.ModuleError(err, "PLACES_FAILED", "safety"))
+	}
+	if err := a.analyzeStreetLighting(ctx, &analysis); err != nil {
+		return scraper.ModuleError(err, "OVERPASS_FAILED", "safety")
+	}
+	if err := a.getCrimeStats(ctx, &analysis); err != nil {
+		return scraper.ModuleError(err, "CSO_UNAVAILABLE", "safety")
+	}
+	if err := a.analyzeRoadSafety(ctx, &analysis); err != nil {
+		// as colisões são um complemento: sem elas a nota de segurança continua valendo
+		property.Warnings = append(property.Warnings, scraper.ModuleError(err, "RSA_UNAVAILABLE", "safety"))
+	}
+
+	safety.Score(&analysis.SafetyInfo)
+
+	property.SafetyInfo.CrimeRate = analysis.SafetyInfo.CrimeStats.PerCapita
+	property.SafetyInfo.CrimeEstimated = analysis.SafetyInfo.CrimeStats.Estimated
+	property.SafetyInfo.CrimeEstimate = analysis.SafetyInfo.CrimeStats.EstimateReason
+	property.SafetyInfo.CrimeGranularity = analysis.SafetyInfo.CrimeStats.Granularity
+	property.SafetyInfo.CrimeArea = analysis.SafetyInfo.CrimeStats.District
+	if property.SafetyInfo.CrimeArea == "" {
+		property.SafetyInfo.CrimeArea = analysis.SafetyInfo.CrimeStats.Division
+	}
+	property.SafetyInfo.SafetyRating = analysis.SafetyInfo.SafetyScore / 10
+	property.SafetyInfo.StreetLighting = analysis.SafetyInfo.StreetLighting.Description
+	property.SafetyInfo.StreetLamps = analysis.SafetyInfo.StreetLighting.LampCount
+	property.SafetyInfo.LampsPerKm = analysis.SafetyInfo.StreetLighting.LampsPerKm
+	property.SafetyInfo.RoadSafety = analysis.SafetyInfo.RoadSafety
+	property.SafetyInfo.ScoreFactors = append(append([]safety.SafetyFactor{}, analysis.SafetyInfo.SafetyFactors...), analysis.SafetyInfo.RiskFactors...)
+	property.SafetyInfo.ScoreInputs = &analysis.SafetyInfo.ScoreInputs
+	for _, g := range analysis.SafetyInfo.NearbyGardai {
+		property.SafetyInfo.NearbyGardai = append(property.SafetyInfo.NearbyGardai,
+			scraper.NewPOI(g.Name, "garda_station", g.Distance, g.Lat, g.Lng))
+	}
+	property.SafetyInfo.NearbyGardai = a.TidyPOIs(property.SafetyInfo.NearbyGardai)
+
+	return nil
+}
+
+// AnalyzeSafety analisa a segurança da região
+func (a *Analyzer) AnalyzeSafety(ctx context.Context, analysis *scraper.AnalysisResponse) error {
+	// 1. Buscar delegacias próximas usando Google Places API
+	if err := a.findNearbyGardai(ctx, analysis); err != nil {
+		return fmt.Errorf("error finding nearby Gardai: %w", err)
+	}
+
+	// 2. Analisar iluminação pública usando dados do OpenStreetMap
+	if err := a.analyzeStreetLighting(ctx, analysis); err != nil {
+		return fmt.Errorf("error analyzing street lighting: %w", err)
+	}
+
+	// 3. Obter estatísticas de crime da região
+	if err := a.getCrimeStats(ctx, analysis); err != nil {
+		return fmt.Errorf("error getting crime stats: %w", err)
+	}
+
+	// 4. Contar colisões com pedestres e ciclistas; a falha não derruba a análise
+	if err := a.analyzeRoadSafety(ctx, analysis); err != nil {
+		telemetry.LogFor(ctx).Warn("Road safety failed", "error", err)
+	}
+
+	// 5. Calcular score de segurança
+	safety.Score(&analysis.SafetyInfo)
+
+	return nil
+}
+
+// findNearbyGardai encontra delegacias próximas pelo provedor de lugares configurado
+func (a *Analyzer) findNearbyGardai(ctx context.Context, analysis *scraper.AnalysisResponse) error {
+	ctx, cancel := a.WithStageTimeout(WithMapsUsage(ctx, analysis.Property.Usage()), "places")
+	defer cancel()
+
+	location := &maps.LatLng{
+		Lat: analysis.Property.Coordinates.Lat,
+		Lng: analysis.Property.Coordinates.Lng,
+	}
+
+	results, err := a.Places.SearchNearby(ctx, location, "police", a.Radius("gardai"))
+	if err != nil {
+		return err
+	}
+
+	for _, place := range results {
+		dist := scraper.CalculateDistance(location.Lat, location.Lng, place.Geometry.Location.Lat, place.Geometry.Location.Lng)
+		station := struct {
+			Name           string  `json:"name"`
+			DistanceMeters int     `json:"distanceMeters"`
+			Distance       float64 `json:"distance"` // em km; legado, use distanceMeters
+			Phone          string  `json:"phone,omitempty"`
+			Lat            float64 `json:"lat,omitempty"`
+			Lng            float64 `json:"lng,omitempty"`
+		}{
+			Name:           place.Name,
+			DistanceMeters: int(math.Round(dist * 1000)),
+			Distance:       dist,
+			Lat:            place.Geometry.Location.Lat,
+			Lng:            place.Geometry.Location.Lng,
+		}
+		analysis.SafetyInfo.NearbyGardai = append(analysis.SafetyInfo.NearbyGardai, station)
+	}
+
+	return nil
+}
+
+// analyzeStreetLighting analisa a iluminação pública usando OpenStreetMap: conta os
+// postes no raio e mede as ruas do mesmo raio, para que a nota venha da densidade
+// (postes por km de rua) e não só do total, que depende de quanta rua há em volta
+func (a *Analyzer) analyzeStreetLighting(ctx context.Context, analysis *scraper.AnalysisResponse) (err error) {
+	ctx, s := telemetry.StartSpan(ctx, "overpass street lighting", telemetry.SpanInternal)
+	defer func() { s.End(err) }()
+
+	radius := a.Radius("lighting")
+	lat, lng := analysis.Property.Coordinates.Lat, analysis.Property.Coordinates.Lng
+	query := fmt.Sprintf(`[out:json];node["highway"="street_lamp"](around:%[1]d,%[2]f,%[3]f);out count;`+
+		`way["highway"~"^(%[4]s)$"](around:%[1]d,%[2]f,%[3]f);out geom;`,
+		radius, lat, lng, strings.Join(litRoadTypes, "|"))
+
+	ctx, cancel := a.WithStageTimeout(ctx, "overpass")
+	defer cancel()
+	elements, err := a.Overpass.query(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	count, roadMeters := 0, 0.0
+	for _, el := range elements {
+		switch el.Type {
+		case "count":
+			count = overpassCount(el.Tags)
+		case "way":
+			roadMeters += roadLengthWithin(el, lat, lng, float64(radius))
+		}
+	}
+
+	lighting := &analysis.SafetyInfo.StreetLighting
+	lighting.LampCount = count
+	lighting.RoadLengthMeters = int(math.Round(roadMeters))
+	if roadMeters >= minLitRoadMeters {
+		lighting.LampsPerKm = math.Round(float64(count)/(roadMeters/1000)*10) / 10
+	}
+	lighting.Rating = lightingRating(count, lighting.LampsPerKm)
+	lighting.Description = fmt.Sprintf("%d street lights within %dm", count, radius)
+	if lighting.LampsPerKm > 0 {
+		lighting.Description += fmt.Sprintf(" (%.0f per km of road)", lighting.LampsPerKm)
+	}
+	return nil
+}
+
+// getCrimeStats obtém estatísticas de crime da região
+func (a *Analyzer) getCrimeStats(ctx context.Context, analysis *scraper.AnalysisResponse) (err error) {
+	ctx, s := telemetry.StartSpan(ctx, "cso crime stats", telemetry.SpanInternal)
+	defer func() { s.End(err) }()
+
+	// 1. Consulta CSO
+	ctx, cancel := a.WithStageTimeout(ctx, "crime")
+	defer cancel()
+	stats, err := a.Crime.GetCrimeStats(ctx,
+		analysis.Property.Coordinates.Lat,
+		analysis.Property.Coordinates.Lng,
+	)
+	if err != nil {
+		return fmt.Errorf("error getting crime stats: %w", err)
+	}
+
+	// 2. Copia total, per-capita, a área Garda e a comparação com as médias
+	analysis.SafetyInfo.CrimeStats.Total = stats.Total
+	analysis.SafetyInfo.CrimeStats.PerCapita = stats.PerCapita
+	analysis.SafetyInfo.CrimeStats.Estimated = stats.Estimated
+	analysis.SafetyInfo.CrimeStats.EstimateReason = stats.EstimateReason
+	analysis.SafetyInfo.CrimeStats.Source = stats.Source
+	analysis.SafetyInfo.CrimeStats.Granularity = stats.Granularity
+	analysis.SafetyInfo.CrimeStats.Division = stats.Division
+	analysis.SafetyInfo.CrimeStats.District = stats.District
+	analysis.SafetyInfo.CrimeStats.County = stats.County
+	analysis.SafetyInfo.CrimeStats.ComparedToCountyAvg = stats.ComparedToCountyAvg
+	analysis.SafetyInfo.CrimeStats.ComparedToNationalAvg = stats.ComparedToNationalAvg
+
+	// 3. Converte []CrimeTypeData → slice anônimo esperado pelo JSON
+	if len(stats.Breakdown) == 0 {
+		analysis.SafetyInfo.CrimeStats.Breakdown = []struct {
+			Type  string `json:"type"`
+			Count int    `json:"count"`
+		}{}
+		return nil
+	}
+
+	converted := make([]struct {
+		Type  string `json:"type"`
+		Count int    `json:"count"`
+	}, len(stats.Breakdown))
+
+	for i, ct := range stats.Breakdown {
+		converted[i].Type = ct.Type
+		converted[i].Count = ct.Count
+	}
+
+	analysis.SafetyInfo.CrimeStats.Breakdown = converted
+	return nil
+}
+
+// analyzeRoadSafety preenche RoadSafety da análise; sem a camada configurada, fica nil
+func (a *Analyzer) analyzeRoadSafety(ctx context.Context, analysis *scraper.AnalysisResponse) (err error) {
+	ctx, s := telemetry.StartSpan(ctx, "rsa collisions", telemetry.SpanInternal)
+	defer func() { s.End(err) }()
+
+	analysis.SafetyInfo.RoadSafety, err = a.RSA.RoadSafety(ctx,
+		analysis.Property.Coordinates.Lat, analysis.Property.Coordinates.Lng, time.Now())
+	return err
+}
@@ -0,0 +1,93 @@
+package enrich
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestRetryTransportResendsBody(t *testing.T) {
+	t.Setenv("HTTP_RETRIES", "2")
+	t.Setenv("HTTP_RETRY_BASE_DELAY", "1ms")
+	client := &http.Client{Transport: scraper.NewRetryTransport(http.DefaultTransport)}
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		bodies = append(bodies, r.Form.Get("data"))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		io.WriteString(w, `{"elements":[{"type":"count","tags":{"nodes":"12"}}]}`)
+	}))
+	defer srv.Close()
+
+	// Overpass rate limits often; one 429 should not discard the lighting data
+	a := &Analyzer{Overpass: overpassClient{
+		client:    client,
+		endpoints: []string{srv.URL},
+	}}
+	var analysis scraper.AnalysisResponse
+	if err := a.analyzeStreetLighting(context.Background(), &analysis); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 || bodies[0] != bodies[1] || !strings.Contains(bodies[1], "street_lamp") {
+		t.Errorf("bodies = %q, want the same query twice", bodies)
+	}
+	if analysis.SafetyInfo.StreetLighting.Rating != 6 {
+		t.Errorf("rating = %d, want 6", analysis.SafetyInfo.StreetLighting.Rating)
+	}
+}
+
+func TestHungOverpassOnlyFailsLighting(t *testing.T) {
+	srv := hangingServer(t)
+	a := &Analyzer{Overpass: overpassClient{client: http.DefaultClient, endpoints: []string{srv.URL}},
+		Timeouts: map[string]time.Duration{"overpass": 50 * time.Millisecond}}
+
+	start := time.Now()
+	var analysis scraper.AnalysisResponse
+	err := a.analyzeStreetLighting(context.Background(), &analysis)
+	if err == nil {
+		t.Fatal("expected a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("the stage took %v, the timeout was not applied", elapsed)
+	}
+	if apiErr := scraper.ModuleError(err, "OVERPASS_FAILED", "safety"); apiErr.Code != "UPSTREAM_TIMEOUT" {
+		t.Errorf("module warning = %+v, want UPSTREAM_TIMEOUT", apiErr)
+	}
+}
+
+func TestRequestCancellationStopsStages(t *testing.T) {
+	srv := hangingServer(t)
+	a := &Analyzer{Overpass: overpassClient{client: http.DefaultClient, endpoints: []string{srv.URL}}}
+
+	// a client that disconnects cancels the request context
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	var analysis scraper.AnalysisResponse
+	if err := a.analyzeStreetLighting(ctx, &analysis); err == nil {
+		t.Fatal("expected the cancelled request to fail")
+	}
+}
+
+// hangingServer never answers; the handlers are released when the test ends
+func hangingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	return srv
+}
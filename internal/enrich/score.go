@@ -0,0 +1,55 @@
+package enrich
+
+import (
+	"daft-scraper-api/internal/env"
+	"daft-scraper-api/internal/scraper"
+)
+
+// ScoreWeights são os pesos de cada módulo na nota geral (SCORE_WEIGHT_*)
+type ScoreWeights struct {
+	Safety    float64
+	Transport float64
+	Walk      float64
+	Value     float64
+}
+
+// scoreWeightsFromEnv lê SCORE_WEIGHT_SAFETY, _TRANSPORT, _WALK e _VALUE (padrão 1)
+func scoreWeightsFromEnv() ScoreWeights {
+	return ScoreWeights{
+		Safety:    env.Float("SCORE_WEIGHT_SAFETY", 1),
+		Transport: env.Float("SCORE_WEIGHT_TRANSPORT", 1),
+		Walk:      env.Float("SCORE_WEIGHT_WALK", 1),
+		Value:     env.Float("SCORE_WEIGHT_VALUE", 1),
+	}
+}
+
+// OverallScore combina as notas dos módulos numa nota única de 0 a 100, numa média
+// ponderada pelos ScoreWeights do Analyzer (todos 1 quando zerados). Módulos sem dado
+// (nota zero) ficam de fora da média em vez de puxá-la para baixo.
+func (a *Analyzer) OverallScore(property *scraper.PropertyInfo) int {
+	w := a.ScoreWeights
+	if w == (ScoreWeights{}) {
+		w = ScoreWeights{Safety: 1, Transport: 1, Walk: 1, Value: 1}
+	}
+	parts := []struct {
+		score  int
+		weight float64
+	}{
+		{property.SafetyInfo.SafetyRating * 10, w.Safety},
+		{property.QualityOfLife.TransportScore * 10, w.Transport},
+		{property.QualityOfLife.WalkScore, w.Walk},
+		{property.ValueAnalysis.PriceRating * 10, w.Value},
+	}
+
+	var total, weights float64
+	for _, p := range parts {
+		if p.score > 0 {
+			total += float64(p.score) * p.weight
+			weights += p.weight
+		}
+	}
+	if weights == 0 {
+		return 0
+	}
+	return int(total / weights)
+}
@@ -0,0 +1,106 @@
+package enrich
+
+import (
+	"fmt"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+// PropertySummary é a visão resumida de uma análise, para cartões e listas
+type PropertySummary struct {
+	Address      string `json:"address"`
+	Price        string `json:"price"`
+	URL          string `json:"url"`
+	OverallScore int    `json:"overallScore"` // 0-100
+	Scores       struct {
+		Safety     int `json:"safety"`     // 1-10
+		Transport  int `json:"transport"`  // 1-10
+		Walk       int `json:"walk"`       // 1-100
+		Bike       int `json:"bike"`       // 1-100
+		RemoteWork int `json:"remoteWork"` // 1-100
+		Family     int `json:"family"`     // 1-100
+		Quiet      int `json:"quiet"`      // 1-100
+		Value      int `json:"value"`      // 1-10
+	} `json:"scores"`
+	Pros            []string                  `json:"pros"`
+	Cons            []string                  `json:"cons"`
+	RedFlags        []scraper.DescriptionFlag `json:"redFlags"`
+	ComplianceFlags []scraper.ComplianceFlag  `json:"complianceFlags"`
+	Briefing        string                    `json:"briefing"` // texto curto para assistentes de voz
+}
+
+// SummarizeProperty junta os prós/contras da descrição com os derivados dos módulos
+func (a *Analyzer) SummarizeProperty(p *scraper.PropertyInfo) PropertySummary {
+	s := PropertySummary{
+		Address:      p.Address,
+		Price:        p.RentPrice,
+		URL:          p.URL,
+		OverallScore: a.OverallScore(p),
+		Pros:         append([]string{}, p.DescriptionAnalysis.Pros...),
+		Cons:         append([]string{}, p.DescriptionAnalysis.Cons...),
+		RedFlags:     append([]scraper.DescriptionFlag{}, p.DescriptionAnalysis.RedFlags...),
+	}
+	s.ComplianceFlags = p.ComplianceFlags
+	s.Scores.Safety = p.SafetyInfo.SafetyRating
+	s.Scores.Transport = p.QualityOfLife.TransportScore
+	s.Scores.Walk = p.QualityOfLife.WalkScore
+	s.Scores.Bike = p.QualityOfLife.BikeScore
+	s.Scores.RemoteWork = p.QualityOfLife.RemoteWorkScore
+	s.Scores.Family = p.QualityOfLife.FamilyScore
+	s.Scores.Quiet = p.QualityOfLife.QuietScore
+	s.Scores.Value = p.ValueAnalysis.PriceRating
+
+	switch {
+	case p.QualityOfLife.TransportScore >= 8:
+		s.Pros = append(s.Pros, "Excellent public transport nearby")
+	case p.QualityOfLife.TransportScore > 0 && p.QualityOfLife.TransportScore < 5:
+		s.Cons = append(s.Cons, "Limited public transport")
+	}
+	switch {
+	case p.QualityOfLife.WalkScore >= 80:
+		s.Pros = append(s.Pros, "Very walkable area")
+	case p.QualityOfLife.WalkScore > 0 && p.QualityOfLife.WalkScore < 50:
+		s.Cons = append(s.Cons, "Car-dependent area")
+	}
+	if p.QualityOfLife.BikeScore >= 70 {
+		s.Pros = append(s.Pros, "Good for cycling")
+	}
+	if p.QualityOfLife.RemoteWorkScore >= 70 {
+		s.Pros = append(s.Pros, "Well suited to remote work")
+	}
+	if p.QualityOfLife.FamilyScore >= 70 {
+		s.Pros = append(s.Pros, "Family-friendly area")
+	}
+	switch {
+	case p.QualityOfLife.QuietScore >= 80:
+		s.Pros = append(s.Pros, "Quiet surroundings")
+	case p.QualityOfLife.QuietScore > 0 && p.QualityOfLife.QuietScore < 40:
+		s.Cons = append(s.Cons, "Noisy surroundings")
+	}
+	switch {
+	case p.ValueAnalysis.PriceRating >= 8:
+		s.Pros = append(s.Pros, fmt.Sprintf("Priced below the area average (€%.0f)", p.ValueAnalysis.AreaAveragePrice))
+	case p.ValueAnalysis.PriceRating > 0 && p.ValueAnalysis.PriceRating <= 3:
+		s.Cons = append(s.Cons, fmt.Sprintf("Priced above the area average (€%.0f)", p.ValueAnalysis.AreaAveragePrice))
+	}
+	if n := p.ValueAnalysis.Negotiation; n != nil && n.Stance == "room_to_negotiate" {
+		s.Pros = append(s.Pros, fmt.Sprintf("Room to negotiate: consider offering €%.0f", n.SuggestedOffer))
+	}
+	if r := p.AdvertiserReputation; r != nil && r.Verdict == "poor" {
+		s.Cons = append(s.Cons, fmt.Sprintf("Poorly reviewed agency: %s (%.1f from %d Google reviews)", r.PlaceName, r.Rating, r.ReviewsCount))
+	}
+	if p.EnergyWarning != "" {
+		s.Cons = append(s.Cons, fmt.Sprintf("Poor energy rating (BER %s): expect high heating bills", p.BER))
+	}
+	if p.SafetyInfo.SafetyRating > 0 && p.SafetyInfo.SafetyRating <= 5 {
+		s.Cons = append(s.Cons, "Below-average safety rating")
+	}
+	if len(p.PhotoDuplicates) > 0 {
+		s.RedFlags = append(s.RedFlags, scraper.DescriptionFlag{
+			Category: "scam",
+			Phrase:   p.PhotoDuplicates[0].OtherListingURL,
+			Note:     "Listing photos also appear on another listing",
+		})
+	}
+	return s
+}
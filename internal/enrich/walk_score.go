@@ -0,0 +1,114 @@
+package enrich
+
+import (
+	"math"
+	"sort"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Walk score por categoria e distância ────────────────────────── */
+
+// Segue a metodologia publicada do Walk Score: cada categoria de lugar vale uma lista
+// de pesos (o mais perto leva o primeiro peso, o segundo mais perto o segundo...) e
+// cada lugar conta pelo peso vezes o decaimento da distância a pé. O mercado pesa
+// mais; restaurantes somam o mesmo, mas divididos entre os dez mais próximos. O
+// transporte público tem o seu próprio score e não entra aqui.
+
+// walkCategoryWeights são os pesos de cada categoria, do lugar mais próximo em diante
+var walkCategoryWeights = map[string][]float64{
+	"grocery":       {3},
+	"restaurants":   {.75, .45, .25, .25, .225, .225, .225, .225, .2, .2},
+	"shopping":      {.5, .45, .4, .35, .3},
+	"coffee":        {1.25, .75},
+	"banks":         {1},
+	"parks":         {1},
+	"schools":       {1},
+	"books":         {1},
+	"entertainment": {1},
+	"health":        {1},
+}
+
+// walkCategories diz a categoria de cada tipo de lugar de AMENITY_TYPES e
+// ENTERTAINMENT_TYPES; tipos fora do mapa não contam para o score
+var walkCategories = map[string]string{
+	"supermarket":            "grocery",
+	"grocery_or_supermarket": "grocery",
+	"convenience_store":      "grocery",
+	"restaurant":             "restaurants",
+	"bar":                    "restaurants",
+	"cafe":                   "coffee",
+	"shopping_mall":          "shopping",
+	"clothing_store":         "shopping",
+	"store":                  "shopping",
+	"bank":                   "banks",
+	"park":                   "parks",
+	"school":                 "schools",
+	"book_store":             "books",
+	"library":                "books",
+	"movie_theater":          "entertainment",
+	"gym":                    "entertainment",
+	"pharmacy":               "health",
+	"doctor":                 "health",
+	"hospital":               "health",
+}
+
+// walkDecay é quanto vale um lugar a meters de distância: inteiro até 400 m (uns 5
+// minutos a pé), ~12% a 1,6 km e nada a partir de 2,4 km
+func walkDecay(meters float64) float64 {
+	const full, none = 400.0, 2400.0
+	switch {
+	case meters <= full:
+		return 1
+	case meters >= none:
+		return 0
+	}
+	x := (meters - full) / 1600
+	return math.Exp(-3.77 * x * x)
+}
+
+// CalculateWalkScore calcula o score de caminhabilidade (1-100) das amenidades e do
+// entretenimento. A nota é relativa às categorias que a instalação procura: sem
+// "school" em AMENITY_TYPES, por exemplo, a falta de escolas não tira pontos.
+func (a *Analyzer) CalculateWalkScore(property *scraper.PropertyInfo) {
+	distances := map[string][]float64{}
+	searched := map[string]bool{}
+	for _, t := range append(append([]string{}, a.amenityTypes()...), a.entertainmentTypes()...) {
+		if category, ok := walkCategories[t]; ok {
+			searched[category] = true
+		}
+	}
+	for _, pois := range [][]scraper.POI{property.QualityOfLife.Amenities, property.QualityOfLife.Entertainment} {
+		for _, poi := range pois {
+			if category, ok := walkCategories[poi.Type]; ok {
+				searched[category] = true
+				distances[category] = append(distances[category], poi.Distance*1000)
+			}
+		}
+	}
+
+	categories := make([]string, 0, len(searched))
+	for category := range searched {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories) // a soma em ordem fixa dá sempre a mesma nota
+
+	var points, possible float64
+	for _, category := range categories {
+		weights := walkCategoryWeights[category]
+		nearest := distances[category]
+		sort.Float64s(nearest)
+		for i, w := range weights {
+			possible += w
+			if i < len(nearest) {
+				points += w * walkDecay(nearest[i])
+			}
+		}
+	}
+
+	score := 1
+	if possible > 0 {
+		score = int(math.Round(points / possible * 100))
+	}
+	property.QualityOfLife.WalkScore = min(max(score, 1), 100)
+}
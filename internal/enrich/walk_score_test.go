@@ -0,0 +1,61 @@
+package enrich
+
+import (
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestWalkDecay(t *testing.T) {
+	if walkDecay(300) != 1 || walkDecay(2500) != 0 {
+		t.Error("full value within 400 m, nothing beyond 2.4 km")
+	}
+	if d := walkDecay(1600); d < 0.1 || d > 0.14 {
+		t.Errorf("decay at a mile = %.3f, want about 0.12", d)
+	}
+	if walkDecay(800) <= walkDecay(1200) {
+		t.Error("decay should fall with distance")
+	}
+}
+
+func TestWalkScoreWeighsCategoriesAndDistance(t *testing.T) {
+	t.Setenv("AMENITY_TYPES", "")
+	t.Setenv("ENTERTAINMENT_TYPES", "")
+
+	var nothing scraper.PropertyInfo
+	(&Analyzer{}).CalculateWalkScore(&nothing)
+	if nothing.QualityOfLife.WalkScore != 1 {
+		t.Errorf("no places nearby scored %d, want the minimum 1", nothing.QualityOfLife.WalkScore)
+	}
+
+	// a supermarket next door counts for more than five far-away restaurants
+	var grocery, restaurants scraper.PropertyInfo
+	grocery.QualityOfLife.Amenities = []scraper.POI{scraper.NewPOI("Tesco", "supermarket", 0.2, 0, 0)}
+	for i := 0; i < 5; i++ {
+		restaurants.QualityOfLife.Entertainment = append(restaurants.QualityOfLife.Entertainment,
+			scraper.NewPOI("Bistro", "restaurant", 1.5, 0, 0))
+	}
+	(&Analyzer{}).CalculateWalkScore(&grocery)
+	(&Analyzer{}).CalculateWalkScore(&restaurants)
+	if grocery.QualityOfLife.WalkScore <= restaurants.QualityOfLife.WalkScore {
+		t.Errorf("grocery %d should beat distant restaurants %d", grocery.QualityOfLife.WalkScore, restaurants.QualityOfLife.WalkScore)
+	}
+
+	// everything within a few minutes' walk is a walker's paradise
+	var city scraper.PropertyInfo
+	for _, typ := range DefaultAmenityTypes {
+		city.QualityOfLife.Amenities = append(city.QualityOfLife.Amenities, scraper.NewPOI(typ, typ, 0.3, 0, 0))
+	}
+	for _, typ := range DefaultEntertainmentTypes {
+		for i := 0; i < 10; i++ {
+			city.QualityOfLife.Entertainment = append(city.QualityOfLife.Entertainment, scraper.NewPOI(typ, typ, 0.3, 0, 0))
+		}
+	}
+	for _, shop := range []string{"Arnotts", "Dunnes", "Penneys", "Brown Thomas"} {
+		city.QualityOfLife.Amenities = append(city.QualityOfLife.Amenities, scraper.NewPOI(shop, "shopping_mall", 0.35, 0, 0))
+	}
+	(&Analyzer{}).CalculateWalkScore(&city)
+	if city.QualityOfLife.WalkScore != 100 {
+		t.Errorf("dense centre scored %d, want 100", city.QualityOfLife.WalkScore)
+	}
+}
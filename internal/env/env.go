@@ -0,0 +1,55 @@
+// Package env lê os ajustes das variáveis de ambiente, com o padrão de cada um quando
+// a variável falta ou é inválida.
+package env
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+/* ───── Leitura do ambiente ─────────────────────────────────────────── */
+
+// Name converte a chave do arquivo no nome da variável (rate_limit.per_minute
+// → RATE_LIMIT_PER_MINUTE)
+func Name(key string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_", " ", "").Replace(key))
+}
+
+// Or lê a variável, ou def quando ela está vazia
+func Or(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// Int lê um inteiro positivo da variável, ou def
+func Int(name string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(name)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
+
+// Float lê um número não negativo da variável, ou def
+func Float(name string, def float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
+
+// List lê uma lista separada por vírgulas, ou def
+func List(name string, def []string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	if len(list) == 0 {
+		return def
+	}
+	return list
+}
@@ -0,0 +1,538 @@
+// Package safety calcula a nota de segurança e consulta as fontes dela que não
+// dependem de PropertyInfo: a criminalidade (divisões Garda no ArcGIS e o cubo CJA07
+// da CSO) e as colisões de trânsito da RSA. Pode ser usado e testado sozinho; o
+// Analyzer de internal/enrich o usa em AnalyzeSafety.
+package safety
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+/* ───── Helpers ──────────────────────────────────────────────────────── */
+
+func normalize(s string) string {
+	s = strings.ToLower(s)
+	for _, r := range []struct{ old, new string }{
+		{".", ""}, {",", ""}, {"-", ""}, {"–", ""}, {"—", ""},
+		{"(", ""}, {")", ""}, {"division", ""},
+		{"  ", " "},
+	} {
+		s = strings.ReplaceAll(s, r.old, r.new)
+	}
+	return strings.TrimSpace(s)
+}
+
+/* ───── Estruturas devolvidas ao main.go ────────────────────────────── */
+
+// CrimeTypeData é a contagem de um tipo de crime.
+type CrimeTypeData struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// CrimeStats são as estatísticas de crime de uma área.
+type CrimeStats struct {
+	Total     int             `json:"total"`
+	PerCapita float64         `json:"perCapita"`
+	Breakdown []CrimeTypeData `json:"breakdown"`
+	Estimated bool            `json:"estimated,omitempty"` // CSO sem dados: valores estimados
+
+	EstimateReason string `json:"estimateReason,omitempty"` // por que os números são estimados
+	Source         string `json:"source,omitempty"`         // cubo da CSO usado, ex.: "CSO CJA07"
+
+	// de que área são os números: "district" (o distrito Garda, pelas delegacias
+	// dele) quando a CSO tem o distrito, senão "division", bem maior
+	Granularity string `json:"granularity"`
+	Division    string `json:"division,omitempty"`
+	District    string `json:"district,omitempty"`
+
+	// quanto o per-capita da divisão está acima (positivo) ou abaixo (negativo) da
+	// média do condado e do país, em %; nil quando não há como comparar
+	County                string   `json:"county,omitempty"`
+	ComparedToCountyAvg   *float64 `json:"comparedToCountyAvg,omitempty"`
+	ComparedToNationalAvg *float64 `json:"comparedToNationalAvg,omitempty"`
+
+	countyAvg, nationalAvg float64 // per-capita médios, base das comparações
+}
+
+/* ───── JSON-stat genérico ──────────────────────────────────────────── */
+
+// PxStatResp é a resposta JSON-stat da PxStat.
+type PxStatResp struct {
+	Dataset struct {
+		Dimension map[string]pxDimension `json:"dimension"`
+		Value     []float64              `json:"value"`
+	} `json:"dataset"`
+}
+
+// pxDimension é uma dimensão do cubo (região, ano...) com os códigos na ordem do vetor
+type pxDimension struct {
+	Label    string `json:"label"`
+	Category struct {
+		Index []string          `json:"index"`
+		Label map[string]string `json:"label"`
+	} `json:"category"`
+}
+
+/* ───── População aproximada por divisão (ajuste se quiser) ─────────── */
+
+func pop(div string) int {
+	if v, ok := map[string]int{
+		"D.M.R. Northern Division":      180000,
+		"D.M.R. North Central Division": 300000,
+		"D.M.R. Southern Division":      200000,
+		"D.M.R. South Central Division": 280000,
+		"D.M.R. Eastern Division":       260000,
+		"D.M.R. Western Division":       220000,
+	}[div]; ok {
+		return v
+	}
+	return 100000
+}
+
+/* ───── Médias do condado e do país ─────────────────────────────────── */
+
+// county é o condado (ou par de condados) da divisão: as seis divisões D.M.R. são
+// Dublin e as divisões de uma cidade ("Cork City", "Cork North") ficam com o condado
+func county(division string) string {
+	name := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(division), "Division"))
+	if strings.HasPrefix(name, "D.M.R.") || strings.HasPrefix(strings.ToLower(name), "dublin") {
+		return "Dublin"
+	}
+	for _, suffix := range []string{" City", " North", " South", " East", " West"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	return name
+}
+
+// isAggregate diz se a linha do cubo é um total (o país inteiro) e não uma divisão
+func isAggregate(label string) bool {
+	switch normalize(label) {
+	case "state", "ireland", "all garda regions", "all garda divisions":
+		return true
+	}
+	return false
+}
+
+// compareWithAverages preenche County e as comparações a partir do total de cada
+// divisão no mesmo ano; as médias são per-capita, somando totais e populações
+func compareWithAverages(stats *CrimeStats, division string, totals map[string]int) {
+	var countyCrimes, countyPop, crimes, population int
+	stats.County = county(division)
+	for label, total := range totals {
+		if isAggregate(label) {
+			continue
+		}
+		crimes += total
+		population += pop(label)
+		if county(label) == stats.County {
+			countyCrimes += total
+			countyPop += pop(label)
+		}
+	}
+	if countyPop > 0 {
+		stats.countyAvg = float64(countyCrimes) / float64(countyPop)
+	}
+	if population > 0 {
+		stats.nationalAvg = float64(crimes) / float64(population)
+	}
+	stats.compare()
+}
+
+// compare refaz as comparações com as médias para o PerCapita atual
+func (s *CrimeStats) compare() {
+	s.ComparedToCountyAvg = percentDiff(s.PerCapita, s.countyAvg)
+	s.ComparedToNationalAvg = percentDiff(s.PerCapita, s.nationalAvg)
+}
+
+// percentDiff é a diferença de value para avg em %, com uma casa decimal
+func percentDiff(value, avg float64) *float64 {
+	if avg <= 0 {
+		return nil
+	}
+	diff := math.Round((value/avg-1)*1000) / 10
+	return &diff
+}
+
+/* ───── ArcGIS → Divisão e distrito ─────────────────────────────────── */
+
+// gardaArea é a divisão Garda do ponto e o distrito dela
+type gardaArea struct {
+	Division, District string
+}
+
+type gardaResp struct {
+	Features []struct{ Attributes gardaArea }
+}
+
+const gardaLayer = "https://services1.arcgis.com/eNO7HHeQ3rUcBllm/arcgis/rest/services/" +
+	"GardaDistricts/FeatureServer/0/query"
+
+func (c Client) getGardaArea(ctx context.Context, lat, lng float64) (gardaArea, error) {
+	q := url.Values{
+		"geometry":     {fmt.Sprintf("%f,%f", lng, lat)},
+		"geometryType": {"esriGeometryPoint"},
+		"inSR":         {"4326"},
+		"outFields":    {"Division,District"},
+		"f":            {"json"},
+	}
+	var gr gardaResp
+	if err := c.getJSON(ctx, gardaLayer+"?"+q.Encode(), &gr); err != nil {
+		return gardaArea{}, err
+	}
+	if len(gr.Features) == 0 {
+		return gardaArea{}, fmt.Errorf("coordenadas fora de qualquer divisão Garda")
+	}
+	return gr.Features[0].Attributes, nil
+}
+
+// districtCount conta os distritos da divisão, para repartir a população dela
+func (c Client) districtCount(ctx context.Context, division string) (int, error) {
+	q := url.Values{
+		"where":           {"Division='" + strings.ReplaceAll(division, "'", "''") + "'"},
+		"returnCountOnly": {"true"},
+		"f":               {"json"},
+	}
+	var resp struct{ Count int }
+	if err := c.getJSON(ctx, gardaLayer+"?"+q.Encode(), &resp); err != nil {
+		return 0, err
+	}
+	if resp.Count == 0 {
+		return 0, fmt.Errorf("nenhum distrito na divisão '%s'", division)
+	}
+	return resp.Count, nil
+}
+
+// getJSON faz o GET e decodifica a resposta em v
+func (c Client) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+/* ───── Função pública usada no main.go ─────────────────────────────── */
+
+// Client consulta o ArcGIS e a CSO pelo cliente HTTP injetado. Só lê o campo; é
+// seguro para uso concorrente.
+type Client struct {
+	HTTP *http.Client
+}
+
+// GetCrimeStats devolve as estatísticas de crime do distrito Garda que contém o ponto
+// ou, quando a CSO não tem o distrito, da divisão inteira
+func (c Client) GetCrimeStats(ctx context.Context, lat, lng float64) (*CrimeStats, error) {
+	area, err := c.getGardaArea(ctx, lat, lng)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := c.fetchStats(ctx, area.Division, "2024")
+	if err != nil {
+		return nil, err
+	}
+	stats.Granularity, stats.Division = "division", area.Division
+	if area.District == "" || stats.Estimated {
+		return stats, nil
+	}
+
+	// o distrito troca os números da divisão; as médias do condado e do país ficam
+	total, perCap, err := c.fetchDistrictStats(ctx, area, "2024")
+	if err != nil {
+		return stats, nil // fica a divisão, já marcada em Granularity
+	}
+	stats.Total, stats.PerCapita = total, perCap
+	stats.Granularity, stats.District = "district", area.District
+	stats.compare()
+	return stats, nil
+}
+
+/* ───── Distrito: cubo CJQ06, por delegacia ─────────────────────────── */
+
+// fetchDistrictStats soma os incidentes do ano nas delegacias do distrito (o cubo
+// CJQ06 é trimestral e por delegacia). A população do distrito é a da divisão
+// repartida igualmente entre os distritos dela.
+func (c Client) fetchDistrictStats(ctx context.Context, area gardaArea, year string) (int, float64, error) {
+	px, err := c.fetchCube(ctx, "CJQ06")
+	if err != nil {
+		return 0, 0, err
+	}
+	total, err := areaTotal(px, area.District, year)
+	if err != nil {
+		return 0, 0, err
+	}
+	n, err := c.districtCount(ctx, area.Division)
+	if err != nil {
+		return 0, 0, err
+	}
+	return total, float64(total) / (float64(pop(area.Division)) / float64(n)), nil
+}
+
+// areaTotal soma no cubo os valores das delegacias da área (distrito ou divisão) nos
+// períodos do ano ("2024" ou os trimestres "20241".."20244")
+func areaTotal(px *PxStatResp, area, year string) (int, error) {
+	regDim, yrDim, err := cubeDimensions(px)
+	if err != nil {
+		return 0, err
+	}
+	var regions, periods []int
+	target := normalize(area)
+	for idx, code := range regDim.Category.Index {
+		if target != "" && strings.Contains(normalize(regDim.Category.Label[code]), target) {
+			regions = append(regions, idx)
+		}
+	}
+	for idx, code := range yrDim.Category.Index {
+		if strings.HasPrefix(code, year) {
+			periods = append(periods, idx)
+		}
+	}
+	if len(regions) == 0 {
+		return 0, fmt.Errorf("'%s' não encontrado no CSO", area)
+	}
+	if len(periods) == 0 {
+		return 0, fmt.Errorf("ano %s não disponível", year)
+	}
+
+	nYr, total := len(yrDim.Category.Index), 0.0
+	for _, r := range regions {
+		for _, y := range periods {
+			if pos := r*nYr + y; pos < len(px.Dataset.Value) {
+				total += px.Dataset.Value[pos]
+			}
+		}
+	}
+	return int(total), nil
+}
+
+/* ───── Core: consulta CSO e devolve CrimeStats ─────────────────────── */
+
+// fetchCube baixa um cubo da PxStat em JSON-stat
+func (c Client) fetchCube(ctx context.Context, cube string) (*PxStatResp, error) {
+	urlCSO := "https://ws.cso.ie/public/api.restful/PxStat.Data.Cube_API.ReadDataset/" + cube + "/JSON-stat/2.0/en?format=jsonstat2"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlCSO, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch CSO data: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CSO API returned status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var px PxStatResp
+	if err := json.Unmarshal(body, &px); err != nil {
+		return nil, fmt.Errorf("decoding CSO JSON: %w", err)
+	}
+	return &px, nil
+}
+
+// cubeDimensions identifica as dimensões de região e de ano (ou trimestre) do cubo
+func cubeDimensions(px *PxStatResp) (pxDimension, pxDimension, error) {
+	/* ─── 1. Identificar chaves da dimensão Região e Ano ─── */
+	var regionKey, yearKey string
+
+	// 1a) tenta pelo label descritivo
+	for k, v := range px.Dataset.Dimension {
+		l := strings.ToLower(v.Label)
+		if regionKey == "" && (strings.Contains(l, "garda") ||
+			strings.Contains(l, "division") || strings.Contains(l, "station") ||
+			strings.Contains(l, "area") || strings.Contains(l, "region")) {
+			regionKey = k
+		}
+		if yearKey == "" && (strings.Contains(l, "year") ||
+			strings.Contains(l, "time") || strings.Contains(l, "period")) {
+			yearKey = k
+		}
+	}
+
+	// 1b) se falhou, tenta pelo nome da chave
+	if regionKey == "" {
+		for k := range px.Dataset.Dimension {
+			if strings.HasPrefix(k, "C0") || strings.HasPrefix(k, "STATISTIC") ||
+				strings.HasPrefix(k, "REGION") || strings.HasPrefix(k, "AREA") {
+				regionKey = k
+				break
+			}
+		}
+	}
+	if yearKey == "" {
+		for k := range px.Dataset.Dimension {
+			if strings.HasPrefix(strings.ToUpper(k), "TLIST") || strings.HasPrefix(k, "TIME") ||
+				strings.HasPrefix(k, "YEAR") || strings.HasPrefix(k, "PERIOD") {
+				yearKey = k
+				break
+			}
+		}
+	}
+
+	// 1c) último fallback: assume 1ª dimensão = região, 2ª = ano
+	if regionKey == "" || yearKey == "" {
+		keys := make([]string, 0, len(px.Dataset.Dimension))
+		for k := range px.Dataset.Dimension {
+			keys = append(keys, k)
+		}
+		if len(keys) >= 2 {
+			if regionKey == "" {
+				regionKey = keys[0]
+			}
+			if yearKey == "" {
+				yearKey = keys[1]
+			}
+		}
+	}
+
+	// Confirma existência
+	regDim, okR := px.Dataset.Dimension[regionKey]
+	yrDim, okY := px.Dataset.Dimension[yearKey]
+	if !okR || !okY {
+		all := make([]string, 0, len(px.Dataset.Dimension))
+		for k := range px.Dataset.Dimension {
+			all = append(all, k)
+		}
+		return pxDimension{}, pxDimension{}, fmt.Errorf("dimensões não encontradas (reg: %s / ano: %s). chaves disponíveis: %v",
+			regionKey, yearKey, all)
+	}
+	return regDim, yrDim, nil
+}
+
+// estimatedPerCapita é a taxa usada quando nenhum cubo da CSO tem o dado: uma média
+// nacional aproximada de incidentes registrados por habitante, e não um número da área
+const estimatedPerCapita = 0.045
+
+// fetchStats devolve os números da divisão pelo cubo CJA07 ou, quando ele não tem a
+// divisão (ou mudou de formato), somando as delegacias dela no CJQ06. Só se os dois
+// vierem sem o dado sai uma estimativa, com Estimated e o motivo em EstimateReason;
+// se a CSO nem respondeu, o erro volta para o chamador.
+func (c Client) fetchStats(ctx context.Context, division, year string) (*CrimeStats, error) {
+	var reasons []string
+	var fetchErr error
+	answered := false
+
+	if px, err := c.fetchCube(ctx, "CJA07"); err != nil {
+		fetchErr = err
+		reasons = append(reasons, "CJA07: "+err.Error())
+	} else if stats, err := divisionStats(px, division, year); err != nil {
+		answered = true
+		reasons = append(reasons, "CJA07: "+err.Error())
+	} else {
+		stats.Source = "CSO CJA07"
+		return stats, nil
+	}
+
+	if px, err := c.fetchCube(ctx, "CJQ06"); err != nil {
+		fetchErr = err
+		reasons = append(reasons, "CJQ06: "+err.Error())
+	} else if total, err := areaTotal(px, division, year); err != nil {
+		answered = true
+		reasons = append(reasons, "CJQ06: "+err.Error())
+	} else {
+		return &CrimeStats{
+			Total:     total,
+			PerCapita: float64(total) / float64(pop(division)),
+			Breakdown: []CrimeTypeData{},
+			Source:    "CSO CJQ06",
+		}, nil
+	}
+
+	if !answered {
+		return nil, fetchErr
+	}
+	population := pop(division)
+	return &CrimeStats{
+		Total:     int(math.Round(estimatedPerCapita * float64(population))),
+		PerCapita: estimatedPerCapita,
+		Breakdown: []CrimeTypeData{}, // sem dado, sem tipos de crime
+		Estimated: true,
+		EstimateReason: fmt.Sprintf("CSO has no %s figures for %s (%s); using an approximate national rate",
+			year, division, strings.Join(reasons, "; ")),
+	}, nil
+}
+
+// divisionStats lê do cubo CJA07 o total da divisão no ano e o compara com as médias
+func divisionStats(px *PxStatResp, division, year string) (*CrimeStats, error) {
+	regDim, yrDim, err := cubeDimensions(px)
+	if err != nil {
+		return nil, err
+	}
+
+	/* ─── 2. Match da divisão ─── */
+	target := normalize(division)
+	regIdx := -1
+	var regLabel string
+	for idx, code := range regDim.Category.Index {
+		lbl := regDim.Category.Label[code]
+		if normalize(lbl) == target || strings.Contains(normalize(lbl), target) {
+			regIdx = idx
+			regLabel = lbl
+			break
+		}
+	}
+	if regIdx == -1 {
+		return nil, fmt.Errorf("divisão '%s' não encontrada no CSO", division)
+	}
+
+	/* ─── 3. Índice do ano ─── */
+	yrIdx := -1
+	for idx, code := range yrDim.Category.Index {
+		if code == year {
+			yrIdx = idx
+			break
+		}
+	}
+	if yrIdx == -1 {
+		return nil, fmt.Errorf("ano %s não disponível", year)
+	}
+
+	/* ─── 4. Total de incidentes ─── */
+	nYr := len(yrDim.Category.Index)
+	pos := regIdx*nYr + yrIdx
+	if pos >= len(px.Dataset.Value) {
+		return nil, fmt.Errorf("posição fora do vetor Value")
+	}
+	total := int(px.Dataset.Value[pos])
+
+	/* ─── 5. Per-capita ─── */
+	perCap := 0.0
+	if p := pop(regLabel); p > 0 {
+		perCap = float64(total) / float64(p)
+	}
+
+	/* ─── 6. Comparação com o condado e o país ─── */
+	stats := &CrimeStats{
+		Total:     total,
+		PerCapita: perCap,
+		Breakdown: []CrimeTypeData{}, // cubo não inclui tipos de crime
+	}
+	totals := make(map[string]int, len(regDim.Category.Index))
+	for idx, code := range regDim.Category.Index {
+		if p := idx*nYr + yrIdx; p < len(px.Dataset.Value) {
+			totals[regDim.Category.Label[code]] = int(px.Dataset.Value[p])
+		}
+	}
+	compareWithAverages(stats, regLabel, totals)
+
+	/* ─── 7. Retorno ─── */
+	return stats, nil
+}
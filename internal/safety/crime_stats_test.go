@@ -0,0 +1,166 @@
+package safety
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeDivision(t *testing.T) {
+	// ArcGIS and the CSO cube spell the same division differently
+	cases := map[string]string{
+		"D.M.R. South Central Division": "dmr south central",
+		"Cork City":                     "cork city",
+		"Kerry (Tralee) – Division":     "kerry tralee",
+	}
+	for in, want := range cases {
+		if got := normalize(in); got != want {
+			t.Errorf("normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDivisionPopulation(t *testing.T) {
+	if pop("D.M.R. Southern Division") != 200000 {
+		t.Error("known DMR division should use its population")
+	}
+	if pop("Unknown") != 100000 {
+		t.Error("unknown divisions fall back to 100000")
+	}
+}
+
+func TestCounty(t *testing.T) {
+	cases := map[string]string{
+		"D.M.R. South Central Division": "Dublin",
+		"Dublin North":                  "Dublin",
+		"Cork City Division":            "Cork",
+		"Cork West":                     "Cork",
+		"Kerry Division":                "Kerry",
+		"Sligo/Leitrim":                 "Sligo/Leitrim",
+	}
+	for in, want := range cases {
+		if got := county(in); got != want {
+			t.Errorf("county(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCompareWithAverages(t *testing.T) {
+	totals := map[string]int{
+		"State":                         99999, // the cube's national total is not a division
+		"D.M.R. Southern Division":      4000,  // 0.020 per capita
+		"D.M.R. Northern Division":      9000,  // 0.050
+		"Kerry Division":                1000,  // 0.010
+		"D.M.R. South Central Division": 0,
+	}
+	stats := &CrimeStats{Total: 4000, PerCapita: 4000.0 / 200000}
+	compareWithAverages(stats, "D.M.R. Southern Division", totals)
+
+	if stats.County != "Dublin" {
+		t.Errorf("county = %q", stats.County)
+	}
+	// Dublin: 13000 / 660000 ≈ 0.0197 → +1.5%; all: 14000 / 760000 ≈ 0.0184 → +8.6%
+	if stats.ComparedToCountyAvg == nil || *stats.ComparedToCountyAvg != 1.5 {
+		t.Errorf("comparedToCountyAvg = %v, want 1.5", stats.ComparedToCountyAvg)
+	}
+	if stats.ComparedToNationalAvg == nil || *stats.ComparedToNationalAvg != 8.6 {
+		t.Errorf("comparedToNationalAvg = %v, want 8.6", stats.ComparedToNationalAvg)
+	}
+
+	empty := &CrimeStats{}
+	compareWithAverages(empty, "Kerry Division", map[string]int{"Kerry Division": 0})
+	if empty.ComparedToCountyAvg != nil || empty.ComparedToNationalAvg != nil {
+		t.Error("no crimes anywhere leaves nothing to compare against")
+	}
+}
+
+func TestDistrictTotal(t *testing.T) {
+	// a quarterly cube by station: 3 stations × 5 quarters
+	var px PxStatResp
+	err := json.Unmarshal([]byte(`{"dataset":{
+		"dimension":{
+			"C02480V03003":{"label":"Garda Station","category":{
+				"index":["10","11","20"],
+				"label":{"10":"Pearse Street, D.M.R. South Central Division","11":"Pearse Street Harcourt Terrace","20":"Tallaght, D.M.R. Western Division"}}},
+			"TLIST(Q1)":{"label":"Quarter","category":{
+				"index":["20234","20241","20242","20243","20244"],
+				"label":{}}}
+		},
+		"value":[99,1,2,3,4, 10,20,30,40, 50, 7,7,7,7,7]
+	}}`), &px)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total, err := areaTotal(&px, "Pearse Street", "2024")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// station 10: 1+2+3+4, station 11: 20+30+40+50 (its 2023 Q4 is 10)
+	if total != 150 {
+		t.Errorf("total = %d, want 150", total)
+	}
+	if _, err := areaTotal(&px, "Kilkenny", "2024"); err == nil {
+		t.Error("a district missing from the cube should be an error, so the division is used")
+	}
+}
+
+// roundTripFunc answers the client's requests without a network
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// csoCubes serves each cube's body, or a 503 for cubes not in the map
+func csoCubes(bodies map[string]string) Client {
+	return Client{HTTP: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		for cube, body := range bodies {
+			if strings.Contains(r.URL.Path, "/"+cube+"/") {
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+			}
+		}
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader("")), Request: r}, nil
+	})}}
+}
+
+const stationCube = `{"dataset":{
+	"dimension":{
+		"STATION":{"label":"Garda Station","category":{"index":["10","11"],
+			"label":{"10":"Pearse Street, D.M.R. South Central Division","11":"Kevin Street, D.M.R. South Central Division"}}},
+		"TLIST(Q1)":{"label":"Quarter","category":{"index":["20241","20242"],"label":{}}}
+	},
+	"value":[100,200, 300,400]
+}}`
+
+func TestFetchStatsFallsBackToStationCube(t *testing.T) {
+	c := csoCubes(map[string]string{"CJA07": `{"dataset":{}}`, "CJQ06": stationCube})
+	stats, err := c.fetchStats(context.Background(), "D.M.R. South Central Division", "2024")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Estimated || stats.Total != 1000 || stats.Source != "CSO CJQ06" {
+		t.Errorf("stats = %+v, want the 1000 incidents summed from the stations", stats)
+	}
+}
+
+func TestFetchStatsEstimatesWithAReason(t *testing.T) {
+	c := csoCubes(map[string]string{"CJA07": `{"dataset":{}}`, "CJQ06": stationCube})
+	stats, err := c.fetchStats(context.Background(), "Kerry Division", "2024")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stats.Estimated || stats.PerCapita != estimatedPerCapita || len(stats.Breakdown) != 0 {
+		t.Errorf("stats = %+v, want a flagged estimate without a made-up breakdown", stats)
+	}
+	if !strings.Contains(stats.EstimateReason, "CJA07") || !strings.Contains(stats.EstimateReason, "CJQ06") {
+		t.Errorf("reason %q should say what each cube lacked", stats.EstimateReason)
+	}
+}
+
+func TestFetchStatsCSODown(t *testing.T) {
+	if _, err := csoCubes(nil).fetchStats(context.Background(), "Kerry Division", "2024"); err == nil {
+		t.Error("an unreachable CSO is an error, not an estimate")
+	}
+}
@@ -0,0 +1,166 @@
+package safety
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* ───── Segurança viária (colisões da RSA) ──────────────────────────── */
+
+// A RSA publica as colisões com vítimas numa camada do ArcGIS (RSA_COLLISIONS_URL,
+// o endereço .../FeatureServer/0/query). Contamos as que envolveram pedestres e
+// ciclistas no raio COLLISIONS_RADIUS nos últimos COLLISIONS_YEARS anos, o que ajuda
+// famílias e ciclistas a escolher entre ruas parecidas. Os nomes dos campos mudam
+// entre as versões da camada, então o ano e o tipo de vítima são lidos pelos valores
+// (como na leitura do cubo da CSO) e não por nomes fixos.
+
+// RoadSafetyClient consulta a camada de colisões; só lê os campos, é seguro para uso
+// concorrente
+type RoadSafetyClient struct {
+	HTTP     *http.Client
+	Endpoint string        // vazio = sem dados de colisões
+	Radius   uint          // metros em volta do imóvel (COLLISIONS_RADIUS); 0 = defaultCollisionRadius
+	Years    int           // anos contados (COLLISIONS_YEARS); 0 = DefaultCollisionYears
+	Timeout  time.Duration // RSA_TIMEOUT; 0 = defaultRSATimeout
+}
+
+// Padrões de um RoadSafetyClient montado sem os ajustes do ambiente
+const (
+	defaultCollisionRadius = 500
+	DefaultCollisionYears  = 5
+	defaultRSATimeout      = 15 * time.Second
+)
+
+// arcgisQueryResp é a resposta de /query do ArcGIS com outFields=*
+type arcgisQueryResp struct {
+	Features []struct {
+		Attributes map[string]any `json:"attributes"`
+	} `json:"features"`
+	ExceededTransferLimit bool `json:"exceededTransferLimit"`
+	Error                 *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// RoadSafety conta as colisões em volta do ponto; nil sem RSA_COLLISIONS_URL
+func (c RoadSafetyClient) RoadSafety(ctx context.Context, lat, lng float64, now time.Time) (*RoadSafety, error) {
+	if c.Endpoint == "" {
+		return nil, nil
+	}
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = defaultRSATimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	radius, years := int(c.Radius), c.Years
+	if radius == 0 {
+		radius = defaultCollisionRadius
+	}
+	if years == 0 {
+		years = DefaultCollisionYears
+	}
+	q := url.Values{
+		"geometry":       {fmt.Sprintf("%f,%f", lng, lat)},
+		"geometryType":   {"esriGeometryPoint"},
+		"inSR":           {"4326"},
+		"distance":       {strconv.Itoa(radius)},
+		"units":          {"esriSRUnit_Meter"},
+		"spatialRel":     {"esriSpatialRelIntersects"},
+		"where":          {"1=1"},
+		"outFields":      {"*"},
+		"returnGeometry": {"false"},
+		"f":              {"json"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Endpoint+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error querying RSA collisions: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("RSA collisions returned status code: %d", resp.StatusCode)
+	}
+
+	var result arcgisQueryResp
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error decoding RSA collisions: %w", err)
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("RSA collisions query failed (%d): %s", result.Error.Code, result.Error.Message)
+	}
+
+	rs := &RoadSafety{
+		RadiusMeters: radius,
+		SinceYear:    now.Year() - years,
+		Partial:      result.ExceededTransferLimit,
+	}
+	for _, f := range result.Features {
+		if year, ok := collisionYear(f.Attributes); ok && year < rs.SinceYear {
+			continue
+		}
+		rs.TotalCollisions++
+		pedestrian, cyclist, fatal := collisionKind(f.Attributes)
+		if pedestrian {
+			rs.PedestrianCollisions++
+		}
+		if cyclist {
+			rs.CyclistCollisions++
+		}
+		if fatal {
+			rs.FatalCollisions++
+		}
+	}
+	return rs, nil
+}
+
+// collisionYear acha o ano da colisão num campo "year" ou numa data (milissegundos
+// desde 1970, como o ArcGIS devolve os campos de data)
+func collisionYear(attrs map[string]any) (int, bool) {
+	for key, value := range attrs {
+		n, ok := value.(float64)
+		if !ok {
+			if s, isString := value.(string); isString {
+				parsed, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+				n, ok = parsed, err == nil
+			}
+		}
+		if !ok {
+			continue
+		}
+		switch key := strings.ToLower(key); {
+		case strings.Contains(key, "year") && n >= 1900 && n < 3000:
+			return int(n), true
+		case strings.Contains(key, "date") && n > 0:
+			return time.UnixMilli(int64(n)).UTC().Year(), true
+		}
+	}
+	return 0, false
+}
+
+// collisionKind lê dos valores de texto da colisão o tipo de vítima ("Pedestrian",
+// "Pedal Cyclist") e se ela foi fatal (o valor "Fatal", e não "Non-fatal")
+func collisionKind(attrs map[string]any) (pedestrian, cyclist, fatal bool) {
+	for _, value := range attrs {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		s = strings.ToLower(strings.TrimSpace(s))
+		pedestrian = pedestrian || strings.Contains(s, "pedestrian")
+		cyclist = cyclist || strings.Contains(s, "cyclist") || strings.Contains(s, "pedal")
+		fatal = fatal || s == "fatal"
+	}
+	return pedestrian, cyclist, fatal
+}
@@ -0,0 +1,53 @@
+package safety
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoadSafetyCountsVulnerableRoadUsers(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"features":[
+			{"attributes":{"Year":2023,"Casualty":"Pedestrian","Severity":"Fatal"}},
+			{"attributes":{"Year":"2022","Casualty":"Pedal Cyclist","Severity":"Non-fatal"}},
+			{"attributes":{"CollisionDate":1672531200000,"Casualty":"Car Driver","Severity":"Minor"}},
+			{"attributes":{"Year":2015,"Casualty":"Pedestrian","Severity":"Serious"}}
+		]}`))
+	}))
+	defer srv.Close()
+
+	c := RoadSafetyClient{HTTP: srv.Client(), Endpoint: srv.URL}
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	rs, err := c.RoadSafety(context.Background(), 53.34, -6.26, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the 2015 collision is older than five years
+	want := RoadSafety{PedestrianCollisions: 1, CyclistCollisions: 1, FatalCollisions: 1, TotalCollisions: 3, RadiusMeters: 500, SinceYear: 2020}
+	if *rs != want {
+		t.Errorf("road safety = %+v, want %+v", *rs, want)
+	}
+	if !strings.Contains(query, "distance=500") || !strings.Contains(query, "geometry=-6.260000%2C53.340000") {
+		t.Errorf("query = %s", query)
+	}
+}
+
+func TestRoadSafetyDisabledAndErrors(t *testing.T) {
+	if rs, err := (RoadSafetyClient{}).RoadSafety(context.Background(), 53, -6, time.Now()); rs != nil || err != nil {
+		t.Errorf("without an endpoint: %+v, %v", rs, err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error":{"code":400,"message":"Invalid query parameters"}}`))
+	}))
+	defer srv.Close()
+	if _, err := (RoadSafetyClient{HTTP: srv.Client(), Endpoint: srv.URL}).RoadSafety(context.Background(), 53, -6, time.Now()); err == nil {
+		t.Error("an ArcGIS error body should be reported")
+	}
+}
@@ -0,0 +1,167 @@
+package safety
+
+import "fmt"
+
+/* ───── Score de segurança explicável ───────────────────────────────── */
+
+// O safetyScore parte de uma base e soma ou tira pontos por fator. Cada fator vai na
+// resposta com o peso, o quanto contribuiu e a evidência usada, junto das entradas da
+// fórmula, para o plugin explicar a nota e o usuário contestar um fator específico:
+//
+//	score = base + Σ contribution, limitado a 1-100
+
+const (
+	safetyBaseScore       = 70
+	safetyGardaWeight     = 5  // há estação da Garda por perto
+	safetyWellLitWeight   = 5  // iluminação com nota de wellLitRating para cima
+	safetyLightingWeight  = 2  // por ponto da nota de iluminação (1-10)
+	safetyHighCrimeWeight = 10 // crimes per capita acima de highCrimePerCapita
+
+	wellLitRating      = 7
+	highCrimePerCapita = 0.02
+)
+
+// SafetyFactor é um fator do safetyScore
+type SafetyFactor struct {
+	Factor       string `json:"factor"`       // identificador estável, ex.: crime_rate
+	Weight       int    `json:"weight"`       // pontos por unidade do fator
+	Contribution int    `json:"contribution"` // pontos somados (negativos nos riscos)
+	Evidence     string `json:"evidence"`     // o dado que levou ao fator
+}
+
+// SafetyScoreInputs são as entradas da fórmula do safetyScore
+type SafetyScoreInputs struct {
+	Formula            string   `json:"formula"`
+	Base               int      `json:"base"`
+	NearestGardaKm     *float64 `json:"nearestGardaKm,omitempty"` // nil sem estação encontrada
+	LightingRating     int      `json:"lightingRating"`
+	CrimePerCapita     float64  `json:"crimePerCapita"`
+	CrimeEstimated     bool     `json:"crimeEstimated,omitempty"`
+	HighCrimePerCapita float64  `json:"highCrimePerCapita"`
+	RawScore           int      `json:"rawScore"` // antes de limitar a 1-100
+}
+
+// Score calcula o score de segurança e os fatores que o explicam
+func Score(safety *SafetyAnalysis) {
+	inputs := SafetyScoreInputs{
+		Formula:            "base + sum(contribution), clamped to 1-100",
+		Base:               safetyBaseScore,
+		LightingRating:     safety.StreetLighting.Rating,
+		CrimePerCapita:     safety.CrimeStats.PerCapita,
+		CrimeEstimated:     safety.CrimeStats.Estimated,
+		HighCrimePerCapita: highCrimePerCapita,
+	}
+
+	// Fatores positivos
+	safety.SafetyFactors = []SafetyFactor{}
+	if len(safety.NearbyGardai) > 0 {
+		km := safety.NearbyGardai[0].Distance
+		inputs.NearestGardaKm = &km
+		safety.SafetyFactors = append(safety.SafetyFactors, SafetyFactor{
+			Factor: "garda_station", Weight: safetyGardaWeight, Contribution: safetyGardaWeight,
+			Evidence: fmt.Sprintf("Garda station within %.1f km", km),
+		})
+	}
+	if rating := safety.StreetLighting.Rating; rating > 0 {
+		safety.SafetyFactors = append(safety.SafetyFactors, SafetyFactor{
+			Factor: "street_lighting", Weight: safetyLightingWeight, Contribution: rating * safetyLightingWeight,
+			Evidence: fmt.Sprintf("Street lighting rated %d/10: %s", rating, safety.StreetLighting.Description),
+		})
+		if rating >= wellLitRating {
+			safety.SafetyFactors = append(safety.SafetyFactors, SafetyFactor{
+				Factor: "well_lit_streets", Weight: safetyWellLitWeight, Contribution: safetyWellLitWeight,
+				Evidence: fmt.Sprintf("%d street lamps nearby", safety.StreetLighting.LampCount),
+			})
+		}
+	}
+
+	// Fatores de risco; uma taxa estimada (ver CrimeStats.EstimateReason) não é dado
+	// da área e não pesa na nota
+	safety.RiskFactors = []SafetyFactor{}
+	if perCapita := safety.CrimeStats.PerCapita; perCapita > highCrimePerCapita && !safety.CrimeStats.Estimated {
+		evidence := fmt.Sprintf("%.3f crimes per capita, above the %.2f average", perCapita, highCrimePerCapita)
+		if avg := safety.CrimeStats.ComparedToCountyAvg; avg != nil && *avg > 0 {
+			evidence += fmt.Sprintf("; %.0f%% above the %s average", *avg, safety.CrimeStats.County)
+		}
+		safety.RiskFactors = append(safety.RiskFactors, SafetyFactor{
+			Factor: "crime_rate", Weight: safetyHighCrimeWeight, Contribution: -safetyHighCrimeWeight,
+			Evidence: evidence,
+		})
+	}
+
+	// Calcular score final (1-100)
+	score := inputs.Base
+	for _, f := range append(append([]SafetyFactor{}, safety.SafetyFactors...), safety.RiskFactors...) {
+		score += f.Contribution
+	}
+	inputs.RawScore = score
+
+	// Garantir que está entre 1-100
+	if score < 1 {
+		score = 1
+	} else if score > 100 {
+		score = 100
+	}
+
+	safety.SafetyScore = score
+	safety.ScoreInputs = inputs
+}
+
+// SafetyAnalysis é a análise de segurança detalhada de uma localização
+type SafetyAnalysis struct {
+	CrimeStats struct {
+		Total     int     `json:"total"`
+		PerCapita float64 `json:"perCapita"`
+		Estimated bool    `json:"estimated,omitempty"`
+
+		EstimateReason string `json:"estimateReason,omitempty"` // por que os números são estimados
+		Source         string `json:"source,omitempty"`         // cubo da CSO usado
+
+		// "district" ou "division": a área Garda de onde vêm os números
+		Granularity string `json:"granularity"`
+		Division    string `json:"division,omitempty"`
+		District    string `json:"district,omitempty"`
+
+		// per-capita acima (positivo) ou abaixo (negativo) da média, em %
+		County                string   `json:"county,omitempty"`
+		ComparedToCountyAvg   *float64 `json:"comparedToCountyAvg,omitempty"`
+		ComparedToNationalAvg *float64 `json:"comparedToNationalAvg,omitempty"`
+
+		Breakdown []struct {
+			Type  string `json:"type"`
+			Count int    `json:"count"`
+		} `json:"breakdown"`
+	} `json:"crimeStats"`
+	NearbyGardai []struct {
+		Name           string  `json:"name"`
+		DistanceMeters int     `json:"distanceMeters"`
+		Distance       float64 `json:"distance"` // em km; legado, use distanceMeters
+		Phone          string  `json:"phone,omitempty"`
+		Lat            float64 `json:"lat,omitempty"`
+		Lng            float64 `json:"lng,omitempty"`
+	} `json:"nearbyGardai"`
+	StreetLighting struct {
+		Rating           int     `json:"rating"` // 1-10
+		Description      string  `json:"description"`
+		LampCount        int     `json:"lampCount"`
+		RoadLengthMeters int     `json:"roadLengthMeters"`     // ruas dentro do raio
+		LampsPerKm       float64 `json:"lampsPerKm,omitempty"` // postes por km de rua
+	} `json:"streetLighting"`
+	SafetyScore   int               `json:"safetyScore"` // 1-100
+	SafetyFactors []SafetyFactor    `json:"safetyFactors"`
+	RiskFactors   []SafetyFactor    `json:"riskFactors"`
+	ScoreInputs   SafetyScoreInputs `json:"scoreInputs"` // entradas da fórmula do safetyScore
+
+	RoadSafety *RoadSafety `json:"roadSafety,omitempty"` // colisões com pedestres e ciclistas; nil sem RSA_COLLISIONS_URL
+}
+
+// RoadSafety resume as colisões perto do imóvel
+type RoadSafety struct {
+	PedestrianCollisions int  `json:"pedestrianCollisions"`
+	CyclistCollisions    int  `json:"cyclistCollisions"`
+	FatalCollisions      int  `json:"fatalCollisions"` // de todos os tipos
+	TotalCollisions      int  `json:"totalCollisions"`
+	RadiusMeters         int  `json:"radiusMeters"`
+	SinceYear            int  `json:"sinceYear"`
+	Partial              bool `json:"partial,omitempty"` // a camada cortou o resultado no limite de registros
+}
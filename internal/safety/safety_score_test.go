@@ -0,0 +1,65 @@
+package safety
+
+import "testing"
+
+func TestSafetyScoreExplainsItself(t *testing.T) {
+	var safety SafetyAnalysis
+	safety.NearbyGardai = append(safety.NearbyGardai, struct {
+		Name           string  `json:"name"`
+		DistanceMeters int     `json:"distanceMeters"`
+		Distance       float64 `json:"distance"`
+		Phone          string  `json:"phone,omitempty"`
+		Lat            float64 `json:"lat,omitempty"`
+		Lng            float64 `json:"lng,omitempty"`
+	}{Name: "Pearse Street Garda Station", Distance: 0.8})
+	safety.StreetLighting.Rating = 8
+	safety.StreetLighting.LampCount = 40
+	safety.CrimeStats.PerCapita = 0.031
+
+	Score(&safety)
+
+	// 70 + 5 (garda) + 16 (lighting 8 × 2) + 5 (well lit) - 10 (crime)
+	if safety.SafetyScore != 86 || safety.ScoreInputs.RawScore != 86 {
+		t.Fatalf("score %d, raw %d; want 86", safety.SafetyScore, safety.ScoreInputs.RawScore)
+	}
+	sum := safety.ScoreInputs.Base
+	for _, f := range append(safety.SafetyFactors, safety.RiskFactors...) {
+		if f.Factor == "" || f.Evidence == "" {
+			t.Errorf("factor without id or evidence: %+v", f)
+		}
+		sum += f.Contribution
+	}
+	if sum != safety.SafetyScore {
+		t.Errorf("base plus contributions = %d, score %d", sum, safety.SafetyScore)
+	}
+	if len(safety.RiskFactors) != 1 || safety.RiskFactors[0].Factor != "crime_rate" || safety.RiskFactors[0].Contribution != -10 {
+		t.Errorf("risk factors = %+v", safety.RiskFactors)
+	}
+	if km := safety.ScoreInputs.NearestGardaKm; km == nil || *km != 0.8 {
+		t.Errorf("nearest garda input = %v", km)
+	}
+}
+
+func TestSafetyScoreWithoutData(t *testing.T) {
+	var safety SafetyAnalysis
+	Score(&safety)
+	if safety.SafetyScore != safetyBaseScore || len(safety.SafetyFactors) != 0 || len(safety.RiskFactors) != 0 {
+		t.Errorf("score %d, factors %+v %+v; want the base alone", safety.SafetyScore, safety.SafetyFactors, safety.RiskFactors)
+	}
+	if safety.SafetyFactors == nil || safety.RiskFactors == nil {
+		t.Error("factor lists should encode as [] rather than null")
+	}
+	if safety.ScoreInputs.NearestGardaKm != nil {
+		t.Error("no garda station should leave nearestGardaKm out")
+	}
+}
+
+func TestSafetyScoreIgnoresEstimatedCrime(t *testing.T) {
+	var safety SafetyAnalysis
+	safety.CrimeStats.PerCapita = 0.045
+	safety.CrimeStats.Estimated = true
+	Score(&safety)
+	if len(safety.RiskFactors) != 0 || safety.SafetyScore != safetyBaseScore {
+		t.Errorf("an estimated crime rate should not count against the area: %+v", safety.RiskFactors)
+	}
+}
@@ -0,0 +1,102 @@
+package scraper
+
+import (
+	"regexp"
+	"strings"
+)
+
+/* ───── Anunciante: agência ou proprietário ─────────────────────────── */
+
+// O anunciante vem do objeto seller dos dados estruturados do anúncio. Agências
+// precisam de licença da PSRA (Property Services Regulatory Authority) e muitas só a
+// citam no rodapé da descrição, assim como proprietários deixam o telefone no texto;
+// completamos o que faltar pela descrição. Key agrupa os anúncios do mesmo anunciante
+// (GET /analyses/advertisers).
+
+// Advertiser é quem anuncia o imóvel
+type Advertiser struct {
+	Name          string `json:"name,omitempty"`
+	Type          string `json:"type,omitempty"` // agent ou private
+	Branch        string `json:"branch,omitempty"`
+	LicenceNumber string `json:"licenceNumber,omitempty"` // licença PSRA
+	Phone         string `json:"phone,omitempty"`
+	Key           string `json:"key,omitempty"` // licence:…, agent:… ou phone:…; "" sem como agrupar
+}
+
+var (
+	// psraPattern casa "PSRA Licence No: 001234", "PSRA No. 4567", "PSRA licence number 002345"
+	psraPattern = regexp.MustCompile(`(?i)\bPSRA\s*(?:licen[cs]e)?\s*(?:no\.?|number|#)?\s*:?\s*(\d{3,7})\b`)
+	// irishPhonePattern casa celulares (083..089) e fixos de Dublin (01), com ou sem +353
+	irishPhonePattern = regexp.MustCompile(`(?:\+353\s?\(?0?\)?\s?|\b0)(8[35679]|1)[\s-]?\d{3}[\s-]?\d{3,4}\b`)
+)
+
+// advertiser monta o anunciante a partir do seller do __NEXT_DATA__; nil sem nome
+func (l *DaftListing) advertiser() *Advertiser {
+	s := l.Seller
+	if strings.TrimSpace(s.Name) == "" {
+		return nil
+	}
+	a := &Advertiser{
+		Name:          strings.TrimSpace(s.Name),
+		Branch:        strings.TrimSpace(s.Branch),
+		LicenceNumber: strings.TrimSpace(s.LicenceNumber),
+		Phone:         strings.TrimSpace(s.Phone),
+		Type:          "agent",
+	}
+	if strings.EqualFold(s.SellerType, "PRIVATE_USER") {
+		a.Type = "private"
+	}
+	if a.Phone == "" {
+		a.Phone = strings.TrimSpace(s.AlternativePhone)
+	}
+	return a
+}
+
+// resolveAdvertiser completa o anunciante pela descrição (licença e telefone) e
+// calcula a chave de agrupamento
+func resolveAdvertiser(property *PropertyInfo) {
+	a := property.Advertiser
+	licence := ""
+	if m := psraPattern.FindStringSubmatch(property.Description); m != nil {
+		licence = m[1]
+	}
+	phone := irishPhonePattern.FindString(property.Description)
+	if a == nil {
+		if licence == "" && phone == "" {
+			return
+		}
+		a = &Advertiser{}
+	}
+	if a.LicenceNumber == "" {
+		a.LicenceNumber = licence
+	}
+	if a.Phone == "" {
+		a.Phone = phone
+	}
+	if a.Type == "" && a.LicenceNumber != "" {
+		a.Type = "agent"
+	}
+	a.Key = advertiserKey(a)
+	property.Advertiser = a
+}
+
+// advertiserKey prefere a licença PSRA (única por agência), depois o nome da agência
+// e, para proprietários, o telefone; nome de pessoa física sozinho juntaria estranhos
+func advertiserKey(a *Advertiser) string {
+	switch {
+	case a.LicenceNumber != "":
+		return "licence:" + strings.TrimLeft(a.LicenceNumber, "0")
+	case a.Type == "agent" && a.Name != "":
+		return "agent:" + strings.Join(Tokenize(a.Name), "-")
+	case a.Phone != "":
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, a.Phone)
+		// +353 87 … e 087 … são o mesmo número
+		return "phone:" + strings.TrimPrefix(strings.TrimPrefix(digits, "353"), "0")
+	}
+	return ""
+}
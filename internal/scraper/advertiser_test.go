@@ -0,0 +1,42 @@
+package scraper
+
+import "testing"
+
+func TestListingAdvertiser(t *testing.T) {
+	listing, err := parseListingNextData([]byte(`{"props":{"pageProps":{"listing":{"seller":{
+		"name":"Sherry FitzGerald","branch":"Sherry FitzGerald Bray","phone":"","alternativePhone":"01 286 1234",
+		"licenceNumber":"001234","sellerType":"BRANDED_AGENT"}}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := PropertyInfo{Advertiser: listing.advertiser()}
+	resolveAdvertiser(&p)
+	a := p.Advertiser
+	if a == nil || a.Type != "agent" || a.Phone != "01 286 1234" || a.Key != "licence:1234" {
+		t.Errorf("advertiser = %+v", a)
+	}
+}
+
+func TestResolveAdvertiserFromDescription(t *testing.T) {
+	// a private landlord who only leaves a phone number in the text
+	p := PropertyInfo{Description: "Two-bed cottage. Call Mary on 087 123 4567 to view."}
+	resolveAdvertiser(&p)
+	if p.Advertiser == nil || p.Advertiser.Phone != "087 123 4567" || p.Advertiser.Key != "phone:871234567" {
+		t.Errorf("advertiser = %+v", p.Advertiser)
+	}
+
+	// the licence in the footer fills the gap in the seller data
+	p = PropertyInfo{
+		Advertiser:  &Advertiser{Name: "Local Lettings", Type: "agent"},
+		Description: "Viewing strictly by appointment. PSRA Licence No: 002345",
+	}
+	resolveAdvertiser(&p)
+	if p.Advertiser.LicenceNumber != "002345" || p.Advertiser.Key != "licence:2345" {
+		t.Errorf("advertiser = %+v", p.Advertiser)
+	}
+
+	p = PropertyInfo{Description: "Bright apartment near the Luas."}
+	if resolveAdvertiser(&p); p.Advertiser != nil {
+		t.Errorf("expected no advertiser, got %+v", p.Advertiser)
+	}
+}
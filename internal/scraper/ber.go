@@ -0,0 +1,85 @@
+package scraper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/* ───── Classificação energética (BER) ──────────────────────────────── */
+
+// O BER vem de preferência dos dados estruturados do anúncio. Anúncios antigos ou de
+// agências que não preenchem o campo trazem só o selo (uma imagem com "BER C2" no alt
+// ou no nome do arquivo) ou a classificação no meio da descrição. A faixa alimenta a
+// estimativa de energia em internal/value/living_costs.go; F e G ganham um alerta próprio.
+
+const berBandPattern = `(A[1-3]|B[1-3]|C[1-3]|D[12]|E[12]|F|G)`
+
+// berTextPattern casa "BER: C2", "BER rating of B3", "Building Energy Rating E1"
+var berTextPattern = regexp.MustCompile(`(?i)\b(?:BER|building energy rating)\b\s*(?:rating|cert(?:ificate)?)?\s*(?:is|of)?\s*[:\-]?\s*` + berBandPattern + `\b`)
+
+// berFilePattern casa o nome do arquivo do selo: ".../ber/C2.svg", "ber-c2.png", "ber_G.png"
+var berFilePattern = regexp.MustCompile(`(?i)(?:^|[^a-z])ber[/_-]?` + berBandPattern + `(?:[^a-z0-9]|$)`)
+
+// parseBER procura a classificação num texto livre; "" se não achar
+func parseBER(text string) string {
+	if m := berTextPattern.FindStringSubmatch(text); m != nil {
+		return strings.ToUpper(m[1])
+	}
+	return ""
+}
+
+// berFromBadge lê a faixa do selo BER pelo alt ou, sem alt útil, pelo endereço da imagem
+func berFromBadge(alt, src string) string {
+	if ber := parseBER(alt); ber != "" {
+		return ber
+	}
+	if m := berFilePattern.FindStringSubmatch(src); m != nil {
+		return strings.ToUpper(m[1])
+	}
+	return ""
+}
+
+// ResolveBER completa o BER pela descrição quando nem o JSON nem o selo o trouxeram
+// e monta o alerta para F e G
+func ResolveBER(property *PropertyInfo) {
+	if property.BER == "" {
+		if ber := parseBER(property.Description); ber != "" {
+			property.BER, property.BERSource = ber, "description"
+		}
+	}
+	property.EnergyWarning = berWarning(property.BER)
+}
+
+// berWarning é o alerta para as piores faixas, com o consumo comparado a um C1
+func berWarning(ber string) string {
+	if ber != "F" && ber != "G" {
+		return ""
+	}
+	ratio := BEREnergyUse[ber] / BEREnergyUse["C1"]
+	return fmt.Sprintf("BER %s: one of the least energy-efficient ratings, using about %.1f times the energy of a C1 home; expect high heating bills and ask about insulation and the heating system",
+		ber, ratio)
+}
+
+// BEREnergyUse é o meio de cada faixa BER, em kWh/m²/ano
+var BEREnergyUse = map[string]float64{
+	"A1": 12.5, "A2": 37.5, "A3": 62.5,
+	"B1": 87.5, "B2": 112.5, "B3": 137.5,
+	"C1": 162.5, "C2": 187.5, "C3": 212.5,
+	"D1": 242.5, "D2": 280, "E1": 320, "E2": 360,
+	"F": 415, "G": 500,
+}
+
+// berBands do melhor (A1) ao pior (G)
+var berBands = []string{"A1", "A2", "A3", "B1", "B2", "B3", "C1", "C2", "C3", "D1", "D2", "E1", "E2", "F", "G"}
+
+// BERBand devolve a posição da faixa BER (maior = pior); -1 se inválida
+func BERBand(ber string) int {
+	ber = strings.ToUpper(strings.TrimSpace(ber))
+	for i, b := range berBands {
+		if b == ber {
+			return i
+		}
+	}
+	return -1
+}
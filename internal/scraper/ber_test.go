@@ -0,0 +1,55 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseBER(t *testing.T) {
+	cases := map[string]string{
+		"Bright apartment. BER: C2. Viewing by appointment": "C2",
+		"BER rating of b3, gas heating":                     "B3",
+		"Building Energy Rating E1":                         "E1",
+		"BER Cert: G":                                       "G",
+		"BER exempt (protected structure)":                  "",
+		"Amber glow in the evenings":                        "",
+		"No energy details":                                 "",
+	}
+	for text, want := range cases {
+		if got := parseBER(text); got != want {
+			t.Errorf("parseBER(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestBERFromBadge(t *testing.T) {
+	cases := []struct{ alt, src, want string }{
+		{"BER C2", "", "C2"},
+		{"", "https://hermes.daft.ie/dsch-daft-frontend/assets/images/ber/B3.svg", "B3"},
+		{"energy", "/static/ber-f.png", "F"},
+		{"", "/static/numbered/gallery.png", ""},
+	}
+	for _, c := range cases {
+		if got := berFromBadge(c.alt, c.src); got != c.want {
+			t.Errorf("berFromBadge(%q, %q) = %q, want %q", c.alt, c.src, got, c.want)
+		}
+	}
+}
+
+func TestResolveBER(t *testing.T) {
+	// the structured rating wins over the description
+	p := PropertyInfo{BER: "B2", BERSource: "listing", Description: "BER: G"}
+	ResolveBER(&p)
+	if p.BER != "B2" || p.BERSource != "listing" || p.EnergyWarning != "" {
+		t.Errorf("listing BER = %+v", p)
+	}
+
+	p = PropertyInfo{Description: "Two-bed cottage, BER F, oil heating"}
+	ResolveBER(&p)
+	if p.BER != "F" || p.BERSource != "description" {
+		t.Errorf("description BER = %q from %q", p.BER, p.BERSource)
+	}
+	if !strings.Contains(p.EnergyWarning, "2.6 times") {
+		t.Errorf("warning = %q", p.EnergyWarning)
+	}
+}
@@ -0,0 +1,291 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"daft-scraper-api/internal/env"
+)
+
+/* ───── Circuit breaker por fonte externa ───────────────────────────── */
+
+// ErrCircuitOpen indica que a chamada nem foi feita: a fonte falhou seguidamente e
+// está em pausa. ModuleError o traduz para UPSTREAM_UNAVAILABLE e a qualidade dos
+// dados marca o módulo como degraded.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitSettings são os limites do circuit breaker: Failures falhas seguidas abrem o
+// circuito (0 desliga) e ele fica aberto por Cooldown antes de testar de novo
+type CircuitSettings struct {
+	Failures int
+	Cooldown time.Duration
+}
+
+// CircuitSettingsFromEnv lê CIRCUIT_FAILURES (padrão 5; 0 desliga) e CIRCUIT_COOLDOWN
+// (padrão 30s)
+func CircuitSettingsFromEnv() CircuitSettings {
+	s := CircuitSettings{Failures: 5, Cooldown: 30 * time.Second}
+	if n, err := strconv.Atoi(os.Getenv("CIRCUIT_FAILURES")); err == nil && n >= 0 {
+		s.Failures = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("CIRCUIT_COOLDOWN")); err == nil && d > 0 {
+		s.Cooldown = d
+	}
+	return s
+}
+
+// circuitBreaker acompanha uma fonte externa. Fechado, tudo passa; depois de
+// Failures falhas seguidas abre e recusa na hora; passado o cooldown deixa
+// uma chamada de teste (half-open) e fecha de novo se ela der certo.
+// Seguro para uso concorrente.
+type circuitBreaker struct {
+	name string
+
+	mu       sync.Mutex
+	state    string // closed, open ou half-open
+	failures int
+	openedAt time.Time
+	probing  bool // a chamada de teste do half-open está em andamento
+
+	// para GET /admin/upstreams
+	lastError     string
+	lastErrorAt   time.Time
+	lastSuccessAt time.Time
+}
+
+// allow diz se a chamada pode seguir agora
+func (b *circuitBreaker) allow(now time.Time, s CircuitSettings) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if s.Failures == 0 {
+		return nil
+	}
+	switch b.state {
+	case "open":
+		if now.Sub(b.openedAt) < s.Cooldown {
+			return fmt.Errorf("%s is temporarily unavailable after repeated failures (%w)", b.name, ErrCircuitOpen)
+		}
+		b.state, b.probing = "half-open", true
+		slog.Info("Circuit half-open, sending a trial request", "upstream", b.name)
+	case "half-open":
+		if b.probing {
+			return fmt.Errorf("%s is temporarily unavailable, a trial request is in flight (%w)", b.name, ErrCircuitOpen)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record registra o resultado de uma chamada liberada por allow; ok=nil quando a
+// chamada não diz nada sobre a fonte (cancelada pelo cliente, recusada por nós)
+func (b *circuitBreaker) record(ok *bool, now time.Time, s CircuitSettings) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if ok == nil {
+		return
+	}
+	if *ok {
+		if b.state != "closed" {
+			slog.Info("Circuit closed", "upstream", b.name)
+		}
+		b.state, b.failures = "closed", 0
+		return
+	}
+	b.failures++
+	if b.state == "half-open" || (s.Failures > 0 && b.failures >= s.Failures) {
+		if b.state != "open" {
+			slog.Warn("Circuit opened", "upstream", b.name, "failures", b.failures)
+		}
+		b.state, b.openedAt = "open", now
+	}
+}
+
+// note guarda o resultado da última chamada, para GET /admin/upstreams
+func (b *circuitBreaker) note(ok bool, detail string, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if ok {
+		b.lastSuccessAt = now
+		return
+	}
+	b.lastError, b.lastErrorAt = detail, now
+}
+
+// circuits guarda um circuitBreaker por fonte, criado no primeiro uso
+var circuits = struct {
+	sync.Mutex
+	byName map[string]*circuitBreaker
+}{byName: map[string]*circuitBreaker{}}
+
+func circuitFor(name string) *circuitBreaker {
+	circuits.Lock()
+	defer circuits.Unlock()
+	b, ok := circuits.byName[name]
+	if !ok {
+		b = &circuitBreaker{name: name, state: "closed"}
+		circuits.byName[name] = b
+	}
+	return b
+}
+
+// UpstreamName agrupa os hosts por fonte: todos os endpoints do Google Maps caem no
+// mesmo circuito, assim como www.daft.ie e daft.ie. Cada mirror do Overpass tem o
+// seu ("overpass:host"), para que um mirror fora do ar não bloqueie os outros.
+func UpstreamName(host string) string {
+	host = strings.ToLower(host)
+	if isOverpassHost(host) {
+		return "overpass:" + host
+	}
+	switch {
+	case strings.HasSuffix(host, "googleapis.com"):
+		return "google"
+	case strings.HasSuffix(host, "cso.ie"):
+		return "cso"
+	case strings.HasSuffix(host, "arcgis.com"):
+		return "arcgis"
+	case strings.Contains(host, "overpass"):
+		return "overpass:" + host
+	case host == "daft.ie" || strings.HasSuffix(host, ".daft.ie"):
+		return "daft"
+	}
+	return strings.TrimPrefix(host, "www.")
+}
+
+// CircuitTransport passa cada requisição pelo circuito da sua fonte. Fica por fora do
+// retryTransport, então uma chamada que esgotou as tentativas conta como uma falha.
+// Com settings zerado o circuito nunca abre.
+type CircuitTransport struct {
+	Base     http.RoundTripper
+	Settings CircuitSettings
+}
+
+func (t CircuitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	b := circuitFor(UpstreamName(req.URL.Host))
+	if err := b.allow(time.Now(), t.Settings); err != nil {
+		return nil, err
+	}
+	resp, err := t.Base.RoundTrip(req)
+	ok, now := callSucceeded(resp, err), time.Now()
+	b.record(ok, now, t.Settings)
+	if ok != nil {
+		detail := ""
+		if err != nil {
+			detail = err.Error()
+		} else if !*ok {
+			detail = req.URL.Host + ": " + resp.Status
+		}
+		b.note(*ok, detail, now)
+	}
+	return resp, err
+}
+
+// callSucceeded diz se a chamada mostra a fonte saudável (true), com problema
+// (false) ou não diz nada (nil): cancelamento pelo cliente ou recusa nossa, como
+// a falta de orçamento do Maps. Um timeout de etapa conta como falha.
+func callSucceeded(resp *http.Response, err error) *bool {
+	ok := err == nil && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests
+	if err != nil {
+		var apiErr *APIError
+		if errors.Is(err, context.Canceled) || errors.As(err, &apiErr) {
+			return nil
+		}
+	}
+	return &ok
+}
+
+// DefaultOverpassMirrors são os mirrors públicos usados sem OVERPASS_MIRRORS
+var DefaultOverpassMirrors = []string{
+	"https://overpass-api.de/api/interpreter",
+	"https://overpass.kumi.systems/api/interpreter",
+	"https://maps.mail.ru/osm/tools/overpass/api/interpreter",
+}
+
+// OverpassEndpoints lista as instâncias em ordem de preferência: OVERPASS_URL (uma
+// instância própria) e depois OVERPASS_MIRRORS, sem repetir
+func OverpassEndpoints() []string {
+	var endpoints []string
+	seen := map[string]bool{}
+	for _, u := range append([]string{os.Getenv("OVERPASS_URL")}, env.List("OVERPASS_MIRRORS", DefaultOverpassMirrors)...) {
+		if u = strings.TrimSpace(u); u != "" && !seen[u] {
+			seen[u] = true
+			endpoints = append(endpoints, u)
+		}
+	}
+	return endpoints
+}
+
+// isOverpassHost informa se host é de uma das instâncias configuradas
+func isOverpassHost(host string) bool {
+	for _, endpoint := range OverpassEndpoints() {
+		if u, err := url.Parse(endpoint); err == nil && strings.EqualFold(u.Host, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// UpstreamStatus é um item de GET /admin/upstreams: o circuito de uma fonte externa
+type UpstreamStatus struct {
+	Name          string     `json:"name"`
+	State         string     `json:"state"` // closed, open ou half-open
+	Failures      int        `json:"failures"`
+	OpenedAt      *time.Time `json:"openedAt,omitempty"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastErrorAt   *time.Time `json:"lastErrorAt,omitempty"`
+	LastSuccessAt *time.Time `json:"lastSuccessAt,omitempty"`
+}
+
+// UpstreamStatuses é o estado do circuito de cada fonte chamada desde o start, em
+// ordem de nome
+func UpstreamStatuses() []UpstreamStatus {
+	circuits.Lock()
+	breakers := make([]*circuitBreaker, 0, len(circuits.byName))
+	for _, b := range circuits.byName {
+		breakers = append(breakers, b)
+	}
+	circuits.Unlock()
+
+	statuses := make([]UpstreamStatus, 0, len(breakers))
+	for _, b := range breakers {
+		statuses = append(statuses, b.status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// status copia o estado do circuito
+func (b *circuitBreaker) status() UpstreamStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	at := func(t time.Time) *time.Time {
+		if t.IsZero() {
+			return nil
+		}
+		return &t
+	}
+	s := UpstreamStatus{
+		Name:          b.name,
+		State:         b.state,
+		Failures:      b.failures,
+		LastError:     b.lastError,
+		LastErrorAt:   at(b.lastErrorAt),
+		LastSuccessAt: at(b.lastSuccessAt),
+	}
+	if b.state != "closed" {
+		s.OpenedAt = at(b.openedAt)
+	}
+	return s
+}
@@ -0,0 +1,163 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func freshCircuits(t *testing.T) {
+	t.Helper()
+	circuits.Lock()
+	prev := circuits.byName
+	circuits.byName = map[string]*circuitBreaker{}
+	circuits.Unlock()
+	t.Cleanup(func() {
+		circuits.Lock()
+		circuits.byName = prev
+		circuits.Unlock()
+	})
+}
+
+func TestCircuitBreakerStates(t *testing.T) {
+	t.Setenv("CIRCUIT_FAILURES", "2")
+	t.Setenv("CIRCUIT_COOLDOWN", "30s")
+	settings := CircuitSettingsFromEnv()
+	b := &circuitBreaker{name: "overpass", state: "closed"}
+	now := time.Now()
+	ok, failed := true, false
+
+	b.record(&failed, now, settings)
+	b.record(&ok, now, settings) // a success resets the count
+	b.record(&failed, now, settings)
+	if err := b.allow(now, settings); err != nil {
+		t.Fatalf("one failure in a row should not open the circuit: %v", err)
+	}
+	b.record(&failed, now, settings)
+	if err := b.allow(now, settings); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("two failures in a row should open the circuit, got %v", err)
+	}
+
+	// after the cooldown one trial request goes through, the rest wait for it
+	later := now.Add(31 * time.Second)
+	if err := b.allow(later, settings); err != nil {
+		t.Fatalf("trial request refused: %v", err)
+	}
+	if err := b.allow(later, settings); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("a second request during the trial should be refused, got %v", err)
+	}
+	// a failed trial opens the circuit again straight away
+	b.record(&failed, later, settings)
+	if err := b.allow(later.Add(time.Second), settings); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("a failed trial should reopen the circuit, got %v", err)
+	}
+
+	muchLater := later.Add(time.Minute)
+	b.allow(muchLater, settings)
+	b.record(&ok, muchLater, settings)
+	if b.state != "closed" || b.failures != 0 {
+		t.Errorf("a successful trial should close the circuit, got %s with %d failures", b.state, b.failures)
+	}
+}
+
+func TestCircuitTransportSkipsFailingUpstream(t *testing.T) {
+	freshCircuits(t)
+	settings := CircuitSettings{Failures: 2, Cooldown: 30 * time.Second}
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: CircuitTransport{Base: http.DefaultTransport, Settings: settings}}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	start := time.Now()
+	_, err := client.Get(srv.URL)
+	if !errors.Is(err, ErrCircuitOpen) || calls != 2 {
+		t.Fatalf("expected the open circuit to refuse without calling, got %v after %d calls", err, calls)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("an open circuit should fail fast")
+	}
+
+	if apiErr := ModuleError(err, "OVERPASS_FAILED", "safety"); apiErr.Code != "UPSTREAM_UNAVAILABLE" {
+		t.Errorf("module warning = %+v", apiErr)
+	}
+}
+
+func TestCircuitIgnoresCancelledCalls(t *testing.T) {
+	freshCircuits(t)
+	settings := CircuitSettings{Failures: 1, Cooldown: 30 * time.Second}
+	srv := hangingServer(t)
+	client := &http.Client{Transport: CircuitTransport{Base: http.DefaultTransport, Settings: settings}}
+
+	// the client going away says nothing about the upstream
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("expected the cancelled request to fail")
+	}
+	if err := circuitFor(UpstreamName(req.URL.Host)).allow(time.Now(), settings); err != nil {
+		t.Errorf("a cancelled call should not open the circuit: %v", err)
+	}
+}
+
+func TestUpstreamStatuses(t *testing.T) {
+	freshCircuits(t)
+	settings := CircuitSettings{Failures: 1, Cooldown: 30 * time.Second}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: CircuitTransport{Base: http.DefaultTransport, Settings: settings}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	circuitFor("daft").note(true, "", time.Now())
+
+	statuses := UpstreamStatuses()
+	if len(statuses) != 2 || statuses[1].Name != "daft" {
+		t.Fatalf("statuses = %+v", statuses)
+	}
+	if s := statuses[0]; s.State != "open" || s.OpenedAt == nil || s.LastErrorAt == nil || !strings.Contains(s.LastError, "503") {
+		t.Errorf("failing upstream = %+v", s)
+	}
+	if s := statuses[1]; s.State != "closed" || s.LastSuccessAt == nil || s.LastError != "" || s.OpenedAt != nil {
+		t.Errorf("daft = %+v", s)
+	}
+}
+
+func TestUpstreamName(t *testing.T) {
+	t.Setenv("OVERPASS_URL", "https://overpass.example.org/api/interpreter")
+	cases := map[string]string{
+		"maps.googleapis.com":  "google",
+		"ws.cso.ie":            "cso",
+		"services1.arcgis.com": "arcgis",
+		"overpass-api.de":      "overpass:overpass-api.de",
+		"overpass.example.org": "overpass:overpass.example.org",
+		"maps.mail.ru":         "overpass:maps.mail.ru",
+		"www.daft.ie":          "daft",
+		"daft.ie":              "daft",
+		"www.rent.ie":          "rent.ie",
+	}
+	for host, want := range cases {
+		if got := UpstreamName(host); got != want {
+			t.Errorf("upstreamName(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
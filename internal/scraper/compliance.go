@@ -0,0 +1,70 @@
+package scraper
+
+import (
+	"regexp"
+	"strings"
+)
+
+/* ───── Equal Status Acts 2000–2018: frases discriminatórias ────────── */
+
+// ComplianceFlag é um trecho do anúncio que pode violar a legislação de igualdade irlandesa
+type ComplianceFlag struct {
+	Ground      string `json:"ground"` // fundamento protegido (housing_assistance, family_status, ...)
+	Phrase      string `json:"phrase"`
+	Note        string `json:"note"`
+	Indirect    bool   `json:"indirect,omitempty"`    // discriminação indireta (depende do caso)
+	MayBeExempt bool   `json:"mayBeExempt,omitempty"` // quarto na casa do proprietário, s.6(2)(d)
+}
+
+type complianceRule struct {
+	pattern  *regexp.Regexp
+	ground   string
+	note     string
+	indirect bool
+}
+
+var complianceRules = []complianceRule{
+	{regexp.MustCompile(`(?i)\b(no|not accepting|does not accept|not suitable for|cannot accept)\s+(hap|rent allowance|rent supplement|housing assistance|ras)\b|\b(hap|rent allowance)\s+not\s+(accepted|considered)\b`),
+		"housing_assistance", "Refusing HAP or rent supplement is prohibited on the housing assistance ground", false},
+	{regexp.MustCompile(`(?i)\bprofessionals?\s+only\b|\bworking\s+professionals?\s+only\b`),
+		"housing_assistance", "\"Professionals only\" can indirectly exclude people receiving housing assistance", true},
+	{regexp.MustCompile(`(?i)\bno\s+(children|kids|families|single parents)\b|\b(not suitable|unsuitable)\s+for\s+(children|families)\b|\bchild[\s-]free\b`),
+		"family_status", "Excluding tenants with children breaches the family status ground", false},
+	{regexp.MustCompile(`(?i)\b(married|non-married)\s+couples?\s+only\b`),
+		"civil_status", "Restricting by marital or civil status breaches the civil status ground", false},
+	{regexp.MustCompile(`(?i)\b(young\s+professionals?|students?)\s+only\b|\b(under|over)\s+\d{2}s?\s+only\b|\baged?\s+\d{2}\s*[-–]\s*\d{2}\s+only\b`),
+		"age", "Age limits on tenants breach the age ground", false},
+	{regexp.MustCompile(`(?i)\b(irish|european|eu|non[\s-]eu)\s+(nationals?|citizens?|people)?\s*only\b|\bno\s+(foreigners|non[\s-]nationals)\b`),
+		"race", "Restricting by nationality or ethnic origin breaches the race ground", false},
+	{regexp.MustCompile(`(?i)\bno\s+travell?ers\b`),
+		"traveller_community", "Excluding members of the Traveller community is prohibited", false},
+	{regexp.MustCompile(`(?i)\b(female|male|ladies|girls|guys|men|women)s?\s+only\b`),
+		"gender", "Gender restrictions are only lawful in limited shared-accommodation cases", false},
+	{regexp.MustCompile(`(?i)\b(christians?|catholics?|muslims?|protestants?)\s+only\b`),
+		"religion", "Restricting by religion breaches the religion ground", false},
+	{regexp.MustCompile(`(?i)\bno\s+(wheelchairs?|disabled)\b|\bmust\s+be\s+(able[\s-]bodied|in good health)\b`),
+		"disability", "Excluding people with disabilities breaches the disability ground", false},
+}
+
+// CheckCompliance procura frases que podem violar o Equal Status Act. Anúncios de quarto
+// compartilhado (/share/) podem estar isentos quando o proprietário mora no imóvel.
+func CheckCompliance(property *PropertyInfo) []ComplianceFlag {
+	shared := strings.Contains(property.URL, "/share/")
+	text := property.Description
+
+	flags := []ComplianceFlag{}
+	for _, rule := range complianceRules {
+		match := rule.pattern.FindString(text)
+		if match == "" {
+			continue
+		}
+		flags = append(flags, ComplianceFlag{
+			Ground:      rule.ground,
+			Phrase:      strings.TrimSpace(match),
+			Note:        rule.note,
+			Indirect:    rule.indirect,
+			MayBeExempt: shared,
+		})
+	}
+	return flags
+}
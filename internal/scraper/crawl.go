@@ -0,0 +1,106 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"daft-scraper-api/internal/env"
+
+	"github.com/gocolly/colly/v2"
+)
+
+/* ───── Cortesia com os portais (LimitRule por domínio) ─────────────── */
+
+// Todo collector (anúncio, histórico de preço, comparáveis, buscas) passa por
+// LimitCrawl, que aplica uma LimitRule por portal. CRAWL_DELAY, CRAWL_RANDOM_DELAY e
+// CRAWL_PARALLELISM valem para todos; CRAWL_<DOMÍNIO>_DELAY e afins (CRAWL_DAFT_IE_DELAY,
+// ou [crawl.daft_ie] delay = "3s" no config.toml) sobrescrevem um portal. O colly espera
+// o delay depois de cada requisição, dentro do próprio Visit.
+
+// crawlDomains são os portais raspados, com uma regra cada
+var crawlDomains = []string{"daft.ie", "rent.ie", "myhome.ie"}
+
+const (
+	defaultCrawlDelay       = 2 * time.Second
+	defaultCrawlRandomDelay = 1 * time.Second
+	defaultCrawlParallelism = 1
+)
+
+// crawlRule monta a regra do domínio com a configuração dele ou a geral
+func crawlRule(domain string) *colly.LimitRule {
+	prefix := "CRAWL_" + env.Name(domain) + "_"
+	return &colly.LimitRule{
+		DomainGlob:  "*" + domain + "*",
+		Delay:       crawlDuration(prefix+"DELAY", crawlDuration("CRAWL_DELAY", defaultCrawlDelay)),
+		RandomDelay: crawlDuration(prefix+"RANDOM_DELAY", crawlDuration("CRAWL_RANDOM_DELAY", defaultCrawlRandomDelay)),
+		Parallelism: env.Int(prefix+"PARALLELISM", env.Int("CRAWL_PARALLELISM", defaultCrawlParallelism)),
+	}
+}
+
+// crawlDuration lê um intervalo da variável; "0s" desliga o delay, inválido usa def
+func crawlDuration(name string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(name)); err == nil && d >= 0 {
+		return d
+	}
+	return def
+}
+
+// LimitCrawl aplica ao collector as regras de todos os portais; só a do domínio
+// visitado entra em jogo
+func LimitCrawl(c *colly.Collector) {
+	for _, domain := range crawlDomains {
+		c.Limit(crawlRule(domain))
+	}
+}
+
+/* ───── Orçamento de páginas por execução ───────────────────────────── */
+
+// Uma execução (uma análise, um lote, uma análise de busca) pode pedir no máximo
+// CRAWL_BUDGET_PAGES páginas de busca e de comparáveis, somadas. O lote divide um
+// orçamento só entre os anúncios, para que 25 URLs não virem 75 buscas no Daft; o que
+// passa do limite fica de fora, com um aviso no log.
+
+const defaultCrawlBudgetPages = 40
+
+// ErrCrawlBudgetExhausted indica que a execução já usou todas as páginas permitidas
+var ErrCrawlBudgetExhausted = errors.New("crawl budget exhausted")
+
+// crawlBudget conta as páginas que ainda podem ser pedidas; seguro para uso concorrente
+type crawlBudget struct {
+	remaining int32
+}
+
+type crawlBudgetKey struct{}
+
+// WithCrawlBudget abre o orçamento da execução; se ctx já tem um (a análise dentro de
+// um lote), ele continua valendo
+func WithCrawlBudget(ctx context.Context) context.Context {
+	if crawlBudgetFrom(ctx) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, crawlBudgetKey{}, &crawlBudget{remaining: int32(env.Int("CRAWL_BUDGET_PAGES", defaultCrawlBudgetPages))})
+}
+
+func crawlBudgetFrom(ctx context.Context) *crawlBudget {
+	b, _ := ctx.Value(crawlBudgetKey{}).(*crawlBudget)
+	return b
+}
+
+// ShareCrawlBudget passa para ctx o orçamento de from, que os dois gastam juntos (o
+// trabalho destacado de uma requisição conta nas páginas dela)
+func ShareCrawlBudget(ctx, from context.Context) context.Context {
+	if b := crawlBudgetFrom(from); b != nil {
+		return context.WithValue(ctx, crawlBudgetKey{}, b)
+	}
+	return ctx
+}
+
+// TakeCrawlPage debita uma página do orçamento; false quando ele acabou. Sem
+// orçamento no contexto não há limite.
+func TakeCrawlPage(ctx context.Context) bool {
+	b := crawlBudgetFrom(ctx)
+	return b == nil || atomic.AddInt32(&b.remaining, -1) >= 0
+}
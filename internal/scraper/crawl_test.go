@@ -0,0 +1,52 @@
+package scraper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+func TestCrawlRule(t *testing.T) {
+	r := crawlRule("daft.ie")
+	if r.DomainGlob != "*daft.ie*" || r.Delay != defaultCrawlDelay || r.RandomDelay != defaultCrawlRandomDelay || r.Parallelism != 1 {
+		t.Errorf("default rule = %+v", r)
+	}
+
+	// the general settings apply to every domain, the per-domain ones override them
+	t.Setenv("CRAWL_DELAY", "500ms")
+	t.Setenv("CRAWL_RANDOM_DELAY", "0s")
+	t.Setenv("CRAWL_MYHOME_IE_DELAY", "5s")
+	t.Setenv("CRAWL_MYHOME_IE_PARALLELISM", "2")
+	if r := crawlRule("rent.ie"); r.Delay != 500*time.Millisecond || r.RandomDelay != 0 || r.Parallelism != 1 {
+		t.Errorf("rent.ie rule = %+v", r)
+	}
+	if r := crawlRule("myhome.ie"); r.Delay != 5*time.Second || r.RandomDelay != 0 || r.Parallelism != 2 {
+		t.Errorf("myhome.ie rule = %+v", r)
+	}
+
+	t.Setenv("CRAWL_DAFT_IE_DELAY", "soon")
+	if r := crawlRule("daft.ie"); r.Delay != 500*time.Millisecond {
+		t.Errorf("invalid delay should fall back to CRAWL_DELAY, got %v", r.Delay)
+	}
+}
+
+func TestLimitCrawlOnlyMatchesPortals(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// a host outside crawlDomains (the test server) is not slowed down
+	c := colly.NewCollector(colly.AllowURLRevisit())
+	LimitCrawl(c)
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := c.Visit(srv.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > defaultCrawlDelay {
+		t.Errorf("unrelated host waited %v", elapsed)
+	}
+}
@@ -0,0 +1,127 @@
+package scraper
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/env"
+	"daft-scraper-api/internal/telemetry"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/gocolly/colly/v2/debug"
+)
+
+/* ───── Modo debug: eventos do colly e HTML raspado ─────────────────── */
+
+// Fora do modo debug o scraping não loga os eventos do colly nem grava nada em disco
+// (containers com sistema de arquivos só de leitura, páginas de terceiros largadas no
+// diretório de trabalho). DEBUG=true liga o modo para tudo; ?debug=true liga só para
+// a requisição. O HTML de cada página vai para DEBUG_DIR, um arquivo por página, e os
+// arquivos mais velhos que DEBUG_RETENTION são apagados a cada gravação.
+
+const defaultDebugRetention = 24 * time.Hour
+
+type debugKey struct{}
+
+// WithDebug liga o modo debug para o trabalho feito com ctx
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey{}, true)
+}
+
+// DebugEnabled diz se o modo debug vale para ctx (DEBUG=true ou ?debug=true)
+func DebugEnabled(ctx context.Context) bool {
+	on, _ := ctx.Value(debugKey{}).(bool)
+	return on || env.Or("DEBUG", "false") == "true"
+}
+
+// DebugCollector manda os eventos do collector para o log quando o modo debug vale
+func DebugCollector(ctx context.Context, c *colly.Collector) {
+	if DebugEnabled(ctx) {
+		c.SetDebugger(collyDebugger{telemetry.LogFor(ctx)})
+	}
+}
+
+func debugDir() string {
+	return env.Or("DEBUG_DIR", filepath.Join("data", "debug"))
+}
+
+func debugRetention() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("DEBUG_RETENTION")); err == nil && d > 0 {
+		return d
+	}
+	return defaultDebugRetention
+}
+
+// SaveDebugPage grava o HTML da resposta em DEBUG_DIR quando o modo debug vale para
+// ctx; name identifica a página no nome do arquivo. Falhas só vão para o log.
+func SaveDebugPage(ctx context.Context, name string, r *colly.Response) {
+	if !DebugEnabled(ctx) {
+		return
+	}
+	dir := debugDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		telemetry.LogFor(ctx).Warn("Could not create the debug directory", "dir", dir, "error", err)
+		return
+	}
+	now := time.Now()
+	// o instante no nome separa os scrapings concorrentes da mesma página
+	file := filepath.Join(dir, now.UTC().Format("20060102T150405.000000000")+"-"+debugFileName(name)+".html")
+	if err := os.WriteFile(file, r.Body, 0o600); err != nil {
+		telemetry.LogFor(ctx).Warn("Could not save the debug page", "file", file, "error", err)
+		return
+	}
+	telemetry.LogFor(ctx).Info("Debug page saved", "file", file, "url", r.Request.URL.String())
+	pruneDebugPages(ctx, dir, now.Add(-debugRetention()))
+}
+
+// debugFileName deixa só letras, números e hífens no nome
+func debugFileName(name string) string {
+	return strings.Trim(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '-'
+	}, name), "-")
+}
+
+// pruneDebugPages apaga os arquivos de debug modificados antes de cutoff
+func pruneDebugPages(ctx context.Context, dir string, cutoff time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".html") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil && !os.IsNotExist(err) {
+			telemetry.LogFor(ctx).Warn("Could not remove an old debug page", "file", e.Name(), "error", err)
+		}
+	}
+}
+
+// collyDebugger manda os eventos do colly para o log, no lugar do debug.LogDebugger
+// que escrevia tudo no stderr. Só é ligado no modo debug (DebugCollector), então os
+// eventos saem em nível info, sem depender de LOG_LEVEL.
+type collyDebugger struct {
+	log *slog.Logger
+}
+
+func (d collyDebugger) Init() error { return nil }
+
+func (d collyDebugger) Event(e *debug.Event) {
+	args := []any{"collector", e.CollectorID, "request", e.RequestID}
+	for k, v := range e.Values {
+		args = append(args, k, v)
+	}
+	d.log.Info("Scraper "+e.Type, args...)
+}
@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+func debugResponse(t *testing.T, body string) *colly.Response {
+	t.Helper()
+	u, err := url.Parse("https://www.daft.ie/for-rent/x/123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &colly.Response{Body: []byte(body), Request: &colly.Request{URL: u}}
+}
+
+func TestSaveDebugPageOnlyInDebugMode(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DEBUG", "false")
+	t.Setenv("DEBUG_DIR", dir)
+
+	SaveDebugPage(context.Background(), "listing 123", debugResponse(t, "<html>off</html>"))
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("debug off wrote %d files", len(entries))
+	}
+
+	SaveDebugPage(WithDebug(context.Background()), "listing 123", debugResponse(t, "<html>on</html>"))
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("debug on wrote %d files, want 1", len(entries))
+	}
+	name := entries[0].Name()
+	if filepath.Ext(name) != ".html" || !strings.HasSuffix(name, "-listing-123.html") {
+		t.Errorf("file name = %q", name)
+	}
+	if body, _ := os.ReadFile(filepath.Join(dir, name)); string(body) != "<html>on</html>" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestSaveDebugPagePrunesOldFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DEBUG_DIR", dir)
+	t.Setenv("DEBUG_RETENTION", "1h")
+
+	old := filepath.Join(dir, "old.html")
+	keep := filepath.Join(dir, "notes.txt")
+	for _, f := range []string{old, keep} {
+		if err := os.WriteFile(f, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		stale := time.Now().Add(-2 * time.Hour)
+		if err := os.Chtimes(f, stale, stale); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	SaveDebugPage(WithDebug(context.Background()), "comparables daft.ie", debugResponse(t, "<html></html>"))
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("the expired page should have been removed")
+	}
+	if _, err := os.Stat(keep); err != nil {
+		t.Error("files that are not debug pages must be kept")
+	}
+}
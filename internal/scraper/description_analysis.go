@@ -0,0 +1,96 @@
+package scraper
+
+import (
+	"regexp"
+	"strings"
+)
+
+/* ───── Análise por regras da descrição do anúncio ──────────────────── */
+
+// DescriptionAnalysis reúne alertas e pontos fortes/fracos encontrados no texto do anúncio
+type DescriptionAnalysis struct {
+	RedFlags []DescriptionFlag `json:"redFlags"`
+	Pros     []string          `json:"pros"`
+	Cons     []string          `json:"cons"`
+}
+
+// DescriptionFlag é um trecho preocupante da descrição
+type DescriptionFlag struct {
+	Category string `json:"category"`
+	Phrase   string `json:"phrase"` // trecho encontrado no texto
+	Note     string `json:"note"`
+}
+
+type descriptionRule struct {
+	pattern  *regexp.Regexp
+	kind     string // red_flag, pro ou con
+	category string
+	text     string // nota do alerta ou texto do pró/contra
+}
+
+var descriptionRules = []descriptionRule{
+	// Alertas
+	{regexp.MustCompile(`(?i)\b(no|not accepting|does not accept|not suitable for)\s+(rent allowance|hap|housing assistance|rent supplement)\b`),
+		"red_flag", "housing_assistance", "Refuses housing assistance (HAP / rent allowance)"},
+	{regexp.MustCompile(`(?i)\bsuit(s|able for)?\s+(a\s+)?single\s+(person|professional|occupant)(\s+only)?\b`),
+		"red_flag", "occupancy_restriction", "Restricted to a single occupant"},
+	{regexp.MustCompile(`(?i)\bno\s+(children|kids|families)\b`),
+		"red_flag", "family_status", "Excludes families or children"},
+	{regexp.MustCompile(`(?i)\b(irish|european|eu)\s+(nationals?\s+)?only\b|\bno\s+foreigners\b`),
+		"red_flag", "nationality", "Restricts applicants by nationality"},
+	{regexp.MustCompile(`(?i)\b(western union|moneygram|wire transfer|deposit (before|prior to) (the )?viewing|currently (living )?(abroad|overseas)|keys? (will be )?(posted|sent by courier))\b`),
+		"red_flag", "scam", "Common rental scam pattern (payment before viewing / absent landlord)"},
+	{regexp.MustCompile(`(?i)\bcash only\b`),
+		"red_flag", "payment", "Cash-only payment"},
+	{regexp.MustCompile(`(?i)\bno\s+viewings?\b`),
+		"red_flag", "scam", "No viewings offered"},
+
+	// Pontos fortes
+	{regexp.MustCompile(`(?i)\b(newly|recently|fully)\s+(refurbished|renovated|decorated)\b`), "pro", "", "Recently refurbished"},
+	{regexp.MustCompile(`(?i)\bsouth[\s-]facing\b`), "pro", "", "South-facing"},
+	{regexp.MustCompile(`(?i)\bbalcony\b`), "pro", "", "Balcony"},
+	{regexp.MustCompile(`(?i)\b(private|designated|off[\s-]street|secure)\s+parking\b|\bparking space\b`), "pro", "", "Parking"},
+	{regexp.MustCompile(`(?i)\ben[\s-]?suite\b`), "pro", "", "Ensuite"},
+	{regexp.MustCompile(`(?i)\b(private|rear|back|front)\s+garden\b`), "pro", "", "Garden"},
+	{regexp.MustCompile(`(?i)\bbills\s+(are\s+)?included\b|\ball[\s-]inclusive\b`), "pro", "", "Bills included"},
+	{regexp.MustCompile(`(?i)\bdishwasher\b`), "pro", "", "Dishwasher"},
+	{regexp.MustCompile(`(?i)\b(walking distance|minutes?('s)? walk|close)\s+(from|to)\s+(the\s+)?(luas|dart|train|bus)\b`), "pro", "", "Close to public transport"},
+	{regexp.MustCompile(`(?i)\bpets?\s+(are\s+)?(welcome|allowed|considered)\b`), "pro", "", "Pet friendly"},
+
+	// Pontos fracos
+	{regexp.MustCompile(`(?i)\bno\s+parking\b`), "con", "", "No parking"},
+	{regexp.MustCompile(`(?i)\bshared\s+bathroom\b`), "con", "", "Shared bathroom"},
+	{regexp.MustCompile(`(?i)\bbills\s+(are\s+)?(not included|extra|excluded)\b|\bplus\s+bills\b`), "con", "", "Bills not included"},
+	{regexp.MustCompile(`(?i)\bstorage heaters?\b`), "con", "", "Storage heating"},
+	{regexp.MustCompile(`(?i)\bno\s+pets\b`), "con", "", "No pets"},
+	{regexp.MustCompile(`(?i)\b(no lift|no elevator|walk[\s-]?up)\b`), "con", "", "No lift"},
+	{regexp.MustCompile(`(?i)\b(box room|single room)\b`), "con", "", "Small room"},
+}
+
+// AnalyzeDescription aplica as regras ao texto do anúncio
+func AnalyzeDescription(description string) DescriptionAnalysis {
+	analysis := DescriptionAnalysis{RedFlags: []DescriptionFlag{}, Pros: []string{}, Cons: []string{}}
+	seen := map[string]bool{}
+
+	for _, rule := range descriptionRules {
+		match := rule.pattern.FindString(description)
+		if match == "" || seen[rule.text] {
+			continue
+		}
+		seen[rule.text] = true
+
+		switch rule.kind {
+		case "red_flag":
+			analysis.RedFlags = append(analysis.RedFlags, DescriptionFlag{
+				Category: rule.category,
+				Phrase:   strings.TrimSpace(match),
+				Note:     rule.text,
+			})
+		case "pro":
+			analysis.Pros = append(analysis.Pros, rule.text)
+		case "con":
+			analysis.Cons = append(analysis.Cons, rule.text)
+		}
+	}
+	return analysis
+}
@@ -0,0 +1,52 @@
+package scraper
+
+import "testing"
+
+func TestAnalyzeDescription(t *testing.T) {
+	desc := "Newly refurbished south-facing apartment with balcony. Suit single professional only. " +
+		"No rent allowance. Bills not included. 5 minutes walk to the Luas."
+
+	got := AnalyzeDescription(desc)
+
+	categories := map[string]bool{}
+	for _, f := range got.RedFlags {
+		categories[f.Category] = true
+	}
+	for _, want := range []string{"housing_assistance", "occupancy_restriction"} {
+		if !categories[want] {
+			t.Errorf("expected red flag %q, got %+v", want, got.RedFlags)
+		}
+	}
+	if len(got.Pros) != 4 {
+		t.Errorf("expected 4 pros, got %v", got.Pros)
+	}
+	if len(got.Cons) != 1 || got.Cons[0] != "Bills not included" {
+		t.Errorf("expected [Bills not included] cons, got %v", got.Cons)
+	}
+}
+
+func TestCheckCompliance(t *testing.T) {
+	property := &PropertyInfo{
+		URL:         "https://www.daft.ie/for-rent/apartment-dublin-8/123",
+		Description: "Lovely flat. Professionals only, no HAP. Not suitable for children.",
+	}
+
+	flags := CheckCompliance(property)
+	if len(flags) != 3 {
+		t.Fatalf("expected 3 flags, got %+v", flags)
+	}
+	want := []struct {
+		ground   string
+		indirect bool
+	}{{"housing_assistance", false}, {"housing_assistance", true}, {"family_status", false}}
+	for i, w := range want {
+		if flags[i].Ground != w.ground || flags[i].Indirect != w.indirect {
+			t.Errorf("flag %d = %+v, want ground %q indirect %v", i, flags[i], w.ground, w.indirect)
+		}
+	}
+	for _, f := range flags {
+		if f.MayBeExempt {
+			t.Errorf("whole-property listing should not be marked exempt: %+v", f)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+// Package scraper raspa os anúncios do Daft.ie e dos outros portais e define o modelo
+// da análise (PropertyInfo e os tipos de cada módulo). Traz também o que toda chamada
+// externa usa: o circuit breaker, o retry, os timeouts por etapa, o robots.txt e o
+// orçamento de crawl, além da seleção dos módulos de análise.
+package scraper
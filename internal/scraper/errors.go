@@ -0,0 +1,69 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+/* ───── Erros da análise ────────────────────────────────────────────── */
+
+// APIError é o erro devolvido aos clientes: um código estável para tratar no código,
+// a mensagem para exibir, se vale tentar de novo e o módulo da análise que falhou.
+// Valores como ErrMapsBudgetExceeded são compartilhados: copie antes de mudar o
+// Module, como faz ModuleError.
+type APIError struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"`
+	Module    string `json:"module,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// UnmarshalJSON aceita também o formato antigo, em que o erro do anúncio era só a
+// mensagem ("error": "Could not find..."): análises guardadas antes do envelope
+// continuam carregando, como LISTING_PARSE_FAILED do scraping
+func (e *APIError) UnmarshalJSON(data []byte) error {
+	var message string
+	if json.Unmarshal(data, &message) == nil {
+		*e = APIError{Code: "LISTING_PARSE_FAILED", Message: message, Module: "scrape"}
+		return nil
+	}
+	type envelope APIError // sem o método, para não recursar
+	return json.Unmarshal(data, (*envelope)(e))
+}
+
+// ErrScrapeBlocked indica que o Daft.ie recusou a requisição (403)
+var ErrScrapeBlocked = errors.New("access blocked by Daft.ie")
+
+// ModuleError embrulha o erro de um módulo da análise; se err já for um APIError
+// (de um módulo mais interno), ele é mantido, só ganhando o módulo se não tiver um.
+// Um timeout da etapa vira UPSTREAM_TIMEOUT no lugar de code, e uma fonte com o
+// circuito aberto, UPSTREAM_UNAVAILABLE.
+func ModuleError(err error, code, module string) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Module == "" {
+			withModule := *apiErr
+			withModule.Module = module
+			return &withModule
+		}
+		return apiErr
+	}
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		code = "UPSTREAM_UNAVAILABLE"
+	case IsTimeout(err):
+		code = "UPSTREAM_TIMEOUT"
+	}
+	return &APIError{Code: code, Message: err.Error(), Retryable: true, Module: module}
+}
+
+// ErrMapsBudgetExceeded é devolvido no lugar da chamada ao Maps quando o orçamento do
+// dia acabou; é um APIError para que os avisos dos módulos saiam com este código
+var ErrMapsBudgetExceeded = &APIError{
+	Code:    "MAPS_BUDGET_EXCEEDED",
+	Message: "The daily Google Maps budget has been spent. Try again tomorrow.",
+}
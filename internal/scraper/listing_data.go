@@ -0,0 +1,135 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DaftListing é o subconjunto do JSON __NEXT_DATA__ de um anúncio do Daft.ie que usamos
+// (props.pageProps.listing; mesmo formato dos itens de uma página de resultados)
+type DaftListing struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Media struct {
+		Images []struct {
+			Size1440x960 string `json:"size1440x960"`
+			Size720x480  string `json:"size720x480"`
+		} `json:"images"`
+		FloorPlans []struct {
+			Size1440x960 string `json:"size1440x960"`
+			Size720x480  string `json:"size720x480"`
+		} `json:"floorPlans"`
+	} `json:"media"`
+	FloorArea struct {
+		Unit  string `json:"unit"` // METRES_SQUARED ou FEET_SQUARED
+		Value string `json:"value"`
+	} `json:"floorArea"`
+	Ber struct {
+		Rating string `json:"rating"` // A1..G, ou SI_666 (isento)
+	} `json:"ber"`
+	Seller struct {
+		Name             string `json:"name"`
+		Branch           string `json:"branch"`
+		Phone            string `json:"phone"`
+		AlternativePhone string `json:"alternativePhone"`
+		LicenceNumber    string `json:"licenceNumber"` // PSRA
+		SellerType       string `json:"sellerType"`    // BRANDED_AGENT, UNBRANDED_AGENT ou PRIVATE_USER
+	} `json:"seller"`
+	PropertyType string `json:"propertyType"`
+	PublishDate  int64  `json:"publishDate"` // epoch em ms
+	Label        string `json:"label"`       // selo do anúncio, ex.: "LET_AGREED"
+	State        string `json:"state"`
+
+	// SeoFriendlyPath é o caminho da página do anúncio, a base da URL canônica
+	SeoFriendlyPath string `json:"seoFriendlyPath"`
+
+	// Views vem de pageProps.listingViews, fora do objeto do anúncio
+	Views int `json:"-"`
+}
+
+// parseListingNextData extrai o anúncio do JSON __NEXT_DATA__ da página do anúncio
+func parseListingNextData(nextData []byte) (*DaftListing, error) {
+	var data struct {
+		Props struct {
+			PageProps struct {
+				Listing      DaftListing `json:"listing"`
+				ListingViews int         `json:"listingViews"`
+			} `json:"pageProps"`
+		} `json:"props"`
+	}
+	if err := json.Unmarshal(nextData, &data); err != nil {
+		return nil, fmt.Errorf("error decoding __NEXT_DATA__: %w", err)
+	}
+	listing := &data.Props.PageProps.Listing
+	listing.Views = data.Props.PageProps.ListingViews
+	return listing, nil
+}
+
+// Availability devolve "let_agreed"/"sale_agreed" quando o anúncio tem esse selo
+func (l *DaftListing) Availability(listingType string) string {
+	for _, v := range []string{l.Label, l.State} {
+		v = strings.ToUpper(strings.NewReplacer(" ", "_", "-", "_").Replace(v))
+		switch {
+		case strings.Contains(v, "SALE_AGREED"):
+			return "sale_agreed"
+		case strings.Contains(v, "LET_AGREED"), strings.Contains(v, "AGREED") && listingType != "sale":
+			return "let_agreed"
+		case strings.Contains(v, "AGREED"):
+			return "sale_agreed"
+		}
+	}
+	return ""
+}
+
+// publishedAt devolve a data de publicação (ou renovação) do anúncio
+func (l *DaftListing) publishedAt() *time.Time {
+	if l.PublishDate <= 0 {
+		return nil
+	}
+	t := time.UnixMilli(l.PublishDate).UTC()
+	return &t
+}
+
+// photoURLs devolve a versão 720x480 de cada foto (suficiente para exibir e para o hash)
+func (l *DaftListing) photoURLs() []string {
+	var urls []string
+	for _, img := range l.Media.Images {
+		switch {
+		case img.Size720x480 != "":
+			urls = append(urls, img.Size720x480)
+		case img.Size1440x960 != "":
+			urls = append(urls, img.Size1440x960)
+		}
+	}
+	return urls
+}
+
+// floorPlanURLs devolve as imagens de planta baixa, na maior resolução (melhor para OCR)
+func (l *DaftListing) floorPlanURLs() []string {
+	var urls []string
+	for _, img := range l.Media.FloorPlans {
+		switch {
+		case img.Size1440x960 != "":
+			urls = append(urls, img.Size1440x960)
+		case img.Size720x480 != "":
+			urls = append(urls, img.Size720x480)
+		}
+	}
+	return urls
+}
+
+// floorArea devolve a área declarada no anúncio em m² (0 se ausente)
+func (l *DaftListing) floorArea() float64 {
+	value, err := strconv.ParseFloat(l.FloorArea.Value, 64)
+	if err != nil || value <= 0 {
+		return 0
+	}
+	if strings.Contains(strings.ToUpper(l.FloorArea.Unit), "FEET") {
+		value *= SqFtToSqM
+	}
+	return math.Round(value*10) / 10
+}
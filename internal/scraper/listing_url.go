@@ -0,0 +1,154 @@
+package scraper
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"daft-scraper-api/internal/env"
+)
+
+/* ───── ID e URL canônica do anúncio ────────────────────────────────── */
+
+// O mesmo anúncio chega por muitas URLs: o link do app com parâmetros de rastreamento,
+// o site móvel (m.daft.ie), daft.ie sem www, o formato antigo com o id no fim do slug
+// ("…-dublin-6-1234567") ou só o id na query (?id=1234567). CanonicalListingURL reduz
+// todas a uma URL só, que é a chave do cache, do store e da deduplicação. Links que
+// não trazem o caminho completo (formato antigo, id na query) passam a usar o caminho
+// do próprio anúncio (seoFriendlyPath) depois do scraping.
+
+var (
+	// legacyIDPattern acha o id no fim do slug do formato antigo do Daft
+	legacyIDPattern = regexp.MustCompile(`-(\d{5,})$`)
+	// listingIDParams são os parâmetros de query que trazem o id nos links antigos e do app
+	listingIDParams = []string{"id", "adId", "ad_id"}
+)
+
+// ListingIDFromURL devolve o id numérico do anúncio ("" se não houver): o último
+// segmento do caminho, o fim do slug antigo ou o parâmetro id
+func ListingIDFromURL(u string) string {
+	parsed, err := url.Parse(strings.TrimSpace(u))
+	if err != nil {
+		return ""
+	}
+	path := strings.TrimRight(parsed.Path, "/")
+	last := path[strings.LastIndex(path, "/")+1:]
+	if isListingID(last) {
+		return last
+	}
+	if m := legacyIDPattern.FindStringSubmatch(last); m != nil {
+		return m[1]
+	}
+	for _, name := range listingIDParams {
+		if id := parsed.Query().Get(name); isListingID(id) {
+			return id
+		}
+	}
+	return ""
+}
+
+// isListingID informa se s é só dígitos
+func isListingID(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// isDaftHost informa se o host é do Daft.ie (daft.ie, www., m. e outros subdomínios)
+func isDaftHost(host string) bool {
+	return host == "daft.ie" || strings.HasSuffix(host, ".daft.ie")
+}
+
+// CanonicalListingURL reduz as variações de um mesmo anúncio (http, host do Daft sem
+// www ou móvel, barra no fim, parâmetros de rastreamento) a uma URL só. Só o parâmetro
+// com o id sobrevive, quando o caminho não o traz.
+func CanonicalListingURL(listingURL string) string {
+	u, err := url.Parse(strings.TrimSpace(listingURL))
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(listingURL)
+	}
+	host := strings.ToLower(u.Hostname())
+	if isDaftHost(host) {
+		host = "www.daft.ie"
+	} else if u.Port() != "" {
+		host += ":" + u.Port()
+	}
+	canonical := "https://" + host + strings.TrimRight(u.EscapedPath(), "/")
+
+	id := ListingIDFromURL(listingURL)
+	path := strings.TrimRight(u.Path, "/")
+	if id != "" && !strings.HasSuffix(path, "/"+id) && !strings.HasSuffix(path, "-"+id) {
+		canonical += "?id=" + id
+	}
+	return canonical
+}
+
+// canonicalFromPath monta a URL canônica a partir do seoFriendlyPath do anúncio; ""
+// quando o caminho não é do mesmo id (a página de um anúncio removido é outra)
+func canonicalFromPath(seoFriendlyPath, id string) string {
+	if seoFriendlyPath == "" || id == "" || ListingIDFromURL(seoFriendlyPath) != id {
+		return ""
+	}
+	return CanonicalListingURL("https://www.daft.ie/" + strings.TrimLeft(seoFriendlyPath, "/"))
+}
+
+// AddressKey normaliza um endereço para comparação entre portais
+// ("Apt 4, Main St., Co. Dublin" ≡ "apt 4 main st dublin")
+func AddressKey(addr string) string {
+	addr = strings.ToLower(addr)
+	addr = strings.ReplaceAll(addr, "co.", " ")
+	addr = strings.ReplaceAll(addr, "county", " ")
+	addr = strings.ReplaceAll(addr, "ireland", " ")
+
+	var b strings.Builder
+	for _, r := range addr {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// ScrapeDomains são os domínios que os coletores do Daft.ie podem visitar
+func ScrapeDomains() []string {
+	return env.List("SCRAPE_DOMAINS", []string{"www.daft.ie", "daft.ie"})
+}
+
+// IsScrapeURL informa se os coletores podem visitar a URL (host em SCRAPE_DOMAINS)
+func IsScrapeURL(rawURL string) bool {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return false
+	}
+	for _, domain := range ScrapeDomains() {
+		if strings.EqualFold(u.Hostname(), domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListingType deduz o tipo de anúncio pela URL do Daft.ie: sale, rent ou share
+func ListingType(listingURL string) string {
+	switch {
+	case strings.Contains(listingURL, "/for-sale/"), strings.Contains(listingURL, "/new-home"):
+		return "sale"
+	case strings.Contains(listingURL, "/share/"), strings.Contains(listingURL, "/sharing/"):
+		return "share"
+	case strings.Contains(listingURL, "/for-rent/"):
+		return "rent"
+	default:
+		return ""
+	}
+}
+
+// RelistAddressKey é a chave de endereço usada para ligar anúncios; "" quando o
+// endereço não tem número (só rua e bairro juntaria imóveis diferentes)
+func RelistAddressKey(address string) string {
+	key := AddressKey(address)
+	if !strings.ContainsAny(key, "0123456789") {
+		return ""
+	}
+	return key
+}
@@ -0,0 +1,53 @@
+package scraper
+
+import "testing"
+
+func TestListingIDFromURL(t *testing.T) {
+	cases := map[string]string{
+		"https://www.daft.ie/for-rent/apartment-1-main-street-dublin-1/5123456":                          "5123456",
+		"https://www.daft.ie/for-rent/apartment-1-main-street-dublin-1/5123456/":                         "5123456",
+		"https://www.daft.ie/for-rent/apartment-1-main-street/5123456?from=email":                        "5123456",
+		"https://m.daft.ie/for-rent/apartment-1-main-street/5123456":                                     "5123456",
+		"https://www.daft.ie/dublin/apartments-for-rent/rathmines/1-main-st-rathmines-dublin-6-2345678/": "2345678",
+		"https://www.daft.ie/searchrental.daft?id=2345678&utm_source=app":                                "2345678",
+		"https://www.daft.ie/property-for-rent/dublin":                                                   "",
+		"https://www.daft.ie/for-rent/apartment-12-main-street-dublin-6":                                 "",
+	}
+	for in, want := range cases {
+		if got := ListingIDFromURL(in); got != want {
+			t.Errorf("listingIDFromURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCanonicalListingURL(t *testing.T) {
+	want := "https://www.daft.ie/for-rent/apartment-1-main-street/123"
+	for _, u := range []string{
+		"https://www.daft.ie/for-rent/apartment-1-main-street/123",
+		"http://daft.ie/for-rent/apartment-1-main-street/123/",
+		" https://WWW.DAFT.IE/for-rent/apartment-1-main-street/123?utm_source=share#photos ",
+		"https://m.daft.ie/for-rent/apartment-1-main-street/123?utm_medium=app&utm_campaign=x",
+	} {
+		if got := CanonicalListingURL(u); got != want {
+			t.Errorf("canonicalListingURL(%q) = %q", u, got)
+		}
+	}
+
+	// the id survives when only the query carries it
+	if got := CanonicalListingURL("https://daft.ie/searchrental.daft?utm_source=app&id=2345678"); got != "https://www.daft.ie/searchrental.daft?id=2345678" {
+		t.Errorf("query id = %q", got)
+	}
+	// other hosts keep their port
+	if got := CanonicalListingURL("http://127.0.0.1:8080/for-rent/x/123/"); got != "https://127.0.0.1:8080/for-rent/x/123" {
+		t.Errorf("other host = %q", got)
+	}
+}
+
+func TestCanonicalFromPath(t *testing.T) {
+	if got := canonicalFromPath("/for-rent/apartment-1-main-street/123", "123"); got != "https://www.daft.ie/for-rent/apartment-1-main-street/123" {
+		t.Errorf("canonicalFromPath = %q", got)
+	}
+	if got := canonicalFromPath("/property-for-rent/dublin", "123"); got != "" {
+		t.Errorf("path of another page = %q, want empty", got)
+	}
+}
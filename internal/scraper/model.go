@@ -0,0 +1,368 @@
+package scraper
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"daft-scraper-api/internal/safety"
+)
+
+/* ───── Modelo da análise ───────────────────────────────────────────── */
+
+// PropertyInfo struct para armazenar os dados do imóvel.
+// Os módulos de enrichPropertyInfo rodam em sequência sobre ele; depois de pronto
+// é só lido (cache, store, resposta) e quem precisa alterá-lo trabalha numa cópia.
+type PropertyInfo struct {
+	Address      string    `json:"address"`
+	RentPrice    string    `json:"price"`
+	Bedrooms     string    `json:"bedrooms"`
+	Bathrooms    string    `json:"bathrooms"`
+	PropertyType string    `json:"propertyType"`
+	Description  string    `json:"description"`
+	URL          string    `json:"url"`
+	ListingType  string    `json:"listingType,omitempty"` // sale, rent ou share
+	BER          string    `json:"ber,omitempty"`         // classificação energética (A1..G)
+	Error        *APIError `json:"error,omitempty"`       // anúncio sem os dados essenciais
+	Summary      string    `json:"summary,omitempty"`     // visão geral em texto gerada por LLM
+
+	// Quartos, banheiros e preço interpretados (ver numeric_fields.go); "price" já é
+	// o texto bruto, então o preço estruturado sai como priceDetails
+	BedroomsCount  *int   `json:"bedroomsCount,omitempty"`
+	BathroomsCount *int   `json:"bathroomsCount,omitempty"`
+	Price          *Price `json:"priceDetails,omitempty"`
+
+	// Módulos que falharam; o resto da análise continua válido
+	Warnings []*APIError `json:"warnings,omitempty"`
+
+	// O que cada módulo entregou (ok, falhou, estimado, sem dados, não rodou)
+	DataQuality *DataQuality `json:"dataQuality,omitempty"`
+
+	// Mudanças desde a análise anterior do mesmo anúncio
+	Changes []ListingChange `json:"changes,omitempty"`
+
+	// Notas de usuários sobre a área ou o prédio
+	Annotations []Annotation `json:"annotations,omitempty"`
+
+	// Fotos do anúncio e fotos que também aparecem em outros anúncios
+	Photos          []string     `json:"photos,omitempty"`
+	PhotoDuplicates []PhotoMatch `json:"photoDuplicates,omitempty"`
+
+	// Agência ou proprietário que anuncia, com a licença PSRA e o contato
+	Advertiser *Advertiser `json:"advertiser,omitempty"`
+
+	// Nota da agência no Google (só agências, com o Google Maps configurado)
+	AdvertiserReputation *AdvertiserReputation `json:"advertiserReputation,omitempty"`
+
+	// Locação registrada no RTB no endereço (aluguel e quarto compartilhado)
+	RTBRegistration *TenancyRegistration `json:"rtbRegistration,omitempty"`
+
+	// Horários de visita anunciados (ver GET /analyses/{id}/viewings.ics)
+	Viewings []ViewingTime `json:"viewings,omitempty"`
+
+	// Mobília, prazo mínimo e data de entrada (aluguel e quarto compartilhado)
+	RentalTerms *RentalTerms `json:"rentalTerms,omitempty"`
+
+	// Contagem de fotos e fotos de banco de imagens, e o risco de golpe (ver internal/enrich/fraud.go)
+	PhotoAnalysis *PhotoAnalysis `json:"photoAnalysis,omitempty"`
+	FraudRisk     *FraudRisk     `json:"fraudRisk,omitempty"`
+
+	// Alertas e prós/contras extraídos do texto do anúncio
+	DescriptionAnalysis DescriptionAnalysis `json:"descriptionAnalysis"`
+
+	// Frases que podem violar o Equal Status Act (denunciáveis à WRC)
+	ComplianceFlags []ComplianceFlag `json:"complianceFlags"`
+
+	// Área útil; quando vem do OCR da planta, Source = "floorplan_ocr"
+	FloorPlans []string   `json:"floorPlans,omitempty"`
+	FloorArea  *FloorArea `json:"floorArea,omitempty"`
+
+	// Taxa de condomínio anual e OMC (apartamentos)
+	ServiceCharge *ServiceCharge `json:"serviceCharge,omitempty"`
+
+	// De onde veio o BER (listing, badge ou description) e o alerta para F e G
+	BERSource     string `json:"berSource,omitempty"`
+	EnergyWarning string `json:"energyWarning,omitempty"`
+
+	// Publicação e visualizações no Daft.ie, e a recomendação de quão rápido agir
+	PublishedAt  *time.Time     `json:"publishedAt,omitempty"`
+	Views        int            `json:"views,omitempty"`
+	Availability string         `json:"availability,omitempty"` // let_agreed ou sale_agreed
+	ActFast      *ActFastAdvice `json:"actFast,omitempty"`
+
+	// Tempo no mercado contando os anúncios anteriores do mesmo imóvel
+	MarketHistory *MarketHistory `json:"marketHistory,omitempty"`
+
+	// Custo do trajeto até o destino do cliente (só quando pedido com commuteTo)
+	Commute *CommuteCost `json:"commute,omitempty"`
+
+	// Aluguel contra a renda do cliente (só quando pedido com monthlyNetIncome)
+	Affordability *Affordability `json:"affordability,omitempty"`
+
+	// O que verificar ou perguntar na visita, a partir das lacunas e riscos da análise
+	Checklist []ChecklistItem `json:"checklist,omitempty"`
+
+	// Unidades das distâncias e tempos dos POIs
+	Units ResponseUnits `json:"units"`
+
+	// Chamadas ao Google Maps feitas por esta análise (não serializado; ver internal/enrich/budget.go)
+	MapsUsage *MapsUsage `json:"-"`
+
+	// Informações de localização
+	Coordinates struct {
+		Lat    float64 `json:"lat"`
+		Lng    float64 `json:"lng"`
+		Source string  `json:"source,omitempty"` // geocoder que achou: google, nominatim ou eircode
+	} `json:"coordinates"`
+
+	// Informações de segurança
+	SafetyInfo struct {
+		CrimeRate      float64 `json:"crimeRate"`
+		CrimeEstimated bool    `json:"crimeRateEstimated,omitempty"` // CSO indisponível: valores estimados
+		SafetyRating   int     `json:"safetyRating"`                 // 1-10
+		NearbyGardai   []POI   `json:"nearbyGardai"`                 // Estações de polícia próximas
+		StreetLighting string  `json:"streetLighting"`
+		StreetLamps    int     `json:"streetLamps"`                // postes no raio de LIGHTING_RADIUS
+		LampsPerKm     float64 `json:"streetLampsPerKm,omitempty"` // por km de rua no mesmo raio
+
+		// a área Garda do crimeRate: o distrito quando a CSO o tem, senão a divisão
+		CrimeGranularity string `json:"crimeRateGranularity,omitempty"`
+		CrimeArea        string `json:"crimeRateArea,omitempty"`
+		CrimeEstimate    string `json:"crimeRateEstimateReason,omitempty"` // só quando crimeRateEstimated
+
+		// o porquê do safetyRating: cada fator com o quanto somou ou tirou da nota
+		ScoreFactors []safety.SafetyFactor     `json:"scoreFactors,omitempty"`
+		ScoreInputs  *safety.SafetyScoreInputs `json:"scoreInputs,omitempty"`
+
+		RoadSafety *safety.RoadSafety `json:"roadSafety,omitempty"` // colisões perto do imóvel
+	} `json:"safetyInfo"`
+
+	// Qualidade de vida
+	QualityOfLife QualityOfLifeInfo `json:"qualityOfLife"`
+
+	// Análise de valor
+	ValueAnalysis struct {
+		AreaAveragePrice float64             `json:"areaAveragePrice"`
+		PricePerSqm      float64             `json:"pricePerSqm,omitempty"`
+		EnergyUpgrades   *EnergyUpgradeHints `json:"energyUpgrades,omitempty"`       // só venda com BER ruim
+		Negotiation      *Negotiation        `json:"negotiation,omitempty"`          // preço contra os comparáveis, com as provas
+		EffectiveMonthly float64             `json:"effectiveMonthlyCost,omitempty"` // prestação + condomínio (venda)
+		LivingCosts      *LivingCosts        `json:"livingCosts,omitempty"`          // energia, lixo, internet e condomínio
+		OccupancyCost    float64             `json:"occupancyCost,omitempty"`        // aluguel ou prestação + livingCosts
+		TrueMonthlyCost  float64             `json:"trueMonthlyCost,omitempty"`      // custo de morar + trajeto (com commuteTo)
+		PriceRating      int                 `json:"priceRating"`                    // 1-10 (1 = muito caro, 10 = muito barato)
+		PriceHistory     []PricePoint        `json:"priceHistory"`
+		Similar          []SimilarProperty   `json:"similar"`
+	} `json:"valueAnalysis"`
+}
+
+// Clone devolve uma cópia de p que não divide slices, maps nem ponteiros com ela, para
+// que quem recebe a análise possa completá-la (Analyzer.ApplyCommute, Analyzer.ApplyAffordability...)
+// sem mexer na dos outros; o contador do Maps, não exportado, continua o mesmo
+func (p *PropertyInfo) Clone() PropertyInfo {
+	return deepCopy(reflect.ValueOf(*p)).Interface().(PropertyInfo)
+}
+
+// deepCopy copia v recursivamente: ponteiros, slices, maps e interfaces ganham cópias
+// próprias; campos não exportados são copiados como estão
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	}
+	return v
+}
+
+// QualityOfLifeInfo reúne transporte, amenidades e caminhabilidade de uma localização
+type QualityOfLifeInfo struct {
+	TransportScore  int   `json:"transportScore"` // 1-10
+	PublicTransport []POI `json:"publicTransport"`
+	Amenities       []POI `json:"amenities"`       // Supermercados, farmácias, etc
+	Entertainment   []POI `json:"entertainment"`   // Pubs, restaurantes, etc
+	WalkScore       int   `json:"walkScore"`       // 1-100
+	BikeScore       int   `json:"bikeScore"`       // 1-100; 0 sem o módulo de transporte
+	RemoteWorkScore int   `json:"remoteWorkScore"` // 1-100; 0 sem o módulo de amenidades
+	FamilyScore     int   `json:"familyScore"`     // 1-100; 0 sem o módulo family
+	QuietScore      int   `json:"quietScore"`      // 1-100; 0 sem o módulo de entretenimento
+
+	Cycling    *CyclingInfo    `json:"cycling,omitempty"`    // os dados do bikeScore
+	RemoteWork *RemoteWorkInfo `json:"remoteWork,omitempty"` // os dados do remoteWorkScore
+	Family     *FamilyInfo     `json:"family,omitempty"`     // os fatores do familyScore
+	Quiet      *QuietInfo      `json:"quiet,omitempty"`      // os dados do quietScore
+}
+
+// POI (Point of Interest) representa um local de interesse próximo
+type POI struct {
+	Name           string  `json:"name"`
+	Type           string  `json:"type"`
+	DistanceMeters int     `json:"distanceMeters"`
+	WalkMinutes    int     `json:"walkMinutes"` // a walkMetersPerMinute
+	Lat            float64 `json:"lat,omitempty"`
+	Lng            float64 `json:"lng,omitempty"`
+
+	// Legado, mantidos por uma versão: distance em km e duration em minutos. Distance
+	// continua sendo o valor exato usado nas contas internas.
+	Distance float64 `json:"distance"`
+	Duration int     `json:"duration"`
+}
+
+// walkMetersPerMinute é a velocidade usada para estimar o tempo a pé
+const walkMetersPerMinute = 80
+
+// NewPOI monta o POI a distanceKm da origem, com a distância em metros e o tempo a pé
+func NewPOI(name, poiType string, distanceKm, lat, lng float64) POI {
+	meters := int(math.Round(distanceKm * 1000))
+	return POI{
+		Name:           name,
+		Type:           poiType,
+		DistanceMeters: meters,
+		WalkMinutes:    meters / walkMetersPerMinute,
+		Lat:            lat,
+		Lng:            lng,
+		Distance:       distanceKm,
+		Duration:       meters / walkMetersPerMinute,
+	}
+}
+
+// UnmarshalJSON preenche distanceMeters e walkMinutes dos POIs gravados (ou vindos
+// de uma instância upstream) antes de os campos existirem
+func (p *POI) UnmarshalJSON(data []byte) error {
+	type plain POI
+	if err := json.Unmarshal(data, (*plain)(p)); err != nil {
+		return err
+	}
+	if p.DistanceMeters == 0 && p.Distance > 0 {
+		*p = NewPOI(p.Name, p.Type, p.Distance, p.Lat, p.Lng)
+	}
+	return nil
+}
+
+// ResponseUnits declara as unidades dos campos de distância e tempo da resposta
+// O valor é sempre o de responseUnits, inclusive nas análises gravadas.
+type ResponseUnits struct {
+	Distance       string `json:"distance"`       // distanceMeters
+	WalkTime       string `json:"walkTime"`       // walkMinutes
+	LegacyDistance string `json:"legacyDistance"` // distance, que sai na próxima versão
+	LegacyWalkTime string `json:"legacyWalkTime"` // duration, que sai na próxima versão
+}
+
+var responseUnits = ResponseUnits{
+	Distance:       "meters",
+	WalkTime:       "minutes",
+	LegacyDistance: "kilometers",
+	LegacyWalkTime: "minutes",
+}
+
+func (ResponseUnits) MarshalJSON() ([]byte, error) {
+	type plain ResponseUnits
+	return json.Marshal(plain(responseUnits))
+}
+
+// PricePoint representa um ponto no histórico de preços
+type PricePoint struct {
+	Date  string  `json:"date"`
+	Price float64 `json:"price"`
+}
+
+// SimilarProperty representa um imóvel similar na região
+type SimilarProperty struct {
+	Address string  `json:"address"`
+	Price   float64 `json:"price"` // por mês; aluguéis semanais são convertidos (× 52 / 12)
+	URL     string  `json:"url"`
+	Source  string  `json:"source"` // portal de origem (daft.ie, rent.ie, myhome.ie) ou private:<agência>
+
+	// Preço como anunciado e o seu período (week ou month)
+	OriginalPrice float64 `json:"originalPrice,omitempty"`
+	Period        string  `json:"period,omitempty"`
+}
+
+// AnalysisResponse representa a resposta completa da análise
+type AnalysisResponse struct {
+	Property   PropertyInfo          `json:"property"`
+	SafetyInfo safety.SafetyAnalysis `json:"safetyInfo"`
+}
+
+// MapsUsage conta as chamadas HTTP à API do Google Maps feitas por uma análise. Fica
+// num ponteiro em PropertyInfo para que as cópias (AnalysisResponse) somem no mesmo
+// contador. Seguro para uso concorrente.
+type MapsUsage struct {
+	Calls int32
+}
+
+func (u *MapsUsage) Count() int {
+	if u == nil {
+		return 0
+	}
+	return int(atomic.LoadInt32(&u.Calls))
+}
+
+// Usage devolve o contador de chamadas ao Maps da análise, criando-o se preciso
+func (p *PropertyInfo) Usage() *MapsUsage {
+	if p.MapsUsage == nil {
+		p.MapsUsage = &MapsUsage{}
+	}
+	return p.MapsUsage
+}
+
+// MapsCalls é quantas chamadas ao Maps esta análise fez (0 quando veio do cache)
+func (p *PropertyInfo) MapsCalls() int {
+	return p.MapsUsage.Count()
+}
+
+// NearestPOIs devolve os n POIs mais próximos, sem alterar a ordem original
+func NearestPOIs(pois []POI, n int) []POI {
+	sorted := append([]POI(nil), pois...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Distance < sorted[j].Distance })
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
@@ -0,0 +1,14 @@
+package scraper
+
+import "testing"
+
+func TestNearestPOIs(t *testing.T) {
+	pois := []POI{{Name: "c", Distance: 3}, {Name: "a", Distance: 1}, {Name: "b", Distance: 2}}
+	got := NearestPOIs(pois, 2)
+	if len(got) != 2 || got[0].Name != "a" || got[1].Name != "b" {
+		t.Errorf("nearestPOIs = %+v", got)
+	}
+	if pois[0].Name != "c" {
+		t.Error("nearestPOIs reordered its input")
+	}
+}
@@ -0,0 +1,116 @@
+package scraper
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"daft-scraper-api/internal/env"
+)
+
+/* ───── Seleção dos módulos de análise ──────────────────────────────── */
+
+// AnalysisModules são as etapas caras do enriquecimento que podem ser puladas. O
+// scraping do anúncio e a análise da descrição rodam sempre.
+var AnalysisModules = []string{"safety", "transport", "amenities", "entertainment", "family", "value", "photos", "summary"}
+
+// DisabledModules são os módulos e partes desligados nesta instalação, pelo nome na
+// variável (MODULES_VALUE_COMPARABLES → "VALUE_COMPARABLES"); relidos em setup(),
+// depois do .env e do arquivo de configuração
+var DisabledModules = DisabledModulesFromEnv()
+
+// DisabledModulesFromEnv lê as variáveis MODULES_*=false
+func DisabledModulesFromEnv() map[string]bool {
+	disabled := map[string]bool{}
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if module, ok := strings.CutPrefix(name, "MODULES_"); ok && value == "false" {
+			disabled[module] = true
+		}
+	}
+	return disabled
+}
+
+// ModuleEnabled informa se o módulo (ou a parte dele, "value.comparables") está
+// ligado nesta instalação. MODULES_SAFETY=false, ou [modules] safety = false no
+// arquivo de configuração, desliga o módulo para todas as requisições; uma parte
+// desligada deixa o módulo rodar sem ela.
+func ModuleEnabled(name string) bool {
+	return !DisabledModules[env.Name(name)]
+}
+
+// ModuleSet indica quais módulos rodar; o valor zero (nil) significa todos os
+// ligados na instalação
+type ModuleSet map[string]bool
+
+func AllModules() ModuleSet {
+	return nil
+}
+
+// Has informa se o módulo deve rodar; um módulo desligado na instalação nunca roda
+func (m ModuleSet) Has(name string) bool {
+	return (m == nil || m[name]) && ModuleEnabled(name)
+}
+
+// Full informa se todos os módulos ligados na instalação estão selecionados
+func (m ModuleSet) Full() bool {
+	for _, name := range AnalysisModules {
+		if ModuleEnabled(name) && !m.Has(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// String lista os módulos selecionados na ordem de AnalysisModules
+func (m ModuleSet) String() string {
+	var names []string
+	for _, name := range AnalysisModules {
+		if m.Has(name) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// NeedsLocation informa se algum módulo selecionado precisa das coordenadas
+func (m ModuleSet) NeedsLocation() bool {
+	return m.Has("safety") || m.Has("transport") || m.Has("amenities") || m.Has("entertainment") || m.Has("family")
+}
+
+// ParseModules monta o conjunto a partir de uma lista de inclusão (only) ou de
+// exclusão (skip); nomes desconhecidos e pedidos de módulos desligados são erro
+func ParseModules(only, skip []string) (ModuleSet, error) {
+	known := map[string]bool{}
+	for _, name := range AnalysisModules {
+		known[name] = true
+	}
+	for _, name := range append(append([]string{}, only...), skip...) {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown module %q (valid: %s)", name, strings.Join(AnalysisModules, ", "))
+		}
+	}
+	for _, name := range only {
+		if !ModuleEnabled(name) {
+			return nil, fmt.Errorf("module %q is disabled in this deployment", name)
+		}
+	}
+
+	if len(only) == 0 && len(skip) == 0 {
+		return AllModules(), nil
+	}
+	m := ModuleSet{}
+	if len(only) > 0 {
+		for _, name := range only {
+			m[name] = true
+		}
+	} else {
+		for _, name := range AnalysisModules {
+			m[name] = true
+		}
+	}
+	for _, name := range skip {
+		delete(m, name)
+	}
+	return m, nil
+}
@@ -0,0 +1,65 @@
+package scraper
+
+import "testing"
+
+func TestParseModules(t *testing.T) {
+	all, err := ParseModules(nil, nil)
+	if err != nil || !all.Full() {
+		t.Fatalf("expected all modules, got %v (%v)", all, err)
+	}
+
+	skip, err := ParseModules(nil, []string{"value", "safety"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skip.Has("value") || skip.Has("safety") || !skip.Has("transport") || skip.Full() {
+		t.Errorf("skip=value,safety gave %v", skip)
+	}
+
+	only, err := ParseModules([]string{"transport"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !only.Has("transport") || only.Has("amenities") || !only.NeedsLocation() {
+		t.Errorf("modules=transport gave %v", only)
+	}
+
+	valueOnly, _ := ParseModules([]string{"value"}, nil)
+	if valueOnly.NeedsLocation() {
+		t.Error("value module alone should not need geocoding")
+	}
+
+	if _, err := ParseModules([]string{"weather"}, nil); err == nil {
+		t.Error("expected error for unknown module")
+	}
+}
+
+func TestModulesDisabledInDeployment(t *testing.T) {
+	t.Setenv("MODULES_SAFETY", "false")
+	reloadModules(t)
+
+	all, err := ParseModules(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.Has("safety") || !all.Has("transport") || !all.Full() {
+		t.Errorf("with safety disabled, the default set should be every other module: %v", all)
+	}
+	if !ModuleSet(nil).Full() || AllModules().Has("safety") {
+		t.Error("the zero moduleSet should respect the deployment flags")
+	}
+	if _, err := ParseModules([]string{"safety", "transport"}, nil); err == nil {
+		t.Error("asking for a disabled module should be an error")
+	}
+	if skip, err := ParseModules(nil, []string{"safety"}); err != nil || !skip.Full() {
+		t.Errorf("skipping a disabled module should be fine, got %v (%v)", skip, err)
+	}
+}
+
+// reloadModules rereads the MODULES_* flags after t.Setenv and restores them afterwards
+func reloadModules(t *testing.T) {
+	t.Helper()
+	prev := DisabledModules
+	DisabledModules = DisabledModulesFromEnv()
+	t.Cleanup(func() { DisabledModules = prev })
+}
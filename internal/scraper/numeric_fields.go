@@ -0,0 +1,98 @@
+package scraper
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/* ───── Campos numéricos (quartos, banheiros e preço) ───────────────── */
+
+// O anúncio traz quartos, banheiros e preço como texto livre ("3 bed", "€650 per
+// week"). Os campos brutos continuam na resposta; ao lado deles vão os números já
+// interpretados, para quem consome a API não ter de reinterpretar o texto.
+
+// Price é o preço do anúncio interpretado
+type Price struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`          // ISO 4217, ex.: EUR
+	Period   string  `json:"period,omitempty"`  // week ou month no aluguel; vazio na venda
+	Monthly  float64 `json:"monthly,omitempty"` // aluguel por mês (semanal × 52 / 12)
+}
+
+var roomCountPattern = regexp.MustCompile(`\d+`)
+
+// ParseRoomCount lê o número de "3 bed", "2 bathrooms" ou "Bedrooms: 4"; studio é 0
+func ParseRoomCount(text string) (int, bool) {
+	if n, err := strconv.Atoi(roomCountPattern.FindString(text)); err == nil {
+		return n, true
+	}
+	if strings.Contains(strings.ToLower(text), "studio") {
+		return 0, true
+	}
+	return 0, false
+}
+
+// PricePeriod lê o período de "per week", "/month", "weekly" etc.; "" se não houver
+func PricePeriod(text string) string {
+	lower := strings.ToLower(text)
+	switch {
+	case strings.Contains(lower, "week"):
+		return "week"
+	case strings.Contains(lower, "month"):
+		return "month"
+	}
+	return ""
+}
+
+// ParsePrice interpreta o preço bruto; period vem do próprio texto ou, quando o texto
+// foi cortado antes dele, do resto da descrição do anúncio
+func ParsePrice(raw, period string) *Price {
+	amount := ExtractPriceValue(raw)
+	if amount <= 0 {
+		return nil
+	}
+	if p := PricePeriod(raw); p != "" {
+		period = p
+	}
+	price := &Price{Amount: amount, Currency: "EUR", Period: period}
+	if strings.Contains(raw, "£") {
+		price.Currency = "GBP"
+	}
+	price.Monthly = MonthlyAmount(amount, period)
+	return price
+}
+
+// MonthlyAmount normaliza o aluguel para o mês; 0 quando não é aluguel periódico
+func MonthlyAmount(amount float64, period string) float64 {
+	switch period {
+	case "month":
+		return amount
+	case "week":
+		return math.Round(amount*52/12*100) / 100
+	}
+	return 0
+}
+
+// FillNumericFields preenche os campos numéricos a partir dos textos do anúncio
+func FillNumericFields(property *PropertyInfo, pricePeriod string) {
+	if n, ok := ParseRoomCount(property.Bedrooms); ok {
+		property.BedroomsCount = &n
+	}
+	if n, ok := ParseRoomCount(property.Bathrooms); ok {
+		property.BathroomsCount = &n
+	}
+	property.Price = ParsePrice(property.RentPrice, pricePeriod)
+}
+
+// MonthlyPrice é o preço usado na análise de valor: o aluguel normalizado para o mês
+// ou, na venda e quando o período é desconhecido, o valor do anúncio
+func MonthlyPrice(property *PropertyInfo) float64 {
+	if property.Price != nil && property.Price.Monthly > 0 {
+		return property.Price.Monthly
+	}
+	return ExtractPriceValue(property.RentPrice)
+}
+
+const SqFtToSqM = 0.092903
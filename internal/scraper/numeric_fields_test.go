@@ -0,0 +1,58 @@
+package scraper
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseRoomCount(t *testing.T) {
+	for text, want := range map[string]int{"3 bed": 3, "2 bathrooms": 2, "bedrooms: 4": 4, "Studio": 0} {
+		if got, ok := ParseRoomCount(text); !ok || got != want {
+			t.Errorf("parseRoomCount(%q) = %d, %v; want %d", text, got, ok, want)
+		}
+	}
+	if _, ok := ParseRoomCount("double bedroom"); ok {
+		t.Error("text without a number should not parse")
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	cases := []struct {
+		raw, period   string
+		amount, month float64
+		wantPeriod    string
+	}{
+		{"€650 per week", "", 650, 2816.67, "week"},
+		{"€2,100", "month", 2100, 2100, "month"},
+		{"€2,100 per month", "week", 2100, 2100, "month"}, // the raw text wins
+		{"€395,000", "", 395000, 0, ""},
+	}
+	for _, c := range cases {
+		p := ParsePrice(c.raw, c.period)
+		if p == nil || p.Amount != c.amount || p.Monthly != c.month || p.Period != c.wantPeriod || p.Currency != "EUR" {
+			t.Errorf("parsePrice(%q, %q) = %+v", c.raw, c.period, p)
+		}
+	}
+	if p := ParsePrice("Price on application", ""); p != nil {
+		t.Errorf("a price without a number = %+v, want nil", p)
+	}
+}
+
+func TestFillNumericFields(t *testing.T) {
+	p := PropertyInfo{RentPrice: "€650", Bedrooms: "studio", Bathrooms: "1 bath"}
+	FillNumericFields(&p, "week")
+	raw, _ := json.Marshal(p)
+	for _, want := range []string{`"bedroomsCount":0`, `"bathroomsCount":1`, `"priceDetails":{"amount":650,"currency":"EUR","period":"week","monthly":2816.67}`, `"price":"€650"`} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("missing %s in %s", want, raw)
+		}
+	}
+
+	// unknown counts are left out rather than reported as zero
+	p = PropertyInfo{}
+	FillNumericFields(&p, "")
+	if p.BedroomsCount != nil || p.BathroomsCount != nil || p.Price != nil {
+		t.Errorf("empty listing = %+v / %+v / %+v", p.BedroomsCount, p.BathroomsCount, p.Price)
+	}
+}
@@ -0,0 +1,138 @@
+package scraper
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* ───── Mobília, prazo mínimo e data de entrada do aluguel ──────────── */
+
+// O Daft mostra estes dados na lista de visão geral ("Furnished: Yes", "Lease: Minimum
+// 1 Year", "Available From: Immediately"), mas muitos anunciantes só os escrevem na
+// descrição. Lemos primeiro a lista e completamos com a descrição. Só para aluguel e
+// quarto compartilhado; na venda o bloco fica de fora.
+
+// RentalTerms são as condições do aluguel que o inquilino usa para filtrar
+type RentalTerms struct {
+	Furnishing         string     `json:"furnishing,omitempty"`         // furnished, unfurnished, part_furnished ou either
+	MinimumLeaseMonths int        `json:"minimumLeaseMonths,omitempty"` // prazo mínimo do contrato
+	AvailableFrom      *time.Time `json:"availableFrom,omitempty"`
+	AvailableNow       bool       `json:"availableNow,omitempty"`
+}
+
+var (
+	// leasePattern casa "Lease: Minimum 1 Year", "minimum lease of 6 months", "12 month lease"
+	leasePattern = regexp.MustCompile(`(?i)(?:\blease(?:\s+length)?\s*:?\s*(?:minimum\s+|min\.?\s+)?(?:of\s+)?|minimum\s+(?:lease|term)\s+(?:of\s+)?)(\d{1,2}|one|two|three|six|nine|twelve|eighteen)[\s-]*(month|year)s?`)
+	// leaseBeforePattern casa "12 month lease", "1-year minimum lease"
+	leaseBeforePattern = regexp.MustCompile(`(?i)\b(\d{1,2}|one|two|three|six|nine|twelve|eighteen)[\s-]*(month|year)s?\s+(?:minimum\s+)?(?:lease|term)\b`)
+	// availablePattern casa "Available From: Immediately", "available from 1st November 2026", "available 15/11/2026"
+	availablePattern = regexp.MustCompile(`(?i)\bavailable\s*(?:from)?\s*:?\s*(immediately|now|\d{1,2}(?:st|nd|rd|th)?\s+(?:of\s+)?[a-z]{3,9}\.?(?:,?\s+\d{4})?|[a-z]{3,9}\.?\s+\d{1,2}(?:st|nd|rd|th)?(?:,?\s+\d{4})?|\d{1,2}/\d{1,2}/\d{2,4})`)
+	ordinalSuffix    = regexp.MustCompile(`(?i)(\d)(st|nd|rd|th)\b`)
+)
+
+var leaseNumberWords = map[string]int{
+	"one": 1, "two": 2, "three": 3, "six": 6, "nine": 9, "twelve": 12, "eighteen": 18,
+}
+
+// availableLayouts são os formatos de data aceitos, já sem o sufixo ordinal
+var availableLayouts = []string{
+	"2 January 2006", "2 Jan 2006", "January 2 2006", "Jan 2 2006", "2/1/2006", "2/1/06",
+	"2 January", "2 Jan", "January 2", "Jan 2",
+}
+
+// parseRentalTerms lê as linhas da visão geral e a descrição; nil quando nada foi
+// encontrado. now resolve datas sem ano e "immediately".
+func parseRentalTerms(overview []string, description string, now time.Time) *RentalTerms {
+	t := &RentalTerms{}
+	texts := append(append([]string{}, overview...), description)
+	for _, text := range texts {
+		if t.Furnishing == "" {
+			t.Furnishing = parseFurnishing(text)
+		}
+		if t.MinimumLeaseMonths == 0 {
+			t.MinimumLeaseMonths = parseLeaseMonths(text)
+		}
+		if t.AvailableFrom == nil && !t.AvailableNow {
+			t.AvailableFrom, t.AvailableNow = parseAvailableFrom(text, now)
+		}
+	}
+	if *t == (RentalTerms{}) {
+		return nil
+	}
+	return t
+}
+
+// parseFurnishing reconhece a mobília; "" quando o texto não fala disso
+func parseFurnishing(text string) string {
+	t := strings.ToLower(text)
+	if !strings.Contains(t, "furnish") {
+		return ""
+	}
+	switch {
+	case strings.Contains(t, "furnished or unfurnished"), strings.Contains(t, "unfurnished or furnished"),
+		strings.Contains(t, "furnished: either"), strings.Contains(t, "furnishing: either"):
+		return "either"
+	case strings.Contains(t, "part furnished"), strings.Contains(t, "part-furnished"), strings.Contains(t, "partially furnished"):
+		return "part_furnished"
+	case strings.Contains(t, "unfurnished"), strings.Contains(t, "furnished: no"):
+		return "unfurnished"
+	case strings.Contains(t, "furnished"):
+		return "furnished"
+	}
+	return ""
+}
+
+// parseLeaseMonths devolve o prazo mínimo em meses; 0 se não achar
+func parseLeaseMonths(text string) int {
+	m := leasePattern.FindStringSubmatch(text)
+	if m == nil {
+		m = leaseBeforePattern.FindStringSubmatch(text)
+	}
+	if m == nil {
+		return 0
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		n = leaseNumberWords[strings.ToLower(m[1])]
+	}
+	if strings.EqualFold(m[2], "year") {
+		n *= 12
+	}
+	return n
+}
+
+// parseAvailableFrom devolve a data de entrada, ou true quando é imediata. Data sem ano
+// que já passou há mais de dois meses é do ano seguinte ("available 5 January" em
+// novembro).
+func parseAvailableFrom(text string, now time.Time) (*time.Time, bool) {
+	m := availablePattern.FindStringSubmatch(text)
+	if m == nil {
+		return nil, false
+	}
+	raw := strings.ToLower(m[1])
+	if raw == "immediately" || raw == "now" {
+		return nil, true
+	}
+	raw = ordinalSuffix.ReplaceAllString(raw, "$1")
+	raw = strings.NewReplacer(" of ", " ", ",", "", ".", "").Replace(raw)
+	raw = strings.Join(strings.Fields(raw), " ")
+	for _, layout := range availableLayouts {
+		d, err := time.Parse(layout, raw)
+		if err != nil {
+			continue
+		}
+		if d.Year() == 0 {
+			d = d.AddDate(now.Year(), 0, 0)
+			if d.Before(now.AddDate(0, -2, 0)) {
+				d = d.AddDate(1, 0, 0)
+			}
+		}
+		if !d.After(now) {
+			return nil, true
+		}
+		return &d, false
+	}
+	return nil, false
+}
@@ -0,0 +1,66 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRentalTerms(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+
+	// the overview list wins; the description fills the gaps
+	overview := []string{"furnished: yes", "lease: minimum 1 year", "available from: 1st november 2026"}
+	terms := parseRentalTerms(overview, "Unfurnished on request. Available immediately.", now)
+	if terms == nil || terms.Furnishing != "furnished" || terms.MinimumLeaseMonths != 12 || terms.AvailableNow {
+		t.Fatalf("terms = %+v", terms)
+	}
+	if terms.AvailableFrom == nil || !terms.AvailableFrom.Equal(time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("available from = %v", terms.AvailableFrom)
+	}
+
+	terms = parseRentalTerms(nil, "Part furnished two-bed. Minimum lease of 6 months, available now.", now)
+	if terms == nil || terms.Furnishing != "part_furnished" || terms.MinimumLeaseMonths != 6 || !terms.AvailableNow {
+		t.Errorf("description terms = %+v", terms)
+	}
+
+	if terms := parseRentalTerms(nil, "Bright apartment close to the Luas. Please call to arrange a viewing.", now); terms != nil {
+		t.Errorf("expected no terms, got %+v", terms)
+	}
+}
+
+func TestParseAvailableFrom(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	cases := []struct {
+		text  string
+		date  time.Time
+		ready bool
+	}{
+		{"Available from 5th January", time.Date(2027, 1, 5, 0, 0, 0, 0, time.UTC), false},
+		{"available 20/11/2026", time.Date(2026, 11, 20, 0, 0, 0, 0, time.UTC), false},
+		{"Available Dec 1st, 2026", time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC), false},
+		{"Available from 1 September", time.Time{}, true}, // already passed
+		{"Unavailable for viewings this week", time.Time{}, false},
+	}
+	for _, c := range cases {
+		date, ready := parseAvailableFrom(c.text, now)
+		if ready != c.ready || (date == nil) != c.date.IsZero() || (date != nil && !date.Equal(c.date)) {
+			t.Errorf("%q = %v, %v", c.text, date, ready)
+		}
+	}
+}
+
+func TestParseLeaseMonths(t *testing.T) {
+	cases := map[string]int{
+		"Lease: Minimum 1 Year":         12,
+		"12 month lease preferred":      12,
+		"a one-year minimum lease":      12,
+		"Short term lease of 6 months":  6,
+		"Please note no pets":           0,
+		"Lease to be agreed with agent": 0,
+	}
+	for text, want := range cases {
+		if got := parseLeaseMonths(text); got != want {
+			t.Errorf("parseLeaseMonths(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package scraper
+
+import "time"
+
+/* ───── Resultados dos módulos ──────────────────────────────────────── */
+
+// ActFastAdvice diz se vale aplicar imediatamente ou se há tempo para marcar visita
+type ActFastAdvice struct {
+	Score        int      `json:"score"` // 0-100, maior = mais urgente
+	Level        string   `json:"level"` // apply_now, apply_soon ou time_to_view
+	Advice       string   `json:"advice"`
+	DaysOnMarket *int     `json:"daysOnMarket,omitempty"`
+	ViewsPerDay  float64  `json:"viewsPerDay,omitempty"`
+	AreaListings int      `json:"areaListings,omitempty"` // anúncios parecidos vistos na área nos últimos 14 dias
+	Reasons      []string `json:"reasons"`
+}
+
+// Affordability compara o aluguel (ou a prestação, na venda) com a renda informada
+type Affordability struct {
+	MonthlyNetIncome float64 `json:"monthlyNetIncome"`
+	MonthlyPayment   float64 `json:"monthlyPayment"` // aluguel do mês ou prestação estimada
+	RentToIncome     float64 `json:"rentToIncome"`   // percentual da renda
+	Rating           string  `json:"rating"`         // green (até 30%), amber (até 35%) ou red
+	IncomeFor30      float64 `json:"incomeFor30"`    // renda líquida para o aluguel ficar em 30%
+	IncomeFor35      float64 `json:"incomeFor35"`
+}
+
+// ListingChange descreve um campo que mudou desde a análise anterior
+type ListingChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+	Note  string `json:"note,omitempty"`
+}
+
+// Annotation é uma nota de um usuário sobre uma área ou prédio
+// (ex.: "problemas com o lixo", "ótima administradora")
+type Annotation struct {
+	ID        string    `json:"id"`
+	Author    string    `json:"author"`
+	Kind      string    `json:"kind"`            // area ou building
+	Label     string    `json:"label,omitempty"` // nome do prédio / da área
+	Lat       float64   `json:"lat"`
+	Lng       float64   `json:"lng"`
+	Radius    float64   `json:"radius"` // em metros
+	Note      string    `json:"note"`
+	Rating    int       `json:"rating,omitempty"` // 1-5
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CyclingInfo são os dados por trás do bikeScore
+type CyclingInfo struct {
+	RadiusMeters      int     `json:"radiusMeters"`
+	CycleLaneKm       float64 `json:"cycleLaneKm"` // ciclovias e ciclofaixas dentro do raio
+	BikeParking       int     `json:"bikeParking"`
+	BikeShareStations int     `json:"bikeShareStations"`
+	ElevationRange    *int    `json:"elevationRangeMeters,omitempty"` // desnível em metros; nil sem dado de elevação
+}
+
+// ChecklistItem é uma coisa a verificar ou perguntar na visita, com o motivo
+type ChecklistItem struct {
+	Category string `json:"category"` // energy, contents, noise, safety, costs, listing, general
+	Item     string `json:"item"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// CommuteCost é a estimativa do custo mensal do trajeto até o destino do cliente
+type CommuteCost struct {
+	Destination string  `json:"destination"`
+	Mode        string  `json:"mode"`       // public ou car
+	DistanceKm  float64 `json:"distanceKm"` // em linha reta
+	DaysPerWeek int     `json:"daysPerWeek"`
+	FareZone    string  `json:"fareZone,omitempty"`    // dublin_city, dublin_commuter ou intercity (public)
+	TollPerTrip float64 `json:"tollPerTrip,omitempty"` // car
+	Monthly     float64 `json:"monthly"`
+	Basis       string  `json:"basis"` // como o valor foi estimado
+}
+
+// ModuleStatus é a situação de um módulo da análise: ok, failed, degraded (a fonte
+// está fora e foi pulada sem esperar; ver circuit.go), estimated (usou valores
+// estimados no lugar dos reais), no_data (rodou mas não achou dados), skipped (não
+// foi pedido ou não está configurado) ou disabled (desligado nesta instalação, ver
+// ModuleEnabled)
+type ModuleStatus struct {
+	Module string `json:"module"`
+	Status string `json:"status"`
+	Code   string `json:"code,omitempty"` // código do APIError quando failed
+	Note   string `json:"note,omitempty"`
+}
+
+// DataQuality diz ao cliente em que partes da análise confiar. Complete só é true se
+// todos os módulos pedidos terminaram ok.
+// Montado no fim da análise, que fica só para leitura.
+type DataQuality struct {
+	Complete bool           `json:"complete"`
+	Modules  []ModuleStatus `json:"modules"`
+}
+
+// MarketHistory é o tempo no mercado do anúncio e do imóvel, contando re-anúncios
+type MarketHistory struct {
+	FirstSeen            time.Time `json:"firstSeen"`    // este anúncio, pela publicação ou pelo primeiro registro
+	DaysOnMarket         int       `json:"daysOnMarket"` // deste anúncio
+	PropertyFirstSeen    time.Time `json:"propertyFirstSeen"`
+	PropertyDaysOnMarket int       `json:"propertyDaysOnMarket"` // desde o anúncio mais antigo do imóvel
+	Relists              []Relist  `json:"relists"`              // anúncios anteriores do mesmo imóvel
+	NegotiationHint      string    `json:"negotiationHint,omitempty"`
+}
+
+// Relist é outro anúncio do mesmo imóvel
+type Relist struct {
+	URL       string     `json:"url"`
+	FirstSeen *time.Time `json:"firstSeen,omitempty"` // nil se só o índice de fotos o conhece
+	MatchedBy string     `json:"matchedBy"`           // address ou photos
+}
+
+// FamilyFactor é um fator do familyScore
+type FamilyFactor struct {
+	Factor   string  `json:"factor"`
+	Weight   float64 `json:"weight"` // fração da nota
+	Score    int     `json:"score"`  // 0-100
+	Evidence string  `json:"evidence"`
+}
+
+// FamilyInfo é o detalhamento do familyScore
+type FamilyInfo struct {
+	Factors []FamilyFactor `json:"factors"`
+	Places  []POI          `json:"places"` // escolas, creches e parquinhos encontrados
+}
+
+// FloorArea é a área útil do imóvel em m² e de onde ela veio
+type FloorArea struct {
+	SquareMeters float64 `json:"squareMeters"`
+	Source       string  `json:"source"` // listing, description ou floorplan_ocr
+}
+
+// FraudRisk é o risco de golpe do anúncio e os sinais que o compõem
+type FraudRisk struct {
+	Score   int           `json:"score"` // 0 a 100
+	Level   string        `json:"level"` // low, medium ou high
+	Signals []FraudSignal `json:"signals"`
+}
+
+// FraudSignal é um sinal de golpe com o seu peso na nota
+type FraudSignal struct {
+	Signal   string `json:"signal"`
+	Weight   int    `json:"weight"`
+	Evidence string `json:"evidence"`
+}
+
+// LivingCosts é a estimativa mensal dos custos além do aluguel ou da prestação
+type LivingCosts struct {
+	Energy        float64  `json:"energy"` // luz e gás, com as taxas fixas
+	Bins          float64  `json:"bins"`
+	Broadband     float64  `json:"broadband"`
+	ManagementFee float64  `json:"managementFee,omitempty"` // condomínio (venda)
+	Total         float64  `json:"total"`
+	Basis         []string `json:"basis"` // as premissas de cada parte
+}
+
+// Negotiation é a seção de negociação da análise de valor
+type Negotiation struct {
+	Stance         string            `json:"stance"`      // room_to_negotiate, at_market ou below_market
+	AskingPrice    float64           `json:"askingPrice"` // por mês no aluguel
+	MedianPrice    float64           `json:"medianPrice"` // dos comparáveis
+	AboveMedianPct float64           `json:"aboveMedianPct"`
+	SuggestedOffer float64           `json:"suggestedOffer,omitempty"` // só com room_to_negotiate
+	Comparables    []SimilarProperty `json:"comparables"`              // os citados como prova
+	Evidence       []string          `json:"evidence"`
+}
+
+// PhotoMatch é uma foto do anúncio que também aparece em outro anúncio
+type PhotoMatch struct {
+	PhotoURL        string `json:"photoUrl"`
+	OtherListingURL string `json:"otherListingUrl"`
+	OtherAddress    string `json:"otherAddress"`
+	Distance        int    `json:"distance"` // bits diferentes entre os hashes (0 = idêntica)
+	Kind            string `json:"kind"`     // different_address (golpe) ou relisted
+}
+
+// PhotoAnalysis resume as fotos do anúncio: quantas são, quantas passaram pelo hash e
+// quais parecem banco de imagens (a mesma foto em anúncios de vários endereços)
+type PhotoAnalysis struct {
+	Count       int      `json:"count"`
+	Hashed      int      `json:"hashed"`
+	StockPhotos []string `json:"stockPhotos"`
+}
+
+// StockPhotoAddresses é a partir de quantos endereços diferentes com a mesma foto ela
+// é de banco de imagens
+const StockPhotoAddresses = 3
+
+// QuietInfo são os dados por trás do quietScore
+type QuietInfo struct {
+	BusiestRoad string   `json:"busiestRoad,omitempty"` // classe da via mais movimentada a até 200 m
+	MajorRoads  []string `json:"majorRoads"`            // nomes das vias que descontam pontos
+	Nightlife   int      `json:"nightlife"`             // bares, pubs e boates a até 300 m
+	FlightPath  string   `json:"flightPath,omitempty"`  // pista cujo prolongamento passa perto
+}
+
+// RemoteWorkInfo são os dados por trás do remoteWorkScore
+type RemoteWorkInfo struct {
+	Broadband    string   `json:"broadband"`    // fibre, broadband ou unknown (o anúncio não diz)
+	WifiCafes    *int     `json:"wifiCafes"`    // nil se o Overpass falhou
+	Coworking    []POI    `json:"coworking"`    // do mais perto ao mais longe
+	NoiseSources []string `json:"noiseSources"` // o que pode atrapalhar chamadas
+	RadiusMeters int      `json:"radiusMeters"` // raio de cafés e coworkings
+}
+
+// AdvertiserReputation é a nota da agência no Google
+type AdvertiserReputation struct {
+	PlaceName    string  `json:"placeName"`
+	Address      string  `json:"address,omitempty"`
+	Rating       float32 `json:"rating"` // 1 a 5
+	ReviewsCount int     `json:"reviewsCount"`
+	Verdict      string  `json:"verdict"` // good, mixed, poor ou too_few_reviews
+	Source       string  `json:"source"`  // google_places
+}
+
+// TenancyRegistration é o resultado da consulta ao registro do RTB
+type TenancyRegistration struct {
+	Status         string `json:"status"`             // registered, not_found ou unavailable
+	LookupBy       string `json:"lookupBy,omitempty"` // eircode ou address
+	MatchedAddress string `json:"matchedAddress,omitempty"`
+	Tenancies      int    `json:"tenancies,omitempty"` // locações registradas no endereço
+	Note           string `json:"note,omitempty"`
+}
+
+// EnergyUpgradeHints estima as reformas subsidiadas para imóveis à venda com BER ruim
+type EnergyUpgradeHints struct {
+	BER          string          `json:"ber"`
+	HouseType    string          `json:"houseType"` // detached, semi, terrace ou apartment
+	Measures     []EnergyUpgrade `json:"measures"`
+	TotalGrant   float64         `json:"totalGrant"`
+	TotalCostMin float64         `json:"totalCostMin"`
+	TotalCostMax float64         `json:"totalCostMax"`
+	Note         string          `json:"note"`
+}
+
+// EnergyUpgrade é uma medida elegível, com subsídio e faixas típicas de custo/economia
+type EnergyUpgrade struct {
+	Measure         string  `json:"measure"`
+	Grant           float64 `json:"grant"`
+	CostMin         float64 `json:"costMin"`
+	CostMax         float64 `json:"costMax"`
+	AnnualSavingMin float64 `json:"annualSavingMin"`
+	AnnualSavingMax float64 `json:"annualSavingMax"`
+}
+
+// ServiceCharge é a taxa anual de condomínio declarada no anúncio
+type ServiceCharge struct {
+	Annual            float64            `json:"annual"`
+	Monthly           float64            `json:"monthly"`
+	Stated            string             `json:"stated"` // trecho original do anúncio
+	ManagementCompany *ManagementCompany `json:"managementCompany,omitempty"`
+}
+
+// ManagementCompany é a Owners' Management Company encontrada no CRO
+type ManagementCompany struct {
+	Name   string `json:"name"`
+	Number string `json:"number"`
+	Status string `json:"status"`
+}
@@ -0,0 +1,143 @@
+package scraper
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Retry com backoff exponencial das chamadas externas ─────────── */
+
+// retryPolicy diz quantas vezes repetir uma chamada e quanto esperar entre elas.
+// HTTP_RETRIES (padrão 3, 0 desliga), HTTP_RETRY_BASE_DELAY (500ms) e
+// HTTP_RETRY_MAX_DELAY (10s) trocam os valores.
+type retryPolicy struct {
+	retries   int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+func retryPolicyFromEnv() retryPolicy {
+	p := retryPolicy{retries: 3, baseDelay: 500 * time.Millisecond, maxDelay: 10 * time.Second}
+	if n, err := strconv.Atoi(os.Getenv("HTTP_RETRIES")); err == nil && n >= 0 {
+		p.retries = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("HTTP_RETRY_BASE_DELAY")); err == nil && d > 0 {
+		p.baseDelay = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("HTTP_RETRY_MAX_DELAY")); err == nil && d > 0 {
+		p.maxDelay = d
+	}
+	return p
+}
+
+// backoff é a espera antes da tentativa attempt+1: dobra a cada tentativa, até
+// maxDelay, com metade do valor sorteada para as instâncias não baterem juntas
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	d := p.maxDelay
+	if attempt < 30 && p.baseDelay<<attempt < p.maxDelay {
+		d = p.baseDelay << attempt
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryableStatus são as respostas que valem repetir: limite de taxa e falhas
+// temporárias do servidor ou do gateway
+var retryableStatus = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// retryAfter lê o cabeçalho Retry-After, em segundos ou como data HTTP
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// retryTransport repete as requisições que falham na rede ou recebem 429/502/503/504.
+// Respeita o Retry-After (se pedir mais que maxDelay, devolve a resposta em vez de
+// esperar) e o contexto da requisição: não espera além do prazo nem depois de um
+// cancelamento. Só lê os campos; é seguro para uso concorrente.
+type retryTransport struct {
+	base   http.RoundTripper
+	policy retryPolicy
+}
+
+// NewRetryTransport envolve base com a política de HTTP_RETRIES
+func NewRetryTransport(base http.RoundTripper) retryTransport {
+	return retryTransport{base: base, policy: retryPolicyFromEnv()}
+}
+
+func (t retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if attempt >= t.policy.retries || req.Context().Err() != nil {
+			return resp, err
+		}
+		if err == nil && !retryableStatus[resp.StatusCode] {
+			return resp, nil
+		}
+		// sem GetBody o corpo já foi consumido e não dá para reenviar
+		if req.Body != nil && req.GetBody == nil {
+			return resp, err
+		}
+
+		wait := t.policy.backoff(attempt)
+		if resp != nil {
+			if d, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if d > t.policy.maxDelay {
+					return resp, nil
+				}
+				wait = d
+			}
+		}
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < wait {
+			return resp, err
+		}
+
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		telemetry.LogFor(req.Context()).Info("Retrying upstream call", "method", req.Method, "host", req.URL.Host, "wait", wait.Round(time.Millisecond), "attempt", attempt+2, "of", t.policy.retries+1, "reason", reason)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
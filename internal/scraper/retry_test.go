@@ -0,0 +1,149 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer answers with statuses in order, then 200 with body
+func flakyServer(t *testing.T, body string, statuses ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if int(n) <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// retryClient builds a client with the given HTTP_RETRIES and HTTP_RETRY_BASE_DELAY
+func retryClient(t *testing.T, retries, baseDelay string) *http.Client {
+	t.Helper()
+	t.Setenv("HTTP_RETRIES", retries)
+	t.Setenv("HTTP_RETRY_BASE_DELAY", baseDelay)
+	return &http.Client{Transport: NewRetryTransport(http.DefaultTransport)}
+}
+
+func TestRetryTransportRecovers(t *testing.T) {
+	client := retryClient(t, "3", "1ms")
+	srv, calls := flakyServer(t, "ok", http.StatusTooManyRequests, http.StatusServiceUnavailable)
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		t.Errorf("got %d %q", resp.StatusCode, body)
+	}
+	if *calls != 3 {
+		t.Errorf("calls = %d, want 3", *calls)
+	}
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	client := retryClient(t, "2", "1ms")
+	srv, calls := flakyServer(t, "ok", 503, 503, 503, 503)
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || *calls != 3 {
+		t.Errorf("got %d after %d calls, want 503 after 3", resp.StatusCode, *calls)
+	}
+
+	// errors that won't change on a retry are returned at once
+	srv, calls = flakyServer(t, "ok", http.StatusNotFound)
+	resp, err = client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || *calls != 1 {
+		t.Errorf("got %d after %d calls, want 404 after 1", resp.StatusCode, *calls)
+	}
+}
+
+func TestRetryTransportHonorsRetryAfter(t *testing.T) {
+	t.Setenv("HTTP_RETRY_MAX_DELAY", "1s")
+	client := retryClient(t, "3", "1ms")
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	// waiting two minutes is worse than failing now
+	start := time.Now()
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || calls != 1 || time.Since(start) > time.Second {
+		t.Errorf("got %d after %d calls in %v", resp.StatusCode, calls, time.Since(start))
+	}
+}
+
+func TestRetryTransportStopsAtDeadline(t *testing.T) {
+	client := retryClient(t, "5", "1s")
+	srv, calls := flakyServer(t, "ok", 502, 502, 502)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	// the backoff would end after the deadline, so the 502 is returned right away
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || *calls != 1 {
+		t.Errorf("got %d after %d calls", resp.StatusCode, *calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"7", 7 * time.Second, true},
+		{"Sun, 01 Mar 2026 12:00:30 GMT", 30 * time.Second, true},
+		{"Sun, 01 Mar 2026 11:00:00 GMT", 0, true},
+		{"later", 0, false},
+	}
+	for _, c := range cases {
+		if got, ok := retryAfter(c.value, now); got != c.want || ok != c.ok {
+			t.Errorf("retryAfter(%q) = %v, %v; want %v, %v", c.value, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := retryPolicy{retries: 3, baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+	for attempt, max := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		max *= time.Millisecond
+		if d := p.backoff(attempt); d < max/2 || d > max {
+			t.Errorf("backoff(%d) = %v, want between %v and %v", attempt, d, max/2, max)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"daft-scraper-api/internal/env"
+	"daft-scraper-api/internal/telemetry"
+
+	"github.com/temoto/robotstxt"
+)
+
+/* ───── robots.txt dos portais ──────────────────────────────────────── */
+
+// Antes de cada página de um portal (crawlDomains), consultamos o robots.txt do host,
+// guardado por robotsTTL. Uma página proibida não é pedida e a visita falha com
+// ErrRobotsDisallowed. CRAWL_ROBOTS=ignore (ou CRAWL_<DOMÍNIO>_ROBOTS, como
+// CRAWL_DAFT_IE_ROBOTS) desliga a consulta. Sem resposta do host o robots.txt não
+// bloqueia nada; um 5xx bloqueia tudo até a próxima consulta (regra do próprio
+// robots.txt).
+
+// ErrRobotsDisallowed indica que o robots.txt do portal proíbe a página
+var ErrRobotsDisallowed = errors.New("robots.txt disallows this page")
+
+const (
+	robotsTTL     = 6 * time.Hour
+	robotsAgent   = "daft-scraper-api"
+	maxRobotsSize = 512 << 10
+)
+
+// robotsCache guarda o robots.txt de cada host; seguro para uso concorrente
+type robotsCache struct {
+	mu    sync.Mutex
+	hosts map[string]robotsEntry
+}
+
+type robotsEntry struct {
+	data    *robotstxt.RobotsData
+	fetched time.Time
+}
+
+var robots = &robotsCache{hosts: map[string]robotsEntry{}}
+
+// crawlDomainOf devolve o portal de crawlDomains a que o host pertence; "" se nenhum
+func crawlDomainOf(host string) string {
+	host = strings.ToLower(host)
+	for _, domain := range crawlDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return domain
+		}
+	}
+	return ""
+}
+
+// respectsRobots diz se o robots.txt do portal vale (padrão) ou foi desligado
+func respectsRobots(domain string) bool {
+	policy := env.Or("CRAWL_"+env.Name(domain)+"_ROBOTS", env.Or("CRAWL_ROBOTS", "respect"))
+	return !strings.EqualFold(policy, "ignore")
+}
+
+// allowed consulta o robots.txt do host de u, baixando-o por base quando não está
+// guardado ou venceu
+func (rc *robotsCache) allowed(ctx context.Context, u *url.URL, base http.RoundTripper) bool {
+	key := u.Scheme + "://" + u.Host
+	rc.mu.Lock()
+	entry, ok := rc.hosts[key]
+	rc.mu.Unlock()
+
+	if !ok || time.Since(entry.fetched) > robotsTTL {
+		data, err := fetchRobots(ctx, key, base)
+		if err != nil {
+			telemetry.LogFor(ctx).Warn("Could not read robots.txt", "host", u.Host, "error", err)
+			return true
+		}
+		entry = robotsEntry{data: data, fetched: time.Now()}
+		rc.mu.Lock()
+		rc.hosts[key] = entry
+		rc.mu.Unlock()
+	}
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return entry.data.TestAgent(path, robotsAgent)
+}
+
+// fetchRobots baixa e interpreta o robots.txt de origin (scheme://host)
+func fetchRobots(ctx context.Context, origin string, base http.RoundTripper) (*robotstxt.RobotsData, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+	if err != nil {
+		return nil, err
+	}
+	return robotstxt.FromStatusAndBytes(resp.StatusCode, body)
+}
+
+// robotsTransport recusa as páginas dos portais que o robots.txt proíbe; os outros
+// hosts passam direto
+type robotsTransport struct {
+	base http.RoundTripper
+}
+
+func (t robotsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	domain := crawlDomainOf(req.URL.Hostname())
+	if domain == "" || req.URL.Path == "/robots.txt" || !respectsRobots(domain) {
+		return t.base.RoundTrip(req)
+	}
+	if !robots.allowed(req.Context(), req.URL, t.base) {
+		telemetry.LogFor(req.Context()).Warn("Page disallowed by robots.txt", "url", req.URL.String())
+		return nil, fmt.Errorf("%w: %s", ErrRobotsDisallowed, req.URL.Path)
+	}
+	return t.base.RoundTrip(req)
+}
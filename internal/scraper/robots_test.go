@@ -0,0 +1,94 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeRobotsTransport answers robots.txt with body and every other page with 200
+type fakeRobotsTransport struct {
+	status  int
+	body    string
+	fetches *int32
+	pages   *int32
+}
+
+func (f fakeRobotsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Path == "/robots.txt" {
+		atomic.AddInt32(f.fetches, 1)
+		return &http.Response{StatusCode: f.status, Body: io.NopCloser(strings.NewReader(f.body)), Request: req}, nil
+	}
+	atomic.AddInt32(f.pages, 1)
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok")), Request: req}, nil
+}
+
+func withRobotsCache(t *testing.T) {
+	old := robots
+	robots = &robotsCache{hosts: map[string]robotsEntry{}}
+	t.Cleanup(func() { robots = old })
+}
+
+func TestRobotsTransport(t *testing.T) {
+	withRobotsCache(t)
+	var fetches, pages int32
+	rt := robotsTransport{fakeRobotsTransport{status: http.StatusOK, fetches: &fetches, pages: &pages,
+		body: "User-agent: *\nDisallow: /property-for-rent/\nAllow: /for-rent/\n"}}
+
+	get := func(u string) error {
+		req, _ := http.NewRequest(http.MethodGet, u, nil)
+		_, err := rt.RoundTrip(req)
+		return err
+	}
+	if err := get("https://www.daft.ie/for-rent/apartment-1-main-street/123"); err != nil {
+		t.Errorf("allowed page: %v", err)
+	}
+	if err := get("https://www.daft.ie/property-for-rent/dublin?from=20"); !errors.Is(err, ErrRobotsDisallowed) {
+		t.Errorf("disallowed page: err = %v", err)
+	}
+	// hosts outside the portals are not checked
+	if err := get("http://127.0.0.1:8080/property-for-rent/dublin"); err != nil {
+		t.Errorf("other host: %v", err)
+	}
+	if fetches != 1 || pages != 2 {
+		t.Errorf("robots.txt fetched %d times, %d pages sent; want 1 and 2", fetches, pages)
+	}
+
+	// the override turns the check off for one portal
+	t.Setenv("CRAWL_DAFT_IE_ROBOTS", "ignore")
+	if err := get("https://www.daft.ie/property-for-rent/dublin"); err != nil {
+		t.Errorf("robots ignored: %v", err)
+	}
+}
+
+func TestRobotsServerErrorDisallowsAll(t *testing.T) {
+	withRobotsCache(t)
+	var fetches, pages int32
+	base := fakeRobotsTransport{status: http.StatusServiceUnavailable, fetches: &fetches, pages: &pages}
+	u, _ := url.Parse("https://www.rent.ie/houses-to-let/dublin/")
+	if robots.allowed(context.Background(), u, base) {
+		t.Error("a 5xx robots.txt should disallow the site")
+	}
+	base.status = http.StatusNotFound
+	robots = &robotsCache{hosts: map[string]robotsEntry{}}
+	if !robots.allowed(context.Background(), u, base) {
+		t.Error("a missing robots.txt should allow the site")
+	}
+}
+
+func TestCrawlDomainOf(t *testing.T) {
+	cases := map[string]string{
+		"www.daft.ie": "daft.ie", "DAFT.IE": "daft.ie", "www.myhome.ie": "myhome.ie",
+		"notdaft.ie": "", "127.0.0.1": "",
+	}
+	for host, want := range cases {
+		if got := crawlDomainOf(host); got != want {
+			t.Errorf("crawlDomainOf(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
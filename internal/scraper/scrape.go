@@ -0,0 +1,308 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"daft-scraper-api/internal/telemetry"
+
+	"github.com/gocolly/colly/v2"
+)
+
+/* ───── Scraping do anúncio ─────────────────────────────────────────── */
+
+// ExtractPriceValue extrai o valor numérico de uma string de preço
+func ExtractPriceValue(price string) float64 {
+	// Remover símbolos de moeda e separadores
+	price = strings.TrimSpace(price)
+	price = strings.ReplaceAll(price, "€", "")
+	price = strings.ReplaceAll(price, ",", "")
+	price = strings.ReplaceAll(price, " ", "")
+
+	// Capturar apenas os dígitos iniciais (ignorando qualquer texto)
+	re := regexp.MustCompile(`^[0-9]+(?:\.[0-9]+)?`)
+	match := re.FindString(price)
+	if match == "" {
+		return 0
+	}
+
+	value, err := strconv.ParseFloat(match, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// extractLocationFromAddress extrai a localização principal do endereço
+func extractLocationFromAddress(addr string) string {
+	suburb, county := SplitLocation(addr)
+	if suburb == "" && county == "" {
+		return ""
+	}
+
+	// gera slug preservando letras e hífens (sem números)
+	return Slugify(suburb) + "-" + Slugify(county)
+}
+
+// SplitLocation separa o endereço em bairro/subúrbio e condado
+func SplitLocation(addr string) (suburb, county string) {
+	parts := strings.Split(addr, ",")
+	for i := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(parts[i]))
+	}
+
+	// limpa vazios
+	filtered := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p != "" {
+			filtered = append(filtered, p)
+		}
+	}
+	if len(filtered) == 0 {
+		return "", ""
+	}
+
+	// heurística: penúltimo = bairro/subúrbio, último = condado / "dublin X"
+	if len(filtered) >= 2 {
+		suburb = filtered[len(filtered)-2]
+	}
+	county = filtered[len(filtered)-1]
+
+	// remove prefixos "co.", "county"
+	county = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(county, "co."), "county"))
+
+	// se o condado tiver números (ex.: "dublin 9"), pega só a 1ª palavra (⇒ "dublin")
+	if idx := strings.IndexFunc(county, unicode.IsDigit); idx != -1 {
+		county = strings.Fields(county)[0]
+	}
+
+	return suburb, county
+}
+
+// RoundToNearest50 arredonda um número para o múltiplo de 50 mais próximo
+func RoundToNearest50(value float64) float64 {
+	return math.Round(value/50) * 50
+}
+
+func Slugify(s string) string {
+	s = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(s, " ", "-")))
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ErrListingNotFound indica que o anúncio foi removido do Daft.ie (404/410)
+var ErrListingNotFound = errors.New("listing not found")
+
+// ScrapeDaftListing raspa apenas os dados básicos do anúncio, sem enriquecimento
+func ScrapeDaftListing(ctx context.Context, url string) (_ PropertyInfo, err error) {
+	ctx, s := telemetry.StartSpan(ctx, "scrape", telemetry.SpanInternal, "url", url)
+	defer func() { s.End(err) }()
+
+	c := colly.NewCollector(
+		colly.AllowedDomains(ScrapeDomains()...),
+		colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+		colly.AllowURLRevisit(),
+	)
+	BindCollector(ctx, c)
+	DebugCollector(ctx, c)
+	lg := telemetry.LogFor(ctx).With("url", url)
+
+	// Configurar headers adicionais
+	c.OnRequest(func(r *colly.Request) {
+		r.Headers.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
+		r.Headers.Set("Accept-Language", "en-US,en;q=0.5")
+		r.Headers.Set("Cache-Control", "no-cache")
+		r.Headers.Set("Pragma", "no-cache")
+		r.Headers.Set("DNT", "1")
+		r.Headers.Set("Connection", "keep-alive")
+		r.Headers.Set("Upgrade-Insecure-Requests", "1")
+	})
+
+	property := PropertyInfo{URL: url, ListingType: ListingType(url)}
+	foundAddress := false
+	period := "" // do preço, lido do og:description
+	// linhas da visão geral com mobília, contrato e data de entrada
+	var terms []string
+	statusCode := 0
+	redirected := false
+
+	c.OnResponse(func(r *colly.Response) {
+		// anúncios removidos costumam redirecionar para a página de busca
+		if id := ListingIDFromURL(url); id != "" && ListingIDFromURL(r.Request.URL.String()) != id {
+			lg.Info("Listing redirected", "to", r.Request.URL.String())
+			redirected = true
+		}
+		lg.Debug("Listing page fetched", "status", r.StatusCode, "bytes", len(r.Body))
+
+		// Salvar HTML para debug (só no modo debug, em DEBUG_DIR)
+		SaveDebugPage(ctx, "listing "+ListingIDFromURL(url), r)
+	})
+
+	// Encontrar o endereço
+	c.OnHTML("meta[property='og:title']", func(e *colly.HTMLElement) {
+		if !foundAddress {
+			text := strings.TrimSpace(e.Attr("content"))
+			if text != "" && strings.Contains(text, "to share on Daft.ie") {
+				text = strings.TrimSuffix(text, " to share on Daft.ie")
+				property.Address = text
+				foundAddress = true
+			}
+		}
+	})
+
+	// Encontrar o preço
+	c.OnHTML("meta[property='og:description']", func(e *colly.HTMLElement) {
+		if property.RentPrice == "" {
+			text := strings.TrimSpace(e.Attr("content"))
+			if strings.Contains(text, "€") {
+				priceStart := strings.Index(text, "€")
+				priceEnd := strings.Index(text[priceStart:], " per")
+				if priceEnd > 0 {
+					price := text[priceStart : priceStart+priceEnd]
+					property.RentPrice = price
+					// "€650 per week, 2 Bed..." -> week
+					if rest := strings.Fields(text[priceStart+priceEnd:]); len(rest) > 1 {
+						period = PricePeriod(rest[1])
+					}
+				}
+			}
+		}
+	})
+
+	// Encontrar características do imóvel
+	c.OnHTML("[data-testid='features'], [data-testid='overview'], ul[class*='PropertyFeatures'], ul[class*='PropertyOverview']", func(e *colly.HTMLElement) {
+		e.ForEach("li", func(_ int, item *colly.HTMLElement) {
+			text := strings.ToLower(strings.TrimSpace(item.Text))
+			if strings.Contains(text, "bed") || strings.Contains(text, "bedroom") {
+				property.Bedrooms = text
+			} else if strings.Contains(text, "bath") {
+				property.Bathrooms = text
+			} else if strings.Contains(text, "property type") || strings.Contains(text, "type:") {
+				property.PropertyType = text
+			} else if strings.Contains(text, "furnish") || strings.Contains(text, "lease") || strings.Contains(text, "available") {
+				terms = append(terms, text)
+			}
+		})
+	})
+
+	// Encontrar descrição
+	c.OnHTML("[data-testid='description'], div[class*='PropertyDescription']", func(e *colly.HTMLElement) {
+		if property.Description == "" {
+			text := strings.TrimSpace(e.Text)
+			if text != "" {
+				property.Description = text
+			}
+		}
+	})
+
+	// Dados estruturados do anúncio (fotos etc.)
+	c.OnHTML("script#__NEXT_DATA__", func(e *colly.HTMLElement) {
+		listing, err := parseListingNextData([]byte(e.Text))
+		if err != nil {
+			lg.Warn("Could not decode __NEXT_DATA__", "error", err)
+			return
+		}
+		if photos := listing.photoURLs(); len(photos) > 0 {
+			property.Photos = photos
+		}
+		property.FloorPlans = listing.floorPlanURLs()
+		property.Advertiser = listing.advertiser()
+		// links curtos e do formato antigo passam a valer pela URL da própria página
+		if canonical := canonicalFromPath(listing.SeoFriendlyPath, strconv.FormatInt(listing.ID, 10)); canonical != "" {
+			property.URL = canonical
+			if property.ListingType == "" {
+				property.ListingType = ListingType(canonical)
+			}
+		}
+		if BERBand(listing.Ber.Rating) >= 0 {
+			property.BER, property.BERSource = strings.ToUpper(listing.Ber.Rating), "listing"
+		}
+		if property.PropertyType == "" {
+			property.PropertyType = listing.PropertyType
+		}
+		if area := listing.floorArea(); area > 0 {
+			property.FloorArea = &FloorArea{SquareMeters: area, Source: "listing"}
+		}
+		property.PublishedAt = listing.publishedAt()
+		property.Views = listing.Views
+		property.Availability = listing.Availability(property.ListingType)
+	})
+
+	// Selo BER da página, caso o JSON não traga a classificação
+	c.OnHTML("img[alt*='BER'], img[src*='ber']", func(e *colly.HTMLElement) {
+		if property.BER != "" {
+			return
+		}
+		if ber := berFromBadge(e.Attr("alt"), e.Attr("src")); ber != "" {
+			property.BER, property.BERSource = ber, "badge"
+		}
+	})
+
+	// Foto de capa, caso o JSON não traga a galeria
+	c.OnHTML("meta[property='og:image']", func(e *colly.HTMLElement) {
+		if len(property.Photos) == 0 && e.Attr("content") != "" {
+			property.Photos = []string{e.Attr("content")}
+		}
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		lg.Warn("Listing request failed", "status", r.StatusCode, "error", err)
+		statusCode = r.StatusCode
+	})
+
+	// Configurar limite de requisições
+	LimitCrawl(c)
+
+	err = c.Visit(url)
+	if err != nil {
+		if statusCode == http.StatusNotFound || statusCode == http.StatusGone {
+			return PropertyInfo{}, fmt.Errorf("failed to visit URL: %w", ErrListingNotFound)
+		}
+		if statusCode == http.StatusForbidden {
+			return PropertyInfo{}, fmt.Errorf("failed to visit URL: %w", ErrScrapeBlocked)
+		}
+		return PropertyInfo{}, fmt.Errorf("failed to visit URL: %w", err)
+	}
+	if redirected {
+		return PropertyInfo{}, fmt.Errorf("listing redirected: %w", ErrListingNotFound)
+	}
+
+	FillNumericFields(&property, period)
+	ResolveBER(&property)
+	resolveAdvertiser(&property)
+	property.Viewings = parseViewingTimes(property.Description, time.Now())
+	if property.ListingType != "sale" {
+		property.RentalTerms = parseRentalTerms(terms, property.Description, time.Now())
+	}
+
+	// Verificar se os dados essenciais foram encontrados
+	if !foundAddress || property.RentPrice == "" {
+		property.Error = &APIError{
+			Code:    "LISTING_PARSE_FAILED",
+			Message: "Could not find essential property data. The page structure might have changed or it's not a property listing.",
+			Module:  "scrape",
+		}
+	}
+
+	return property, nil
+}
+
+// Tokenize separa o texto em termos minúsculos, sem pontuação
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
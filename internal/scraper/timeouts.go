@@ -0,0 +1,133 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/telemetry"
+
+	"github.com/gocolly/colly/v2"
+)
+
+/* ───── Timeouts por etapa da análise ───────────────────────────────── */
+
+// StageTimeouts são os limites padrão de cada etapa; <ETAPA>_TIMEOUT troca um deles
+// (SCRAPE_TIMEOUT=20s, OVERPASS_TIMEOUT=10s...). Assim um Overpass travado derruba só
+// a iluminação pública, e não a requisição inteira.
+var StageTimeouts = map[string]time.Duration{
+	"scrape":    30 * time.Second, // cada página do Daft.ie e dos sites de comparáveis
+	"geocode":   10 * time.Second,
+	"places":    15 * time.Second, // cada módulo de lugares próximos
+	"overpass":  30 * time.Second,
+	"crime":     15 * time.Second,
+	"rsa":       15 * time.Second, // colisões de trânsito
+	"elevation": 10 * time.Second, // relevo do bike score
+	"photos":    30 * time.Second,
+	"llm":       60 * time.Second,
+	"upstream":  30 * time.Second,
+}
+
+// StageTimeoutsFromEnv lê <ETAPA>_TIMEOUT para cada etapa de StageTimeouts
+func StageTimeoutsFromEnv() map[string]time.Duration {
+	timeouts := make(map[string]time.Duration, len(StageTimeouts))
+	for stage, def := range StageTimeouts {
+		timeouts[stage] = def
+		if d, err := time.ParseDuration(os.Getenv(strings.ToUpper(stage) + "_TIMEOUT")); err == nil && d > 0 {
+			timeouts[stage] = d
+		}
+	}
+	return timeouts
+}
+
+// IsTimeout diz se err veio de um timeout de etapa ou do cliente HTTP
+func IsTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// contextTransport amarra as requisições de um colly.Collector ao contexto da análise,
+// já que o colly v2.1 cria as próprias requisições sem contexto
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// FetchSettings são os ajustes das páginas raspadas: o timeout de cada uma e o
+// circuit breaker. Vão no contexto (o Analyzer da requisição os põe lá) até
+// BindCollector.
+type FetchSettings struct {
+	Timeout time.Duration // 0 = StageTimeouts["scrape"]
+	Circuit CircuitSettings
+}
+
+type fetchSettingsKey struct{}
+
+// WithFetchSettings anota no contexto os ajustes usados por BindCollector
+func WithFetchSettings(ctx context.Context, s FetchSettings) context.Context {
+	return context.WithValue(ctx, fetchSettingsKey{}, s)
+}
+
+// fetchSettingsFrom devolve os ajustes do contexto; sem eles, os padrões
+func fetchSettingsFrom(ctx context.Context) FetchSettings {
+	s, _ := ctx.Value(fetchSettingsKey{}).(FetchSettings)
+	if s.Timeout <= 0 {
+		s.Timeout = StageTimeouts["scrape"]
+	}
+	return s
+}
+
+// BindCollector limita cada requisição do collector ao timeout de scraping, a cancela
+// junto com ctx, confere o robots.txt e as passa pelo circuit breaker e pelo retry
+// (robots.go, circuit.go, retry.go)
+func BindCollector(ctx context.Context, c *colly.Collector) {
+	settings := fetchSettingsFrom(ctx)
+	c.SetRequestTimeout(settings.Timeout)
+	c.WithTransport(contextTransport{ctx: ctx, base: robotsTransport{TracingTransport{CircuitTransport{
+		Base: NewRetryTransport(http.DefaultTransport), Settings: settings.Circuit}}}})
+}
+
+// TracingTransport abre um span de cliente para cada chamada a uma fonte externa e
+// passa o traceparent adiante. Fica por fora do CircuitTransport, então o span mostra
+// também as tentativas do retry e as recusas do circuito.
+type TracingTransport struct {
+	Base http.RoundTripper
+}
+
+func (t TracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// o span leva a fonte sem o mirror ("overpass:host" vira "overpass"); o host
+	// fica em server.address
+	source, _, _ := strings.Cut(UpstreamName(req.URL.Host), ":")
+	ctx, s := telemetry.StartSpan(req.Context(), req.Method+" "+source, telemetry.SpanClient,
+		"http.request.method", req.Method,
+		"server.address", req.URL.Host,
+		"url.path", req.URL.Path,
+	)
+	if s == nil {
+		return t.Base.RoundTrip(req)
+	}
+	req = req.WithContext(ctx)
+	req.Header = req.Header.Clone()
+	req.Header.Set("traceparent", s.Traceparent())
+
+	resp, err := t.Base.RoundTrip(req)
+	if err == nil {
+		s.Set("http.response.status_code", resp.StatusCode)
+		if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+			err = fmt.Errorf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		}
+		s.End(err)
+		return resp, nil
+	}
+	s.End(err)
+	return resp, err
+}
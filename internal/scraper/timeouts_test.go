@@ -0,0 +1,74 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/telemetry"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// hangingServer never answers; the handlers are released when the test ends
+func hangingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	return srv
+}
+
+func TestBindCollector(t *testing.T) {
+	srv := hangingServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	c := colly.NewCollector()
+	BindCollector(ctx, c)
+
+	done := make(chan error, 1)
+	go func() { done <- c.Visit(srv.URL) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected the visit to fail once the context was cancelled")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the collector ignored the context")
+	}
+}
+
+func TestTracingDisabled(t *testing.T) {
+	prev := telemetry.Tracer
+	telemetry.Tracer = nil
+	defer func() { telemetry.Tracer = prev }()
+
+	var gotTraceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get("traceparent")
+	}))
+	defer srv.Close()
+
+	ctx, s := telemetry.StartSpan(context.Background(), "scrape", telemetry.SpanInternal)
+	s.Set("url", "https://www.daft.ie/")
+	s.End(nil)
+	client := &http.Client{Transport: TracingTransport{http.DefaultTransport}}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if s != nil || gotTraceparent != "" {
+		t.Errorf("tracing should be off without OTEL_EXPORTER_OTLP_ENDPOINT, got span %v and traceparent %q", s, gotTraceparent)
+	}
+}
@@ -0,0 +1,81 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+/* ───── Modo read-through: consulta uma instância central antes de raspar ─ */
+
+// UpstreamClient consulta outra instância da API (UPSTREAM_URL, ex.:
+// https://exchange-helper.example.org, com UPSTREAM_API_KEY); o valor zero é o modo
+// desligado
+type UpstreamClient struct {
+	baseURL string
+	apiKey  string
+	timeout time.Duration // 0 = StageTimeouts["upstream"]
+}
+
+// NewUpstreamClientFromEnv lê UPSTREAM_URL e UPSTREAM_API_KEY; cada consulta tem até
+// timeout
+func NewUpstreamClientFromEnv(timeout time.Duration) UpstreamClient {
+	return UpstreamClient{baseURL: strings.TrimRight(os.Getenv("UPSTREAM_URL"), "/"), apiKey: os.Getenv("UPSTREAM_API_KEY"),
+		timeout: timeout}
+}
+
+// Enabled informa se há uma instância upstream configurada
+func (u UpstreamClient) Enabled() bool {
+	return u.baseURL != ""
+}
+
+// Fetch pede a análise do anúncio à instância configurada
+func (u UpstreamClient) Fetch(ctx context.Context, listingURL string) (PropertyInfo, error) {
+	if !u.Enabled() {
+		return PropertyInfo{}, fmt.Errorf("upstream not configured")
+	}
+
+	body, err := json.Marshal(map[string]string{"daftUrl": listingURL})
+	if err != nil {
+		return PropertyInfo{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.baseURL+"/scrape", bytes.NewReader(body))
+	if err != nil {
+		return PropertyInfo{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if u.apiKey != "" {
+		req.Header.Set("X-API-Key", u.apiKey)
+	}
+
+	timeout := u.timeout
+	if timeout == 0 {
+		timeout = StageTimeouts["upstream"]
+	}
+	client := &http.Client{Timeout: timeout, Transport: TracingTransport{http.DefaultTransport}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return PropertyInfo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return PropertyInfo{}, fmt.Errorf("upstream returned status code: %d", resp.StatusCode)
+	}
+
+	var property PropertyInfo
+	if err := json.NewDecoder(resp.Body).Decode(&property); err != nil {
+		return PropertyInfo{}, fmt.Errorf("error decoding upstream response: %w", err)
+	}
+	if property.Error != nil {
+		return PropertyInfo{}, fmt.Errorf("upstream could not analyse listing: %w", property.Error)
+	}
+	if property.Address == "" {
+		return PropertyInfo{}, fmt.Errorf("upstream could not analyse listing")
+	}
+	return property, nil
+}
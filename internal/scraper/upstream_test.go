@@ -0,0 +1,49 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUpstreamFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/scrape" || r.Header.Get("X-API-Key") != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			DaftURL string `json:"daftUrl"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.DaftURL == "https://www.daft.ie/for-rent/missing/1" {
+			json.NewEncoder(w).Encode(PropertyInfo{URL: body.DaftURL, Error: &APIError{Code: "LISTING_PARSE_FAILED"}})
+			return
+		}
+		json.NewEncoder(w).Encode(PropertyInfo{URL: body.DaftURL, Address: "1 Main St, Dublin 1", RentPrice: "€2,000"})
+	}))
+	defer srv.Close()
+
+	t.Setenv("UPSTREAM_URL", srv.URL+"/")
+	t.Setenv("UPSTREAM_API_KEY", "secret")
+	upstream := NewUpstreamClientFromEnv(0)
+
+	property, err := upstream.Fetch(context.Background(), "https://www.daft.ie/for-rent/flat/2")
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if property.Address != "1 Main St, Dublin 1" {
+		t.Errorf("unexpected property: %+v", property)
+	}
+
+	if _, err := upstream.Fetch(context.Background(), "https://www.daft.ie/for-rent/missing/1"); err == nil {
+		t.Error("expected error when upstream could not analyse the listing")
+	}
+
+	upstream.apiKey = "wrong"
+	if _, err := upstream.Fetch(context.Background(), "https://www.daft.ie/for-rent/flat/2"); err == nil {
+		t.Error("expected error on unauthorized upstream")
+	}
+}
@@ -0,0 +1,185 @@
+package scraper
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* ───── Horários de visita e exportação para o calendário ───────────── */
+
+// Anunciantes escrevem os horários de visita no texto ("Viewing: Saturday 19th October
+// 11:00-11:30", "Open viewing Sat 2-2.30pm"). Lemos os trechos que falam de visita,
+// ligamos cada faixa de horário à data citada antes dela e resolvemos datas sem ano e
+// dias da semana a partir do momento do scraping. GET /analyses/{id}/viewings.ics
+// devolve os horários como eventos de calendário.
+
+// ViewingTime é um horário de visita anunciado
+type ViewingTime struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Text  string    `json:"text"` // trecho do anúncio de onde saiu
+}
+
+const (
+	viewingDefaultLength = 30 * time.Minute // "at 2pm", sem horário de fim
+	maxViewings          = 10
+)
+
+var (
+	// viewingPattern acha os trechos que falam de visita, até o fim da linha
+	viewingPattern = regexp.MustCompile(`(?i)\b(?:open\s+(?:viewing|house|day)|viewings?|viewing\s+times?)\b[^\n]{0,160}`)
+	// viewingDatePattern casa "Saturday 19th October", "Sat 19 Oct", "Tuesday", "19th of October"
+	viewingDatePattern = regexp.MustCompile(`(?i)\b(?:(monday|tuesday|wednesday|thursday|friday|saturday|sunday|mon|tues?|wed|thu(?:rs?)?|fri|sat|sun)\b\.?,?\s*)?(?:(?:the\s+)?(\d{1,2})(?:st|nd|rd|th)?(?:\s+of)?\s+(january|february|march|april|may|june|july|august|september|october|november|december|jan|feb|mar|apr|jun|jul|aug|sept?|oct|nov|dec)\b)?`)
+	// viewingTimePattern casa "11:00-11:30", "2-2.30pm", "5pm to 5.30pm", "2pm"
+	viewingTimePattern = regexp.MustCompile(`(?i)\b(\d{1,2})(?:[:.](\d{2}))?\s*(am|pm)?(?:\s*(?:-|–|to|until)\s*(\d{1,2})(?:[:.](\d{2}))?\s*(am|pm)?)?\b`)
+)
+
+var weekdayPrefixes = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// dublinTime é o fuso dos horários anunciados; UTC quando o sistema não tem tzdata
+var dublinTime = func() *time.Location {
+	if loc, err := time.LoadLocation("Europe/Dublin"); err == nil {
+		return loc
+	}
+	return time.UTC
+}()
+
+// parseViewingTimes lê os horários de visita do texto; now resolve datas sem ano e
+// dias da semana (a próxima ocorrência, contando hoje)
+func parseViewingTimes(text string, now time.Time) []ViewingTime {
+	now = now.In(dublinTime)
+	var viewings []ViewingTime
+	seen := map[time.Time]bool{}
+	for _, segment := range viewingPattern.FindAllString(text, -1) {
+		dates := viewingDatePattern.FindAllStringSubmatchIndex(segment, -1)
+		for _, tm := range viewingTimePattern.FindAllStringSubmatchIndex(segment, -1) {
+			g := func(i int) string {
+				if tm[2*i] < 0 {
+					return ""
+				}
+				return strings.ToLower(segment[tm[2*i]:tm[2*i+1]])
+			}
+			// número solto (dia do mês, número da casa) não é horário
+			if g(4) == "" && g(3) == "" && g(2) == "" {
+				continue
+			}
+			day, ok := viewingDay(segment, dates, tm[0], now)
+			if !ok {
+				continue
+			}
+			start, end, ok := viewingClock(g(1), g(2), g(3), g(4), g(5), g(6))
+			if !ok {
+				continue
+			}
+			v := ViewingTime{
+				Start: day.Add(start),
+				Text:  strings.TrimSpace(segment),
+			}
+			v.End = v.Start.Add(viewingDefaultLength)
+			if end > start {
+				v.End = day.Add(end)
+			}
+			if seen[v.Start] {
+				continue
+			}
+			seen[v.Start] = true
+			viewings = append(viewings, v)
+			if len(viewings) == maxViewings {
+				return viewings
+			}
+		}
+	}
+	return viewings
+}
+
+// viewingDay acha a última data citada antes do horário (ou, sem ela, a primeira
+// depois: "2-2.30pm on Saturday") e a resolve para meia-noite daquele dia
+func viewingDay(segment string, dates [][]int, at int, now time.Time) (time.Time, bool) {
+	var ordered [][]int
+	for i := len(dates) - 1; i >= 0; i-- {
+		if dates[i][1] <= at {
+			ordered = append(ordered, dates[i])
+		}
+	}
+	for _, d := range dates {
+		if d[0] > at {
+			ordered = append(ordered, d)
+		}
+	}
+	for _, d := range ordered {
+		if d[0] == d[1] {
+			continue
+		}
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, dublinTime)
+		if d[4] >= 0 && d[6] >= 0 {
+			dayOfMonth, _ := strconv.Atoi(segment[d[4]:d[5]])
+			month, err := time.Parse("Jan", segment[d[6]:d[6]+3])
+			if err != nil || dayOfMonth < 1 || dayOfMonth > 31 {
+				continue
+			}
+			date := time.Date(now.Year(), month.Month(), dayOfMonth, 0, 0, 0, 0, dublinTime)
+			if date.Before(today) {
+				date = date.AddDate(1, 0, 0)
+			}
+			return date, true
+		}
+		if d[2] >= 0 {
+			weekday := weekdayPrefixes[strings.ToLower(segment[d[2]:d[2]+3])]
+			return today.AddDate(0, 0, (int(weekday)-int(today.Weekday())+7)%7), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// viewingClock converte a faixa de horário em deslocamentos desde a meia-noite. Sem
+// am/pm, o início herda o do fim; sem nenhum dos dois, antes das 8 é à tarde (ninguém
+// marca visita às 5 da manhã).
+func viewingClock(h1, m1, ap1, h2, m2, ap2 string) (time.Duration, time.Duration, bool) {
+	clock := func(h, m, ap string) (time.Duration, bool) {
+		hour, err := strconv.Atoi(h)
+		if err != nil || hour > 23 {
+			return 0, false
+		}
+		minute := 0
+		if m != "" {
+			minute, _ = strconv.Atoi(m)
+		}
+		switch {
+		case ap == "pm" && hour < 12:
+			hour += 12
+		case ap == "am" && hour == 12:
+			hour = 0
+		case ap == "" && hour < 8:
+			hour += 12
+		}
+		if minute > 59 {
+			return 0, false
+		}
+		return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, true
+	}
+
+	if ap1 == "" && ap2 != "" {
+		ap1 = ap2
+		s, _ := clock(h1, m1, ap1)
+		if e, _ := clock(h2, m2, ap2); s > e {
+			ap1 = "am" // "11-12pm"
+		}
+	}
+	start, ok := clock(h1, m1, ap1)
+	if !ok {
+		return 0, 0, false
+	}
+	if h2 == "" {
+		return start, 0, true
+	}
+	end, ok := clock(h2, m2, ap2)
+	if !ok {
+		return 0, 0, false
+	}
+	return start, end, true
+}
@@ -0,0 +1,46 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseViewingTimes(t *testing.T) {
+	// Thursday 15 October 2026
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, dublinTime)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, dublinTime)
+	}
+	cases := []struct {
+		text       string
+		start, end time.Time
+	}{
+		{"Viewing: Saturday 17th October 11:00-11:30", at(10, 17, 11, 0), at(10, 17, 11, 30)},
+		{"Open viewing Sat 2-2.30pm. Bring ID.", at(10, 17, 14, 0), at(10, 17, 14, 30)},
+		{"Viewings 5pm to 5.30pm on Tuesday", at(10, 20, 17, 0), at(10, 20, 17, 30)},
+		{"Open house on the 1st of November at 2pm", at(11, 1, 14, 0), at(11, 1, 14, 30)},
+		{"Viewing Thursday 11-12pm", at(10, 15, 11, 0), at(10, 15, 12, 0)},
+	}
+	for _, c := range cases {
+		got := parseViewingTimes(c.text, now)
+		if len(got) != 1 || !got[0].Start.Equal(c.start) || !got[0].End.Equal(c.end) {
+			t.Errorf("%q = %+v, want %v to %v", c.text, got, c.start, c.end)
+		}
+	}
+
+	// a date earlier in the year is next year's
+	got := parseViewingTimes("Viewing 5th January 10-10.30am", now)
+	if len(got) != 1 || got[0].Start.Year() != 2027 {
+		t.Errorf("January viewing = %+v", got)
+	}
+
+	for _, text := range []string{
+		"Viewings strictly by appointment.",
+		"Viewing of this 2 bed apartment is recommended",
+		"Available from 1st November, 2pm check-in",
+	} {
+		if got := parseViewingTimes(text, now); len(got) != 0 {
+			t.Errorf("%q should have no viewings, got %+v", text, got)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Endpoints de administração ──────────────────────────────────── */
+
+// requireAdmin confere o token de ADMIN_TOKEN ("Authorization: Bearer" ou "X-Admin-Token")
+// e escreve o erro quando ele falta; sem ADMIN_TOKEN os endpoints ficam desligados
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	if adminToken == "" {
+		writeError(w, http.StatusForbidden, "Admin endpoints are disabled: ADMIN_TOKEN is not set")
+		return false
+	}
+
+	token := r.Header.Get("X-Admin-Token")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+		writeError(w, http.StatusUnauthorized, "Valid admin token required")
+		return false
+	}
+	return true
+}
+
+/* ───── GET/DELETE /admin/cache ─────────────────────────────────────── */
+
+// Os caches do servidor são as análises guardadas (servidas enquanto estão dentro de
+// ANALYSIS_CACHE_TTL) e os rankings de /areas/rank. GET mostra o tamanho e a taxa de
+// acerto de cada um desde o start; DELETE descarta entradas ruins sem reiniciar.
+
+// cacheCounter conta os acertos e as faltas de um cache (atômicos)
+type cacheCounter struct {
+	hits, misses int64
+}
+
+// cacheCounters tem um contador por cache; o mapa em si nunca muda
+var cacheCounters = map[string]*cacheCounter{
+	"analyses":  {},
+	"area-rank": {},
+}
+
+// countCache registra uma consulta ao cache name
+func countCache(name string, hit bool) {
+	if hit {
+		atomic.AddInt64(&cacheCounters[name].hits, 1)
+	} else {
+		atomic.AddInt64(&cacheCounters[name].misses, 1)
+	}
+}
+
+// CacheReport é um item de GET /admin/cache
+type CacheReport struct {
+	Name    string  `json:"name"`
+	Entries int     `json:"entries"`
+	Fresh   int     `json:"fresh"` // entradas que ainda seriam servidas
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hitRate"` // 0-1; 0 sem consultas
+}
+
+// CachePurgeResult é a resposta de DELETE /admin/cache: entradas descartadas por cache
+type CachePurgeResult struct {
+	Purged map[string]int `json:"purged"`
+}
+
+// handleAdminCache é o handler de /admin/cache
+func handleAdminCache(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(cacheReports(time.Now()))
+	case http.MethodDelete:
+		q := r.URL.Query()
+		name, key, prefix := q.Get("cache"), q.Get("key"), q.Get("prefix")
+		if name != "" && cacheCounters[name] == nil {
+			writeError(w, http.StatusBadRequest, "cache must be analyses or area-rank")
+			return
+		}
+		if key == "" && prefix == "" {
+			writeError(w, http.StatusBadRequest, "key or prefix is required")
+			return
+		}
+		result, err := purgeCaches(name, key, prefix)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Could not purge the cache")
+			return
+		}
+		slog.Info("Purged cache entries", "cache", name, "key", key, "prefix", prefix, "purged", result.Purged)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Only GET and DELETE methods are allowed")
+	}
+}
+
+// cacheReports mede os caches agora
+func cacheReports(now time.Time) []CacheReport {
+	analyses := CacheReport{Name: "analyses"}
+	ttl := analysisCacheTTL()
+	store.View(func(d *storeData) {
+		for _, a := range d.Analyses {
+			analyses.Entries++
+			if !a.Expired && now.Sub(a.AnalyzedAt) < ttl {
+				analyses.Fresh++
+			}
+		}
+	})
+
+	areas := CacheReport{Name: "area-rank"}
+	areaRankMu.Lock()
+	for _, ranking := range areaRankCache {
+		areas.Entries++
+		if now.Sub(ranking.ComputedAt) <= areaRankTTL() {
+			areas.Fresh++
+		}
+	}
+	areaRankMu.Unlock()
+
+	reports := []CacheReport{analyses, areas}
+	for i := range reports {
+		c := cacheCounters[reports[i].Name]
+		reports[i].Hits, reports[i].Misses = atomic.LoadInt64(&c.hits), atomic.LoadInt64(&c.misses)
+		if total := reports[i].Hits + reports[i].Misses; total > 0 {
+			reports[i].HitRate = float64(reports[i].Hits) / float64(total)
+		}
+	}
+	return reports
+}
+
+// purgeCaches descarta as entradas de name ("" = todos os caches) com a chave key
+// ou que começam com prefix. Nas análises a chave é o ID ou a URL do anúncio e o
+// prefixo vale para a URL; elas só expiram, para não perder acompanhamento e
+// histórico. No ranking a chave é o condado.
+func purgeCaches(name, key, prefix string) (CachePurgeResult, error) {
+	matches := func(k string) bool {
+		return key != "" && k == key || prefix != "" && strings.HasPrefix(k, prefix)
+	}
+	result := CachePurgeResult{Purged: map[string]int{}}
+
+	if name == "" || name == "analyses" {
+		err := store.Update(func(d *storeData) error {
+			for id, a := range d.Analyses {
+				if !a.Expired && (key == id || matches(a.URL)) {
+					a.Expired = true
+					result.Purged["analyses"]++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if name == "" || name == "area-rank" {
+		areaRankMu.Lock()
+		for county := range areaRankCache {
+			if matches(county) {
+				delete(areaRankCache, county)
+				result.Purged["area-rank"]++
+			}
+		}
+		areaRankMu.Unlock()
+	}
+	return result, nil
+}
+
+/* ───── GET /admin/upstreams ────────────────────────────────────────── */
+
+// handleAdminUpstreams lista as fontes chamadas desde o start com o estado do circuito
+func handleAdminUpstreams(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scraper.UpstreamStatuses())
+}
+
+// handleMapsBudget devolve o gasto estimado do dia com o Maps
+func handleMapsBudget(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(analyzerFor(r.Context()).Spend().Report(time.Now()))
+}
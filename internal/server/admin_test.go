@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+)
+
+// adminCall sends an admin request with the test token to handler
+func adminCall(t *testing.T, handler http.HandlerFunc, method, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("X-Admin-Token", "s3cret")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestAdminCacheReportsAndPurges(t *testing.T) {
+	useJobStore(t)
+	t.Setenv("ADMIN_TOKEN", "s3cret")
+	url := "https://www.daft.ie/for-rent/apartment/1"
+	recordAnalysis(context.Background(), &scraper.PropertyInfo{URL: url, Address: "1 Main St"})
+	recordAnalysis(context.Background(), &scraper.PropertyInfo{URL: "https://www.daft.ie/for-sale/house/2", Address: "2 Main St"})
+	store.Update(func(d *storeData) error {
+		d.Analyses[analysisKey(url)].Tracking = &Tracking{Status: "shortlisted"}
+		return nil
+	})
+	areaRankMu.Lock()
+	prevRanks := areaRankCache
+	areaRankCache = map[string]*areaRanking{"dublin": {County: "dublin", ComputedAt: time.Now()}}
+	areaRankMu.Unlock()
+	t.Cleanup(func() {
+		areaRankMu.Lock()
+		areaRankCache = prevRanks
+		areaRankMu.Unlock()
+	})
+
+	rec := adminCall(t, handleAdminCache, http.MethodGet, "/admin/cache")
+	var reports []CacheReport
+	json.NewDecoder(rec.Body).Decode(&reports)
+	if len(reports) != 2 || reports[0].Name != "analyses" || reports[0].Entries != 2 || reports[0].Fresh != 2 ||
+		reports[1].Entries != 1 {
+		t.Fatalf("reports = %+v", reports)
+	}
+
+	if rec := adminCall(t, handleAdminCache, http.MethodDelete, "/admin/cache"); rec.Code != http.StatusBadRequest {
+		t.Errorf("purge without key or prefix: status %d, want 400", rec.Code)
+	}
+	if rec := adminCall(t, handleAdminCache, http.MethodDelete, "/admin/cache?cache=bogus&key=x"); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown cache: status %d, want 400", rec.Code)
+	}
+
+	rec = adminCall(t, handleAdminCache, http.MethodDelete, "/admin/cache?prefix=https://www.daft.ie/for-rent/")
+	var result CachePurgeResult
+	json.NewDecoder(rec.Body).Decode(&result)
+	if result.Purged["analyses"] != 1 || result.Purged["area-rank"] != 0 {
+		t.Errorf("purged %+v", result.Purged)
+	}
+	if _, _, ok := cachedAnalysis(context.Background(), url, time.Hour); ok {
+		t.Error("a purged analysis was served from the cache")
+	}
+	store.View(func(d *storeData) {
+		if a := d.Analyses[analysisKey(url)]; a == nil || a.Tracking == nil {
+			t.Error("purging the cache dropped the tracking of the analysis")
+		}
+	})
+
+	// the next analysis of the listing puts it back in the cache
+	recordAnalysis(context.Background(), &scraper.PropertyInfo{URL: url, Address: "1 Main St"})
+	if _, _, ok := cachedAnalysis(context.Background(), url, time.Hour); !ok {
+		t.Error("a fresh analysis should be cached again")
+	}
+
+	rec = adminCall(t, handleAdminCache, http.MethodDelete, "/admin/cache?cache=area-rank&key=dublin")
+	result = CachePurgeResult{}
+	json.NewDecoder(rec.Body).Decode(&result)
+	if result.Purged["area-rank"] != 1 || result.Purged["analyses"] != 0 {
+		t.Errorf("area-rank purge = %+v", result.Purged)
+	}
+}
+
+func TestCacheHitRate(t *testing.T) {
+	useJobStore(t)
+	prev := *cacheCounters["analyses"]
+	t.Cleanup(func() { *cacheCounters["analyses"] = prev })
+	*cacheCounters["analyses"] = cacheCounter{}
+
+	countCache("analyses", true)
+	countCache("analyses", true)
+	countCache("analyses", false)
+	countCache("analyses", true)
+	if got := cacheReports(time.Now())[0]; got.Hits != 3 || got.Misses != 1 || got.HitRate != 0.75 {
+		t.Errorf("report = %+v", got)
+	}
+}
+
+func TestAdminUpstreams(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "s3cret")
+	settings := scraper.CircuitSettings{Failures: 1, Cooldown: 30 * time.Second}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: scraper.CircuitTransport{Base: http.DefaultTransport, Settings: settings}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	rec := httptest.NewRecorder()
+	handleAdminUpstreams(rec, httptest.NewRequest(http.MethodGet, "/admin/upstreams", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("without a token: status %d, want 401", rec.Code)
+	}
+
+	rec = adminCall(t, handleAdminUpstreams, http.MethodGet, "/admin/upstreams")
+	var statuses []scraper.UpstreamStatus
+	json.NewDecoder(rec.Body).Decode(&statuses)
+	name := scraper.UpstreamName(strings.TrimPrefix(srv.URL, "http://"))
+	for _, s := range statuses {
+		if s.Name == name {
+			if s.State != "open" {
+				t.Errorf("failing upstream = %+v", s)
+			}
+			return
+		}
+	}
+	t.Fatalf("%s missing from %+v", name, statuses)
+}
+
+func TestHandleMapsBudget(t *testing.T) {
+	b := &enrich.MapsBudget{}
+	ctx := enrich.WithAnalyzer(context.Background(), &enrich.Analyzer{Budget: b})
+
+	rec := httptest.NewRecorder()
+	handleMapsBudget(rec, httptest.NewRequest(http.MethodGet, "/admin/maps-budget", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("without ADMIN_TOKEN: status %d, want 403", rec.Code)
+	}
+
+	t.Setenv("ADMIN_TOKEN", "s3cret")
+	rec = httptest.NewRecorder()
+	handleMapsBudget(rec, httptest.NewRequest(http.MethodGet, "/admin/maps-budget", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("without a token: status %d, want 401", rec.Code)
+	}
+
+	b.Reserve("geocode", time.Now())
+	req := httptest.NewRequest(http.MethodGet, "/admin/maps-budget", nil).WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec = httptest.NewRecorder()
+	handleMapsBudget(rec, req)
+	var report enrich.MapsBudgetReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Calls["geocode"] != 1 || report.Spend != enrich.MapsSKUPrices["geocode"] || report.Mode != "degrade" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── GET /analyses/advertisers ───────────────────────────────────── */
+
+// AdvertiserGroup são as análises guardadas de um mesmo anunciante
+type AdvertiserGroup struct {
+	Advertiser scraper.Advertiser `json:"advertiser"`
+	Analyses   []TrackedAnalysis  `json:"analyses"`
+}
+
+// advertiserGroups agrupa as análises guardadas do tenant por anunciante, os que têm
+// mais anúncios primeiro
+func advertiserGroups(ctx context.Context) []AdvertiserGroup {
+	byKey := map[string]*AdvertiserGroup{}
+	store.View(func(d *storeData) {
+		for id, a := range d.Analyses {
+			adv := a.Property.Advertiser
+			if !a.visibleTo(ctx) || adv == nil || adv.Key == "" {
+				continue
+			}
+			g, ok := byKey[adv.Key]
+			if !ok {
+				g = &AdvertiserGroup{Advertiser: *adv}
+				byKey[adv.Key] = g
+			}
+			g.Analyses = append(g.Analyses, TrackedAnalysis{
+				ID:         id,
+				URL:        a.URL,
+				Address:    a.Property.Address,
+				Price:      a.Property.RentPrice,
+				Tracking:   a.Tracking,
+				AnalyzedAt: a.AnalyzedAt,
+			})
+		}
+	})
+
+	groups := []AdvertiserGroup{}
+	for _, g := range byKey {
+		sort.Slice(g.Analyses, func(i, j int) bool { return g.Analyses[i].AnalyzedAt.After(g.Analyses[j].AnalyzedAt) })
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].Analyses) != len(groups[j].Analyses) {
+			return len(groups[i].Analyses) > len(groups[j].Analyses)
+		}
+		return groups[i].Advertiser.Key < groups[j].Advertiser.Key
+	})
+	return groups
+}
+
+// handleAdvertisers é o handler HTTP para GET /analyses/advertisers
+func handleAdvertisers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(advertiserGroups(r.Context()))
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestAdvertiserGroups(t *testing.T) {
+	store = newMemoryStore()
+	t.Cleanup(func() { store = newMemoryStore() })
+	now := time.Now()
+	agency := &scraper.Advertiser{Name: "Hooke & MacDonald", Type: "agent", Key: "agent:hooke-macdonald"}
+	store.Update(func(d *storeData) error {
+		d.Analyses["a"] = &StoredAnalysis{ID: "a", URL: "https://www.daft.ie/for-rent/a/1", AnalyzedAt: now, Property: scraper.PropertyInfo{Advertiser: agency}}
+		d.Analyses["b"] = &StoredAnalysis{ID: "b", URL: "https://www.daft.ie/for-rent/b/2", AnalyzedAt: now.Add(time.Hour), Property: scraper.PropertyInfo{Advertiser: agency}}
+		d.Analyses["c"] = &StoredAnalysis{ID: "c", URL: "https://www.daft.ie/for-rent/c/3", AnalyzedAt: now, Property: scraper.PropertyInfo{Advertiser: &scraper.Advertiser{Phone: "087 123 4567", Key: "phone:871234567"}}}
+		d.Analyses["d"] = &StoredAnalysis{ID: "d", URL: "https://www.daft.ie/for-rent/d/4", AnalyzedAt: now}
+		return nil
+	})
+
+	groups := advertiserGroups(context.Background())
+	if len(groups) != 2 || groups[0].Advertiser.Key != "agent:hooke-macdonald" || len(groups[0].Analyses) != 2 {
+		t.Fatalf("groups = %+v", groups)
+	}
+	if groups[0].Analyses[0].ID != "b" {
+		t.Errorf("most recent analysis should come first, got %s", groups[0].Analyses[0].ID)
+	}
+}
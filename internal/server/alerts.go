@@ -0,0 +1,253 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Alert é um aviso gerado pelo monitoramento de anúncios (watchlist, buscas salvas)
+type Alert struct {
+	Kind     string    `json:"kind"` // price_change, listing_removed, new_listing
+	URL      string    `json:"url"`
+	Address  string    `json:"address,omitempty"`
+	Message  string    `json:"message"`
+	OldPrice float64   `json:"oldPrice,omitempty"`
+	NewPrice float64   `json:"newPrice,omitempty"`
+	Briefing string    `json:"briefing,omitempty"` // new_listing: texto para assistentes de voz
+	Time     time.Time `json:"time"`
+}
+
+// NotifyTarget é o destino escolhido por quem criou o watch
+type NotifyTarget struct {
+	Webhook  string `json:"webhook,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Telegram string `json:"telegram,omitempty"` // chat_id; requer TELEGRAM_BOT_TOKEN
+}
+
+// alertPublishers recebem todos os alertas, além do destino escolhido em cada watch.
+// São registrados no startup, mas o mutex permite registrar depois com alertas em voo.
+var (
+	alertPublishersMu sync.RWMutex
+	alertPublishers   []func(Alert) error
+)
+
+// addAlertPublisher registra um publicador global
+func addAlertPublisher(publish func(Alert) error) {
+	alertPublishersMu.Lock()
+	defer alertPublishersMu.Unlock()
+	alertPublishers = append(alertPublishers, publish)
+}
+
+// setupAlertPublishers registra os publicadores configurados por variáveis de ambiente
+func setupAlertPublishers() {
+	if broker := os.Getenv("MQTT_BROKER"); broker != "" {
+		pub := newMQTTPublisherFromEnv(broker)
+		addAlertPublisher(pub.Publish)
+		slog.Info("Publishing alerts over MQTT", "broker", broker, "topic", pub.Topic)
+	}
+}
+
+// deliverAlert envia o alerta ao destino do watch e aos publicadores globais
+func deliverAlert(alert Alert, target NotifyTarget) {
+	if alert.Time.IsZero() {
+		alert.Time = time.Now()
+	}
+	slog.Info("Alert", "kind", alert.Kind, "url", alert.URL, "message", alert.Message)
+
+	if target.Webhook != "" {
+		if err := sendWebhook(target.Webhook, alert); err != nil {
+			slog.Warn("Could not send the alert webhook", "url", alert.URL, "error", err)
+		}
+	}
+	if target.Email != "" {
+		if err := sendEmail(target.Email, alert); err != nil {
+			slog.Warn("Could not send the alert email", "url", alert.URL, "error", err)
+		}
+	}
+	if target.Telegram != "" {
+		if err := sendTelegram(target.Telegram, alert.Message+"\n"+alert.URL); err != nil {
+			slog.Warn("Could not send the alert to Telegram", "url", alert.URL, "error", err)
+		}
+	}
+	publishAlert(alert)
+}
+
+// publishAlert envia o alerta para todos os publicadores registrados
+func publishAlert(alert Alert) {
+	if alert.Time.IsZero() {
+		alert.Time = time.Now()
+	}
+	alertPublishersMu.RLock()
+	publishers := alertPublishers
+	alertPublishersMu.RUnlock()
+	for _, publish := range publishers {
+		if err := publish(alert); err != nil {
+			slog.Warn("Could not publish the alert", "url", alert.URL, "error", err)
+		}
+	}
+}
+
+// sendWebhook faz POST do alerta em JSON na URL informada
+func sendWebhook(webhookURL string, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("error encoding alert: %w", err)
+	}
+
+	resp, err := webhookClient.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+/* ───── Webhooks sem SSRF ───────────────────────────────────────────── */
+
+// O webhook é uma URL de quem criou o watch ou a busca, chamada pelo servidor: sem
+// estas checagens bastaria apontá-la para localhost, para a rede interna ou para o
+// endpoint de metadados da nuvem (169.254.169.254).
+
+// errInternalAddress recusa a conexão de um webhook com um endereço interno
+var errInternalAddress = errors.New("webhook points to an internal address")
+
+// webhookClient confere o endereço no momento da conexão, depois da resolução do
+// DNS, para que um host que passou em validateWebhook e depois mudou de IP (ou um
+// redirect) não alcance a rede interna
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+					return fmt.Errorf("%w: %s", errInternalAddress, host)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+// validateWebhook exige https e um host que resolva só para endereços públicos;
+// chamada ao criar watches e buscas salvas. Um webhook vazio é válido.
+func validateWebhook(ctx context.Context, webhookURL string) error {
+	if webhookURL == "" {
+		return nil
+	}
+	u, err := url.Parse(webhookURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return errors.New("notify.webhook must be an https URL")
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("notify.webhook host could not be resolved: %w", err)
+	}
+	for _, ip := range ips {
+		if !publicIP(ip.IP) {
+			return fmt.Errorf("notify.webhook must not point to an internal address (%s)", ip.IP)
+		}
+	}
+	return nil
+}
+
+// publicIP informa se ip é roteável na internet: não é loopback, link-local, de rede
+// privada, CGNAT, multicast nem não especificado
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || ip.IsPrivate() || ip.IsUnspecified() {
+		return false
+	}
+	return !sharedAddressSpace.Contains(ip)
+}
+
+// sharedAddressSpace é a faixa do CGNAT (RFC 6598), interna em muitas nuvens
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// sendEmail envia o alerta via SMTP (SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM)
+func sendEmail(to string, alert Alert) error {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return fmt.Errorf("SMTP_HOST not set")
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = os.Getenv("SMTP_USERNAME")
+	}
+
+	var auth smtp.Auth
+	if user := os.Getenv("SMTP_USERNAME"); user != "" {
+		auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+	}
+
+	subject := "Daft.ie alert: " + strings.ReplaceAll(alert.Kind, "_", " ")
+	msg := "From: " + from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n\r\n" +
+		alert.Message + "\r\n\r\n" + alert.URL + "\r\n"
+
+	return smtp.SendMail(host+":"+port, auth, from, []string{to}, []byte(msg))
+}
+
+// sendTelegram envia uma mensagem de texto pelo bot configurado em TELEGRAM_BOT_TOKEN
+func sendTelegram(chatID, text string) error {
+	return telegramSendMessage(chatID, text, "")
+}
+
+// sendTelegramHTML envia uma mensagem formatada (parse_mode HTML)
+func sendTelegramHTML(chatID, text string) error {
+	return telegramSendMessage(chatID, text, "HTML")
+}
+
+func telegramSendMessage(chatID, text, parseMode string) error {
+	token := os.Getenv("TELEGRAM_BOT_TOKEN")
+	if token == "" {
+		return fmt.Errorf("TELEGRAM_BOT_TOKEN not set")
+	}
+
+	form := url.Values{"chat_id": {chatID}, "text": {text}}
+	if parseMode != "" {
+		form.Set("parse_mode", parseMode)
+		form.Set("disable_web_page_preview", "true")
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.PostForm("https://api.telegram.org/bot"+token+"/sendMessage", form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram API returned status code: %d", resp.StatusCode)
+	}
+	return nil
+}
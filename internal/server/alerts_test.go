@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateWebhook(t *testing.T) {
+	for _, raw := range []string{
+		"http://hooks.example.com/alert",            // not https
+		"https://127.0.0.1/alert",                   // loopback
+		"https://localhost:8443/alert",              // resolves to loopback
+		"https://169.254.169.254/latest/meta-data/", // cloud metadata
+		"https://10.0.0.5/alert",                    // private
+		"https://192.168.1.1/alert",
+		"https://100.64.0.1/alert", // CGNAT
+		"https://[::1]/alert",
+		"https://[fd00::1]/alert",
+		"https:///alert",
+		"ftp://8.8.8.8/alert",
+	} {
+		if err := validateWebhook(context.Background(), raw); err == nil {
+			t.Errorf("%s should be rejected", raw)
+		}
+	}
+	for _, raw := range []string{"", "https://8.8.8.8/alert", "https://[2001:4860:4860::8888]/alert"} {
+		if err := validateWebhook(context.Background(), raw); err != nil {
+			t.Errorf("%s should be accepted, got %v", raw, err)
+		}
+	}
+}
+
+func TestSendWebhookRefusesInternalAddressesAtDialTime(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	defer srv.Close()
+
+	// a watch saved before validation existed, or a host that now resolves inside
+	err := sendWebhook(srv.URL, Alert{Kind: "price_change"})
+	if !errors.Is(err, errInternalAddress) || called {
+		t.Errorf("webhook to %s: err %v, called %v", srv.URL, err, called)
+	}
+}
+
+func TestHandleWatchRejectsInternalWebhooks(t *testing.T) {
+	prev := store
+	store = newMemoryStore()
+	t.Cleanup(func() { store = prev })
+	srv := listingServer(t, "€1,800")
+
+	body := `{"url":"` + srv.URL + `/for-rent/x/123","notify":{"webhook":"https://169.254.169.254/latest"}}`
+	rec := httptest.NewRecorder()
+	handleWatch(rec, httptest.NewRequest(http.MethodPost, "/watch", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "internal address") {
+		t.Errorf("status %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/safety"
+	"daft-scraper-api/internal/scraper"
+)
+
+// StoredAnalysis é a última análise de um anúncio, usada para detectar mudanças.
+// Fica em storeData.Analyses como ponteiro e só muda dentro de store.Update: o
+// rescrape troca Property inteira, mas os históricos crescem por append, então
+// copie-os antes de usá-los fora do lock (como faz handleAnalysisHistory).
+type StoredAnalysis struct {
+	ID              string               `json:"id"`
+	URL             string               `json:"url"`
+	Property        scraper.PropertyInfo `json:"property"`
+	FirstAnalyzedAt time.Time            `json:"firstAnalyzedAt"`
+	AnalyzedAt      time.Time            `json:"analyzedAt"`
+	Tracking        *Tracking            `json:"tracking,omitempty"` // status, notas e visita, editados pelo usuário
+
+	// SafetyInfo é a segurança calculada por /analyze para este snapshot; cada novo
+	// scraping a descarta, e GET /analyze a serve junto com o anúncio em cache
+	SafetyInfo *safety.SafetyAnalysis `json:"safetyInfo,omitempty"`
+
+	// Monitor de disponibilidade: última checagem e, se o anúncio saiu do ar, quando e por quê
+	CheckedAt time.Time  `json:"checkedAt,omitempty"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+	EndReason string     `json:"endReason,omitempty"` // removed, let_agreed ou sale_agreed
+
+	Tenant string `json:"tenant,omitempty"` // dono da análise; "" é o tenant padrão
+
+	// Expired tira a análise do cache (DELETE /admin/cache) sem perder o acompanhamento
+	// e o histórico; a próxima análise do anúncio a substitui
+	Expired bool `json:"expired,omitempty"`
+
+	// Histórico de preço e notas, um ponto por dia em que o anúncio foi analisado
+	PriceHistory []scraper.PricePoint `json:"priceHistory,omitempty"`
+	ScoreHistory []ScorePoint         `json:"scoreHistory,omitempty"`
+}
+
+// analysisKey deriva a chave de armazenamento a partir da URL canônica do anúncio
+func analysisKey(listingURL string) string {
+	sum := sha1.Sum([]byte(scraper.CanonicalListingURL(listingURL)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// handleAnalysisRoutes atende as sub-rotas de /analyses/{id}/...
+func handleAnalysisRoutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/analyses/"), "/"), "/")
+	if len(parts) != 2 {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	switch parts[1] {
+	case "ask":
+		handleAsk(w, r, parts[0])
+	case "tracking":
+		handleTracking(w, r, parts[0])
+	case "history":
+		handleAnalysisHistory(w, r, parts[0])
+	case "viewings.ics":
+		handleViewingsICS(w, r, parts[0])
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// recordAnalysis compara a análise com o snapshot anterior do mesmo anúncio,
+// preenche property.Changes e guarda a análise atual como novo snapshot, em nome do
+// tenant da requisição
+func recordAnalysis(ctx context.Context, property *scraper.PropertyInfo) {
+	if property.Address == "" {
+		return // scraping falhou; não sobrescreve um snapshot bom
+	}
+
+	tenant := tenantID(ctx)
+	key := analysisKeyFor(tenant, property.URL)
+	overall, now := analyzerFor(ctx).OverallScore(property), time.Now()
+	err := store.Update(func(d *storeData) error {
+		prev, ok := d.Analyses[key]
+		if !ok {
+			stored := &StoredAnalysis{ID: key, URL: property.URL, Property: *property, FirstAnalyzedAt: now, AnalyzedAt: now, Tenant: tenant}
+			appendHistory(stored, property, overall, now)
+			d.Analyses[key] = stored
+			return nil
+		}
+
+		property.Changes = diffListing(prev.Property, *property)
+		prev.Property = *property
+		prev.AnalyzedAt = now
+		prev.Expired = false
+		prev.SafetyInfo = nil
+		appendHistory(prev, property, overall, now)
+		return nil
+	})
+	if err != nil {
+		slog.Warn("Could not save the analysis", "url", property.URL, "error", err)
+	}
+}
+
+// diffListing lista as diferenças relevantes entre dois snapshots do mesmo anúncio
+func diffListing(old, cur scraper.PropertyInfo) []scraper.ListingChange {
+	var changes []scraper.ListingChange
+
+	if old.RentPrice != cur.RentPrice {
+		change := scraper.ListingChange{Field: "price", Old: old.RentPrice, New: cur.RentPrice}
+		oldPrice, newPrice := scraper.ExtractPriceValue(old.RentPrice), scraper.ExtractPriceValue(cur.RentPrice)
+		if oldPrice > 0 && newPrice > 0 {
+			change.Note = fmt.Sprintf("%+.1f%%", (newPrice-oldPrice)/oldPrice*100)
+		}
+		changes = append(changes, change)
+	}
+
+	if old.Description != cur.Description {
+		changes = append(changes, scraper.ListingChange{
+			Field: "description",
+			Old:   fmt.Sprintf("%d chars", len(old.Description)),
+			New:   fmt.Sprintf("%d chars", len(cur.Description)),
+			Note:  "description edited",
+		})
+	}
+
+	for _, f := range []struct{ name, old, cur string }{
+		{"address", old.Address, cur.Address},
+		{"bedrooms", old.Bedrooms, cur.Bedrooms},
+		{"bathrooms", old.Bathrooms, cur.Bathrooms},
+		{"propertyType", old.PropertyType, cur.PropertyType},
+	} {
+		if f.old != f.cur {
+			changes = append(changes, scraper.ListingChange{Field: f.name, Old: f.old, New: f.cur})
+		}
+	}
+
+	if added, removed := diffPhotos(old.Photos, cur.Photos); added > 0 || removed > 0 {
+		changes = append(changes, scraper.ListingChange{
+			Field: "photos",
+			Old:   fmt.Sprintf("%d photos", len(old.Photos)),
+			New:   fmt.Sprintf("%d photos", len(cur.Photos)),
+			Note:  fmt.Sprintf("%d added, %d removed", added, removed),
+		})
+	}
+
+	if o, c := availableFromText(old.RentalTerms), availableFromText(cur.RentalTerms); o != c {
+		changes = append(changes, scraper.ListingChange{Field: "availableFrom", Old: o, New: c})
+	}
+
+	return changes
+}
+
+// diffPhotos conta as fotos que entraram e saíram entre dois snapshots
+func diffPhotos(old, cur []string) (added, removed int) {
+	before := make(map[string]bool, len(old))
+	for _, p := range old {
+		before[p] = true
+	}
+	after := make(map[string]bool, len(cur))
+	for _, p := range cur {
+		after[p] = true
+		if !before[p] {
+			added++
+		}
+	}
+	for p := range before {
+		if !after[p] {
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// availableFromText é a data de entrada como aparece na mudança: "immediately", a
+// data (2006-01-02) ou "" quando o anúncio não diz
+func availableFromText(t *scraper.RentalTerms) string {
+	switch {
+	case t == nil:
+		return ""
+	case t.AvailableNow:
+		return "immediately"
+	case t.AvailableFrom != nil:
+		return t.AvailableFrom.Format("2006-01-02")
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestDiffListing(t *testing.T) {
+	nov := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	dec := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	base := scraper.PropertyInfo{
+		Address: "1 Main Street", RentPrice: "€2,000", Bedrooms: "2 bed", Description: "Bright flat",
+		Photos:      []string{"a.jpg", "b.jpg"},
+		RentalTerms: &scraper.RentalTerms{AvailableFrom: &nov},
+	}
+
+	cases := []struct {
+		name   string
+		change func(p *scraper.PropertyInfo)
+		want   []scraper.ListingChange
+	}{
+		{"unchanged", func(p *scraper.PropertyInfo) {}, nil},
+		{"price cut", func(p *scraper.PropertyInfo) { p.RentPrice = "€1,800" },
+			[]scraper.ListingChange{{Field: "price", Old: "€2,000", New: "€1,800", Note: "-10.0%"}}},
+		{"description", func(p *scraper.PropertyInfo) { p.Description = "Bright flat, newly painted" },
+			[]scraper.ListingChange{{Field: "description", Old: "11 chars", New: "26 chars", Note: "description edited"}}},
+		{"bedrooms", func(p *scraper.PropertyInfo) { p.Bedrooms = "3 bed" },
+			[]scraper.ListingChange{{Field: "bedrooms", Old: "2 bed", New: "3 bed"}}},
+		{"photo swapped", func(p *scraper.PropertyInfo) { p.Photos = []string{"a.jpg", "c.jpg", "d.jpg"} },
+			[]scraper.ListingChange{{Field: "photos", Old: "2 photos", New: "3 photos", Note: "2 added, 1 removed"}}},
+		{"photos reordered", func(p *scraper.PropertyInfo) { p.Photos = []string{"b.jpg", "a.jpg"} }, nil},
+		{"available later", func(p *scraper.PropertyInfo) { p.RentalTerms = &scraper.RentalTerms{AvailableFrom: &dec} },
+			[]scraper.ListingChange{{Field: "availableFrom", Old: "2026-11-01", New: "2026-12-01"}}},
+		{"available now", func(p *scraper.PropertyInfo) { p.RentalTerms = &scraper.RentalTerms{AvailableNow: true} },
+			[]scraper.ListingChange{{Field: "availableFrom", Old: "2026-11-01", New: "immediately"}}},
+		{"date dropped", func(p *scraper.PropertyInfo) { p.RentalTerms = nil },
+			[]scraper.ListingChange{{Field: "availableFrom", Old: "2026-11-01", New: ""}}},
+	}
+	for _, tc := range cases {
+		cur := base
+		tc.change(&cur)
+		got := diffListing(base, cur)
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: change %d = %+v, want %+v", tc.name, i, got[i], tc.want[i])
+			}
+		}
+	}
+}
+
+func TestAnalysisKeyUsesCanonicalURL(t *testing.T) {
+	a := analysisKey("https://www.daft.ie/for-rent/apartment-1-main-street/123")
+	if b := analysisKey("https://m.daft.ie/for-rent/apartment-1-main-street/123/?utm_source=share"); a != b {
+		t.Errorf("same listing under two URLs got two keys: %s, %s", a, b)
+	}
+	if a, b := analysisKeyFor("acme", "https://daft.ie/for-rent/x/123"), analysisKeyFor("acme", "https://www.daft.ie/for-rent/x/123#map"); a != b {
+		t.Errorf("tenant keys differ: %s, %s", a, b)
+	}
+}
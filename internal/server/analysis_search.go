@@ -0,0 +1,217 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"daft-scraper-api/internal/env"
+	"daft-scraper-api/internal/scraper"
+
+	_ "modernc.org/sqlite" // driver "sqlite", sem cgo
+)
+
+/* ───── Busca textual nas análises guardadas (SQLite FTS5) ──────────── */
+
+// As análises guardadas são indexadas numa tabela FTS5 do SQLite, que faz o ranking
+// (bm25) e os trechos (snippet). O banco fica em SEARCH_DB, ou em memória se vazio:
+// é só um índice derivado do store, então pode ser apagado e é refeito na próxima busca.
+
+// AnalysisHit é um resultado da busca nas análises guardadas
+type AnalysisHit struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	Address    string    `json:"address"`
+	Price      string    `json:"price"`
+	Score      float64   `json:"score"`
+	Snippet    string    `json:"snippet"`
+	AnalyzedAt time.Time `json:"analyzedAt"`
+}
+
+// analysisIndex é sincronizado com o store a cada busca: só reindexa o que mudou
+type analysisIndex struct {
+	mu sync.Mutex
+	db *sql.DB
+}
+
+// searchIndex é o índice do servidor, aberto em setup()
+var searchIndex *analysisIndex
+
+// openAnalysisIndex abre (ou cria) o índice em path; ":memory:" fica só na memória
+func openAnalysisIndex(path string) (*analysisIndex, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// uma conexão só: cada conexão a ":memory:" seria um banco diferente
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS analyses USING fts5(
+		id UNINDEXED, tenant UNINDEXED, analyzed_at UNINDEXED, url UNINDEXED, price UNINDEXED,
+		address, property_type, description,
+		tokenize = 'unicode61 remove_diacritics 2')`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create the search index: %w", err)
+	}
+	return &analysisIndex{db: db}, nil
+}
+
+func openAnalysisIndexFromEnv() (*analysisIndex, error) {
+	return openAnalysisIndex(env.Or("SEARCH_DB", ":memory:"))
+}
+
+func (idx *analysisIndex) sync() error {
+	current := map[string]StoredAnalysis{}
+	store.View(func(d *storeData) {
+		for id, a := range d.Analyses {
+			current[id] = *a
+		}
+	})
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	indexed := map[string]int64{}
+	rows, err := idx.db.Query(`SELECT id, analyzed_at FROM analyses`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var (
+			id string
+			at int64
+		)
+		if err := rows.Scan(&id, &at); err != nil {
+			rows.Close()
+			return err
+		}
+		indexed[id] = at
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tx, err := idx.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for id := range indexed {
+		if _, ok := current[id]; !ok {
+			if err := removeDoc(tx, id); err != nil {
+				return err
+			}
+		}
+	}
+	for id, a := range current {
+		if at, ok := indexed[id]; ok && at == a.AnalyzedAt.UnixNano() {
+			continue
+		}
+		if err := removeDoc(tx, id); err != nil {
+			return err
+		}
+		if err := addDoc(tx, id, a); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func addDoc(tx *sql.Tx, id string, a StoredAnalysis) error {
+	p := a.Property
+	_, err := tx.Exec(`INSERT INTO analyses (id, tenant, analyzed_at, url, price, address, property_type, description)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, a.Tenant, a.AnalyzedAt.UnixNano(), a.URL, p.RentPrice, p.Address, p.PropertyType, p.Description)
+	return err
+}
+
+func removeDoc(tx *sql.Tx, id string) error {
+	_, err := tx.Exec(`DELETE FROM analyses WHERE id = ?`, id)
+	return err
+}
+
+// matchQuery traduz a busca do usuário para a sintaxe do FTS5: todos os termos são
+// obrigatórios, e um termo terminado em * casa por prefixo ("refurb*"). Os termos vão
+// entre aspas, então a pontuação digitada não vira operador.
+func matchQuery(query string) string {
+	var terms []string
+	for _, field := range strings.Fields(query) {
+		words := scraper.Tokenize(strings.TrimSuffix(field, "*"))
+		for i, word := range words {
+			term := `"` + word + `"`
+			if i == len(words)-1 && strings.HasSuffix(field, "*") {
+				term += "*"
+			}
+			terms = append(terms, term)
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// search devolve os documentos do tenant que contêm todos os termos, ordenados por
+// bm25 (quanto maior o score, mais relevante)
+func (idx *analysisIndex) search(query, tenant string, limit int) ([]AnalysisHit, error) {
+	match := matchQuery(query)
+	if match == "" {
+		return []AnalysisHit{}, nil
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	rows, err := idx.db.Query(`SELECT id, url, address, price, analyzed_at, -bm25(analyses),
+			snippet(analyses, 7, '', '', '…', 24)
+		FROM analyses WHERE analyses MATCH ? AND tenant = ?
+		ORDER BY bm25(analyses) LIMIT ?`, match, tenant, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []AnalysisHit{}
+	for rows.Next() {
+		var (
+			hit AnalysisHit
+			at  int64
+		)
+		if err := rows.Scan(&hit.ID, &hit.URL, &hit.Address, &hit.Price, &at, &hit.Score, &hit.Snippet); err != nil {
+			return nil, err
+		}
+		hit.AnalyzedAt = time.Unix(0, at)
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// handleAnalysesSearch é o handler HTTP para GET /analyses/search?q=...&limit=20
+func handleAnalysesSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		writeError(w, http.StatusBadRequest, "q query parameter is required")
+		return
+	}
+	limit := 20
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+
+	if err := searchIndex.sync(); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Error indexing analyses: %v", err))
+		return
+	}
+	hits, err := searchIndex.search(query, tenantID(r.Context()), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Error searching analyses: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hits)
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestMatchQuery(t *testing.T) {
+	for query, want := range map[string]string{
+		"south facing balcony": `"south" "facing" "balcony"`,
+		"refurb* Drumcondra":   `"refurb"* "drumcondra"`,
+		`"balcony" OR NEAR(x)`: `"balcony" "or" "near" "x"`,
+		"two-bed":              `"two" "bed"`,
+		"  ***  ":              "",
+	} {
+		if got := matchQuery(query); got != want {
+			t.Errorf("matchQuery(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestAnalysisIndexSearch(t *testing.T) {
+	prevStore, prevIndex := store, searchIndex
+	t.Cleanup(func() { store, searchIndex = prevStore, prevIndex })
+	store = newMemoryStore()
+	idx, err := openAnalysisIndex(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	searchIndex = idx
+
+	now := time.Now()
+	add := func(id, tenant, address, description string) {
+		store.Update(func(d *storeData) error {
+			d.Analyses[id] = &StoredAnalysis{ID: id, URL: "https://www.daft.ie/for-rent/" + id, Tenant: tenant, AnalyzedAt: now,
+				Property: scraper.PropertyInfo{Address: address, Description: description}}
+			return nil
+		})
+	}
+	add("a", "", "12 Church Ave, Drumcondra, Dublin 9", "Bright south facing apartment with a large balcony. Newly refurbished.")
+	add("b", "", "3 Main St, Rathmines, Dublin 6", "South facing garden, balcony off the master bedroom.")
+	add("c", "", "8 Home Farm Rd, Drumcondra, Dublin 9", "Two bed house, refurbished kitchen, north facing garden.")
+	add("d", "acme", "1 Upper Drumcondra Rd, Dublin 9", "South facing balcony.")
+
+	search := func(query string) []AnalysisHit {
+		t.Helper()
+		if err := idx.sync(); err != nil {
+			t.Fatal(err)
+		}
+		hits, err := idx.search(query, "", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hits
+	}
+
+	hits := search("south facing balcony Drumcondra")
+	if len(hits) != 1 || hits[0].ID != "a" {
+		t.Fatalf("expected only listing a, got %+v", hits)
+	}
+	if hits[0].Snippet == "" || hits[0].Score <= 0 || !hits[0].AnalyzedAt.Equal(now) {
+		t.Errorf("incomplete hit %+v", hits[0])
+	}
+	if hits := search("refurb* drumcondra"); len(hits) != 2 {
+		t.Fatalf("expected 2 prefix matches, got %+v", hits)
+	}
+
+	if hits, _ := idx.search("balcony drumcondra", "acme", 10); len(hits) != 1 || hits[0].ID != "d" {
+		t.Fatalf("tenant acme should only see d, got %+v", hits)
+	}
+
+	store.Update(func(d *storeData) error { delete(d.Analyses, "a"); return nil })
+	if hits := search("balcony"); len(hits) != 1 || hits[0].ID != "b" {
+		t.Fatalf("expected only b after removing a, got %+v", hits)
+	}
+
+	rec := httptest.NewRecorder()
+	handleAnalysesSearch(rec, httptest.NewRequest(http.MethodGet, "/analyses/search?q=balcony", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+}
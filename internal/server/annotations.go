@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+// raio padrão de cada tipo de anotação, em metros
+var annotationRadius = map[string]float64{
+	"area":     500,
+	"building": 50,
+}
+
+// handleAnnotations cria (POST), lista (GET ?lat&lng) ou remove (DELETE ?id) anotações
+func handleAnnotations(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticate(r.Header.Get("Authorization"), r.Header.Get("X-API-Key"))
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Valid API token required")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		lat, errLat := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+		lng, errLng := strconv.ParseFloat(r.URL.Query().Get("lng"), 64)
+		if errLat != nil || errLng != nil {
+			writeError(w, http.StatusBadRequest, "lat and lng query parameters are required")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(nearbyAnnotations(lat, lng))
+
+	case http.MethodPost:
+		var a scraper.Annotation
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		a.Kind = strings.ToLower(a.Kind)
+		if a.Kind == "" {
+			a.Kind = "area"
+		}
+		defaultRadius, validKind := annotationRadius[a.Kind]
+		switch {
+		case !validKind:
+			writeError(w, http.StatusBadRequest, "kind must be area or building")
+			return
+		case a.Lat == 0 || a.Lng == 0:
+			writeError(w, http.StatusBadRequest, "lat and lng are required")
+			return
+		case strings.TrimSpace(a.Note) == "":
+			writeError(w, http.StatusBadRequest, "note is required")
+			return
+		case a.Rating < 0 || a.Rating > 5:
+			writeError(w, http.StatusBadRequest, "rating must be between 1 and 5")
+			return
+		}
+		if a.Radius <= 0 {
+			a.Radius = defaultRadius
+		}
+		a.ID = newID()
+		a.Author = user
+		a.CreatedAt = time.Now()
+
+		if err := store.Update(func(d *storeData) error {
+			d.Annotations = append(d.Annotations, a)
+			return nil
+		}); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Error saving annotation: %v", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(a)
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		found := false
+		err := store.Update(func(d *storeData) error {
+			for i, a := range d.Annotations {
+				if a.ID != id {
+					continue
+				}
+				if a.Author != user {
+					return errForbidden
+				}
+				d.Annotations = append(d.Annotations[:i], d.Annotations[i+1:]...)
+				found = true
+				return nil
+			}
+			return nil
+		})
+		switch {
+		case errors.Is(err, errForbidden):
+			writeError(w, http.StatusForbidden, "Only the author can delete an annotation")
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Error deleting annotation: %v", err))
+		case !found:
+			writeError(w, http.StatusNotFound, "Annotation not found")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Only GET, POST and DELETE methods are allowed")
+	}
+}
+
+// errForbidden sinaliza tentativa de alterar a anotação de outro usuário
+var errForbidden = errors.New("forbidden")
+
+// nearbyAnnotations devolve as anotações cujo raio cobre o ponto, da mais próxima à mais distante
+func nearbyAnnotations(lat, lng float64) []scraper.Annotation {
+	type withDist struct {
+		a    scraper.Annotation
+		dist float64
+	}
+	var found []withDist
+	store.View(func(d *storeData) {
+		for _, a := range d.Annotations {
+			dist := scraper.CalculateDistance(lat, lng, a.Lat, a.Lng) * 1000
+			if dist <= a.Radius {
+				found = append(found, withDist{a, dist})
+			}
+		}
+	})
+	sort.Slice(found, func(i, j int) bool { return found[i].dist < found[j].dist })
+
+	out := make([]scraper.Annotation, len(found))
+	for i, f := range found {
+		out[i] = f.a
+	}
+	return out
+}
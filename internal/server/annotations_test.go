@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestHandleAnnotations(t *testing.T) {
+	prev := store
+	store = newMemoryStore()
+	t.Cleanup(func() { store = prev })
+	useAPITokens(t, "alice:tok-a,bob:tok-b")
+
+	do := func(method, target, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handleAnnotations(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/annotations?lat=53.34&lng=-6.26", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous GET: status %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/annotations?lat=53.34&lng=-6.26", "wrong", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unknown token: status %d", rec.Code)
+	}
+	for _, body := range []string{
+		`{"kind":"street","lat":53.34,"lng":-6.26,"note":"x"}`,
+		`{"lat":53.34,"lng":-6.26,"note":"  "}`,
+		`{"note":"bins"}`,
+		`{"lat":53.34,"lng":-6.26,"note":"bins","rating":6}`,
+	} {
+		if rec := do(http.MethodPost, "/annotations", "tok-a", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("POST %s: status %d, want 400", body, rec.Code)
+		}
+	}
+
+	rec := do(http.MethodPost, "/annotations", "tok-a", `{"kind":"Building","label":"Block A","lat":53.3400,"lng":-6.2600,"note":"bin storage issues","rating":2}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST status %d: %s", rec.Code, rec.Body)
+	}
+	var building scraper.Annotation
+	json.NewDecoder(rec.Body).Decode(&building)
+	if building.Author != "alice" || building.Kind != "building" || building.Radius != 50 {
+		t.Errorf("created = %+v", building)
+	}
+	do(http.MethodPost, "/annotations", "tok-b", `{"lat":53.3420,"lng":-6.2600,"note":"great management company"}`)
+
+	// ~220 m from the building: only the area note (500 m) covers the point
+	var near []scraper.Annotation
+	json.NewDecoder(do(http.MethodGet, "/annotations?lat=53.3420&lng=-6.2600", "tok-a", "").Body).Decode(&near)
+	if len(near) != 1 || near[0].Author != "bob" {
+		t.Errorf("near the area note = %+v", near)
+	}
+	// at the building both apply, nearest first
+	near = nil
+	json.NewDecoder(do(http.MethodGet, "/annotations?lat=53.3400&lng=-6.2600", "tok-a", "").Body).Decode(&near)
+	if len(near) != 2 || near[0].ID != building.ID {
+		t.Errorf("at the building = %+v", near)
+	}
+
+	if rec := do(http.MethodDelete, "/annotations?id="+building.ID, "tok-b", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("DELETE by another user: status %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/annotations?id="+building.ID, "tok-a", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE by the author: status %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/annotations?id="+building.ID, "tok-a", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("second DELETE: status %d", rec.Code)
+	}
+}
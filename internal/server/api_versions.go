@@ -0,0 +1,280 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"daft-scraper-api/internal/safety"
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Versões da API (/v1 congelada, /v2 com os campos novos) ──────── */
+
+// /v1/scrape e /v1/analyze respondem com o contrato original do anúncio: as structs
+// v1Property e v1Analysis abaixo reproduzem PropertyInfo e AnalysisResponse como eram
+// antes de qualquer campo novo, com os blocos aninhados no formato de então (erro como
+// texto, fatores de segurança como frases). As rotas antigas (/scrape e /analyze) são
+// apelidos da v1. Os campos novos entram só em /v2, que responde com as structs atuais.
+
+type apiVersionKey struct{}
+
+// versionedRoutes são as rotas servidas também sob /v1 e /v2
+var versionedRoutes = []string{"/scrape", "/analyze"}
+
+// v2Responses são os tipos de resposta das rotas versionadas na v2
+var v2Responses = map[string]interface{}{"/scrape": scraper.PropertyInfo{}, "/analyze": scraper.AnalysisResponse{}}
+
+// apiV1 serve o handler com o contrato congelado da v1
+func apiV1(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "v1")
+		next(w, r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, "v1")))
+	}
+}
+
+// apiV2 serve o handler com a resposta completa
+func apiV2(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "v2")
+		next(w, r)
+	}
+}
+
+// isAPIV1 diz se a requisição veio por uma rota da v1
+func isAPIV1(ctx context.Context) bool {
+	version, _ := ctx.Value(apiVersionKey{}).(string)
+	return version == "v1"
+}
+
+// v1Response converte o anúncio ou a análise para o contrato da v1; outras respostas
+// passam como estão
+func v1Response(v interface{}) interface{} {
+	switch v := v.(type) {
+	case scraper.PropertyInfo:
+		return newV1Property(&v)
+	case *scraper.PropertyInfo:
+		return newV1Property(v)
+	case scraper.AnalysisResponse:
+		return newV1Analysis(&v)
+	case *scraper.AnalysisResponse:
+		return newV1Analysis(v)
+	}
+	return v
+}
+
+/* ───── Contrato da v1 ──────────────────────────────────────────────── */
+
+// Não altere estas structs: TestV1Shape compara o JSON delas com o contrato publicado.
+
+// v1POI é o POI da v1
+type v1POI struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Distance float64 `json:"distance"` // em km
+	Duration int     `json:"duration"` // tempo de caminhada em minutos
+}
+
+// v1PricePoint é um ponto do histórico de preços da v1
+type v1PricePoint struct {
+	Date  string  `json:"date"`
+	Price float64 `json:"price"`
+}
+
+// v1SimilarProperty é um imóvel similar da v1
+type v1SimilarProperty struct {
+	Address string  `json:"address"`
+	Price   float64 `json:"price"`
+	URL     string  `json:"url"`
+}
+
+// v1Property é o anúncio da v1
+type v1Property struct {
+	Address      string `json:"address"`
+	RentPrice    string `json:"price"`
+	Bedrooms     string `json:"bedrooms"`
+	Bathrooms    string `json:"bathrooms"`
+	PropertyType string `json:"propertyType"`
+	Description  string `json:"description"`
+	URL          string `json:"url"`
+	Error        string `json:"error,omitempty"`
+
+	Coordinates struct {
+		Lat float64 `json:"lat"`
+		Lng float64 `json:"lng"`
+	} `json:"coordinates"`
+
+	SafetyInfo struct {
+		CrimeRate      float64 `json:"crimeRate"`
+		SafetyRating   int     `json:"safetyRating"`
+		NearbyGardai   []v1POI `json:"nearbyGardai"`
+		StreetLighting string  `json:"streetLighting"`
+	} `json:"safetyInfo"`
+
+	QualityOfLife struct {
+		TransportScore  int     `json:"transportScore"`
+		PublicTransport []v1POI `json:"publicTransport"`
+		Amenities       []v1POI `json:"amenities"`
+		Entertainment   []v1POI `json:"entertainment"`
+		WalkScore       int     `json:"walkScore"`
+	} `json:"qualityOfLife"`
+
+	ValueAnalysis struct {
+		AreaAveragePrice float64             `json:"areaAveragePrice"`
+		PriceRating      int                 `json:"priceRating"`
+		PriceHistory     []v1PricePoint      `json:"priceHistory"`
+		Similar          []v1SimilarProperty `json:"similar"`
+	} `json:"valueAnalysis"`
+}
+
+// v1CrimeBreakdown é uma linha do detalhamento de crimes da v1
+type v1CrimeBreakdown struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// v1Garda é uma delegacia da análise de segurança da v1
+type v1Garda struct {
+	Name     string  `json:"name"`
+	Distance float64 `json:"distance"` // em km
+	Phone    string  `json:"phone,omitempty"`
+}
+
+// v1Analysis é a resposta de /analyze na v1
+type v1Analysis struct {
+	Property   v1Property `json:"property"`
+	SafetyInfo struct {
+		CrimeStats struct {
+			Total     int                `json:"total"`
+			PerCapita float64            `json:"perCapita"`
+			Breakdown []v1CrimeBreakdown `json:"breakdown"`
+		} `json:"crimeStats"`
+		NearbyGardai   []v1Garda `json:"nearbyGardai"`
+		StreetLighting struct {
+			Rating      int    `json:"rating"`
+			Description string `json:"description"`
+		} `json:"streetLighting"`
+		SafetyScore   int      `json:"safetyScore"`
+		SafetyFactors []string `json:"safetyFactors"`
+		RiskFactors   []string `json:"riskFactors"`
+	} `json:"safetyInfo"`
+}
+
+func newV1POIs(pois []scraper.POI) []v1POI {
+	if pois == nil {
+		return nil
+	}
+	out := make([]v1POI, len(pois))
+	for i, p := range pois {
+		out[i] = v1POI{Name: p.Name, Type: p.Type, Distance: p.Distance, Duration: p.Duration}
+	}
+	return out
+}
+
+func newV1Property(p *scraper.PropertyInfo) v1Property {
+	v := v1Property{
+		Address:      p.Address,
+		RentPrice:    p.RentPrice,
+		Bedrooms:     p.Bedrooms,
+		Bathrooms:    p.Bathrooms,
+		PropertyType: p.PropertyType,
+		Description:  p.Description,
+		URL:          p.URL,
+	}
+	if p.Error != nil {
+		v.Error = p.Error.Message
+	}
+	v.Coordinates.Lat, v.Coordinates.Lng = p.Coordinates.Lat, p.Coordinates.Lng
+
+	v.SafetyInfo.CrimeRate = p.SafetyInfo.CrimeRate
+	v.SafetyInfo.SafetyRating = p.SafetyInfo.SafetyRating
+	v.SafetyInfo.NearbyGardai = newV1POIs(p.SafetyInfo.NearbyGardai)
+	v.SafetyInfo.StreetLighting = p.SafetyInfo.StreetLighting
+
+	v.QualityOfLife.TransportScore = p.QualityOfLife.TransportScore
+	v.QualityOfLife.PublicTransport = newV1POIs(p.QualityOfLife.PublicTransport)
+	v.QualityOfLife.Amenities = newV1POIs(p.QualityOfLife.Amenities)
+	v.QualityOfLife.Entertainment = newV1POIs(p.QualityOfLife.Entertainment)
+	v.QualityOfLife.WalkScore = p.QualityOfLife.WalkScore
+
+	v.ValueAnalysis.AreaAveragePrice = p.ValueAnalysis.AreaAveragePrice
+	v.ValueAnalysis.PriceRating = p.ValueAnalysis.PriceRating
+	for _, h := range p.ValueAnalysis.PriceHistory {
+		v.ValueAnalysis.PriceHistory = append(v.ValueAnalysis.PriceHistory, v1PricePoint{Date: h.Date, Price: h.Price})
+	}
+	for _, s := range p.ValueAnalysis.Similar {
+		v.ValueAnalysis.Similar = append(v.ValueAnalysis.Similar, v1SimilarProperty{Address: s.Address, Price: s.Price, URL: s.URL})
+	}
+	return v
+}
+
+// v1FactorText devolve a frase da v1 para um fator do safetyScore; fatores que não
+// existiam na v1 ficam de fora
+func v1FactorText(f safety.SafetyFactor) (string, bool) {
+	switch f.Factor {
+	case "garda_station":
+		return f.Evidence, true
+	case "well_lit_streets":
+		return "Well-lit streets", true
+	case "crime_rate":
+		return "Above average crime rate", true
+	}
+	return "", false
+}
+
+func newV1Analysis(a *scraper.AnalysisResponse) v1Analysis {
+	v := v1Analysis{Property: newV1Property(&a.Property)}
+	s := &a.SafetyInfo
+
+	v.SafetyInfo.CrimeStats.Total = s.CrimeStats.Total
+	v.SafetyInfo.CrimeStats.PerCapita = s.CrimeStats.PerCapita
+	for _, b := range s.CrimeStats.Breakdown {
+		v.SafetyInfo.CrimeStats.Breakdown = append(v.SafetyInfo.CrimeStats.Breakdown, v1CrimeBreakdown{Type: b.Type, Count: b.Count})
+	}
+	for _, g := range s.NearbyGardai {
+		v.SafetyInfo.NearbyGardai = append(v.SafetyInfo.NearbyGardai, v1Garda{Name: g.Name, Distance: g.Distance, Phone: g.Phone})
+	}
+	v.SafetyInfo.StreetLighting.Rating = s.StreetLighting.Rating
+	v.SafetyInfo.StreetLighting.Description = s.StreetLighting.Description
+	v.SafetyInfo.SafetyScore = s.SafetyScore
+	if s.SafetyFactors != nil {
+		v.SafetyInfo.SafetyFactors = []string{}
+	}
+	for _, f := range s.SafetyFactors {
+		if text, ok := v1FactorText(f); ok {
+			v.SafetyInfo.SafetyFactors = append(v.SafetyInfo.SafetyFactors, text)
+		}
+	}
+	if s.RiskFactors != nil {
+		v.SafetyInfo.RiskFactors = []string{}
+	}
+	for _, f := range s.RiskFactors {
+		if text, ok := v1FactorText(f); ok {
+			v.SafetyInfo.RiskFactors = append(v.SafetyInfo.RiskFactors, text)
+		}
+	}
+	return v
+}
+
+// versionedOperations documenta as rotas de versionedRoutes sob /v1 e /v2, com os
+// mesmos parâmetros da rota sem prefixo; a v1 responde com os tipos da rota sem
+// prefixo e a v2 com os de v2Responses
+func versionedOperations() []apiOperation {
+	var ops []apiOperation
+	for _, version := range []string{"v1", "v2"} {
+		for _, op := range apiOperations {
+			for _, route := range versionedRoutes {
+				if op.Path != route {
+					continue
+				}
+				op.Path = "/" + version + route
+				op.Summary = strings.ToUpper(version[:1]) + version[1:] + ": " + op.Summary
+				if version == "v2" {
+					op.Response = v2Responses[route]
+				}
+				ops = append(ops, op)
+			}
+		}
+	}
+	return ops
+}
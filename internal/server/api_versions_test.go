@@ -0,0 +1,214 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+// jsonPaths lists every key path in a JSON document, with [] for list items
+func jsonPaths(prefix string, v interface{}, paths map[string]bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, sub := range v {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			paths[path] = true
+			jsonPaths(path, sub, paths)
+		}
+	case []interface{}:
+		for _, item := range v {
+			jsonPaths(prefix+"[]", item, paths)
+		}
+	}
+}
+
+func shapeOf(t *testing.T, v interface{}) []string {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc interface{}
+	json.Unmarshal(raw, &doc)
+	paths := map[string]bool{}
+	jsonPaths("", doc, paths)
+	var out []string
+	for p := range paths {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// a listing and an analysis with every block filled in, including fields added after v1
+const fullAnalysisJSON = `{
+	"property": {
+		"address": "1 Main St, Rathmines, Dublin 6", "price": "€2,000 per month", "bedrooms": "2", "bathrooms": "1",
+		"propertyType": "Apartment", "description": "Bright", "url": "https://www.daft.ie/for-rent/x/1",
+		"listingType": "rent", "error": {"code": "NO_LISTING_DATA", "message": "no data", "retryable": false},
+		"coordinates": {"lat": 53.3, "lng": -6.2, "source": "google"},
+		"safetyInfo": {"crimeRate": 0.01, "safetyRating": 8, "streetLighting": "Well lit", "streetLamps": 40,
+			"nearbyGardai": [{"name": "Rathmines", "type": "garda_station", "distance": 0.5, "duration": 6, "distanceMeters": 500}]},
+		"qualityOfLife": {"transportScore": 7, "walkScore": 80, "bikeScore": 60,
+			"publicTransport": [{"name": "Luas", "type": "light_rail_station", "distance": 0.4, "duration": 5, "walkMinutes": 5}],
+			"amenities": [{"name": "Tesco", "type": "supermarket", "distance": 0.2, "duration": 3}],
+			"entertainment": [{"name": "The Bleeding Horse", "type": "bar", "distance": 0.1, "duration": 1}]},
+		"valueAnalysis": {"areaAveragePrice": 2100, "priceRating": 6, "pricePerSqm": 30,
+			"priceHistory": [{"date": "2024-01-01", "price": 2000}],
+			"similar": [{"address": "2 Main St", "price": 1900, "url": "https://www.daft.ie/for-rent/x/2", "source": "daft.ie"}]}
+	},
+	"safetyInfo": {
+		"crimeStats": {"total": 120, "perCapita": 0.03, "granularity": "district", "breakdown": [{"type": "theft", "count": 40}]},
+		"nearbyGardai": [{"name": "Rathmines", "distance": 0.5, "distanceMeters": 500, "phone": "01 666 6700", "lat": 53.3}],
+		"streetLighting": {"rating": 8, "description": "Well lit", "lampCount": 40},
+		"safetyScore": 82,
+		"safetyFactors": [{"factor": "garda_station", "weight": 5, "contribution": 5, "evidence": "Garda station within 0.5 km"},
+			{"factor": "street_lighting", "weight": 2, "contribution": 16, "evidence": "Street lighting rated 8/10: Well lit"},
+			{"factor": "well_lit_streets", "weight": 5, "contribution": 5, "evidence": "40 street lamps nearby"}],
+		"riskFactors": [{"factor": "crime_rate", "weight": 10, "contribution": -10, "evidence": "0.030 crimes per capita"}],
+		"scoreInputs": {"base": 70}
+	}
+}`
+
+func fullAnalysis(t *testing.T) scraper.AnalysisResponse {
+	t.Helper()
+	var a scraper.AnalysisResponse
+	if err := json.Unmarshal([]byte(fullAnalysisJSON), &a); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// v1PropertyShape is the published v1 listing contract; it must never change
+var v1PropertyShape = []string{
+	"address", "bathrooms", "bedrooms",
+	"coordinates", "coordinates.lat", "coordinates.lng",
+	"description", "error", "price", "propertyType",
+	"qualityOfLife", "qualityOfLife.amenities",
+	"qualityOfLife.amenities[].distance", "qualityOfLife.amenities[].duration", "qualityOfLife.amenities[].name", "qualityOfLife.amenities[].type",
+	"qualityOfLife.entertainment",
+	"qualityOfLife.entertainment[].distance", "qualityOfLife.entertainment[].duration", "qualityOfLife.entertainment[].name", "qualityOfLife.entertainment[].type",
+	"qualityOfLife.publicTransport",
+	"qualityOfLife.publicTransport[].distance", "qualityOfLife.publicTransport[].duration", "qualityOfLife.publicTransport[].name", "qualityOfLife.publicTransport[].type",
+	"qualityOfLife.transportScore", "qualityOfLife.walkScore",
+	"safetyInfo", "safetyInfo.crimeRate", "safetyInfo.nearbyGardai",
+	"safetyInfo.nearbyGardai[].distance", "safetyInfo.nearbyGardai[].duration", "safetyInfo.nearbyGardai[].name", "safetyInfo.nearbyGardai[].type",
+	"safetyInfo.safetyRating", "safetyInfo.streetLighting",
+	"url",
+	"valueAnalysis", "valueAnalysis.areaAveragePrice", "valueAnalysis.priceHistory",
+	"valueAnalysis.priceHistory[].date", "valueAnalysis.priceHistory[].price",
+	"valueAnalysis.priceRating", "valueAnalysis.similar",
+	"valueAnalysis.similar[].address", "valueAnalysis.similar[].price", "valueAnalysis.similar[].url",
+}
+
+// v1SafetyShape is the published v1 safetyInfo of /analyze
+var v1SafetyShape = []string{
+	"safetyInfo", "safetyInfo.crimeStats",
+	"safetyInfo.crimeStats.breakdown", "safetyInfo.crimeStats.breakdown[].count", "safetyInfo.crimeStats.breakdown[].type",
+	"safetyInfo.crimeStats.perCapita", "safetyInfo.crimeStats.total",
+	"safetyInfo.nearbyGardai", "safetyInfo.nearbyGardai[].distance", "safetyInfo.nearbyGardai[].name", "safetyInfo.nearbyGardai[].phone",
+	"safetyInfo.riskFactors", "safetyInfo.safetyFactors", "safetyInfo.safetyScore",
+	"safetyInfo.streetLighting", "safetyInfo.streetLighting.description", "safetyInfo.streetLighting.rating",
+}
+
+func TestV1Shape(t *testing.T) {
+	a := fullAnalysis(t)
+
+	if got := shapeOf(t, v1Response(a.Property)); !reflect.DeepEqual(got, v1PropertyShape) {
+		t.Errorf("v1 listing shape changed:\n got %q\nwant %q", got, v1PropertyShape)
+	}
+
+	want := []string{"property"}
+	for _, p := range v1PropertyShape {
+		want = append(want, "property."+p)
+	}
+	want = append(want, v1SafetyShape...)
+	sort.Strings(want)
+	if got := shapeOf(t, v1Response(a)); !reflect.DeepEqual(got, want) {
+		t.Errorf("v1 analysis shape changed:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestV1NestedValues(t *testing.T) {
+	v := v1Response(fullAnalysis(t)).(v1Analysis)
+
+	if v.Property.Error != "no data" {
+		t.Errorf("error = %q, want the message as a string", v.Property.Error)
+	}
+	if want := []string{"Garda station within 0.5 km", "Well-lit streets"}; !reflect.DeepEqual(v.SafetyInfo.SafetyFactors, want) {
+		t.Errorf("safetyFactors = %q, want %q", v.SafetyInfo.SafetyFactors, want)
+	}
+	if want := []string{"Above average crime rate"}; !reflect.DeepEqual(v.SafetyInfo.RiskFactors, want) {
+		t.Errorf("riskFactors = %q, want %q", v.SafetyInfo.RiskFactors, want)
+	}
+	if v.Property.QualityOfLife.PublicTransport[0].Duration != 5 || v.SafetyInfo.NearbyGardai[0].Phone != "01 666 6700" {
+		t.Errorf("values lost in the conversion: %+v", v)
+	}
+}
+
+func TestAPIVersionsShapeTheResponse(t *testing.T) {
+	a := fullAnalysis(t)
+	for _, tc := range []struct {
+		name    string
+		wrap    func(http.HandlerFunc) http.HandlerFunc
+		body    interface{}
+		version string
+		v2      bool
+	}{
+		{"v1 scrape", apiV1, a.Property, "v1", false},
+		{"v1 analyze", apiV1, a, "v1", false},
+		{"v2 scrape", apiV2, a.Property, "v2", true},
+		{"v2 analyze", apiV2, a, "v2", true},
+	} {
+		body := tc.body
+		handler := tc.wrap(func(w http.ResponseWriter, r *http.Request) { writeJSONFields(w, r, body) })
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/analyze", nil))
+
+		if got := rec.Header().Get("API-Version"); got != tc.version {
+			t.Errorf("%s: API-Version = %q", tc.name, got)
+		}
+		// listingType was added after v1 was frozen
+		if got := strings.Contains(rec.Body.String(), `"listingType"`); got != tc.v2 {
+			t.Errorf("%s: listingType present = %v: %s", tc.name, got, rec.Body)
+		}
+	}
+}
+
+func TestV1KeepsSparseFieldsets(t *testing.T) {
+	analysis := scraper.AnalysisResponse{Property: scraper.PropertyInfo{Address: "1 Main St", RentPrice: "€2,000 per month"}}
+	rec := httptest.NewRecorder()
+	apiV1(func(w http.ResponseWriter, r *http.Request) { writeJSONFields(w, r, analysis) })(rec,
+		httptest.NewRequest(http.MethodGet, "/v1/analyze?fields=price", nil))
+
+	if got := rec.Body.String(); got != `{"property":{"price":"€2,000 per month"}}`+"\n" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestVersionedOperationsAreDocumented(t *testing.T) {
+	ops := map[string]apiOperation{}
+	for _, op := range versionedOperations() {
+		ops[op.Method+" "+op.Path] = op
+	}
+	for _, want := range []string{"POST /v1/scrape", "GET /v1/analyze", "POST /v1/analyze", "POST /v2/scrape", "GET /v2/analyze"} {
+		if _, ok := ops[want]; !ok {
+			t.Errorf("%s is not documented", want)
+		}
+	}
+	if _, ok := ops["GET /v1/analyze"].Response.(v1Analysis); !ok {
+		t.Errorf("GET /v1/analyze documents %T", ops["GET /v1/analyze"].Response)
+	}
+	if _, ok := ops["GET /v2/analyze"].Response.(scraper.AnalysisResponse); !ok {
+		t.Errorf("GET /v2/analyze documents %T", ops["GET /v2/analyze"].Response)
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Envelope de erro da API ─────────────────────────────────────── */
+
+// statusCodes são os códigos genéricos por status HTTP, usados por writeError
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            "INVALID_REQUEST",
+	http.StatusUnauthorized:          "UNAUTHORIZED",
+	http.StatusForbidden:             "FORBIDDEN",
+	http.StatusNotFound:              "NOT_FOUND",
+	http.StatusMethodNotAllowed:      "METHOD_NOT_ALLOWED",
+	http.StatusConflict:              "CONFLICT",
+	http.StatusRequestEntityTooLarge: "PAYLOAD_TOO_LARGE",
+	http.StatusTooManyRequests:       "RATE_LIMITED",
+	http.StatusNotImplemented:        "NOT_IMPLEMENTED",
+	http.StatusBadGateway:            "UPSTREAM_FAILED",
+	http.StatusServiceUnavailable:    "UNAVAILABLE",
+	http.StatusGatewayTimeout:        "UPSTREAM_TIMEOUT",
+}
+
+// scrapeError classifica um erro do scraping do anúncio
+func scrapeError(err error) (int, *scraper.APIError) {
+	switch {
+	case errors.Is(err, scraper.ErrListingNotFound):
+		return http.StatusNotFound, &scraper.APIError{Code: "LISTING_NOT_FOUND", Message: "The listing was removed from Daft.ie", Module: "scrape"}
+	case errors.Is(err, scraper.ErrMapsBudgetExceeded):
+		return http.StatusServiceUnavailable, scraper.ErrMapsBudgetExceeded
+	case errors.Is(err, scraper.ErrCircuitOpen):
+		return http.StatusServiceUnavailable, &scraper.APIError{Code: "UPSTREAM_UNAVAILABLE", Message: "Daft.ie is failing right now, so the request was not sent. Try again later.", Retryable: true, Module: "scrape"}
+	case scraper.IsTimeout(err):
+		return http.StatusGatewayTimeout, &scraper.APIError{Code: "UPSTREAM_TIMEOUT", Message: "Daft.ie took too long to respond. Try again later.", Retryable: true, Module: "scrape"}
+	case errors.Is(err, scraper.ErrRobotsDisallowed):
+		return http.StatusForbidden, &scraper.APIError{Code: "ROBOTS_DISALLOWED", Message: "Daft.ie's robots.txt does not allow this page to be fetched", Module: "scrape"}
+	case errors.Is(err, scraper.ErrScrapeBlocked):
+		return http.StatusServiceUnavailable, &scraper.APIError{Code: "SCRAPE_BLOCKED", Message: "Daft.ie blocked the request. Try again later.", Retryable: true, Module: "scrape"}
+	default:
+		return http.StatusBadGateway, &scraper.APIError{Code: "SCRAPE_FAILED", Message: "Error during scraping: " + err.Error(), Retryable: true, Module: "scrape"}
+	}
+}
+
+// writeError escreve o envelope com o código genérico do status
+func writeError(w http.ResponseWriter, status int, message string) {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = "INTERNAL"
+	}
+	retryable := status == http.StatusTooManyRequests || (status >= http.StatusInternalServerError && status != http.StatusNotImplemented)
+	writeAPIError(w, status, &scraper.APIError{Code: code, Message: message, Retryable: retryable})
+}
+
+// writeScrapeError escreve o envelope de uma falha do scraping
+func writeScrapeError(w http.ResponseWriter, err error) {
+	status, apiErr := scrapeError(err)
+	writeAPIError(w, status, apiErr)
+}
+
+// errorEnvelope é o corpo de toda resposta de erro; requestId é o que o cliente passa
+// ao suporte para achar os logs da requisição
+type errorEnvelope struct {
+	Error     *scraper.APIError `json:"error"`
+	RequestID string            `json:"requestId,omitempty"`
+}
+
+// writeAPIError escreve {"error": {...}} com o status dado e o request ID posto por
+// logRequests
+func writeAPIError(w http.ResponseWriter, status int, apiErr *scraper.APIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorEnvelope{apiErr, w.Header().Get("X-Request-ID")})
+}
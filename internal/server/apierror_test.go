@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestScrapeErrorClassification(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{fmt.Errorf("failed to visit URL: %w", scraper.ErrListingNotFound), http.StatusNotFound, "LISTING_NOT_FOUND"},
+		{fmt.Errorf("failed to visit URL: %w", scraper.ErrScrapeBlocked), http.StatusServiceUnavailable, "SCRAPE_BLOCKED"},
+		{fmt.Errorf("failed to visit URL: %w", scraper.ErrRobotsDisallowed), http.StatusForbidden, "ROBOTS_DISALLOWED"},
+		{fmt.Errorf("timeout"), http.StatusBadGateway, "SCRAPE_FAILED"},
+	}
+	for _, c := range cases {
+		status, apiErr := scrapeError(c.err)
+		if status != c.status || apiErr.Code != c.code || apiErr.Module != "scrape" {
+			t.Errorf("scrapeError(%v) = %d %+v, want %d %s", c.err, status, apiErr, c.status, c.code)
+		}
+	}
+	// an analysis refused over the Maps budget keeps its own code
+	if status, apiErr := scrapeError(scraper.ErrMapsBudgetExceeded); status != http.StatusServiceUnavailable || apiErr.Code != "MAPS_BUDGET_EXCEEDED" {
+		t.Errorf("scrapeError(budget) = %d %+v", status, apiErr)
+	}
+}
+
+func TestWriteErrorEnvelope(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeError(rec, http.StatusBadRequest, "daftUrl is required in the request body")
+
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected response: %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var body struct {
+		Error scraper.APIError `json:"error"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error.Code != "INVALID_REQUEST" || body.Error.Retryable {
+		t.Errorf("unexpected envelope: %+v", body.Error)
+	}
+}
+
+func TestModuleErrorKeepsInnerCode(t *testing.T) {
+	inner := scraper.ModuleError(fmt.Errorf("CSO returned status code: 503"), "CSO_UNAVAILABLE", "safety")
+	outer := scraper.ModuleError(fmt.Errorf("wrapped: %w", inner), "SAFETY_FAILED", "safety")
+	if outer.Code != "CSO_UNAVAILABLE" {
+		t.Errorf("code = %s, want CSO_UNAVAILABLE", outer.Code)
+	}
+}
+
+func TestAPIErrorAcceptsLegacyString(t *testing.T) {
+	var p scraper.PropertyInfo
+	if err := json.Unmarshal([]byte(`{"address":"1 Main St","error":"Could not find essential property data."}`), &p); err != nil {
+		t.Fatalf("legacy error string: %v", err)
+	}
+	if p.Error == nil || p.Error.Message != "Could not find essential property data." || p.Error.Code != "LISTING_PARSE_FAILED" {
+		t.Errorf("legacy error = %+v", p.Error)
+	}
+
+	p = scraper.PropertyInfo{}
+	if err := json.Unmarshal([]byte(`{"error":{"code":"SCRAPE_FAILED","message":"boom","retryable":true}}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Error == nil || *p.Error != (scraper.APIError{Code: "SCRAPE_FAILED", Message: "boom", Retryable: true}) {
+		t.Errorf("envelope error = %+v", p.Error)
+	}
+}
+
+func TestStoreLoadsLegacyErrorStrings(t *testing.T) {
+	dir := t.TempDir()
+	legacy := `{"analyses":{"daft:1":{"id":"daft:1","url":"https://www.daft.ie/for-rent/x/1",` +
+		`"property":{"address":"1 Main St","error":"Could not find essential property data."}}}}`
+	if err := os.WriteFile(filepath.Join(dir, "store.json"), []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := openStore(dir)
+	if err != nil {
+		t.Fatalf("a store saved before the error envelope should still load: %v", err)
+	}
+	s.View(func(d *storeData) {
+		if a := d.Analyses["daft:1"]; a == nil || a.Property.Error == nil {
+			t.Errorf("legacy analysis lost its error: %+v", a)
+		}
+	})
+}
+
+func TestScrapeListingErrorStatusByVersion(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><title>Not a listing</title></head></html>`)
+	}))
+	t.Cleanup(srv.Close)
+	prevTransport := http.DefaultTransport
+	http.DefaultTransport = srv.Client().Transport
+	t.Cleanup(func() { http.DefaultTransport = prevTransport })
+	t.Setenv("SCRAPE_DOMAINS", "127.0.0.1")
+
+	body := `{"daftUrl":"` + srv.URL + `/for-rent/x/1"}`
+	for _, tc := range []struct {
+		name   string
+		wrap   func(http.HandlerFunc) http.HandlerFunc
+		status int
+		want   string
+	}{
+		{"v1", apiV1, http.StatusOK, `"error":"Could not find essential property data`},
+		{"v2", apiV2, http.StatusUnprocessableEntity, `"code":"LISTING_PARSE_FAILED"`},
+	} {
+		rec := httptest.NewRecorder()
+		tc.wrap(handleScrape)(rec, httptest.NewRequest(http.MethodPost, "/scrape?modules=photos", strings.NewReader(body)))
+		if rec.Code != tc.status || !strings.Contains(rec.Body.String(), tc.want) {
+			t.Errorf("%s: %d %s, want %d with %s", tc.name, rec.Code, rec.Body, tc.status, tc.want)
+		}
+	}
+}
+
+func TestScrapeErrorTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if status, apiErr := scrapeError(ctx.Err()); status != http.StatusGatewayTimeout || apiErr.Code != "UPSTREAM_TIMEOUT" {
+		t.Errorf("got %d %+v", status, apiErr)
+	}
+}
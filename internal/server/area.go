@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"daft-scraper-api/internal/safety"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Análise só da área (sem anúncio) ────────────────────────────── */
+
+// eircodePattern casa códigos postais irlandeses como "D02 X285" ou "D6WXY12"
+var eircodePattern = regexp.MustCompile(`(?i)^([AC-FHKNPRTV-Y]\d{2}|D6W)\s?[0-9AC-FHKNPRTV-Y]{4}$`)
+
+// AreaAnalysis é o resultado de POST /area: tudo da análise menos os campos do anúncio
+type AreaAnalysis struct {
+	Query       string `json:"query"`
+	Coordinates struct {
+		Lat float64 `json:"lat"`
+		Lng float64 `json:"lng"`
+	} `json:"coordinates"`
+	SafetyInfo    safety.SafetyAnalysis     `json:"safetyInfo"`
+	QualityOfLife scraper.QualityOfLifeInfo `json:"qualityOfLife"`
+	Annotations   []scraper.Annotation      `json:"annotations,omitempty"`
+	Units         scraper.ResponseUnits     `json:"units"`
+
+	mapsCalls int // chamadas ao Google Maps feitas pela análise
+}
+
+// areaRequest é o corpo de POST /area
+type areaRequest struct {
+	Address string   `json:"address"`
+	Eircode string   `json:"eircode"`
+	Lat     *float64 `json:"lat"`
+	Lng     *float64 `json:"lng"`
+}
+
+// handleArea é o handler HTTP para POST /area {"address"|"eircode"|"lat"+"lng"}
+func handleArea(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+
+	var requestBody areaRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// a análise de área reaproveita os módulos que trabalham sobre um PropertyInfo
+	location := scraper.PropertyInfo{}
+	query := ""
+	switch {
+	case requestBody.Lat != nil && requestBody.Lng != nil:
+		if *requestBody.Lat < -90 || *requestBody.Lat > 90 || *requestBody.Lng < -180 || *requestBody.Lng > 180 {
+			writeError(w, http.StatusBadRequest, "lat/lng out of range")
+			return
+		}
+		location.Coordinates.Lat, location.Coordinates.Lng = *requestBody.Lat, *requestBody.Lng
+		query = fmt.Sprintf("%f,%f", *requestBody.Lat, *requestBody.Lng)
+	case requestBody.Eircode != "":
+		if !eircodePattern.MatchString(strings.TrimSpace(requestBody.Eircode)) {
+			writeError(w, http.StatusBadRequest, "Invalid Eircode")
+			return
+		}
+		query = strings.ToUpper(strings.TrimSpace(requestBody.Eircode))
+		location.Address = query
+	case requestBody.Address != "":
+		query = requestBody.Address
+		location.Address = query
+	default:
+		writeError(w, http.StatusBadRequest, "address, eircode or lat/lng is required in the request body")
+		return
+	}
+
+	telemetry.LogFor(r.Context()).Info("Area analysis requested", "query", query)
+
+	area, err := analyzeArea(r.Context(), location)
+	chargeMapsCalls(w, r, area.mapsCalls)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("Error analyzing area: %v", err))
+		return
+	}
+	area.Query = query
+
+	writeJSONFields(w, r, area)
+}
+
+// analyzeArea roda segurança, crime, transporte e amenidades para a localização
+func analyzeArea(ctx context.Context, location scraper.PropertyInfo) (AreaAnalysis, error) {
+	var area AreaAnalysis
+	usage := location.Usage()
+
+	if location.Coordinates.Lat == 0 && location.Coordinates.Lng == 0 {
+		if err := analyzerFor(ctx).GetCoordinates(ctx, &location); err != nil {
+			area.mapsCalls = usage.Count()
+			return area, fmt.Errorf("error geocoding location: %w", err)
+		}
+	}
+	area.Coordinates.Lat, area.Coordinates.Lng = location.Coordinates.Lat, location.Coordinates.Lng
+
+	analysis := scraper.AnalysisResponse{Property: location}
+	if err := analyzerFor(ctx).AnalyzeSafety(ctx, &analysis); err != nil {
+		telemetry.LogFor(ctx).Warn("Safety analysis failed", "address", location.Address, "error", err)
+	}
+	area.SafetyInfo = analysis.SafetyInfo
+
+	if err := analyzerFor(ctx).GetQualityOfLife(ctx, &location, scraper.AllModules()); err != nil {
+		telemetry.LogFor(ctx).Warn("Quality of life failed", "address", location.Address, "error", err)
+	}
+	area.QualityOfLife = location.QualityOfLife
+	area.Annotations = nearbyAnnotations(location.Coordinates.Lat, location.Coordinates.Lng)
+	area.mapsCalls = usage.Count()
+
+	return area, nil
+}
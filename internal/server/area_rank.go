@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Ranking de bairros por condado ──────────────────────────────── */
+
+// rankedSuburbs é a lista de bairros analisados por condado
+var rankedSuburbs = map[string][]string{
+	"dublin": {
+		"Rathmines", "Ranelagh", "Drumcondra", "Phibsborough", "Stoneybatter", "Clontarf",
+		"Sandymount", "Ballsbridge", "Glasnevin", "Harold's Cross", "Inchicore", "Dún Laoghaire",
+		"Blackrock", "Dundrum", "Tallaght", "Swords", "Lucan", "Blanchardstown", "Howth", "Malahide",
+	},
+	"cork": {
+		"Ballincollig", "Douglas", "Blackrock", "Bishopstown", "Wilton", "Mahon",
+		"Glanmire", "Carrigaline", "Cobh", "Midleton",
+	},
+	"galway": {
+		"Salthill", "Knocknacarra", "Renmore", "Newcastle", "Oranmore", "Rahoon", "Ballybane", "Terryland",
+	},
+	"limerick": {
+		"Castletroy", "Raheen", "Dooradoyle", "Corbally", "Annacotty", "Ennis Road", "Caherdavin",
+	},
+	"waterford": {
+		"Ballybricken", "Tramore", "Ferrybank", "Lismore Park", "Dunmore Road",
+	},
+}
+
+const areaRankConcurrency = 3
+
+// RankedArea é um bairro com o score composto e os componentes
+type RankedArea struct {
+	Rank         int     `json:"rank"`
+	Name         string  `json:"name"`
+	Lat          float64 `json:"lat"`
+	Lng          float64 `json:"lng"`
+	OverallScore int     `json:"overallScore"` // 0-100
+	Safety       int     `json:"safety"`       // 1-10
+	Transport    int     `json:"transport"`    // 1-10
+	Walk         int     `json:"walk"`         // 1-100
+	CrimePerCap  float64 `json:"crimePerCapita"`
+}
+
+type areaRanking struct {
+	County     string       `json:"county"`
+	Areas      []RankedArea `json:"areas"`
+	ComputedAt time.Time    `json:"computedAt"`
+
+	mapsCalls int // chamadas ao Google Maps feitas para calcular o ranking
+}
+
+var (
+	areaRankMu    sync.Mutex // protege areaRankCache; não fica preso durante o cálculo
+	areaRankCache = map[string]*areaRanking{}
+
+	// areaRankLocks tem um mutex por condado: duas requisições não calculam o mesmo
+	// ranking em paralelo, mas o cálculo de um condado não segura os outros
+	areaRankLocks = func() map[string]*sync.Mutex {
+		locks := map[string]*sync.Mutex{}
+		for county := range rankedSuburbs {
+			locks[county] = &sync.Mutex{}
+		}
+		return locks
+	}()
+)
+
+// areaRankTTL é a validade do ranking em cache (AREA_RANK_TTL, padrão 7 dias);
+// os dados de crime e POIs mudam devagar
+func areaRankTTL() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("AREA_RANK_TTL")); err == nil && d > 0 {
+		return d
+	}
+	return 7 * 24 * time.Hour
+}
+
+// handleAreasRank é o handler HTTP para GET /areas/rank?county=dublin
+func handleAreasRank(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	county := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("county")))
+	suburbs, ok := rankedSuburbs[county]
+	if !ok {
+		counties := make([]string, 0, len(rankedSuburbs))
+		for c := range rankedSuburbs {
+			counties = append(counties, c)
+		}
+		sort.Strings(counties)
+		writeError(w, http.StatusBadRequest, "county must be one of: "+strings.Join(counties, ", "))
+		return
+	}
+
+	lock := areaRankLocks[county]
+	lock.Lock()
+	areaRankMu.Lock()
+	ranking, cached := areaRankCache[county]
+	areaRankMu.Unlock()
+	cached = cached && time.Since(ranking.ComputedAt) <= areaRankTTL()
+	countCache("area-rank", cached)
+	if !cached {
+		telemetry.LogFor(r.Context()).Info("Ranking areas", "county", county, "areas", len(suburbs))
+		// o ranking fica no cache para todos os clientes, então não é cortado se este
+		// desconectar; os timeouts por etapa e o desligamento continuam valendo
+		ranking = rankAreas(detachedContext(r.Context()), county, suburbs)
+		areaRankMu.Lock()
+		areaRankCache[county] = ranking
+		areaRankMu.Unlock()
+		chargeMapsCalls(w, r, ranking.mapsCalls)
+	}
+	lock.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ranking)
+}
+
+// rankAreas analisa cada bairro e ordena pelo score composto
+func rankAreas(ctx context.Context, county string, suburbs []string) *areaRanking {
+	areas := make([]*RankedArea, len(suburbs))
+	calls := make([]int, len(suburbs))
+	sem := make(chan struct{}, areaRankConcurrency)
+	var wg sync.WaitGroup
+
+	for i, name := range suburbs {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			area, err := analyzeArea(ctx, scraper.PropertyInfo{Address: name + ", Co. " + county})
+			calls[i] = area.mapsCalls
+			if err != nil {
+				telemetry.LogFor(ctx).Warn("Area analysis failed", "area", name, "error", err)
+				return
+			}
+			areas[i] = rankedArea(analyzerFor(ctx), name, area)
+		}(i, name)
+	}
+	wg.Wait()
+
+	ranking := &areaRanking{County: county, Areas: []RankedArea{}, ComputedAt: time.Now()}
+	for _, n := range calls {
+		ranking.mapsCalls += n
+	}
+	for _, a := range areas {
+		if a != nil {
+			ranking.Areas = append(ranking.Areas, *a)
+		}
+	}
+	sort.SliceStable(ranking.Areas, func(i, j int) bool {
+		return ranking.Areas[i].OverallScore > ranking.Areas[j].OverallScore
+	})
+	for i := range ranking.Areas {
+		ranking.Areas[i].Rank = i + 1
+	}
+	return ranking
+}
+
+// rankedArea calcula o score composto de um bairro com a mesma fórmula dos anúncios
+// (sem o componente de preço)
+func rankedArea(a *enrich.Analyzer, name string, area AreaAnalysis) *RankedArea {
+	p := scraper.PropertyInfo{QualityOfLife: area.QualityOfLife}
+	p.SafetyInfo.SafetyRating = area.SafetyInfo.SafetyScore / 10
+
+	return &RankedArea{
+		Name:         name,
+		Lat:          area.Coordinates.Lat,
+		Lng:          area.Coordinates.Lng,
+		OverallScore: a.OverallScore(&p),
+		Safety:       p.SafetyInfo.SafetyRating,
+		Transport:    area.QualityOfLife.TransportScore,
+		Walk:         area.QualityOfLife.WalkScore,
+		CrimePerCap:  area.SafetyInfo.CrimeStats.PerCapita,
+	}
+}
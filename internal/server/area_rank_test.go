@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/enrich"
+)
+
+func TestRankedArea(t *testing.T) {
+	var area AreaAnalysis
+	area.SafetyInfo.SafetyScore = 80
+	area.QualityOfLife.TransportScore = 6
+	area.QualityOfLife.WalkScore = 70
+
+	ranked := rankedArea((&enrich.Analyzer{}), "Rathmines", area)
+	if ranked.Safety != 8 || ranked.OverallScore != 70 {
+		t.Fatalf("unexpected ranked area: %+v", ranked)
+	}
+}
+
+func TestAreasRankDoesNotBlockOtherCounties(t *testing.T) {
+	areaRankMu.Lock()
+	prev := areaRankCache
+	areaRankCache = map[string]*areaRanking{
+		"cork": {County: "cork", Areas: []RankedArea{{Rank: 1, Name: "Douglas"}}, ComputedAt: time.Now()},
+	}
+	areaRankMu.Unlock()
+	t.Cleanup(func() {
+		areaRankMu.Lock()
+		areaRankCache = prev
+		areaRankMu.Unlock()
+	})
+
+	// a Dublin ranking in progress holds only Dublin's lock
+	areaRankLocks["dublin"].Lock()
+	defer areaRankLocks["dublin"].Unlock()
+
+	done := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		handleAreasRank(rec, httptest.NewRequest(http.MethodGet, "/areas/rank?county=cork", nil))
+		done <- rec.Code
+	}()
+	select {
+	case code := <-done:
+		if code != http.StatusOK {
+			t.Fatalf("status = %d", code)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the cached Cork ranking waited for the Dublin one")
+	}
+}
@@ -0,0 +1,16 @@
+package server
+
+import "testing"
+
+func TestEircodePattern(t *testing.T) {
+	for _, code := range []string{"D02 X285", "d02x285", "D6W XY12", "A65 F4E2", "T12 RTE4"} {
+		if !eircodePattern.MatchString(code) {
+			t.Errorf("expected %q to be a valid Eircode", code)
+		}
+	}
+	for _, code := range []string{"D2 X285", "B02 X285", "Dublin 2", "D02 X28"} {
+		if eircodePattern.MatchString(code) {
+			t.Errorf("expected %q to be rejected", code)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Perguntas em texto livre sobre uma análise guardada ─────────── */
+
+// AskResponse é a resposta de POST /analyses/{id}/ask
+type AskResponse struct {
+	Answer string `json:"answer"`
+	Source string `json:"source"` // rules, llm ou none
+}
+
+// poiAliases mapeia palavras da pergunta para tipos de POI
+var poiAliases = map[string]string{
+	"luas":        "light_rail_station",
+	"tram":        "light_rail_station",
+	"dart":        "train_station",
+	"train":       "train_station",
+	"rail":        "train_station",
+	"bus":         "bus_station",
+	"garda":       "garda_station",
+	"gardai":      "garda_station",
+	"police":      "garda_station",
+	"chemist":     "pharmacy",
+	"gp":          "doctor",
+	"shop":        "supermarket",
+	"groceries":   "supermarket",
+	"cinema":      "movie_theater",
+	"pub":         "bar",
+	"coffee":      "cafe",
+	"hospital":    "hospital",
+	"supermarket": "supermarket",
+}
+
+var berQuestion = regexp.MustCompile(`\bber\b|energy rating`)
+
+var nearestPattern = regexp.MustCompile(`(?i)\b(?:nearest|closest|how far(?: away)?(?: is)?(?: the)?(?: nearest| closest)?|distance to(?: the)?(?: nearest)?)\s+(?:a |an |the )?([\p{L}' ]+)`)
+
+// askStopwords são removidas do alvo extraído de "nearest ..."
+var askStopwords = map[string]bool{"is": true, "the": true, "a": true, "an": true, "from": true, "here": true, "to": true, "it": true, "away": true, "property": true}
+
+// answerQuestion tenta as regras sobre os dados estruturados e, se nenhuma servir,
+// o LLM configurado com os fatos da análise
+func answerQuestion(ctx context.Context, p *scraper.PropertyInfo, question string) AskResponse {
+	a := analyzerFor(ctx)
+	if answer := answerByRules(a, p, question); answer != "" {
+		return AskResponse{Answer: answer, Source: "rules"}
+	}
+
+	if provider := enrich.LLMProviderFromEnv(); provider != nil {
+		facts, err := a.BuildSummaryFacts(p)
+		if err == nil {
+			facts += poiFacts(p)
+			llmCtx, cancel := a.WithStageTimeout(ctx, "llm")
+			answer, err := provider.Complete(llmCtx, askSystemPrompt, facts+"\n\nQuestion: "+question)
+			cancel()
+			if err == nil && strings.TrimSpace(answer) != "" {
+				return AskResponse{Answer: strings.TrimSpace(answer), Source: "llm"}
+			}
+		}
+	}
+	return AskResponse{Answer: "I couldn't find that in this analysis.", Source: "none"}
+}
+
+const askSystemPrompt = `Answer the user's question about a property listing in one or two sentences.
+Use ONLY the facts provided. If the facts do not contain the answer, say you don't know.`
+
+func poiFacts(p *scraper.PropertyInfo) string {
+	var b strings.Builder
+	for _, poi := range allPOIs(p) {
+		fmt.Fprintf(&b, "\nNearby %s: %s, %.1f km", strings.ReplaceAll(poi.Type, "_", " "), poi.Name, poi.Distance)
+	}
+	return b.String()
+}
+
+func allPOIs(p *scraper.PropertyInfo) []scraper.POI {
+	var pois []scraper.POI
+	pois = append(pois, p.QualityOfLife.PublicTransport...)
+	pois = append(pois, p.QualityOfLife.Amenities...)
+	pois = append(pois, p.QualityOfLife.Entertainment...)
+	pois = append(pois, p.SafetyInfo.NearbyGardai...)
+	return pois
+}
+
+func answerByRules(a *enrich.Analyzer, p *scraper.PropertyInfo, question string) string {
+	q := strings.ToLower(question)
+
+	if m := nearestPattern.FindStringSubmatch(q); m != nil {
+		if answer := answerNearest(p, m[1]); answer != "" {
+			return answer
+		}
+	}
+
+	switch {
+	case strings.Contains(q, "rpz") || strings.Contains(q, "rent pressure"):
+		return "Yes. Since 20 June 2025 the whole of Ireland is a Rent Pressure Zone, so rent increases are capped at the lower of inflation or 2% a year."
+	case berQuestion.MatchString(q):
+		if p.BER == "" {
+			return "The listing doesn't state a BER."
+		}
+		return fmt.Sprintf("The BER is %s.", p.BER)
+	case strings.Contains(q, "service charge") || strings.Contains(q, "management fee"):
+		if p.ServiceCharge == nil || p.ServiceCharge.Annual == 0 {
+			return "The listing doesn't state a service charge."
+		}
+		return fmt.Sprintf("The service charge is about €%.0f a year (€%.0f a month).", p.ServiceCharge.Annual, p.ServiceCharge.Monthly)
+	case strings.Contains(q, "safe") || strings.Contains(q, "crime"):
+		if p.SafetyInfo.SafetyRating == 0 {
+			return ""
+		}
+		return fmt.Sprintf("The safety rating is %d/10, with %.4f recorded crimes per capita in the area.", p.SafetyInfo.SafetyRating, p.SafetyInfo.CrimeRate)
+	case strings.Contains(q, "walk"):
+		if p.QualityOfLife.WalkScore == 0 {
+			return ""
+		}
+		return fmt.Sprintf("The walk score is %d/100.", p.QualityOfLife.WalkScore)
+	case strings.Contains(q, "bedroom"):
+		if p.Bedrooms == "" {
+			return ""
+		}
+		return fmt.Sprintf("It has %s.", p.Bedrooms)
+	case strings.Contains(q, "bathroom"):
+		if p.Bathrooms == "" {
+			return ""
+		}
+		return fmt.Sprintf("It has %s.", p.Bathrooms)
+	case strings.Contains(q, "red flag") || strings.Contains(q, "scam"):
+		flags := a.SummarizeProperty(p).RedFlags
+		if len(flags) == 0 {
+			return "No red flags were found in this listing."
+		}
+		notes := make([]string, len(flags))
+		for i, f := range flags {
+			notes[i] = f.Note
+		}
+		return "Red flags: " + strings.Join(notes, "; ") + "."
+	case strings.Contains(q, "price") || strings.Contains(q, "rent") || strings.Contains(q, "how much") || strings.Contains(q, "cost"):
+		answer := fmt.Sprintf("It's listed at %s.", p.RentPrice)
+		if p.ValueAnalysis.AreaAveragePrice > 0 {
+			answer += fmt.Sprintf(" The area average for similar listings is €%.0f.", p.ValueAnalysis.AreaAveragePrice)
+		}
+		return answer
+	}
+	return ""
+}
+
+// answerNearest procura o POI mais próximo cujo nome ou tipo casa com o alvo da pergunta
+func answerNearest(p *scraper.PropertyInfo, target string) string {
+	var words []string
+	for _, w := range strings.Fields(target) {
+		if !askStopwords[w] {
+			words = append(words, w)
+		}
+	}
+	if len(words) == 0 {
+		return ""
+	}
+	phrase := strings.Join(words, " ")
+
+	var best *scraper.POI
+	for _, poi := range allPOIs(p) {
+		if !poiMatches(poi, phrase, words) {
+			continue
+		}
+		if best == nil || poi.Distance < best.Distance {
+			poi := poi
+			best = &poi
+		}
+	}
+	if best == nil {
+		return fmt.Sprintf("I didn't find a %s near this property in the analysis.", phrase)
+	}
+	return fmt.Sprintf("The nearest %s is %s, %.1f km away (about %d minutes' walk).", phrase, best.Name, best.Distance, best.Duration)
+}
+
+func poiMatches(poi scraper.POI, phrase string, words []string) bool {
+	name := strings.ToLower(poi.Name)
+	if strings.Contains(name, phrase) {
+		return true
+	}
+	for _, w := range words {
+		singular := strings.TrimSuffix(w, "s")
+		if t, ok := poiAliases[w]; ok && poi.Type == t {
+			return true
+		}
+		if t, ok := poiAliases[singular]; ok && poi.Type == t {
+			return true
+		}
+		if len(singular) >= 3 && strings.Contains(poi.Type, singular) {
+			return true
+		}
+	}
+	return false
+}
+
+// askRequest é o corpo de POST /analyses/{id}/ask
+type askRequest struct {
+	Question string `json:"question"`
+}
+
+// handleAsk é o handler HTTP para POST /analyses/{id}/ask {"question": "..."}
+func handleAsk(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+
+	var requestBody askRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(requestBody.Question) == "" {
+		writeError(w, http.StatusBadRequest, "question is required in the request body")
+		return
+	}
+
+	var (
+		property scraper.PropertyInfo
+		found    bool
+	)
+	store.View(func(d *storeData) {
+		if a, ok := d.Analyses[id]; ok && a.visibleTo(r.Context()) {
+			property, found = a.Property, true
+		}
+	})
+	if !found {
+		writeError(w, http.StatusNotFound, "Analysis not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(answerQuestion(r.Context(), &property, requestBody.Question))
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestAnswerByRules(t *testing.T) {
+	p := &scraper.PropertyInfo{RentPrice: "€2,100 per month", BER: "C1"}
+	p.QualityOfLife.Amenities = []scraper.POI{
+		{Name: "Tesco Express", Type: "supermarket", Distance: 0.4, Duration: 5},
+		{Name: "Lidl Rathmines", Type: "supermarket", Distance: 0.9, Duration: 11},
+	}
+	p.QualityOfLife.PublicTransport = []scraper.POI{{Name: "Ranelagh", Type: "light_rail_station", Distance: 0.7, Duration: 9}}
+
+	cases := []struct {
+		question string
+		want     string
+	}{
+		{"How far is the nearest Lidl?", "Lidl Rathmines, 0.9 km"},
+		{"where's the closest supermarket", "Tesco Express, 0.4 km"},
+		{"nearest luas stop?", "Ranelagh"},
+		{"Is it in an RPZ?", "Rent Pressure Zone"},
+		{"what's the BER", "C1"},
+		{"how much is the rent", "€2,100 per month"},
+		{"nearest gym", "didn't find"},
+	}
+	for _, c := range cases {
+		got := answerByRules((&enrich.Analyzer{}), p, c.question)
+		if !strings.Contains(got, c.want) {
+			t.Errorf("(&Analyzer{}).answerByRules(%q) = %q, want it to contain %q", c.question, got, c.want)
+		}
+	}
+
+	if got := answerQuestion(context.Background(), p, "does the landlord like cats?"); got.Source != "none" {
+		t.Errorf("expected no answer without LLM, got %+v", got)
+	}
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"crypto/subtle"
+	"os"
+	"strings"
+)
+
+// apiUsers mapeia token → usuário, de API_TOKENS; carregado em setup()
+var apiUsers = map[string]string{}
+
+// apiUsersFromEnv lê API_TOKENS ("alice:token1,bob:token2") e devolve token → usuário
+func apiUsersFromEnv() map[string]string {
+	users := map[string]string{}
+	for _, pair := range strings.Split(os.Getenv("API_TOKENS"), ",") {
+		user, token, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if ok && user != "" && token != "" {
+			users[token] = user
+		}
+	}
+	return users
+}
+
+// authenticate identifica o usuário pelo header "Authorization: Bearer <token>" ou "X-API-Key"
+func authenticate(authorization, apiKey string) (string, bool) {
+	token := apiKey
+	if strings.HasPrefix(authorization, "Bearer ") {
+		token = strings.TrimPrefix(authorization, "Bearer ")
+	}
+	if token == "" {
+		return "", false
+	}
+
+	for t, user := range apiUsers {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return user, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,29 @@
+package server
+
+import "testing"
+
+// useAPITokens carrega os usuários de raw (formato de API_TOKENS) durante o teste
+func useAPITokens(t *testing.T, raw string) {
+	t.Helper()
+	t.Setenv("API_TOKENS", raw)
+	prev := apiUsers
+	apiUsers = apiUsersFromEnv()
+	t.Cleanup(func() { apiUsers = prev })
+}
+
+func TestAuthenticate(t *testing.T) {
+	useAPITokens(t, "alice:tok-a, bob:tok-b,broken")
+	for _, c := range []struct {
+		authorization, apiKey, user string
+		ok                          bool
+	}{
+		{"Bearer tok-a", "", "alice", true},
+		{"", "tok-b", "bob", true},
+		{"Bearer nope", "", "", false},
+		{"", "", "", false},
+	} {
+		if user, ok := authenticate(c.authorization, c.apiKey); user != c.user || ok != c.ok {
+			t.Errorf("authenticate(%q, %q) = %q, %v", c.authorization, c.apiKey, user, ok)
+		}
+	}
+}
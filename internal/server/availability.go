@@ -0,0 +1,189 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Disponibilidade dos anúncios analisados e velocidade de locação ── */
+
+// availabilityCheckInterval é o intervalo entre verificações de cada análise guardada
+// (AVAILABILITY_INTERVAL). Anúncios mais velhos que availabilityMaxAge deixam de ser
+// verificados, e cada rodada verifica no máximo availabilityBatch anúncios.
+func availabilityCheckInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("AVAILABILITY_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return 24 * time.Hour
+}
+
+const (
+	availabilityMaxAge = 180 * 24 * time.Hour
+	availabilityBatch  = 50
+
+	// limite dos dias no ar na média (sensibilidade do ruído)
+	letSpeedMaxDays = 180
+)
+
+// LetSpeed resume quanto tempo os anúncios alugados ou vendidos de uma área ficaram no ar
+type LetSpeed struct {
+	Area        string           `json:"area"`
+	ListingType string           `json:"listingType"`
+	Listings    int              `json:"listings"` // anúncios alugados ou vendidos com data conhecida
+	AverageDays float64          `json:"averageDays"`
+	Privacy     aggregatePrivacy `json:"privacy"`
+}
+
+// runAvailabilityMonitor verifica periodicamente se os anúncios analisados ainda estão no ar
+func runAvailabilityMonitor() {
+	for {
+		checkAvailability(time.Now())
+		if !sleepUnlessStopping(time.Hour) {
+			return
+		}
+	}
+}
+
+// checkAvailability verifica as análises ativas cuja última checagem venceu
+func checkAvailability(now time.Time) {
+	type due struct {
+		id, url string
+		checked time.Time
+	}
+	var pending []due
+	interval := availabilityCheckInterval()
+	store.View(func(d *storeData) {
+		for id, a := range d.Analyses {
+			if a.EndedAt != nil || now.Sub(a.FirstAnalyzedAt) > availabilityMaxAge {
+				continue
+			}
+			checked := a.CheckedAt
+			if checked.IsZero() {
+				checked = a.AnalyzedAt
+			}
+			if now.Sub(checked) >= interval {
+				pending = append(pending, due{id, a.URL, checked})
+			}
+		}
+	})
+	// os verificados há mais tempo primeiro
+	sort.Slice(pending, func(i, j int) bool { return pending[i].checked.Before(pending[j].checked) })
+	if len(pending) > availabilityBatch {
+		pending = pending[:availabilityBatch]
+	}
+
+	for _, p := range pending {
+		if stopping.Err() != nil {
+			return
+		}
+		property, err := scraper.ScrapeDaftListing(serverContext, p.url)
+		reason := ""
+		switch {
+		case errors.Is(err, scraper.ErrListingNotFound):
+			reason = "removed"
+		case err != nil:
+			slog.Warn("Availability check failed", "url", p.url, "error", err)
+			continue
+		case property.Availability != "":
+			reason = property.Availability
+		}
+		if reason != "" {
+			slog.Info("Listing ended", "url", p.url, "reason", reason)
+		}
+		markAvailability(p.id, reason, time.Now())
+	}
+}
+
+// markAvailability registra a checagem e, se reason não for vazio, encerra (tombstone)
+// a análise com a data de fim
+func markAvailability(id, reason string, at time.Time) {
+	err := store.Update(func(d *storeData) error {
+		a, ok := d.Analyses[id]
+		if !ok {
+			return nil
+		}
+		a.CheckedAt = at
+		if reason != "" && a.EndedAt == nil {
+			a.EndedAt = &at
+			a.EndReason = reason
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Warn("Could not save availability", "id", id, "error", err)
+	}
+}
+
+// daysListed devolve quantos dias o anúncio ficou no ar até ser alugado ou vendido; o
+// início é a data de publicação no Daft.ie ou, sem ela, a primeira análise. Anúncios
+// só removidos (retirados, não necessariamente alugados) não contam.
+func daysListed(a *StoredAnalysis) (float64, bool) {
+	if a.EndedAt == nil || (a.EndReason != "let_agreed" && a.EndReason != "sale_agreed") {
+		return 0, false
+	}
+	start := a.FirstAnalyzedAt
+	if a.Property.PublishedAt != nil && a.Property.PublishedAt.Before(start) {
+		start = *a.Property.PublishedAt
+	}
+	days := a.EndedAt.Sub(start).Hours() / 24
+	if days < 0 {
+		return 0, false
+	}
+	return math.Min(days, letSpeedMaxDays), true
+}
+
+// handleLetSpeed é o handler HTTP para GET /market/let-speed?area=...&type=rent|sale
+func handleLetSpeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	area := scraper.Slugify(r.URL.Query().Get("area"))
+	if area == "" {
+		writeError(w, http.StatusBadRequest, "area query parameter is required")
+		return
+	}
+	kind := r.URL.Query().Get("type")
+	if kind == "" {
+		kind = "rent"
+	}
+	if _, ok := priceBounds[kind]; !ok {
+		writeError(w, http.StatusBadRequest, "type must be rent, share or sale")
+		return
+	}
+
+	writePrivateAggregate(w, r, "let-speed:"+kind+":"+area, func(privacy aggregatePrivacy) interface{} {
+		var days []float64
+		store.View(func(d *storeData) {
+			for _, a := range d.Analyses {
+				t := a.Property.ListingType
+				if t == "" {
+					t = scraper.ListingType(a.URL)
+				}
+				suburb, county := scraper.SplitLocation(a.Property.Address)
+				if t != kind || (scraper.Slugify(suburb) != area && scraper.Slugify(county) != area) {
+					continue
+				}
+				if n, ok := daysListed(a); ok {
+					days = append(days, n)
+				}
+			}
+		})
+
+		// o orçamento é dividido entre a contagem e a soma
+		eps := privacy.Epsilon / 2
+		speed := LetSpeed{Area: area, ListingType: kind, Privacy: privacy}
+		if count, publishable := privacy.count(len(days), eps); publishable {
+			speed.Listings = count
+			speed.AverageDays = math.Round(privacy.mean(days, letSpeedMaxDays, count, eps)*10) / 10
+		}
+		return speed
+	})
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestListingAvailability(t *testing.T) {
+	cases := []struct {
+		label, state, kind, want string
+	}{
+		{"LET_AGREED", "", "rent", "let_agreed"},
+		{"Sale Agreed", "", "sale", "sale_agreed"},
+		{"", "AGREED", "sale", "sale_agreed"},
+		{"", "AGREED", "share", "let_agreed"},
+		{"NEW", "PUBLISHED", "rent", ""},
+	}
+	for _, c := range cases {
+		l := scraper.DaftListing{Label: c.label, State: c.state}
+		if got := l.Availability(c.kind); got != c.want {
+			t.Errorf("availability(%q, %q, %q) = %q, want %q", c.label, c.state, c.kind, got, c.want)
+		}
+	}
+}
+
+func TestLetSpeed(t *testing.T) {
+	prev := store
+	store = newMemoryStore()
+	t.Cleanup(func() { store = prev })
+	t.Setenv("MARKET_PRIVACY", "off")
+
+	now := time.Now()
+	for i, days := range []int{6, 10, 14} {
+		url := "https://www.daft.ie/for-rent/apartment/" + string(rune('1'+i))
+		recordAnalysis(context.Background(), &scraper.PropertyInfo{URL: url, Address: "1 Main St, Rathmines, Dublin 6", ListingType: "rent"})
+		published := now.Add(-time.Duration(days) * 24 * time.Hour)
+		store.Update(func(d *storeData) error {
+			d.Analyses[analysisKey(url)].Property.PublishedAt = &published
+			return nil
+		})
+		markAvailability(analysisKey(url), "let_agreed", now)
+	}
+	// withdrawn, not necessarily let: not part of the average
+	withdrawn := "https://www.daft.ie/for-rent/apartment/8"
+	recordAnalysis(context.Background(), &scraper.PropertyInfo{URL: withdrawn, Address: "3 Main St, Rathmines, Dublin 6", ListingType: "rent"})
+	markAvailability(analysisKey(withdrawn), "removed", now.Add(24*time.Hour))
+	// still live: not part of the average
+	recordAnalysis(context.Background(), &scraper.PropertyInfo{URL: "https://www.daft.ie/for-rent/apartment/9", Address: "2 Main St, Rathmines, Dublin 6", ListingType: "rent"})
+
+	rec := httptest.NewRecorder()
+	handleLetSpeed(rec, httptest.NewRequest("GET", "/market/let-speed?area=rathmines", nil))
+	var speed LetSpeed
+	json.NewDecoder(rec.Body).Decode(&speed)
+	if speed.Listings != 3 || speed.AverageDays != 10 {
+		t.Errorf("unexpected let speed: %+v", speed)
+	}
+}
@@ -0,0 +1,329 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Análise em lote (JSON, CSV ou NDJSON) ───────────────────────── */
+
+const (
+	maxBatchURLs     = 25
+	batchConcurrency = 2
+)
+
+// BatchResult é o resultado de um anúncio dentro de uma análise em lote
+// Montado pelo worker do anúncio e entregue pronto a analyzeBatchEach.
+type BatchResult struct {
+	URL      string                `json:"url"`
+	Status   string                `json:"status,omitempty"` // situação do job nos lotes assíncronos
+	Property *scraper.PropertyInfo `json:"property,omitempty"`
+	Error    *scraper.APIError     `json:"error,omitempty"`
+}
+
+// BatchJob é a resposta dos lotes assíncronos (POST /analyze/batch?async=true e
+// GET /analyze/batch/{id}); Done fica true quando nenhum job do lote está na fila
+type BatchJob struct {
+	BatchID string        `json:"batchId"`
+	Done    bool          `json:"done"`
+	Results []BatchResult `json:"results"`
+}
+
+// batchCSVHeader lista as colunas do CSV, uma linha por imóvel
+var batchCSVHeader = []string{
+	"url", "address", "price", "bedrooms", "bathrooms", "property_type",
+	"overall_score", "safety_rating", "transport_score", "walk_score", "price_rating",
+	"area_average_price", "price_per_sqm", "nearest_station_km", "crime_per_capita", "error",
+}
+
+// batchRequest é o corpo de POST /analyze/batch
+type batchRequest struct {
+	URLs []string `json:"urls"`
+}
+
+// handleAnalyzeBatch é o handler HTTP para POST /analyze/batch. Responde CSV com
+// Accept: text/csv ou ?format=csv; NDJSON com Accept: application/x-ndjson ou
+// ?format=ndjson, uma linha por anúncio assim que ele termina; caso contrário JSON.
+// Com ?async=true o lote vai para a fila de jobs e a resposta traz o id para
+// acompanhar em /analyze/batch/{id}.
+func handleAnalyzeBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+
+	var requestBody batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(requestBody.URLs) == 0 {
+		writeError(w, http.StatusBadRequest, "urls is required in the request body")
+		return
+	}
+	if len(requestBody.URLs) > maxBatchURLs {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %d urls per batch", maxBatchURLs))
+		return
+	}
+
+	if isAsyncBatch(r) {
+		enqueueBatch(w, r, requestBody.URLs)
+		return
+	}
+
+	telemetry.LogFor(r.Context()).Info("Batch analysis requested", "listings", len(requestBody.URLs))
+	if wantsNDJSON(r) {
+		streamBatch(w, r, requestBody.URLs)
+		return
+	}
+	results := analyzeBatch(r.Context(), requestBody.URLs)
+	calls := 0
+	for _, res := range results {
+		if res.Property != nil {
+			calls += res.Property.MapsCalls()
+		}
+	}
+	chargeMapsCalls(w, r, calls)
+
+	if wantsCSV(r) {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="analysis.csv"`)
+		if err := writeBatchCSV(analyzerFor(r.Context()), w, results); err != nil {
+			telemetry.LogFor(r.Context()).Warn("Could not write the batch CSV", "error", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// streamBatch escreve cada resultado como uma linha JSON, na ordem em que os
+// anúncios terminam (a url de cada linha diz de qual anúncio ela é)
+func streamBatch(w http.ResponseWriter, r *http.Request, urls []string) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	var mu sync.Mutex
+	calls := 0
+	analyzeBatchEach(r.Context(), urls, func(_ int, res BatchResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		if res.Property != nil {
+			calls += res.Property.MapsCalls()
+		}
+		if err := enc.Encode(res); err != nil {
+			return // o cliente foi embora; o contexto cancela o resto do lote
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	})
+	// os cabeçalhos já foram enviados, então X-Maps-Calls não sai, mas a cota é cobrada
+	chargeMapsCalls(w, r, calls)
+}
+
+// enqueueBatch põe um job de análise por URL na fila, todos com o mesmo id de lote
+func enqueueBatch(w http.ResponseWriter, r *http.Request, urls []string) {
+	batchID := newID()
+	for _, u := range urls {
+		if _, err := enqueueJob(r.Context(), jobAnalyze, u, batchID); err != nil {
+			telemetry.LogFor(r.Context()).Error("Could not queue the batch", "error", err)
+			writeError(w, http.StatusInternalServerError, "Could not queue the batch")
+			return
+		}
+	}
+	telemetry.LogFor(r.Context()).Info("Batch analysis queued", "batch_id", batchID, "listings", len(urls))
+
+	batch, err := batchStatus(r.Context(), batchID)
+	if err != nil {
+		telemetry.LogFor(r.Context()).Error("Could not read the batch", "error", err)
+		writeError(w, http.StatusInternalServerError, "Could not read the batch")
+		return
+	}
+	w.Header().Set("Location", "/analyze/batch/"+batchID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(batch)
+}
+
+// handleBatchStatus é o handler de GET /analyze/batch/{id}: a situação de cada anúncio
+// do lote e, dos concluídos, a análise guardada
+func handleBatchStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	batch, err := batchStatus(r.Context(), strings.Trim(strings.TrimPrefix(r.URL.Path, "/analyze/batch/"), "/"))
+	if err != nil {
+		telemetry.LogFor(r.Context()).Error("Could not read the batch", "error", err)
+		writeError(w, http.StatusInternalServerError, "Could not read the batch")
+		return
+	}
+	if len(batch.Results) == 0 {
+		writeError(w, http.StatusNotFound, "Batch not found")
+		return
+	}
+
+	if wantsCSV(r) {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="analysis.csv"`)
+		if err := writeBatchCSV(analyzerFor(r.Context()), w, batch.Results); err != nil {
+			telemetry.LogFor(r.Context()).Warn("Could not write the batch CSV", "error", err)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(batch)
+}
+
+// batchStatus monta o lote do tenant da requisição a partir dos jobs, na ordem em
+// que entraram
+func batchStatus(ctx context.Context, batchID string) (BatchJob, error) {
+	batch := BatchJob{BatchID: batchID, Done: true, Results: []BatchResult{}}
+	jobs, err := jobQueue.batch(batchID, tenantID(ctx))
+	if err != nil {
+		return batch, err
+	}
+	store.View(func(d *storeData) {
+		for _, job := range jobs {
+			res := BatchResult{URL: job.Target, Status: job.Status, Error: job.LastError}
+			if job.Status == "done" {
+				if a, ok := d.Analyses[analysisKeyFor(job.Tenant, job.Target)]; ok {
+					property := a.Property
+					res.Property, res.Error = &property, property.Error
+				}
+			}
+			batch.Done = batch.Done && job.finished()
+			batch.Results = append(batch.Results, res)
+		}
+	})
+	return batch, nil
+}
+
+// analyzeBatch analisa as URLs com concorrência limitada, preservando a ordem
+func analyzeBatch(ctx context.Context, urls []string) []BatchResult {
+	results := make([]BatchResult, len(urls))
+	analyzeBatchEach(ctx, urls, func(i int, res BatchResult) { results[i] = res })
+	return results
+}
+
+// analyzeBatchEach analisa as URLs com concorrência limitada e chama done (de várias
+// goroutines) com o índice e o resultado de cada uma assim que ela termina
+func analyzeBatchEach(ctx context.Context, urls []string, done func(i int, res BatchResult)) {
+	ctx = scraper.WithCrawlBudget(ctx) // um orçamento de páginas para o lote inteiro
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+
+	for i, u := range urls {
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			res := BatchResult{URL: u}
+			property, err := analyzeListing(ctx, u)
+			if err != nil {
+				_, res.Error = scrapeError(err)
+			} else {
+				res.Property, res.Error = &property, property.Error
+			}
+			done(i, res)
+		}(i, u)
+	}
+	wg.Wait()
+}
+
+// wantsCSV decide o formato pela query (?format=csv) ou pelo cabeçalho Accept
+func wantsCSV(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return strings.EqualFold(format, "csv")
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/csv")
+}
+
+// wantsNDJSON decide pelo streaming em NDJSON (?format=ndjson ou Accept)
+func wantsNDJSON(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return strings.EqualFold(format, "ndjson")
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/x-ndjson")
+}
+
+// writeBatchCSV achata as métricas principais de cada imóvel numa linha
+func writeBatchCSV(a *enrich.Analyzer, w io.Writer, results []BatchResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(batchCSVHeader); err != nil {
+		return err
+	}
+	for _, res := range results {
+		if err := cw.Write(batchCSVRow(a, res)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func batchCSVRow(a *enrich.Analyzer, res BatchResult) []string {
+	p := res.Property
+	if p == nil {
+		row := make([]string, len(batchCSVHeader))
+		row[0], row[len(row)-1] = res.URL, errorCode(res.Error)
+		return row
+	}
+
+	nearestStation := ""
+	if top := scraper.NearestPOIs(p.QualityOfLife.PublicTransport, 1); len(top) > 0 {
+		nearestStation = formatFloat(top[0].Distance)
+	}
+
+	return []string{
+		res.URL,
+		p.Address,
+		formatFloat(scraper.ExtractPriceValue(p.RentPrice)),
+		p.Bedrooms,
+		p.Bathrooms,
+		p.PropertyType,
+		strconv.Itoa(a.OverallScore(p)),
+		strconv.Itoa(p.SafetyInfo.SafetyRating),
+		strconv.Itoa(p.QualityOfLife.TransportScore),
+		strconv.Itoa(p.QualityOfLife.WalkScore),
+		strconv.Itoa(p.ValueAnalysis.PriceRating),
+		formatFloat(p.ValueAnalysis.AreaAveragePrice),
+		formatFloat(p.ValueAnalysis.PricePerSqm),
+		nearestStation,
+		formatFloat(p.SafetyInfo.CrimeRate),
+		errorCode(res.Error),
+	}
+}
+
+// errorCode devolve o código do erro para a coluna do CSV ("" se não houver)
+func errorCode(err *scraper.APIError) string {
+	if err == nil {
+		return ""
+	}
+	return err.Code
+}
+
+// formatFloat escreve números sem notação científica; zero vira célula vazia
+func formatFloat(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestWriteBatchCSV(t *testing.T) {
+	property := &scraper.PropertyInfo{Address: "1 Main St, Dublin 1", RentPrice: "€2,150 per month", Bedrooms: "2 bed"}
+	property.SafetyInfo.SafetyRating = 7
+	property.SafetyInfo.CrimeRate = 0.0125
+	property.QualityOfLife.PublicTransport = []scraper.POI{{Name: "Far", Distance: 1.2}, {Name: "Near", Distance: 0.35}}
+
+	var b strings.Builder
+	err := writeBatchCSV((&enrich.Analyzer{}), &b, []BatchResult{
+		{URL: "https://www.daft.ie/for-rent/a/1", Property: property},
+		{URL: "https://www.daft.ie/for-rent/b/2", Error: &scraper.APIError{Code: "LISTING_NOT_FOUND", Message: "The listing was removed from Daft.ie"}},
+	})
+	if err != nil {
+		t.Fatalf("writeBatchCSV: %v", err)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(b.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected header + 2 rows, got %d", len(rows))
+	}
+	col := map[string]int{}
+	for i, name := range rows[0] {
+		col[name] = i
+	}
+	if got := rows[1][col["price"]]; got != "2150" {
+		t.Errorf("price = %q", got)
+	}
+	if got := rows[1][col["nearest_station_km"]]; got != "0.35" {
+		t.Errorf("nearest_station_km = %q", got)
+	}
+	if got := rows[1][col["crime_per_capita"]]; got != "0.0125" {
+		t.Errorf("crime_per_capita = %q", got)
+	}
+	if got := rows[2][col["error"]]; got != "LISTING_NOT_FOUND" {
+		t.Errorf("error = %q", got)
+	}
+}
+
+func TestWantsCSV(t *testing.T) {
+	r := httptest.NewRequest("POST", "/analyze/batch?format=csv", nil)
+	if !wantsCSV(r) {
+		t.Error("expected CSV for ?format=csv")
+	}
+	r = httptest.NewRequest("POST", "/analyze/batch", nil)
+	r.Header.Set("Accept", "text/csv")
+	if !wantsCSV(r) {
+		t.Error("expected CSV for Accept: text/csv")
+	}
+	r = httptest.NewRequest("POST", "/analyze/batch?format=json", nil)
+	r.Header.Set("Accept", "text/csv")
+	if wantsCSV(r) {
+		t.Error("?format should take precedence over Accept")
+	}
+}
+
+func TestAnalyzeBatchStreamsNDJSON(t *testing.T) {
+	useJobStore(t)
+	urls := []string{"https://www.daft.ie/for-rent/a/1", "https://www.daft.ie/for-rent/b/2"}
+	for i, u := range urls {
+		recordAnalysis(context.Background(), &scraper.PropertyInfo{URL: u, Address: fmt.Sprintf("%d Main St", i+1)})
+	}
+
+	body := `{"urls":["` + strings.Join(urls, `","`) + `"]}`
+	req := httptest.NewRequest(http.MethodPost, "/analyze/batch", strings.NewReader(body))
+	req.Header.Set("Accept", "application/x-ndjson")
+	rec := httptest.NewRecorder()
+	handleAnalyzeBatch(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	if !rec.Flushed {
+		t.Error("each line should be flushed as soon as it is written")
+	}
+	if lines := strings.Count(rec.Body.String(), "\n"); lines != 2 {
+		t.Errorf("got %d lines, want one per listing", lines)
+	}
+	seen := map[string]string{}
+	dec := json.NewDecoder(rec.Body)
+	for dec.More() {
+		var res BatchResult
+		if err := dec.Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Property != nil {
+			seen[res.URL] = res.Property.Address
+		}
+	}
+	if len(seen) != 2 || seen[urls[0]] != "1 Main St" || seen[urls[1]] != "2 Main St" {
+		t.Errorf("streamed %v", seen)
+	}
+}
+
+func TestWantsNDJSON(t *testing.T) {
+	r := httptest.NewRequest("POST", "/analyze/batch?format=ndjson", nil)
+	if !wantsNDJSON(r) {
+		t.Error("expected NDJSON for ?format=ndjson")
+	}
+	r = httptest.NewRequest("POST", "/analyze/batch", nil)
+	r.Header.Set("Accept", "application/x-ndjson")
+	if !wantsNDJSON(r) || wantsCSV(r) {
+		t.Error("expected NDJSON (and not CSV) for Accept: application/x-ndjson")
+	}
+	r = httptest.NewRequest("POST", "/analyze/batch?format=csv", nil)
+	r.Header.Set("Accept", "application/x-ndjson")
+	if wantsNDJSON(r) {
+		t.Error("?format should take precedence over Accept")
+	}
+}
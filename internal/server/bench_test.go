@@ -0,0 +1,241 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"testing"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/value"
+
+	"googlemaps.github.io/maps"
+)
+
+// Benchmarks for the hot paths of the pipeline. Everything is fixture-driven and never
+// touches the network, so results are comparable release over release:
+//
+//	go test -run '^$' -bench . -benchmem -count 10 ./... > new.txt
+//	benchstat old.txt new.txt
+//
+// The BenchmarkLoad* functions drive the HTTP handlers with a warm cache in parallel
+// and act as the load-test harness (use -cpu 1,4,8 to vary concurrency).
+
+// benchSeed keeps the synthetic fixtures identical between runs
+const benchSeed = 42
+
+var benchOrigin = maps.LatLng{Lat: 53.3331, Lng: -6.2489}
+
+// benchPlaces generates n places scattered up to ~2 km around benchOrigin
+func benchPlaces(n int) []maps.PlacesSearchResult {
+	rng := rand.New(rand.NewSource(benchSeed))
+	types := []string{"train_station", "bus_station", "supermarket", "restaurant", "bar", "park"}
+	places := make([]maps.PlacesSearchResult, n)
+	for i := range places {
+		places[i].Name = fmt.Sprintf("Place %d", i)
+		places[i].Types = []string{types[i%len(types)]}
+		places[i].Geometry.Location = maps.LatLng{
+			Lat: benchOrigin.Lat + (rng.Float64()-0.5)*0.036,
+			Lng: benchOrigin.Lng + (rng.Float64()-0.5)*0.06,
+		}
+	}
+	return places
+}
+
+// benchProperty builds a fully enriched analysis with hundreds of POIs, like a busy
+// Dublin listing
+func benchProperty() scraper.PropertyInfo {
+	p := scraper.PropertyInfo{
+		URL:          "https://www.daft.ie/for-rent/apartment-1-main-street-dublin-6/5123456",
+		Address:      "Apartment 1, Main Street, Ranelagh, Dublin 6",
+		RentPrice:    "€2,350 per month",
+		Bedrooms:     "2 Bed",
+		Bathrooms:    "2 Bath",
+		PropertyType: "Apartment",
+		ListingType:  "rent",
+		BER:          "C2",
+		Description: "Bright two bedroom apartment close to the Luas, with a balcony, dishwasher and " +
+			"secure parking. No pets. Deposit required before viewing. Fully furnished, gas heating, " +
+			"fibre broadband available. Professionals only, references required.",
+	}
+	p.Coordinates.Lat, p.Coordinates.Lng = benchOrigin.Lat, benchOrigin.Lng
+	p.SafetyInfo.SafetyRating = 7
+	p.SafetyInfo.StreetLighting = "42 street lights within 300m"
+	p.DescriptionAnalysis = scraper.AnalyzeDescription(p.Description)
+
+	places := benchPlaces(600)
+	p.QualityOfLife.PublicTransport = enrich.PlacesToPOIs(&benchOrigin, places[:100], "")
+	p.QualityOfLife.Amenities = enrich.PlacesToPOIs(&benchOrigin, places[100:350], "supermarket")
+	p.QualityOfLife.Entertainment = enrich.PlacesToPOIs(&benchOrigin, places[350:], "restaurant")
+	p.QualityOfLife.TransportScore = 9
+	(&enrich.Analyzer{}).CalculateWalkScore(&p)
+
+	for i := 0; i < 40; i++ {
+		p.ValueAnalysis.Similar = append(p.ValueAnalysis.Similar, scraper.SimilarProperty{
+			Address: fmt.Sprintf("%d Main Street, Dublin 6", i),
+			Price:   2000 + float64(i*25),
+			URL:     fmt.Sprintf("https://www.daft.ie/for-rent/x/%d", 6000000+i),
+			Source:  "daft.ie",
+		})
+	}
+	value.CalculateAreaAveragePrice(&p)
+	value.CalculatePriceRating(&p)
+	p.Checklist = enrich.ViewingChecklist(&p)
+	return p
+}
+
+// quietLogs silences the pipeline's logging for the duration of the benchmark
+func quietLogs(b *testing.B) {
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+/* Parsing */
+
+func BenchmarkParseSearchListings(b *testing.B) {
+	html, err := os.ReadFile("debug_similar_response.html")
+	if err != nil {
+		b.Skipf("fixture not available: %v", err)
+	}
+	m := regexp.MustCompile(`(?s)<script id="__NEXT_DATA__"[^>]*>(.*?)</script>`).FindSubmatch(html)
+	if m == nil {
+		b.Fatal("fixture has no __NEXT_DATA__ script")
+	}
+	b.SetBytes(int64(len(m[1])))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseSearchListings(m[1]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAnalyzeDescription(b *testing.B) {
+	description := benchProperty().Description
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		scraper.AnalyzeDescription(description)
+	}
+}
+
+/* Distances and scoring */
+
+func BenchmarkCalculateDistance(b *testing.B) {
+	places := benchPlaces(1)
+	to := places[0].Geometry.Location
+	for i := 0; i < b.N; i++ {
+		scraper.CalculateDistance(benchOrigin.Lat, benchOrigin.Lng, to.Lat, to.Lng)
+	}
+}
+
+func BenchmarkPlacesToPOIs(b *testing.B) {
+	for _, n := range []int{20, 200, 1000} {
+		places := benchPlaces(n)
+		b.Run(fmt.Sprintf("places=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				enrich.PlacesToPOIs(&benchOrigin, places, "")
+			}
+		})
+	}
+}
+
+func BenchmarkScoring(b *testing.B) {
+	p := benchProperty()
+	b.Run("walkScore", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			(&enrich.Analyzer{}).CalculateWalkScore(&p)
+		}
+	})
+	b.Run("summary", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			(&enrich.Analyzer{}).SummarizeProperty(&p)
+		}
+	})
+	b.Run("checklist", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			enrich.ViewingChecklist(&p)
+		}
+	})
+}
+
+/* JSON encoding */
+
+func BenchmarkEncodeProperty(b *testing.B) {
+	p := benchProperty()
+	raw, _ := json.Marshal(p)
+	b.SetBytes(int64(len(raw)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	var buf bytes.Buffer
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if err := json.NewEncoder(&buf).Encode(p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodePropertyFields(b *testing.B) {
+	p := benchProperty()
+	req := httptest.NewRequest(http.MethodGet, "/analyze?fields=address,price,qualityOfLife.walkScore", nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		writeJSONFields(httptest.NewRecorder(), req, p)
+	}
+}
+
+/* Load harness: handlers with a warm cache, in parallel */
+
+func benchWarmCache(b *testing.B) scraper.PropertyInfo {
+	quietLogs(b)
+	store = newMemoryStore()
+	b.Cleanup(func() { store = newMemoryStore() })
+	p := benchProperty()
+	recordAnalysis(context.Background(), &p)
+	return p
+}
+
+func BenchmarkLoadAnalyzeCached(b *testing.B) {
+	p := benchWarmCache(b)
+	target := "/analyze?url=" + url.QueryEscape(p.URL)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rec := httptest.NewRecorder()
+			handleAnalyze(rec, httptest.NewRequest(http.MethodGet, target, nil))
+			if rec.Code != http.StatusOK {
+				b.Errorf("status = %d", rec.Code)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkLoadSummaryCached(b *testing.B) {
+	p := benchWarmCache(b)
+	body, _ := json.Marshal(map[string]string{"daftUrl": p.URL})
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rec := httptest.NewRecorder()
+			handleSummary(rec, httptest.NewRequest(http.MethodPost, "/summary", bytes.NewReader(body)))
+			if rec.Code != http.StatusOK {
+				b.Errorf("status = %d", rec.Code)
+				return
+			}
+		}
+	})
+}
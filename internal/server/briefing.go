@@ -0,0 +1,123 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Resumo falado para assistentes de voz ───────────────────────── */
+
+// briefingText monta ~4 frases curtas para serem lidas por um assistente de voz.
+// O texto não tem markup nem símbolos, então pode ser embutido direto em SSML.
+func briefingText(a *enrich.Analyzer, p *scraper.PropertyInfo) string {
+	var sentences []string
+
+	what := strings.TrimSpace(strings.ToLower(p.Bedrooms + " " + p.PropertyType))
+	if what == "" {
+		what = "property"
+	}
+	first := fmt.Sprintf("%s at %s", what, p.Address)
+	if price := spokenPrice(p.RentPrice); price != "" {
+		first += ", listed at " + price
+	}
+	sentences = append(sentences, strings.ToUpper(first[:1])+first[1:]+".")
+
+	s := a.SummarizeProperty(p)
+	scores := fmt.Sprintf("It scores %d out of 100 overall", s.OverallScore)
+	if s.Scores.Safety > 0 {
+		scores += fmt.Sprintf(", with a safety rating of %d out of 10", s.Scores.Safety)
+	}
+	sentences = append(sentences, scores+".")
+
+	if top := scraper.NearestPOIs(p.QualityOfLife.PublicTransport, 1); len(top) > 0 {
+		sentences = append(sentences, fmt.Sprintf("The nearest public transport is %s, a %d minute walk.", top[0].Name, top[0].Duration))
+	}
+
+	switch {
+	case len(s.RedFlags) > 0:
+		sentences = append(sentences, "Watch out: "+strings.TrimSuffix(s.RedFlags[0].Note, ".")+".")
+	case len(s.Pros) > 0:
+		sentences = append(sentences, "A highlight: "+strings.TrimSuffix(s.Pros[0], ".")+".")
+	}
+
+	return speechSafe(strings.Join(sentences, " "))
+}
+
+// spokenPrice converte "€2,100 per month" em "2100 euro per month"
+func spokenPrice(price string) string {
+	value := scraper.ExtractPriceValue(price)
+	if value == 0 {
+		return ""
+	}
+	spoken := fmt.Sprintf("%.0f euro", value)
+	lower := strings.ToLower(price)
+	switch {
+	case strings.Contains(lower, "week"):
+		spoken += " per week"
+	case strings.Contains(lower, "month"):
+		spoken += " per month"
+	}
+	return spoken
+}
+
+// speechSafe remove o que quebra SSML ou soa mal em voz alta: markup, "&", emojis,
+// símbolos e abreviações comuns nos endereços
+func speechSafe(text string) string {
+	replacer := strings.NewReplacer(
+		"&", " and ",
+		"€", " euro ",
+		"Co. ", "County ",
+		" km", " kilometres",
+		"/", " ",
+	)
+	text = replacer.Replace(text)
+
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), unicode.IsSpace(r):
+			b.WriteRune(r)
+		case strings.ContainsRune(".,:;!?'-%()", r):
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// handleBriefing é o handler HTTP para GET /briefing?url=...[&format=ssml]
+func handleBriefing(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	listingURL := r.URL.Query().Get("url")
+	if listingURL == "" {
+		writeError(w, http.StatusBadRequest, "url query parameter is required")
+		return
+	}
+
+	telemetry.LogFor(r.Context()).Info("Briefing requested", "url", listingURL)
+
+	property, err := analyzeListing(r.Context(), listingURL)
+	if err != nil {
+		writeScrapeError(w, err)
+		return
+	}
+
+	chargeMapsCalls(w, r, property.MapsCalls())
+
+	text := briefingText(analyzerFor(r.Context()), &property)
+	if r.URL.Query().Get("format") == "ssml" {
+		w.Header().Set("Content-Type", "application/ssml+xml; charset=utf-8")
+		fmt.Fprintf(w, "<speak>%s</speak>", text)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, text)
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestBriefingText(t *testing.T) {
+	p := &scraper.PropertyInfo{
+		Address:      "Apt 4 <Block B>, Rathmines & Ranelagh, Co. Dublin",
+		RentPrice:    "€2,100 per month",
+		Bedrooms:     "2 Bed",
+		PropertyType: "Apartment",
+	}
+	p.QualityOfLife.PublicTransport = []scraper.POI{{Name: "Ranelagh", Type: "light_rail_station", Distance: 0.7, Duration: 9}}
+	p.DescriptionAnalysis.RedFlags = []scraper.DescriptionFlag{{Category: "scam", Note: "Deposit requested before viewing"}}
+
+	got := briefingText((&enrich.Analyzer{}), p)
+	for _, want := range []string{
+		"2 bed apartment at Apt 4 Block B, Rathmines and Ranelagh, County Dublin, listed at 2100 euro per month.",
+		"The nearest public transport is Ranelagh, a 9 minute walk.",
+		"Watch out: Deposit requested before viewing.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("briefing missing %q:\n%s", want, got)
+		}
+	}
+	if strings.ContainsAny(got, "<>&€") {
+		t.Errorf("briefing is not SSML-safe: %s", got)
+	}
+}
+
+func TestSpokenPrice(t *testing.T) {
+	cases := map[string]string{
+		"€2,100 per month":     "2100 euro per month",
+		"€450 per week":        "450 euro per week",
+		"€395,000":             "395000 euro",
+		"Price on application": "",
+	}
+	for in, want := range cases {
+		if got := spokenPrice(in); got != want {
+			t.Errorf("spokenPrice(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+	"daft-scraper-api/internal/value"
+)
+
+/* ───── Comparação lado a lado de 2–5 anúncios ──────────────────────── */
+
+// CompareCategory é uma linha da matriz: o valor de cada anúncio, a diferença para o
+// melhor e o vencedor. Valores ausentes vêm como null.
+type CompareCategory struct {
+	Name           string     `json:"name"`
+	HigherIsBetter bool       `json:"higherIsBetter"`
+	Values         []*float64 `json:"values"`
+	Deltas         []*float64 `json:"deltas"` // valor − melhor valor da categoria
+	Winner         int        `json:"winner"` // índice do anúncio vencedor; -1 se não houver
+}
+
+// CompareResponse é a resposta de POST /compare
+type CompareResponse struct {
+	Listings   []BatchResult     `json:"listings"`
+	Categories []CompareCategory `json:"categories"`
+	Winners    map[string]string `json:"winners"` // categoria → URL do vencedor
+}
+
+// compareMetric é uma categoria da comparação
+type compareMetric struct {
+	name           string
+	higherIsBetter bool
+	value          func(p *scraper.PropertyInfo) (v float64, ok bool)
+}
+
+// compareMetrics define as categorias comparadas e como extrair cada valor; ok false
+// é valor ausente. Nas notas e nos custos o 0 significa "não calculado", mas zero
+// amenidades ou zero crimes são valores reais, que vencem a categoria. A nota geral
+// usa os pesos do Analyzer.
+func compareMetrics(a *enrich.Analyzer) []compareMetric {
+	return []compareMetric{
+		{"overall", true, func(p *scraper.PropertyInfo) (float64, bool) { return positive(float64(a.OverallScore(p))) }},
+		{"safety", true, func(p *scraper.PropertyInfo) (float64, bool) { return positive(float64(p.SafetyInfo.SafetyRating)) }},
+		{"transport", true, func(p *scraper.PropertyInfo) (float64, bool) {
+			return positive(float64(p.QualityOfLife.TransportScore))
+		}},
+		{"walk", true, func(p *scraper.PropertyInfo) (float64, bool) { return positive(float64(p.QualityOfLife.WalkScore)) }},
+		{"bike", true, func(p *scraper.PropertyInfo) (float64, bool) { return positive(float64(p.QualityOfLife.BikeScore)) }},
+		{"remoteWork", true, func(p *scraper.PropertyInfo) (float64, bool) {
+			return positive(float64(p.QualityOfLife.RemoteWorkScore))
+		}},
+		{"family", true, func(p *scraper.PropertyInfo) (float64, bool) { return positive(float64(p.QualityOfLife.FamilyScore)) }},
+		{"quiet", true, func(p *scraper.PropertyInfo) (float64, bool) { return positive(float64(p.QualityOfLife.QuietScore)) }},
+		{"value", true, func(p *scraper.PropertyInfo) (float64, bool) { return positive(float64(p.ValueAnalysis.PriceRating)) }},
+		{"price", false, func(p *scraper.PropertyInfo) (float64, bool) { return positive(scraper.ExtractPriceValue(p.RentPrice)) }},
+		{"occupancyCost", false, func(p *scraper.PropertyInfo) (float64, bool) { return positive(p.ValueAnalysis.OccupancyCost) }},
+		{"trueMonthlyCost", false, func(p *scraper.PropertyInfo) (float64, bool) { return positive(p.ValueAnalysis.TrueMonthlyCost) }},
+		{"pricePerSqm", false, func(p *scraper.PropertyInfo) (float64, bool) { return positive(p.ValueAnalysis.PricePerSqm) }},
+		{"nearestStationKm", false, func(p *scraper.PropertyInfo) (float64, bool) {
+			if top := scraper.NearestPOIs(p.QualityOfLife.PublicTransport, 1); len(top) > 0 {
+				return top[0].Distance, true
+			}
+			return 0, false
+		}},
+		// Amenities fica nil quando a busca não rodou (ver findAmenities)
+		{"amenities", true, func(p *scraper.PropertyInfo) (float64, bool) {
+			return float64(len(p.QualityOfLife.Amenities)), p.QualityOfLife.Amenities != nil
+		}},
+		// a granularidade é preenchida junto com a taxa quando a CSO (ou a estimativa) responde
+		{"crimePerCapita", false, func(p *scraper.PropertyInfo) (float64, bool) {
+			return p.SafetyInfo.CrimeRate, p.SafetyInfo.CrimeGranularity != ""
+		}},
+	}
+}
+
+// positive trata 0 como ausente, para métricas em que ele significa "não calculado"
+func positive(v float64) (float64, bool) {
+	return v, v > 0
+}
+
+// compareRequest é o corpo de POST /compare
+type compareRequest struct {
+	URLs             []string `json:"urls"`
+	Status           string   `json:"status"`           // compara as análises guardadas com esse status
+	MonthlyNetIncome float64  `json:"monthlyNetIncome"` // opcional, para Affordability
+	enrich.CommuteOptions
+}
+
+// handleCompare é o handler HTTP para POST /compare {"urls": [...]}
+func handleCompare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+
+	var requestBody compareRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if requestBody.Status != "" {
+		if !trackingStatuses[requestBody.Status] {
+			writeError(w, http.StatusBadRequest, "status must be one of: shortlisted, viewed, applied, rejected")
+			return
+		}
+		// as mais recentes primeiro, até o limite da comparação
+		for _, a := range trackedAnalyses(r.Context(), requestBody.Status) {
+			if len(requestBody.URLs) == 5 {
+				break
+			}
+			requestBody.URLs = append(requestBody.URLs, a.URL)
+		}
+	}
+	if len(requestBody.URLs) < 2 || len(requestBody.URLs) > 5 {
+		writeError(w, http.StatusBadRequest, "between 2 and 5 urls are required")
+		return
+	}
+	commute, err := analyzerFor(r.Context()).CommuteFromRequest(r, requestBody.CommuteOptions)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	income, err := value.IncomeFromRequest(r, requestBody.MonthlyNetIncome)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if commute.To != "" {
+		// o destino é o mesmo para todos: geocodificado uma vez só
+		if err := analyzerFor(r.Context()).LocateCommute(r.Context(), &commute, &scraper.MapsUsage{}); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "commuteTo could not be geocoded")
+			return
+		}
+	}
+
+	telemetry.LogFor(r.Context()).Info("Comparison requested", "listings", len(requestBody.URLs))
+
+	results := analyzeBatch(r.Context(), requestBody.URLs)
+	for _, res := range results {
+		if res.Property != nil {
+			analyzerFor(r.Context()).ApplyCommute(r.Context(), res.Property, commute)
+			analyzerFor(r.Context()).Value.ApplyAffordability(res.Property, income)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(compareListings(analyzerFor(r.Context()), results))
+}
+
+// compareListings monta a matriz de comparação a partir dos resultados analisados
+func compareListings(a *enrich.Analyzer, results []BatchResult) CompareResponse {
+	resp := CompareResponse{Listings: results, Categories: []CompareCategory{}, Winners: map[string]string{}}
+
+	for _, m := range compareMetrics(a) {
+		cat := CompareCategory{
+			Name:           m.name,
+			HigherIsBetter: m.higherIsBetter,
+			Values:         make([]*float64, len(results)),
+			Deltas:         make([]*float64, len(results)),
+			Winner:         -1,
+		}
+
+		for i, res := range results {
+			if res.Property == nil {
+				continue
+			}
+			v, ok := m.value(res.Property)
+			if !ok {
+				continue
+			}
+			v = math.Round(v*100) / 100
+			cat.Values[i] = &v
+			if cat.Winner < 0 || better(v, *cat.Values[cat.Winner], m.higherIsBetter) {
+				cat.Winner = i
+			}
+		}
+
+		if cat.Winner >= 0 {
+			best := *cat.Values[cat.Winner]
+			for i, v := range cat.Values {
+				if v != nil {
+					d := math.Round((*v-best)*100) / 100
+					cat.Deltas[i] = &d
+				}
+			}
+			resp.Winners[m.name] = results[cat.Winner].URL
+		}
+		resp.Categories = append(resp.Categories, cat)
+	}
+	return resp
+}
+
+func better(a, b float64, higherIsBetter bool) bool {
+	if higherIsBetter {
+		return a > b
+	}
+	return a < b
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"testing"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestCompareListings(t *testing.T) {
+	a := &scraper.PropertyInfo{RentPrice: "€2,000"}
+	a.SafetyInfo.SafetyRating = 8
+	a.QualityOfLife.TransportScore = 6
+	b := &scraper.PropertyInfo{RentPrice: "€1,800"}
+	b.SafetyInfo.SafetyRating = 6
+	b.QualityOfLife.TransportScore = 9
+
+	resp := compareListings((&enrich.Analyzer{}), []BatchResult{
+		{URL: "a", Property: a},
+		{URL: "b", Property: b},
+		{URL: "c", Error: &scraper.APIError{Code: "LISTING_NOT_FOUND"}},
+	})
+
+	if resp.Winners["safety"] != "a" || resp.Winners["transport"] != "b" || resp.Winners["price"] != "b" {
+		t.Fatalf("unexpected winners: %v", resp.Winners)
+	}
+	if _, ok := resp.Winners["pricePerSqm"]; ok {
+		t.Error("no listing has pricePerSqm; category should have no winner")
+	}
+
+	for _, cat := range resp.Categories {
+		if cat.Name != "price" {
+			continue
+		}
+		if *cat.Deltas[0] != 200 || *cat.Deltas[1] != 0 || cat.Values[2] != nil {
+			t.Errorf("unexpected price row: values=%v deltas=%v", cat.Values, cat.Deltas)
+		}
+	}
+}
+
+func TestCompareListingsKeepsRealZeros(t *testing.T) {
+	quiet := &scraper.PropertyInfo{}
+	quiet.QualityOfLife.Amenities = []scraper.POI{} // searched, nothing nearby
+	quiet.SafetyInfo.CrimeRate, quiet.SafetyInfo.CrimeGranularity = 0, "district"
+	busy := &scraper.PropertyInfo{}
+	busy.QualityOfLife.Amenities = []scraper.POI{scraper.NewPOI("Tesco", "supermarket", 0.2, 0, 0)}
+	busy.SafetyInfo.CrimeRate, busy.SafetyInfo.CrimeGranularity = 0.02, "district"
+	unknown := &scraper.PropertyInfo{} // amenities and crime never looked up
+
+	resp := compareListings((&enrich.Analyzer{}), []BatchResult{{URL: "quiet", Property: quiet}, {URL: "busy", Property: busy}, {URL: "unknown", Property: unknown}})
+	if resp.Winners["crimePerCapita"] != "quiet" {
+		t.Errorf("a crime rate of 0 should win, winners %v", resp.Winners)
+	}
+	if resp.Winners["amenities"] != "busy" {
+		t.Errorf("amenities winner = %q", resp.Winners["amenities"])
+	}
+	for _, cat := range resp.Categories {
+		if cat.Name != "amenities" && cat.Name != "crimePerCapita" {
+			continue
+		}
+		if cat.Values[0] == nil || *cat.Values[0] != 0 || cat.Values[2] != nil {
+			t.Errorf("%s: zero should be a value and a missing lookup null, got %v", cat.Name, cat.Values)
+		}
+	}
+}
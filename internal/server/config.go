@@ -0,0 +1,418 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/env"
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Arquivo de configuração ─────────────────────────────────────── */
+
+// O arquivo é um TOML simples (CONFIG_FILE, padrão config.toml no diretório de
+// trabalho) cujas chaves são as próprias variáveis de ambiente: a seção vira prefixo,
+// então [maps] daily_budget = 5 é MAPS_DAILY_BUDGET=5 e [rate_limit] per_minute é
+// RATE_LIMIT_PER_MINUTE. Listas viram valores separados por vírgula. Uma variável já
+// definida no ambiente (ou no .env) tem prioridade sobre o arquivo, e o resto do
+// código continua lendo só o ambiente.
+
+// setting é uma variável de configuração conhecida, para a visão de GET /config
+type setting struct {
+	Name    string
+	Default string
+	Secret  bool
+}
+
+// settings lista as variáveis lidas pelo serviço com o padrão de cada uma
+var settings = []setting{
+	{Name: "PORT", Default: "8080"},
+	{Name: "LISTEN_ADDR", Default: ":8080"},
+	{Name: "TLS_CERT_FILE"},
+	{Name: "TLS_KEY_FILE"},
+	{Name: "AUTOCERT_DOMAINS"},
+	{Name: "AUTOCERT_EMAIL"},
+	{Name: "AUTOCERT_CACHE_DIR", Default: "data/autocert"},
+	{Name: "AUTOCERT_HTTP_ADDR"},
+	{Name: "DATA_DIR", Default: "data"},
+	{Name: "STORE_S3_BUCKET"},
+	{Name: "STORE_S3_KEY", Default: "store.json"},
+	{Name: "STORE_S3_ENDPOINT"},
+	{Name: "SEARCH_DB"},
+	{Name: "JOBS_DB", Default: "data/jobs.db"},
+	{Name: "AWS_REGION", Default: "us-east-1"},
+	{Name: "AWS_ACCESS_KEY_ID"},
+	{Name: "AWS_SECRET_ACCESS_KEY", Secret: true},
+	{Name: "AWS_SESSION_TOKEN", Secret: true},
+	{Name: "LOG_LEVEL", Default: "info"},
+	{Name: "LOG_FORMAT", Default: "json"},
+	{Name: "DEBUG", Default: "false"},
+	{Name: "DEBUG_DIR", Default: "data/debug"},
+	{Name: "DEBUG_RETENTION", Default: "24h"},
+	{Name: "SHUTDOWN_TIMEOUT", Default: "30s"},
+	{Name: "ADMIN_TOKEN", Secret: true},
+	{Name: "API_TOKENS", Secret: true},
+	{Name: "TENANTS_FILE"},
+	{Name: "TRUST_PROXY", Default: "false"},
+	{Name: "RATE_LIMIT_PER_MINUTE", Default: "60"},
+	{Name: "RATE_LIMIT_BURST", Default: "20"},
+	{Name: "CORS_ALLOWED_ORIGINS"},
+	{Name: "CORS_MAX_AGE", Default: "10m"},
+
+	{Name: "GOOGLE_MAPS_API_KEY", Secret: true},
+	{Name: "MAPS_DAILY_BUDGET"},
+	{Name: "MAPS_BUDGET_MODE", Default: "degrade"},
+	{Name: "MAPS_SKU_PRICES"},
+	{Name: "GEOCODERS", Default: "google,nominatim,eircode"},
+	{Name: "NOMINATIM_URL", Default: "https://nominatim.openstreetmap.org/search"},
+	{Name: "PLACES_PROVIDER"},
+	{Name: "FOURSQUARE_API_KEY", Secret: true},
+	{Name: "FOURSQUARE_URL", Default: enrich.DefaultFoursquareURL},
+	{Name: "OVERPASS_URL"},
+	{Name: "OVERPASS_MIRRORS", Default: strings.Join(scraper.DefaultOverpassMirrors, ",")},
+	{Name: "ELEVATION_URL", Default: "https://api.open-meteo.com/v1/elevation"},
+	{Name: "SCRAPE_DOMAINS", Default: "www.daft.ie,daft.ie"},
+	{Name: "CRAWL_DELAY", Default: "2s"},
+	{Name: "CRAWL_RANDOM_DELAY", Default: "1s"},
+	{Name: "CRAWL_PARALLELISM", Default: "1"},
+	{Name: "CRAWL_DAFT_IE_DELAY"},
+	{Name: "CRAWL_DAFT_IE_RANDOM_DELAY"},
+	{Name: "CRAWL_DAFT_IE_PARALLELISM"},
+	{Name: "CRAWL_RENT_IE_DELAY"},
+	{Name: "CRAWL_RENT_IE_RANDOM_DELAY"},
+	{Name: "CRAWL_RENT_IE_PARALLELISM"},
+	{Name: "CRAWL_MYHOME_IE_DELAY"},
+	{Name: "CRAWL_MYHOME_IE_RANDOM_DELAY"},
+	{Name: "CRAWL_MYHOME_IE_PARALLELISM"},
+	{Name: "CRAWL_ROBOTS", Default: "respect"},
+	{Name: "CRAWL_DAFT_IE_ROBOTS"},
+	{Name: "CRAWL_RENT_IE_ROBOTS"},
+	{Name: "CRAWL_MYHOME_IE_ROBOTS"},
+	{Name: "CRAWL_BUDGET_PAGES", Default: "40"},
+
+	{Name: "TRAIN_RADIUS", Default: "2000"},
+	{Name: "BUS_RADIUS", Default: "1000"},
+	{Name: "AMENITIES_RADIUS", Default: "1500"},
+	{Name: "ENTERTAINMENT_RADIUS", Default: "2000"},
+	{Name: "GARDAI_RADIUS", Default: "5000"},
+	{Name: "LIGHTING_RADIUS", Default: "500"},
+	{Name: "BIKE_RADIUS", Default: "1000"},
+	{Name: "REMOTE_WORK_RADIUS", Default: "1500"},
+	{Name: "FAMILY_RADIUS", Default: "1500"},
+	{Name: "RSA_COLLISIONS_URL"},
+	{Name: "COLLISIONS_RADIUS", Default: "500"},
+	{Name: "COLLISIONS_YEARS", Default: "5"},
+	{Name: "RTB_REGISTER_URL"},
+	{Name: "AMENITY_TYPES", Default: strings.Join(enrich.DefaultAmenityTypes, ",")},
+	{Name: "ENTERTAINMENT_TYPES", Default: strings.Join(enrich.DefaultEntertainmentTypes, ",")},
+	{Name: "POI_MAX_PER_TYPE", Default: "10"},
+	{Name: "SCORE_WEIGHT_SAFETY", Default: "1"},
+	{Name: "SCORE_WEIGHT_TRANSPORT", Default: "1"},
+	{Name: "SCORE_WEIGHT_WALK", Default: "1"},
+	{Name: "SCORE_WEIGHT_VALUE", Default: "1"},
+
+	{Name: "MODULES_SAFETY", Default: "true"},
+	{Name: "MODULES_TRANSPORT", Default: "true"},
+	{Name: "MODULES_AMENITIES", Default: "true"},
+	{Name: "MODULES_ENTERTAINMENT", Default: "true"},
+	{Name: "MODULES_FAMILY", Default: "true"},
+	{Name: "MODULES_VALUE", Default: "true"},
+	{Name: "MODULES_VALUE_COMPARABLES", Default: "true"},
+	{Name: "MODULES_VALUE_PRICE_HISTORY", Default: "true"},
+	{Name: "MODULES_PHOTOS", Default: "true"},
+	{Name: "MODULES_SUMMARY", Default: "true"},
+
+	{Name: "ANALYSIS_CACHE_TTL", Default: "1h"},
+	{Name: "AREA_RANK_TTL", Default: "168h"},
+	{Name: "WATCH_INTERVAL", Default: "24h"},
+	{Name: "SEARCH_INTERVAL", Default: "30m"},
+	{Name: "AVAILABILITY_INTERVAL", Default: "24h"},
+	{Name: "REANALYSIS_INTERVAL"},
+	{Name: "REANALYSIS_BATCH", Default: "20"},
+	{Name: "JOB_WORKERS", Default: "2"},
+	{Name: "JOB_MAX_ATTEMPTS", Default: "5"},
+	{Name: "JOB_RETRY_BASE_DELAY", Default: "30s"},
+	{Name: "JOB_RETENTION", Default: "168h"},
+	{Name: "UPSTREAM_URL"},
+	{Name: "UPSTREAM_API_KEY", Secret: true},
+	{Name: "HTTP_RETRIES", Default: "3"},
+	{Name: "HTTP_RETRY_BASE_DELAY", Default: "500ms"},
+	{Name: "HTTP_RETRY_MAX_DELAY", Default: "10s"},
+	{Name: "CIRCUIT_FAILURES", Default: "5"},
+	{Name: "CIRCUIT_COOLDOWN", Default: "30s"},
+	{Name: "SCRAPE_TIMEOUT", Default: "30s"},
+	{Name: "GEOCODE_TIMEOUT", Default: "10s"},
+	{Name: "PLACES_TIMEOUT", Default: "15s"},
+	{Name: "OVERPASS_TIMEOUT", Default: "30s"},
+	{Name: "CRIME_TIMEOUT", Default: "15s"},
+	{Name: "RSA_TIMEOUT", Default: "15s"},
+	{Name: "ELEVATION_TIMEOUT", Default: "10s"},
+	{Name: "PHOTOS_TIMEOUT", Default: "30s"},
+	{Name: "LLM_TIMEOUT", Default: "60s"},
+	{Name: "UPSTREAM_TIMEOUT", Default: "30s"},
+
+	{Name: "LLM_PROVIDER"},
+	{Name: "LLM_MODEL"},
+	{Name: "OPENAI_API_KEY", Secret: true},
+	{Name: "OPENAI_BASE_URL", Default: "https://api.openai.com/v1"},
+	{Name: "OLLAMA_URL", Default: "http://localhost:11434"},
+	{Name: "EMBEDDINGS_PROVIDER"},
+	{Name: "OPENAI_EMBEDDINGS_MODEL", Default: "text-embedding-3-small"},
+	{Name: "OLLAMA_EMBEDDINGS_MODEL", Default: "nomic-embed-text"},
+	{Name: "TESSERACT_PATH", Default: "tesseract"},
+	{Name: "CRO_API_EMAIL"},
+	{Name: "CRO_API_KEY", Secret: true},
+	{Name: "MORTGAGE_RATE", Default: "4"},
+	{Name: "ENERGY_PRICE_KWH", Default: "0.20"},
+	{Name: "COMMUTE_DAYS", Default: "5"},
+	{Name: "FUEL_PRICE", Default: "1.75"},
+	{Name: "FUEL_CONSUMPTION", Default: "6.5"},
+	{Name: "MARKET_PRIVACY"},
+	{Name: "PRIVACY_EPSILON", Default: "1"},
+	{Name: "PRIVACY_BUDGET", Default: "10"},
+	{Name: "PRIVACY_WINDOW", Default: "24h"},
+	{Name: "MARKET_MIN_COUNT", Default: "5"},
+
+	{Name: "SMTP_HOST"},
+	{Name: "SMTP_PORT", Default: "587"},
+	{Name: "SMTP_FROM"},
+	{Name: "SMTP_USERNAME"},
+	{Name: "SMTP_PASSWORD", Secret: true},
+	{Name: "TELEGRAM_BOT_ENABLED", Default: "false"},
+	{Name: "TELEGRAM_BOT_TOKEN", Secret: true},
+	{Name: "MQTT_BROKER"},
+	{Name: "MQTT_TOPIC", Default: "exchange-helper/alerts"},
+	{Name: "MQTT_CLIENT_ID"},
+	{Name: "MQTT_USERNAME"},
+	{Name: "MQTT_PASSWORD", Secret: true},
+	{Name: "MQTT_QOS", Default: "0"},
+	{Name: "MQTT_RETAIN", Default: "false"},
+	{Name: "GOOGLE_SHEETS_CREDENTIALS"},
+	{Name: "GOOGLE_SHEETS_ID"},
+	{Name: "GOOGLE_SHEETS_RANGE", Default: "Sheet1!A1"},
+
+	{Name: "OTEL_EXPORTER_OTLP_ENDPOINT"},
+	{Name: "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"},
+	{Name: "OTEL_SERVICE_NAME", Default: "daft-scraper-api"},
+}
+
+// configFile é o caminho do arquivo carregado na partida ("" sem arquivo) e
+// fromConfigFile as variáveis que vieram dele; só escritos em loadConfig
+var (
+	configFile     string
+	fromConfigFile = map[string]bool{}
+)
+
+// configPath é CONFIG_FILE ou, se existir, config.toml
+func configPath() string {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return path
+	}
+	if _, err := os.Stat("config.toml"); err == nil {
+		return "config.toml"
+	}
+	return ""
+}
+
+// loadConfig lê o arquivo e define as variáveis que ainda não estão no ambiente.
+// Chamado em Configure(), depois do .env e antes de qualquer leitura de configuração.
+func loadConfig(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	values, err := parseConfig(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	configFile = path
+	for name, value := range values {
+		if _, set := os.LookupEnv(name); set {
+			continue
+		}
+		os.Setenv(name, value)
+		fromConfigFile[name] = true
+	}
+	return nil
+}
+
+// parseConfig lê o subconjunto de TOML usado na configuração: comentários, [seções]
+// (inclusive aninhadas, [score.weight]), chave = valor com strings, números,
+// booleanos e listas numa linha só. Devolve os valores pelo nome da variável.
+func parseConfig(r io.Reader) (map[string]string, error) {
+	values := map[string]string{}
+	section := ""
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: invalid section %q", n, line)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		key = strings.TrimSpace(key)
+		if section != "" {
+			key = section + "." + key
+		}
+		value, err := parseConfigValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		values[env.Name(key)] = value
+	}
+	return values, scanner.Err()
+}
+
+// stripComment remove o comentário (#) fora de strings
+func stripComment(line string) string {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && inString:
+			i++
+		case line[i] == '"':
+			inString = !inString
+		case line[i] == '#' && !inString:
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseConfigValue(raw string) (string, error) {
+	switch {
+	case raw == "":
+		return "", fmt.Errorf("missing value")
+	case strings.HasPrefix(raw, `"`):
+		return strconv.Unquote(raw)
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			return "", fmt.Errorf("unterminated string %s", raw)
+		}
+		return raw[1 : len(raw)-1], nil // string literal do TOML, sem escapes
+	case strings.HasPrefix(raw, "["):
+		if !strings.HasSuffix(raw, "]") {
+			return "", fmt.Errorf("lists must fit on one line: %s", raw)
+		}
+		var items []string
+		for _, item := range splitConfigList(raw[1 : len(raw)-1]) {
+			v, err := parseConfigValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, v)
+		}
+		return strings.Join(items, ","), nil
+	case raw == "true" || raw == "false":
+		return raw, nil
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(raw, "_", ""), 64); err != nil {
+		return "", fmt.Errorf("invalid value %s (strings must be quoted)", raw)
+	}
+	return strings.ReplaceAll(raw, "_", ""), nil
+}
+
+// splitConfigList separa os itens de uma lista nas vírgulas fora de strings
+func splitConfigList(raw string) []string {
+	var items []string
+	inString, start := false, 0
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\' && inString:
+			i++
+		case raw[i] == '"':
+			inString = !inString
+		case raw[i] == ',' && !inString:
+			items = append(items, strings.TrimSpace(raw[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(raw[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
+
+/* ───── GET /config ─────────────────────────────────────────────────── */
+
+// EffectiveSetting é uma variável na visão de GET /config; segredos definidos e as
+// variáveis que o código não conhece aparecem como "[redacted]"
+type EffectiveSetting struct {
+	Name         string `json:"name"`
+	Value        string `json:"value"`
+	Source       string `json:"source"` // env, file ou default
+	Default      string `json:"default,omitempty"`
+	Unrecognised bool   `json:"unrecognised,omitempty"` // no arquivo, mas não lida pelo código
+}
+
+// EffectiveConfig é a resposta de GET /config
+type EffectiveConfig struct {
+	File     string             `json:"file,omitempty"`
+	Settings []EffectiveSetting `json:"settings"`
+}
+
+// effectiveConfig monta a configuração em vigor: as variáveis conhecidas e as do
+// arquivo que o código não conhece (provavelmente erros de digitação). Estas são
+// sempre escondidas, já que um segredo com o nome errado não seria reconhecido como tal.
+func effectiveConfig() EffectiveConfig {
+	known := map[string]setting{}
+	for _, s := range settings {
+		known[s.Name] = s
+	}
+	unrecognised := map[string]bool{}
+	for name := range fromConfigFile {
+		if _, ok := known[name]; !ok {
+			known[name] = setting{Name: name, Secret: true}
+			unrecognised[name] = true
+		}
+	}
+
+	cfg := EffectiveConfig{File: configFile, Settings: []EffectiveSetting{}}
+	for _, s := range known {
+		e := EffectiveSetting{Name: s.Name, Value: s.Default, Source: "default", Default: s.Default, Unrecognised: unrecognised[s.Name]}
+		if value, set := os.LookupEnv(s.Name); set {
+			e.Value, e.Source = value, "env"
+			if fromConfigFile[s.Name] {
+				e.Source = "file"
+			}
+			if s.Secret && value != "" {
+				e.Value = "[redacted]"
+			}
+		}
+		cfg.Settings = append(cfg.Settings, e)
+	}
+	sort.Slice(cfg.Settings, func(i, j int) bool { return cfg.Settings[i].Name < cfg.Settings[j].Name })
+	return cfg
+}
+
+// handleConfig mostra a configuração em vigor (token de admin)
+func handleConfig(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(effectiveConfig())
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+)
+
+const sampleConfig = `# exchange helper
+port = 9090
+data_dir = "/var/lib/helper" # trailing comment
+
+[google_maps]
+api_key = "AIza-secret"
+
+[maps]
+daily_budget = 5.50
+
+[amenity]
+types = ["supermarket", "pharmacy, 24h", 'bank']
+
+[score.weight]
+safety = 2
+
+[telegram.bot]
+enabled = true
+`
+
+func TestParseConfig(t *testing.T) {
+	values, err := parseConfig(strings.NewReader(sampleConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"PORT":                 "9090",
+		"DATA_DIR":             "/var/lib/helper",
+		"GOOGLE_MAPS_API_KEY":  "AIza-secret",
+		"MAPS_DAILY_BUDGET":    "5.50",
+		"AMENITY_TYPES":        "supermarket,pharmacy, 24h,bank",
+		"SCORE_WEIGHT_SAFETY":  "2",
+		"TELEGRAM_BOT_ENABLED": "true",
+	}
+	if len(values) != len(want) {
+		t.Errorf("got %d values, want %d: %v", len(values), len(want), values)
+	}
+	for name, value := range want {
+		if values[name] != value {
+			t.Errorf("%s = %q, want %q", name, values[name], value)
+		}
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	for _, input := range []string{
+		"port",
+		"port =",
+		"log_level = debug",
+		`data_dir = "unterminated`,
+		"[maps",
+		"[[sources]]",
+		"types = [\"a\",",
+	} {
+		if _, err := parseConfig(strings.NewReader(input)); err == nil {
+			t.Errorf("parseConfig(%q) should fail", input)
+		}
+	}
+}
+
+func TestLoadConfigEnvOverridesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte("[rate_limit]\nper_minute = 10\nburst = 3\n"), 0o644)
+	t.Setenv("RATE_LIMIT_PER_MINUTE", "100")
+	t.Setenv("RATE_LIMIT_BURST", "") // registered for cleanup, then unset
+	os.Unsetenv("RATE_LIMIT_BURST")
+	t.Cleanup(func() {
+		configFile = ""
+		fromConfigFile = map[string]bool{}
+	})
+
+	if err := loadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("RATE_LIMIT_PER_MINUTE"); got != "100" {
+		t.Errorf("environment should win over the file, got %q", got)
+	}
+	if got := os.Getenv("RATE_LIMIT_BURST"); got != "3" {
+		t.Errorf("RATE_LIMIT_BURST = %q, want 3 from the file", got)
+	}
+	if err := loadConfig(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Error("a missing config file should be an error")
+	}
+}
+
+func TestConfigEndpointRedactsSecrets(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "s3cret")
+	t.Setenv("OPENAI_API_KEY", "sk-live")
+	t.Setenv("LIGHTING_RADIUS", "800")
+	t.Setenv("GOOGLE_MAP_API_KEY", "AIza-typo")
+	fromConfigFile = map[string]bool{"LIGHTING_RADIUS": true, "GOOGLE_MAP_API_KEY": true}
+	t.Cleanup(func() { fromConfigFile = map[string]bool{} })
+
+	req := httptest.NewRequest(http.MethodGet, "/config", nil)
+	rec := httptest.NewRecorder()
+	handleConfig(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("without a token: status %d, want 401", rec.Code)
+	}
+
+	req.Header.Set("X-Admin-Token", "s3cret")
+	rec = httptest.NewRecorder()
+	handleConfig(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "sk-live") || strings.Contains(rec.Body.String(), "s3cret") || strings.Contains(rec.Body.String(), "AIza-typo") {
+		t.Fatalf("secrets leaked: %s", rec.Body)
+	}
+	var cfg EffectiveConfig
+	json.NewDecoder(rec.Body).Decode(&cfg)
+	got := map[string]EffectiveSetting{}
+	for _, s := range cfg.Settings {
+		got[s.Name] = s
+	}
+	for name, want := range map[string]EffectiveSetting{
+		"OPENAI_API_KEY":     {Value: "[redacted]", Source: "env"},
+		"LIGHTING_RADIUS":    {Value: "800", Source: "file"},
+		"GOOGLE_MAP_API_KEY": {Value: "[redacted]", Source: "file", Unrecognised: true},
+		"BUS_RADIUS":         {Value: "1000", Source: "default"},
+	} {
+		if got[name].Value != want.Value || got[name].Source != want.Source || got[name].Unrecognised != want.Unrecognised {
+			t.Errorf("%s = %+v, want %+v", name, got[name], want)
+		}
+	}
+}
+
+func TestConfigurableKnobs(t *testing.T) {
+	t.Setenv("SCORE_WEIGHT_SAFETY", "3")
+	t.Setenv("LIGHTING_RADIUS", "750")
+	t.Setenv("AMENITY_TYPES", " gym , ,park")
+	a := enrich.NewAnalyzerFromEnv()
+	p := &scraper.PropertyInfo{}
+	p.SafetyInfo.SafetyRating = 8            // 80
+	p.QualityOfLife.WalkScore = 40           // 40
+	if got := a.OverallScore(p); got != 70 { // (80*3 + 40) / 4
+		t.Errorf("weighted score = %d, want 70", got)
+	}
+	if got := a.Radius("lighting"); got != 750 {
+		t.Errorf("lighting radius = %d", got)
+	}
+	if got := a.AmenityTypes; strings.Join(got, "|") != "gym|park" {
+		t.Errorf("amenity types = %q", got)
+	}
+
+	// the analyzer keeps what it read; a new one sees the change
+	t.Setenv("LIGHTING_RADIUS", "-1")
+	if got := a.Radius("lighting"); got != 750 {
+		t.Errorf("the analyzer re-read LIGHTING_RADIUS, got %d", got)
+	}
+	if got := enrich.NewAnalyzerFromEnv().Radius("lighting"); got != 500 {
+		t.Errorf("invalid radius should fall back to the default, got %d", got)
+	}
+}
+
+// every variable the code reads should show up in GET /config
+func TestSettingsListsEveryVariable(t *testing.T) {
+	known := map[string]bool{"CONFIG_FILE": true}
+	for _, s := range settings {
+		known[s.Name] = true
+	}
+	read := regexp.MustCompile(`(?:os\.Getenv|os\.LookupEnv|env\.Int|env\.Float|env\.List|env\.Or)\("([A-Z0-9_]+)"[,)]`)
+	// the analysis packages read their settings too (internal/scraper, internal/enrich...)
+	files, _ := filepath.Glob("../*/*.go")
+	nested, _ := filepath.Glob("../*/*/*.go")
+	for _, file := range append(files, nested...) {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, _ := os.ReadFile(file)
+		for _, m := range read.FindAllStringSubmatch(string(src), -1) {
+			if !known[m[1]] {
+				t.Errorf("%s reads %s, which is missing from settings", file, m[1])
+			}
+		}
+	}
+}
+
+func TestModuleFlagsFromConfigFile(t *testing.T) {
+	values, err := parseConfig(strings.NewReader("[modules]\nsafety = false\nvalue.comparables = false\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["MODULES_SAFETY"] != "false" || values["MODULES_VALUE_COMPARABLES"] != "false" {
+		t.Errorf("got %v", values)
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/env"
+)
+
+/* ───── CORS para a extensão do navegador ───────────────────────────── */
+
+// corsAllowedHeaders são os cabeçalhos que o cliente pode mandar (autenticação,
+// request ID e trace) e corsExposedHeaders os da resposta que ele pode ler
+var (
+	corsAllowedHeaders = []string{"Authorization", "Content-Type", "X-API-Key", "X-Admin-Token", "X-Request-ID", "traceparent"}
+	corsExposedHeaders = []string{"X-Request-ID", "X-Cache", "Age", "Retry-After", "Content-Disposition",
+		"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-Maps-Calls"}
+	corsMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+)
+
+// corsOriginAllowed confere a origem com CORS_ALLOWED_ORIGINS: origens exatas
+// (https://app.example.com), padrões com * (chrome-extension://*,
+// https://*.example.com) ou * para qualquer uma
+func corsOriginAllowed(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	for _, pattern := range env.List("CORS_ALLOWED_ORIGINS", nil) {
+		if pattern == "*" || strings.EqualFold(pattern, origin) {
+			return true
+		}
+		if ok, _ := path.Match(pattern, origin); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// corsMaxAge é por quanto tempo o navegador guarda a resposta do preflight
+// (CORS_MAX_AGE, padrão 10m)
+func corsMaxAge() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("CORS_MAX_AGE")); err == nil && d >= 0 {
+		return d
+	}
+	return 10 * time.Minute
+}
+
+// cors responde aos preflights (OPTIONS) das origens permitidas e marca as demais
+// respostas para elas, de modo que a extensão chame a API direto dos content
+// scripts. Fica por fora do rateLimit: preflights não gastam a cota e as recusas
+// (429, 401) chegam legíveis ao navegador. Origens fora da lista seguem como antes,
+// sem cabeçalhos CORS.
+func cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		if !corsOriginAllowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h.Set("Access-Control-Allow-Origin", origin)
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", strings.Join(corsMethods, ", "))
+			h.Set("Access-Control-Allow-Headers", strings.Join(corsAllowedHeaders, ", "))
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(corsMaxAge().Seconds())))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+		next.ServeHTTP(w, r)
+	})
+}
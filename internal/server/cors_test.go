@@ -0,0 +1,110 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCORSOriginAllowed(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "chrome-extension://*, https://app.example.com,https://*.example.org")
+	for origin, want := range map[string]bool{
+		"chrome-extension://abcdefghijklmnop": true,
+		"https://app.example.com":             true,
+		"https://APP.example.com":             true,
+		"https://eu.example.org":              true,
+		"https://example.org":                 false,
+		"moz-extension://1234":                false,
+		"https://evil.com":                    false,
+		"":                                    false,
+	} {
+		if got := corsOriginAllowed(origin); got != want {
+			t.Errorf("corsOriginAllowed(%q) = %v, want %v", origin, got, want)
+		}
+	}
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "")
+	if corsOriginAllowed("chrome-extension://abcdefghijklmnop") {
+		t.Error("CORS should be off without CORS_ALLOWED_ORIGINS")
+	}
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+	if !corsOriginAllowed("moz-extension://1234") {
+		t.Error("* should allow any origin")
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "chrome-extension://*")
+	t.Setenv("CORS_MAX_AGE", "1h")
+	useLimiter(t, 60, 1)
+	reached := 0
+	handler := cors(rateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached++
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "Only POST method is allowed")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/analyze", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		req.Header.Set("Access-Control-Request-Headers", "content-type, x-api-key")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// preflights are answered without reaching the POST-only handler or the rate limit
+	for i := 0; i < 3; i++ {
+		rec := preflight("chrome-extension://abcdefghijklmnop")
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("preflight status %d, want 204", rec.Code)
+		}
+		h := rec.Header()
+		if h.Get("Access-Control-Allow-Origin") != "chrome-extension://abcdefghijklmnop" ||
+			!strings.Contains(h.Get("Access-Control-Allow-Methods"), "POST") ||
+			!strings.Contains(h.Get("Access-Control-Allow-Headers"), "X-API-Key") ||
+			h.Get("Access-Control-Max-Age") != "3600" {
+			t.Errorf("preflight headers = %v", h)
+		}
+	}
+	if reached != 0 {
+		t.Errorf("preflight reached the handler %d times", reached)
+	}
+
+	// an origin that is not allowed gets the old behaviour
+	if rec := preflight("https://evil.com"); rec.Code != http.StatusMethodNotAllowed ||
+		rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disallowed preflight: status %d, headers %v", rec.Code, rec.Header())
+	}
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "chrome-extension://*")
+	useLimiter(t, 60, 1)
+	handler := cors(rateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	var last *httptest.ResponseRecorder
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/analyze", nil)
+		req.Header.Set("Origin", "chrome-extension://abcdefghijklmnop")
+		last = httptest.NewRecorder()
+		handler.ServeHTTP(last, req)
+	}
+	// the second request is rate limited, and the extension can still read why
+	if last.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429", last.Code)
+	}
+	h := last.Header()
+	if h.Get("Access-Control-Allow-Origin") != "chrome-extension://abcdefghijklmnop" ||
+		!strings.Contains(h.Get("Access-Control-Expose-Headers"), "Retry-After") ||
+		!strings.Contains(strings.Join(h.Values("Vary"), ","), "Origin") {
+		t.Errorf("headers = %v", h)
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/value"
+)
+
+/* ───── Tempo no mercado e re-anúncios ──────────────────────────────── */
+
+// O Daft renova a data de publicação quando o anunciante "sobe" o anúncio, e um
+// imóvel que não sai costuma voltar com outra URL. Guardamos quando vimos cada
+// anúncio pela primeira vez (pelo id da URL) e ligamos os anúncios do mesmo imóvel
+// pelo endereço e pelas fotos repetidas, para contar o tempo real no mercado. Imóvel
+// parado há muito tempo é espaço para negociar.
+
+// ListingSighting é quando um anúncio foi visto pela primeira e pela última vez
+type ListingSighting struct {
+	URL        string    `json:"url"`
+	Address    string    `json:"address"`
+	AddressKey string    `json:"addressKey"`
+	FirstSeen  time.Time `json:"firstSeen"`
+	LastSeen   time.Time `json:"lastSeen"`
+}
+
+// listingKey identifica o anúncio pelo id da URL (a mesma página com outro slug) ou,
+// sem id, pela própria URL
+func listingKey(u string) string {
+	if id := scraper.ListingIDFromURL(u); id != "" {
+		return id
+	}
+	return u
+}
+
+// trackMarketHistory registra o anúncio no Store e monta MarketHistory com os outros
+// anúncios do mesmo endereço e os re-anúncios achados pelas fotos (PhotoDuplicates)
+func trackMarketHistory(property *scraper.PropertyInfo, now time.Time) (*scraper.MarketHistory, error) {
+	key := listingKey(property.URL)
+	addrKey := scraper.RelistAddressKey(property.Address)
+	var own ListingSighting
+	var earlier []ListingSighting
+
+	err := store.Update(func(d *storeData) error {
+		s := d.ListingSightings[key]
+		if s == nil {
+			s = &ListingSighting{FirstSeen: now}
+			d.ListingSightings[key] = s
+		}
+		s.URL, s.Address, s.AddressKey, s.LastSeen = property.URL, property.Address, addrKey, now
+		own = *s
+
+		if addrKey == "" {
+			return nil
+		}
+		for k, other := range d.ListingSightings {
+			if k != key && other.AddressKey == addrKey {
+				earlier = append(earlier, *other)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error saving listing sighting: %w", err)
+	}
+
+	h := &scraper.MarketHistory{FirstSeen: own.FirstSeen, Relists: []scraper.Relist{}}
+	if property.PublishedAt != nil && property.PublishedAt.Before(h.FirstSeen) {
+		h.FirstSeen = *property.PublishedAt
+	}
+	h.PropertyFirstSeen = h.FirstSeen
+
+	sort.Slice(earlier, func(i, j int) bool { return earlier[i].FirstSeen.Before(earlier[j].FirstSeen) })
+	linked := map[string]bool{}
+	for _, s := range earlier {
+		firstSeen := s.FirstSeen
+		h.Relists = append(h.Relists, scraper.Relist{URL: s.URL, FirstSeen: &firstSeen, MatchedBy: "address"})
+		linked[listingKey(s.URL)] = true
+		if firstSeen.Before(h.PropertyFirstSeen) {
+			h.PropertyFirstSeen = firstSeen
+		}
+	}
+	for _, m := range property.PhotoDuplicates {
+		if m.Kind == "relisted" && !linked[listingKey(m.OtherListingURL)] {
+			linked[listingKey(m.OtherListingURL)] = true
+			h.Relists = append(h.Relists, scraper.Relist{URL: m.OtherListingURL, MatchedBy: "photos"})
+		}
+	}
+
+	h.DaysOnMarket = daysSince(h.FirstSeen, now)
+	h.PropertyDaysOnMarket = daysSince(h.PropertyFirstSeen, now)
+	switch {
+	case h.PropertyDaysOnMarket >= value.LongOnMarketDays && len(h.Relists) > 0:
+		h.NegotiationHint = fmt.Sprintf("On the market for %d days across %d listings; there may be room to negotiate",
+			h.PropertyDaysOnMarket, len(h.Relists)+1)
+	case h.PropertyDaysOnMarket >= value.LongOnMarketDays:
+		h.NegotiationHint = fmt.Sprintf("On the market for %d days; there may be room to negotiate", h.PropertyDaysOnMarket)
+	case len(h.Relists) > 0:
+		h.NegotiationHint = "Listed before under a different URL; ask why it did not let or sell"
+	}
+	return h, nil
+}
+
+func daysSince(t, now time.Time) int {
+	return max(int(now.Sub(t).Hours()/24), 0)
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestTrackMarketHistory(t *testing.T) {
+	store = newMemoryStore()
+	t.Cleanup(func() { store = newMemoryStore() })
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	first := scraper.PropertyInfo{URL: "https://www.daft.ie/for-rent/apartment-4-the-maltings-bray/1001", Address: "Apartment 4, The Maltings, Bray, Co. Wicklow"}
+	h, err := trackMarketHistory(&first, start)
+	if err != nil || h.DaysOnMarket != 0 || len(h.Relists) != 0 {
+		t.Fatalf("first sighting = %+v, %v", h, err)
+	}
+
+	// the same listing with a new slug keeps its first sighting
+	renamed := scraper.PropertyInfo{URL: "https://www.daft.ie/for-rent/apt-4-maltings/1001", Address: first.Address}
+	if h, _ := trackMarketHistory(&renamed, start.AddDate(0, 0, 10)); h.DaysOnMarket != 10 || len(h.Relists) != 0 {
+		t.Errorf("same id = %+v", h)
+	}
+
+	// the property comes back under a new ID, with a refreshed publish date
+	published := start.AddDate(0, 0, 40)
+	relist := scraper.PropertyInfo{
+		URL:         "https://www.daft.ie/for-rent/apartment-4-the-maltings-bray/2002",
+		Address:     "Apartment 4, The Maltings, Bray, Co. Wicklow",
+		PublishedAt: &published,
+		PhotoDuplicates: []scraper.PhotoMatch{
+			{OtherListingURL: "https://www.daft.ie/for-rent/apartment-4-the-maltings-bray/1001", Kind: "relisted"},
+			{OtherListingURL: "https://www.daft.ie/for-rent/old/999", Kind: "relisted"},
+			{OtherListingURL: "https://www.daft.ie/for-rent/elsewhere/555", Kind: "different_address"},
+		},
+	}
+	h, err = trackMarketHistory(&relist, start.AddDate(0, 0, 45))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.DaysOnMarket != 5 || h.PropertyDaysOnMarket != 45 {
+		t.Errorf("days = %d listing, %d property", h.DaysOnMarket, h.PropertyDaysOnMarket)
+	}
+	if len(h.Relists) != 2 || h.Relists[0].MatchedBy != "address" || h.Relists[1].MatchedBy != "photos" || h.Relists[1].FirstSeen != nil {
+		t.Errorf("relists = %+v", h.Relists)
+	}
+	if !strings.Contains(h.NegotiationHint, "45 days across 3 listings") {
+		t.Errorf("hint = %q", h.NegotiationHint)
+	}
+}
+
+func TestRelistAddressKeyNeedsANumber(t *testing.T) {
+	if key := scraper.RelistAddressKey("Main Street, Bray, Co. Wicklow"); key != "" {
+		t.Errorf("an address without a number should not link listings, got %q", key)
+	}
+	if key := scraper.RelistAddressKey("12 Main Street, Bray"); key == "" {
+		t.Error("a numbered address should have a key")
+	}
+}
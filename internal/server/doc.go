@@ -0,0 +1,8 @@
+// Package server é a API HTTP do analisador: os handlers, o store, os jobs, os
+// tenants e a configuração. A análise fica em internal/scraper (scraping e modelo),
+// internal/enrich (módulos de enriquecimento), internal/value (análise de valor) e
+// internal/safety (criminalidade, colisões e nota de segurança); o servidor liga o
+// Analyzer ao Store pela History de enrich (ver storeBacked). O main da raiz só chama
+// Configure e Run (ou Lambda); programas que querem só a análise usam o pacote
+// pkg/exchangehelper.
+package server
@@ -0,0 +1,302 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Embeddings das descrições e busca "mais como este" ──────────── */
+
+// embeddingProvider transforma textos em vetores. Escolhido por EMBEDDINGS_PROVIDER:
+// "hash" (padrão, offline), "ollama" (modelo local) ou "openai".
+type embeddingProvider interface {
+	Name() string
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// StoredEmbedding é o vetor de uma análise guardada. Quando a análise muda, o vetor é
+// substituído por um novo, nunca alterado no lugar.
+type StoredEmbedding struct {
+	Provider   string    `json:"provider"`
+	Vector     []float64 `json:"vector"`
+	AnalyzedAt time.Time `json:"analyzedAt"`
+}
+
+// SimilarListing é um resultado de GET /analyses/similar
+type SimilarListing struct {
+	ID         string  `json:"id"`
+	URL        string  `json:"url"`
+	Address    string  `json:"address"`
+	Price      string  `json:"price"`
+	Similarity float64 `json:"similarity"` // cosseno, 0-1
+}
+
+func embeddingProviderFromEnv() embeddingProvider {
+	switch os.Getenv("EMBEDDINGS_PROVIDER") {
+	case "openai":
+		model := os.Getenv("OPENAI_EMBEDDINGS_MODEL")
+		if model == "" {
+			model = "text-embedding-3-small"
+		}
+		return openAIEmbeddings{APIKey: os.Getenv("OPENAI_API_KEY"), Model: model}
+	case "ollama":
+		baseURL := os.Getenv("OLLAMA_URL")
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+		model := os.Getenv("OLLAMA_EMBEDDINGS_MODEL")
+		if model == "" {
+			model = "nomic-embed-text"
+		}
+		return ollamaEmbeddings{BaseURL: strings.TrimRight(baseURL, "/"), Model: model}
+	default:
+		return hashEmbeddings{Dims: 512}
+	}
+}
+
+// hashEmbeddings é um bag-of-words com feature hashing (unigramas e bigramas). Não
+// entende sinônimos, mas funciona sem rede nem modelo.
+type hashEmbeddings struct{ Dims int }
+
+func (h hashEmbeddings) Name() string { return "hash-" + strconv.Itoa(h.Dims) }
+
+func (h hashEmbeddings) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	out := make([][]float64, len(texts))
+	for i, text := range texts {
+		vec := make([]float64, h.Dims)
+		tokens := scraper.Tokenize(text)
+		for j, tok := range tokens {
+			h.add(vec, tok)
+			if j > 0 {
+				h.add(vec, tokens[j-1]+" "+tok)
+			}
+		}
+		out[i] = normalizeVector(vec)
+	}
+	return out, nil
+}
+
+func (h hashEmbeddings) add(vec []float64, feature string) {
+	f := fnv.New64a()
+	f.Write([]byte(feature))
+	sum := f.Sum64()
+	sign := 1.0
+	if sum>>63 == 1 {
+		sign = -1
+	}
+	vec[sum%uint64(h.Dims)] += sign
+}
+
+// ollamaEmbeddings usa um modelo local servido pelo Ollama (/api/embed)
+type ollamaEmbeddings struct{ BaseURL, Model string }
+
+func (o ollamaEmbeddings) Name() string { return "ollama-" + o.Model }
+
+func (o ollamaEmbeddings) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	var result struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	err := scraper.PostJSON(ctx, o.BaseURL+"/api/embed", "", map[string]interface{}{"model": o.Model, "input": texts}, &result)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d texts", len(result.Embeddings), len(texts))
+	}
+	return result.Embeddings, nil
+}
+
+// openAIEmbeddings usa a API de embeddings da OpenAI (OPENAI_API_KEY)
+type openAIEmbeddings struct{ APIKey, Model string }
+
+func (o openAIEmbeddings) Name() string { return "openai-" + o.Model }
+
+func (o openAIEmbeddings) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	if o.APIKey == "" {
+		return nil, fmt.Errorf("OPENAI_API_KEY not set")
+	}
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	err := scraper.PostJSON(ctx, "https://api.openai.com/v1/embeddings", o.APIKey, map[string]interface{}{"model": o.Model, "input": texts}, &result)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]float64, len(texts))
+	for _, d := range result.Data {
+		if d.Index >= 0 && d.Index < len(out) {
+			out[d.Index] = d.Embedding
+		}
+	}
+	for i := range out {
+		if out[i] == nil {
+			return nil, fmt.Errorf("missing embedding for input %d", i)
+		}
+	}
+	return out, nil
+}
+
+func normalizeVector(vec []float64) []float64 {
+	var norm float64
+	for _, v := range vec {
+		norm += v * v
+	}
+	if norm == 0 {
+		return vec
+	}
+	norm = math.Sqrt(norm)
+	for i := range vec {
+		vec[i] /= norm
+	}
+	return vec
+}
+
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+func embeddingText(p *scraper.PropertyInfo) string {
+	return p.PropertyType + ". " + p.Bedrooms + ". " + p.Description
+}
+
+// refreshEmbeddings gera os vetores que faltam (ou estão desatualizados) em lotes de 32
+func refreshEmbeddings(ctx context.Context, provider embeddingProvider) error {
+	type pending struct {
+		id         string
+		text       string
+		analyzedAt time.Time
+	}
+	var todo []pending
+	store.View(func(d *storeData) {
+		for id, a := range d.Analyses {
+			e, ok := d.Embeddings[id]
+			if ok && e.Provider == provider.Name() && e.AnalyzedAt.Equal(a.AnalyzedAt) {
+				continue
+			}
+			if strings.TrimSpace(a.Property.Description) == "" {
+				continue
+			}
+			todo = append(todo, pending{id, embeddingText(&a.Property), a.AnalyzedAt})
+		}
+	})
+
+	for start := 0; start < len(todo); start += 32 {
+		end := start + 32
+		if end > len(todo) {
+			end = len(todo)
+		}
+		batch := todo[start:end]
+		texts := make([]string, len(batch))
+		for i, p := range batch {
+			texts[i] = p.text
+		}
+		vectors, err := provider.Embed(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("error generating embeddings: %w", err)
+		}
+		err = store.Update(func(d *storeData) error {
+			for i, p := range batch {
+				d.Embeddings[p.id] = &StoredEmbedding{Provider: provider.Name(), Vector: vectors[i], AnalyzedAt: p.analyzedAt}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// similarListings ordena as análises guardadas do tenant da requisição por
+// similaridade com a análise id
+func similarListings(ctx context.Context, id, providerName string, limit int) ([]SimilarListing, bool) {
+	results := []SimilarListing{}
+	found := false
+	store.View(func(d *storeData) {
+		target, ok := d.Embeddings[id]
+		if a, exists := d.Analyses[id]; !ok || !exists || !a.visibleTo(ctx) || target.Provider != providerName {
+			return
+		}
+		found = true
+		for otherID, e := range d.Embeddings {
+			a, ok := d.Analyses[otherID]
+			if otherID == id || !ok || !a.visibleTo(ctx) || e.Provider != providerName {
+				continue
+			}
+			results = append(results, SimilarListing{
+				ID:         otherID,
+				URL:        a.URL,
+				Address:    a.Property.Address,
+				Price:      a.Property.RentPrice,
+				Similarity: math.Round(cosineSimilarity(target.Vector, e.Vector)*1000) / 1000,
+			})
+		}
+	})
+	sort.Slice(results, func(i, j int) bool { return results[i].Similarity > results[j].Similarity })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, found
+}
+
+// handleSimilarListings é o handler HTTP para GET /analyses/similar?id=...|url=...&limit=10
+func handleSimilarListings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	id := r.URL.Query().Get("id")
+	if id == "" && r.URL.Query().Get("url") != "" {
+		id = analysisKeyFor(tenantID(r.Context()), r.URL.Query().Get("url"))
+	}
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "id or url query parameter is required")
+		return
+	}
+	limit := 10
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 50 {
+		limit = v
+	}
+
+	provider := embeddingProviderFromEnv()
+	if err := refreshEmbeddings(r.Context(), provider); err != nil {
+		telemetry.LogFor(r.Context()).Warn("Could not refresh embeddings", "error", err)
+		writeError(w, http.StatusBadGateway, "Embeddings provider unavailable")
+		return
+	}
+
+	results, found := similarListings(r.Context(), id, provider.Name(), limit)
+	if !found {
+		writeError(w, http.StatusNotFound, "Analysis not found or has no description")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestSimilarListings_HashEmbeddings(t *testing.T) {
+	prev := store
+	store = newMemoryStore()
+	defer func() { store = prev }()
+
+	now := time.Now()
+	store.Update(func(d *storeData) error {
+		for id, desc := range map[string]string{
+			"a": "Bright south facing two bed apartment with balcony, walking distance to the Luas.",
+			"b": "Sunny south facing 2 bed apartment with a balcony, minutes walk to the Luas stop.",
+			"c": "Large detached farmhouse on two acres with stables and a paddock.",
+		} {
+			d.Analyses[id] = &StoredAnalysis{ID: id, URL: "https://www.daft.ie/" + id, AnalyzedAt: now,
+				Property: scraper.PropertyInfo{Description: desc}}
+		}
+		return nil
+	})
+
+	provider := hashEmbeddings{Dims: 512}
+	if err := refreshEmbeddings(context.Background(), provider); err != nil {
+		t.Fatalf("refreshEmbeddings: %v", err)
+	}
+	results, found := similarListings(context.Background(), "a", provider.Name(), 10)
+	if !found || len(results) != 2 {
+		t.Fatalf("expected 2 results, got %v (found=%v)", results, found)
+	}
+	if results[0].ID != "b" || results[0].Similarity <= results[1].Similarity {
+		t.Errorf("expected b to be most similar to a, got %+v", results)
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+/* ───── Seleção de campos (?fields=a.b,c) ───────────────────────────── */
+
+// fieldTree é a árvore dos caminhos pedidos; um nó sem filhos seleciona o valor inteiro
+type fieldTree map[string]fieldTree
+
+// parseFields monta a árvore a partir de "property.price,safetyInfo.safetyScore"
+func parseFields(fields string) fieldTree {
+	tree := fieldTree{}
+	for _, path := range strings.Split(fields, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		node := tree
+		parts := strings.Split(path, ".")
+		for i, part := range parts {
+			child, ok := node[part]
+			if ok && len(child) == 0 {
+				break // o valor inteiro já foi pedido
+			}
+			if i == len(parts)-1 {
+				node[part] = fieldTree{}
+				break
+			}
+			if !ok {
+				child = fieldTree{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return tree
+}
+
+// project copia de src só o que a árvore seleciona; listas são projetadas item a item
+func (t fieldTree) project(src interface{}) interface{} {
+	if len(t) == 0 {
+		return src
+	}
+	switch v := src.(type) {
+	case map[string]interface{}:
+		out := map[string]interface{}{}
+		for key, sub := range t {
+			if value, ok := v[key]; ok {
+				out[key] = sub.project(value)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = t.project(item)
+		}
+		return out
+	default:
+		return src
+	}
+}
+
+// writeJSONFields escreve v como JSON, no contrato da versão da API (ver
+// api_versions.go) e recortado por ?fields= quando presente. Caminhos que não existem na
+// raiz mas existem em "property" (ex.: qualityOfLife.walkScore na resposta de /analyze)
+// são procurados lá.
+func writeJSONFields(w http.ResponseWriter, r *http.Request, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if isAPIV1(r.Context()) {
+		v = v1Response(v)
+	}
+	fields := r.URL.Query().Get("fields")
+	if fields == "" {
+		json.NewEncoder(w).Encode(v)
+		return
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error encoding response")
+		return
+	}
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		writeError(w, http.StatusInternalServerError, "Error encoding response")
+		return
+	}
+
+	tree := parseFields(fields)
+	if root, ok := doc.(map[string]interface{}); ok {
+		if property, ok := root["property"].(map[string]interface{}); ok {
+			for key, sub := range tree {
+				if _, atRoot := root[key]; atRoot {
+					continue
+				}
+				if _, inProperty := property[key]; !inProperty {
+					continue
+				}
+				nested, ok := tree["property"]
+				if !ok {
+					nested = fieldTree{}
+					tree["property"] = nested
+				}
+				if !ok || len(nested) > 0 {
+					nested[key] = sub
+				}
+				delete(tree, key)
+			}
+		}
+	}
+
+	json.NewEncoder(w).Encode(tree.project(doc))
+}
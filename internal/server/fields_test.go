@@ -0,0 +1,36 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestWriteJSONFields(t *testing.T) {
+	analysis := scraper.AnalysisResponse{Property: scraper.PropertyInfo{Address: "1 Main St, Dublin 1", RentPrice: "€2,000 per month"}}
+	analysis.Property.QualityOfLife.WalkScore = 85
+	analysis.Property.QualityOfLife.Amenities = []scraper.POI{{Name: "Tesco", Type: "supermarket", Distance: 0.2}, {Name: "Boots", Type: "pharmacy", Distance: 0.4}}
+	analysis.SafetyInfo.SafetyScore = 72
+
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/analyze?fields=property.price,qualityOfLife.walkScore,safetyInfo.safetyScore,qualityOfLife.amenities.name", nil)
+	writeJSONFields(rec, r, analysis)
+
+	want := `{"property":{"price":"€2,000 per month","qualityOfLife":{"amenities":[{"name":"Tesco"},{"name":"Boots"}],"walkScore":85}},"safetyInfo":{"safetyScore":72}}`
+	if got := strings.TrimSpace(rec.Body.String()); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestParseFieldsWholeValueWins(t *testing.T) {
+	tree := parseFields("property.qualityOfLife.walkScore,property.qualityOfLife, property.price")
+	qol := tree["property"]["qualityOfLife"]
+	if qol == nil || len(qol) != 0 {
+		t.Errorf("expected whole qualityOfLife to be selected, got %v", qol)
+	}
+	if _, ok := tree["property"]["price"]; !ok {
+		t.Error("expected property.price to be selected")
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Saída GeoJSON (Leaflet, QGIS) ───────────────────────────────── */
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONPoint           `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"` // [lng, lat], como manda a RFC 7946
+}
+
+func newPointFeature(lat, lng float64, props map[string]interface{}) geoJSONFeature {
+	return geoJSONFeature{
+		Type:       "Feature",
+		Geometry:   geoJSONPoint{Type: "Point", Coordinates: [2]float64{lng, lat}},
+		Properties: props,
+	}
+}
+
+// propertyGeoJSON monta a FeatureCollection com o imóvel e todos os POIs que têm coordenadas
+func propertyGeoJSON(a *enrich.Analyzer, p *scraper.PropertyInfo) geoJSONFeatureCollection {
+	fc := geoJSONFeatureCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+
+	if p.Coordinates.Lat != 0 || p.Coordinates.Lng != 0 {
+		fc.Features = append(fc.Features, newPointFeature(p.Coordinates.Lat, p.Coordinates.Lng, map[string]interface{}{
+			"kind":         "property",
+			"address":      p.Address,
+			"price":        p.RentPrice,
+			"url":          p.URL,
+			"overallScore": a.OverallScore(p),
+		}))
+	}
+
+	for _, group := range []struct {
+		category string
+		pois     []scraper.POI
+	}{
+		{"transport", p.QualityOfLife.PublicTransport},
+		{"amenity", p.QualityOfLife.Amenities},
+		{"entertainment", p.QualityOfLife.Entertainment},
+		{"garda", p.SafetyInfo.NearbyGardai},
+	} {
+		for _, poi := range group.pois {
+			if poi.Lat == 0 && poi.Lng == 0 {
+				continue
+			}
+			fc.Features = append(fc.Features, newPointFeature(poi.Lat, poi.Lng, map[string]interface{}{
+				"kind":           "poi",
+				"category":       group.category,
+				"name":           poi.Name,
+				"type":           poi.Type,
+				"distanceMeters": poi.DistanceMeters,
+				"walkMinutes":    poi.WalkMinutes,
+				"distance":       poi.Distance, // legado, em km
+				"duration":       poi.Duration, // legado
+			}))
+		}
+	}
+	return fc
+}
+
+// wantsGeoJSON indica se a resposta deve sair como GeoJSON (?format=geojson)
+func wantsGeoJSON(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "geojson"
+}
+
+func writeGeoJSON(a *enrich.Analyzer, w http.ResponseWriter, p *scraper.PropertyInfo) {
+	w.Header().Set("Content-Type", "application/geo+json")
+	json.NewEncoder(w).Encode(propertyGeoJSON(a, p))
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"testing"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestPropertyGeoJSON(t *testing.T) {
+	p := &scraper.PropertyInfo{Address: "1 Main St, Dublin 1"}
+	p.Coordinates.Lat, p.Coordinates.Lng = 53.35, -6.26
+	p.QualityOfLife.PublicTransport = []scraper.POI{
+		{Name: "Abbey Street", Type: "light_rail_station", Distance: 0.3, Lat: 53.348, Lng: -6.258},
+		{Name: "No coordinates", Distance: 0.5},
+	}
+	p.SafetyInfo.NearbyGardai = []scraper.POI{{Name: "Store Street", Type: "garda_station", Lat: 53.35, Lng: -6.25}}
+
+	fc := propertyGeoJSON((&enrich.Analyzer{}), p)
+	if fc.Type != "FeatureCollection" || len(fc.Features) != 3 {
+		t.Fatalf("expected 3 features, got %+v", fc)
+	}
+	if got := fc.Features[0].Geometry.Coordinates; got != [2]float64{-6.26, 53.35} {
+		t.Errorf("property coordinates should be [lng, lat], got %v", got)
+	}
+	if fc.Features[1].Properties["name"] != "Abbey Street" || fc.Features[1].Properties["category"] != "transport" {
+		t.Errorf("unexpected POI feature: %+v", fc.Features[1].Properties)
+	}
+	if fc.Features[2].Properties["category"] != "garda" {
+		t.Errorf("unexpected garda feature: %+v", fc.Features[2].Properties)
+	}
+}
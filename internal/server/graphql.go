@@ -0,0 +1,679 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── GraphQL: consultas seletivas sobre o grafo da análise ───────── */
+
+// Suporta o subconjunto de GraphQL que os clientes usam para recortar a análise:
+// operações query (nomeadas ou não), variáveis com default, aliases, argumentos
+// escalares e listas, e __typename. Fragments, diretivas, mutations e introspecção
+// não são suportados; o schema dos tipos está em /openapi.json.
+//
+// Os tipos são as próprias structs da API e os campos são as tags json, então o grafo
+// property → safetyInfo → nearbyGardai, qualityOfLife → POIs e valueAnalysis → similar
+// é o mesmo do REST:
+//
+//	{ analysis(url: "https://www.daft.ie/...") { address price safetyInfo { safetyRating } } }
+
+// graphqlRequest é o corpo de POST /graphql
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// graphqlError segue o formato de erro do spec; extensions.code reaproveita os códigos
+// do APIError
+type graphqlError struct {
+	Message    string            `json:"message"`
+	Path       []interface{}     `json:"path,omitempty"`
+	Extensions map[string]string `json:"extensions,omitempty"`
+}
+
+// graphqlResponse é a resposta de /graphql
+type graphqlResponse struct {
+	Data   interface{}    `json:"data"`
+	Errors []graphqlError `json:"errors,omitempty"`
+
+	mapsCalls int // chamadas ao Google Maps feitas pelos resolvers
+}
+
+// gqlField é um campo selecionado, com alias, argumentos e subcampos
+type gqlField struct {
+	Alias, Name string
+	Args        map[string]interface{}
+	Selections  []gqlField
+}
+
+// key é o nome do campo na resposta
+func (f gqlField) key() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// gqlOperation é uma operação do documento
+type gqlOperation struct {
+	Name       string
+	Defaults   map[string]interface{}
+	Selections []gqlField
+}
+
+/* Parser */
+
+type gqlParser struct {
+	src  string
+	pos  int
+	vars map[string]interface{} // variáveis da requisição, resolvidas durante o parse
+	op   *gqlOperation
+}
+
+func (p *gqlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// skip pula espaços, vírgulas e comentários (ignorados pela gramática do GraphQL)
+func (p *gqlParser) skip() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *gqlParser) peek() byte {
+	p.skip()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *gqlParser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// name lê um nome GraphQL: /[_A-Za-z][_0-9A-Za-z]*/
+func (p *gqlParser) name() (string, error) {
+	p.skip()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		letter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if letter || (p.pos > start && c >= '0' && c <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	if start == p.pos {
+		return "", p.errorf("expected a name")
+	}
+	return p.src[start:p.pos], nil
+}
+
+// parseGraphQL lê o documento e devolve a operação pedida (operationName, ou a única)
+func parseGraphQL(query, operationName string, vars map[string]interface{}) (*gqlOperation, error) {
+	p := &gqlParser{src: query, vars: vars}
+	var ops []*gqlOperation
+	for p.peek() != 0 {
+		op, err := p.parseOperation()
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	switch {
+	case len(ops) == 0:
+		return nil, fmt.Errorf("the document has no operations")
+	case operationName != "":
+		for _, op := range ops {
+			if op.Name == operationName {
+				return op, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown operation %q", operationName)
+	case len(ops) > 1:
+		return nil, fmt.Errorf("operationName is required when the document has several operations")
+	}
+	return ops[0], nil
+}
+
+// parseOperation lê uma operação: "{ ... }" ou "query Nome($var: Tipo) { ... }"
+func (p *gqlParser) parseOperation() (*gqlOperation, error) {
+	op := &gqlOperation{Defaults: map[string]interface{}{}}
+	p.op = op
+	if p.peek() != '{' {
+		kind, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case "query":
+		case "mutation", "subscription":
+			return nil, fmt.Errorf("%s operations are not supported", kind)
+		case "fragment":
+			return nil, fmt.Errorf("fragments are not supported")
+		default:
+			return nil, p.errorf("unexpected %q", kind)
+		}
+		if c := p.peek(); c != '{' && c != '(' {
+			if op.Name, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if p.peek() == '(' {
+			if err := p.parseVariableDefinitions(); err != nil {
+				return nil, err
+			}
+		}
+		if p.peek() == '@' {
+			return nil, fmt.Errorf("directives are not supported")
+		}
+	}
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.Selections = selections
+	return op, nil
+}
+
+// parseVariableDefinitions lê ($url: String!, $n: Int = 5) guardando os defaults
+func (p *gqlParser) parseVariableDefinitions() error {
+	p.pos++ // (
+	for p.peek() != ')' {
+		if err := p.expect('$'); err != nil {
+			return err
+		}
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(':'); err != nil {
+			return err
+		}
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if p.peek() == '=' {
+			p.pos++
+			value, err := p.parseValue()
+			if err != nil {
+				return err
+			}
+			p.op.Defaults[name] = value
+		}
+		if p.peek() == 0 {
+			return p.errorf("unterminated variable definitions")
+		}
+	}
+	p.pos++ // )
+	return nil
+}
+
+// skipType pula a anotação de tipo (String!, [Int], ...): os argumentos são validados
+// pelos resolvers
+func (p *gqlParser) skipType() error {
+	if p.peek() == '[' {
+		p.pos++
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if err := p.expect(']'); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+	if p.peek() == '!' {
+		p.pos++
+	}
+	return nil
+}
+
+func (p *gqlParser) parseSelectionSet() ([]gqlField, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	var fields []gqlField
+	for p.peek() != '}' {
+		switch p.peek() {
+		case 0:
+			return nil, p.errorf("unterminated selection set")
+		case '.':
+			return nil, fmt.Errorf("fragments are not supported")
+		}
+		field, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	p.pos++ // }
+	if len(fields) == 0 {
+		return nil, p.errorf("empty selection set")
+	}
+	return fields, nil
+}
+
+func (p *gqlParser) parseField() (gqlField, error) {
+	var f gqlField
+	name, err := p.name()
+	if err != nil {
+		return f, err
+	}
+	f.Name = name
+	if p.peek() == ':' {
+		p.pos++
+		f.Alias = name
+		if f.Name, err = p.name(); err != nil {
+			return f, err
+		}
+	}
+	if p.peek() == '(' {
+		p.pos++
+		f.Args = map[string]interface{}{}
+		for p.peek() != ')' {
+			arg, err := p.name()
+			if err != nil {
+				return f, err
+			}
+			if err := p.expect(':'); err != nil {
+				return f, err
+			}
+			value, err := p.parseValue()
+			if err != nil {
+				return f, err
+			}
+			f.Args[arg] = value
+			if p.peek() == 0 {
+				return f, p.errorf("unterminated arguments")
+			}
+		}
+		p.pos++ // )
+	}
+	if p.peek() == '@' {
+		return f, fmt.Errorf("directives are not supported")
+	}
+	if p.peek() == '{' {
+		if f.Selections, err = p.parseSelectionSet(); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+// parseValue lê um valor: variável, string, número, booleano, null, enum ou lista
+func (p *gqlParser) parseValue() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '$':
+		p.pos++
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if v, ok := p.vars[name]; ok {
+			return v, nil
+		}
+		return p.op.Defaults[name], nil
+	case c == '"':
+		return p.parseString()
+	case c == '[':
+		p.pos++
+		list := []interface{}{}
+		for p.peek() != ']' {
+			if p.peek() == 0 {
+				return nil, p.errorf("unterminated list")
+			}
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		p.pos++
+		return list, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return n, nil
+	case c == '{':
+		return nil, fmt.Errorf("input objects are not supported")
+	default:
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return name, nil // enum
+	}
+}
+
+func (p *gqlParser) parseString() (string, error) {
+	// strings GraphQL usam os mesmos escapes do JSON
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) && p.src[p.pos] != '"' {
+		if p.src[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		return "", p.errorf("unterminated string")
+	}
+	p.pos++
+	var s string
+	if err := json.Unmarshal([]byte(p.src[start:p.pos]), &s); err != nil {
+		return "", p.errorf("invalid string")
+	}
+	return s, nil
+}
+
+/* Validação e execução */
+
+// gqlRoot é um campo raiz: o tipo que devolve (para validar a seleção) e o resolver
+type gqlRoot struct {
+	typ     reflect.Type
+	resolve func(ctx context.Context, args map[string]interface{}) (interface{}, error)
+}
+
+var gqlRoots = map[string]gqlRoot{
+	// analysis(url: String, id: String, refresh: Boolean): a análise do anúncio, ou a
+	// guardada com esse id
+	"analysis": {reflect.TypeOf(scraper.PropertyInfo{}), func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		if id, _ := args["id"].(string); id != "" {
+			var (
+				property scraper.PropertyInfo
+				found    bool
+			)
+			store.View(func(d *storeData) {
+				if a, ok := d.Analyses[id]; ok && a.visibleTo(ctx) {
+					property, found = a.Property, true
+				}
+			})
+			if !found {
+				return nil, &scraper.APIError{Code: "NOT_FOUND", Message: "Analysis not found"}
+			}
+			return property, nil
+		}
+		url, _ := args["url"].(string)
+		if url == "" {
+			return nil, &scraper.APIError{Code: "INVALID_REQUEST", Message: "analysis requires url or id"}
+		}
+		refresh, _ := args["refresh"].(bool)
+		result, err := resolveAnalysis(ctx, url, refresh)
+		if err != nil {
+			_, apiErr := scrapeError(err)
+			return nil, apiErr
+		}
+		return result.Property, nil
+	}},
+	// analyses(status: String): as análises guardadas, mais recentes primeiro
+	"analyses": {reflect.TypeOf([]TrackedAnalysis{}), func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		status, _ := args["status"].(string)
+		if status != "" && !trackingStatuses[status] {
+			return nil, &scraper.APIError{Code: "INVALID_REQUEST", Message: "status must be one of: shortlisted, viewed, applied, rejected"}
+		}
+		return trackedAnalyses(ctx, status), nil
+	}},
+}
+
+// gqlKind classifica o tipo: object (struct), list ou leaf (escalares, datas e mapas)
+func gqlKind(t reflect.Type) (string, reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return "leaf", t
+	case t.Kind() == reflect.Struct:
+		return "object", t
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() != reflect.Uint8:
+		return "list", t
+	}
+	return "leaf", t
+}
+
+// typeName é o nome do tipo no GraphQL; structs anônimas herdam o nome do campo
+func typeName(t reflect.Type, field string) string {
+	if t.Name() != "" {
+		return t.Name()
+	}
+	return strings.ToUpper(field[:1]) + field[1:]
+}
+
+// validateSelection confere a seleção contra o tipo antes de executar qualquer coisa
+func validateSelection(fields []gqlField, t reflect.Type, parent string, path []interface{}) []graphqlError {
+	kind, t := gqlKind(t)
+	for kind == "list" {
+		kind, t = gqlKind(t.Elem())
+	}
+	var errs []graphqlError
+	if kind == "leaf" {
+		if len(fields) > 0 {
+			errs = append(errs, graphqlError{Message: fmt.Sprintf("Field %q must not have a selection since it is a scalar", parent), Path: path})
+		}
+		return errs
+	}
+	if len(fields) == 0 {
+		return append(errs, graphqlError{Message: fmt.Sprintf("Field %q of type %s must have a selection of subfields", parent, typeName(t, parent)), Path: path})
+	}
+
+	known := jsonFields(t)
+	for _, f := range fields {
+		fieldPath := append(append([]interface{}{}, path...), f.key())
+		if f.Name == "__typename" {
+			continue
+		}
+		ft, ok := known[f.Name]
+		if !ok {
+			errs = append(errs, graphqlError{Message: fmt.Sprintf("Cannot query field %q on type %s", f.Name, typeName(t, parent)), Path: fieldPath})
+			continue
+		}
+		if len(f.Args) > 0 {
+			errs = append(errs, graphqlError{Message: fmt.Sprintf("Field %q does not take arguments", f.Name), Path: fieldPath})
+		}
+		errs = append(errs, validateSelection(f.Selections, ft, f.Name, fieldPath)...)
+	}
+	return errs
+}
+
+// gqlObject mantém os campos na ordem da seleção, como pede o spec
+type gqlObject []struct {
+	key   string
+	value interface{}
+}
+
+func (o gqlObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, kv := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(kv.key)
+		value, err := json.Marshal(kv.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func (o *gqlObject) add(key string, value interface{}) {
+	*o = append(*o, struct {
+		key   string
+		value interface{}
+	}{key, value})
+}
+
+// selectValue recorta doc (o valor já convertido para JSON genérico) pela seleção
+func selectValue(doc interface{}, t reflect.Type, fields []gqlField, parent string) interface{} {
+	if doc == nil {
+		return nil
+	}
+	kind, t := gqlKind(t)
+	switch kind {
+	case "list":
+		items, ok := doc.([]interface{})
+		if !ok {
+			return nil
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			out[i] = selectValue(item, t.Elem(), fields, parent)
+		}
+		return out
+	case "object":
+		m, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		known := jsonFields(t)
+		out := gqlObject{}
+		for _, f := range fields {
+			if f.Name == "__typename" {
+				out.add(f.key(), typeName(t, parent))
+				continue
+			}
+			out.add(f.key(), selectValue(m[f.Name], known[f.Name], f.Selections, f.Name))
+		}
+		return out
+	}
+	return doc
+}
+
+// executeGraphQL valida e executa a operação; campos raiz que falham viram null com erro
+func executeGraphQL(ctx context.Context, op *gqlOperation) graphqlResponse {
+	var errs []graphqlError
+	for _, f := range op.Selections {
+		if f.Name == "__typename" {
+			continue
+		}
+		root, ok := gqlRoots[f.Name]
+		if !ok {
+			errs = append(errs, graphqlError{Message: fmt.Sprintf("Cannot query field %q on type Query", f.Name), Path: []interface{}{f.key()}})
+			continue
+		}
+		errs = append(errs, validateSelection(f.Selections, root.typ, f.Name, []interface{}{f.key()})...)
+	}
+	if len(errs) > 0 {
+		return graphqlResponse{Errors: errs}
+	}
+
+	data := gqlObject{}
+	mapsCalls := 0
+	for _, f := range op.Selections {
+		if f.Name == "__typename" {
+			data.add(f.key(), "Query")
+			continue
+		}
+		root := gqlRoots[f.Name]
+		value, err := root.resolve(ctx, f.Args)
+		if p, ok := value.(scraper.PropertyInfo); ok {
+			mapsCalls += p.MapsCalls()
+		}
+		if err == nil {
+			var raw []byte
+			if raw, err = json.Marshal(value); err == nil {
+				var doc interface{}
+				if err = json.Unmarshal(raw, &doc); err == nil {
+					data.add(f.key(), selectValue(doc, root.typ, f.Selections, f.Name))
+					continue
+				}
+			}
+		}
+		data.add(f.key(), nil)
+		gqlErr := graphqlError{Message: err.Error(), Path: []interface{}{f.key()}}
+		if apiErr, ok := err.(*scraper.APIError); ok {
+			gqlErr.Extensions = map[string]string{"code": apiErr.Code}
+		}
+		errs = append(errs, gqlErr)
+	}
+	return graphqlResponse{Data: data, Errors: errs, mapsCalls: mapsCalls}
+}
+
+// handleGraphQL é o handler HTTP para GET /graphql?query=... e POST /graphql
+func handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphqlRequest
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeError(w, http.StatusBadRequest, "variables must be a JSON object")
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Only GET and POST methods are allowed")
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	op, err := parseGraphQL(req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(graphqlResponse{Errors: []graphqlError{{Message: err.Error()}}})
+		return
+	}
+	result := executeGraphQL(r.Context(), op)
+	chargeMapsCalls(w, r, result.mapsCalls)
+	if result.Data == nil {
+		w.WriteHeader(http.StatusBadRequest) // não passou da validação
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func seedGraphQLAnalysis(t *testing.T) string {
+	t.Helper()
+	store = newMemoryStore()
+	t.Cleanup(func() { store = newMemoryStore() })
+
+	p := scraper.PropertyInfo{URL: "https://www.daft.ie/for-rent/apartment-1-main-street/123", Address: "1 Main Street, Dublin 6", RentPrice: "€2,000 per month"}
+	p.SafetyInfo.SafetyRating = 8
+	p.QualityOfLife.PublicTransport = []scraper.POI{{Name: "Ranelagh Luas", Type: "train_station", Distance: 0.3}}
+	p.ValueAnalysis.Similar = []scraper.SimilarProperty{{Address: "2 Main Street", Price: 1900}}
+	recordAnalysis(context.Background(), &p)
+	return p.URL
+}
+
+func postGraphQL(t *testing.T, req graphqlRequest) (int, string) {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	rec := httptest.NewRecorder()
+	handleGraphQL(rec, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+	return rec.Code, strings.TrimSpace(rec.Body.String())
+}
+
+func TestGraphQLSelectsRequestedFields(t *testing.T) {
+	url := seedGraphQLAnalysis(t)
+
+	code, body := postGraphQL(t, graphqlRequest{
+		Query: `query Card($url: String!) {
+			listing: analysis(url: $url) {
+				price
+				address
+				safetyInfo { safetyRating }
+				qualityOfLife { publicTransport { name distance } }
+				valueAnalysis { similar { price } }
+				__typename
+			}
+		}`,
+		Variables: map[string]interface{}{"url": url},
+	})
+	if code != http.StatusOK {
+		t.Fatalf("status = %d: %s", code, body)
+	}
+	// fields come back in selection order, under the alias
+	want := `{"data":{"listing":{"price":"€2,000 per month","address":"1 Main Street, Dublin 6",` +
+		`"safetyInfo":{"safetyRating":8},"qualityOfLife":{"publicTransport":[{"name":"Ranelagh Luas","distance":0.3}]},` +
+		`"valueAnalysis":{"similar":[{"price":1900}]},"__typename":"PropertyInfo"}}}`
+	if body != want {
+		t.Errorf("got  %s\nwant %s", body, want)
+	}
+}
+
+func TestGraphQLValidation(t *testing.T) {
+	seedGraphQLAnalysis(t)
+	cases := map[string]string{
+		`{ analysis(url: "x") { nope } }`:                         `Cannot query field \"nope\" on type PropertyInfo`,
+		`{ analysis(url: "x") { safetyInfo } }`:                   `must have a selection of subfields`,
+		`{ analysis(url: "x") { address { length } } }`:           `must not have a selection`,
+		`{ listings { address } }`:                                `Cannot query field \"listings\" on type Query`,
+		`mutation { analysis(url: "x") { address } }`:             `mutation operations are not supported`,
+		`{ analysis(url: "x") { ...Card } }`:                      `fragments are not supported`,
+		`{ analysis(url: "x") { address `:                         `unterminated selection set`,
+		`query A { analyses { id } } query B { analyses { id } }`: `operationName is required`,
+	}
+	for query, want := range cases {
+		code, body := postGraphQL(t, graphqlRequest{Query: query})
+		if code != http.StatusBadRequest || !strings.Contains(body, want) {
+			t.Errorf("%s: got %d %s, want 400 containing %s", query, code, body, want)
+		}
+	}
+}
+
+func TestGraphQLResolverErrors(t *testing.T) {
+	seedGraphQLAnalysis(t)
+
+	code, body := postGraphQL(t, graphqlRequest{Query: `{ analysis(id: "missing") { address } analyses { address } }`})
+	if code != http.StatusOK {
+		t.Fatalf("status = %d: %s", code, body)
+	}
+	var resp struct {
+		Data   map[string]json.RawMessage `json:"data"`
+		Errors []graphqlError             `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Data["analysis"]) != "null" || len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "NOT_FOUND" {
+		t.Errorf("expected analysis: null with a NOT_FOUND error, got %s", body)
+	}
+	if string(resp.Data["analyses"]) != `[{"address":"1 Main Street, Dublin 6"}]` {
+		t.Errorf("other root fields should still resolve, got %s", resp.Data["analyses"])
+	}
+}
+
+func TestGraphQLGet(t *testing.T) {
+	seedGraphQLAnalysis(t)
+	rec := httptest.NewRecorder()
+	handleGraphQL(rec, httptest.NewRequest(http.MethodGet, "/graphql?query=%7B+analyses+%7B+price+%7D+%7D", nil))
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"data":{"analyses":[{"price":"€2,000 per month"}]}}` {
+		t.Errorf("got %s", got)
+	}
+}
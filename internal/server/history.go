@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Histórico entre anúncios guardado no Store ──────────────────── */
+
+// storeBacked liga a ao Store do servidor: o histórico entre anúncios e os
+// comparáveis privados dos tenants
+func storeBacked(a *enrich.Analyzer) *enrich.Analyzer {
+	a.History = storeHistory{}
+	a.Value.PrivateComparables = findPrivateComparables
+	return a
+}
+
+// storeHistory é a History do servidor: as anotações, o índice de fotos, os anúncios
+// já vistos e as análises recentes ficam no Store
+type storeHistory struct{}
+
+func (storeHistory) Annotations(lat, lng float64) []scraper.Annotation {
+	return nearbyAnnotations(lat, lng)
+}
+
+func (storeHistory) DuplicatePhotos(ctx context.Context, property *scraper.PropertyInfo) error {
+	return detectDuplicatePhotos(ctx, property)
+}
+
+func (storeHistory) MarketHistory(property *scraper.PropertyInfo, now time.Time) (*scraper.MarketHistory, error) {
+	return trackMarketHistory(property, now)
+}
+
+func (storeHistory) AreaSupply(property *scraper.PropertyInfo, now time.Time) int {
+	return areaSupply(property, now)
+}
+
+// actFastSupplyWindow é até quando uma análise conta na oferta recente do bairro
+const actFastSupplyWindow = 14 * 24 * time.Hour
+
+// areaSupply conta os anúncios do mesmo tipo no mesmo bairro analisados recentemente
+func areaSupply(p *scraper.PropertyInfo, now time.Time) int {
+	suburb, _ := scraper.SplitLocation(p.Address)
+	if suburb == "" {
+		return 0
+	}
+	kind := p.ListingType
+	if kind == "" {
+		kind = scraper.ListingType(p.URL)
+	}
+
+	n := 0
+	store.View(func(d *storeData) {
+		for _, a := range d.Analyses {
+			if a.URL == p.URL || now.Sub(a.AnalyzedAt) > actFastSupplyWindow {
+				continue
+			}
+			other, _ := scraper.SplitLocation(a.Property.Address)
+			t := a.Property.ListingType
+			if t == "" {
+				t = scraper.ListingType(a.URL)
+			}
+			if other == suburb && t == kind {
+				n++
+			}
+		}
+	})
+	return n
+}
@@ -0,0 +1,22 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestAreaSupply(t *testing.T) {
+	prev := store
+	store = newMemoryStore()
+	t.Cleanup(func() { store = prev })
+	for i, addr := range []string{"1 A Rd, Rathmines, Dublin 6", "2 B Rd, Rathmines, Dublin 6", "3 C Rd, Ranelagh, Dublin 6"} {
+		recordAnalysis(context.Background(), &scraper.PropertyInfo{URL: "https://www.daft.ie/for-rent/x/" + string(rune('a'+i)), Address: addr, ListingType: "rent"})
+	}
+	p := &scraper.PropertyInfo{URL: "https://www.daft.ie/for-rent/x/z", Address: "9 D Rd, Rathmines, Dublin 6", ListingType: "rent"}
+	if n := areaSupply(p, time.Now()); n != 2 {
+		t.Errorf("areaSupply = %d, want 2", n)
+	}
+}
@@ -0,0 +1,305 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/env"
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Fila de jobs no SQLite ──────────────────────────────────────── */
+
+// A fila fica numa tabela do SQLite (JOBS_DB, padrão DATA_DIR/jobs.db), fora do store:
+// pegar, concluir ou reenviar um job grava só a linha dele numa transação, em vez de
+// regravar o store inteiro. No Lambda, onde nenhum worker roda, ela fica em memória.
+// Os jobs que um store de versão anterior ainda guarda passam para cá na abertura
+// (ver migrateStoreJobs).
+
+// jobStore é a fila persistente. Uma conexão só: as transações ficam em série, e cada
+// conexão a ":memory:" seria um banco diferente.
+type jobStore struct {
+	db *sql.DB
+}
+
+// jobQueue é a fila do servidor, aberta em setup(); antes disso (e nos testes) fica
+// em memória
+var jobQueue = newMemoryJobStore()
+
+func newMemoryJobStore() *jobStore {
+	q, err := openJobStore(":memory:")
+	if err != nil {
+		panic(err) // só acontece sem o driver do SQLite
+	}
+	return q
+}
+
+// openJobStoreFromEnv abre a fila em JOBS_DB ou em DATA_DIR/jobs.db
+func openJobStoreFromEnv() (*jobStore, error) {
+	if lambdaMode {
+		return openJobStore(":memory:")
+	}
+	return openJobStore(env.Or("JOBS_DB", filepath.Join(env.Or("DATA_DIR", "data"), "jobs.db")))
+}
+
+// openJobStore abre (ou cria) a fila em path; ":memory:" fica só na memória
+func openJobStore(path string) (*jobStore, error) {
+	if path != ":memory:" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("error creating the jobs dir: %w", err)
+		}
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		`PRAGMA journal_mode = WAL`,
+		`CREATE TABLE IF NOT EXISTS jobs (
+			id          TEXT PRIMARY KEY,
+			kind        TEXT NOT NULL,
+			target      TEXT NOT NULL,
+			batch_id    TEXT NOT NULL DEFAULT '',
+			tenant      TEXT NOT NULL DEFAULT '',
+			status      TEXT NOT NULL,
+			attempts    INTEGER NOT NULL DEFAULT 0,
+			last_error  TEXT,
+			created_at  INTEGER NOT NULL,
+			run_after   INTEGER NOT NULL,
+			finished_at INTEGER
+		)`,
+		`CREATE INDEX IF NOT EXISTS jobs_ready ON jobs (status, run_after)`,
+		`CREATE INDEX IF NOT EXISTS jobs_target ON jobs (kind, target)`,
+		`CREATE INDEX IF NOT EXISTS jobs_batch ON jobs (batch_id, tenant)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("create the job queue: %w", err)
+		}
+	}
+	return &jobStore{db: db}, nil
+}
+
+const jobColumns = `id, kind, target, batch_id, tenant, status, attempts, last_error, created_at, run_after, finished_at`
+
+// rowScanner é *sql.Row ou *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(row rowScanner) (*Job, error) {
+	var (
+		j         Job
+		lastError sql.NullString
+		created   int64
+		runAfter  int64
+		finished  sql.NullInt64
+	)
+	if err := row.Scan(&j.ID, &j.Kind, &j.Target, &j.BatchID, &j.Tenant, &j.Status, &j.Attempts,
+		&lastError, &created, &runAfter, &finished); err != nil {
+		return nil, err
+	}
+	if lastError.Valid {
+		j.LastError = &scraper.APIError{}
+		if err := json.Unmarshal([]byte(lastError.String), j.LastError); err != nil {
+			return nil, fmt.Errorf("error decoding job %s: %w", j.ID, err)
+		}
+	}
+	j.CreatedAt, j.RunAfter = fromUnixNano(created), fromUnixNano(runAfter)
+	if finished.Valid {
+		t := fromUnixNano(finished.Int64)
+		j.FinishedAt = &t
+	}
+	return &j, nil
+}
+
+// writeJob grava o job com verb ("INSERT OR REPLACE" ou, na migração, "INSERT OR IGNORE")
+func writeJob(tx *sql.Tx, verb string, j *Job) error {
+	var lastError, finished any
+	if j.LastError != nil {
+		raw, err := json.Marshal(j.LastError)
+		if err != nil {
+			return fmt.Errorf("error encoding job %s: %w", j.ID, err)
+		}
+		lastError = string(raw)
+	}
+	if j.FinishedAt != nil {
+		finished = unixNano(*j.FinishedAt)
+	}
+	_, err := tx.Exec(verb+` INTO jobs (`+jobColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		j.ID, j.Kind, j.Target, j.BatchID, j.Tenant, j.Status, j.Attempts,
+		lastError, unixNano(j.CreatedAt), unixNano(j.RunAfter), finished)
+	return err
+}
+
+// unixNano e fromUnixNano guardam os horários em nanossegundos; 0 é o horário zero
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+func (q *jobStore) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// enqueue grava job, a não ser que já haja um não concluído do mesmo tipo, alvo, lote
+// e tenant; devolve o que ficou na fila
+func (q *jobStore) enqueue(job Job) (Job, error) {
+	queued := job
+	err := q.inTx(func(tx *sql.Tx) error {
+		existing, err := scanJob(tx.QueryRow(`SELECT `+jobColumns+` FROM jobs
+			WHERE kind = ? AND target = ? AND batch_id = ? AND tenant = ? AND status NOT IN ('done', 'dead')
+			LIMIT 1`, job.Kind, job.Target, job.BatchID, job.Tenant))
+		switch {
+		case err == nil:
+			queued = *existing
+			return nil
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+		return writeJob(tx, "INSERT OR REPLACE", &job)
+	})
+	return queued, err
+}
+
+// claim marca como running o job pronto para rodar há mais tempo, e apaga os que
+// terminaram antes de now - retention
+func (q *jobStore) claim(now time.Time, retention time.Duration) (*Job, error) {
+	var claimed *Job
+	err := q.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM jobs WHERE status IN ('done', 'dead') AND finished_at < ?`,
+			unixNano(now.Add(-retention))); err != nil {
+			return err
+		}
+		j, err := scanJob(tx.QueryRow(`SELECT `+jobColumns+` FROM jobs
+			WHERE status IN ('pending', 'failed') AND run_after <= ?
+			ORDER BY run_after LIMIT 1`, unixNano(now)))
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		j.Status = "running"
+		j.Attempts++
+		claimed = j
+		return writeJob(tx, "INSERT OR REPLACE", j)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// update aplica fn ao job id numa transação; errJobNotFound se ele não existe, e um
+// erro de fn desfaz a alteração
+func (q *jobStore) update(id string, fn func(j *Job) error) (Job, error) {
+	var updated Job
+	err := q.inTx(func(tx *sql.Tx) error {
+		j, err := scanJob(tx.QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return errJobNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(j); err != nil {
+			return err
+		}
+		updated = *j
+		return writeJob(tx, "INSERT OR REPLACE", j)
+	})
+	return updated, err
+}
+
+// requeueRunning devolve à fila os jobs que estavam rodando; devolve quantos
+func (q *jobStore) requeueRunning() (int64, error) {
+	res, err := q.db.Exec(`UPDATE jobs SET status = 'pending' WHERE status = 'running'`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// list devolve os jobs com um dos status, ou todos sem status, na ordem de criação
+func (q *jobStore) list(statuses ...string) ([]Job, error) {
+	query, args := `SELECT `+jobColumns+` FROM jobs`, []any{}
+	if len(statuses) > 0 {
+		query += ` WHERE status IN (?` + strings.Repeat(`, ?`, len(statuses)-1) + `)`
+		for _, s := range statuses {
+			args = append(args, s)
+		}
+	}
+	return q.query(query+` ORDER BY created_at, id`, args...)
+}
+
+// batch devolve os jobs do lote do tenant, na ordem em que entraram
+func (q *jobStore) batch(batchID, tenant string) ([]Job, error) {
+	return q.query(`SELECT `+jobColumns+` FROM jobs WHERE batch_id = ? AND tenant = ? ORDER BY created_at, id`, batchID, tenant)
+}
+
+func (q *jobStore) query(query string, args ...any) ([]Job, error) {
+	rows, err := q.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	jobs := []Job{}
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *j)
+	}
+	return jobs, rows.Err()
+}
+
+// migrateStoreJobs passa para a fila os jobs que um store de versão anterior ainda
+// guarda e os tira do store. Os que já estão na fila (de uma migração interrompida)
+// ficam como estão.
+func migrateStoreJobs(q *jobStore) error {
+	return store.Update(func(d *storeData) error {
+		if len(d.Jobs) == 0 {
+			return nil
+		}
+		if err := q.inTx(func(tx *sql.Tx) error {
+			for _, j := range d.Jobs {
+				if err := writeJob(tx, "INSERT OR IGNORE", j); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		slog.Info("Moved the job queue out of the store", "jobs", len(d.Jobs))
+		d.Jobs = nil
+		return nil
+	})
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestJobStoreSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "jobs.db")
+	q, err := openJobStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	queued, err := q.enqueue(Job{ID: "j1", Kind: jobAnalyze, Target: "https://www.daft.ie/for-rent/a/1", Status: "pending", CreatedAt: now, RunAfter: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.claim(now, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.update(queued.ID, func(j *Job) error {
+		j.Status, j.LastError = "failed", &scraper.APIError{Code: "SCRAPE_BLOCKED", Retryable: true}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	q.db.Close()
+
+	reopened, err := openJobStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.db.Close()
+	jobs, err := reopened.list("failed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].Attempts != 1 || jobs[0].LastError == nil || jobs[0].LastError.Code != "SCRAPE_BLOCKED" ||
+		!jobs[0].CreatedAt.Equal(now) {
+		t.Errorf("reopened queue = %+v", jobs)
+	}
+	if _, err := reopened.update("missing", func(*Job) error { return nil }); err != errJobNotFound {
+		t.Errorf("updating a missing job: %v, want errJobNotFound", err)
+	}
+}
+
+func TestMigrateStoreJobs(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "store.json"), []byte(`{"jobs": {
+		"j1": {"id": "j1", "kind": "analyze", "target": "https://www.daft.ie/for-rent/a/1", "status": "pending"},
+		"j2": {"id": "j2", "kind": "watch_check", "target": "w1", "status": "dead", "lastError": {"code": "LISTING_NOT_FOUND"}}
+	}}`), 0o644)
+	s, err := openStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := store
+	store = s
+	t.Cleanup(func() { store = prev })
+	q := newMemoryJobStore()
+	defer q.db.Close()
+
+	if err := migrateStoreJobs(q); err != nil {
+		t.Fatal(err)
+	}
+	if jobs, _ := q.list(); len(jobs) != 2 {
+		t.Errorf("migrated %d jobs, want 2", len(jobs))
+	}
+	reopened, err := openStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.View(func(d *storeData) {
+		if len(d.Jobs) != 0 {
+			t.Errorf("the store still holds %d jobs", len(d.Jobs))
+		}
+	})
+}
@@ -0,0 +1,284 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/env"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Fila de jobs persistente ────────────────────────────────────── */
+
+// Os jobs ficam numa tabela do SQLite (ver job_store.go), então sobrevivem a um crash
+// ou restart: um job que estava rodando volta para a fila na partida (recoverJobs). Uma
+// falha temporária é tentada de novo com espera exponencial; depois de
+// JOB_MAX_ATTEMPTS tentativas, ou numa falha permanente (anúncio removido, URL
+// inválida), o job vai para a fila de mortos (dead) e aparece em GET /admin/jobs.
+
+const (
+	jobAnalyze    = "analyze"     // Target é a URL do anúncio
+	jobWatchCheck = "watch_check" // Target é o ID do watch
+	jobReanalyze  = "reanalyze"   // Target é a URL do anúncio; ignora o cache
+)
+
+// Job é um trabalho na fila. Status: pending (esperando), running, failed (a última
+// tentativa falhou; roda de novo em RunAfter), done ou dead.
+type Job struct {
+	ID         string            `json:"id"`
+	Kind       string            `json:"kind"`
+	Target     string            `json:"target"`
+	BatchID    string            `json:"batchId,omitempty"`
+	Tenant     string            `json:"tenant,omitempty"` // roda em nome deste tenant; "" é o padrão
+	Status     string            `json:"status"`
+	Attempts   int               `json:"attempts"`
+	LastError  *scraper.APIError `json:"lastError,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	RunAfter   time.Time         `json:"runAfter"`
+	FinishedAt *time.Time        `json:"finishedAt,omitempty"`
+}
+
+// finished informa se o job saiu da fila (concluído ou morto)
+func (j *Job) finished() bool {
+	return j.Status == "done" || j.Status == "dead"
+}
+
+// jobRunners executa cada tipo de job
+var jobRunners = map[string]func(ctx context.Context, target string) error{
+	jobAnalyze: func(ctx context.Context, target string) error {
+		_, err := analyzeListing(ctx, target)
+		return err
+	},
+	jobWatchCheck: runWatchCheck,
+	jobReanalyze: func(ctx context.Context, target string) error {
+		_, err := resolveAnalysis(ctx, target, true)
+		return err
+	},
+}
+
+// jobWake acorda um worker parado quando um job entra na fila
+var jobWake = make(chan struct{}, 1)
+
+// jobMaxAttempts é JOB_MAX_ATTEMPTS (padrão 5)
+func jobMaxAttempts() int {
+	return env.Int("JOB_MAX_ATTEMPTS", 5)
+}
+
+// jobRetryDelay é a espera antes da tentativa seguinte: JOB_RETRY_BASE_DELAY (padrão
+// 30s) dobrando a cada falha, até 30 minutos
+func jobRetryDelay(attempts int) time.Duration {
+	base := 30 * time.Second
+	if d, err := time.ParseDuration(os.Getenv("JOB_RETRY_BASE_DELAY")); err == nil && d > 0 {
+		base = d
+	}
+	delay := time.Duration(float64(base) * math.Pow(2, float64(attempts-1)))
+	if delay > 30*time.Minute || delay <= 0 {
+		delay = 30 * time.Minute
+	}
+	return delay
+}
+
+// jobRetention é por quanto tempo os jobs concluídos e os mortos continuam na fila
+// (JOB_RETENTION, padrão 7 dias), para consulta dos lotes e do admin
+func jobRetention() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("JOB_RETENTION")); err == nil && d > 0 {
+		return d
+	}
+	return 7 * 24 * time.Hour
+}
+
+// enqueueJob põe um job na fila em nome do tenant de ctx. Um job ainda não concluído
+// do mesmo tipo, alvo, lote e tenant é devolvido no lugar de um novo.
+func enqueueJob(ctx context.Context, kind, target, batchID string) (*Job, error) {
+	now := time.Now()
+	job, err := jobQueue.enqueue(Job{ID: newID(), Kind: kind, Target: target, BatchID: batchID, Tenant: tenantID(ctx),
+		Status: "pending", CreatedAt: now, RunAfter: now})
+	if err != nil {
+		return nil, err
+	}
+	wakeJobWorkers()
+	return &job, nil
+}
+
+// wakeJobWorkers avisa um worker parado que há job pronto
+func wakeJobWorkers() {
+	select {
+	case jobWake <- struct{}{}:
+	default:
+	}
+}
+
+// recoverJobs devolve à fila os jobs que estavam rodando quando o processo caiu
+func recoverJobs() {
+	recovered, err := jobQueue.requeueRunning()
+	if err != nil {
+		slog.Warn("Could not recover interrupted jobs", "error", err)
+	} else if recovered > 0 {
+		slog.Info("Requeued jobs interrupted by the last shutdown", "jobs", recovered)
+	}
+}
+
+// claimJob marca como running o job mais antigo pronto para rodar, e aproveita para
+// tirar da fila os que terminaram há mais de jobRetention
+func claimJob(now time.Time) (*Job, error) {
+	return jobQueue.claim(now, jobRetention())
+}
+
+// finishJob registra o resultado de uma tentativa
+func finishJob(job *Job, runErr error, now time.Time) error {
+	_, err := jobQueue.update(job.ID, func(stored *Job) error {
+		if runErr == nil {
+			stored.Status, stored.LastError, stored.FinishedAt = "done", nil, &now
+			return nil
+		}
+
+		_, apiErr := scrapeError(runErr)
+		stored.LastError = apiErr
+		if !apiErr.Retryable || stored.Attempts >= jobMaxAttempts() {
+			stored.Status, stored.FinishedAt = "dead", &now
+			return nil
+		}
+		stored.Status, stored.RunAfter = "failed", now.Add(jobRetryDelay(stored.Attempts))
+		return nil
+	})
+	if errors.Is(err, errJobNotFound) {
+		return nil // apagado enquanto rodava
+	}
+	return err
+}
+
+// runJob executa uma tentativa do job
+func runJob(job *Job) {
+	l := slog.Default().With("job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+	if job.Tenant != "" {
+		l = l.With("tenant", job.Tenant)
+	}
+	ctx, known := tenantContext(telemetry.WithLogger(serverContext, l), job.Tenant)
+
+	run, ok := jobRunners[job.Kind]
+	var err error
+	switch {
+	case !ok:
+		err = fmt.Errorf("unknown job kind %q", job.Kind)
+	case !known:
+		err = fmt.Errorf("unknown tenant %q", job.Tenant)
+	default:
+		err = run(ctx, job.Target)
+	}
+	if err != nil {
+		l.Warn("Job failed", "target", job.Target, "error", err)
+	}
+	if err := finishJob(job, err, time.Now()); err != nil {
+		l.Warn("Could not save the job result", "error", err)
+	}
+}
+
+// jobWorkers é JOB_WORKERS (padrão 2)
+func jobWorkers() int {
+	return env.Int("JOB_WORKERS", 2)
+}
+
+// startJobWorkers devolve à fila os jobs interrompidos e sobe os workers, que
+// processam a fila até o desligamento; o job em andamento termina antes de o worker
+// sair
+func startJobWorkers() {
+	recoverJobs()
+	for i := 0; i < jobWorkers(); i++ {
+		goBackground(runJobWorker)
+	}
+}
+
+func runJobWorker() {
+	for stopping.Err() == nil {
+		job, err := claimJob(time.Now())
+		if err != nil {
+			slog.Warn("Could not claim a job", "error", err)
+		}
+		if job == nil {
+			// sem trabalho: espera um job novo ou, no máximo, alguns segundos pelas
+			// novas tentativas agendadas
+			select {
+			case <-jobWake:
+			case <-time.After(5 * time.Second):
+			case <-stopping.Done():
+			}
+			continue
+		}
+		runJob(job)
+	}
+}
+
+/* ───── GET /admin/jobs ─────────────────────────────────────────────── */
+
+// handleAdminJobs lista os jobs (GET, ?status=pending,failed,dead; por padrão todos
+// os que não terminaram bem) e reenvia um job morto ou com falha (POST
+// /admin/jobs/{id}/retry)
+func handleAdminJobs(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/jobs"), "/"); rest != "" {
+		id, action, _ := strings.Cut(rest, "/")
+		if action != "retry" || r.Method != http.MethodPost {
+			writeError(w, http.StatusNotFound, "Not found")
+			return
+		}
+		retryJob(w, id)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+
+	statuses := splitList(r.URL.Query().Get("status"))
+	if len(statuses) == 0 {
+		statuses = []string{"pending", "running", "failed", "dead"}
+	}
+	jobs, err := jobQueue.list(statuses...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Could not read the job queue")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+func retryJob(w http.ResponseWriter, id string) {
+	job, err := jobQueue.update(id, func(stored *Job) error {
+		if stored.Status != "failed" && stored.Status != "dead" {
+			return errNotRetryable
+		}
+		stored.Status, stored.Attempts, stored.RunAfter, stored.FinishedAt = "pending", 0, time.Now(), nil
+		return nil
+	})
+	switch {
+	case errors.Is(err, errJobNotFound):
+		writeError(w, http.StatusNotFound, "Job not found")
+		return
+	case errors.Is(err, errNotRetryable):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "Could not save the job")
+		return
+	}
+	wakeJobWorkers()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+var (
+	errJobNotFound  = errors.New("job not found")
+	errNotRetryable = errors.New("only failed or dead jobs can be retried")
+)
@@ -0,0 +1,258 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func useJobStore(t *testing.T) {
+	t.Helper()
+	prev, prevQueue := store, jobQueue
+	store, jobQueue = newMemoryStore(), newMemoryJobStore()
+	t.Cleanup(func() {
+		jobQueue.db.Close()
+		store, jobQueue = prev, prevQueue
+	})
+}
+
+// putJob writes job straight into the queue
+func putJob(t *testing.T, job Job) {
+	t.Helper()
+	if err := jobQueue.inTx(func(tx *sql.Tx) error { return writeJob(tx, "INSERT OR REPLACE", &job) }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// storedJob reads a job straight from the queue; ok is false when it is gone
+func storedJob(t *testing.T, id string) (job Job, ok bool) {
+	t.Helper()
+	jobs, err := jobQueue.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, j := range jobs {
+		if j.ID == id {
+			return j, true
+		}
+	}
+	return Job{}, false
+}
+
+// stubJobRunner replaces the runner of kind for the duration of the test
+func stubJobRunner(t *testing.T, kind string, run func(ctx context.Context, target string) error) {
+	t.Helper()
+	prev := jobRunners[kind]
+	jobRunners[kind] = run
+	t.Cleanup(func() { jobRunners[kind] = prev })
+}
+
+func TestEnqueueJobDeduplicates(t *testing.T) {
+	useJobStore(t)
+	a, _ := enqueueJob(context.Background(), jobAnalyze, "https://www.daft.ie/for-rent/a/1", "")
+	b, _ := enqueueJob(context.Background(), jobAnalyze, "https://www.daft.ie/for-rent/a/1", "")
+	if a.ID != b.ID {
+		t.Errorf("the same unfinished job was queued twice: %s and %s", a.ID, b.ID)
+	}
+	c, _ := enqueueJob(context.Background(), jobAnalyze, "https://www.daft.ie/for-rent/a/1", "batch-1")
+	if c.ID == a.ID {
+		t.Error("a job of another batch should be queued separately")
+	}
+}
+
+func TestJobRetryAndDeadLetter(t *testing.T) {
+	useJobStore(t)
+	t.Setenv("JOB_MAX_ATTEMPTS", "2")
+	t.Setenv("JOB_RETRY_BASE_DELAY", "1m")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	queued, _ := enqueueJob(context.Background(), jobAnalyze, "https://www.daft.ie/for-rent/a/1", "")
+	queued.RunAfter = now
+	putJob(t, *queued)
+
+	job, _ := claimJob(now)
+	if job == nil || job.Attempts != 1 {
+		t.Fatalf("claimed %+v", job)
+	}
+	if again, _ := claimJob(now); again != nil {
+		t.Fatal("a running job was claimed twice")
+	}
+
+	// a temporary failure waits for the backoff
+	finishJob(job, scraper.ErrScrapeBlocked, now)
+	if job, _ := claimJob(now.Add(30 * time.Second)); job != nil {
+		t.Fatal("the retry ran before its delay")
+	}
+	job, _ = claimJob(now.Add(time.Minute))
+	if job == nil || job.Attempts != 2 {
+		t.Fatalf("retry claimed %+v", job)
+	}
+
+	// the last attempt sends the job to the dead-letter list
+	finishJob(job, scraper.ErrScrapeBlocked, now.Add(time.Minute))
+	if stored, _ := storedJob(t, job.ID); stored.Status != "dead" || stored.LastError == nil || stored.LastError.Code != "SCRAPE_BLOCKED" {
+		t.Errorf("after max attempts: %+v", stored)
+	}
+}
+
+func TestJobPermanentFailureIsNotRetried(t *testing.T) {
+	useJobStore(t)
+	queued, _ := enqueueJob(context.Background(), jobAnalyze, "https://www.daft.ie/for-rent/gone/1", "")
+	job, _ := claimJob(queued.RunAfter)
+	finishJob(job, scraper.ErrListingNotFound, time.Now())
+	if stored, _ := storedJob(t, job.ID); stored.Status != "dead" {
+		t.Errorf("a removed listing should not be retried: %+v", stored)
+	}
+}
+
+func TestJobRetryDelay(t *testing.T) {
+	t.Setenv("JOB_RETRY_BASE_DELAY", "10s")
+	for attempts, want := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 4: 80 * time.Second, 20: 30 * time.Minute} {
+		if got := jobRetryDelay(attempts); got != want {
+			t.Errorf("jobRetryDelay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestRecoverJobsRequeuesRunning(t *testing.T) {
+	useJobStore(t)
+	queued, _ := enqueueJob(context.Background(), jobWatchCheck, "w1", "")
+	claimJob(queued.RunAfter)
+	recoverJobs()
+	if stored, _ := storedJob(t, queued.ID); stored.Status != "pending" {
+		t.Errorf("interrupted job status %q, want pending", stored.Status)
+	}
+}
+
+func TestClaimJobPrunesOldFinishedJobs(t *testing.T) {
+	useJobStore(t)
+	old := time.Now().Add(-8 * 24 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+	putJob(t, Job{ID: "old", Status: "done", FinishedAt: &old})
+	putJob(t, Job{ID: "recent", Status: "dead", FinishedAt: &recent})
+	claimJob(time.Now())
+	if _, ok := storedJob(t, "old"); ok {
+		t.Error("a job finished past the retention was kept")
+	}
+	if _, ok := storedJob(t, "recent"); !ok {
+		t.Error("a job finished within the retention was pruned")
+	}
+}
+
+func TestAdminJobsListAndRetry(t *testing.T) {
+	useJobStore(t)
+	t.Setenv("ADMIN_TOKEN", "s3cret")
+	putJob(t, Job{ID: "dead", Kind: jobAnalyze, Status: "dead", Attempts: 5, LastError: &scraper.APIError{Code: "SCRAPE_BLOCKED"}})
+	putJob(t, Job{ID: "done", Kind: jobAnalyze, Status: "done"})
+	call := func(method, target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if token != "" {
+			req.Header.Set("X-Admin-Token", token)
+		}
+		rec := httptest.NewRecorder()
+		handleAdminJobs(rec, req)
+		return rec
+	}
+
+	if rec := call(http.MethodGet, "/admin/jobs", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without a token: status %d, want 401", rec.Code)
+	}
+
+	rec := call(http.MethodGet, "/admin/jobs", "s3cret")
+	var jobs []Job
+	json.NewDecoder(rec.Body).Decode(&jobs)
+	if len(jobs) != 1 || jobs[0].ID != "dead" {
+		t.Errorf("default listing = %+v, want only the dead job", jobs)
+	}
+	rec = call(http.MethodGet, "/admin/jobs?status=done", "s3cret")
+	json.NewDecoder(rec.Body).Decode(&jobs)
+	if len(jobs) != 1 || jobs[0].ID != "done" {
+		t.Errorf("?status=done = %+v", jobs)
+	}
+
+	if rec := call(http.MethodPost, "/admin/jobs/done/retry", "s3cret"); rec.Code != http.StatusConflict {
+		t.Errorf("retrying a done job: status %d, want 409", rec.Code)
+	}
+	if rec := call(http.MethodPost, "/admin/jobs/missing/retry", "s3cret"); rec.Code != http.StatusNotFound {
+		t.Errorf("retrying a missing job: status %d, want 404", rec.Code)
+	}
+	if rec := call(http.MethodPost, "/admin/jobs/dead/retry", "s3cret"); rec.Code != http.StatusOK {
+		t.Fatalf("retry: status %d: %s", rec.Code, rec.Body)
+	}
+	if job, _ := storedJob(t, "dead"); job.Status != "pending" || job.Attempts != 0 {
+		t.Errorf("retried job = %+v", job)
+	}
+}
+
+func TestAsyncBatch(t *testing.T) {
+	useJobStore(t)
+	stubJobRunner(t, jobAnalyze, func(ctx context.Context, target string) error {
+		if strings.HasSuffix(target, "/gone") {
+			return scraper.ErrListingNotFound
+		}
+		return store.Update(func(d *storeData) error {
+			d.Analyses[analysisKey(target)] = &StoredAnalysis{URL: target, Property: scraper.PropertyInfo{Address: "1 Main St"}}
+			return nil
+		})
+	})
+
+	body := `{"urls":["https://www.daft.ie/for-rent/a/1","https://www.daft.ie/for-rent/gone"]}`
+	req := httptest.NewRequest(http.MethodPost, "/analyze/batch?async=true", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handleAnalyzeBatch(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status %d, want 202: %s", rec.Code, rec.Body)
+	}
+	var queued BatchJob
+	json.NewDecoder(rec.Body).Decode(&queued)
+	if location := rec.Header().Get("Location"); location != "/analyze/batch/"+queued.BatchID ||
+		queued.Done || len(queued.Results) != 2 || queued.Results[0].Status != "pending" {
+		t.Fatalf("queued batch %+v (Location %q)", queued, location)
+	}
+
+	for job, _ := claimJob(time.Now()); job != nil; job, _ = claimJob(time.Now()) {
+		runJob(job)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/analyze/batch/"+queued.BatchID, nil)
+	rec = httptest.NewRecorder()
+	handleBatchStatus(rec, req)
+	var batch BatchJob
+	json.NewDecoder(rec.Body).Decode(&batch)
+	if !batch.Done || len(batch.Results) != 2 {
+		t.Fatalf("finished batch %+v", batch)
+	}
+	for _, res := range batch.Results {
+		switch {
+		case strings.HasSuffix(res.URL, "/1"):
+			if res.Status != "done" || res.Property == nil || res.Property.Address != "1 Main St" {
+				t.Errorf("done result %+v", res)
+			}
+		default:
+			if res.Status != "dead" || res.Error == nil || res.Error.Code != "LISTING_NOT_FOUND" {
+				t.Errorf("dead result %+v", res)
+			}
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handleBatchStatus(rec, httptest.NewRequest(http.MethodGet, "/analyze/batch/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown batch: status %d, want 404", rec.Code)
+	}
+}
+
+func TestRunJobUnknownKind(t *testing.T) {
+	useJobStore(t)
+	putJob(t, Job{ID: "x", Kind: "bogus", Status: "running", Attempts: 1})
+	runJob(&Job{ID: "x", Kind: "bogus", Attempts: 1})
+	if job, _ := storedJob(t, "x"); job.Status == "running" || job.LastError == nil {
+		t.Errorf("unknown kind left %+v", job)
+	}
+}
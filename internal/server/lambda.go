@@ -0,0 +1,219 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+/* ───── Modo AWS Lambda ─────────────────────────────────────────────── */
+
+// No build com -tags lambda (ver Lambda em run.go) os mesmos handlers atendem eventos
+// do API Gateway em vez de um servidor HTTP: eventos REST (proxy, formato 1.0) e
+// eventos de HTTP API e function URLs (formato 2.0). O trabalho em segundo plano
+// (fila de jobs, buscas salvas, prefetch, bot do Telegram) não roda nesse modo, e o
+// estado fica no S3 (STORE_S3_BUCKET) para sobreviver entre as instâncias.
+
+// lambdaMode é ligado por Lambda antes de setup: nenhum worker, scheduler ou fila de
+// prefetch roda, então as rotas que só agendam trabalho respondem 501 (ver
+// needsBackground) em vez de aceitar algo que nunca vai ser feito
+var lambdaMode bool
+
+// needsBackground recusa com 501, no modo Lambda, as requisições para as quais
+// queues devolve true; as demais (e todas fora desse modo) seguem para next
+func needsBackground(next http.HandlerFunc, queues func(*http.Request) bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if lambdaMode && queues(r) {
+			writeError(w, http.StatusNotImplemented, "This request needs the background workers, which do not run in Lambda mode")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// isPost separa a criação de watches e buscas salvas (que dependem dos schedulers) da
+// listagem e da remoção, que só mexem no store
+func isPost(r *http.Request) bool { return r.Method == http.MethodPost }
+
+// isAsyncBatch indica um lote que seria posto na fila de jobs
+func isAsyncBatch(r *http.Request) bool { return r.URL.Query().Get("async") == "true" }
+
+// always marca rotas que não fazem nada além de agendar trabalho
+func always(*http.Request) bool { return true }
+
+// lambdaHandler adapta handler a um evento do API Gateway, relendo o store antes de
+// cada requisição para ver as gravações das outras instâncias
+func lambdaHandler(handler http.Handler) func(ctx context.Context, event json.RawMessage) (any, error) {
+	return func(ctx context.Context, event json.RawMessage) (any, error) {
+		if err := store.Refresh(); err != nil {
+			slog.Warn("Could not refresh the store", "error", err)
+		}
+
+		var probe struct {
+			Version string `json:"version"`
+		}
+		json.Unmarshal(event, &probe)
+		if probe.Version == "2.0" {
+			var req events.APIGatewayV2HTTPRequest
+			if err := json.Unmarshal(event, &req); err != nil {
+				return nil, err
+			}
+			r, err := requestFromV2(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			return serveLambda(handler, r).v2(), nil
+		}
+
+		var req events.APIGatewayProxyRequest
+		if err := json.Unmarshal(event, &req); err != nil {
+			return nil, err
+		}
+		r, err := requestFromV1(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return serveLambda(handler, r).v1(), nil
+	}
+}
+
+// requestFromV1 monta a requisição de um evento REST API (proxy integration)
+func requestFromV1(ctx context.Context, event events.APIGatewayProxyRequest) (*http.Request, error) {
+	query := url.Values{}
+	for name, values := range event.MultiValueQueryStringParameters {
+		query[name] = values
+	}
+	for name, value := range event.QueryStringParameters {
+		if _, ok := query[name]; !ok {
+			query.Set(name, value)
+		}
+	}
+	header := http.Header{}
+	for name, values := range event.MultiValueHeaders {
+		for _, v := range values {
+			header.Add(name, v)
+		}
+	}
+	for name, value := range event.Headers {
+		if header.Get(name) == "" {
+			header.Set(name, value)
+		}
+	}
+	return lambdaRequest(ctx, event.HTTPMethod, event.Path, query.Encode(), header,
+		event.Body, event.IsBase64Encoded, event.RequestContext.Identity.SourceIP)
+}
+
+// requestFromV2 monta a requisição de um evento HTTP API ou function URL
+func requestFromV2(ctx context.Context, event events.APIGatewayV2HTTPRequest) (*http.Request, error) {
+	header := http.Header{}
+	for name, value := range event.Headers {
+		header.Set(name, value)
+	}
+	if len(event.Cookies) > 0 {
+		header.Set("Cookie", strings.Join(event.Cookies, "; "))
+	}
+	return lambdaRequest(ctx, event.RequestContext.HTTP.Method, event.RawPath, event.RawQueryString, header,
+		event.Body, event.IsBase64Encoded, event.RequestContext.HTTP.SourceIP)
+}
+
+func lambdaRequest(ctx context.Context, method, path, rawQuery string, header http.Header, body string, isBase64 bool, sourceIP string) (*http.Request, error) {
+	raw := []byte(body)
+	if isBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 body: %w", err)
+		}
+		raw = decoded
+	}
+	target := path
+	if rawQuery != "" {
+		target += "?" + rawQuery
+	}
+	r, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	r.Header = header
+	r.Host = header.Get("Host")
+	r.RemoteAddr = net.JoinHostPort(sourceIP, "0") // clientKey separa o IP da porta
+	r.RequestURI = target
+	return r, nil
+}
+
+// lambdaResponse acumula a resposta do handler; o API Gateway não faz streaming,
+// então NDJSON e SSE chegam de uma vez no fim
+type lambdaResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *lambdaResponse) Header() http.Header { return w.header }
+
+func (w *lambdaResponse) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *lambdaResponse) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+func (w *lambdaResponse) Flush() {}
+
+func serveLambda(handler http.Handler, r *http.Request) *lambdaResponse {
+	w := &lambdaResponse{header: http.Header{}}
+	handler.ServeHTTP(w, r)
+	w.WriteHeader(http.StatusOK)
+	return w
+}
+
+// encodedBody devolve o corpo em texto ou, se binário (PDF, imagens), em base64
+func (w *lambdaResponse) encodedBody() (string, bool) {
+	mediaType, _, _ := mime.ParseMediaType(w.header.Get("Content-Type"))
+	if w.body.Len() == 0 || strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") || mediaType == "application/javascript" {
+		return w.body.String(), false
+	}
+	return base64.StdEncoding.EncodeToString(w.body.Bytes()), true
+}
+
+func (w *lambdaResponse) v1() events.APIGatewayProxyResponse {
+	body, isBase64 := w.encodedBody()
+	return events.APIGatewayProxyResponse{
+		StatusCode:        w.status,
+		MultiValueHeaders: w.header,
+		Body:              body,
+		IsBase64Encoded:   isBase64,
+	}
+}
+
+func (w *lambdaResponse) v2() events.APIGatewayV2HTTPResponse {
+	body, isBase64 := w.encodedBody()
+	resp := events.APIGatewayV2HTTPResponse{
+		StatusCode:      w.status,
+		Headers:         map[string]string{},
+		Body:            body,
+		IsBase64Encoded: isBase64,
+	}
+	for name, values := range w.header {
+		if name == "Set-Cookie" {
+			resp.Cookies = values
+			continue
+		}
+		resp.Headers[name] = strings.Join(values, ", ")
+	}
+	return resp
+}
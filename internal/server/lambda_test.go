@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func echoHandler() http.Handler {
+	return logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Add("Set-Cookie", "a=1")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{
+			"method": r.Method,
+			"path":   r.URL.Path,
+			"ids":    r.URL.Query()["id"],
+			"key":    r.Header.Get("X-API-Key"),
+			"body":   string(body),
+			"client": clientKey(r),
+		})
+	}))
+}
+
+func decodeEcho(t *testing.T, body string) map[string]any {
+	t.Helper()
+	var got map[string]any
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatalf("invalid body %q: %v", body, err)
+	}
+	return got
+}
+
+func TestLambdaRESTEvent(t *testing.T) {
+	event, _ := json.Marshal(events.APIGatewayProxyRequest{
+		HTTPMethod:                      "POST",
+		Path:                            "/analyze",
+		MultiValueQueryStringParameters: map[string][]string{"id": {"1", "2"}},
+		Headers:                         map[string]string{"x-api-key": "k1"},
+		Body:                            base64.StdEncoding.EncodeToString([]byte(`{"url":"x"}`)),
+		IsBase64Encoded:                 true,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Identity: events.APIGatewayRequestIdentity{SourceIP: "203.0.113.9"},
+		},
+	})
+
+	out, err := lambdaHandler(echoHandler())(context.Background(), event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := out.(events.APIGatewayProxyResponse)
+	if resp.StatusCode != http.StatusCreated || resp.IsBase64Encoded {
+		t.Fatalf("status %d, base64 %v", resp.StatusCode, resp.IsBase64Encoded)
+	}
+	if resp.MultiValueHeaders["X-Request-Id"] == nil || len(resp.MultiValueHeaders["Set-Cookie"]) != 1 {
+		t.Errorf("headers = %v", resp.MultiValueHeaders)
+	}
+	got := decodeEcho(t, resp.Body)
+	if got["method"] != "POST" || got["path"] != "/analyze" || got["key"] != "k1" ||
+		got["body"] != `{"url":"x"}` || got["client"] != "ip:203.0.113.9" {
+		t.Errorf("handler saw %v", got)
+	}
+	if ids, _ := got["ids"].([]any); len(ids) != 2 {
+		t.Errorf("multi-value query lost: %v", got["ids"])
+	}
+}
+
+func TestLambdaHTTPAPIEvent(t *testing.T) {
+	event := `{
+		"version": "2.0",
+		"rawPath": "/analyses/abc",
+		"rawQueryString": "id=1&id=2",
+		"headers": {"x-api-key": "k2", "content-type": "application/json"},
+		"requestContext": {"http": {"method": "GET", "sourceIp": "198.51.100.7"}},
+		"isBase64Encoded": false
+	}`
+	out, err := lambdaHandler(echoHandler())(context.Background(), json.RawMessage(event))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := out.(events.APIGatewayV2HTTPResponse)
+	if resp.StatusCode != http.StatusCreated || len(resp.Cookies) != 1 || resp.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("response = %+v", resp)
+	}
+	got := decodeEcho(t, resp.Body)
+	if got["method"] != "GET" || got["path"] != "/analyses/abc" || got["key"] != "k2" || got["client"] != "ip:198.51.100.7" {
+		t.Errorf("handler saw %v", got)
+	}
+}
+
+func TestLambdaBinaryResponse(t *testing.T) {
+	pdf := []byte("%PDF-1.4\x00\x01")
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(pdf)
+	})
+	event := `{"httpMethod": "GET", "path": "/report"}`
+	out, err := lambdaHandler(handler)(context.Background(), json.RawMessage(event))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := out.(events.APIGatewayProxyResponse)
+	decoded, _ := base64.StdEncoding.DecodeString(resp.Body)
+	if resp.StatusCode != http.StatusOK || !resp.IsBase64Encoded || !strings.HasPrefix(string(decoded), "%PDF") {
+		t.Errorf("binary body not base64 encoded: %+v", resp)
+	}
+}
+
+func TestNeedsBackgroundRejectsQueuedWorkInLambdaMode(t *testing.T) {
+	reached := false
+	next := func(w http.ResponseWriter, r *http.Request) { reached = true }
+	cases := []struct {
+		lambda bool
+		method string
+		target string
+		queues func(*http.Request) bool
+		want   int
+	}{
+		{true, http.MethodPost, "/analyze/batch?async=true", isAsyncBatch, http.StatusNotImplemented},
+		{true, http.MethodPost, "/analyze/batch", isAsyncBatch, http.StatusOK},
+		{true, http.MethodPost, "/watch", isPost, http.StatusNotImplemented},
+		{true, http.MethodGet, "/watch", isPost, http.StatusOK},
+		{true, http.MethodDelete, "/searches?id=x", isPost, http.StatusOK},
+		{true, http.MethodPost, "/prefetch", always, http.StatusNotImplemented},
+		{false, http.MethodPost, "/prefetch", always, http.StatusOK},
+	}
+	prev := lambdaMode
+	t.Cleanup(func() { lambdaMode = prev })
+	for _, c := range cases {
+		lambdaMode, reached = c.lambda, false
+		rec := httptest.NewRecorder()
+		needsBackground(next, c.queues)(rec, httptest.NewRequest(c.method, c.target, nil))
+		if rec.Code != c.want || reached != (c.want == http.StatusOK) {
+			t.Errorf("%s %s (lambda=%v): got %d, handler reached=%v", c.method, c.target, c.lambda, rec.Code, reached)
+		}
+		if c.want == http.StatusNotImplemented && !strings.Contains(rec.Body.String(), `"NOT_IMPLEMENTED"`) {
+			t.Errorf("%s %s: expected the NOT_IMPLEMENTED code, got %s", c.method, c.target, rec.Body)
+		}
+	}
+}
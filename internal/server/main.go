@@ -0,0 +1,331 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+	"daft-scraper-api/internal/value"
+
+	"github.com/joho/godotenv"
+)
+
+// analyzeRequest é o corpo de POST /scrape e POST /analyze
+type analyzeRequest struct {
+	DaftURL          string   `json:"daftUrl"`
+	Modules          []string `json:"modules"`
+	MonthlyNetIncome float64  `json:"monthlyNetIncome"` // opcional, para Affordability
+	enrich.CommuteOptions
+}
+
+// handleScrape é o handler HTTP para a rota de scraping
+func handleScrape(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+
+	var requestBody analyzeRequest
+
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if requestBody.DaftURL == "" {
+		writeError(w, http.StatusBadRequest, "daftUrl is required in the request body")
+		return
+	}
+
+	modules, err := requestedModules(r, requestBody.Modules)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	commute, err := analyzerFor(r.Context()).CommuteFromRequest(r, requestBody.CommuteOptions)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	income, err := value.IncomeFromRequest(r, requestBody.MonthlyNetIncome)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	telemetry.LogFor(r.Context()).Info("Scrape requested", "url", requestBody.DaftURL)
+
+	property, scrapeErr := analyzeListingModules(r.Context(), requestBody.DaftURL, modules)
+	if scrapeErr != nil {
+		telemetry.LogFor(r.Context()).Warn("Scraping failed", "url", requestBody.DaftURL, "error", scrapeErr)
+		writeScrapeError(w, scrapeErr)
+		return
+	}
+	if property.Error == nil {
+		analyzerFor(r.Context()).ApplyCommute(r.Context(), &property, commute)
+		analyzerFor(r.Context()).Value.ApplyAffordability(&property, income)
+	}
+	chargeMapsCalls(w, r, property.MapsCalls())
+
+	// Se houver um erro dentro da struct PropertyInfo, significa que o scraping falhou em encontrar dados.
+	// A v1 (e a rota sem versão) responde 200 com o erro no anúncio, como sempre fez;
+	// a v2 responde 422 com o envelope de erro.
+	if property.Error != nil {
+		telemetry.LogFor(r.Context()).Warn("No listing data extracted", "url", requestBody.DaftURL, "error", property.Error.Message)
+		if isAPIV1(r.Context()) {
+			writeJSONFields(w, r, property)
+		} else {
+			writeAPIError(w, http.StatusUnprocessableEntity, property.Error)
+		}
+		return
+	}
+
+	if wantsGeoJSON(r) {
+		writeGeoJSON(analyzerFor(r.Context()), w, &property)
+		return
+	}
+
+	writeJSONFields(w, r, property)
+}
+
+// handleAnalyze é o handler HTTP para a rota de análise completa
+func handleAnalyze(w http.ResponseWriter, r *http.Request) {
+	var (
+		listingURL  string
+		refresh     bool
+		bodyModules []string
+		bodyCommute enrich.CommuteOptions
+		bodyIncome  float64
+	)
+	switch r.Method {
+	case http.MethodGet:
+		// GET permite links simples e prefetch do navegador; respeita o cache
+		listingURL = r.URL.Query().Get("url")
+		refresh = r.URL.Query().Get("refresh") == "true"
+		if listingURL == "" {
+			writeError(w, http.StatusBadRequest, "url query parameter is required")
+			return
+		}
+	case http.MethodPost:
+		var requestBody analyzeRequest
+
+		err := json.NewDecoder(r.Body).Decode(&requestBody)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if requestBody.DaftURL == "" {
+			writeError(w, http.StatusBadRequest, "daftUrl is required in the request body")
+			return
+		}
+		listingURL, bodyModules, bodyCommute = requestBody.DaftURL, requestBody.Modules, requestBody.CommuteOptions
+		bodyIncome = requestBody.MonthlyNetIncome
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Only GET and POST methods are allowed")
+		return
+	}
+
+	modules, err := requestedModules(r, bodyModules)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	commute, err := analyzerFor(r.Context()).CommuteFromRequest(r, bodyCommute)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	income, err := value.IncomeFromRequest(r, bodyIncome)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	telemetry.LogFor(r.Context()).Info("Analysis requested", "url", listingURL)
+
+	analysis, result, err := runAnalysis(r.Context(), listingURL, refresh, modules)
+	if err != nil {
+		writeScrapeError(w, err)
+		return
+	}
+	if modules.Full() {
+		setCacheHeaders(w, result)
+	}
+
+	// Custo do trajeto até o destino do cliente e o aluguel contra a renda dele
+	analyzerFor(r.Context()).ApplyCommute(r.Context(), &analysis.Property, commute)
+	analyzerFor(r.Context()).Value.ApplyAffordability(&analysis.Property, income)
+	chargeMapsCalls(w, r, analysis.Property.MapsCalls())
+
+	if wantsGeoJSON(r) {
+		writeGeoJSON(analyzerFor(r.Context()), w, &analysis.Property)
+		return
+	}
+
+	writeJSONFields(w, r, analysis)
+}
+
+// runAnalysis faz os passos de /analyze que não dependem da requisição HTTP: o
+// scraping (ou o anúncio em cache), as coordenadas e a segurança. result traz a idade
+// do anúncio em cache e só é preenchido na análise completa.
+func runAnalysis(ctx context.Context, listingURL string, refresh bool, modules scraper.ModuleSet) (analysis scraper.AnalysisResponse, result listingAnalysis, err error) {
+	// 1. Primeiro fazer o scraping básico (ou servir a análise recente do cache)
+	if modules.Full() {
+		if result, err = resolveAnalysis(ctx, listingURL, refresh); err != nil {
+			return analysis, result, err
+		}
+		analysis.Property = result.Property
+	} else if analysis.Property, err = analyzeListingModules(ctx, listingURL, modules); err != nil {
+		return analysis, result, err
+	}
+
+	// 2. Obter coordenadas do endereço, se o scraping ainda não as trouxe
+	if modules.NeedsLocation() && analysis.Property.Coordinates.Lat == 0 && analysis.Property.Coordinates.Lng == 0 {
+		if err := analyzerFor(ctx).GetCoordinates(ctx, &analysis.Property); err != nil {
+			telemetry.LogFor(ctx).Warn("Geocoding failed", "url", listingURL, "error", err)
+		}
+	}
+
+	// 3. Analisar segurança; com o anúncio em cache, a segurança guardada com ele é
+	// servida sem refazer as chamadas pagas
+	if modules.Has("safety") {
+		var safetyCached bool
+		if result.Cached {
+			analysis.SafetyInfo, safetyCached = cachedSafetyInfo(ctx, &analysis.Property)
+		}
+		if !safetyCached {
+			if err := analyzerFor(ctx).AnalyzeSafety(ctx, &analysis); err != nil {
+				telemetry.LogFor(ctx).Warn("Safety analysis failed", "url", listingURL, "error", err)
+			} else if modules.Full() {
+				saveSafetyInfo(ctx, &analysis.Property, analysis.SafetyInfo)
+			}
+		}
+	}
+	return analysis, result, nil
+}
+
+// Configure carrega o .env e o arquivo de configuração e instala o logger; os mains
+// do servidor e do Lambda chamam antes de Run/Lambda. Quem usa o pacote
+// exchangehelper como biblioteca não passa por aqui e configura o ambiente por conta.
+func Configure() {
+	// Carregar variáveis de ambiente do arquivo .env
+	// o ambiente tem prioridade sobre o .env, e os dois sobre o arquivo de configuração
+	envErr := godotenv.Load()
+	var configErr error
+	if path := configPath(); path != "" {
+		configErr = loadConfig(path)
+	}
+	slog.SetDefault(telemetry.NewLogger(os.Stderr)) // LOG_LEVEL e LOG_FORMAT podem vir dos arquivos
+	if envErr != nil {
+		slog.Info(".env file not found, using system environment variables")
+	}
+	if configErr != nil {
+		slog.Error("Could not load the config file", "error", configErr)
+		os.Exit(1)
+	}
+
+	// Verificar se a chave da API está definida
+	if os.Getenv("GOOGLE_MAPS_API_KEY") == "" {
+		slog.Warn("GOOGLE_MAPS_API_KEY not set, using OpenStreetMap for geocoding and places")
+	}
+}
+
+// setup abre o store, cria os clientes e registra as rotas; devolve o handler com os
+// middlewares. Comum ao servidor (Run) e ao Lambda (Lambda), em run.go.
+func setup() http.Handler {
+	s, err := openStoreFromEnv()
+	if err != nil {
+		slog.Error("Could not open the store", "error", err)
+		os.Exit(1)
+	}
+	store = s
+	limiter = newRateLimiterFromEnv() // depois do .env carregado em Configure()
+	scraper.DisabledModules = scraper.DisabledModulesFromEnv()
+	telemetry.Tracer = telemetry.NewTracerFromEnv()
+	apiUsers = apiUsersFromEnv()
+	privacyAnswers = newPrivacyLedgerFromEnv(len(apiUsers))
+	analyzer := storeBacked(enrich.NewAnalyzerFromEnv())
+	serverContext = enrich.WithAnalyzer(serverContext, analyzer) // jobs e trabalho destacado
+	if tenants, err = loadTenantsFromEnv(); err != nil {
+		slog.Error("Could not load the tenants", "error", err)
+		os.Exit(1)
+	}
+	if jobQueue, err = openJobStoreFromEnv(); err != nil {
+		slog.Error("Could not open the job queue", "error", err)
+		os.Exit(1)
+	}
+	if err := migrateStoreJobs(jobQueue); err != nil {
+		slog.Error("Could not move the job queue out of the store", "error", err)
+		os.Exit(1)
+	}
+	if searchIndex, err = openAnalysisIndexFromEnv(); err != nil {
+		slog.Error("Could not open the search index", "error", err)
+		os.Exit(1)
+	}
+	setupAlertPublishers()
+
+	http.HandleFunc("/scrape", apiV1(handleScrape))
+	http.HandleFunc("/analyze", apiV1(handleAnalyze))
+	http.HandleFunc("/v1/scrape", apiV1(handleScrape))
+	http.HandleFunc("/v1/analyze", apiV1(handleAnalyze))
+	http.HandleFunc("/v2/scrape", apiV2(handleScrape))
+	http.HandleFunc("/v2/analyze", apiV2(handleAnalyze))
+	http.HandleFunc("/analyze/batch", needsBackground(handleAnalyzeBatch, isAsyncBatch))
+	http.HandleFunc("/analyze/batch/", handleBatchStatus)
+	http.HandleFunc("/analyze/search", handleAnalyzeSearch)
+	http.HandleFunc("/watch", needsBackground(handleWatch, isPost))
+	http.HandleFunc("/comparables/upload", handleComparablesUpload)
+	http.HandleFunc("/searches", needsBackground(handleSearches, isPost))
+	http.HandleFunc("/annotations", handleAnnotations)
+	http.HandleFunc("/summary", handleSummary)
+	http.HandleFunc("/briefing", handleBriefing)
+	http.HandleFunc("/prefetch", needsBackground(handlePrefetch, always))
+	http.HandleFunc("/report", handleReport)
+	http.HandleFunc("/market/snapshot", handleMarketSnapshot)
+	http.HandleFunc("/market/heatmap", handleMarketHeatmap)
+	http.HandleFunc("/market/let-speed", handleLetSpeed)
+	http.HandleFunc("/analyses", handleAnalyses)
+	http.HandleFunc("/analyses/search", handleAnalysesSearch)
+	http.HandleFunc("/analyses/similar", handleSimilarListings)
+	http.HandleFunc("/analyses/advertisers", handleAdvertisers)
+	http.HandleFunc("/analyses/", handleAnalysisRoutes)
+	http.HandleFunc("/compare", handleCompare)
+	http.HandleFunc("/area", handleArea)
+	http.HandleFunc("/areas/rank", handleAreasRank)
+	http.HandleFunc("/graphql", handleGraphQL)
+	http.HandleFunc("/admin/maps-budget", handleMapsBudget)
+	http.HandleFunc("/admin/jobs", handleAdminJobs)
+	http.HandleFunc("/admin/jobs/", handleAdminJobs)
+	http.HandleFunc("/admin/tenants", handleAdminTenants)
+	http.HandleFunc("/admin/cache", handleAdminCache)
+	http.HandleFunc("/admin/upstreams", handleAdminUpstreams)
+	http.HandleFunc("/config", handleConfig)
+	http.HandleFunc("/openapi.json", handleOpenAPI)
+	http.HandleFunc("/docs", handleDocs)
+
+	return traceRequests(logRequests(enrich.ServeAnalyzer(analyzer, debugRequests(cacheOnlyForGET(cors(identifyTenant(rateLimit(http.DefaultServeMux))))))))
+}
+
+// requestedModules lê ?modules=a,b e ?skip=a,b da URL, somados aos "modules" do corpo
+func requestedModules(r *http.Request, bodyModules []string) (scraper.ModuleSet, error) {
+	only := append(splitList(r.URL.Query().Get("modules")), bodyModules...)
+	return scraper.ParseModules(only, splitList(r.URL.Query().Get("skip")))
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
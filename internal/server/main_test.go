@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/value"
+
+	"googlemaps.github.io/maps"
+)
+
+func TestExtractPriceValue(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected float64
+	}{
+		{"€1,234", 1234},
+		{"1,234", 1234},
+		{"1 234", 1234},
+	}
+	for _, c := range cases {
+		got := scraper.ExtractPriceValue(c.input)
+		if got != c.expected {
+			t.Errorf("extractPriceValue(%q) = %v, want %v", c.input, got, c.expected)
+		}
+	}
+}
+
+func TestFindPublicTransport_EmptyTypes(t *testing.T) {
+	property := &scraper.PropertyInfo{}
+	property.Coordinates.Lat = 0
+	property.Coordinates.Lng = 0
+
+	// Stub searchNearbyPlaces
+	places := enrich.PlacesSearchFunc(func(location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+		res := maps.PlacesSearchResult{Name: "Test Station"}
+		res.Geometry.Location = maps.LatLng{Lat: 0.1, Lng: 0.1}
+		// Types left empty
+		return []maps.PlacesSearchResult{res}, nil
+	})
+
+	if err := (&enrich.Analyzer{Places: places}).FindPublicTransport(context.Background(), property); err != nil {
+		t.Fatalf("findPublicTransport returned error: %v", err)
+	}
+
+	if len(property.QualityOfLife.PublicTransport) != 1 {
+		t.Fatalf("expected 1 transport, got %d", len(property.QualityOfLife.PublicTransport))
+	}
+	if property.QualityOfLife.PublicTransport[0].Type != "" {
+		t.Fatalf("expected empty type, got %q", property.QualityOfLife.PublicTransport[0].Type)
+	}
+}
+
+func TestParseFloorArea(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected float64
+	}{
+		{"Spacious apartment of 85 m² with balcony", 85},
+		{"Floor area: 72sqm approx.", 72},
+		{"approx 915 sq ft", 85},
+		{"Bedroom 1: 3.5m x 4m, total 110 square metres", 110},
+		{"Two bed apartment, close to Luas", 0},
+	}
+	for _, c := range cases {
+		got := value.ParseFloorArea(c.input)
+		if got != c.expected {
+			t.Errorf("parseFloorArea(%q) = %v, want %v", c.input, got, c.expected)
+		}
+	}
+}
+
+func TestNewPOIUnits(t *testing.T) {
+	poi := scraper.NewPOI("Abbey Street", "light_rail_station", 0.8004, 53.348, -6.258)
+	if poi.DistanceMeters != 800 || poi.WalkMinutes != 10 {
+		t.Errorf("distanceMeters %d, walkMinutes %d", poi.DistanceMeters, poi.WalkMinutes)
+	}
+	if poi.Distance != 0.8004 || poi.Duration != 10 {
+		t.Errorf("legacy fields: distance %v, duration %d", poi.Distance, poi.Duration)
+	}
+}
+
+func TestPOIBackfillsLegacyJSON(t *testing.T) {
+	var poi scraper.POI
+	if err := json.Unmarshal([]byte(`{"name":"Tesco","type":"supermarket","distance":0.25,"duration":3}`), &poi); err != nil {
+		t.Fatal(err)
+	}
+	if poi.DistanceMeters != 250 || poi.WalkMinutes != 3 || poi.Name != "Tesco" {
+		t.Errorf("stored POI decoded as %+v", poi)
+	}
+}
+
+func TestResponsesDeclareUnits(t *testing.T) {
+	for _, v := range []interface{}{scraper.PropertyInfo{}, AreaAnalysis{}} {
+		raw, _ := json.Marshal(v)
+		var decoded struct {
+			Units map[string]string `json:"units"`
+		}
+		json.Unmarshal(raw, &decoded)
+		if decoded.Units["distance"] != "meters" || decoded.Units["legacyDistance"] != "kilometers" {
+			t.Errorf("%T units = %v", v, decoded.Units)
+		}
+	}
+}
+
+func TestTidyPOIs(t *testing.T) {
+	pois := []scraper.POI{
+		scraper.NewPOI("Tesco", "supermarket", 0.4, 53.3201, -6.2650),
+		scraper.NewPOI("Lidl", "supermarket", 0.9, 53.3250, -6.2700),
+		scraper.NewPOI("Aldi", "supermarket", 0.2, 53.3180, -6.2600),
+		scraper.NewPOI("Tesco", "convenience_store", 0.4, 53.32011, -6.26502), // the same shop under another keyword
+		scraper.NewPOI("Spar", "convenience_store", 0.1, 53.3170, -6.2590),
+	}
+	got := (&enrich.Analyzer{POIMaxPerType: 2}).TidyPOIs(pois)
+
+	var names []string
+	for _, poi := range got {
+		names = append(names, poi.Name+"/"+poi.Type)
+	}
+	want := "Spar/convenience_store,Aldi/supermarket,Tesco/supermarket"
+	if strings.Join(names, ",") != want {
+		t.Errorf("got %s, want %s", strings.Join(names, ","), want)
+	}
+}
+
+func TestTransportScoreUsesNearestStation(t *testing.T) {
+	property := &scraper.PropertyInfo{}
+	places := enrich.PlacesSearchFunc(func(location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+		far := maps.PlacesSearchResult{Name: "Far Station"}
+		far.Geometry.Location = maps.LatLng{Lat: 0.015, Lng: 0} // ~1.7 km
+		near := maps.PlacesSearchResult{Name: "Near Stop"}
+		near.Geometry.Location = maps.LatLng{Lat: 0.002, Lng: 0} // ~220 m
+		if placeType == "train_station" {
+			return []maps.PlacesSearchResult{far}, nil
+		}
+		return []maps.PlacesSearchResult{near}, nil
+	})
+
+	if err := (&enrich.Analyzer{Places: places}).FindPublicTransport(context.Background(), property); err != nil {
+		t.Fatal(err)
+	}
+	if property.QualityOfLife.PublicTransport[0].Name != "Near Stop" {
+		t.Errorf("first POI = %s, want the nearest", property.QualityOfLife.PublicTransport[0].Name)
+	}
+	if property.QualityOfLife.TransportScore != 10 {
+		t.Errorf("transport score = %d, want 10 (a stop within 500 m and two options)", property.QualityOfLife.TransportScore)
+	}
+}
+
+func TestRequestedModules(t *testing.T) {
+	r := httptest.NewRequest("GET", "/analyze?url=x&modules=Transport,%20amenities", nil)
+	m, err := requestedModules(r, []string{"value"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"transport", "amenities", "value"} {
+		if !m.Has(name) {
+			t.Errorf("expected %s to be selected", name)
+		}
+	}
+	if m.Has("safety") {
+		t.Error("safety should not be selected")
+	}
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Agregados de mercado a partir das análises guardadas ────────── */
+
+// MarketSnapshot resume as análises guardadas de uma área
+type MarketSnapshot struct {
+	Area               string           `json:"area"`
+	ListingType        string           `json:"listingType"`
+	Listings           int              `json:"listings"`
+	Watched            int              `json:"watched"` // anúncios da área em alguma watchlist
+	AveragePrice       float64          `json:"averagePrice"`
+	AveragePricePerSqm float64          `json:"averagePricePerSqm"`
+	AverageScore       float64          `json:"averageScore"`
+	Privacy            aggregatePrivacy `json:"privacy"`
+}
+
+// HeatmapCell é uma célula da grade com a contagem e o preço médio
+type HeatmapCell struct {
+	Lat          float64 `json:"lat"`
+	Lng          float64 `json:"lng"`
+	Listings     int     `json:"listings"`
+	AveragePrice float64 `json:"averagePrice"`
+}
+
+// priceBounds limita a contribuição de cada anúncio às médias (sensibilidade do ruído)
+var priceBounds = map[string]struct{ price, perSqm float64 }{
+	"rent":  {10000, 100},
+	"share": {3000, 100},
+	"sale":  {3000000, 15000},
+}
+
+// storedListings devolve os snapshots guardados do tipo pedido e as URLs vigiadas
+func storedListings(kind string) ([]scraper.PropertyInfo, map[string]bool) {
+	var (
+		listings []scraper.PropertyInfo
+		watched  = map[string]bool{}
+	)
+	store.View(func(d *storeData) {
+		for _, a := range d.Analyses {
+			t := a.Property.ListingType
+			if t == "" {
+				t = scraper.ListingType(a.URL)
+			}
+			if t == kind {
+				listings = append(listings, a.Property)
+			}
+		}
+		for _, w := range d.Watches {
+			watched[w.URL] = true
+		}
+	})
+	return listings, watched
+}
+
+// handleMarketSnapshot é o handler HTTP para GET /market/snapshot?area=...&type=rent|sale
+func handleMarketSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	area := scraper.Slugify(r.URL.Query().Get("area"))
+	if area == "" {
+		writeError(w, http.StatusBadRequest, "area query parameter is required")
+		return
+	}
+	kind := r.URL.Query().Get("type")
+	if kind == "" {
+		kind = "rent"
+	}
+	bounds, ok := priceBounds[kind]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "type must be rent, share or sale")
+		return
+	}
+
+	writePrivateAggregate(w, r, "snapshot:"+kind+":"+area, func(privacy aggregatePrivacy) interface{} {
+		listings, watchedURLs := storedListings(kind)
+		var prices, perSqm, scores []float64
+		watched := 0
+		for i := range listings {
+			p := &listings[i]
+			suburb, county := scraper.SplitLocation(p.Address)
+			if scraper.Slugify(suburb) != area && scraper.Slugify(county) != area {
+				continue
+			}
+			prices = append(prices, scraper.ExtractPriceValue(p.RentPrice))
+			if p.ValueAnalysis.PricePerSqm > 0 {
+				perSqm = append(perSqm, p.ValueAnalysis.PricePerSqm)
+			}
+			if s := analyzerFor(r.Context()).OverallScore(p); s > 0 {
+				scores = append(scores, float64(s))
+			}
+			if watchedURLs[p.URL] {
+				watched++
+			}
+		}
+
+		// o orçamento é dividido entre as sete contagens e somas publicadas
+		eps := privacy.Epsilon / 7
+		snapshot := MarketSnapshot{Area: area, ListingType: kind, Privacy: privacy}
+
+		count, publishable := privacy.count(len(prices), eps)
+		if publishable {
+			snapshot.Listings = count
+			snapshot.Watched, _ = privacy.count(watched, eps)
+			snapshot.AveragePrice = math.Round(privacy.mean(prices, bounds.price, count, eps))
+			if n, ok := privacy.count(len(perSqm), eps); ok {
+				snapshot.AveragePricePerSqm = math.Round(privacy.mean(perSqm, bounds.perSqm, n, eps)*100) / 100
+			}
+			if n, ok := privacy.count(len(scores), eps); ok {
+				snapshot.AverageScore = math.Round(privacy.mean(scores, 100, n, eps))
+			}
+		}
+		return snapshot
+	})
+}
+
+// handleMarketHeatmap é o handler HTTP para GET /market/heatmap?type=rent&cell=0.01.
+// Com privacidade ativa, células com poucos anúncios são omitidas.
+func handleMarketHeatmap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	kind := r.URL.Query().Get("type")
+	if kind == "" {
+		kind = "rent"
+	}
+	bounds, ok := priceBounds[kind]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "type must be rent, share or sale")
+		return
+	}
+	cellSize := 0.01 // ~1 km
+	if v, err := strconv.ParseFloat(r.URL.Query().Get("cell"), 64); err == nil && v >= 0.005 && v <= 1 {
+		cellSize = v
+	}
+
+	query := "heatmap:" + kind + ":" + strconv.FormatFloat(cellSize, 'g', -1, 64)
+	writePrivateAggregate(w, r, query, func(privacy aggregatePrivacy) interface{} {
+		type cellKey struct{ lat, lng int }
+		cells := map[cellKey][]float64{}
+		listings, _ := storedListings(kind)
+		for _, p := range listings {
+			if p.Coordinates.Lat == 0 && p.Coordinates.Lng == 0 {
+				continue
+			}
+			k := cellKey{int(math.Floor(p.Coordinates.Lat / cellSize)), int(math.Floor(p.Coordinates.Lng / cellSize))}
+			cells[k] = append(cells[k], scraper.ExtractPriceValue(p.RentPrice))
+		}
+
+		// células são disjuntas: cada uma usa o orçamento inteiro, dividido entre contagem e média
+		eps := privacy.Epsilon / 2
+		result := []HeatmapCell{}
+		for k, prices := range cells {
+			count, publishable := privacy.count(len(prices), eps)
+			if !publishable {
+				continue
+			}
+			result = append(result, HeatmapCell{
+				Lat:          (float64(k.lat) + 0.5) * cellSize,
+				Lng:          (float64(k.lng) + 0.5) * cellSize,
+				Listings:     count,
+				AveragePrice: math.Round(privacy.mean(prices, bounds.price, count, eps)),
+			})
+		}
+		return result
+	})
+}
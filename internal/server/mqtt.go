@@ -0,0 +1,189 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+/* ───── Publicador MQTT 3.1.1 mínimo (apenas CONNECT/PUBLISH) ───────── */
+
+// mqttPublisher publica alertas em um broker MQTT, abrindo uma conexão por
+// alerta — alertas são raros e isso evita manter keepalive/reconexão
+type mqttPublisher struct {
+	Broker   string // tcp://host:1883, ssl://host:8883 ou host:porta
+	Topic    string // o tipo do alerta é anexado: <Topic>/<kind>
+	ClientID string
+	Username string
+	Password string
+	QoS      byte // 0 ou 1
+	Retain   bool
+	Timeout  time.Duration
+}
+
+func newMQTTPublisherFromEnv(broker string) *mqttPublisher {
+	p := &mqttPublisher{
+		Broker:   broker,
+		Topic:    os.Getenv("MQTT_TOPIC"),
+		ClientID: os.Getenv("MQTT_CLIENT_ID"),
+		Username: os.Getenv("MQTT_USERNAME"),
+		Password: os.Getenv("MQTT_PASSWORD"),
+		Retain:   os.Getenv("MQTT_RETAIN") == "true",
+		Timeout:  10 * time.Second,
+	}
+	if p.Topic == "" {
+		p.Topic = "exchange-helper/alerts"
+	}
+	if p.ClientID == "" {
+		p.ClientID = "exchange-helper-" + strconv.Itoa(os.Getpid())
+	}
+	if q, err := strconv.Atoi(os.Getenv("MQTT_QOS")); err == nil && q == 1 {
+		p.QoS = 1
+	}
+	return p
+}
+
+// Publish envia o alerta como JSON em <Topic>/<kind>
+func (p *mqttPublisher) Publish(alert Alert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("error encoding alert: %w", err)
+	}
+	topic := p.Topic
+	if alert.Kind != "" {
+		topic += "/" + alert.Kind
+	}
+	return p.publish(topic, payload)
+}
+
+func (p *mqttPublisher) publish(topic string, payload []byte) error {
+	conn, err := p.dial()
+	if err != nil {
+		return fmt.Errorf("error connecting to MQTT broker: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(p.Timeout))
+
+	// CONNECT
+	if _, err := conn.Write(mqttConnectPacket(p.ClientID, p.Username, p.Password)); err != nil {
+		return fmt.Errorf("error sending MQTT CONNECT: %w", err)
+	}
+	ack := make([]byte, 4)
+	if _, err := io.ReadFull(conn, ack); err != nil {
+		return fmt.Errorf("error reading MQTT CONNACK: %w", err)
+	}
+	if ack[0] != 0x20 || ack[3] != 0 {
+		return fmt.Errorf("MQTT broker refused connection (code %d)", ack[3])
+	}
+
+	// PUBLISH
+	const packetID = 1
+	if _, err := conn.Write(mqttPublishPacket(topic, payload, p.QoS, p.Retain, packetID)); err != nil {
+		return fmt.Errorf("error sending MQTT PUBLISH: %w", err)
+	}
+	if p.QoS == 1 {
+		puback := make([]byte, 4)
+		if _, err := io.ReadFull(conn, puback); err != nil {
+			return fmt.Errorf("error reading MQTT PUBACK: %w", err)
+		}
+		if puback[0] != 0x40 || binary.BigEndian.Uint16(puback[2:]) != packetID {
+			return fmt.Errorf("unexpected MQTT PUBACK %x", puback)
+		}
+	}
+
+	// DISCONNECT
+	_, err = conn.Write([]byte{0xE0, 0x00})
+	return err
+}
+
+func (p *mqttPublisher) dial() (net.Conn, error) {
+	host, useTLS := p.Broker, false
+	if u, err := url.Parse(p.Broker); err == nil && u.Host != "" {
+		host = u.Host
+		useTLS = u.Scheme == "ssl" || u.Scheme == "tls" || u.Scheme == "mqtts"
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		if useTLS {
+			host += ":8883"
+		} else {
+			host += ":1883"
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: p.Timeout}
+	if useTLS {
+		return tls.DialWithDialer(dialer, "tcp", host, nil)
+	}
+	return dialer.Dial("tcp", host)
+}
+
+func mqttConnectPacket(clientID, username, password string) []byte {
+	var body bytes.Buffer
+	mqttWriteString(&body, "MQTT")
+	body.WriteByte(4) // protocol level 3.1.1
+
+	flags := byte(0x02) // clean session
+	if username != "" {
+		flags |= 0x80
+		if password != "" {
+			flags |= 0x40
+		}
+	}
+	body.WriteByte(flags)
+	binary.Write(&body, binary.BigEndian, uint16(60)) // keepalive (s)
+
+	mqttWriteString(&body, clientID)
+	if username != "" {
+		mqttWriteString(&body, username)
+		if password != "" {
+			mqttWriteString(&body, password)
+		}
+	}
+	return mqttPacket(0x10, body.Bytes())
+}
+
+func mqttPublishPacket(topic string, payload []byte, qos byte, retain bool, packetID uint16) []byte {
+	var body bytes.Buffer
+	mqttWriteString(&body, topic)
+	if qos > 0 {
+		binary.Write(&body, binary.BigEndian, packetID)
+	}
+	body.Write(payload)
+
+	header := byte(0x30) | qos<<1
+	if retain {
+		header |= 0x01
+	}
+	return mqttPacket(header, body.Bytes())
+}
+
+// mqttPacket monta header fixo + remaining length (varint) + corpo
+func mqttPacket(header byte, body []byte) []byte {
+	out := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(out, body...)
+}
+
+func mqttWriteString(buf *bytes.Buffer, s string) {
+	binary.Write(buf, binary.BigEndian, uint16(len(s)))
+	buf.WriteString(s)
+}
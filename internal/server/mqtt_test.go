@@ -0,0 +1,52 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMQTTPublisher_Publish(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		readPacket := func() []byte {
+			hdr := make([]byte, 2)
+			if _, err := io.ReadFull(conn, hdr); err != nil {
+				return nil
+			}
+			body := make([]byte, hdr[1]) // pacotes de teste < 128 bytes
+			io.ReadFull(conn, body)
+			return append(hdr, body...)
+		}
+
+		readPacket()                         // CONNECT
+		conn.Write([]byte{0x20, 0x02, 0, 0}) // CONNACK aceito
+		received <- readPacket()             // PUBLISH
+		readPacket()                         // DISCONNECT
+	}()
+
+	p := &mqttPublisher{Broker: "tcp://" + ln.Addr().String(), Topic: "test", ClientID: "t", Timeout: time.Second}
+	if err := p.publish("test/new_listing", []byte("hi")); err != nil {
+		t.Fatalf("publish returned error: %v", err)
+	}
+
+	pkt := <-received
+	want := mqttPublishPacket("test/new_listing", []byte("hi"), 0, false, 0)
+	if !bytes.Equal(pkt, want) {
+		t.Fatalf("broker received %x, want %x", pkt, want)
+	}
+}
@@ -0,0 +1,389 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Especificação OpenAPI gerada a partir das structs ───────────── */
+
+// A especificação é montada por reflexão sobre os tipos de request/response de cada
+// rota, então um campo novo numa struct aparece no documento sem edição manual. Uma
+// cópia estática fica em openapi.json para geradores de clientes; regenere com:
+//
+//go:generate go test -run TestOpenAPISpecUpToDate -update-openapi
+
+// apiParam é um parâmetro de query ou de path
+type apiParam struct {
+	Name        string
+	In          string // query (padrão) ou path
+	Description string
+	Required    bool
+}
+
+// apiOperation descreve uma rota. Request e Response são valores de exemplo dos tipos
+// usados pelo handler; ContentType indica respostas que não são JSON.
+type apiOperation struct {
+	Method      string
+	Path        string
+	Summary     string
+	Params      []apiParam
+	Request     interface{}
+	Response    interface{}
+	ContentType string
+}
+
+var (
+	urlParam     = apiParam{Name: "url", Description: "Daft.ie listing URL", Required: true}
+	fieldsParam  = apiParam{Name: "fields", Description: "Comma-separated sparse fieldset, e.g. address,price,qualityOfLife.walkScore"}
+	idParam      = apiParam{Name: "id", In: "path", Description: "Analysis ID", Required: true}
+	areaParam    = apiParam{Name: "area", Description: "Suburb or county", Required: true}
+	listingParam = apiParam{Name: "type", Description: "rent (default), share or sale"}
+	debugParam   = apiParam{Name: "debug", Description: "true logs the scraper events and saves the fetched HTML under DEBUG_DIR"}
+)
+
+// apiOperations são as rotas documentadas; TestOpenAPICoversRoutes garante que toda
+// rota registrada em setup() está aqui
+var apiOperations = []apiOperation{
+	{Method: "POST", Path: "/scrape", Summary: "Scrape and enrich a listing (always fresh)",
+		Params: []apiParam{fieldsParam, debugParam}, Request: analyzeRequest{}, Response: v1Property{}},
+	{Method: "GET", Path: "/analyze", Summary: "Analyze a listing, served from cache when fresh",
+		Params: []apiParam{urlParam, {Name: "refresh", Description: "true skips the cache"},
+			{Name: "modules", Description: "Only run these modules (comma-separated)"},
+			{Name: "skip", Description: "Skip these modules (comma-separated)"},
+			{Name: "commuteTo", Description: "Commute destination, for commute.monthly and valueAnalysis.trueMonthlyCost"},
+			{Name: "commuteMode", Description: "public (default) or car"},
+			{Name: "commuteDays", Description: "Commuting days per week (default COMMUTE_DAYS)"},
+			{Name: "monthlyNetIncome", Description: "Monthly net income, for the affordability block"}, fieldsParam, debugParam},
+		Response: v1Analysis{}},
+	{Method: "POST", Path: "/analyze", Summary: "Analyze a listing (always fresh)",
+		Params: []apiParam{fieldsParam}, Request: analyzeRequest{}, Response: v1Analysis{}},
+	{Method: "POST", Path: "/analyze/batch", Summary: "Analyze several listings (JSON, CSV with Accept: text/csv, or NDJSON with Accept: application/x-ndjson)",
+		Params: []apiParam{{Name: "format", Description: "csv for a spreadsheet export, ndjson to stream one line per finished listing"},
+			{Name: "async", Description: "true to queue the listings and answer 202 with the batch ID"}},
+		Request: batchRequest{}, Response: []BatchResult{}},
+	{Method: "GET", Path: "/analyze/batch/{id}", Summary: "Progress and results of a queued batch",
+		Params:   []apiParam{{Name: "id", In: "path", Description: "Batch ID", Required: true}},
+		Response: BatchJob{}},
+	{Method: "POST", Path: "/analyze/search", Summary: "Rank the listings of a Daft.ie search by price against the area and public transport",
+		Request: searchAnalysisRequest{}, Response: SearchAnalysis{}},
+	{Method: "GET", Path: "/watch", Summary: "List watched listings", Response: []Watch{}},
+	{Method: "POST", Path: "/watch", Summary: "Watch a listing for price changes or removal",
+		Request: watchRequest{}, Response: Watch{}},
+	{Method: "DELETE", Path: "/watch", Summary: "Stop watching a listing",
+		Params: []apiParam{{Name: "id", Required: true}}},
+	{Method: "POST", Path: "/comparables/upload", Summary: "Upload private comparables (CSV: address,rent,let_date)",
+		Params:   []apiParam{{Name: "agency", Description: "Agency that let the properties", Required: true}},
+		Response: uploadResult{}},
+	{Method: "GET", Path: "/searches", Summary: "List saved searches", Response: []SavedSearch{}},
+	{Method: "POST", Path: "/searches", Summary: "Save a Daft.ie search and get alerts for new listings",
+		Request: searchRequest{}, Response: SavedSearch{}},
+	{Method: "DELETE", Path: "/searches", Summary: "Delete a saved search",
+		Params: []apiParam{{Name: "id", Required: true}}},
+	{Method: "GET", Path: "/annotations", Summary: "Annotations near a point",
+		Params: []apiParam{{Name: "lat", Required: true}, {Name: "lng", Required: true}}, Response: []scraper.Annotation{}},
+	{Method: "POST", Path: "/annotations", Summary: "Add an annotation about an area or building",
+		Request: scraper.Annotation{}, Response: scraper.Annotation{}},
+	{Method: "DELETE", Path: "/annotations", Summary: "Delete your annotation",
+		Params: []apiParam{{Name: "id", Required: true}}},
+	{Method: "POST", Path: "/summary", Summary: "Short summary of a listing for cards and lists",
+		Params: []apiParam{fieldsParam}, Request: summaryRequest{}, Response: enrich.PropertySummary{}},
+	{Method: "GET", Path: "/briefing", Summary: "Spoken briefing for voice assistants",
+		Params:   []apiParam{urlParam, {Name: "format", Description: "ssml wraps the text in <speak>"}},
+		Response: "", ContentType: "text/plain"},
+	{Method: "POST", Path: "/prefetch", Summary: "Queue listings to be analyzed in the background",
+		Request: prefetchRequest{}, Response: map[string]int{}},
+	{Method: "GET", Path: "/report", Summary: "Printable HTML report of a listing",
+		Params: []apiParam{urlParam}, Response: "", ContentType: "text/html"},
+	{Method: "GET", Path: "/market/snapshot", Summary: "Market snapshot of an area",
+		Params: []apiParam{areaParam, listingParam}, Response: MarketSnapshot{}},
+	{Method: "GET", Path: "/market/heatmap", Summary: "Listing count and average price per grid cell",
+		Params:   []apiParam{listingParam, {Name: "cell", Description: "Cell size in degrees (0.005 to 1)"}},
+		Response: []HeatmapCell{}},
+	{Method: "GET", Path: "/market/let-speed", Summary: "How long let or sold listings stayed on the market",
+		Params: []apiParam{areaParam, listingParam}, Response: LetSpeed{}},
+	{Method: "GET", Path: "/analyses", Summary: "Stored analyses with their tracking status",
+		Params:   []apiParam{{Name: "status", Description: "shortlisted, viewed, applied or rejected"}},
+		Response: []TrackedAnalysis{}},
+	{Method: "GET", Path: "/analyses/search", Summary: "Full-text search over stored analyses",
+		Params: []apiParam{{Name: "q", Required: true}, {Name: "limit"}}, Response: []AnalysisHit{}},
+	{Method: "GET", Path: "/analyses/similar", Summary: "Stored listings with similar descriptions",
+		Params: []apiParam{{Name: "id"}, {Name: "url"}, {Name: "limit"}}, Response: []SimilarListing{}},
+	{Method: "GET", Path: "/analyses/advertisers", Summary: "Stored analyses grouped by agent or landlord",
+		Response: []AdvertiserGroup{}},
+	{Method: "POST", Path: "/analyses/{id}/ask", Summary: "Ask a question about a stored analysis",
+		Params: []apiParam{idParam}, Request: askRequest{}, Response: AskResponse{}},
+	{Method: "GET", Path: "/analyses/{id}/tracking", Summary: "Tracking status, notes and viewing date",
+		Params: []apiParam{idParam}, Response: Tracking{}},
+	{Method: "PATCH", Path: "/analyses/{id}/tracking", Summary: "Update tracking status, notes or viewing date",
+		Params: []apiParam{idParam}, Request: trackingUpdate{}, Response: Tracking{}},
+	{Method: "GET", Path: "/analyses/{id}/history", Summary: "Daily price and score history, for trend charts",
+		Params: []apiParam{idParam}, Response: AnalysisHistory{}},
+	{Method: "GET", Path: "/analyses/{id}/viewings.ics", Summary: "Advertised viewing times as an iCalendar file",
+		Params: []apiParam{idParam}, Response: "", ContentType: "text/calendar"},
+	{Method: "POST", Path: "/compare", Summary: "Compare listings side by side",
+		Request: compareRequest{}, Response: CompareResponse{}},
+	{Method: "POST", Path: "/area", Summary: "Safety and quality of life for an address, Eircode or point",
+		Params: []apiParam{fieldsParam}, Request: areaRequest{}, Response: AreaAnalysis{}},
+	{Method: "GET", Path: "/areas/rank", Summary: "Rank the suburbs of a county",
+		Params: []apiParam{{Name: "county", Required: true}}, Response: &areaRanking{}},
+	{Method: "GET", Path: "/graphql", Summary: "GraphQL query over the analysis graph (see graphql.go for the supported subset)",
+		Params:   []apiParam{{Name: "query", Required: true}, {Name: "variables", Description: "JSON object"}, {Name: "operationName"}},
+		Response: graphqlResponse{}},
+	{Method: "POST", Path: "/graphql", Summary: "GraphQL query over the analysis graph (see graphql.go for the supported subset)",
+		Request: graphqlRequest{}, Response: graphqlResponse{}},
+	{Method: "GET", Path: "/admin/maps-budget", Summary: "Estimated Google Maps spend today (admin token required)",
+		Response: enrich.MapsBudgetReport{}},
+	{Method: "GET", Path: "/admin/jobs", Summary: "Queued, failed and dead jobs (admin token required)",
+		Params:   []apiParam{{Name: "status", Description: "Comma-separated statuses, e.g. dead,failed"}},
+		Response: []Job{}},
+	{Method: "POST", Path: "/admin/jobs/{id}/retry", Summary: "Requeue a failed or dead job (admin token required)",
+		Params:   []apiParam{{Name: "id", In: "path", Description: "Job ID", Required: true}},
+		Response: Job{}},
+	{Method: "GET", Path: "/admin/tenants", Summary: "Tenants with their limits and Maps usage (admin token required)",
+		Response: []TenantReport{}},
+	{Method: "GET", Path: "/admin/cache", Summary: "Size and hit rate of each cache (admin token required)",
+		Response: []CacheReport{}},
+	{Method: "DELETE", Path: "/admin/cache", Summary: "Purge cache entries by key or prefix (admin token required)",
+		Params: []apiParam{{Name: "cache", Description: "analyses or area-rank; all caches when omitted"},
+			{Name: "key", Description: "Analysis ID or listing URL, or county"},
+			{Name: "prefix", Description: "Listing URL or county prefix"}},
+		Response: CachePurgeResult{}},
+	{Method: "GET", Path: "/admin/upstreams", Summary: "Circuit breaker state and last error of each upstream (admin token required)",
+		Response: []scraper.UpstreamStatus{}},
+	{Method: "GET", Path: "/config", Summary: "Effective configuration with secrets redacted (admin token required)",
+		Response: EffectiveConfig{}},
+}
+
+// schemaGen converte tipos Go em schemas; structs nomeadas viram componentes
+type schemaGen struct {
+	components map[string]interface{}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *schemaGen) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		if _, ok := g.components[t.Name()]; !ok {
+			g.components[t.Name()] = nil // reserva o nome antes de recursar (tipos recursivos)
+			g.components[t.Name()] = g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return g.object(t)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// object monta o schema de uma struct a partir dos campos serializados (jsonFields)
+func (g *schemaGen) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for name, ft := range jsonFields(t) {
+		properties[name] = g.schema(ft)
+	}
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// jsonFields devolve os campos que encoding/json serializa numa struct, pelo nome da tag:
+// campos "-" e não exportados ficam de fora e structs embutidas sem tag são achatadas
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	var add func(t reflect.Type)
+	add = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, _, _ := strings.Cut(tag, ",")
+			if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+				add(f.Type)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			fields[name] = f.Type
+		}
+	}
+	add(t)
+	return fields
+}
+
+// openAPIDocument monta o documento OpenAPI 3 a partir de apiOperations
+func openAPIDocument() map[string]interface{} {
+	g := &schemaGen{components: map[string]interface{}{}}
+	errorResponse := map[string]interface{}{
+		"description": "Error envelope with a stable code",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(errorEnvelope{}))},
+		},
+	}
+
+	paths := map[string]interface{}{}
+	for _, op := range append(apiOperations, versionedOperations()...) {
+		operation := map[string]interface{}{
+			"summary":     op.Summary,
+			"operationId": operationID(op),
+		}
+
+		var params []interface{}
+		for _, p := range op.Params {
+			in := p.In
+			if in == "" {
+				in = "query"
+			}
+			param := map[string]interface{}{"name": p.Name, "in": in, "schema": map[string]interface{}{"type": "string"}}
+			if p.Description != "" {
+				param["description"] = p.Description
+			}
+			if p.Required || in == "path" {
+				param["required"] = true
+			}
+			params = append(params, param)
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+
+		if op.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(op.Request))},
+				},
+			}
+		}
+
+		ok := map[string]interface{}{"description": "OK"}
+		if op.Response != nil {
+			contentType := op.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			ok["content"] = map[string]interface{}{
+				contentType: map[string]interface{}{"schema": g.schema(reflect.TypeOf(op.Response))},
+			}
+		}
+		operation["responses"] = map[string]interface{}{"200": ok, "default": errorResponse}
+
+		item, _ := paths[op.Path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Daft Scraper API",
+			"version":     "1.0.0",
+			"description": "Analyzes Daft.ie listings: safety, transport, amenities, value and more.",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": g.components},
+	}
+}
+
+// operationID gera um id estável, ex.: GET /analyses/{id}/tracking → getAnalysesIdTracking
+func operationID(op apiOperation) string {
+	id := strings.ToLower(op.Method)
+	for _, part := range strings.FieldsFunc(op.Path, func(r rune) bool { return r == '/' || r == '-' || r == '{' || r == '}' }) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+// openAPISpec é o documento serializado, montado uma vez (os tipos não mudam em runtime)
+var (
+	openAPIOnce sync.Once
+	openAPIJSON []byte
+)
+
+func openAPISpec() []byte {
+	openAPIOnce.Do(func() {
+		raw, err := json.MarshalIndent(openAPIDocument(), "", "  ")
+		if err != nil {
+			panic(fmt.Sprintf("openapi: %v", err)) // só tipos serializáveis entram no documento
+		}
+		openAPIJSON = append(raw, '\n')
+	})
+	return openAPIJSON
+}
+
+// handleOpenAPI é o handler HTTP para GET /openapi.json
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec())
+}
+
+// swaggerUI carrega o Swagger UI do CDN apontando para /openapi.json
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Daft Scraper API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`
+
+// handleDocs é o handler HTTP para GET /docs
+func handleDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, swaggerUI)
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var updateOpenAPI = flag.Bool("update-openapi", false, "rewrite openapi.json from the structs")
+
+func TestOpenAPISpecUpToDate(t *testing.T) {
+	spec := openAPISpec()
+	if *updateOpenAPI {
+		if err := os.WriteFile("openapi.json", spec, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	committed, err := os.ReadFile("openapi.json")
+	if err != nil {
+		t.Fatalf("reading openapi.json: %v", err)
+	}
+	if !bytes.Equal(committed, spec) {
+		t.Fatal("openapi.json is out of date with the request/response structs; run go generate")
+	}
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	src, err := os.ReadFile("main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	documented := map[string]bool{}
+	for _, op := range append(apiOperations, versionedOperations()...) {
+		documented[op.Path] = true
+	}
+
+	for _, m := range regexp.MustCompile(`http\.HandleFunc\("([^"]+)"`).FindAllSubmatch(src, -1) {
+		route := string(m[1])
+		switch {
+		case route == "/openapi.json" || route == "/docs":
+			continue
+		case strings.HasSuffix(route, "/"):
+			// prefix routes are documented by their sub-paths
+			found := false
+			for path := range documented {
+				if strings.HasPrefix(path, route) && path != route {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("no sub-path of %s is documented", route)
+			}
+		case !documented[route]:
+			t.Errorf("route %s is not in apiOperations", route)
+		}
+	}
+}
+
+func TestOpenAPIDocumentShape(t *testing.T) {
+	var doc struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+		Comps   struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(openAPISpec(), &doc); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+	if _, ok := doc.Paths["/analyses/{id}/tracking"]["patch"]; !ok {
+		t.Error("PATCH /analyses/{id}/tracking is missing")
+	}
+
+	property, ok := doc.Comps.Schemas["PropertyInfo"]
+	if !ok {
+		t.Fatal("PropertyInfo schema is missing")
+	}
+	// fields come from the json tags, "-" fields are left out
+	for _, name := range []string{"address", "price", "dataQuality", "warnings", "qualityOfLife"} {
+		if _, ok := property.Properties[name]; !ok {
+			t.Errorf("PropertyInfo.%s is missing", name)
+		}
+	}
+	if _, ok := doc.Comps.Schemas["errorEnvelope"]; !ok {
+		t.Error("error envelope schema is missing")
+	}
+}
+
+func TestHandleDocs(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handleOpenAPI(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("openapi.json: status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	rec = httptest.NewRecorder()
+	handleDocs(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if !strings.Contains(rec.Body.String(), `url: "/openapi.json"`) {
+		t.Error("docs page does not load /openapi.json")
+	}
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math/bits"
+	"net/http"
+	"strconv"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Detecção de fotos repetidas entre anúncios ──────────────────── */
+
+// PhotoSighting registra onde uma foto (pelo hash perceptual) já apareceu
+type PhotoSighting struct {
+	ListingURL string    `json:"listingUrl"`
+	Address    string    `json:"address"`
+	PhotoURL   string    `json:"photoUrl"`
+	SeenAt     time.Time `json:"seenAt"`
+}
+
+const (
+	maxHashedPhotos       = 6 // fotos baixadas por anúncio
+	maxPhotoBytes         = 5 << 20
+	photoMatchMaxDistance = 6 // dHash: até 6 de 64 bits diferentes = mesma foto
+)
+
+// detectDuplicatePhotos calcula o hash das fotos do anúncio, procura-as no índice
+// e registra as novas ocorrências. Uma foto vista em scraper.StockPhotoAddresses endereços ou
+// mais é de banco de imagens (fachada do empreendimento, foto genérica da agência):
+// fica em PhotoAnalysis.StockPhotos e não conta como foto roubada de outro anúncio.
+func detectDuplicatePhotos(ctx context.Context, property *scraper.PropertyInfo) error {
+	photos := property.Photos
+	if len(photos) > maxHashedPhotos {
+		photos = photos[:maxHashedPhotos]
+	}
+
+	myKey := scraper.AddressKey(property.Address)
+	seen := map[string]bool{}
+	var matches []scraper.PhotoMatch
+	analysis := &scraper.PhotoAnalysis{Count: len(property.Photos), StockPhotos: []string{}}
+
+	for _, photoURL := range photos {
+		hash, err := fetchPhotoHash(ctx, photoURL)
+		if err != nil {
+			telemetry.LogFor(ctx).Warn("Could not hash photo", "photo", photoURL, "error", err)
+			continue
+		}
+		analysis.Hashed++
+
+		err = store.Update(func(d *storeData) error {
+			var found []scraper.PhotoMatch
+			addresses, listings := map[string]bool{}, map[string]bool{}
+			for _, candidate := range photoCandidates(d, hash) {
+				dist := bits.OnesCount64(hash ^ candidate)
+				if dist > photoMatchMaxDistance {
+					continue
+				}
+				for _, s := range d.PhotoHashes[formatPhotoHash(candidate)] {
+					if s.ListingURL == property.URL {
+						continue
+					}
+					otherKey := scraper.AddressKey(s.Address)
+					if otherKey != myKey {
+						addresses[otherKey] = true
+					}
+					if seen[s.ListingURL] || listings[s.ListingURL] {
+						continue
+					}
+					listings[s.ListingURL] = true
+					kind := "different_address"
+					if otherKey == myKey {
+						kind = "relisted"
+					}
+					found = append(found, scraper.PhotoMatch{
+						PhotoURL:        photoURL,
+						OtherListingURL: s.ListingURL,
+						OtherAddress:    s.Address,
+						Distance:        dist,
+						Kind:            kind,
+					})
+				}
+			}
+			if len(addresses) >= scraper.StockPhotoAddresses {
+				analysis.StockPhotos = append(analysis.StockPhotos, photoURL)
+			} else {
+				for _, m := range found {
+					seen[m.OtherListingURL] = true
+					matches = append(matches, m)
+				}
+			}
+			indexPhoto(d, hash, PhotoSighting{
+				ListingURL: property.URL,
+				Address:    property.Address,
+				PhotoURL:   photoURL,
+				SeenAt:     time.Now(),
+			})
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("error saving photo index: %w", err)
+		}
+	}
+
+	property.PhotoDuplicates = matches
+	property.PhotoAnalysis = analysis
+	return nil
+}
+
+// photoCandidates usa o índice por faixas: o hash é dividido em 8 bytes e dois hashes
+// com distância ≤ 7 compartilham obrigatoriamente ao menos um byte na mesma posição
+func photoCandidates(d *storeData, hash uint64) []uint64 {
+	unique := map[string]bool{}
+	var out []uint64
+	for _, band := range photoBands(hash) {
+		for _, h := range d.PhotoBands[band] {
+			if unique[h] {
+				continue
+			}
+			unique[h] = true
+			if v, err := strconv.ParseUint(h, 16, 64); err == nil {
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+func indexPhoto(d *storeData, hash uint64, sighting PhotoSighting) {
+	key := formatPhotoHash(hash)
+	for _, s := range d.PhotoHashes[key] {
+		if s.ListingURL == sighting.ListingURL {
+			return // já indexada para este anúncio
+		}
+	}
+	if len(d.PhotoHashes[key]) == 0 {
+		for _, band := range photoBands(hash) {
+			d.PhotoBands[band] = append(d.PhotoBands[band], key)
+		}
+	}
+	d.PhotoHashes[key] = append(d.PhotoHashes[key], sighting)
+}
+
+func photoBands(hash uint64) []string {
+	bands := make([]string, 8)
+	for i := range bands {
+		bands[i] = fmt.Sprintf("%d:%02x", i, byte(hash>>(8*i)))
+	}
+	return bands
+}
+
+func formatPhotoHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// fetchPhotoHash baixa a foto e calcula seu dHash
+func fetchPhotoHash(ctx context.Context, photoURL string) (uint64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, photoURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("photo returned status code: %d", resp.StatusCode)
+	}
+
+	img, _, err := image.Decode(io.LimitReader(resp.Body, maxPhotoBytes))
+	if err != nil {
+		return 0, fmt.Errorf("error decoding photo: %w", err)
+	}
+	return dHash(img), nil
+}
+
+// dHash reduz a imagem a 9x8 em tons de cinza e compara pixels vizinhos:
+// resiste a redimensionamento, recompressão e pequenos ajustes de cor
+func dHash(img image.Image) uint64 {
+	const w, h = 9, 8
+	b := img.Bounds()
+	var gray [h][w]float64
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// média da célula correspondente na imagem original
+			x0, x1 := b.Min.X+x*b.Dx()/w, b.Min.X+(x+1)*b.Dx()/w
+			y0, y1 := b.Min.Y+y*b.Dy()/h, b.Min.Y+(y+1)*b.Dy()/h
+			var sum float64
+			n := 0
+			for yy := y0; yy < y1; yy++ {
+				for xx := x0; xx < x1; xx++ {
+					r, g, bl, _ := img.At(xx, yy).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)
+					n++
+				}
+			}
+			if n > 0 {
+				gray[y][x] = sum / float64(n)
+			}
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w-1; x++ {
+			hash <<= 1
+			if gray[y][x] > gray[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"math/bits"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func gradientImage(w, h int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			img.SetGray(x, y, color.Gray{Y: uint8(128 + 100*math.Sin(7*fx)*math.Cos(5*fy))})
+		}
+	}
+	return img
+}
+
+func TestDHash_ResizeInvariant(t *testing.T) {
+	small, large := dHash(gradientImage(90, 80)), dHash(gradientImage(900, 800))
+	if d := bits.OnesCount64(small ^ large); d > photoMatchMaxDistance {
+		t.Fatalf("resized image hash distance = %d, want <= %d", d, photoMatchMaxDistance)
+	}
+}
+
+func TestPhotoIndex_FindsNearDuplicate(t *testing.T) {
+	d := &storeData{}
+	d.init()
+
+	const hash = uint64(0xF0F0F0F0AAAA5555)
+	indexPhoto(d, hash, PhotoSighting{ListingURL: "https://www.daft.ie/a/1", Address: "1 Main St, Dublin"})
+
+	near := hash ^ 0x0101010101010101 // 8 bits diferentes, um por faixa
+	if got := photoCandidates(d, near); len(got) != 0 {
+		t.Fatalf("expected no candidate when every band differs, got %x", got)
+	}
+
+	near = hash ^ 0x0000000000000107 // 4 bits diferentes
+	got := photoCandidates(d, near)
+	if len(got) != 1 || got[0] != hash {
+		t.Fatalf("expected candidate %x, got %x", hash, got)
+	}
+}
+
+func TestDetectDuplicatePhotos_StockPhotos(t *testing.T) {
+	store = newMemoryStore()
+	t.Cleanup(func() { store = newMemoryStore() })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		png.Encode(w, gradientImage(90, 80))
+	}))
+	defer srv.Close()
+
+	// the same photo on listings at three other addresses is a stock image, not a stolen one
+	hash := dHash(gradientImage(90, 80))
+	store.Update(func(d *storeData) error {
+		for i, addr := range []string{"1 Main St, Dublin", "2 High St, Cork", "3 Quay St, Galway"} {
+			indexPhoto(d, hash, PhotoSighting{ListingURL: "https://www.daft.ie/x/" + string(rune('1'+i)), Address: addr, SeenAt: time.Now()})
+		}
+		return nil
+	})
+
+	p := &scraper.PropertyInfo{URL: "https://www.daft.ie/x/9", Address: "9 New Rd, Bray", Photos: []string{srv.URL + "/a.png"}}
+	if err := detectDuplicatePhotos(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.PhotoDuplicates) != 0 {
+		t.Errorf("stock photo reported as duplicates: %+v", p.PhotoDuplicates)
+	}
+	if a := p.PhotoAnalysis; a == nil || a.Count != 1 || a.Hashed != 1 || len(a.StockPhotos) != 1 {
+		t.Errorf("analysis = %+v", a)
+	}
+}
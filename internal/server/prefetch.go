@@ -0,0 +1,403 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"daft-scraper-api/internal/safety"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Pré-carregamento a partir das buscas abertas na extensão ────── */
+
+const prefetchQueueSize = 200
+
+var (
+	prefetchQueue = make(chan string, prefetchQueueSize)
+
+	prefetchMu      sync.Mutex
+	prefetchPending = map[string]bool{}
+
+	// análises pedidas por usuários em andamento; o prefetch espera zerar
+	foregroundAnalyses int32
+)
+
+// analysisCacheTTL é por quanto tempo uma análise guardada ainda é servida sem novo
+// scraping (ANALYSIS_CACHE_TTL, padrão 1h)
+func analysisCacheTTL() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("ANALYSIS_CACHE_TTL")); err == nil && d > 0 {
+		return d
+	}
+	return time.Hour
+}
+
+type analysisCacheBypassKey struct{}
+
+// cacheOnlyForGET deixa só GET e HEAD serem servidos do cache de análises: um POST
+// (/scrape, /analyze, /summary, lotes) sempre faz a análise de novo
+func cacheOnlyForGET(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			r = r.WithContext(context.WithValue(r.Context(), analysisCacheBypassKey{}, true))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// analysisCacheBypassed diz se o trabalho feito com ctx ignora o cache de análises
+func analysisCacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(analysisCacheBypassKey{}).(bool)
+	return bypass
+}
+
+// cachedAnalysis devolve a análise guardada do anúncio (a do tenant da requisição) se
+// ela for mais nova que maxAge
+func cachedAnalysis(ctx context.Context, listingURL string, maxAge time.Duration) (scraper.PropertyInfo, time.Time, bool) {
+	var (
+		property   scraper.PropertyInfo
+		analyzedAt time.Time
+		ok         bool
+	)
+	store.View(func(d *storeData) {
+		a, found := d.Analyses[analysisKeyFor(tenantID(ctx), listingURL)]
+		if found && !a.Expired && time.Since(a.AnalyzedAt) < maxAge {
+			property, analyzedAt, ok = a.Property.Clone(), a.AnalyzedAt, true
+		}
+	})
+	return property, analyzedAt, ok
+}
+
+// cachedSafetyInfo devolve a segurança guardada para o snapshot em cache do anúncio,
+// se ela foi calculada nas mesmas coordenadas
+func cachedSafetyInfo(ctx context.Context, property *scraper.PropertyInfo) (safety.SafetyAnalysis, bool) {
+	var (
+		safety safety.SafetyAnalysis
+		ok     bool
+	)
+	store.View(func(d *storeData) {
+		a, found := d.Analyses[analysisKeyFor(tenantID(ctx), property.URL)]
+		if found && a.SafetyInfo != nil && a.Property.Coordinates == property.Coordinates {
+			safety, ok = *a.SafetyInfo, true
+		}
+	})
+	return safety, ok
+}
+
+// saveSafetyInfo guarda a segurança calculada junto do snapshot do anúncio, para que
+// o próximo GET /analyze em cache não refaça as chamadas pagas
+func saveSafetyInfo(ctx context.Context, property *scraper.PropertyInfo, safety safety.SafetyAnalysis) {
+	err := store.Update(func(d *storeData) error {
+		a, found := d.Analyses[analysisKeyFor(tenantID(ctx), property.URL)]
+		if found && a.Property.Coordinates == property.Coordinates {
+			a.SafetyInfo = &safety
+		}
+		return nil
+	})
+	if err != nil {
+		telemetry.LogFor(ctx).Warn("Could not save the safety analysis", "url", property.URL, "error", err)
+	}
+}
+
+// listingAnalysis é uma análise com a indicação de onde ela veio
+type listingAnalysis struct {
+	Property   scraper.PropertyInfo
+	AnalyzedAt time.Time
+	Cached     bool
+}
+
+// analyzeListing serve a análise recente do cache (geralmente vinda do prefetch), da
+// instância upstream em modo read-through, ou faz o scraping completo e o registra
+func analyzeListing(ctx context.Context, listingURL string) (scraper.PropertyInfo, error) {
+	result, err := resolveAnalysis(ctx, listingURL, false)
+	return result.Property, err
+}
+
+// resolveAnalysis é o analyzeListing com controle do cache: refresh (ou uma requisição
+// que não é GET) ignora a análise guardada e força uma nova. Pedidos simultâneos do
+// mesmo anúncio dividem uma análise.
+func resolveAnalysis(ctx context.Context, listingURL string, refresh bool) (listingAnalysis, error) {
+	listingURL = scraper.CanonicalListingURL(listingURL)
+	if !refresh && !analysisCacheBypassed(ctx) {
+		property, analyzedAt, ok := cachedAnalysis(ctx, listingURL, analysisCacheTTL())
+		countCache("analyses", ok)
+		if ok {
+			telemetry.LogFor(ctx).Info("Serving cached analysis", "url", listingURL)
+			return listingAnalysis{Property: property, AnalyzedAt: analyzedAt, Cached: true}, nil
+		}
+	}
+
+	return analysisFlights.do(ctx, tenantScoped(ctx, listingURL), func(ctx context.Context) (listingAnalysis, error) {
+		return freshAnalysis(ctx, listingURL)
+	})
+}
+
+// freshAnalysis pede a análise à instância upstream, se houver, ou faz o scraping
+// completo, e a registra
+func freshAnalysis(ctx context.Context, listingURL string) (listingAnalysis, error) {
+	if upstream := analyzerFor(ctx).Upstream; upstream.Enabled() {
+		property, err := upstream.Fetch(ctx, listingURL)
+		if err == nil {
+			telemetry.LogFor(ctx).Info("Serving upstream analysis", "url", listingURL)
+			property.URL = listingURL
+			recordAnalysis(ctx, &property)
+			exportAnalysis(ctx, &property)
+			return listingAnalysis{Property: property, AnalyzedAt: time.Now()}, nil
+		}
+		telemetry.LogFor(ctx).Warn("Upstream lookup failed, scraping locally", "url", listingURL, "error", err)
+	}
+
+	atomic.AddInt32(&foregroundAnalyses, 1)
+	defer atomic.AddInt32(&foregroundAnalyses, -1)
+
+	property, err := analyzerFor(ctx).ScrapeDaftProperty(ctx, listingURL)
+	if err != nil {
+		return listingAnalysis{Property: property}, err
+	}
+	recordAnalysis(ctx, &property)
+	exportAnalysis(ctx, &property)
+	return listingAnalysis{Property: property, AnalyzedAt: time.Now()}, nil
+}
+
+// setCacheHeaders informa a idade da análise (Age, X-Cache) e por quanto tempo o
+// navegador pode reaproveitá-la (Cache-Control)
+func setCacheHeaders(w http.ResponseWriter, result listingAnalysis) {
+	age := int(time.Since(result.AnalyzedAt).Seconds())
+	if age < 0 {
+		age = 0
+	}
+	remaining := int(analysisCacheTTL().Seconds()) - age
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	if result.Cached {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
+	w.Header().Set("Age", strconv.Itoa(age))
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", remaining))
+}
+
+// prefetchRequest é o corpo de POST /prefetch
+type prefetchRequest struct {
+	URLs      []string `json:"urls"`
+	SearchURL string   `json:"searchUrl"`
+}
+
+// handlePrefetch é o handler HTTP para a rota de prefetch. A extensão envia as URLs
+// visíveis na página de busca (ou a própria busca) e recebe 202 imediatamente.
+func handlePrefetch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+
+	var requestBody prefetchRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(requestBody.URLs) == 0 && requestBody.SearchURL == "" {
+		writeError(w, http.StatusBadRequest, "urls or searchUrl is required in the request body")
+		return
+	}
+
+	if requestBody.SearchURL != "" {
+		// a página de busca também é raspada em segundo plano, depois da resposta
+		go func(searchURL string) {
+			listings, err := fetchSearchListings(detachedContext(r.Context()), searchURL)
+			if err != nil {
+				telemetry.LogFor(r.Context()).Warn("Search prefetch failed", "search", searchURL, "error", err)
+				return
+			}
+			for _, l := range listings {
+				queuePrefetch(r.Context(), l.URL)
+			}
+		}(requestBody.SearchURL)
+	}
+
+	queued := 0
+	for _, u := range requestBody.URLs {
+		if queuePrefetch(r.Context(), u) {
+			queued++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]int{"queued": queued, "skipped": len(requestBody.URLs) - queued})
+}
+
+// queuePrefetch põe a URL na fila de prefetch ou, para um tenant, na fila de jobs,
+// para que a análise use a chave do Maps do tenant e fique guardada em nome dele
+func queuePrefetch(ctx context.Context, listingURL string) bool {
+	if tenantID(ctx) == "" {
+		return enqueuePrefetch(listingURL)
+	}
+	if !daftURLPattern.MatchString(listingURL) {
+		return false
+	}
+	if _, _, ok := cachedAnalysis(ctx, listingURL, analysisCacheTTL()); ok {
+		return false
+	}
+	_, err := enqueueJob(ctx, jobAnalyze, listingURL, "")
+	return err == nil
+}
+
+// enqueuePrefetch põe a URL na fila se ela não estiver pendente nem no cache.
+// Com a fila cheia a URL é descartada: prefetch é só uma otimização.
+func enqueuePrefetch(listingURL string) bool {
+	if !daftURLPattern.MatchString(listingURL) {
+		return false
+	}
+	if _, _, ok := cachedAnalysis(serverContext, listingURL, analysisCacheTTL()); ok {
+		return false
+	}
+
+	prefetchMu.Lock()
+	defer prefetchMu.Unlock()
+	if prefetchPending[listingURL] {
+		return false
+	}
+	select {
+	case prefetchQueue <- listingURL:
+		prefetchPending[listingURL] = true
+		return true
+	default:
+		return false
+	}
+}
+
+// runPrefetchWorker processa a fila um anúncio por vez, cedendo lugar às análises
+// pedidas diretamente pelos usuários. No desligamento termina o anúncio em andamento e
+// deixa o resto da fila para persistPrefetchQueue.
+func runPrefetchWorker() {
+	for {
+		var listingURL string
+		select {
+		case listingURL = <-prefetchQueue:
+		case <-stopping.Done():
+			return
+		}
+		for atomic.LoadInt32(&foregroundAnalyses) > 0 {
+			if !sleepUnlessStopping(time.Second) {
+				select {
+				case prefetchQueue <- listingURL: // ainda pendente; volta para ser gravado
+				default:
+				}
+				return
+			}
+		}
+
+		if _, _, ok := cachedAnalysis(serverContext, listingURL, analysisCacheTTL()); !ok {
+			if err := prefetchListing(serverContext, listingURL); err != nil {
+				slog.Warn("Prefetch failed", "url", listingURL, "error", err)
+			}
+		}
+
+		prefetchMu.Lock()
+		delete(prefetchPending, listingURL)
+		prefetchMu.Unlock()
+	}
+}
+
+// persistPrefetchQueue grava no store as URLs que ficaram na fila, para que o próximo
+// processo as retome (ver restorePrefetchQueue). Chamado no desligamento, depois que o
+// worker parou.
+func persistPrefetchQueue() error {
+	var pending []string
+	for len(prefetchQueue) > 0 {
+		pending = append(pending, <-prefetchQueue)
+	}
+	prefetchMu.Lock()
+	for _, u := range pending {
+		delete(prefetchPending, u)
+	}
+	prefetchMu.Unlock()
+	if len(pending) > 0 {
+		slog.Info("Saving the prefetch queue", "listings", len(pending))
+	}
+	return store.Update(func(d *storeData) error {
+		d.PrefetchQueue = pending
+		return nil
+	})
+}
+
+// restorePrefetchQueue devolve à fila o que o processo anterior deixou pendente
+func restorePrefetchQueue() {
+	var pending []string
+	store.View(func(d *storeData) { pending = d.PrefetchQueue })
+	if len(pending) == 0 {
+		return
+	}
+	restored := 0
+	for _, u := range pending {
+		if enqueuePrefetch(u) {
+			restored++
+		}
+	}
+	slog.Info("Restored the prefetch queue", "listings", restored)
+	if err := store.Update(func(d *storeData) error {
+		d.PrefetchQueue = nil
+		return nil
+	}); err != nil {
+		slog.Warn("Could not clear the saved prefetch queue", "error", err)
+	}
+}
+
+func prefetchListing(ctx context.Context, listingURL string) error {
+	if upstream := analyzerFor(ctx).Upstream; upstream.Enabled() {
+		if property, err := upstream.Fetch(ctx, listingURL); err == nil {
+			property.URL = listingURL
+			recordAnalysis(ctx, &property)
+			return nil
+		}
+	}
+
+	property, err := analyzerFor(ctx).ScrapeDaftProperty(ctx, listingURL)
+	if err != nil {
+		return err
+	}
+	if property.Error != nil {
+		return property.Error
+	}
+	recordAnalysis(ctx, &property)
+	slog.Info("Prefetched", "url", listingURL)
+	return nil
+}
+
+// analyzeListingModules é o analyzeListing com seleção de módulos. Uma análise parcial
+// nunca é gravada nem exportada, para não ocupar o lugar da completa no cache.
+func analyzeListingModules(ctx context.Context, listingURL string, modules scraper.ModuleSet) (scraper.PropertyInfo, error) {
+	listingURL = scraper.CanonicalListingURL(listingURL)
+	if modules.Full() {
+		return analyzeListing(ctx, listingURL)
+	}
+	if !analysisCacheBypassed(ctx) {
+		property, _, ok := cachedAnalysis(ctx, listingURL, analysisCacheTTL())
+		countCache("analyses", ok)
+		if ok {
+			return property, nil
+		}
+	}
+
+	key := tenantScoped(ctx, listingURL+"?modules="+modules.String())
+	result, err := analysisFlights.do(ctx, key, func(ctx context.Context) (listingAnalysis, error) {
+		atomic.AddInt32(&foregroundAnalyses, 1)
+		defer atomic.AddInt32(&foregroundAnalyses, -1)
+
+		property, err := analyzerFor(ctx).ScrapeDaftPropertyModules(ctx, listingURL, modules)
+		return listingAnalysis{Property: property}, err
+	})
+	return result.Property, err
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/safety"
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestResolveAnalysisServesCache(t *testing.T) {
+	prev := store
+	store = newMemoryStore()
+	t.Cleanup(func() { store = prev })
+	url := "https://www.daft.ie/for-rent/apartment-1-main-street/123"
+	recordAnalysis(context.Background(), &scraper.PropertyInfo{URL: url, Address: "1 Main Street, Dublin 6", RentPrice: "€2,000 per month"})
+
+	result, err := resolveAnalysis(context.Background(), url, false)
+	if err != nil {
+		t.Fatalf("resolveAnalysis: %v", err)
+	}
+	if !result.Cached || result.Property.Address != "1 Main Street, Dublin 6" {
+		t.Fatalf("expected cached analysis, got %+v", result)
+	}
+
+	rec := httptest.NewRecorder()
+	result.AnalyzedAt = time.Now().Add(-90 * time.Second)
+	setCacheHeaders(rec, result)
+	if got := rec.Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("X-Cache = %q, want HIT", got)
+	}
+	if got := rec.Header().Get("Age"); got != "90" {
+		t.Errorf("Age = %q, want 90", got)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "private, max-age=3510" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	setCacheHeaders(rec, listingAnalysis{AnalyzedAt: time.Now()})
+	if got := rec.Header().Get("X-Cache"); got != "MISS" {
+		t.Errorf("X-Cache = %q, want MISS", got)
+	}
+}
+
+func TestHandleAnalyzeServesCachedSafety(t *testing.T) {
+	prev := store
+	store = newMemoryStore()
+	t.Cleanup(func() { store = prev })
+
+	url := "https://www.daft.ie/for-rent/apartment-1-main-street/123"
+	property := scraper.PropertyInfo{URL: url, Address: "1 Main Street, Dublin 6", RentPrice: "€2,000 per month"}
+	property.Coordinates.Lat, property.Coordinates.Lng = 53.32, -6.26
+	recordAnalysis(context.Background(), &property)
+
+	moved := property
+	moved.Coordinates.Lat = 53.4
+	saveSafetyInfo(context.Background(), &moved, safety.SafetyAnalysis{SafetyScore: 10})
+	if _, ok := cachedSafetyInfo(context.Background(), &property); ok {
+		t.Fatal("safety computed at other coordinates was attached to the snapshot")
+	}
+	saveSafetyInfo(context.Background(), &property, safety.SafetyAnalysis{SafetyScore: 77})
+
+	// with the listing and its safety cached, no geocoding or safety call is made
+	rec := httptest.NewRecorder()
+	handleAnalyze(rec, httptest.NewRequest(http.MethodGet, "/analyze?url="+url, nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("status = %d, X-Cache = %q: %s", rec.Code, rec.Header().Get("X-Cache"), rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), `"safetyScore":77`) {
+		t.Errorf("cached safety not served: %s", rec.Body)
+	}
+
+	recordAnalysis(context.Background(), &property)
+	if _, ok := cachedSafetyInfo(context.Background(), &property); ok {
+		t.Error("a new scrape should drop the cached safety")
+	}
+}
+
+func TestHandleAnalyzeGetRequiresURL(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handleAnalyze(rec, httptest.NewRequest(http.MethodGet, "/analyze", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handleAnalyze(rec, httptest.NewRequest(http.MethodDelete, "/analyze", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", rec.Code)
+	}
+}
+
+func TestAnalysisCacheOnlyForGET(t *testing.T) {
+	prev := store
+	store = newMemoryStore()
+	t.Cleanup(func() { store = prev })
+	t.Setenv("SCRAPE_DOMAINS", "127.0.0.1") // a fresh analysis fails fast instead of reaching Daft
+	url := "https://www.daft.ie/for-rent/apartment-1-main-street/123"
+	recordAnalysis(context.Background(), &scraper.PropertyInfo{URL: url, Address: "1 Main Street, Dublin 6", RentPrice: "€2,000 per month"})
+
+	for _, tc := range []struct {
+		method string
+		cached bool
+	}{
+		{http.MethodGet, true},
+		{http.MethodHead, true},
+		{http.MethodPost, false},
+	} {
+		var result listingAnalysis
+		var err error
+		handler := cacheOnlyForGET(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err = resolveAnalysis(r.Context(), url, false)
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, "/analyze", nil))
+
+		if result.Cached != tc.cached {
+			t.Errorf("%s: cached = %v, want %v", tc.method, result.Cached, tc.cached)
+		}
+		if !tc.cached && err == nil {
+			t.Errorf("%s: expected a fresh analysis attempt", tc.method)
+		}
+	}
+}
+
+func TestHandlePrefetchQueuesUncachedListings(t *testing.T) {
+	prev := store
+	store = newMemoryStore()
+	t.Cleanup(func() { store = prev })
+	t.Cleanup(func() {
+		for len(prefetchQueue) > 0 {
+			<-prefetchQueue
+		}
+		prefetchMu.Lock()
+		prefetchPending = map[string]bool{}
+		prefetchMu.Unlock()
+	})
+	cached := "https://www.daft.ie/for-rent/apartment-1-main-street/123"
+	recordAnalysis(context.Background(), &scraper.PropertyInfo{URL: cached, Address: "1 Main Street, Dublin 6", RentPrice: "€2,000 per month"})
+
+	body := `{"urls":["https://www.daft.ie/for-rent/apartment-2-main-street/456","https://www.daft.ie/for-rent/apartment-2-main-street/456",` +
+		`"` + cached + `","https://www.myhome.ie/rentals/789"]}`
+	rec := httptest.NewRecorder()
+	handlePrefetch(rec, httptest.NewRequest(http.MethodPost, "/prefetch", strings.NewReader(body)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"queued":1,"skipped":3}` {
+		t.Errorf("body = %s", got)
+	}
+	if len(prefetchQueue) != 1 {
+		t.Errorf("queue holds %d listings, want 1", len(prefetchQueue))
+	}
+
+	for _, tc := range []struct {
+		method, body string
+		want         int
+	}{
+		{http.MethodGet, "", http.StatusMethodNotAllowed},
+		{http.MethodPost, `{}`, http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		handlePrefetch(rec, httptest.NewRequest(tc.method, "/prefetch", strings.NewReader(tc.body)))
+		if rec.Code != tc.want {
+			t.Errorf("%s %s: status %d, want %d", tc.method, tc.body, rec.Code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"daft-scraper-api/internal/env"
+)
+
+/* ───── Privacidade diferencial dos agregados compartilhados ────────── */
+
+// aggregatePrivacy controla ruído e supressão dos agregados de mercado. Em instâncias
+// com mais de um usuário (API_TOKENS) fica ligado por padrão; MARKET_PRIVACY=on/off força.
+type aggregatePrivacy struct {
+	Enabled  bool    `json:"enabled"`
+	Epsilon  float64 `json:"epsilon,omitempty"`  // orçamento por consulta (PRIVACY_EPSILON, padrão 1)
+	MinCount int     `json:"minCount,omitempty"` // grupos menores são omitidos (MARKET_MIN_COUNT, padrão 5)
+}
+
+var (
+	noiseMu  sync.Mutex
+	noiseRng = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// marketPrivacyFromEnv lê MARKET_PRIVACY, PRIVACY_EPSILON e MARKET_MIN_COUNT; users é
+// quantos usuários a instância tem
+func marketPrivacyFromEnv(users int) aggregatePrivacy {
+	p := aggregatePrivacy{Epsilon: 1, MinCount: 5}
+	switch os.Getenv("MARKET_PRIVACY") {
+	case "on", "true":
+		p.Enabled = true
+	case "off", "false":
+		p.Enabled = false
+	default:
+		p.Enabled = users > 1
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("PRIVACY_EPSILON"), 64); err == nil && v > 0 {
+		p.Epsilon = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("MARKET_MIN_COUNT")); err == nil && v > 0 {
+		p.MinCount = v
+	}
+	if !p.Enabled {
+		return aggregatePrivacy{}
+	}
+	return p
+}
+
+// laplaceNoise sorteia ruído de Laplace com a escala dada (sensibilidade/epsilon)
+func laplaceNoise(scale float64) float64 {
+	noiseMu.Lock()
+	u := noiseRng.Float64() - 0.5
+	noiseMu.Unlock()
+	if u < 0 {
+		return scale * math.Log(1+2*u)
+	}
+	return -scale * math.Log(1-2*u)
+}
+
+// count devolve a contagem (com ruído, se ativo) e se o grupo pode ser publicado.
+// O limiar é aplicado à contagem ruidosa para não revelar a real.
+func (p aggregatePrivacy) count(n int, epsilon float64) (int, bool) {
+	if !p.Enabled {
+		return n, n > 0
+	}
+	noisy := int(math.Round(float64(n) + laplaceNoise(1/epsilon)))
+	if noisy < p.MinCount {
+		return 0, false
+	}
+	return noisy, true
+}
+
+// mean calcula a média de valores limitados a [0, upper]; com privacidade ativa, a soma
+// recebe ruído calibrado para que nenhum registro individual seja identificável
+func (p aggregatePrivacy) mean(values []float64, upper float64, noisyCount int, epsilon float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += math.Min(math.Max(v, 0), upper)
+	}
+	if !p.Enabled {
+		return sum / float64(len(values))
+	}
+	if noisyCount <= 0 {
+		return 0
+	}
+	mean := (sum + laplaceNoise(upper/epsilon)) / float64(noisyCount)
+	return math.Min(math.Max(mean, 0), upper)
+}
+
+/* ───── Orçamento acumulado entre consultas ─────────────────────────── */
+
+// Cada resposta ruidosa gasta epsilon, e repetir a consulta para tirar a média das
+// respostas anularia o ruído. Por isso a resposta publicada de cada consulta fica
+// guardada por PRIVACY_WINDOW (padrão 24h) e volta igual, sem gastar de novo; e cada
+// cliente (clientKey) tem PRIVACY_BUDGET de epsilon (padrão 10) por janela para
+// consultas novas. Esgotado o orçamento, as consultas novas recebem 429 até a janela virar.
+
+// privacyLedger guarda as respostas publicadas e o epsilon gasto por cliente na janela.
+// Os limites são lidos uma vez, em newPrivacyLedgerFromEnv.
+type privacyLedger struct {
+	privacy aggregatePrivacy // dos agregados de mercado
+	budget  float64          // epsilon por cliente na janela (PRIVACY_BUDGET)
+	window  time.Duration    // PRIVACY_WINDOW
+
+	mu      sync.Mutex
+	answers map[string]interface{}
+	spent   map[string]float64
+	resetAt time.Time
+	now     func() time.Time
+}
+
+// privacyAnswers é o livro do servidor, recriado em setup() com os usuários de API_TOKENS
+var privacyAnswers = newPrivacyLedgerFromEnv(0)
+
+// newPrivacyLedgerFromEnv lê a privacidade dos agregados (marketPrivacyFromEnv),
+// PRIVACY_BUDGET e PRIVACY_WINDOW
+func newPrivacyLedgerFromEnv(users int) *privacyLedger {
+	l := &privacyLedger{privacy: marketPrivacyFromEnv(users), budget: env.Float("PRIVACY_BUDGET", 10), window: 24 * time.Hour, now: time.Now}
+	if d, err := time.ParseDuration(os.Getenv("PRIVACY_WINDOW")); err == nil && d > 0 {
+		l.window = d
+	}
+	return l
+}
+
+// publish devolve a resposta já publicada para query ou, se não houver, calcula uma
+// com compute e debita o epsilon da consulta do cliente. ok=false com o orçamento
+// esgotado; retry é o tempo até a janela virar.
+func (l *privacyLedger) publish(client, query string, p aggregatePrivacy, compute func() interface{}) (answer interface{}, retry time.Duration, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if !now.Before(l.resetAt) {
+		l.answers = map[string]interface{}{}
+		l.spent = map[string]float64{}
+		l.resetAt = now.Add(l.window)
+	}
+	if answer, ok := l.answers[query]; ok {
+		return answer, 0, true
+	}
+	if l.spent[client]+p.Epsilon > l.budget {
+		return nil, l.resetAt.Sub(now), false
+	}
+	l.spent[client] += p.Epsilon
+	answer = compute()
+	l.answers[query] = answer
+	return answer, 0, true
+}
+
+// writePrivateAggregate responde com o agregado de compute. Com privacidade ativa, a
+// resposta passa pelo orçamento acumulado de privacyAnswers.
+func writePrivateAggregate(w http.ResponseWriter, r *http.Request, query string, compute func(aggregatePrivacy) interface{}) {
+	privacy := privacyAnswers.privacy
+	var answer interface{}
+	if !privacy.Enabled {
+		answer = compute(privacy)
+	} else {
+		var (
+			retry time.Duration
+			ok    bool
+		)
+		answer, retry, ok = privacyAnswers.publish(clientKey(r), query, privacy, func() interface{} { return compute(privacy) })
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, "Privacy budget for market aggregates exhausted; try again later")
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(answer)
+}
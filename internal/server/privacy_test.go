@@ -0,0 +1,118 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestAggregatePrivacy_Disabled(t *testing.T) {
+	var p aggregatePrivacy
+	if n, ok := p.count(2, 1); !ok || n != 2 {
+		t.Fatalf("count = %d, %v; want exact 2", n, ok)
+	}
+	if m := p.mean([]float64{1000, 3000}, 10000, 2, 1); m != 2000 {
+		t.Fatalf("mean = %v, want 2000", m)
+	}
+}
+
+func TestAggregatePrivacy_SuppressesSmallGroups(t *testing.T) {
+	p := aggregatePrivacy{Enabled: true, Epsilon: 1, MinCount: 5}
+	// com escala 1/ε = 1, uma contagem de 1 passa do limiar 5 em ~1,5% das vezes
+	published := 0
+	for i := 0; i < 1000; i++ {
+		if _, ok := p.count(1, 1); ok {
+			published++
+		}
+	}
+	if published > 60 {
+		t.Fatalf("small group published %d/1000 times", published)
+	}
+}
+
+func TestAggregatePrivacy_NoisyMeanIsBounded(t *testing.T) {
+	p := aggregatePrivacy{Enabled: true, Epsilon: 1, MinCount: 5}
+	values := make([]float64, 200)
+	for i := range values {
+		values[i] = 2000
+	}
+	var sum float64
+	for i := 0; i < 200; i++ {
+		m := p.mean(values, 10000, len(values), 1)
+		if m < 0 || m > 10000 {
+			t.Fatalf("mean %v outside bounds", m)
+		}
+		sum += m
+	}
+	if avg := sum / 200; math.Abs(avg-2000) > 100 {
+		t.Fatalf("noisy mean drifted to %v", avg)
+	}
+}
+
+func TestPrivacyLedgerRepeatsPublishedAnswers(t *testing.T) {
+	t.Setenv("PRIVACY_BUDGET", "2")
+	t.Setenv("PRIVACY_WINDOW", "1h")
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newPrivacyLedgerFromEnv(0)
+	l.now = func() time.Time { return now }
+	p := aggregatePrivacy{Enabled: true, Epsilon: 1, MinCount: 5}
+	calls := 0
+	compute := func() interface{} { calls++; return calls }
+
+	for i := 0; i < 5; i++ {
+		if answer, _, ok := l.publish("ip:a", "snapshot:rent:dublin", p, compute); !ok || answer != 1 {
+			t.Fatalf("repeat %d = %v, %v; want the first answer again", i, answer, ok)
+		}
+	}
+	if _, _, ok := l.publish("ip:a", "snapshot:rent:cork", p, compute); !ok {
+		t.Fatal("second distinct query should fit the budget")
+	}
+	if _, retry, ok := l.publish("ip:a", "snapshot:rent:galway", p, compute); ok || retry != time.Hour {
+		t.Fatalf("third distinct query = %v, retry %v; want refused for 1h", ok, retry)
+	}
+	if _, _, ok := l.publish("ip:b", "snapshot:rent:galway", p, compute); !ok {
+		t.Fatal("another client has its own budget")
+	}
+	if answer, _, ok := l.publish("ip:a", "snapshot:rent:galway", p, compute); !ok || answer != 3 {
+		t.Fatalf("an already published answer costs nothing, got %v, %v", answer, ok)
+	}
+
+	now = now.Add(time.Hour)
+	if answer, _, ok := l.publish("ip:a", "snapshot:rent:dublin", p, compute); !ok || answer != 4 {
+		t.Fatalf("after the window = %v, %v; want a fresh answer", answer, ok)
+	}
+}
+
+func TestMarketSnapshotRepeatsTheNoisyAnswer(t *testing.T) {
+	t.Setenv("MARKET_PRIVACY", "on")
+	t.Setenv("MARKET_MIN_COUNT", "1")
+	prevStore, prevLedger := store, privacyAnswers
+	store, privacyAnswers = newMemoryStore(), newPrivacyLedgerFromEnv(0)
+	t.Cleanup(func() { store, privacyAnswers = prevStore, prevLedger })
+	store.Update(func(d *storeData) error {
+		for _, id := range []string{"1", "2", "3"} {
+			url := "https://www.daft.ie/for-rent/x/" + id
+			d.Analyses[id] = &StoredAnalysis{ID: id, URL: url,
+				Property: scraper.PropertyInfo{URL: url, Address: "Rathmines, Dublin 6", RentPrice: "€2,000 per month", ListingType: "rent"}}
+		}
+		return nil
+	})
+
+	var first string
+	for i := 0; i < 5; i++ {
+		rec := httptest.NewRecorder()
+		handleMarketSnapshot(rec, httptest.NewRequest(http.MethodGet, "/market/snapshot?area=rathmines", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		if i == 0 {
+			first = rec.Body.String()
+		} else if rec.Body.String() != first {
+			t.Fatalf("repeated query got fresh noise:\n%s\n%s", first, rec.Body)
+		}
+	}
+}
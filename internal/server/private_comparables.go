@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+	"daft-scraper-api/internal/value"
+)
+
+// PrivateComparable é um arrendamento informado por uma agência (upload CSV)
+type PrivateComparable struct {
+	ID         string    `json:"id"`
+	Tenant     string    `json:"tenant,omitempty"` // quem enviou; só as análises dele usam o comparável
+	Agency     string    `json:"agency"`
+	Address    string    `json:"address"`
+	Rent       float64   `json:"rent"`    // por mês
+	LetDate    string    `json:"letDate"` // AAAA-MM-DD
+	UploadedAt time.Time `json:"uploadedAt"`
+}
+
+// privateComparablesMaxAge descarta arrendamentos antigos demais para servir de referência
+const privateComparablesMaxAge = 365 * 24 * time.Hour
+
+// maxPrivateComparables limita quantos comparáveis privados entram na análise
+const maxPrivateComparables = 10
+
+// handleComparablesUpload importa um CSV (address,rent,let_date) enviado como corpo
+// text/csv ou no campo "file" de um multipart. A agência vem em ?agency=
+func handleComparablesUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+
+	agency := strings.TrimSpace(r.URL.Query().Get("agency"))
+	if agency == "" {
+		writeError(w, http.StatusBadRequest, "agency query parameter is required")
+		return
+	}
+
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "file field is required in the multipart body")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	comparables, rowErrors, err := parseComparablesCSV(body, agency)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid CSV: %v", err))
+		return
+	}
+	for i := range comparables {
+		comparables[i].Tenant = tenantID(r.Context())
+	}
+
+	if err := store.Update(func(d *storeData) error {
+		d.PrivateComparables = append(d.PrivateComparables, comparables...)
+		return nil
+	}); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Error saving comparables: %v", err))
+		return
+	}
+
+	telemetry.LogFor(r.Context()).Info("Private comparables imported", "agency", agency, "rows", len(comparables), "rejected", len(rowErrors))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(uploadResult{len(comparables), rowErrors})
+}
+
+// uploadResult é a resposta de POST /comparables/upload
+type uploadResult struct {
+	Imported int      `json:"imported"`
+	Errors   []string `json:"errors"` // linhas inválidas, ignoradas
+}
+
+// parseComparablesCSV lê o CSV; linhas inválidas são reportadas sem abortar o upload
+func parseComparablesCSV(r io.Reader, agency string) ([]PrivateComparable, []string, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading header: %w", err)
+	}
+	cols := map[string]int{}
+	for i, h := range header {
+		cols[strings.ReplaceAll(strings.ToLower(strings.TrimSpace(h)), " ", "_")] = i
+	}
+	for _, required := range []string{"address", "rent", "let_date"} {
+		if _, ok := cols[required]; !ok {
+			return nil, nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	now := time.Now()
+	comparables := []PrivateComparable{}
+	rowErrors := []string{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+
+		address := strings.TrimSpace(record[cols["address"]])
+		rent := scraper.ExtractPriceValue(record[cols["rent"]])
+		letDate, err := parseLetDate(record[cols["let_date"]])
+		switch {
+		case address == "":
+			rowErrors = append(rowErrors, fmt.Sprintf("line %d: empty address", line))
+			continue
+		case rent == 0:
+			rowErrors = append(rowErrors, fmt.Sprintf("line %d: invalid rent %q", line, record[cols["rent"]]))
+			continue
+		case err != nil:
+			rowErrors = append(rowErrors, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+
+		comparables = append(comparables, PrivateComparable{
+			ID:         newID(),
+			Agency:     agency,
+			Address:    address,
+			Rent:       rent,
+			LetDate:    letDate.Format("2006-01-02"),
+			UploadedAt: now,
+		})
+	}
+	return comparables, rowErrors, nil
+}
+
+func parseLetDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{"2006-01-02", "02/01/2006", "2/1/2006", "02-01-2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid let date %q", s)
+}
+
+// findPrivateComparables devolve os comparáveis privados do tenant de ctx na mesma
+// área e faixa de preço
+func findPrivateComparables(ctx context.Context, property *scraper.PropertyInfo, minPrice, maxPrice float64) []scraper.SimilarProperty {
+	suburb, county := scraper.SplitLocation(property.Address)
+	if county == "" {
+		return nil
+	}
+
+	tenant := tenantID(ctx)
+	var similar []scraper.SimilarProperty
+	store.View(func(d *storeData) {
+		for _, pc := range d.PrivateComparables {
+			if len(similar) >= maxPrivateComparables {
+				return
+			}
+			if pc.Tenant != tenant {
+				continue
+			}
+			if pc.Rent < minPrice || pc.Rent > maxPrice {
+				continue
+			}
+			if t, err := time.Parse("2006-01-02", pc.LetDate); err == nil && time.Since(t) > privateComparablesMaxAge {
+				continue
+			}
+
+			pcSuburb, pcCounty := scraper.SplitLocation(pc.Address)
+			if scraper.Slugify(pcCounty) != scraper.Slugify(county) || (suburb != "" && scraper.Slugify(pcSuburb) != scraper.Slugify(suburb)) {
+				continue
+			}
+
+			comparable := value.NewComparable(pc.Address, "", pc.Rent, "month")
+			comparable.Source = "private:" + pc.Agency
+			similar = append(similar, comparable)
+		}
+	})
+	return similar
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// These tests exercise shared state from many goroutines. They only catch data
+// races when run with the race detector: go test -race ./...
+
+func TestConcurrentStoreAccess(t *testing.T) {
+	store = newMemoryStore()
+	defer func() { store = newMemoryStore() }()
+
+	now := time.Now()
+	store.Update(func(d *storeData) error {
+		d.Analyses["a1"] = &StoredAnalysis{URL: "https://www.daft.ie/for-rent/x/1", AnalyzedAt: now, FirstAnalyzedAt: now}
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			markAvailability("a1", "", time.Now())
+		}()
+		go func(i int) {
+			defer wg.Done()
+			notes := fmt.Sprintf("note %d", i)
+			store.Update(func(d *storeData) error {
+				tr := &Tracking{}
+				trackingUpdate{Notes: &notes}.apply(tr)
+				d.Analyses["a1"].Tracking = tr
+				return nil
+			})
+		}(i)
+		go func() {
+			defer wg.Done()
+			trackedAnalyses(context.Background(), "")
+		}()
+	}
+	wg.Wait()
+
+	if got := trackedAnalyses(context.Background(), ""); len(got) != 1 || got[0].Tracking == nil {
+		t.Fatalf("expected one tracked analysis with notes, got %+v", got)
+	}
+}
+
+func TestConcurrentAlertPublishers(t *testing.T) {
+	alertPublishersMu.Lock()
+	saved := alertPublishers
+	alertPublishers = nil
+	alertPublishersMu.Unlock()
+	defer func() {
+		alertPublishersMu.Lock()
+		alertPublishers = saved
+		alertPublishersMu.Unlock()
+	}()
+
+	var (
+		mu        sync.Mutex
+		delivered int
+	)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			addAlertPublisher(func(Alert) error {
+				mu.Lock()
+				delivered++
+				mu.Unlock()
+				return nil
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			publishAlert(Alert{URL: "https://www.daft.ie/for-rent/x/1"})
+		}()
+	}
+	wg.Wait()
+
+	publishAlert(Alert{URL: "https://www.daft.ie/for-rent/x/1"})
+	mu.Lock()
+	defer mu.Unlock()
+	if delivered < 4 {
+		t.Fatalf("expected the final alert to reach all 4 publishers, got %d deliveries", delivered)
+	}
+}
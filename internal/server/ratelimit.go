@@ -0,0 +1,191 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* ───── Rate limiting por cliente e contagem de chamadas ao Google Maps ── */
+
+// clientQuota é o estado de um cliente: o token bucket e o total de chamadas ao Maps
+// feitas em nome dele desde o start
+type clientQuota struct {
+	tokens    float64
+	updated   time.Time
+	mapsCalls int64
+}
+
+// rateLimiter aplica um token bucket por tenant, por chave de API ou, sem chave, por
+// IP: cada cliente acumula até burst requisições e recupera perMinute por minuto (um
+// tenant pode ter limites próprios).
+// Seguro para uso concorrente.
+type rateLimiter struct {
+	mu        sync.Mutex
+	perMinute float64
+	burst     float64
+	clients   map[string]*clientQuota
+	requests  int
+
+	trustProxy bool // TRUST_PROXY: atrás de um proxy, o IP do cliente vem de X-Forwarded-For
+}
+
+// newRateLimiterFromEnv lê RATE_LIMIT_PER_MINUTE (padrão 60; 0 desliga o limite, mas
+// mantém a contagem do Maps), RATE_LIMIT_BURST (padrão 20) e TRUST_PROXY
+func newRateLimiterFromEnv() *rateLimiter {
+	rl := &rateLimiter{perMinute: 60, burst: 20, clients: map[string]*clientQuota{}, trustProxy: os.Getenv("TRUST_PROXY") == "true"}
+	if v, err := strconv.ParseFloat(os.Getenv("RATE_LIMIT_PER_MINUTE"), 64); err == nil && v >= 0 {
+		rl.perMinute = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("RATE_LIMIT_BURST"), 64); err == nil && v >= 1 {
+		rl.burst = v
+	}
+	return rl
+}
+
+// limiter é o rate limiter do servidor, criado em setup()
+var limiter = newRateLimiterFromEnv()
+
+// rateLimitIdle é quanto tempo um cliente por IP fica sem requisições antes de sair
+// do mapa (clientes com chave ficam: são poucos e guardam o total do Maps)
+const rateLimitIdle = time.Hour
+
+// clientKey identifica o cliente pelo tenant, pela chave de API ou, sem ela, pelo
+// IP. Atrás de um proxy (TRUST_PROXY=true no limiter), usa o primeiro IP de
+// X-Forwarded-For.
+func clientKey(r *http.Request) string {
+	if id := tenantID(r.Context()); id != "" {
+		return "tenant:" + id
+	}
+	if user, ok := authenticate(r.Header.Get("Authorization"), r.Header.Get("X-API-Key")); ok {
+		return "key:" + user
+	}
+	if limiter.trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return "ip:" + strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// limits devolve o limite do cliente: o do tenant, se ele definir um, ou o do servidor
+func (rl *rateLimiter) limits(key string) (perMinute, burst float64) {
+	perMinute, burst = rl.perMinute, rl.burst
+	if id, ok := strings.CutPrefix(key, "tenant:"); ok {
+		if t, _ := tenants.get(id); t != nil {
+			if t.RateLimitPerMinute != nil {
+				perMinute = *t.RateLimitPerMinute
+			}
+			if t.RateLimitBurst != nil {
+				burst = *t.RateLimitBurst
+			}
+		}
+	}
+	return perMinute, burst
+}
+
+// allow consome um token do cliente; devolve se pode seguir, os tokens restantes,
+// os segundos até o bucket encher (ou, se negado, até o próximo token) e o total do Maps
+func (rl *rateLimiter) allow(key string, now time.Time) (ok bool, remaining int, wait int, mapsCalls int64) {
+	perMinute, burst := rl.limits(key)
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.requests++
+	if rl.requests%1000 == 0 {
+		rl.sweep(now)
+	}
+
+	q, found := rl.clients[key]
+	if !found {
+		q = &clientQuota{tokens: burst, updated: now}
+		rl.clients[key] = q
+	}
+	if perMinute == 0 {
+		q.updated = now
+		return true, int(burst), 0, q.mapsCalls
+	}
+
+	perSecond := perMinute / 60
+	q.tokens = math.Min(burst, q.tokens+now.Sub(q.updated).Seconds()*perSecond)
+	q.updated = now
+	if q.tokens < 1 {
+		return false, 0, int(math.Ceil((1 - q.tokens) / perSecond)), q.mapsCalls
+	}
+	q.tokens--
+	return true, int(q.tokens), int(math.Ceil((burst - q.tokens) / perSecond)), q.mapsCalls
+}
+
+// sweep descarta clientes por IP parados há mais de rateLimitIdle
+func (rl *rateLimiter) sweep(now time.Time) {
+	for key, q := range rl.clients {
+		if strings.HasPrefix(key, "ip:") && now.Sub(q.updated) > rateLimitIdle {
+			delete(rl.clients, key)
+		}
+	}
+}
+
+// mapsCallsOf devolve o total de chamadas ao Maps feitas em nome do cliente
+func (rl *rateLimiter) mapsCallsOf(key string) int64 {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if q, ok := rl.clients[key]; ok {
+		return q.mapsCalls
+	}
+	return 0
+}
+
+// chargeMaps soma chamadas ao Maps na conta do cliente e devolve o novo total
+func (rl *rateLimiter) chargeMaps(key string, calls int) int64 {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	q, ok := rl.clients[key]
+	if !ok {
+		q = &clientQuota{tokens: rl.burst, updated: time.Now()}
+		rl.clients[key] = q
+	}
+	q.mapsCalls += int64(calls)
+	return q.mapsCalls
+}
+
+// rateLimit é o middleware: aplica o limite e escreve X-RateLimit-* e X-Maps-Calls
+func rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := clientKey(r)
+		ok, remaining, wait, mapsCalls := limiter.allow(key, time.Now())
+		_, burst := limiter.limits(key)
+
+		h := w.Header()
+		h.Set("X-RateLimit-Limit", strconv.Itoa(int(burst)))
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		h.Set("X-RateLimit-Reset", strconv.Itoa(wait))
+		h.Set("X-Maps-Calls", strconv.FormatInt(mapsCalls, 10))
+		if !ok {
+			h.Set("Retry-After", strconv.Itoa(wait))
+			writeError(w, http.StatusTooManyRequests, "Rate limit exceeded. Try again later.")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// chargeMapsCalls debita do cliente as chamadas ao Maps feitas por esta requisição e
+// atualiza X-Maps-Calls; chame antes de escrever a resposta
+func chargeMapsCalls(w http.ResponseWriter, r *http.Request, calls int) {
+	if calls == 0 {
+		return
+	}
+	total := limiter.chargeMaps(clientKey(r), calls)
+	w.Header().Set("X-Maps-Calls", strconv.FormatInt(total, 10))
+}
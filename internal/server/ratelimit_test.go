@@ -0,0 +1,100 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func useLimiter(t *testing.T, perMinute, burst float64) {
+	t.Helper()
+	prev := limiter
+	limiter = &rateLimiter{perMinute: perMinute, burst: burst, clients: map[string]*clientQuota{}}
+	t.Cleanup(func() { limiter = prev })
+}
+
+func TestRateLimiterBucket(t *testing.T) {
+	useLimiter(t, 60, 2)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if ok, _, _, _ := limiter.allow("ip:1.2.3.4", now); !ok {
+			t.Fatalf("request %d should fit in the burst", i+1)
+		}
+	}
+	ok, remaining, wait, _ := limiter.allow("ip:1.2.3.4", now)
+	if ok || remaining != 0 || wait != 1 {
+		t.Errorf("third request: ok=%v remaining=%d wait=%d, want denied with a 1s wait", ok, remaining, wait)
+	}
+	// other clients have their own bucket
+	if ok, _, _, _ := limiter.allow("ip:5.6.7.8", now); !ok {
+		t.Error("a different client should not be limited")
+	}
+	// one token per second comes back at 60/min
+	if ok, _, _, _ := limiter.allow("ip:1.2.3.4", now.Add(time.Second)); !ok {
+		t.Error("a token should be refilled after a second")
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	useLimiter(t, 0, 1)
+	for i := 0; i < 5; i++ {
+		if ok, _, _, _ := limiter.allow("ip:1.2.3.4", time.Now()); !ok {
+			t.Fatal("RATE_LIMIT_PER_MINUTE=0 should not limit")
+		}
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	useLimiter(t, 60, 1)
+	handler := rateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chargeMapsCalls(w, r, 3)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/analyze", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	for header, want := range map[string]string{"X-RateLimit-Limit": "1", "X-RateLimit-Remaining": "0", "X-Maps-Calls": "3"} {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/analyze", nil))
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 429 with Retry-After, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	// the running total is reported even when the request is rejected
+	if got := rec.Header().Get("X-Maps-Calls"); got != "3" {
+		t.Errorf("X-Maps-Calls = %q, want 3", got)
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	useAPITokens(t, "alice:secret")
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-API-Key", "secret")
+	if got := clientKey(r); got != "key:alice" {
+		t.Errorf("with an API key: got %q", got)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:5555"
+	r.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.1")
+	if got := clientKey(r); got != "ip:10.0.0.1" {
+		t.Errorf("X-Forwarded-For should be ignored without TRUST_PROXY: got %q", got)
+	}
+	t.Setenv("TRUST_PROXY", "true")
+	prev := limiter
+	limiter = newRateLimiterFromEnv()
+	t.Cleanup(func() { limiter = prev })
+	if got := clientKey(r); got != "ip:203.0.113.9" {
+		t.Errorf("behind a proxy: got %q", got)
+	}
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"daft-scraper-api/internal/env"
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Reanálise agendada e histórico das análises ─────────────────── */
+
+// A cada REANALYSIS_INTERVAL as análises guardadas ainda no ar são refeitas pela fila
+// de jobs (no máximo REANALYSIS_BATCH por rodada, as mais antigas primeiro). Toda
+// análise registrada acrescenta um ponto de preço e de notas ao histórico da análise,
+// que alimenta os gráficos de tendência da extensão (GET /analyses/{id}/history).
+
+// historyMaxPoints limita o histórico de cada análise (um ponto por dia)
+const historyMaxPoints = 365
+
+// ScorePoint são as notas de uma análise num dia
+type ScorePoint struct {
+	Date      string `json:"date"`
+	Overall   int    `json:"overall"`
+	Safety    int    `json:"safety"`
+	Transport int    `json:"transport"`
+	Walk      int    `json:"walk"`
+	Value     int    `json:"value"`
+}
+
+// AnalysisHistory é a resposta de GET /analyses/{id}/history
+type AnalysisHistory struct {
+	ID           string               `json:"id"`
+	URL          string               `json:"url"`
+	PriceHistory []scraper.PricePoint `json:"priceHistory"`
+	ScoreHistory []ScorePoint         `json:"scoreHistory"`
+}
+
+// reanalysisInterval é REANALYSIS_INTERVAL; zero (o padrão) desliga a reanálise,
+// que gasta a cota do Google Maps como uma análise nova
+func reanalysisInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("REANALYSIS_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return 0
+}
+
+// reanalysisBatch é REANALYSIS_BATCH (padrão 20)
+func reanalysisBatch() int {
+	return env.Int("REANALYSIS_BATCH", 20)
+}
+
+// runReanalysisScheduler põe na fila, de hora em hora, as análises vencidas
+func runReanalysisScheduler() {
+	if reanalysisInterval() == 0 {
+		return
+	}
+	for {
+		if queued := scheduleReanalyses(time.Now()); queued > 0 {
+			slog.Info("Queued scheduled re-analyses", "listings", queued)
+		}
+		if !sleepUnlessStopping(time.Hour) {
+			return
+		}
+	}
+}
+
+// scheduleReanalyses enfileira as análises ativas feitas há mais de
+// reanalysisInterval e devolve quantas entraram na fila
+func scheduleReanalyses(now time.Time) int {
+	interval := reanalysisInterval()
+	if interval == 0 {
+		return 0
+	}
+	type due struct {
+		url, tenant string
+		analyzed    time.Time
+	}
+	var pending []due
+	store.View(func(d *storeData) {
+		for _, a := range d.Analyses {
+			if a.EndedAt == nil && now.Sub(a.FirstAnalyzedAt) <= availabilityMaxAge && now.Sub(a.AnalyzedAt) >= interval {
+				pending = append(pending, due{a.URL, a.Tenant, a.AnalyzedAt})
+			}
+		}
+	})
+	sort.Slice(pending, func(i, j int) bool { return pending[i].analyzed.Before(pending[j].analyzed) })
+	if batch := reanalysisBatch(); len(pending) > batch {
+		pending = pending[:batch]
+	}
+
+	queued := 0
+	for _, p := range pending {
+		ctx, ok := tenantContext(serverContext, p.tenant)
+		if !ok {
+			continue // tenant removido de TENANTS_FILE
+		}
+		if _, err := enqueueJob(ctx, jobReanalyze, p.url, ""); err != nil {
+			slog.Warn("Could not queue the re-analysis", "url", p.url, "error", err)
+			continue
+		}
+		queued++
+	}
+	return queued
+}
+
+// appendHistory acrescenta ao histórico de a o preço e as notas de property. Há um
+// ponto por dia: outra análise no mesmo dia substitui o ponto do dia. overall é a
+// nota geral de property (Analyzer.OverallScore).
+func appendHistory(a *StoredAnalysis, property *scraper.PropertyInfo, overall int, now time.Time) {
+	date := now.Format("2006-01-02")
+	if price := scraper.ExtractPriceValue(property.RentPrice); price > 0 {
+		point := scraper.PricePoint{Date: date, Price: price}
+		if n := len(a.PriceHistory); n > 0 && a.PriceHistory[n-1].Date == date {
+			a.PriceHistory[n-1] = point
+		} else {
+			a.PriceHistory = append(a.PriceHistory, point)
+		}
+		if len(a.PriceHistory) > historyMaxPoints {
+			a.PriceHistory = a.PriceHistory[len(a.PriceHistory)-historyMaxPoints:]
+		}
+	}
+
+	point := ScorePoint{
+		Date:      date,
+		Overall:   overall,
+		Safety:    property.SafetyInfo.SafetyRating,
+		Transport: property.QualityOfLife.TransportScore,
+		Walk:      property.QualityOfLife.WalkScore,
+		Value:     property.ValueAnalysis.PriceRating,
+	}
+	if n := len(a.ScoreHistory); n > 0 && a.ScoreHistory[n-1].Date == date {
+		a.ScoreHistory[n-1] = point
+	} else {
+		a.ScoreHistory = append(a.ScoreHistory, point)
+	}
+	if len(a.ScoreHistory) > historyMaxPoints {
+		a.ScoreHistory = a.ScoreHistory[len(a.ScoreHistory)-historyMaxPoints:]
+	}
+}
+
+// handleAnalysisHistory é o handler de GET /analyses/{id}/history
+func handleAnalysisHistory(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	var (
+		history AnalysisHistory
+		found   bool
+	)
+	store.View(func(d *storeData) {
+		a, ok := d.Analyses[id]
+		if !ok || !a.visibleTo(r.Context()) {
+			return
+		}
+		found = true
+		history = AnalysisHistory{
+			ID:           a.ID,
+			URL:          a.URL,
+			PriceHistory: append([]scraper.PricePoint{}, a.PriceHistory...),
+			ScoreHistory: append([]ScorePoint{}, a.ScoreHistory...),
+		}
+	})
+	if !found {
+		writeError(w, http.StatusNotFound, "Analysis not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestAppendHistoryOnePointPerDay(t *testing.T) {
+	a := &StoredAnalysis{}
+	day := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	property := scraper.PropertyInfo{RentPrice: "€2,000 per month"}
+	property.QualityOfLife.WalkScore = 70
+
+	appendHistory(a, &property, 70, day)
+	property.RentPrice = "€1,900 per month"
+	appendHistory(a, &property, 70, day.Add(3*time.Hour))
+	appendHistory(a, &property, 70, day.Add(24*time.Hour))
+
+	if len(a.PriceHistory) != 2 || a.PriceHistory[0].Price != 1900 || a.PriceHistory[1].Date != "2026-03-02" {
+		t.Errorf("price history = %+v", a.PriceHistory)
+	}
+	if len(a.ScoreHistory) != 2 || a.ScoreHistory[0].Walk != 70 || a.ScoreHistory[0].Overall != 70 {
+		t.Errorf("score history = %+v", a.ScoreHistory)
+	}
+
+	// a listing without a price still gets its scores recorded
+	b := &StoredAnalysis{}
+	appendHistory(b, &scraper.PropertyInfo{}, 0, day)
+	if len(b.PriceHistory) != 0 || len(b.ScoreHistory) != 1 {
+		t.Errorf("no price: %+v", b)
+	}
+}
+
+func TestAppendHistoryIsCapped(t *testing.T) {
+	a := &StoredAnalysis{}
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	property := scraper.PropertyInfo{RentPrice: "€1,500 per month"}
+	for i := 0; i < historyMaxPoints+10; i++ {
+		appendHistory(a, &property, 0, day.AddDate(0, 0, i))
+	}
+	if len(a.PriceHistory) != historyMaxPoints || a.PriceHistory[0].Date != "2025-01-11" {
+		t.Errorf("kept %d points starting %s", len(a.PriceHistory), a.PriceHistory[0].Date)
+	}
+}
+
+func TestRecordAnalysisAppendsHistory(t *testing.T) {
+	useJobStore(t)
+	property := scraper.PropertyInfo{URL: "https://www.daft.ie/for-rent/a/1", Address: "1 Main St", RentPrice: "€2,000 per month"}
+	recordAnalysis(context.Background(), &property)
+	property.RentPrice = "€1,800 per month"
+	recordAnalysis(context.Background(), &property)
+	store.View(func(d *storeData) {
+		a := d.Analyses[analysisKey(property.URL)]
+		if len(a.PriceHistory) != 1 || a.PriceHistory[0].Price != 1800 || len(a.ScoreHistory) != 1 {
+			t.Errorf("history after two analyses today = %+v / %+v", a.PriceHistory, a.ScoreHistory)
+		}
+	})
+}
+
+func TestScheduleReanalyses(t *testing.T) {
+	useJobStore(t)
+	now := time.Now()
+	ended := now.Add(-time.Hour)
+	store.Update(func(d *storeData) error {
+		d.Analyses["stale"] = &StoredAnalysis{URL: "https://www.daft.ie/for-rent/stale/1", FirstAnalyzedAt: now.Add(-10 * 24 * time.Hour), AnalyzedAt: now.Add(-8 * 24 * time.Hour)}
+		d.Analyses["older"] = &StoredAnalysis{URL: "https://www.daft.ie/for-rent/older/1", FirstAnalyzedAt: now.Add(-20 * 24 * time.Hour), AnalyzedAt: now.Add(-9 * 24 * time.Hour)}
+		d.Analyses["fresh"] = &StoredAnalysis{URL: "https://www.daft.ie/for-rent/fresh/1", FirstAnalyzedAt: now, AnalyzedAt: now}
+		d.Analyses["ended"] = &StoredAnalysis{URL: "https://www.daft.ie/for-rent/ended/1", FirstAnalyzedAt: now.Add(-20 * 24 * time.Hour), AnalyzedAt: now.Add(-9 * 24 * time.Hour), EndedAt: &ended}
+		return nil
+	})
+
+	if n := scheduleReanalyses(now); n != 0 {
+		t.Errorf("re-analysis is off by default, but %d were queued", n)
+	}
+
+	t.Setenv("REANALYSIS_INTERVAL", "168h")
+	t.Setenv("REANALYSIS_BATCH", "1")
+	if n := scheduleReanalyses(now); n != 1 {
+		t.Fatalf("queued %d, want 1 (the batch size)", n)
+	}
+	jobs, _ := jobQueue.list()
+	for _, job := range jobs {
+		if job.Kind != jobReanalyze || job.Target != "https://www.daft.ie/for-rent/older/1" {
+			t.Errorf("queued %+v, want the least recently analysed listing", job)
+		}
+	}
+
+	t.Setenv("REANALYSIS_BATCH", "10")
+	scheduleReanalyses(now)
+	if jobs, _ := jobQueue.list(); len(jobs) != 2 {
+		t.Errorf("jobs = %d, want 2 (stale and older, no duplicate)", len(jobs))
+	}
+}
+
+func TestAnalysisHistoryEndpoint(t *testing.T) {
+	useJobStore(t)
+	store.Update(func(d *storeData) error {
+		d.Analyses["abc"] = &StoredAnalysis{ID: "abc", URL: "https://www.daft.ie/for-rent/a/1",
+			PriceHistory: []scraper.PricePoint{{Date: "2026-03-01", Price: 2000}},
+			ScoreHistory: []ScorePoint{{Date: "2026-03-01", Overall: 64}}}
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	handleAnalysisRoutes(rec, httptest.NewRequest(http.MethodGet, "/analyses/abc/history", nil))
+	var history AnalysisHistory
+	json.NewDecoder(rec.Body).Decode(&history)
+	if rec.Code != http.StatusOK || len(history.PriceHistory) != 1 || history.ScoreHistory[0].Overall != 64 {
+		t.Errorf("status %d, history %+v", rec.Code, history)
+	}
+
+	rec = httptest.NewRecorder()
+	handleAnalysisRoutes(rec, httptest.NewRequest(http.MethodGet, "/analyses/missing/history", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing analysis: status %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Relatório HTML (sem precisar da extensão) ───────────────────── */
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(value, max int) int {
+		if max <= 0 || value <= 0 {
+			return 0
+		}
+		if value > max {
+			return 100
+		}
+		return value * 100 / max
+	},
+	"km": func(distance float64) string {
+		return fmt.Sprintf("%.1f km", distance)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Property.Address}} — Exchange Helper report</title>
+<style>
+body{font-family:system-ui,sans-serif;max-width:860px;margin:2rem auto;padding:0 1rem;color:#222}
+h1{margin-bottom:.2rem}.muted{color:#666}
+.gauges{display:grid;grid-template-columns:repeat(auto-fit,minmax(180px,1fr));gap:1rem;margin:1.5rem 0}
+.gauge{border:1px solid #ddd;border-radius:8px;padding:.8rem}
+.bar{background:#eee;border-radius:4px;height:10px;overflow:hidden}
+.fill{background:#2a9d8f;height:100%}
+table{border-collapse:collapse;width:100%}td,th{border-bottom:1px solid #eee;padding:.35rem;text-align:left}
+.flag{color:#b00020}
+</style>
+</head>
+<body>
+<h1>{{.Property.Address}}</h1>
+<p class="muted">{{.Property.RentPrice}}{{with .Property.Bedrooms}} · {{.}}{{end}}{{with .Property.Bathrooms}} · {{.}}{{end}}{{with .Property.BER}} · BER {{.}}{{end}}
+ · <a href="{{.Property.URL}}">listing</a></p>
+{{with .Property.Summary}}<p>{{.}}</p>{{end}}
+
+<div class="gauges">
+{{range .Gauges}}<div class="gauge"><strong>{{.Label}}</strong> {{.Value}}/{{.Max}}
+<div class="bar"><div class="fill" style="width:{{percent .Value .Max}}%"></div></div></div>
+{{end}}</div>
+
+{{with .Summary.RedFlags}}<h2>Red flags</h2><ul>{{range .}}<li class="flag">{{.Note}} <span class="muted">“{{.Phrase}}”</span></li>{{end}}</ul>{{end}}
+{{with .Summary.ComplianceFlags}}<h2>Equal Status concerns</h2><ul>{{range .}}<li class="flag">{{.Note}} <span class="muted">“{{.Phrase}}”</span></li>{{end}}</ul>{{end}}
+{{with .Summary.Pros}}<h2>Pros</h2><ul>{{range .}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{with .Summary.Cons}}<h2>Cons</h2><ul>{{range .}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{with .Property.Checklist}}<h2>Viewing checklist</h2><ul>{{range .}}<li>{{.Item}}{{with .Reason}} <span class="muted">({{.}})</span>{{end}}</li>{{end}}</ul>{{end}}
+
+{{range .POIGroups}}{{if .POIs}}<h2>{{.Title}}</h2>
+<table><tr><th>Name</th><th>Type</th><th>Distance</th><th>Walk</th></tr>
+{{range .POIs}}<tr><td>{{.Name}}</td><td>{{.Type}}</td><td>{{km .Distance}}</td><td>{{.Duration}} min</td></tr>{{end}}
+</table>{{end}}{{end}}
+
+{{with .Property.ValueAnalysis.Similar}}<h2>Comparables</h2>
+<table><tr><th>Address</th><th>Price</th><th>Source</th></tr>
+{{range .}}<tr><td>{{if .URL}}<a href="{{.URL}}">{{.Address}}</a>{{else}}{{.Address}}{{end}}</td><td>€{{printf "%.0f" .Price}}</td><td>{{.Source}}</td></tr>{{end}}
+</table>
+<p class="muted">Area average: €{{printf "%.0f" $.Property.ValueAnalysis.AreaAveragePrice}}</p>{{end}}
+</body>
+</html>
+`))
+
+type reportData struct {
+	Property  scraper.PropertyInfo
+	Summary   enrich.PropertySummary
+	Gauges    []reportGauge
+	POIGroups []reportPOIGroup
+}
+
+type reportGauge struct {
+	Label string
+	Value int
+	Max   int
+}
+
+type reportPOIGroup struct {
+	Title string
+	POIs  []scraper.POI
+}
+
+// handleReport é o handler HTTP para a rota de relatório: GET /report?url=...
+func handleReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	listingURL := r.URL.Query().Get("url")
+	if listingURL == "" {
+		writeError(w, http.StatusBadRequest, "url query parameter is required")
+		return
+	}
+
+	telemetry.LogFor(r.Context()).Info("Report requested", "url", listingURL)
+
+	property, err := analyzeListing(r.Context(), listingURL)
+	if err != nil {
+		writeScrapeError(w, err)
+		return
+	}
+
+	chargeMapsCalls(w, r, property.MapsCalls())
+
+	summary := analyzerFor(r.Context()).SummarizeProperty(&property)
+	data := reportData{
+		Property: property,
+		Summary:  summary,
+		Gauges: []reportGauge{
+			{"Overall", summary.OverallScore, 100},
+			{"Safety", summary.Scores.Safety, 10},
+			{"Transport", summary.Scores.Transport, 10},
+			{"Walk score", summary.Scores.Walk, 100},
+			{"Value", summary.Scores.Value, 10},
+		},
+		POIGroups: []reportPOIGroup{
+			{"Public transport", scraper.NearestPOIs(property.QualityOfLife.PublicTransport, 10)},
+			{"Amenities", scraper.NearestPOIs(property.QualityOfLife.Amenities, 10)},
+			{"Entertainment", scraper.NearestPOIs(property.QualityOfLife.Entertainment, 10)},
+			{"Garda stations", scraper.NearestPOIs(property.SafetyInfo.NearbyGardai, 5)},
+		},
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := reportTemplate.Execute(w, data); err != nil {
+		telemetry.LogFor(r.Context()).Warn("Could not render the report", "url", listingURL, "error", err)
+	}
+}
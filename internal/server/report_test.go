@@ -0,0 +1,31 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestReportTemplate(t *testing.T) {
+	property := scraper.PropertyInfo{Address: "1 Main St, Dublin 1", RentPrice: "€2,000 per month"}
+	property.QualityOfLife.PublicTransport = []scraper.POI{{Name: "Abbey Street", Type: "light_rail_station", Distance: 0.3, Duration: 4}}
+	property.ValueAnalysis.Similar = []scraper.SimilarProperty{{Address: "2 Main St", Price: 1950, Source: "daft.ie"}}
+
+	var b strings.Builder
+	data := reportData{
+		Property:  property,
+		Summary:   (&enrich.Analyzer{}).SummarizeProperty(&property),
+		Gauges:    []reportGauge{{"Safety", 7, 10}},
+		POIGroups: []reportPOIGroup{{"Public transport", property.QualityOfLife.PublicTransport}},
+	}
+	if err := reportTemplate.Execute(&b, data); err != nil {
+		t.Fatalf("template error: %v", err)
+	}
+	for _, want := range []string{"Abbey Street", "0.3 km", "width:70%", "2 Main St"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+}
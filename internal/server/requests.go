@@ -0,0 +1,130 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Logs, traces e modo debug das requisições ───────────────────── */
+
+// debugRequests liga o modo debug nas requisições com ?debug=true
+func debugRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("debug") == "true" {
+			r = r.WithContext(scraper.WithDebug(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validRequestID limita o X-Request-ID recebido ao que é seguro repetir em logs e
+// respostas
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// requestID reaproveita o X-Request-ID do cliente (ou do proxy na frente) quando
+// válido; senão gera um novo
+func requestID(r *http.Request) string {
+	if id := r.Header.Get("X-Request-ID"); validRequestID.MatchString(id) {
+		return id
+	}
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// statusRecorder guarda o status escrito pelo handler para o log da requisição
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush mantém o streaming (NDJSON, SSE) funcionando por trás do statusRecorder
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// logRequests dá a cada requisição um request ID, devolvido no X-Request-ID e no
+// envelope de erro para o suporte achar os logs, e um logger com ele no contexto.
+// Fica por fora do rateLimit, então as recusas também são registradas.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := requestID(r)
+		w.Header().Set("X-Request-ID", id)
+		l := slog.Default().With("request_id", id)
+		if s := telemetry.SpanFrom(r.Context()); s != nil {
+			l = l.With("trace_id", s.TraceIDString())
+		}
+
+		rec := &statusRecorder{ResponseWriter: w}
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(telemetry.WithLogger(r.Context(), l)))
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelWarn
+		}
+		l.Log(r.Context(), level, "Request handled",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"client", clientKey(r),
+		)
+	})
+}
+
+// traceRequests abre o span de servidor de cada requisição, continuando o trace do
+// traceparent recebido. Fica por fora de logRequests, que põe o trace_id nos logs.
+func traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if telemetry.Tracer == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, s := telemetry.StartSpan(telemetry.WithRemoteParent(r.Context(), r.Header.Get("traceparent")), r.Method+" "+r.URL.Path, telemetry.SpanServer,
+			"http.request.method", r.Method,
+			"url.path", r.URL.Path,
+		)
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		s.Set("http.response.status_code", status)
+		var err error
+		if status >= http.StatusInternalServerError {
+			err = fmt.Errorf("%d %s", status, http.StatusText(status))
+		}
+		s.End(err)
+	})
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+	"daft-scraper-api/internal/telemetry/telemetrytest"
+)
+
+func TestDebugRequestsSetsTheFlag(t *testing.T) {
+	t.Setenv("DEBUG", "false")
+	var got []bool
+	h := debugRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, scraper.DebugEnabled(r.Context()))
+	}))
+	for _, target := range []string{"/analyze?debug=true", "/analyze", "/analyze?debug=1"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	if len(got) != 3 || !got[0] || got[1] || got[2] {
+		t.Errorf("debug flags = %v, want [true false false]", got)
+	}
+
+	t.Setenv("DEBUG", "true")
+	if !scraper.DebugEnabled(context.Background()) {
+		t.Error("DEBUG=true should enable debug mode everywhere")
+	}
+}
+
+func TestLogRequestsCorrelatesLogs(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "info")
+	var logs bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(telemetry.NewLogger(&logs))
+	defer slog.SetDefault(prev)
+	handler := logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		telemetry.LogFor(r.Context()).Warn("Scraping failed", "url", "https://www.daft.ie/for-rent/x/1")
+		writeError(w, http.StatusBadGateway, "Error during scraping")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/analyze", nil))
+
+	id := rec.Header().Get("X-Request-ID")
+	if id == "" {
+		t.Fatal("missing X-Request-ID")
+	}
+	var body errorEnvelope
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.RequestID != id {
+		t.Errorf("envelope requestId = %q, want %q", body.RequestID, id)
+	}
+
+	// both the handler's line and the access line carry the request ID
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %q", lines)
+	}
+	for _, line := range lines {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line is not JSON: %q", line)
+		}
+		if entry["request_id"] != id {
+			t.Errorf("log line without the request ID: %q", line)
+		}
+	}
+	if !strings.Contains(lines[1], `"status":502`) {
+		t.Errorf("access line = %q, want the 502 status", lines[1])
+	}
+}
+
+func TestRequestIDFromClient(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/analyze", nil)
+	req.Header.Set("X-Request-ID", "trace-42.a_b")
+	if got := requestID(req); got != "trace-42.a_b" {
+		t.Errorf("a valid incoming ID should be kept, got %q", got)
+	}
+
+	// anything that could forge log lines is replaced
+	req.Header.Set("X-Request-ID", "x\n{\"level\":\"ERROR\"}")
+	if got := requestID(req); !validRequestID.MatchString(got) || strings.Contains(got, "{") {
+		t.Errorf("an invalid incoming ID should be replaced, got %q", got)
+	}
+}
+
+func TestTracingBreaksDownARequest(t *testing.T) {
+	spans := telemetrytest.Collector(t)
+
+	var gotTraceparent string
+	overpass := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer overpass.Close()
+	t.Setenv("OVERPASS_URL", overpass.URL)
+	client := &http.Client{Transport: scraper.TracingTransport{Base: http.DefaultTransport}}
+
+	handler := traceRequests(logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, s := telemetry.StartSpan(r.Context(), "overpass street lighting", telemetry.SpanInternal)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, overpass.URL, nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		s.End(err)
+		w.WriteHeader(http.StatusOK)
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/analyze", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := spans()
+	server := telemetrytest.SpanNamed(got, "GET /analyze")
+	stage := telemetrytest.SpanNamed(got, "overpass street lighting")
+	call := telemetrytest.SpanNamed(got, "POST overpass")
+	if server == nil || stage == nil || call == nil {
+		t.Fatalf("missing spans in %+v", got)
+	}
+
+	// the incoming trace is continued and the spans nest server → stage → call
+	if server.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || server.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("server span did not continue the incoming trace: %+v", server)
+	}
+	if stage.ParentSpanID != server.SpanID || call.ParentSpanID != stage.SpanID || call.TraceID != server.TraceID {
+		t.Errorf("spans are not nested: server %s, stage %s (parent %s), call parent %s",
+			server.SpanID, stage.SpanID, stage.ParentSpanID, call.ParentSpanID)
+	}
+	if call.Kind != telemetry.SpanClient || server.Kind != telemetry.SpanServer {
+		t.Errorf("kinds = %d/%d", server.Kind, call.Kind)
+	}
+	// the failing upstream is the span marked as an error
+	if call.Status == nil || call.Status.Code != 2 || server.Status != nil {
+		t.Errorf("statuses: call %+v, server %+v", call.Status, server.Status)
+	}
+	if !strings.Contains(gotTraceparent, call.TraceID+"-"+call.SpanID) {
+		t.Errorf("upstream got traceparent %q, want the call span", gotTraceparent)
+	}
+}
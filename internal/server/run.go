@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+)
+
+/* ───── Pontos de entrada (servidor HTTP e AWS Lambda) ───────────────── */
+
+// Run sobe o servidor HTTP e os jobs em segundo plano e só volta quando o servidor
+// para; chamado pelo main do build padrão depois de Configure
+func Run() {
+	// as flags têm prioridade sobre o ambiente e o arquivo de configuração
+	opts := tlsOptionsFromEnv()
+	addr := flag.String("addr", listenAddr(), "listen address, host:port (LISTEN_ADDR)")
+	flag.StringVar(&opts.CertFile, "tls-cert", opts.CertFile, "TLS certificate file (TLS_CERT_FILE)")
+	flag.StringVar(&opts.KeyFile, "tls-key", opts.KeyFile, "TLS private key file (TLS_KEY_FILE)")
+	autocertDomains := flag.String("autocert-domains", os.Getenv("AUTOCERT_DOMAINS"),
+		"comma-separated domains to get Let's Encrypt certificates for (AUTOCERT_DOMAINS)")
+	flag.Parse()
+	opts.AutocertDomains = splitList(*autocertDomains)
+
+	handler := setup()
+
+	restorePrefetchQueue()
+	startJobWorkers()
+	goBackground(runWatchScheduler)
+	goBackground(runSearchScheduler)
+	goBackground(runPrefetchWorker)
+	goBackground(runAvailabilityMonitor)
+	goBackground(runReanalysisScheduler)
+	if os.Getenv("TELEGRAM_BOT_ENABLED") == "true" {
+		goBackground(runTelegramBot)
+	}
+
+	srv := &http.Server{Addr: *addr, Handler: handler, BaseContext: func(net.Listener) context.Context { return serverContext }}
+	challenge, err := configureTLS(srv, opts)
+	if err != nil {
+		slog.Error("Invalid TLS configuration", "error", err)
+		os.Exit(1)
+	}
+	if challenge != nil {
+		go func() {
+			if err := challenge.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("ACME challenge server failed", "addr", challenge.Addr, "error", err)
+			}
+		}()
+	}
+	slog.Info("Server starting", "addr", *addr, "tls", srv.TLSConfig != nil)
+	if err := serve(serverContext, srv); err != nil {
+		slog.Error("Server failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// Lambda monta o handler dos eventos do API Gateway (ver lambda.go); chamado pelo main
+// do build com -tags lambda depois de Configure. Sem STORE_S3_BUCKET o store fica em
+// /tmp, que some com a instância. Sem os jobs em segundo plano, as rotas que só
+// agendam trabalho respondem 501 (ver lambdaMode).
+func Lambda() func(ctx context.Context, event json.RawMessage) (any, error) {
+	if os.Getenv("STORE_S3_BUCKET") == "" && os.Getenv("DATA_DIR") == "" {
+		os.Setenv("DATA_DIR", "/tmp/data") // o resto do sistema de arquivos é só leitura
+	}
+	lambdaMode = true
+	return lambdaHandler(setup())
+}
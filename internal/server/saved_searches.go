@@ -0,0 +1,415 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// SavedSearch é uma busca do Daft.ie monitorada em busca de anúncios novos.
+// O scheduler roda a busca numa cópia e grava Seen e LastChecked via store.Update.
+type SavedSearch struct {
+	ID          string          `json:"id"`
+	Tenant      string          `json:"tenant,omitempty"` // dono da busca; "" é o tenant padrão
+	URL         string          `json:"url"`
+	Filters     SearchFilters   `json:"filters"`
+	MinScore    int             `json:"minScore"` // nota geral mínima (0-100) para notificar
+	Notify      NotifyTarget    `json:"notify"`
+	Seen        map[string]bool `json:"seen"` // IDs de anúncios já vistos
+	CreatedAt   time.Time       `json:"createdAt"`
+	LastChecked time.Time       `json:"lastChecked"`
+}
+
+// SearchFilters são critérios aplicados antes de analisar um anúncio novo
+type SearchFilters struct {
+	MinPrice     float64 `json:"minPrice,omitempty"`
+	MaxPrice     float64 `json:"maxPrice,omitempty"`
+	MinBedrooms  int     `json:"minBedrooms,omitempty"`
+	PropertyType string  `json:"propertyType,omitempty"`
+
+	// Conferidos depois de analisar, pois só a página do anúncio traz estes dados
+	Furnishing  string     `json:"furnishing,omitempty"` // furnished, unfurnished ou part_furnished
+	AvailableBy *time.Time `json:"availableBy,omitempty"`
+}
+
+// SearchListing é um anúncio listado numa página de resultados do Daft.ie
+type SearchListing struct {
+	ID       string  `json:"id"`
+	Title    string  `json:"title"`
+	Price    string  `json:"price"`
+	Bedrooms string  `json:"bedrooms"`
+	Type     string  `json:"propertyType"`
+	URL      string  `json:"url"`
+	Lat      float64 `json:"lat"`
+	Lng      float64 `json:"lng"`
+}
+
+// clone copia a busca com o próprio mapa de vistos, para usá-la fora do lock do Store
+// enquanto checkSavedSearch marca anúncios novos no original
+func (s *SavedSearch) clone() *SavedSearch {
+	c := *s
+	c.Seen = make(map[string]bool, len(s.Seen))
+	for id := range s.Seen {
+		c.Seen[id] = true
+	}
+	if s.Filters.AvailableBy != nil {
+		by := *s.Filters.AvailableBy
+		c.Filters.AvailableBy = &by
+	}
+	return &c
+}
+
+// searchCheckInterval é o intervalo entre varreduras das buscas salvas (SEARCH_INTERVAL)
+func searchCheckInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("SEARCH_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return 30 * time.Minute
+}
+
+// searchRequest é o corpo de POST /searches
+type searchRequest struct {
+	URL      string        `json:"url"`
+	Filters  SearchFilters `json:"filters"`
+	MinScore int           `json:"minScore"`
+	Notify   NotifyTarget  `json:"notify"`
+}
+
+// handleSearches cria (POST), lista (GET) ou remove (DELETE ?id=) buscas salvas
+func handleSearches(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		var searches []*SavedSearch
+		store.View(func(d *storeData) {
+			for _, s := range d.SavedSearches {
+				if s.visibleTo(r.Context()) {
+					searches = append(searches, s.clone())
+				}
+			}
+		})
+		sort.Slice(searches, func(i, j int) bool { return searches[i].CreatedAt.Before(searches[j].CreatedAt) })
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(searches)
+
+	case http.MethodPost:
+		var requestBody searchRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if !scraper.IsScrapeURL(requestBody.URL) {
+			writeError(w, http.StatusBadRequest, "url must be a Daft.ie search URL")
+			return
+		}
+		if requestBody.Notify == (NotifyTarget{}) {
+			writeError(w, http.StatusBadRequest, "notify.webhook, notify.email or notify.telegram is required")
+			return
+		}
+		if err := validateWebhook(r.Context(), requestBody.Notify.Webhook); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		switch requestBody.Filters.Furnishing {
+		case "", "furnished", "unfurnished", "part_furnished":
+		default:
+			writeError(w, http.StatusBadRequest, "filters.furnishing must be furnished, unfurnished or part_furnished")
+			return
+		}
+
+		search := &SavedSearch{
+			ID:        newID(),
+			Tenant:    tenantID(r.Context()),
+			URL:       requestBody.URL,
+			Filters:   requestBody.Filters,
+			MinScore:  requestBody.MinScore,
+			Notify:    requestBody.Notify,
+			Seen:      map[string]bool{},
+			CreatedAt: time.Now(),
+		}
+
+		// A primeira varredura só marca os anúncios atuais como vistos
+		listings, err := fetchSearchListings(r.Context(), search.URL)
+		if err != nil {
+			writeScrapeError(w, err)
+			return
+		}
+		for _, l := range listings {
+			search.Seen[l.ID] = true
+		}
+		search.LastChecked = time.Now()
+
+		if err := store.Update(func(d *storeData) error {
+			d.SavedSearches[search.ID] = search.clone()
+			return nil
+		}); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Error saving search: %v", err))
+			return
+		}
+
+		telemetry.LogFor(r.Context()).Info("Search saved", "search", search.URL, "id", search.ID, "listings", len(listings))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(search)
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		found := false
+		if err := store.Update(func(d *storeData) error {
+			stored, ok := d.SavedSearches[id]
+			if found = ok && stored.visibleTo(r.Context()); found {
+				delete(d.SavedSearches, id)
+			}
+			return nil
+		}); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Error deleting search: %v", err))
+			return
+		}
+		if !found {
+			writeError(w, http.StatusNotFound, "Search not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Only GET, POST and DELETE methods are allowed")
+	}
+}
+
+// runSearchScheduler varre periodicamente as buscas salvas
+func runSearchScheduler() {
+	interval := searchCheckInterval()
+	for {
+		var due []SavedSearch
+		store.View(func(d *storeData) {
+			for _, s := range d.SavedSearches {
+				if time.Since(s.LastChecked) >= interval {
+					due = append(due, *s.clone())
+				}
+			}
+		})
+		for _, s := range due {
+			if stopping.Err() != nil {
+				return
+			}
+			// a análise dos anúncios novos usa a chave do Maps do dono da busca
+			if ctx, ok := tenantContext(serverContext, s.Tenant); ok {
+				checkSavedSearch(ctx, s)
+			}
+		}
+		if !sleepUnlessStopping(time.Minute) {
+			return
+		}
+	}
+}
+
+// checkSavedSearch analisa os anúncios novos da busca e notifica os que atingem a nota mínima
+func checkSavedSearch(ctx context.Context, search SavedSearch) {
+	listings, err := fetchSearchListings(ctx, search.URL)
+	if err != nil {
+		slog.Warn("Saved search check failed", "search", search.URL, "error", err)
+		return
+	}
+
+	fresh := search.unseen(listings)
+
+	// marca como vistos antes de analisar, para não repetir alertas se a análise falhar
+	if err := store.Update(func(d *storeData) error {
+		stored, ok := d.SavedSearches[search.ID]
+		if !ok {
+			return nil
+		}
+		if stored.Seen == nil {
+			stored.Seen = map[string]bool{}
+		}
+		for _, l := range fresh {
+			stored.Seen[l.ID] = true
+		}
+		stored.LastChecked = time.Now()
+		return nil
+	}); err != nil {
+		slog.Warn("Could not save the search", "id", search.ID, "error", err)
+	}
+
+	for _, l := range fresh {
+		if !search.Filters.match(l) {
+			continue
+		}
+
+		property, err := analyzerFor(ctx).ScrapeDaftProperty(ctx, l.URL)
+		if err != nil {
+			slog.Warn("Could not analyze the new listing", "url", l.URL, "error", err)
+			continue
+		}
+		if !search.Filters.matchTerms(property.RentalTerms) {
+			continue
+		}
+
+		score := analyzerFor(ctx).OverallScore(&property)
+		if score < search.MinScore {
+			slog.Debug("New listing below the minimum score", "url", l.URL, "score", score, "min_score", search.MinScore)
+			continue
+		}
+
+		deliverAlert(Alert{
+			Kind:     "new_listing",
+			URL:      l.URL,
+			Address:  property.Address,
+			Message:  fmt.Sprintf("New listing %s at %s scored %d/100", property.Address, property.RentPrice, score),
+			NewPrice: scraper.ExtractPriceValue(property.RentPrice),
+			Briefing: briefingText(analyzerFor(ctx), &property),
+		}, search.Notify)
+	}
+}
+
+// unseen devolve os anúncios que a busca ainda não tinha visto
+func (s SavedSearch) unseen(listings []SearchListing) []SearchListing {
+	var fresh []SearchListing
+	for _, l := range listings {
+		if !s.Seen[l.ID] {
+			fresh = append(fresh, l)
+		}
+	}
+	return fresh
+}
+
+// match aplica os filtros ao anúncio listado; campos que não puderam ser lidos não reprovam
+func (f SearchFilters) match(l SearchListing) bool {
+	price := scraper.ExtractPriceValue(l.Price)
+	if f.MinPrice > 0 && price > 0 && price < f.MinPrice {
+		return false
+	}
+	if f.MaxPrice > 0 && price > f.MaxPrice {
+		return false
+	}
+	if f.MinBedrooms > 0 {
+		beds, err := strconv.Atoi(strings.Fields(l.Bedrooms + " x")[0])
+		if err == nil && beds < f.MinBedrooms {
+			return false
+		}
+	}
+	if f.PropertyType != "" && l.Type != "" && !strings.EqualFold(f.PropertyType, l.Type) {
+		return false
+	}
+	return true
+}
+
+// matchTerms aplica os filtros de mobília e data de entrada ao anúncio analisado; como
+// em match, o que o anúncio não diz não reprova, e "either" serve para qualquer mobília
+func (f SearchFilters) matchTerms(t *scraper.RentalTerms) bool {
+	if t == nil {
+		return true
+	}
+	if f.Furnishing != "" && t.Furnishing != "" && t.Furnishing != "either" && t.Furnishing != f.Furnishing {
+		return false
+	}
+	if f.AvailableBy != nil && t.AvailableFrom != nil && t.AvailableFrom.After(*f.AvailableBy) {
+		return false
+	}
+	return true
+}
+
+// fetchSearchListings baixa uma página de resultados do Daft.ie
+func fetchSearchListings(ctx context.Context, searchURL string) ([]SearchListing, error) {
+	c := colly.NewCollector(
+		colly.AllowedDomains(scraper.ScrapeDomains()...),
+		colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+		colly.AllowURLRevisit(),
+	)
+	scraper.BindCollector(ctx, c)
+	scraper.LimitCrawl(c)
+
+	var (
+		listings []SearchListing
+		parseErr error
+	)
+	c.OnHTML("script#__NEXT_DATA__", func(e *colly.HTMLElement) {
+		listings, parseErr = parseSearchListings([]byte(e.Text))
+	})
+
+	if err := c.Visit(searchURL); err != nil {
+		return nil, fmt.Errorf("error visiting search page: %w", err)
+	}
+	return listings, parseErr
+}
+
+// searchPaging é a paginação de uma página de resultados (pageProps.paging); a
+// próxima página é pedida com ?from=NextFrom
+type searchPaging struct {
+	TotalPages   int `json:"totalPages"`
+	CurrentPage  int `json:"currentPage"`
+	NextFrom     int `json:"nextFrom"`
+	TotalResults int `json:"totalResults"`
+}
+
+// parseSearchPaging lê a paginação do JSON __NEXT_DATA__; zerada quando não há
+func parseSearchPaging(nextData []byte) searchPaging {
+	var data struct {
+		Props struct {
+			PageProps struct {
+				Paging searchPaging `json:"paging"`
+			} `json:"pageProps"`
+		} `json:"props"`
+	}
+	json.Unmarshal(nextData, &data) // o erro de decodificação já sai em parseSearchListings
+	return data.Props.PageProps.Paging
+}
+
+// parseSearchListings extrai os anúncios do JSON __NEXT_DATA__ de uma página de resultados
+func parseSearchListings(nextData []byte) ([]SearchListing, error) {
+	var data struct {
+		Props struct {
+			PageProps struct {
+				Listings []struct {
+					Listing struct {
+						ID              int64  `json:"id"`
+						Title           string `json:"title"`
+						Price           string `json:"price"`
+						NumBedrooms     string `json:"numBedrooms"`
+						PropertyType    string `json:"propertyType"`
+						SeoFriendlyPath string `json:"seoFriendlyPath"`
+						Point           struct {
+							Coordinates []float64 `json:"coordinates"` // [lng, lat]
+						} `json:"point"`
+					} `json:"listing"`
+				} `json:"listings"`
+			} `json:"pageProps"`
+		} `json:"props"`
+	}
+	if err := json.Unmarshal(nextData, &data); err != nil {
+		return nil, fmt.Errorf("error decoding __NEXT_DATA__: %w", err)
+	}
+
+	listings := make([]SearchListing, 0, len(data.Props.PageProps.Listings))
+	for _, item := range data.Props.PageProps.Listings {
+		l := item.Listing
+		if l.ID == 0 || l.SeoFriendlyPath == "" {
+			continue
+		}
+		sl := SearchListing{
+			ID:       strconv.FormatInt(l.ID, 10),
+			Title:    l.Title,
+			Price:    l.Price,
+			Bedrooms: l.NumBedrooms,
+			Type:     l.PropertyType,
+			URL:      "https://www.daft.ie" + l.SeoFriendlyPath,
+		}
+		if len(l.Point.Coordinates) == 2 {
+			sl.Lng, sl.Lat = l.Point.Coordinates[0], l.Point.Coordinates[1]
+		}
+		listings = append(listings, sl)
+	}
+	return listings, nil
+}
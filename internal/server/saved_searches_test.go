@@ -0,0 +1,179 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestParseSearchListings_Fixture(t *testing.T) {
+	html, err := os.ReadFile("debug_similar_response.html")
+	if err != nil {
+		t.Skipf("fixture not available: %v", err)
+	}
+	m := regexp.MustCompile(`(?s)<script id="__NEXT_DATA__"[^>]*>(.*?)</script>`).FindSubmatch(html)
+	if m == nil {
+		t.Fatal("fixture has no __NEXT_DATA__ script")
+	}
+
+	listings, err := parseSearchListings(m[1])
+	if err != nil {
+		t.Fatalf("parseSearchListings returned error: %v", err)
+	}
+	if len(listings) != 20 {
+		t.Fatalf("expected 20 listings, got %d", len(listings))
+	}
+
+	first := listings[0]
+	if first.ID != "6138994" || first.URL != "https://www.daft.ie/share/ballygraigue-estate-nenagh-co-tipperary-nenagh-co-tipperary/6138994" {
+		t.Errorf("unexpected first listing: %+v", first)
+	}
+	if first.Lat < 52 || first.Lat > 53 || first.Lng > -8 {
+		t.Errorf("coordinates not mapped as [lng, lat]: %f, %f", first.Lat, first.Lng)
+	}
+}
+
+func TestSearchFilters_Match(t *testing.T) {
+	l := SearchListing{Price: "€1,650 per month", Bedrooms: "2 Bed", Type: "Apartment"}
+	cases := []struct {
+		filters SearchFilters
+		want    bool
+	}{
+		{SearchFilters{}, true},
+		{SearchFilters{MaxPrice: 1500}, false},
+		{SearchFilters{MinPrice: 1000, MaxPrice: 1800}, true},
+		{SearchFilters{MinBedrooms: 3}, false},
+		{SearchFilters{PropertyType: "apartment"}, true},
+		{SearchFilters{PropertyType: "House"}, false},
+	}
+	for _, c := range cases {
+		if got := c.filters.match(l); got != c.want {
+			t.Errorf("%+v.match() = %v, want %v", c.filters, got, c.want)
+		}
+	}
+}
+
+func TestSearchFilters_MatchTerms(t *testing.T) {
+	by := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	later := by.AddDate(0, 1, 0)
+	cases := []struct {
+		filters SearchFilters
+		terms   *scraper.RentalTerms
+		want    bool
+	}{
+		{SearchFilters{Furnishing: "furnished"}, nil, true},
+		{SearchFilters{Furnishing: "furnished"}, &scraper.RentalTerms{Furnishing: "unfurnished"}, false},
+		{SearchFilters{Furnishing: "furnished"}, &scraper.RentalTerms{Furnishing: "either"}, true},
+		{SearchFilters{Furnishing: "furnished"}, &scraper.RentalTerms{MinimumLeaseMonths: 12}, true},
+		{SearchFilters{AvailableBy: &by}, &scraper.RentalTerms{AvailableFrom: &later}, false},
+		{SearchFilters{AvailableBy: &by}, &scraper.RentalTerms{AvailableNow: true}, true},
+	}
+	for _, c := range cases {
+		if got := c.filters.matchTerms(c.terms); got != c.want {
+			t.Errorf("%+v.matchTerms(%+v) = %v, want %v", c.filters, c.terms, got, c.want)
+		}
+	}
+}
+
+func TestHandleSearches(t *testing.T) {
+	prev := store
+	store = newMemoryStore()
+	t.Cleanup(func() { store = prev })
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<script id="__NEXT_DATA__" type="application/json">{"props":{"pageProps":{"listings":[`+
+			`{"listing":{"id":1,"seoFriendlyPath":"/for-rent/a/1"}},{"listing":{"id":2,"seoFriendlyPath":"/for-rent/b/2"}}]}}}</script>`)
+	}))
+	defer srv.Close()
+	prevTransport := http.DefaultTransport
+	http.DefaultTransport = srv.Client().Transport
+	t.Cleanup(func() { http.DefaultTransport = prevTransport })
+	t.Setenv("SCRAPE_DOMAINS", "127.0.0.1")
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handleSearches(rec, httptest.NewRequest(http.MethodPost, "/searches", strings.NewReader(body)))
+		return rec
+	}
+	for _, body := range []string{
+		`{"url":"https://example.com/property-for-rent/dublin","notify":{"email":"a@b.ie"}}`,
+		`{"url":"` + srv.URL + `/property-for-rent/dublin"}`,
+		`{"url":"` + srv.URL + `/property-for-rent/dublin","notify":{"email":"a@b.ie"},"filters":{"furnishing":"maybe"}}`,
+	} {
+		if rec := post(body); rec.Code != http.StatusBadRequest {
+			t.Errorf("POST %s: status %d, want 400", body, rec.Code)
+		}
+	}
+
+	// creating marks the current results as seen
+	rec := post(`{"url":"` + srv.URL + `/property-for-rent/dublin","minScore":60,"notify":{"telegram":"42"}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST status %d: %s", rec.Code, rec.Body)
+	}
+	var created SavedSearch
+	json.NewDecoder(rec.Body).Decode(&created)
+	if created.ID == "" || created.MinScore != 60 || !created.Seen["1"] || !created.Seen["2"] {
+		t.Errorf("created search = %+v", created)
+	}
+
+	rec = httptest.NewRecorder()
+	handleSearches(rec, httptest.NewRequest(http.MethodGet, "/searches", nil))
+	var listed []SavedSearch
+	json.NewDecoder(rec.Body).Decode(&listed)
+	if len(listed) != 1 || listed[0].ID != created.ID {
+		t.Errorf("GET = %+v", listed)
+	}
+
+	for _, want := range []int{http.StatusNoContent, http.StatusNotFound} {
+		rec = httptest.NewRecorder()
+		handleSearches(rec, httptest.NewRequest(http.MethodDelete, "/searches?id="+created.ID, nil))
+		if rec.Code != want {
+			t.Errorf("DELETE status %d, want %d", rec.Code, want)
+		}
+	}
+}
+
+func TestSavedSearchUnseen(t *testing.T) {
+	search := SavedSearch{Seen: map[string]bool{"1": true, "3": true}}
+	fresh := search.unseen([]SearchListing{{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}})
+	if len(fresh) != 2 || fresh[0].ID != "2" || fresh[1].ID != "4" {
+		t.Errorf("unseen = %+v", fresh)
+	}
+	if fresh := (SavedSearch{}).unseen([]SearchListing{{ID: "1"}}); len(fresh) != 1 {
+		t.Errorf("a search without history sees everything as new, got %+v", fresh)
+	}
+}
+
+func TestHandleSearchesListsCopies(t *testing.T) {
+	prev := store
+	store = newMemoryStore()
+	t.Cleanup(func() { store = prev })
+	store.Update(func(d *storeData) error {
+		d.SavedSearches["s1"] = &SavedSearch{ID: "s1", Seen: map[string]bool{"1": true}}
+		return nil
+	})
+
+	// the list is encoded while a check marks new listings as seen; run with -race
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			store.Update(func(d *storeData) error {
+				d.SavedSearches["s1"].Seen[strconv.Itoa(i)] = true
+				return nil
+			})
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		handleSearches(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/searches", nil))
+	}
+	<-done
+}
@@ -0,0 +1,331 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+	"daft-scraper-api/internal/value"
+
+	"github.com/gocolly/colly/v2"
+)
+
+/* ───── Análise de uma busca do Daft.ie ─────────────────────────────── */
+
+// POST /analyze/search recebe a URL de uma busca do Daft.ie, percorre as páginas de
+// resultados (parâmetro from) e faz em cada anúncio uma análise leve, sem abrir a
+// página dele: as coordenadas vêm da própria busca (só geocodificamos quem não as
+// tem), o transporte é o mesmo módulo da análise completa e o preço é comparado à
+// média dos outros anúncios da busca com o mesmo número de quartos. A resposta vem
+// ordenada pela nota.
+
+const (
+	maxSearchPages       = 5
+	defaultSearchResults = 20
+	maxSearchResults     = 60
+	searchConcurrency    = 4
+	searchMinPeers       = 3 // anúncios com o mesmo número de quartos para usar só a média deles
+)
+
+// searchAnalysisRequest é o corpo de POST /analyze/search
+type searchAnalysisRequest struct {
+	SearchURL  string        `json:"searchUrl"`
+	MaxResults int           `json:"maxResults,omitempty"` // padrão 20, no máximo 60
+	Filters    SearchFilters `json:"filters"`
+}
+
+// RankedListing é um anúncio da busca com a análise leve
+// Montado pelo worker do anúncio; não muda depois de ordenado.
+type RankedListing struct {
+	Rank             int                 `json:"rank"`
+	Listing          SearchListing       `json:"listing"`
+	Price            float64             `json:"price,omitempty"` // aluguel por mês; preço de venda na venda
+	AreaAveragePrice float64             `json:"areaAveragePrice,omitempty"`
+	PriceVsArea      float64             `json:"priceVsAreaPercent"` // acima (+) ou abaixo (-) da média, em %
+	PriceRating      int                 `json:"priceRating,omitempty"`
+	TransportScore   int                 `json:"transportScore,omitempty"`
+	NearestStationKm float64             `json:"nearestStationKm,omitempty"`
+	Score            float64             `json:"score"` // média de PriceRating e TransportScore (1-10)
+	Warnings         []*scraper.APIError `json:"warnings,omitempty"`
+}
+
+// SearchAnalysis é a resposta de POST /analyze/search
+type SearchAnalysis struct {
+	SearchURL    string          `json:"searchUrl"`
+	Pages        int             `json:"pages"`        // páginas de resultados lidas
+	TotalResults int             `json:"totalResults"` // total que o Daft diz ter na busca
+	Results      []RankedListing `json:"results"`
+}
+
+// handleAnalyzeSearch é o handler HTTP para POST /analyze/search
+func handleAnalyzeSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+
+	var requestBody searchAnalysisRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !strings.Contains(requestBody.SearchURL, "daft.ie/") {
+		writeError(w, http.StatusBadRequest, "searchUrl must be a Daft.ie search URL")
+		return
+	}
+	limit := requestBody.MaxResults
+	switch {
+	case limit == 0:
+		limit = defaultSearchResults
+	case limit < 0 || limit > maxSearchResults:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("maxResults must be between 1 and %d", maxSearchResults))
+		return
+	}
+
+	ctx := r.Context()
+	telemetry.LogFor(ctx).Info("Search analysis requested", "search_url", requestBody.SearchURL, "max_results", limit)
+	listings, paging, pages, err := fetchSearchPages(ctx, requestBody.SearchURL, limit, requestBody.Filters)
+	if err != nil {
+		telemetry.LogFor(ctx).Warn("Search scrape failed", "search_url", requestBody.SearchURL, "error", err)
+		writeError(w, http.StatusBadGateway, "Could not read the search results")
+		return
+	}
+
+	results, calls := rankSearchListings(ctx, analyzerFor(ctx), listings)
+	chargeMapsCalls(w, r, calls)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SearchAnalysis{
+		SearchURL:    requestBody.SearchURL,
+		Pages:        pages,
+		TotalResults: paging.TotalResults,
+		Results:      results,
+	})
+}
+
+// fetchSearchPages lê as páginas da busca até juntar limit anúncios que passam nos
+// filtros, acabar a busca, chegar a maxSearchPages ou acabar o orçamento de páginas
+func fetchSearchPages(ctx context.Context, searchURL string, limit int, filters SearchFilters) ([]SearchListing, searchPaging, int, error) {
+	u, err := url.Parse(searchURL)
+	if err != nil {
+		return nil, searchPaging{}, 0, fmt.Errorf("invalid search URL: %w", err)
+	}
+
+	var (
+		listings []SearchListing
+		first    searchPaging
+		seen     = map[string]bool{}
+		from     = 0
+		pages    = 0
+	)
+	ctx = scraper.WithCrawlBudget(ctx)
+	for pages < maxSearchPages && len(listings) < limit {
+		if !scraper.TakeCrawlPage(ctx) {
+			if pages == 0 {
+				return nil, searchPaging{}, 0, scraper.ErrCrawlBudgetExhausted
+			}
+			telemetry.LogFor(ctx).Warn("Crawl budget exhausted, stopping the search", "pages", pages)
+			break
+		}
+		q := u.Query()
+		if from > 0 {
+			q.Set("from", strconv.Itoa(from))
+		}
+		u.RawQuery = q.Encode()
+
+		page, paging, err := fetchSearchPage(ctx, u.String())
+		if err != nil {
+			if pages > 0 {
+				// fica com o que já foi lido; as páginas seguintes são bônus
+				telemetry.LogFor(ctx).Warn("Search page failed", "from", from, "error", err)
+				break
+			}
+			return nil, searchPaging{}, 0, err
+		}
+		if pages == 0 {
+			first = paging
+		}
+		pages++
+
+		for _, l := range page {
+			if seen[l.ID] || !filters.match(l) {
+				continue
+			}
+			seen[l.ID] = true
+			listings = append(listings, l)
+			if len(listings) == limit {
+				break
+			}
+		}
+		if len(page) == 0 || paging.NextFrom <= from || paging.CurrentPage >= paging.TotalPages {
+			break
+		}
+		from = paging.NextFrom
+	}
+	return listings, first, pages, nil
+}
+
+// fetchSearchPage baixa uma página de resultados e lê os anúncios e a paginação
+func fetchSearchPage(ctx context.Context, pageURL string) ([]SearchListing, searchPaging, error) {
+	c := colly.NewCollector(
+		colly.AllowedDomains(scraper.ScrapeDomains()...),
+		colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+		colly.AllowURLRevisit(),
+	)
+	scraper.BindCollector(ctx, c)
+	scraper.LimitCrawl(c)
+
+	var (
+		listings []SearchListing
+		paging   searchPaging
+		parseErr = fmt.Errorf("search page has no __NEXT_DATA__")
+	)
+	c.OnHTML("script#__NEXT_DATA__", func(e *colly.HTMLElement) {
+		listings, parseErr = parseSearchListings([]byte(e.Text))
+		paging = parseSearchPaging([]byte(e.Text))
+	})
+
+	if err := c.Visit(pageURL); err != nil {
+		return nil, searchPaging{}, fmt.Errorf("error visiting search page: %w", err)
+	}
+	return listings, paging, parseErr
+}
+
+// rankSearchListings faz a análise leve de cada anúncio, com concorrência limitada, e
+// devolve os anúncios do melhor para o pior junto com as chamadas ao Maps feitas
+func rankSearchListings(ctx context.Context, a *enrich.Analyzer, listings []SearchListing) ([]RankedListing, int) {
+	properties := make([]scraper.PropertyInfo, len(listings))
+	for i, l := range listings {
+		properties[i] = scraper.PropertyInfo{URL: l.URL, Address: l.Title, RentPrice: l.Price, Price: scraper.ParsePrice(l.Price, "")}
+		properties[i].Coordinates.Lat, properties[i].Coordinates.Lng = l.Lat, l.Lng
+	}
+
+	sem := make(chan struct{}, searchConcurrency)
+	var wg sync.WaitGroup
+	for i := range properties {
+		wg.Add(1)
+		go func(p *scraper.PropertyInfo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			analyzeSearchListing(ctx, a, p)
+		}(&properties[i])
+	}
+	wg.Wait()
+
+	results := make([]RankedListing, 0, len(listings))
+	calls := 0
+	for i, l := range listings {
+		p := &properties[i]
+		calls += p.MapsCalls()
+		p.ValueAnalysis.AreaAveragePrice = searchAreaAverage(listings, i)
+		value.CalculatePriceRating(p)
+
+		res := RankedListing{
+			Listing:          l,
+			Price:            scraper.MonthlyPrice(p),
+			AreaAveragePrice: math.Round(p.ValueAnalysis.AreaAveragePrice),
+			PriceRating:      p.ValueAnalysis.PriceRating,
+			TransportScore:   p.QualityOfLife.TransportScore,
+			Warnings:         p.Warnings,
+		}
+		if res.Price > 0 && res.AreaAveragePrice > 0 {
+			res.PriceVsArea = math.Round((res.Price-p.ValueAnalysis.AreaAveragePrice)/p.ValueAnalysis.AreaAveragePrice*1000) / 10
+		}
+		if len(p.QualityOfLife.PublicTransport) > 0 {
+			res.NearestStationKm = p.QualityOfLife.PublicTransport[0].Distance
+		}
+		res.Score = searchScore(res.PriceRating, res.TransportScore)
+		results = append(results, res)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Price < results[j].Price
+	})
+	for i := range results {
+		results[i].Rank = i + 1
+	}
+	return results, calls
+}
+
+// analyzeSearchListing geocodifica (quando a busca não trouxe o ponto) e procura o
+// transporte público; as falhas viram avisos no anúncio
+func analyzeSearchListing(ctx context.Context, a *enrich.Analyzer, p *scraper.PropertyInfo) {
+	if p.Coordinates.Lat == 0 && p.Coordinates.Lng == 0 {
+		if err := a.GetCoordinates(ctx, p); err != nil {
+			p.Warnings = append(p.Warnings, scraper.ModuleError(err, "GEOCODE_FAILED", "location"))
+			return
+		}
+	}
+	placesCtx, cancel := a.WithStageTimeout(enrich.WithMapsUsage(ctx, p.Usage()), "places")
+	defer cancel()
+	if err := a.FindPublicTransport(placesCtx, p); err != nil {
+		telemetry.LogFor(ctx).Warn("Public transport search failed", "url", p.URL, "error", err)
+		p.Warnings = append(p.Warnings, scraper.ModuleError(err, "PLACES_FAILED", "transport"))
+	}
+}
+
+// searchAreaAverage é a média de preço dos outros anúncios da busca com o mesmo número
+// de quartos ou, com menos de searchMinPeers deles, de todos os outros
+func searchAreaAverage(listings []SearchListing, i int) float64 {
+	price := func(l SearchListing) float64 {
+		p := scraper.ParsePrice(l.Price, "")
+		if p == nil {
+			return 0
+		}
+		if p.Monthly > 0 {
+			return p.Monthly
+		}
+		return p.Amount
+	}
+	beds, hasBeds := scraper.ParseRoomCount(listings[i].Bedrooms)
+
+	var sameTotal, allTotal float64
+	var same, all int
+	for j, l := range listings {
+		v := price(l)
+		if j == i || v == 0 {
+			continue
+		}
+		allTotal += v
+		all++
+		if n, ok := scraper.ParseRoomCount(l.Bedrooms); hasBeds && ok && n == beds {
+			sameTotal += v
+			same++
+		}
+	}
+	switch {
+	case same >= searchMinPeers:
+		return sameTotal / float64(same)
+	case all > 0:
+		return allTotal / float64(all)
+	}
+	return 0
+}
+
+// searchScore é a média de PriceRating e TransportScore; a nota que falta (sem preço,
+// sem coordenadas) conta como 5, a base do transporte, para a falha não subir o anúncio
+func searchScore(priceRating, transportScore int) float64 {
+	if priceRating == 0 && transportScore == 0 {
+		return 0
+	}
+	for _, s := range []*int{&priceRating, &transportScore} {
+		if *s == 0 {
+			*s = 5
+		}
+	}
+	return float64(priceRating+transportScore) / 2
+}
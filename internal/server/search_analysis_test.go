@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+
+	"googlemaps.github.io/maps"
+)
+
+func TestSearchAreaAverage(t *testing.T) {
+	listings := []SearchListing{
+		{Price: "€2,000 per month", Bedrooms: "2 Bed"},
+		{Price: "€2,200 per month", Bedrooms: "2 Bed"},
+		{Price: "€2,400 per month", Bedrooms: "2 Bed"},
+		{Price: "€2,600 per month", Bedrooms: "2 Bed"},
+		{Price: "€300 per week", Bedrooms: "1 Bed"},
+		{Price: "Price on Application", Bedrooms: "3 Bed"},
+	}
+	// three other 2-beds: their own average, without the listing itself
+	if got := searchAreaAverage(listings, 0); got != 2400 {
+		t.Errorf("2-bed average = %v, want 2400", got)
+	}
+	// no other 1-beds: every other priced listing, weekly rent as monthly
+	if got := searchAreaAverage(listings, 4); got != 2300 {
+		t.Errorf("1-bed average = %v, want 2300", got)
+	}
+}
+
+func TestSearchScore(t *testing.T) {
+	if got := searchScore(8, 5); got != 6.5 {
+		t.Errorf("score = %v, want 6.5", got)
+	}
+	// a missing rating counts as neutral, so a failed lookup does not lift a listing
+	if got := searchScore(9, 0); got != 7 {
+		t.Errorf("only price = %v, want 7", got)
+	}
+	if got := searchScore(0, 0); got != 0 {
+		t.Errorf("no ratings = %v, want 0", got)
+	}
+}
+
+func TestRankSearchListings(t *testing.T) {
+	// a station right next to the first listing only
+	places := enrich.PlacesSearchFunc(func(location *maps.LatLng, placeType string, radius uint) ([]maps.PlacesSearchResult, error) {
+		if placeType != "train_station" || location.Lat != 53.30 {
+			return nil, nil
+		}
+		place := maps.PlacesSearchResult{Name: "Sandymount", Types: []string{placeType}}
+		place.Geometry.Location = maps.LatLng{Lat: location.Lat + 0.001, Lng: location.Lng}
+		return []maps.PlacesSearchResult{place}, nil
+	})
+	a := &enrich.Analyzer{Places: places, Geocoders: []enrich.NamedGeocoder{{Name: "eircode", Geocoder: enrich.EircodeGeocoder{}}}}
+
+	listings := []SearchListing{
+		{ID: "1", Title: "1 Strand Road", Price: "€2,500 per month", Bedrooms: "2 Bed", Lat: 53.30, Lng: -6.21},
+		{ID: "2", Title: "2 Main Street", Price: "€1,900 per month", Bedrooms: "2 Bed", Lat: 53.40, Lng: -6.30},
+		{ID: "3", Title: "3 Main Street", Price: "€2,000 per month", Bedrooms: "2 Bed", Lat: 53.41, Lng: -6.30},
+		{ID: "4", Title: "Somewhere", Price: "€2,000 per month", Bedrooms: "2 Bed"},
+	}
+	results, _ := rankSearchListings(context.Background(), a, listings)
+	if len(results) != 4 {
+		t.Fatalf("got %d results, want 4", len(results))
+	}
+
+	if results[0].Listing.ID != "2" || results[0].Rank != 1 {
+		t.Errorf("cheapest listing should rank first, got %+v", results[0])
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Score > results[i-1].Score || results[i].Rank != i+1 {
+			t.Errorf("results not ranked: %+v", results)
+		}
+	}
+
+	byID := map[string]RankedListing{}
+	for _, r := range results {
+		byID[r.Listing.ID] = r
+	}
+	if r := byID["1"]; r.NearestStationKm == 0 || r.TransportScore <= byID["2"].TransportScore || r.PriceVsArea <= 0 {
+		t.Errorf("listing near the station = %+v", r)
+	}
+	if r := byID["4"]; len(r.Warnings) == 0 || r.Warnings[0].Code != "GEOCODE_FAILED" || r.TransportScore != 0 {
+		t.Errorf("listing without coordinates = %+v", r)
+	}
+}
+
+func TestFetchSearchPages(t *testing.T) {
+	var mu sync.Mutex
+	var froms []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		from, _ := strconv.Atoi(r.URL.Query().Get("from"))
+		mu.Lock()
+		froms = append(froms, r.URL.Query().Get("from"))
+		mu.Unlock()
+
+		var items []string
+		for i := from; i < from+2; i++ {
+			items = append(items, fmt.Sprintf(`{"listing":{"id":%d,"title":"%d Main Street","price":"€%d per month","numBedrooms":"%d Bed","seoFriendlyPath":"/for-rent/%d"}}`,
+				i+1, i+1, 1500+100*i, 1+i%2, i+1))
+		}
+		fmt.Fprintf(w, `<html><script id="__NEXT_DATA__" type="application/json">{"props":{"pageProps":{"listings":[%s],"paging":{"totalPages":3,"currentPage":%d,"nextFrom":%d,"totalResults":6}}}}</script></html>`,
+			strings.Join(items, ","), from/2+1, from+2)
+	}))
+	defer srv.Close()
+	t.Setenv("SCRAPE_DOMAINS", "127.0.0.1")
+
+	listings, paging, pages, err := fetchSearchPages(context.Background(), srv.URL+"/property-for-rent/dublin", 10, SearchFilters{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages != 3 || len(listings) != 6 || paging.TotalResults != 6 {
+		t.Errorf("pages = %d, listings = %d, paging = %+v", pages, len(listings), paging)
+	}
+	if strings.Join(froms, ",") != ",2,4" {
+		t.Errorf("requested from = %q, want \",2,4\"", froms)
+	}
+
+	// the limit and the filters are applied while paginating
+	froms = nil
+	listings, _, pages, err = fetchSearchPages(context.Background(), srv.URL+"/property-for-rent/dublin", 2, SearchFilters{MinBedrooms: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages != 2 || len(listings) != 2 || listings[0].ID != "2" || listings[1].ID != "4" {
+		t.Errorf("pages = %d, listings = %+v", pages, listings)
+	}
+}
+
+func TestFetchSearchPagesStopsAtBudget(t *testing.T) {
+	t.Setenv("CRAWL_BUDGET_PAGES", "1")
+	t.Setenv("SCRAPE_DOMAINS", "127.0.0.1")
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		fmt.Fprint(w, `<script id="__NEXT_DATA__" type="application/json">{"props":{"pageProps":{"listings":[{"listing":{"id":1,"seoFriendlyPath":"/for-rent/x/1"}}],"paging":{"totalPages":5,"currentPage":1,"nextFrom":20}}}}</script>`)
+	}))
+	defer srv.Close()
+
+	listings, _, pages, err := fetchSearchPages(context.Background(), srv.URL+"/property-for-rent/dublin", 20, SearchFilters{})
+	if err != nil || pages != 1 || len(listings) != 1 || hits != 1 {
+		t.Errorf("pages = %d, listings = %d, hits = %d, err = %v", pages, len(listings), hits, err)
+	}
+
+	ctx := scraper.WithCrawlBudget(context.Background())
+	scraper.TakeCrawlPage(ctx)
+	if _, _, _, err := fetchSearchPages(ctx, srv.URL+"/property-for-rent/dublin", 20, SearchFilters{}); !errors.Is(err, scraper.ErrCrawlBudgetExhausted) {
+		t.Errorf("spent budget: err = %v", err)
+	}
+}
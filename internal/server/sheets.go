@@ -0,0 +1,220 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Exportação para Google Sheets (service account) ─────────────── */
+
+const sheetsScope = "https://www.googleapis.com/auth/spreadsheets"
+
+// serviceAccountKey é o subconjunto do JSON de chave da service account que usamos
+type serviceAccountKey struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// sheetsExporter acrescenta linhas numa planilha. Configurado por
+// GOOGLE_SHEETS_CREDENTIALS (caminho do JSON), GOOGLE_SHEETS_ID e GOOGLE_SHEETS_RANGE.
+type sheetsExporter struct {
+	SpreadsheetID string
+	Range         string
+	Key           serviceAccountKey
+	rsaKey        *rsa.PrivateKey
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+var (
+	sheetsOnce sync.Once
+	sheets     *sheetsExporter
+)
+
+// sheetsFromEnv devolve o exportador configurado, ou nil se a integração estiver desligada
+func sheetsFromEnv() *sheetsExporter {
+	sheetsOnce.Do(func() {
+		path, id := os.Getenv("GOOGLE_SHEETS_CREDENTIALS"), os.Getenv("GOOGLE_SHEETS_ID")
+		if path == "" || id == "" {
+			return
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			slog.Warn("Could not read the Google Sheets credentials", "error", err)
+			return
+		}
+		exporter, err := newSheetsExporter(raw, id, os.Getenv("GOOGLE_SHEETS_RANGE"))
+		if err != nil {
+			slog.Warn("Google Sheets export disabled", "error", err)
+			return
+		}
+		sheets = exporter
+	})
+	return sheets
+}
+
+func newSheetsExporter(credentials []byte, spreadsheetID, sheetRange string) (*sheetsExporter, error) {
+	var key serviceAccountKey
+	if err := json.Unmarshal(credentials, &key); err != nil {
+		return nil, fmt.Errorf("error decoding service account key: %w", err)
+	}
+	if key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, errors.New("service account key missing client_email or private_key")
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return nil, errors.New("invalid PEM in private_key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key: %w", err)
+	}
+	rsaKey, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("service account key is not RSA")
+	}
+
+	if sheetRange == "" {
+		sheetRange = "Sheet1!A1"
+	}
+	return &sheetsExporter{SpreadsheetID: spreadsheetID, Range: sheetRange, Key: key, rsaKey: rsaKey}, nil
+}
+
+// signedJWT monta a asserção RS256 trocada por um access token (RFC 7523)
+func (s *sheetsExporter) signedJWT(now time.Time) (string, error) {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   s.Key.ClientEmail,
+		"scope": sheetsScope,
+		"aud":   s.Key.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + enc.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.rsaKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+// accessToken devolve o token em cache ou pede um novo ao endpoint OAuth
+func (s *sheetsExporter) accessToken(client *http.Client) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Before(s.tokenExpiry) {
+		return s.token, nil
+	}
+
+	assertion, err := s.signedJWT(time.Now())
+	if err != nil {
+		return "", fmt.Errorf("error signing JWT: %w", err)
+	}
+	resp, err := client.PostForm(s.Key.TokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned status code: %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("error decoding token response: %w", err)
+	}
+	s.token = token.AccessToken
+	// renova um minuto antes de expirar
+	s.tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return s.token, nil
+}
+
+// AppendRow acrescenta uma linha ao fim da planilha
+func (s *sheetsExporter) AppendRow(row []string) error {
+	client := &http.Client{Timeout: 15 * time.Second}
+	token, err := s.accessToken(client)
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(row))
+	for i, v := range row {
+		values[i] = v
+	}
+	body, err := json.Marshal(map[string]interface{}{"values": [][]interface{}{values}})
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("https://sheets.googleapis.com/v4/spreadsheets/%s/values/%s:append?valueInputOption=USER_ENTERED&insertDataOption=INSERT_ROWS",
+		url.PathEscape(s.SpreadsheetID), url.PathEscape(s.Range))
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("sheets API returned status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// exportAnalysis acrescenta a análise concluída à planilha em segundo plano, com as
+// mesmas colunas do CSV do lote precedidas da data
+func exportAnalysis(ctx context.Context, property *scraper.PropertyInfo) {
+	exporter := sheetsFromEnv()
+	if exporter == nil || property.Address == "" {
+		return
+	}
+	listingURL := property.URL
+	row := append([]string{time.Now().Format("2006-01-02 15:04")}, batchCSVRow(analyzerFor(ctx), BatchResult{URL: listingURL, Property: property})...)
+	go func() {
+		if err := exporter.AppendRow(row); err != nil {
+			slog.Warn("Google Sheets export failed", "url", listingURL, "error", err)
+		}
+	}()
+}
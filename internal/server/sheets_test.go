@@ -0,0 +1,66 @@
+package server
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSheetsExporter_SignedJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentials, _ := json.Marshal(map[string]string{
+		"client_email": "exporter@project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	})
+
+	exporter, err := newSheetsExporter(credentials, "sheet-id", "")
+	if err != nil {
+		t.Fatalf("newSheetsExporter: %v", err)
+	}
+	if exporter.Range != "Sheet1!A1" || exporter.Key.TokenURI != "https://oauth2.googleapis.com/token" {
+		t.Fatalf("unexpected defaults: %+v", exporter)
+	}
+
+	jwt, err := exporter.signedJWT(time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatalf("signedJWT: %v", err)
+	}
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 JWT parts, got %d", len(parts))
+	}
+
+	enc := base64.RawURLEncoding
+	sig, err := enc.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+		t.Fatalf("invalid signature: %v", err)
+	}
+
+	rawClaims, _ := enc.DecodeString(parts[1])
+	var claims map[string]interface{}
+	if err := json.Unmarshal(rawClaims, &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims["iss"] != "exporter@project.iam.gserviceaccount.com" || claims["scope"] != sheetsScope || claims["exp"].(float64) != 1700003600 {
+		t.Errorf("unexpected claims: %v", claims)
+	}
+}
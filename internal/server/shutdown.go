@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Desligamento gracioso ───────────────────────────────────────── */
+
+var (
+	// stopping é cancelado no início do desligamento: os jobs em segundo plano param
+	// de pegar trabalho novo, mas terminam o que já começaram
+	stopping, beginShutdown = context.WithCancel(context.Background())
+
+	// serverContext é a base dos contextos do processo (requisições, jobs em segundo
+	// plano, trabalho destacado da requisição); é cancelado quando o prazo do
+	// desligamento acaba, interrompendo as chamadas externas que ainda estiverem rodando
+	serverContext, stopServer = context.WithCancel(context.Background())
+
+	// backgroundJobs conta os jobs em segundo plano em andamento (schedulers, worker do
+	// prefetch, análises do bot do Telegram)
+	backgroundJobs sync.WaitGroup
+)
+
+// shutdownTimeout é quanto o desligamento espera as requisições e os jobs em andamento
+// (SHUTDOWN_TIMEOUT, padrão 30s); passado o prazo o processo sai mesmo assim
+func shutdownTimeout() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("SHUTDOWN_TIMEOUT")); err == nil && d > 0 {
+		return d
+	}
+	return 30 * time.Second
+}
+
+// goBackground roda fn numa goroutine contada em backgroundJobs
+func goBackground(fn func()) {
+	backgroundJobs.Add(1)
+	go func() {
+		defer backgroundJobs.Done()
+		fn()
+	}()
+}
+
+// sleepUnlessStopping espera d e diz se o serviço continua no ar; volta na hora
+// (com false) quando o desligamento começa
+func sleepUnlessStopping(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-stopping.Done():
+		return false
+	}
+}
+
+// serve atende em srv até SIGINT, SIGTERM ou o fim de ctx e então desliga em ordem:
+// para de aceitar conexões e espera as requisições em andamento, avisa os jobs e
+// espera os que estão rodando, grava a fila do prefetch e envia os últimos spans.
+// Tudo dentro de SHUTDOWN_TIMEOUT.
+func serve(ctx context.Context, srv *http.Server) error {
+	signals, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			serveErr <- srv.ListenAndServeTLS("", "") // certificados já em srv.TLSConfig (ver configureTLS)
+			return
+		}
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-signals.Done():
+	}
+	stop() // um segundo sinal derruba o processo na hora
+
+	timeout := shutdownTimeout()
+	slog.Info("Shutting down, draining in-flight work", "timeout", timeout)
+	deadline, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	beginShutdown()
+	if err := srv.Shutdown(deadline); err != nil {
+		slog.Warn("Requests still running at the shutdown deadline", "error", err)
+	}
+
+	jobsDone := make(chan struct{})
+	go func() {
+		backgroundJobs.Wait()
+		close(jobsDone)
+	}()
+	select {
+	case <-jobsDone:
+	case <-deadline.Done():
+		slog.Warn("Background jobs still running at the shutdown deadline")
+	}
+	stopServer()
+
+	if err := persistPrefetchQueue(); err != nil {
+		slog.Warn("Could not save the prefetch queue", "error", err)
+	}
+	if telemetry.Tracer != nil {
+		telemetry.Tracer.Flush()
+	}
+	slog.Info("Server stopped")
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// detachedContext é um contexto novo, fora do cancelamento de ctx (mas não do de
+// serverContext), que mantém o logger, o span, o tenant, o Analyzer, o orçamento de
+// páginas e o modo debug de ctx (trabalho que sobrevive à requisição que o começou)
+func detachedContext(ctx context.Context) context.Context {
+	detached := telemetry.WithLogger(serverContext, telemetry.LogFor(ctx))
+	if a := enrich.AnalyzerFrom(ctx); a != nil {
+		detached = enrich.WithAnalyzer(detached, a)
+	}
+	if t := tenantFrom(ctx); t != nil {
+		detached = withTenant(detached, t)
+	}
+	if s := telemetry.SpanFrom(ctx); s != nil {
+		detached = telemetry.WithSpan(detached, s)
+	}
+	if scraper.DebugEnabled(ctx) {
+		detached = scraper.WithDebug(detached)
+	}
+	return scraper.ShareCrawlBudget(detached, ctx)
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+// freshShutdown gives the test its own shutdown signal, so that shutting down here
+// does not stop the schedulers of other tests
+func freshShutdown(t *testing.T) {
+	t.Helper()
+	prevStopping, prevBegin := stopping, beginShutdown
+	prevServer, prevStop := serverContext, stopServer
+	stopping, beginShutdown = context.WithCancel(context.Background())
+	serverContext, stopServer = context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		beginShutdown()
+		stopServer()
+		stopping, beginShutdown = prevStopping, prevBegin
+		serverContext, stopServer = prevServer, prevStop
+	})
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestServeDrainsBeforeExit(t *testing.T) {
+	freshShutdown(t)
+	store = newMemoryStore()
+	t.Cleanup(func() { store = newMemoryStore() })
+	t.Setenv("SHUTDOWN_TIMEOUT", "5s")
+
+	started := make(chan struct{})
+	addr := freePort(t)
+	srv := &http.Server{Addr: addr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond) // an analysis still running at SIGTERM
+		io.WriteString(w, "done")
+	})}
+
+	// a background job finishes the item it is on once told to stop, with its
+	// context still alive
+	var jobFinished int32
+	goBackground(func() {
+		<-stopping.Done()
+		time.Sleep(100 * time.Millisecond)
+		if serverContext.Err() == nil {
+			atomic.StoreInt32(&jobFinished, 1)
+		}
+	})
+	prefetchQueue <- "https://www.daft.ie/for-rent/apartment-1-main-street/123"
+
+	ctx, sigterm := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, srv) }()
+
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		if resp, err = http.Get("http://" + addr); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	<-started
+	sigterm()
+
+	if err := <-served; err != nil {
+		t.Fatalf("serve returned %v", err)
+	}
+	if resp == nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "done" {
+		t.Errorf("in-flight request got %q, want it to finish", body)
+	}
+	if atomic.LoadInt32(&jobFinished) != 1 {
+		t.Error("serve returned before the background job finished")
+	}
+
+	var saved []string
+	store.View(func(d *storeData) { saved = d.PrefetchQueue })
+	if len(saved) != 1 || len(prefetchQueue) != 0 {
+		t.Errorf("saved prefetch queue = %q (%d left in memory)", saved, len(prefetchQueue))
+	}
+
+	// the next process picks the queue up again
+	restorePrefetchQueue()
+	store.View(func(d *storeData) { saved = d.PrefetchQueue })
+	if len(prefetchQueue) != 1 || len(saved) != 0 {
+		t.Errorf("restored %d listings, %d still saved", len(prefetchQueue), len(saved))
+	}
+	persistPrefetchQueue()
+}
+
+func TestServeCancelsJobsPastTheDeadline(t *testing.T) {
+	freshShutdown(t)
+	store = newMemoryStore()
+	t.Cleanup(func() { store = newMemoryStore() })
+	t.Setenv("SHUTDOWN_TIMEOUT", "50ms")
+
+	// a job stuck in an external call only returns when its context is cancelled
+	cancelled := make(chan struct{})
+	jobCtx := serverContext
+	goBackground(func() {
+		<-jobCtx.Done()
+		close(cancelled)
+	})
+
+	ctx, sigterm := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, &http.Server{Addr: freePort(t)}) }()
+	time.Sleep(20 * time.Millisecond)
+	sigterm()
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the job's context was not cancelled at the shutdown deadline")
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("serve returned %v", err)
+	}
+}
+
+func TestSleepUnlessStopping(t *testing.T) {
+	freshShutdown(t)
+	if !sleepUnlessStopping(time.Millisecond) {
+		t.Error("should keep running before shutdown")
+	}
+	time.AfterFunc(20*time.Millisecond, beginShutdown)
+	start := time.Now()
+	if sleepUnlessStopping(time.Hour) || time.Since(start) > 5*time.Second {
+		t.Error("shutdown should interrupt the sleep")
+	}
+}
+
+func TestCrawlBudget(t *testing.T) {
+	t.Setenv("CRAWL_BUDGET_PAGES", "2")
+	ctx := scraper.WithCrawlBudget(context.Background())
+	// a nested run (one listing of a batch) keeps the batch budget
+	inner := scraper.WithCrawlBudget(detachedContext(ctx))
+	if !scraper.TakeCrawlPage(ctx) || !scraper.TakeCrawlPage(inner) || scraper.TakeCrawlPage(ctx) {
+		t.Error("the budget should allow exactly 2 pages across the run")
+	}
+	if !scraper.TakeCrawlPage(context.Background()) {
+		t.Error("no budget in the context means no limit")
+	}
+}
+
+func TestDetachedContextKeepsDebug(t *testing.T) {
+	t.Setenv("DEBUG", "false")
+	ctx, cancel := context.WithCancel(scraper.WithDebug(context.Background()))
+	cancel()
+	if !scraper.DebugEnabled(detachedContext(ctx)) {
+		t.Error("detachedContext dropped the debug flag")
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Uma análise por anúncio em andamento (singleflight) ─────────── */
+
+// analysisFlight é uma análise em andamento e quem está esperando por ela
+type analysisFlight struct {
+	done    chan struct{}
+	result  listingAnalysis
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// flightGroup junta pedidos iguais que chegam ao mesmo tempo (um plugin compartilhado
+// abrindo um anúncio popular) numa análise só. Seguro para uso concorrente.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*analysisFlight
+}
+
+var analysisFlights = &flightGroup{flights: map[string]*analysisFlight{}}
+
+// do roda fn uma vez para todos os pedidos simultâneos com a mesma chave. A análise
+// roda num contexto próprio: um cliente que desconecta só para de esperar, e ela só é
+// cancelada quando não sobra ninguém esperando. Cada pedido recebe a sua cópia da
+// análise (PropertyInfo.Clone). As chamadas ao Maps ficam na conta do primeiro
+// pedido; quem pegou carona recebe a análise sem o contador.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (listingAnalysis, error)) (listingAnalysis, error) {
+	g.mu.Lock()
+	f, joined := g.flights[key]
+	if !joined {
+		// os logs e spans da análise ficam com a requisição de quem a começou
+		flightCtx, cancel := context.WithCancel(detachedContext(ctx))
+		f = &analysisFlight{done: make(chan struct{}), cancel: cancel}
+		g.flights[key] = f
+		go g.run(flightCtx, key, f, fn)
+	}
+	f.waiters++
+	g.mu.Unlock()
+
+	if joined {
+		telemetry.LogFor(ctx).Info("Joining the analysis already in progress", "key", key)
+	}
+
+	select {
+	case <-f.done:
+		result := f.result
+		result.Property = f.result.Property.Clone()
+		if joined {
+			result.Property.MapsUsage = nil
+		}
+		return result, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			if g.flights[key] == f {
+				delete(g.flights, key)
+			}
+		}
+		g.mu.Unlock()
+		return listingAnalysis{}, ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, f *analysisFlight, fn func(ctx context.Context) (listingAnalysis, error)) {
+	defer func() {
+		// fora do handler o net/http não recupera o panic; vira erro para quem espera
+		if r := recover(); r != nil {
+			telemetry.LogFor(ctx).Error("Analysis panicked", "key", key, "panic", r, "stack", string(debug.Stack()))
+			f.err = fmt.Errorf("internal error analyzing the listing")
+		}
+		g.mu.Lock()
+		if g.flights[key] == f {
+			delete(g.flights, key)
+		}
+		g.mu.Unlock()
+		f.cancel()
+		close(f.done)
+	}()
+	f.result, f.err = fn(ctx)
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/safety"
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestFlightGroupCoalesces(t *testing.T) {
+	g := &flightGroup{flights: map[string]*analysisFlight{}}
+	var runs int32
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (listingAnalysis, error) {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		var p scraper.PropertyInfo
+		p.Usage().Calls = 4
+		p.Address = "1 Main Street"
+		return listingAnalysis{Property: p}, nil
+	}
+
+	const clients = 5
+	results := make([]listingAnalysis, clients)
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, err := g.do(context.Background(), "listing", fn)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = result
+		}(i)
+	}
+	// let every client join before the analysis ends
+	for {
+		g.mu.Lock()
+		f := g.flights["listing"]
+		joined := f != nil && f.waiters == clients
+		g.mu.Unlock()
+		if joined {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if runs != 1 {
+		t.Errorf("the analysis ran %d times, want 1", runs)
+	}
+	charged := 0
+	for _, r := range results {
+		if r.Property.Address != "1 Main Street" {
+			t.Errorf("unexpected result %+v", r.Property)
+		}
+		charged += r.Property.MapsCalls()
+	}
+	// the Maps calls are charged once, to the client that started the analysis
+	if charged != 4 {
+		t.Errorf("Maps calls charged %d times over, want 4 in total", charged)
+	}
+
+	// once finished, the next request starts a new analysis
+	release = make(chan struct{})
+	close(release)
+	g.do(context.Background(), "listing", fn)
+	if runs != 2 {
+		t.Errorf("runs = %d, want a new analysis after the first ended", runs)
+	}
+}
+
+func TestFlightGroupCancelsWhenNobodyWaits(t *testing.T) {
+	g := &flightGroup{flights: map[string]*analysisFlight{}}
+	cancelled := make(chan struct{})
+	fn := func(ctx context.Context) (listingAnalysis, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return listingAnalysis{}, ctx.Err()
+	}
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, ctx := range []context.Context{first, second} {
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			g.do(ctx, "listing", fn)
+		}(ctx)
+	}
+	for {
+		g.mu.Lock()
+		f := g.flights["listing"]
+		ready := f != nil && f.waiters == 2
+		g.mu.Unlock()
+		if ready {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// one client leaving does not stop the analysis for the other
+	cancelFirst()
+	select {
+	case <-cancelled:
+		t.Fatal("the analysis was cancelled while a client was still waiting")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancelSecond()
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the analysis kept running with nobody waiting")
+	}
+	wg.Wait()
+}
+
+func TestFlightGroupRecoversPanic(t *testing.T) {
+	g := &flightGroup{flights: map[string]*analysisFlight{}}
+	_, err := g.do(context.Background(), "listing", func(ctx context.Context) (listingAnalysis, error) {
+		panic("boom")
+	})
+	if err == nil {
+		t.Error("a panicking analysis should return an error")
+	}
+}
+
+func TestFlightGroupGivesEachWaiterItsOwnCopy(t *testing.T) {
+	g := &flightGroup{flights: map[string]*analysisFlight{}}
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (listingAnalysis, error) {
+		<-release
+		p := scraper.PropertyInfo{Address: "1 Main Street", Price: &scraper.Price{Monthly: 2000}, Warnings: make([]*scraper.APIError, 0, 4)}
+		p.QualityOfLife.PublicTransport = []scraper.POI{{Name: "Luas"}}
+		return listingAnalysis{Property: p}, nil
+	}
+
+	results := make([]listingAnalysis, 2)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = g.do(context.Background(), "listing", fn)
+		}(i)
+	}
+	for {
+		g.mu.Lock()
+		f := g.flights["listing"]
+		joined := f != nil && f.waiters == 2
+		g.mu.Unlock()
+		if joined {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	// what one handler adds to its analysis must not show up in the other's
+	a, b := &results[0].Property, &results[1].Property
+	a.Warnings = append(a.Warnings, &scraper.APIError{Code: "GEOCODE_FAILED"})
+	a.Price.Monthly = 1
+	a.QualityOfLife.PublicTransport[0].Name = "Bus"
+	b.Warnings = append(b.Warnings, &scraper.APIError{Code: "OTHER"})
+	if a.Warnings[0].Code != "GEOCODE_FAILED" || b.Price.Monthly != 2000 || b.QualityOfLife.PublicTransport[0].Name != "Luas" {
+		t.Errorf("the waiters share the analysis: %+v / %+v", a, b)
+	}
+}
+
+func TestPropertyInfoClone(t *testing.T) {
+	p := scraper.PropertyInfo{Address: "1 Main Street", Changes: []scraper.ListingChange{{Field: "price"}}}
+	p.Usage().Calls = 3
+	km := 0.5
+	p.SafetyInfo.ScoreInputs = &safety.SafetyScoreInputs{Base: 70, NearestGardaKm: &km}
+
+	c := p.Clone()
+	c.Changes[0].Field = "bedrooms"
+	c.SafetyInfo.ScoreInputs.Base = 0
+	*c.SafetyInfo.ScoreInputs.NearestGardaKm = 9
+	if p.Changes[0].Field != "price" || p.SafetyInfo.ScoreInputs.Base != 70 || km != 0.5 {
+		t.Errorf("the clone shares state with the original: %+v", p)
+	}
+	if c.Address != p.Address || c.MapsCalls() != 3 {
+		t.Errorf("clone = %+v, want the same values and Maps counter", c)
+	}
+}
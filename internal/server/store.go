@@ -0,0 +1,260 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"daft-scraper-api/internal/env"
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Persistência simples em JSON ────────────────────────────────── */
+
+// storeData é tudo o que sobrevive a um restart do serviço
+type storeData struct {
+	Watches            map[string]*Watch          `json:"watches"`
+	PrivateComparables []PrivateComparable        `json:"privateComparables"`
+	SavedSearches      map[string]*SavedSearch    `json:"savedSearches"`
+	Analyses           map[string]*StoredAnalysis `json:"analyses"`
+	Annotations        []scraper.Annotation       `json:"annotations"`
+
+	// Índice de fotos: hash → ocorrências, e faixa do hash → hashes (busca por similaridade)
+	PhotoHashes map[string][]PhotoSighting `json:"photoHashes"`
+	PhotoBands  map[string][]string        `json:"photoBands"`
+
+	// Vetores das descrições para a busca por similaridade, por id da análise
+	Embeddings map[string]*StoredEmbedding `json:"embeddings"`
+
+	// URLs que estavam na fila do prefetch no último desligamento
+	PrefetchQueue []string `json:"prefetchQueue,omitempty"`
+
+	// Fila de jobs de versões anteriores, por id; passa para o SQLite na partida (ver
+	// migrateStoreJobs) e não é mais gravada
+	Jobs map[string]*Job `json:"jobs,omitempty"`
+
+	// Quando cada anúncio foi visto, pelo id da URL (tempo no mercado e re-anúncios)
+	ListingSightings map[string]*ListingSighting `json:"listingSightings"`
+}
+
+// Store guarda storeData em memória e o regrava inteiro no backend a cada alteração.
+// O volume esperado (algumas centenas de registros) não justifica um banco de dados.
+// Seguro para uso concorrente: todo acesso passa por View/Update, que seguram mu.
+type Store struct {
+	mu      sync.Mutex
+	backend storeBackend // nil num Store só em memória
+	version string       // versão lida do backend, para as gravações condicionais
+	data    storeData
+	saved   []byte // JSON da última leitura ou gravação, para desfazer um Update que falhou
+}
+
+// store é a instância usada pelos handlers; aberta em setup()
+var store = newMemoryStore()
+
+// newMemoryStore cria um Store sem backend (usado antes de setup() e nos testes)
+func newMemoryStore() *Store {
+	s := &Store{}
+	s.data.init()
+	return s
+}
+
+func (d *storeData) init() {
+	if d.Watches == nil {
+		d.Watches = make(map[string]*Watch)
+	}
+	if d.SavedSearches == nil {
+		d.SavedSearches = make(map[string]*SavedSearch)
+	}
+	if d.Analyses == nil {
+		d.Analyses = make(map[string]*StoredAnalysis)
+	}
+	if d.PhotoHashes == nil {
+		d.PhotoHashes = make(map[string][]PhotoSighting)
+	}
+	if d.Embeddings == nil {
+		d.Embeddings = make(map[string]*StoredEmbedding)
+	}
+	if d.PhotoBands == nil {
+		d.PhotoBands = make(map[string][]string)
+	}
+	if d.ListingSightings == nil {
+		d.ListingSightings = make(map[string]*ListingSighting)
+	}
+}
+
+/* ───── Backends do store ───────────────────────────────────────────── */
+
+// storeBackend guarda o JSON do storeData: num arquivo em DATA_DIR (padrão) ou num
+// objeto do S3 (STORE_S3_BUCKET), para quem roda sem disco persistente, como no Lambda
+type storeBackend interface {
+	// load lê o JSON e a versão dele; raw nil sem nada gravado ainda. Se a versão
+	// guardada ainda for known, pode devolver raw nil e a mesma versão.
+	load(ctx context.Context, known string) (raw []byte, version string, err error)
+	// save grava raw se a versão guardada ainda for version; senão errStoreConflict
+	save(ctx context.Context, raw []byte, version string) (newVersion string, err error)
+}
+
+// errStoreConflict indica que outra instância gravou o store depois da nossa leitura
+var errStoreConflict = errors.New("store was changed by another instance")
+
+// storeTimeout limita cada leitura ou gravação no backend
+const storeTimeout = 30 * time.Second
+
+// fileBackend é o store.json em disco. Só um processo usa o arquivo, então não há
+// versão nem conflito.
+type fileBackend struct {
+	path string
+}
+
+func (f fileBackend) load(ctx context.Context, known string) ([]byte, string, error) {
+	raw, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, "", nil
+	}
+	return raw, "", err
+}
+
+// save grava em arquivo temporário e renomeia, para nunca deixar um JSON pela metade
+func (f fileBackend) save(ctx context.Context, raw []byte, version string) (string, error) {
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return "", err
+	}
+	return "", os.Rename(tmp, f.path)
+}
+
+// openStore carrega o estado de DATA_DIR/store.json (criando o diretório se necessário)
+func openStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating data dir: %w", err)
+	}
+	return openStoreWith(fileBackend{path: filepath.Join(dir, "store.json")})
+}
+
+// openStoreFromEnv abre o store no S3 quando STORE_S3_BUCKET está definido, senão
+// em DATA_DIR (padrão data)
+func openStoreFromEnv() (*Store, error) {
+	if backend, ok := s3BackendFromEnv(); ok {
+		return openStoreWith(backend)
+	}
+	return openStore(env.Or("DATA_DIR", "data"))
+}
+
+func openStoreWith(backend storeBackend) (*Store, error) {
+	s := &Store{backend: backend}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reload relê o backend; chamado com mu seguro ou antes de o Store ser compartilhado
+func (s *Store) reload() error {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	raw, version, err := s.backend.load(ctx, s.version)
+	if err != nil {
+		return fmt.Errorf("error reading store: %w", err)
+	}
+	if raw == nil && version != "" && version == s.version {
+		return nil // nada mudou desde a última leitura
+	}
+	var data storeData
+	if raw != nil {
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return fmt.Errorf("error decoding store: %w", err)
+		}
+	}
+	data.init()
+	s.data, s.version, s.saved = data, version, raw
+	return nil
+}
+
+// Refresh traz as gravações feitas por outras instâncias (no Lambda cada instância
+// tem a sua cópia em memória); barato quando nada mudou
+func (s *Store) Refresh() error {
+	if s.backend == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reload()
+}
+
+// View executa fn com acesso somente-leitura aos dados
+func (s *Store) View(fn func(d *storeData)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.data)
+}
+
+// Update executa fn e persiste o resultado. Se outra instância gravou antes, relê
+// o store e reaplica fn sobre os dados novos, para não apagar a gravação dela. Se fn
+// ou a gravação falham, os dados voltam ao que está persistido: a memória nunca fica
+// com uma alteração que o backend não tem.
+func (s *Store) Update(fn func(d *storeData) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for attempt := 1; ; attempt++ {
+		err := fn(&s.data)
+		if err == nil {
+			err = s.save()
+		}
+		if errors.Is(err, errStoreConflict) && attempt < 3 {
+			err = s.reload()
+			if err == nil {
+				continue
+			}
+		}
+		if err != nil {
+			s.rollback()
+		}
+		return err
+	}
+}
+
+// rollback descarta as alterações feitas desde a última leitura ou gravação
+func (s *Store) rollback() {
+	var data storeData
+	if s.saved != nil {
+		json.Unmarshal(s.saved, &data) // já decodificado por reload ou gerado por save
+	}
+	data.init()
+	s.data = data
+}
+
+func (s *Store) save() error {
+	raw, err := json.MarshalIndent(&s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding store: %w", err)
+	}
+	if s.backend == nil {
+		s.saved = raw
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	version, err := s.backend.save(ctx, raw, s.version)
+	if errors.Is(err, errStoreConflict) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("error writing store: %w", err)
+	}
+	s.version, s.saved = version, raw
+	return nil
+}
+
+// newID gera um identificador aleatório curto para registros persistidos
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
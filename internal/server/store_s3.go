@@ -0,0 +1,222 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/env"
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Store no S3 ─────────────────────────────────────────────────── */
+
+// s3Backend guarda o store.json num objeto do S3 (ou de um serviço compatível, como
+// o MinIO, com STORE_S3_ENDPOINT). As gravações são condicionais ao ETag lido
+// (If-Match), então duas instâncias do Lambda não apagam a gravação uma da outra.
+// Assina as requisições com SigV4, usando as credenciais do ambiente que o Lambda
+// já define (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN).
+type s3Backend struct {
+	client   *http.Client
+	endpoint string // https://bucket.s3.region.amazonaws.com ou STORE_S3_ENDPOINT/bucket
+	key      string
+	region   string
+	creds    awsCredentials
+	now      func() time.Time
+}
+
+type awsCredentials struct {
+	AccessKeyID, SecretAccessKey, SessionToken string
+}
+
+// s3BackendFromEnv lê STORE_S3_BUCKET, STORE_S3_KEY (padrão store.json),
+// STORE_S3_ENDPOINT e AWS_REGION
+func s3BackendFromEnv() (*s3Backend, bool) {
+	bucket := os.Getenv("STORE_S3_BUCKET")
+	if bucket == "" {
+		return nil, false
+	}
+	region := env.Or("AWS_REGION", "us-east-1")
+	endpoint := fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+	if custom := os.Getenv("STORE_S3_ENDPOINT"); custom != "" {
+		endpoint = strings.TrimSuffix(custom, "/") + "/" + bucket
+	}
+	return &s3Backend{
+		client:   &http.Client{Transport: scraper.TracingTransport{Base: http.DefaultTransport}},
+		endpoint: endpoint,
+		key:      env.Or("STORE_S3_KEY", "store.json"),
+		region:   region,
+		creds: awsCredentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		now: time.Now,
+	}, true
+}
+
+func (b *s3Backend) objectURL() string {
+	var parts []string
+	for _, segment := range strings.Split(b.key, "/") {
+		parts = append(parts, awsEscape(segment))
+	}
+	return b.endpoint + "/" + strings.Join(parts, "/")
+}
+
+func (b *s3Backend) do(ctx context.Context, method string, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, b.objectURL(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	signV4(req, body, b.region, "s3", b.creds, b.now())
+	return b.client.Do(req)
+}
+
+func (b *s3Backend) load(ctx context.Context, known string) ([]byte, string, error) {
+	header := http.Header{}
+	if known != "" {
+		header.Set("If-None-Match", known)
+	}
+	resp, err := b.do(ctx, http.MethodGet, nil, header)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, known, nil
+	case http.StatusNotFound:
+		return nil, "", nil
+	case http.StatusOK:
+		raw, err := io.ReadAll(resp.Body)
+		return raw, resp.Header.Get("ETag"), err
+	}
+	return nil, "", s3Error(resp)
+}
+
+func (b *s3Backend) save(ctx context.Context, raw []byte, version string) (string, error) {
+	header := http.Header{"Content-Type": {"application/json"}}
+	if version != "" {
+		header.Set("If-Match", version)
+	} else {
+		header.Set("If-None-Match", "*") // ninguém criou o objeto desde a nossa leitura
+	}
+	resp, err := b.do(ctx, http.MethodPut, raw, header)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Header.Get("ETag"), nil
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		return "", errStoreConflict
+	}
+	return "", s3Error(resp)
+}
+
+func s3Error(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("s3 returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+/* ───── Assinatura SigV4 ────────────────────────────────────────────── */
+
+// signV4 assina req (AWS Signature Version 4) com o corpo body. Assina o Host e
+// todos os cabeçalhos já definidos em req.
+func signV4(req *http.Request, body []byte, region, service string, creds awsCredentials, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), day)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for name, values := range query {
+		for _, v := range values {
+			pairs = append(pairs, awsEscape(name)+"="+awsEscape(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape codifica tudo menos os caracteres não reservados (A-Z a-z 0-9 - _ . ~),
+// como o SigV4 exige
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
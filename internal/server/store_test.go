@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	s, err := openStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Update(func(d *storeData) error {
+		d.Watches["w1"] = &Watch{ID: "w1"}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "store.json.tmp")); !os.IsNotExist(err) {
+		t.Error("the temporary file should be renamed over store.json")
+	}
+
+	reopened, err := openStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.View(func(d *storeData) {
+		if d.Watches["w1"] == nil || d.SavedSearches == nil {
+			t.Errorf("reopened store = %+v", d)
+		}
+	})
+
+	os.WriteFile(filepath.Join(dir, "store.json"), []byte("{"), 0o644)
+	if _, err := openStore(dir); err == nil {
+		t.Error("a corrupt store.json should be an error")
+	}
+}
+
+// readOnlyBackend loads fine but refuses every write
+type readOnlyBackend struct{}
+
+func (readOnlyBackend) load(ctx context.Context, known string) ([]byte, string, error) {
+	return []byte(`{"watches": {"w1": {"id": "w1"}}}`), "v1", nil
+}
+
+func (readOnlyBackend) save(ctx context.Context, raw []byte, version string) (string, error) {
+	return "", errors.New("disk full")
+}
+
+func TestUpdateRollsBackOnFailure(t *testing.T) {
+	mem := newMemoryStore()
+	mem.Update(func(d *storeData) error {
+		d.Watches["w1"] = &Watch{ID: "w1"}
+		return nil
+	})
+	failed := errors.New("boom")
+	if err := mem.Update(func(d *storeData) error {
+		d.Watches["w1"].Removed = true
+		d.Watches["w2"] = &Watch{ID: "w2"}
+		return failed
+	}); !errors.Is(err, failed) {
+		t.Fatalf("expected the fn error, got %v", err)
+	}
+	mem.View(func(d *storeData) {
+		if len(d.Watches) != 1 || d.Watches["w1"] == nil || d.Watches["w1"].Removed {
+			t.Errorf("a failed fn should leave the store untouched, got %+v", d.Watches)
+		}
+	})
+
+	s, err := openStoreWith(readOnlyBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Update(func(d *storeData) error {
+		d.Watches["w2"] = &Watch{ID: "w2"}
+		return nil
+	}); err == nil {
+		t.Fatal("expected the save error")
+	}
+	s.View(func(d *storeData) {
+		if len(d.Watches) != 1 || d.Watches["w1"] == nil {
+			t.Errorf("an unsaved change should be dropped, got %+v", d.Watches)
+		}
+	})
+}
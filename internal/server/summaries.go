@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Resumo da análise ───────────────────────────────────────────── */
+
+// summaryRequest é o corpo de POST /summary
+type summaryRequest struct {
+	DaftURL string `json:"daftUrl"`
+}
+
+// handleSummary é o handler HTTP para a rota de resumo
+func handleSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+
+	var requestBody summaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if requestBody.DaftURL == "" {
+		writeError(w, http.StatusBadRequest, "daftUrl is required in the request body")
+		return
+	}
+
+	telemetry.LogFor(r.Context()).Info("Summary requested", "url", requestBody.DaftURL)
+
+	property, err := analyzeListing(r.Context(), requestBody.DaftURL)
+	if err != nil {
+		writeScrapeError(w, err)
+		return
+	}
+
+	chargeMapsCalls(w, r, property.MapsCalls())
+
+	summary := analyzerFor(r.Context()).SummarizeProperty(&property)
+	summary.Briefing = briefingText(analyzerFor(r.Context()), &property)
+	writeJSONFields(w, r, summary)
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Bot do Telegram (long polling) ──────────────────────────────── */
+
+var daftURLPattern = regexp.MustCompile(`https?://(?:www\.)?daft\.ie/\S+`)
+
+// telegramUpdate é o subconjunto de um Update da Bot API que usamos
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+// runTelegramBot consulta getUpdates em loop e responde a cada link do Daft.ie
+// com um resumo da análise. Ativado com TELEGRAM_BOT_ENABLED=true.
+func runTelegramBot() {
+	token := os.Getenv("TELEGRAM_BOT_TOKEN")
+	if token == "" {
+		slog.Warn("TELEGRAM_BOT_ENABLED set but TELEGRAM_BOT_TOKEN is empty; bot disabled")
+		return
+	}
+	slog.Info("Telegram bot started")
+
+	// análises são lentas; limita quantas rodam ao mesmo tempo
+	sem := make(chan struct{}, 2)
+	client := &http.Client{Timeout: 60 * time.Second}
+	var offset int64
+
+	for stopping.Err() == nil {
+		updates, err := telegramGetUpdates(stopping, client, token, offset)
+		if err != nil {
+			if stopping.Err() == nil {
+				slog.Warn("Telegram getUpdates failed", "error", err)
+			}
+			sleepUnlessStopping(5 * time.Second)
+			continue
+		}
+
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message == nil {
+				continue
+			}
+			chatID := strconv.FormatInt(u.Message.Chat.ID, 10)
+			listingURL := daftURLPattern.FindString(u.Message.Text)
+			if listingURL == "" {
+				sendTelegramHTML(chatID, "Send me a Daft.ie listing link and I'll analyse it.")
+				continue
+			}
+
+			sendTelegramHTML(chatID, "Analysing "+html.EscapeString(listingURL)+" …")
+			goBackground(func() {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				property, err := analyzeListing(serverContext, listingURL)
+				if err != nil {
+					sendTelegramHTML(chatID, "Sorry, I couldn't analyse that listing: "+html.EscapeString(err.Error()))
+					return
+				}
+				if err := sendTelegramHTML(chatID, telegramSummaryCard(analyzerFor(serverContext), &property)); err != nil {
+					slog.Warn("Could not send the Telegram summary", "error", err)
+				}
+			})
+		}
+	}
+}
+
+// telegramGetUpdates faz o long polling; ctx interrompe a espera no desligamento
+func telegramGetUpdates(ctx context.Context, client *http.Client, token string, offset int64) ([]telegramUpdate, error) {
+	q := url.Values{
+		"offset":          {strconv.FormatInt(offset, 10)},
+		"timeout":         {"50"},
+		"allowed_updates": {`["message"]`},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.telegram.org/bot"+token+"/getUpdates?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK     bool             `json:"ok"`
+		Result []telegramUpdate `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error decoding telegram response: %w", err)
+	}
+	if !result.OK {
+		return nil, fmt.Errorf("telegram API returned status code: %d", resp.StatusCode)
+	}
+	return result.Result, nil
+}
+
+// telegramSummaryCard formata o resumo da análise em HTML do Telegram
+func telegramSummaryCard(a *enrich.Analyzer, p *scraper.PropertyInfo) string {
+	var b strings.Builder
+	esc := html.EscapeString
+
+	fmt.Fprintf(&b, "<b>%s</b>\n%s", esc(p.Address), esc(p.RentPrice))
+	if p.Bedrooms != "" || p.Bathrooms != "" {
+		fmt.Fprintf(&b, " · %s %s", esc(p.Bedrooms), esc(p.Bathrooms))
+	}
+	b.WriteString("\n\n")
+
+	fmt.Fprintf(&b, "⭐ Overall: <b>%d/100</b>\n", a.OverallScore(p))
+	fmt.Fprintf(&b, "🛡 Safety: %d/10\n", p.SafetyInfo.SafetyRating)
+	fmt.Fprintf(&b, "🚆 Transport: %d/10\n", p.QualityOfLife.TransportScore)
+	fmt.Fprintf(&b, "🚶 Walk score: %d/100\n", p.QualityOfLife.WalkScore)
+	if p.ValueAnalysis.PriceRating > 0 {
+		fmt.Fprintf(&b, "💶 Value: %d/10 (area avg €%.0f)\n", p.ValueAnalysis.PriceRating, p.ValueAnalysis.AreaAveragePrice)
+	}
+
+	for _, group := range []struct {
+		title string
+		pois  []scraper.POI
+	}{
+		{"Transport", p.QualityOfLife.PublicTransport},
+		{"Amenities", p.QualityOfLife.Amenities},
+	} {
+		top := scraper.NearestPOIs(group.pois, 3)
+		if len(top) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n<b>%s</b>\n", group.title)
+		for _, poi := range top {
+			fmt.Fprintf(&b, "• %s — %.1f km (%d min)\n", esc(poi.Name), poi.Distance, poi.Duration)
+		}
+	}
+
+	if len(p.PhotoDuplicates) > 0 {
+		b.WriteString("\n⚠️ Photos also appear on other listings\n")
+	}
+	fmt.Fprintf(&b, "\n%s", esc(p.URL))
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestDaftURLPattern(t *testing.T) {
+	cases := map[string]string{
+		"look at https://www.daft.ie/for-rent/apartment-1-main-street/123 please": "https://www.daft.ie/for-rent/apartment-1-main-street/123",
+		"http://daft.ie/share/room/456":                                           "http://daft.ie/share/room/456",
+		"https://www.myhome.ie/rentals/789":                                       "",
+		"hello":                                                                   "",
+	}
+	for text, want := range cases {
+		if got := daftURLPattern.FindString(text); got != want {
+			t.Errorf("FindString(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestTelegramSummaryCard(t *testing.T) {
+	p := &scraper.PropertyInfo{Address: "1 Main St <Block A>", RentPrice: "€2,000", Bedrooms: "2 bed", URL: "https://www.daft.ie/for-rent/x/1"}
+	p.QualityOfLife.PublicTransport = []scraper.POI{
+		{Name: "Far", Distance: 1.2, Duration: 15},
+		{Name: "Luas & Bus", Distance: 0.2, Duration: 3},
+		{Name: "Mid", Distance: 0.5, Duration: 6},
+		{Name: "Further", Distance: 2, Duration: 25},
+	}
+	p.PhotoDuplicates = []scraper.PhotoMatch{{}}
+
+	card := telegramSummaryCard((&enrich.Analyzer{}), p)
+	for _, want := range []string{
+		"<b>1 Main St &lt;Block A&gt;</b>",
+		"• Luas &amp; Bus — 0.2 km (3 min)",
+		"Photos also appear on other listings",
+		"https://www.daft.ie/for-rent/x/1",
+	} {
+		if !strings.Contains(card, want) {
+			t.Errorf("card misses %q:\n%s", want, card)
+		}
+	}
+	if strings.Contains(card, "Further") {
+		t.Errorf("card lists more than three stops:\n%s", card)
+	}
+	if strings.Contains(card, "Value:") {
+		t.Errorf("card shows a value line without a price rating:\n%s", card)
+	}
+}
@@ -0,0 +1,290 @@
+package server
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Tenants ─────────────────────────────────────────────────────── */
+
+// Um deployment pode atender vários tenants (usuários ou equipes), listados no
+// arquivo JSON de TENANTS_FILE. Cada tenant entra com as suas chaves de API, tem o
+// seu próprio rate limit e, se quiser, a sua chave do Google Maps com orçamento
+// diário próprio, e as análises que ele guarda (e os jobs que ele enfileira) não
+// aparecem para os outros. Quem chega sem a chave de um tenant usa o tenant padrão,
+// que é o comportamento de antes: a chave, o orçamento e as análises do deployment.
+// Os agregados de mercado continuam somando todos os anúncios, com os limites de
+// privacidade de aggregatePrivacy.
+
+// Tenant é um tenant de TENANTS_FILE, carregado em setup() e não alterado depois.
+type Tenant struct {
+	ID                 string   `json:"id"`
+	APIKeys            []string `json:"apiKeys"`
+	MapsAPIKey         string   `json:"mapsApiKey,omitempty"`
+	MapsDailyBudget    float64  `json:"mapsDailyBudget,omitempty"`    // USD, só com chave própria; 0 = sem limite
+	RateLimitPerMinute *float64 `json:"rateLimitPerMinute,omitempty"` // nil usa RATE_LIMIT_PER_MINUTE
+	RateLimitBurst     *float64 `json:"rateLimitBurst,omitempty"`     // nil usa RATE_LIMIT_BURST
+
+	analyzer *enrich.Analyzer // com a chave própria do Maps; nil usa o do deployment
+}
+
+// tenantRegistry indexa os tenants por ID e por chave de API
+type tenantRegistry struct {
+	byID  map[string]*Tenant
+	order []*Tenant
+}
+
+// tenants são os tenants do servidor, carregados em setup()
+var tenants = &tenantRegistry{byID: map[string]*Tenant{}}
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// loadTenantsFromEnv lê TENANTS_FILE; sem a variável não há tenants
+func loadTenantsFromEnv() (*tenantRegistry, error) {
+	path := os.Getenv("TENANTS_FILE")
+	if path == "" {
+		return &tenantRegistry{byID: map[string]*Tenant{}}, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return parseTenants(raw)
+}
+
+// parseTenants valida a lista de tenants e monta o Analyzer de quem tem chave
+// própria do Maps
+func parseTenants(raw []byte) (*tenantRegistry, error) {
+	var list []*Tenant
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("invalid tenants file: %w", err)
+	}
+	reg := &tenantRegistry{byID: map[string]*Tenant{}}
+	keys := map[string]string{}
+	for _, t := range list {
+		if !tenantIDPattern.MatchString(t.ID) {
+			return nil, fmt.Errorf("tenant id %q must be lowercase letters, digits, - or _", t.ID)
+		}
+		if reg.byID[t.ID] != nil {
+			return nil, fmt.Errorf("tenant %q is listed twice", t.ID)
+		}
+		if len(t.APIKeys) == 0 {
+			return nil, fmt.Errorf("tenant %q has no API keys", t.ID)
+		}
+		for _, key := range t.APIKeys {
+			if key == "" {
+				return nil, fmt.Errorf("tenant %q has an empty API key", t.ID)
+			}
+			if other, ok := keys[key]; ok {
+				return nil, fmt.Errorf("tenants %q and %q share an API key", other, t.ID)
+			}
+			keys[key] = t.ID
+		}
+		if t.MapsDailyBudget < 0 || t.RateLimitPerMinute != nil && *t.RateLimitPerMinute < 0 ||
+			t.RateLimitBurst != nil && *t.RateLimitBurst < 1 {
+			return nil, fmt.Errorf("tenant %q has an invalid limit", t.ID)
+		}
+		if t.MapsAPIKey != "" {
+			budget := enrich.NewMapsBudgetFromEnv()
+			budget.Limit = t.MapsDailyBudget
+			t.analyzer = storeBacked(enrich.NewAnalyzer(t.MapsAPIKey, budget))
+		}
+		reg.byID[t.ID] = t
+		reg.order = append(reg.order, t)
+	}
+	return reg, nil
+}
+
+// lookup devolve o tenant dono da chave de API
+func (reg *tenantRegistry) lookup(token string) (*Tenant, bool) {
+	if token == "" {
+		return nil, false
+	}
+	var found *Tenant
+	for _, t := range reg.order {
+		for _, key := range t.APIKeys {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1 {
+				found = t
+			}
+		}
+	}
+	return found, found != nil
+}
+
+// get devolve o tenant pelo ID; "" é o tenant padrão (nil, true)
+func (reg *tenantRegistry) get(id string) (*Tenant, bool) {
+	if id == "" {
+		return nil, true
+	}
+	t, ok := reg.byID[id]
+	return t, ok
+}
+
+type tenantKey struct{}
+
+// withTenant anota o tenant no contexto (nil = tenant padrão), com os ajustes de
+// scraping do Analyzer dele quando tem um
+func withTenant(ctx context.Context, t *Tenant) context.Context {
+	if t != nil && t.analyzer != nil {
+		ctx = scraper.WithFetchSettings(ctx, t.analyzer.FetchSettings())
+	}
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// tenantFrom devolve o tenant da requisição, ou nil para o tenant padrão
+func tenantFrom(ctx context.Context) *Tenant {
+	t, _ := ctx.Value(tenantKey{}).(*Tenant)
+	return t
+}
+
+// tenantID é o ID do tenant da requisição ("" para o tenant padrão)
+func tenantID(ctx context.Context) string {
+	if t := tenantFrom(ctx); t != nil {
+		return t.ID
+	}
+	return ""
+}
+
+// tenantContext anota em ctx o tenant com esse ID, para o trabalho em segundo plano
+// feito em nome dele; false se o tenant saiu de TENANTS_FILE
+func tenantContext(ctx context.Context, id string) (context.Context, bool) {
+	t, ok := tenants.get(id)
+	if !ok {
+		return ctx, false
+	}
+	return withTenant(ctx, t), true
+}
+
+// analyzerFor devolve o Analyzer da requisição: o do tenant com chave própria do
+// Maps ou o do deployment, que setup() põe no contexto (ver enrich.WithAnalyzer). Sem
+// nenhum dos dois, um Analyzer vazio, sem provedores.
+func analyzerFor(ctx context.Context) *enrich.Analyzer {
+	if t := tenantFrom(ctx); t != nil && t.analyzer != nil {
+		return t.analyzer
+	}
+	if a := enrich.AnalyzerFrom(ctx); a != nil {
+		return a
+	}
+	return &enrich.Analyzer{}
+}
+
+// tenantScoped prefixa key com o tenant da requisição, para que análises em
+// andamento não sejam divididas entre tenants
+func tenantScoped(ctx context.Context, key string) string {
+	if id := tenantID(ctx); id != "" {
+		return "tenant:" + id + "|" + key
+	}
+	return key
+}
+
+// analysisKeyFor é a chave da análise do anúncio no store para o tenant; a do
+// tenant padrão é a analysisKey de sempre
+func analysisKeyFor(tenant, listingURL string) string {
+	if tenant == "" {
+		return analysisKey(listingURL)
+	}
+	sum := sha1.Sum([]byte(tenant + "\n" + scraper.CanonicalListingURL(listingURL)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// visibleTo informa se a análise pertence ao tenant da requisição
+func (a *StoredAnalysis) visibleTo(ctx context.Context) bool {
+	return a.Tenant == tenantID(ctx)
+}
+
+// visibleTo informa se o watch pertence ao tenant da requisição
+func (wt *Watch) visibleTo(ctx context.Context) bool {
+	return wt.Tenant == tenantID(ctx)
+}
+
+// visibleTo informa se a busca salva pertence ao tenant da requisição
+func (s *SavedSearch) visibleTo(ctx context.Context) bool {
+	return s.Tenant == tenantID(ctx)
+}
+
+// requestToken é a chave de API da requisição ("Authorization: Bearer" ou X-API-Key)
+func requestToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// identifyTenant é o middleware que anota o tenant da chave de API no contexto
+func identifyTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if t, ok := tenants.lookup(requestToken(r)); ok {
+			r = r.WithContext(telemetry.WithLogger(withTenant(r.Context(), t), telemetry.LogFor(r.Context()).With("tenant", t.ID)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+/* ───── GET /admin/tenants ──────────────────────────────────────────── */
+
+// TenantReport é um item de GET /admin/tenants, sem as chaves
+type TenantReport struct {
+	ID                 string                   `json:"id"`
+	APIKeys            int                      `json:"apiKeys"`
+	OwnMapsKey         bool                     `json:"ownMapsKey"`
+	RateLimitPerMinute float64                  `json:"rateLimitPerMinute"`
+	RateLimitBurst     float64                  `json:"rateLimitBurst"`
+	MapsCalls          int64                    `json:"mapsCalls"`            // desde o start
+	MapsBudget         *enrich.MapsBudgetReport `json:"mapsBudget,omitempty"` // só com chave própria
+	Analyses           int                      `json:"analyses"`
+}
+
+// handleAdminTenants lista os tenants com os limites e o consumo de cada um
+func handleAdminTenants(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+
+	analyses := map[string]int{}
+	store.View(func(d *storeData) {
+		for _, a := range d.Analyses {
+			analyses[a.Tenant]++
+		}
+	})
+	now := time.Now()
+	reports := []TenantReport{}
+	for _, t := range tenants.order {
+		perMinute, burst := limiter.limits("tenant:" + t.ID)
+		report := TenantReport{
+			ID:                 t.ID,
+			APIKeys:            len(t.APIKeys),
+			OwnMapsKey:         t.analyzer != nil,
+			RateLimitPerMinute: perMinute,
+			RateLimitBurst:     burst,
+			MapsCalls:          limiter.mapsCallsOf("tenant:" + t.ID),
+			Analyses:           analyses[t.ID],
+		}
+		if t.analyzer != nil {
+			budget := t.analyzer.Spend().Report(now)
+			report.MapsBudget = &budget
+		}
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].ID < reports[j].ID })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
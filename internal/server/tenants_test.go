@@ -0,0 +1,305 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+)
+
+// useTenants installs the tenants of raw for the duration of the test
+func useTenants(t *testing.T, raw string) *tenantRegistry {
+	t.Helper()
+	reg, err := parseTenants([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := tenants
+	tenants = reg
+	t.Cleanup(func() { tenants = prev })
+	return reg
+}
+
+const testTenants = `[
+	{"id": "acme", "apiKeys": ["acme-key"], "mapsApiKey": "acme-maps", "mapsDailyBudget": 0.01,
+	 "rateLimitPerMinute": 60, "rateLimitBurst": 1},
+	{"id": "globex", "apiKeys": ["globex-key", "globex-key-2"]}
+]`
+
+func TestParseTenantsValidation(t *testing.T) {
+	for name, raw := range map[string]string{
+		"bad id":        `[{"id": "Acme Inc", "apiKeys": ["k"]}]`,
+		"duplicate id":  `[{"id": "a", "apiKeys": ["k1"]}, {"id": "a", "apiKeys": ["k2"]}]`,
+		"no keys":       `[{"id": "a", "apiKeys": []}]`,
+		"empty key":     `[{"id": "a", "apiKeys": [""]}]`,
+		"shared key":    `[{"id": "a", "apiKeys": ["k"]}, {"id": "b", "apiKeys": ["k"]}]`,
+		"bad burst":     `[{"id": "a", "apiKeys": ["k"], "rateLimitBurst": 0}]`,
+		"bad budget":    `[{"id": "a", "apiKeys": ["k"], "mapsDailyBudget": -1}]`,
+		"invalid json":  `{"id": "a"}`,
+		"negative rate": `[{"id": "a", "apiKeys": ["k"], "rateLimitPerMinute": -5}]`,
+	} {
+		if _, err := parseTenants([]byte(raw)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	reg := useTenants(t, testTenants)
+	if tenant, ok := reg.lookup("globex-key-2"); !ok || tenant.ID != "globex" {
+		t.Errorf("lookup = %v, %v", tenant, ok)
+	}
+	if _, ok := reg.lookup("nope"); ok {
+		t.Error("an unknown key matched a tenant")
+	}
+	if _, ok := reg.lookup(""); ok {
+		t.Error("an empty key matched a tenant")
+	}
+}
+
+func TestIdentifyTenant(t *testing.T) {
+	useTenants(t, testTenants)
+	var seen string
+	handler := identifyTenant(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = tenantID(r.Context())
+	}))
+
+	for header, want := range map[string]string{
+		"X-API-Key: acme-key":              "acme",
+		"Authorization: Bearer globex-key": "globex",
+		"X-API-Key: someone-else":          "",
+	} {
+		name, value, _ := strings.Cut(header, ": ")
+		req := httptest.NewRequest(http.MethodGet, "/analyses", nil)
+		req.Header.Set(name, value)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if seen != want {
+			t.Errorf("%s: tenant %q, want %q", header, seen, want)
+		}
+	}
+}
+
+func TestTenantRateLimits(t *testing.T) {
+	useTenants(t, testTenants)
+	useLimiter(t, 60, 5)
+	handler := identifyTenant(rateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	call := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/analyses", nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// acme has a burst of one
+	if rec := call("acme-key"); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Fatalf("first acme request: status %d, limit %s", rec.Code, rec.Header().Get("X-RateLimit-Limit"))
+	}
+	if rec := call("acme-key"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("second acme request: status %d, want 429", rec.Code)
+	}
+	// globex uses the server limits, and both of its keys share them
+	for i := 0; i < 2; i++ {
+		if rec := call("globex-key"); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "5" {
+			t.Errorf("globex request: status %d, limit %s", rec.Code, rec.Header().Get("X-RateLimit-Limit"))
+		}
+	}
+	if rec := call("globex-key-2"); rec.Header().Get("X-RateLimit-Remaining") != "2" {
+		t.Errorf("globex keys should share a bucket, remaining %s", rec.Header().Get("X-RateLimit-Remaining"))
+	}
+}
+
+func TestTenantMapsKeyAndBudget(t *testing.T) {
+	t.Setenv("MAPS_BUDGET_MODE", "refuse")
+	reg := useTenants(t, testTenants)
+	deployment := &enrich.Analyzer{Budget: enrich.NewMapsBudgetFromEnv()}
+	ctx := enrich.WithAnalyzer(context.Background(), deployment)
+	acme := withTenant(ctx, reg.byID["acme"])
+	globex := withTenant(ctx, reg.byID["globex"])
+
+	if analyzerFor(acme) == deployment || analyzerFor(acme).Maps == nil {
+		t.Error("acme should get its own Maps client")
+	}
+	if analyzerFor(globex) != deployment || analyzerFor(ctx) != deployment {
+		t.Error("tenants without a Maps key use the deployment analyzer")
+	}
+
+	// acme's spend does not touch the deployment budget
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := analyzerFor(acme).Spend().Reserve("geocode", now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if deployment.Spend().Report(now).Spend != 0 {
+		t.Error("acme's Maps calls were charged to the deployment")
+	}
+	if err := analyzerFor(acme).RefuseOverBudget(scraper.AllModules()); err == nil {
+		t.Error("acme's budget is spent, so its analyses should be refused")
+	}
+	if err := analyzerFor(globex).RefuseOverBudget(scraper.AllModules()); err != nil {
+		t.Errorf("the deployment budget is untouched, got %v", err)
+	}
+}
+
+func TestTenantAnalysesAreIsolated(t *testing.T) {
+	reg := useTenants(t, testTenants)
+	useJobStore(t)
+	acme := withTenant(context.Background(), reg.byID["acme"])
+	url := "https://www.daft.ie/for-rent/apartment/1"
+
+	recordAnalysis(acme, &scraper.PropertyInfo{URL: url, Address: "1 Main St", RentPrice: "€2,000 per month"})
+	acmeID := analysisKeyFor("acme", url)
+	if acmeID == analysisKey(url) {
+		t.Fatal("tenant analyses should not share the default key")
+	}
+
+	if _, _, ok := cachedAnalysis(context.Background(), url, time.Hour); ok {
+		t.Error("the default tenant was served acme's analysis from the cache")
+	}
+	if _, _, ok := cachedAnalysis(acme, url, time.Hour); !ok {
+		t.Error("acme should get its own analysis from the cache")
+	}
+	if got := trackedAnalyses(context.Background(), ""); len(got) != 0 {
+		t.Errorf("default tenant lists %+v", got)
+	}
+	if got := trackedAnalyses(acme, ""); len(got) != 1 || got[0].ID != acmeID {
+		t.Errorf("acme lists %+v", got)
+	}
+
+	// another tenant cannot read or edit the analysis by ID
+	globex := withTenant(context.Background(), reg.byID["globex"])
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/analyses/"+acmeID+"/tracking", nil),
+		httptest.NewRequest(http.MethodPatch, "/analyses/"+acmeID+"/tracking", strings.NewReader(`{"status":"rejected"}`)),
+		httptest.NewRequest(http.MethodGet, "/analyses/"+acmeID+"/history", nil),
+	} {
+		rec := httptest.NewRecorder()
+		handleAnalysisRoutes(rec, req.WithContext(globex))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s %s as globex: status %d, want 404", req.Method, req.URL.Path, rec.Code)
+		}
+	}
+	rec := httptest.NewRecorder()
+	handleAnalysisRoutes(rec, httptest.NewRequest(http.MethodGet, "/analyses/"+acmeID+"/history", nil).WithContext(acme))
+	if rec.Code != http.StatusOK {
+		t.Errorf("acme reading its history: status %d", rec.Code)
+	}
+}
+
+func TestTenantJobsRunAsTheTenant(t *testing.T) {
+	reg := useTenants(t, testTenants)
+	useJobStore(t)
+	var ranAs []string
+	stubJobRunner(t, jobAnalyze, func(ctx context.Context, target string) error {
+		ranAs = append(ranAs, tenantID(ctx))
+		return nil
+	})
+
+	acme := withTenant(context.Background(), reg.byID["acme"])
+	a, _ := enqueueJob(acme, jobAnalyze, "https://www.daft.ie/for-rent/a/1", "")
+	b, _ := enqueueJob(context.Background(), jobAnalyze, "https://www.daft.ie/for-rent/a/1", "")
+	if a.ID == b.ID || a.Tenant != "acme" {
+		t.Fatalf("jobs of different tenants were merged: %+v / %+v", a, b)
+	}
+	for job, _ := claimJob(time.Now()); job != nil; job, _ = claimJob(time.Now()) {
+		runJob(job)
+	}
+	if strings.Join(ranAs, ",") != "acme," && strings.Join(ranAs, ",") != ",acme" {
+		t.Errorf("jobs ran as %q", ranAs)
+	}
+
+	// a job whose tenant was removed from the file does not run as the default tenant
+	putJob(t, Job{ID: "orphan", Kind: jobAnalyze, Tenant: "initech", Status: "running", Attempts: 1})
+	ranAs = nil
+	runJob(&Job{ID: "orphan", Kind: jobAnalyze, Tenant: "initech", Attempts: 1})
+	if job, _ := storedJob(t, "orphan"); len(ranAs) != 0 || job.LastError == nil {
+		t.Errorf("orphan job ran as %q: %+v", ranAs, job)
+	}
+}
+
+func TestHandleAdminTenants(t *testing.T) {
+	useTenants(t, testTenants)
+	useJobStore(t)
+	useLimiter(t, 60, 5)
+	t.Setenv("ADMIN_TOKEN", "s3cret")
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/tenants", nil)
+	req.Header.Set("X-Admin-Token", "s3cret")
+	rec := httptest.NewRecorder()
+	handleAdminTenants(rec, req)
+	var reports []TenantReport
+	if err := json.NewDecoder(rec.Body).Decode(&reports); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 || reports[0].ID != "acme" || !reports[0].OwnMapsKey || reports[0].MapsBudget == nil ||
+		reports[0].RateLimitBurst != 1 || reports[1].APIKeys != 2 || reports[1].RateLimitBurst != 5 {
+		t.Errorf("reports = %+v", reports)
+	}
+	if strings.Contains(rec.Body.String(), "acme-key") || strings.Contains(rec.Body.String(), "acme-maps") {
+		t.Error("the report leaked a key")
+	}
+}
+
+func TestTenantWatchesSearchesAndComparablesAreIsolated(t *testing.T) {
+	reg := useTenants(t, testTenants)
+	useJobStore(t)
+	acme := withTenant(context.Background(), reg.byID["acme"])
+	globex := withTenant(context.Background(), reg.byID["globex"])
+	address := "12 Main Street, Rathmines, Dublin 6"
+	store.Update(func(d *storeData) error {
+		d.Watches["w1"] = &Watch{ID: "w1", Tenant: "acme", Notify: NotifyTarget{Webhook: "https://hooks.acme.ie/x"}}
+		d.SavedSearches["s1"] = &SavedSearch{ID: "s1", Tenant: "acme", Seen: map[string]bool{}}
+		d.PrivateComparables = append(d.PrivateComparables, PrivateComparable{
+			ID: "p1", Tenant: "acme", Agency: "Acme Lettings", Address: address, Rent: 2000, LetDate: time.Now().Format("2006-01-02"),
+		})
+		return nil
+	})
+
+	list := func(h http.HandlerFunc, path string, ctx context.Context) int {
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx))
+		var items []map[string]any
+		json.NewDecoder(rec.Body).Decode(&items)
+		return len(items)
+	}
+	for _, c := range []struct {
+		handler http.HandlerFunc
+		path    string
+	}{{handleWatch, "/watch"}, {handleSearches, "/searches"}} {
+		if n := list(c.handler, c.path, globex); n != 0 {
+			t.Errorf("GET %s as globex lists %d of acme's items", c.path, n)
+		}
+		if n := list(c.handler, c.path, acme); n != 1 {
+			t.Errorf("GET %s as acme lists %d items, want 1", c.path, n)
+		}
+	}
+
+	for _, c := range []struct {
+		handler http.HandlerFunc
+		path    string
+	}{{handleWatch, "/watch?id=w1"}, {handleSearches, "/searches?id=s1"}} {
+		rec := httptest.NewRecorder()
+		c.handler(rec, httptest.NewRequest(http.MethodDelete, c.path, nil).WithContext(globex))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("DELETE %s as globex: status %d, want 404", c.path, rec.Code)
+		}
+	}
+	store.View(func(d *storeData) {
+		if d.Watches["w1"] == nil || d.SavedSearches["s1"] == nil {
+			t.Error("globex deleted acme's watch or search")
+		}
+	})
+
+	property := &scraper.PropertyInfo{Address: address}
+	if got := findPrivateComparables(globex, property, 1500, 2500); len(got) != 0 {
+		t.Errorf("globex's analysis used acme's private comparables: %+v", got)
+	}
+	if got := findPrivateComparables(acme, property, 1500, 2500); len(got) != 1 {
+		t.Errorf("acme's analysis should use its own comparable, got %+v", got)
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"daft-scraper-api/internal/env"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+/* ───── Endereço de escuta e TLS ────────────────────────────────────── */
+
+// listenAddr é LISTEN_ADDR (host:porta, ex. 127.0.0.1:8080 para ouvir só localmente)
+// ou :PORT em todas as interfaces
+func listenAddr() string {
+	if addr := os.Getenv("LISTEN_ADDR"); addr != "" {
+		return addr
+	}
+	return ":" + env.Or("PORT", "8080")
+}
+
+// tlsOptions diz como servir HTTPS: com os arquivos de certificado e chave
+// (TLS_CERT_FILE/TLS_KEY_FILE), com certificados do Let's Encrypt para os domínios
+// de AUTOCERT_DOMAINS, ou em HTTP puro quando nada está definido
+type tlsOptions struct {
+	CertFile, KeyFile string
+	AutocertDomains   []string
+}
+
+func tlsOptionsFromEnv() tlsOptions {
+	return tlsOptions{
+		CertFile:        os.Getenv("TLS_CERT_FILE"),
+		KeyFile:         os.Getenv("TLS_KEY_FILE"),
+		AutocertDomains: env.List("AUTOCERT_DOMAINS", nil),
+	}
+}
+
+// configureTLS prepara srv.TLSConfig conforme opts; serve usa HTTPS sempre que ele
+// está definido. Com autocert, o certificado é pedido no primeiro acesso (desafio
+// TLS-ALPN-01, então a porta de escuta precisa ser a 443 vista de fora) e guardado
+// em AUTOCERT_CACHE_DIR. Se AUTOCERT_HTTP_ADDR estiver definido (ex. :80) é devolvido
+// também um servidor HTTP que responde ao desafio HTTP-01 e redireciona o resto
+// para HTTPS; ele é fechado junto com srv.
+func configureTLS(srv *http.Server, opts tlsOptions) (*http.Server, error) {
+	switch {
+	case len(opts.AutocertDomains) > 0 && (opts.CertFile != "" || opts.KeyFile != ""):
+		return nil, errors.New("set either TLS_CERT_FILE/TLS_KEY_FILE or AUTOCERT_DOMAINS, not both")
+	case (opts.CertFile == "") != (opts.KeyFile == ""):
+		return nil, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	case opts.CertFile != "":
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+		return nil, nil
+	case len(opts.AutocertDomains) == 0:
+		return nil, nil
+	}
+
+	cacheDir := os.Getenv("AUTOCERT_CACHE_DIR")
+	if cacheDir == "" {
+		cacheDir = filepath.Join(env.Or("DATA_DIR", "data"), "autocert")
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(opts.AutocertDomains...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      os.Getenv("AUTOCERT_EMAIL"),
+	}
+	srv.TLSConfig = m.TLSConfig()
+	srv.TLSConfig.MinVersion = tls.VersionTLS12
+	slog.Info("Serving HTTPS with Let's Encrypt certificates", "domains", opts.AutocertDomains, "cache", cacheDir)
+
+	addr := os.Getenv("AUTOCERT_HTTP_ADDR")
+	if addr == "" {
+		return nil, nil
+	}
+	challenge := &http.Server{Addr: addr, Handler: m.HTTPHandler(nil)}
+	srv.RegisterOnShutdown(func() { challenge.Close() })
+	return challenge, nil
+}
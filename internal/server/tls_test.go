@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// selfSignedCert writes a certificate for 127.0.0.1 and its key to a temp dir
+func selfSignedCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func TestListenAddr(t *testing.T) {
+	t.Setenv("PORT", "")
+	t.Setenv("LISTEN_ADDR", "")
+	if got := listenAddr(); got != ":8080" {
+		t.Errorf("default = %q", got)
+	}
+	t.Setenv("PORT", "9000")
+	if got := listenAddr(); got != ":9000" {
+		t.Errorf("with PORT = %q", got)
+	}
+	t.Setenv("LISTEN_ADDR", "127.0.0.1:9443")
+	if got := listenAddr(); got != "127.0.0.1:9443" {
+		t.Errorf("LISTEN_ADDR should win, got %q", got)
+	}
+}
+
+func TestServeWithCertFiles(t *testing.T) {
+	freshShutdown(t)
+	certFile, keyFile := selfSignedCert(t)
+	addr := freePort(t)
+	srv := &http.Server{Addr: addr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil {
+			t.Error("request did not arrive over TLS")
+		}
+		io.WriteString(w, "secure")
+	})}
+	if _, err := configureTLS(srv, tlsOptions{CertFile: certFile, KeyFile: keyFile}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, srv) }()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get("https://" + addr); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("HTTPS request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "secure" {
+		t.Errorf("body = %q", body)
+	}
+
+	stop()
+	if err := <-served; err != nil {
+		t.Errorf("serve returned %v", err)
+	}
+}
+
+func TestConfigureTLSErrors(t *testing.T) {
+	certFile, keyFile := selfSignedCert(t)
+	for name, opts := range map[string]tlsOptions{
+		"cert without key":   {CertFile: certFile},
+		"key without cert":   {KeyFile: keyFile},
+		"missing files":      {CertFile: certFile + ".missing", KeyFile: keyFile},
+		"files and autocert": {CertFile: certFile, KeyFile: keyFile, AutocertDomains: []string{"api.example.com"}},
+	} {
+		if _, err := configureTLS(&http.Server{}, opts); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	srv := &http.Server{}
+	if _, err := configureTLS(srv, tlsOptions{}); err != nil || srv.TLSConfig != nil {
+		t.Errorf("without TLS options the server should stay on plain HTTP (%v)", err)
+	}
+}
+
+func TestConfigureAutocert(t *testing.T) {
+	t.Setenv("AUTOCERT_CACHE_DIR", t.TempDir())
+	t.Setenv("AUTOCERT_HTTP_ADDR", "127.0.0.1:0")
+	srv := &http.Server{}
+	challenge, err := configureTLS(srv, tlsOptions{AutocertDomains: []string{"api.example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srv.TLSConfig == nil || srv.TLSConfig.GetCertificate == nil {
+		t.Fatal("autocert should provide certificates on demand")
+	}
+	if challenge == nil {
+		t.Fatal("AUTOCERT_HTTP_ADDR should start the HTTP-01 challenge server")
+	}
+
+	// hosts outside AUTOCERT_DOMAINS are refused before any call to Let's Encrypt
+	_, err = srv.TLSConfig.GetCertificate(&tls.ClientHelloInfo{ServerName: "attacker.example.net"})
+	if err == nil {
+		t.Error("a certificate was requested for a host that is not allowed")
+	}
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+/* ───── Acompanhamento: o que o usuário fez com cada anúncio ────────── */
+
+// trackingStatuses são as etapas do funil de quem procura imóvel
+var trackingStatuses = map[string]bool{"shortlisted": true, "viewed": true, "applied": true, "rejected": true}
+
+// Tracking são os campos editáveis pelo usuário numa análise guardada.
+// O PATCH troca o ponteiro por um valor novo em vez de alterar o atual, então o
+// GET pode devolvê-lo depois de sair de store.View.
+type Tracking struct {
+	Status      string     `json:"status,omitempty"` // shortlisted, viewed, applied, rejected
+	Notes       string     `json:"notes,omitempty"`
+	ViewingDate *time.Time `json:"viewingDate,omitempty"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// TrackedAnalysis é um item de GET /analyses
+type TrackedAnalysis struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	Address    string    `json:"address"`
+	Price      string    `json:"price"`
+	Tracking   *Tracking `json:"tracking,omitempty"`
+	AnalyzedAt time.Time `json:"analyzedAt"`
+}
+
+// trackingUpdate é o corpo de PATCH /analyses/{id}/tracking; campos ausentes não mudam
+type trackingUpdate struct {
+	Status      *string `json:"status"`
+	Notes       *string `json:"notes"`
+	ViewingDate *string `json:"viewingDate"` // RFC 3339 ou "2006-01-02"; "" limpa
+}
+
+// apply valida e aplica a atualização sobre t
+func (u trackingUpdate) apply(t *Tracking) error {
+	if u.Status != nil {
+		status := strings.ToLower(strings.TrimSpace(*u.Status))
+		if status != "" && !trackingStatuses[status] {
+			return fmt.Errorf("status must be one of: shortlisted, viewed, applied, rejected")
+		}
+		t.Status = status
+	}
+	if u.Notes != nil {
+		t.Notes = *u.Notes
+	}
+	if u.ViewingDate != nil {
+		switch date := strings.TrimSpace(*u.ViewingDate); date {
+		case "":
+			t.ViewingDate = nil
+		default:
+			parsed, err := time.Parse(time.RFC3339, date)
+			if err != nil {
+				parsed, err = time.Parse("2006-01-02", date)
+			}
+			if err != nil {
+				return fmt.Errorf("viewingDate must be RFC 3339 or YYYY-MM-DD")
+			}
+			t.ViewingDate = &parsed
+		}
+	}
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// trackedAnalyses lista as análises guardadas do tenant da requisição, filtradas por
+// status ("" = todas)
+func trackedAnalyses(ctx context.Context, status string) []TrackedAnalysis {
+	results := []TrackedAnalysis{}
+	store.View(func(d *storeData) {
+		for id, a := range d.Analyses {
+			if !a.visibleTo(ctx) || status != "" && (a.Tracking == nil || a.Tracking.Status != status) {
+				continue
+			}
+			results = append(results, TrackedAnalysis{
+				ID:         id,
+				URL:        a.URL,
+				Address:    a.Property.Address,
+				Price:      a.Property.RentPrice,
+				Tracking:   a.Tracking,
+				AnalyzedAt: a.AnalyzedAt,
+			})
+		}
+	})
+	sort.Slice(results, func(i, j int) bool { return results[i].AnalyzedAt.After(results[j].AnalyzedAt) })
+	return results
+}
+
+// handleAnalyses é o handler HTTP para GET /analyses?status=...
+func handleAnalyses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if status != "" && !trackingStatuses[status] {
+		writeError(w, http.StatusBadRequest, "status must be one of: shortlisted, viewed, applied, rejected")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trackedAnalyses(r.Context(), status))
+}
+
+// handleTracking é o handler HTTP para GET/PATCH /analyses/{id}/tracking
+func handleTracking(w http.ResponseWriter, r *http.Request, id string) {
+	switch r.Method {
+	case http.MethodGet:
+		var (
+			tracking *Tracking
+			found    bool
+		)
+		store.View(func(d *storeData) {
+			if a, ok := d.Analyses[id]; ok && a.visibleTo(r.Context()) {
+				tracking, found = a.Tracking, true
+			}
+		})
+		if !found {
+			writeError(w, http.StatusNotFound, "Analysis not found")
+			return
+		}
+		if tracking == nil {
+			tracking = &Tracking{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tracking)
+
+	case http.MethodPatch, http.MethodPut:
+		var update trackingUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := update.apply(&Tracking{}); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var tracking Tracking
+		found := false
+		err := store.Update(func(d *storeData) error {
+			a, ok := d.Analyses[id]
+			if !ok || !a.visibleTo(r.Context()) {
+				return nil
+			}
+			found = true
+			updated := Tracking{}
+			if a.Tracking != nil {
+				updated = *a.Tracking
+			}
+			update.apply(&updated) // já validado acima
+			a.Tracking = &updated
+			tracking = updated
+			return nil
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Error saving tracking: %v", err))
+			return
+		}
+		if !found {
+			writeError(w, http.StatusNotFound, "Analysis not found")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tracking)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Only GET and PATCH methods are allowed")
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestTrackingAPI(t *testing.T) {
+	prev := store
+	store = newMemoryStore()
+	t.Cleanup(func() { store = prev })
+	url := "https://www.daft.ie/for-rent/apartment-1-main-street/123"
+	recordAnalysis(context.Background(), &scraper.PropertyInfo{URL: url, Address: "1 Main Street, Dublin 6"})
+	id := analysisKey(url)
+
+	rec := httptest.NewRecorder()
+	body := `{"status":"Shortlisted","notes":"ask about parking","viewingDate":"2024-03-12"}`
+	handleAnalysisRoutes(rec, httptest.NewRequest(http.MethodPatch, "/analyses/"+id+"/tracking", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// a second partial update keeps the other fields
+	rec = httptest.NewRecorder()
+	handleAnalysisRoutes(rec, httptest.NewRequest(http.MethodPatch, "/analyses/"+id+"/tracking", strings.NewReader(`{"status":"viewed"}`)))
+	var tracking Tracking
+	json.NewDecoder(rec.Body).Decode(&tracking)
+	if tracking.Status != "viewed" || tracking.Notes != "ask about parking" || tracking.ViewingDate == nil {
+		t.Errorf("unexpected tracking after partial update: %+v", tracking)
+	}
+
+	if got := trackedAnalyses(context.Background(), "viewed"); len(got) != 1 || got[0].ID != id {
+		t.Errorf("trackedAnalyses(context.Background(), viewed) = %+v", got)
+	}
+	if got := trackedAnalyses(context.Background(), "applied"); len(got) != 0 {
+		t.Errorf("trackedAnalyses(context.Background(), applied) = %+v", got)
+	}
+
+	// re-analysis must not drop the tracking fields
+	recordAnalysis(context.Background(), &scraper.PropertyInfo{URL: url, Address: "1 Main Street, Dublin 6", RentPrice: "€2,000 per month"})
+	if got := trackedAnalyses(context.Background(), "viewed"); len(got) != 1 {
+		t.Error("tracking lost after re-analysis")
+	}
+
+	rec = httptest.NewRecorder()
+	handleAnalysisRoutes(rec, httptest.NewRequest(http.MethodPatch, "/analyses/"+id+"/tracking", strings.NewReader(`{"status":"moved in"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid status: code = %d, want 400", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handleAnalysisRoutes(rec, httptest.NewRequest(http.MethodGet, "/analyses/unknown/tracking", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown id: code = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── GET /analyses/{id}/viewings.ics ─────────────────────────────── */
+
+// viewingsICS monta o calendário com um evento por horário de visita
+func viewingsICS(id string, property scraper.PropertyInfo, analysisLink string, stamp time.Time) string {
+	var b strings.Builder
+	line := func(s string) {
+		// linhas de no máximo 75 octetos; a continuação começa com espaço (RFC 5545)
+		for len(s) > 75 {
+			cut := 75
+			for cut > 0 && !utf8.RuneStart(s[cut]) {
+				cut--
+			}
+			b.WriteString(s[:cut] + "\r\n")
+			s = " " + s[cut:]
+		}
+		b.WriteString(s + "\r\n")
+	}
+	const layout = "20060102T150405Z"
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//daft-scraper-api//viewings//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	for _, v := range property.Viewings {
+		line("BEGIN:VEVENT")
+		line(fmt.Sprintf("UID:%s-%d@daft-scraper-api", id, v.Start.Unix()))
+		line("DTSTAMP:" + stamp.UTC().Format(layout))
+		line("DTSTART:" + v.Start.UTC().Format(layout))
+		line("DTEND:" + v.End.UTC().Format(layout))
+		line("SUMMARY:" + icsEscape("Viewing: "+property.Address))
+		line("LOCATION:" + icsEscape(property.Address))
+		line("DESCRIPTION:" + icsEscape(fmt.Sprintf("%s\nListing: %s\nAnalysis: %s", v.Text, property.URL, analysisLink)))
+		line("URL:" + analysisLink)
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// icsEscape escapa o texto de uma propriedade do iCalendar
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// requestBaseURL é o endereço público do serviço visto pela requisição
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// handleViewingsICS é o handler HTTP para GET /analyses/{id}/viewings.ics
+func handleViewingsICS(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	var (
+		property   scraper.PropertyInfo
+		analyzedAt time.Time
+		found      bool
+	)
+	store.View(func(d *storeData) {
+		if a, ok := d.Analyses[id]; ok && a.visibleTo(r.Context()) {
+			property, analyzedAt, found = a.Property, a.AnalyzedAt, true
+		}
+	})
+	if !found {
+		writeError(w, http.StatusNotFound, "Analysis not found")
+		return
+	}
+	link := requestBaseURL(r) + "/report?url=" + url.QueryEscape(property.URL)
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="viewings.ics"`)
+	w.Write([]byte(viewingsICS(id, property, link, analyzedAt)))
+}
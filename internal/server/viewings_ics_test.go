@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestViewingsICS(t *testing.T) {
+	store = newMemoryStore()
+	t.Cleanup(func() { store = newMemoryStore() })
+	start := time.Date(2026, 10, 17, 11, 0, 0, 0, time.UTC)
+	store.Update(func(d *storeData) error {
+		d.Analyses["abc"] = &StoredAnalysis{ID: "abc", URL: "https://www.daft.ie/for-rent/x/1", AnalyzedAt: start, Property: scraper.PropertyInfo{
+			Address:  "Apartment 4, The Maltings, Bray; Co. Wicklow",
+			URL:      "https://www.daft.ie/for-rent/x/1",
+			Viewings: []scraper.ViewingTime{{Start: start, End: start.Add(30 * time.Minute), Text: "Viewing: Saturday 11-11.30"}},
+		}}
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "http://api.example.com/analyses/abc/viewings.ics", nil)
+	handleAnalysisRoutes(rec, req)
+	if rec.Code != 200 || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/calendar") {
+		t.Fatalf("status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	unfolded := strings.ReplaceAll(body, "\r\n ", "")
+	for _, want := range []string{
+		"BEGIN:VEVENT\r\n",
+		"DTSTART:20261017T110000Z\r\n",
+		"DTEND:20261017T113000Z\r\n",
+		`LOCATION:Apartment 4\, The Maltings\, Bray\; Co. Wicklow`,
+		"URL:http://api.example.com/report?url=https%3A%2F%2Fwww.daft.ie%2Ffor-rent%2Fx%2F1",
+	} {
+		if !strings.Contains(unfolded, want) {
+			t.Errorf("calendar missing %q:\n%s", want, body)
+		}
+	}
+	for _, l := range strings.Split(body, "\r\n") {
+		if len(l) > 75 {
+			t.Errorf("line longer than 75 octets: %q", l)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handleAnalysisRoutes(rec, httptest.NewRequest("GET", "/analyses/missing/viewings.ics", nil))
+	if rec.Code != 404 {
+		t.Errorf("missing analysis: status %d", rec.Code)
+	}
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+// Watch é um anúncio acompanhado para detectar mudança de preço ou remoção.
+// As checagens trabalham num Clone() e gravam o resultado via store.Update.
+type Watch struct {
+	ID           string               `json:"id"`
+	Tenant       string               `json:"tenant,omitempty"` // dono do watch; "" é o tenant padrão
+	URL          string               `json:"url"`
+	Address      string               `json:"address"`
+	Notify       NotifyTarget         `json:"notify"`
+	LastPrice    float64              `json:"lastPrice"`
+	PriceHistory []scraper.PricePoint `json:"priceHistory"`
+	Removed      bool                 `json:"removed"`
+	CreatedAt    time.Time            `json:"createdAt"`
+	LastChecked  time.Time            `json:"lastChecked"`
+}
+
+// clone copia o watch com o próprio histórico de preços, para usá-lo fora do lock
+// do Store enquanto checkWatch altera o original
+func (wt *Watch) clone() *Watch {
+	c := *wt
+	c.PriceHistory = append([]scraper.PricePoint(nil), wt.PriceHistory...)
+	return &c
+}
+
+// watchCheckInterval é o intervalo entre verificações de cada anúncio (WATCH_INTERVAL)
+func watchCheckInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("WATCH_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return 24 * time.Hour
+}
+
+// watchRequest é o corpo de POST /watch
+type watchRequest struct {
+	URL    string       `json:"url"`
+	Notify NotifyTarget `json:"notify"`
+}
+
+// handleWatch cria (POST), lista (GET) ou remove (DELETE ?id=) anúncios acompanhados
+func handleWatch(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		var watches []*Watch
+		store.View(func(d *storeData) {
+			for _, wt := range d.Watches {
+				if wt.visibleTo(r.Context()) {
+					watches = append(watches, wt.clone())
+				}
+			}
+		})
+		sort.Slice(watches, func(i, j int) bool { return watches[i].CreatedAt.Before(watches[j].CreatedAt) })
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(watches)
+
+	case http.MethodPost:
+		var requestBody watchRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if requestBody.URL == "" {
+			writeError(w, http.StatusBadRequest, "url is required in the request body")
+			return
+		}
+		if requestBody.Notify == (NotifyTarget{}) {
+			writeError(w, http.StatusBadRequest, "notify.webhook, notify.email or notify.telegram is required")
+			return
+		}
+		if err := validateWebhook(r.Context(), requestBody.Notify.Webhook); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// Primeira verificação imediata para registrar o preço inicial
+		property, err := scraper.ScrapeDaftListing(r.Context(), scraper.CanonicalListingURL(requestBody.URL))
+		if err != nil {
+			writeScrapeError(w, err)
+			return
+		}
+
+		now := time.Now()
+		watch := &Watch{
+			ID:          newID(),
+			Tenant:      tenantID(r.Context()),
+			URL:         property.URL,
+			Address:     property.Address,
+			Notify:      requestBody.Notify,
+			LastPrice:   scraper.ExtractPriceValue(property.RentPrice),
+			CreatedAt:   now,
+			LastChecked: now,
+		}
+		if watch.LastPrice > 0 {
+			watch.PriceHistory = append(watch.PriceHistory, scraper.PricePoint{Date: now.Format("2006-01-02"), Price: watch.LastPrice})
+		}
+
+		if err := store.Update(func(d *storeData) error {
+			d.Watches[watch.ID] = watch.clone()
+			return nil
+		}); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Error saving watch: %v", err))
+			return
+		}
+
+		telemetry.LogFor(r.Context()).Info("Listing watched", "url", watch.URL, "id", watch.ID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(watch)
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		found := false
+		if err := store.Update(func(d *storeData) error {
+			stored, ok := d.Watches[id]
+			if found = ok && stored.visibleTo(r.Context()); found {
+				delete(d.Watches, id)
+			}
+			return nil
+		}); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Error deleting watch: %v", err))
+			return
+		}
+		if !found {
+			writeError(w, http.StatusNotFound, "Watch not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Only GET, POST and DELETE methods are allowed")
+	}
+}
+
+// runWatchScheduler põe na fila de jobs (ver jobs.go) a verificação dos anúncios cuja
+// última checagem venceu. O tick é menor que o intervalo para que um restart não adie
+// as verificações.
+func runWatchScheduler() {
+	tick := time.Hour
+	if interval := watchCheckInterval(); interval < tick {
+		tick = interval
+	}
+
+	for {
+		checkDueWatches()
+		if !sleepUnlessStopping(tick) {
+			return
+		}
+	}
+}
+
+func checkDueWatches() {
+	var due []Watch
+	interval := watchCheckInterval()
+	store.View(func(d *storeData) {
+		for _, wt := range d.Watches {
+			if !wt.Removed && time.Since(wt.LastChecked) >= interval {
+				due = append(due, *wt)
+			}
+		}
+	})
+
+	// um watch que já tem verificação na fila não ganha outra (ver enqueueJob)
+	for _, wt := range due {
+		ctx, ok := tenantContext(serverContext, wt.Tenant)
+		if !ok {
+			continue // tenant removido de TENANTS_FILE
+		}
+		if _, err := enqueueJob(ctx, jobWatchCheck, wt.ID, ""); err != nil {
+			slog.Warn("Could not queue the watch check", "id", wt.ID, "error", err)
+		}
+	}
+}
+
+// runWatchCheck é o job de verificação de um watch
+func runWatchCheck(ctx context.Context, id string) error {
+	var wt Watch
+	var found bool
+	store.View(func(d *storeData) {
+		if stored, ok := d.Watches[id]; ok && !stored.Removed {
+			wt, found = *stored.clone(), true
+		}
+	})
+	if !found {
+		return nil // removido depois de entrar na fila
+	}
+	return checkWatch(ctx, wt)
+}
+
+// checkWatch raspa novamente o anúncio e dispara alertas quando o preço muda ou ele
+// some; uma falha do scraping volta como erro, para o job tentar de novo
+func checkWatch(ctx context.Context, wt Watch) error {
+	property, err := scraper.ScrapeDaftListing(ctx, wt.URL)
+	removed := errors.Is(err, scraper.ErrListingNotFound)
+	if err != nil && !removed {
+		return err
+	}
+
+	now := time.Now()
+	var alert *Alert
+	price := scraper.ExtractPriceValue(property.RentPrice)
+
+	switch {
+	case removed:
+		alert = &Alert{
+			Kind:    "listing_removed",
+			URL:     wt.URL,
+			Address: wt.Address,
+			Message: fmt.Sprintf("Listing at %s was removed from Daft.ie", wt.Address),
+		}
+	case price > 0 && price != wt.LastPrice:
+		alert = &Alert{
+			Kind:     "price_change",
+			URL:      wt.URL,
+			Address:  wt.Address,
+			Message:  fmt.Sprintf("Price for %s changed from €%.0f to €%.0f", wt.Address, wt.LastPrice, price),
+			OldPrice: wt.LastPrice,
+			NewPrice: price,
+		}
+	}
+
+	err = store.Update(func(d *storeData) error {
+		stored, ok := d.Watches[wt.ID]
+		if !ok {
+			return nil // removido enquanto verificávamos
+		}
+		stored.LastChecked = now
+		if removed {
+			stored.Removed = true
+		}
+		if price > 0 {
+			stored.PriceHistory = append(stored.PriceHistory, scraper.PricePoint{Date: now.Format("2006-01-02"), Price: price})
+			stored.LastPrice = price
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if alert != nil {
+		deliverAlert(*alert, wt.Notify)
+	}
+	return nil
+}
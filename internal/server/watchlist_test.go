@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+// listingServer serves a minimal listing page over TLS; canonical listing URLs are
+// always https
+func listingServer(t *testing.T, price string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><head><meta property="og:title" content="1 Main Street, Dublin 1 to share on Daft.ie">`+
+			`<meta property="og:description" content="%s per month, 2 Bed"></head></html>`, price)
+	}))
+	t.Cleanup(srv.Close)
+
+	prevTransport := http.DefaultTransport
+	http.DefaultTransport = srv.Client().Transport
+	t.Cleanup(func() { http.DefaultTransport = prevTransport })
+	t.Setenv("SCRAPE_DOMAINS", "127.0.0.1")
+	t.Setenv("CRAWL_BUDGET_PAGES", "10")
+	return srv
+}
+
+func TestHandleWatch(t *testing.T) {
+	prev := store
+	store = newMemoryStore()
+	t.Cleanup(func() { store = prev })
+	srv := listingServer(t, "€1,800")
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handleWatch(rec, httptest.NewRequest(http.MethodPost, "/watch", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := post(`{"url":"` + srv.URL + `/for-rent/x/123"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("watch without a notify target: status %d", rec.Code)
+	}
+
+	// a Telegram chat is a target on its own
+	rec := post(`{"url":"` + srv.URL + `/for-rent/x/123","notify":{"telegram":"42"}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST status %d: %s", rec.Code, rec.Body)
+	}
+	var created Watch
+	json.NewDecoder(rec.Body).Decode(&created)
+	if created.ID == "" || created.LastPrice != 1800 || len(created.PriceHistory) != 1 || created.Notify.Telegram != "42" {
+		t.Errorf("created watch = %+v", created)
+	}
+
+	rec = httptest.NewRecorder()
+	handleWatch(rec, httptest.NewRequest(http.MethodGet, "/watch", nil))
+	var listed []Watch
+	json.NewDecoder(rec.Body).Decode(&listed)
+	if len(listed) != 1 || listed[0].ID != created.ID {
+		t.Errorf("GET = %+v", listed)
+	}
+
+	rec = httptest.NewRecorder()
+	handleWatch(rec, httptest.NewRequest(http.MethodDelete, "/watch?id="+created.ID, nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE status %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handleWatch(rec, httptest.NewRequest(http.MethodDelete, "/watch?id="+created.ID, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("second DELETE status %d, want 404", rec.Code)
+	}
+	store.View(func(d *storeData) {
+		if len(d.Watches) != 0 {
+			t.Errorf("%d watches left after DELETE", len(d.Watches))
+		}
+	})
+}
+
+func TestHandleWatchListsCopies(t *testing.T) {
+	prev := store
+	store = newMemoryStore()
+	t.Cleanup(func() { store = prev })
+	store.Update(func(d *storeData) error {
+		d.Watches["w1"] = &Watch{ID: "w1", PriceHistory: []scraper.PricePoint{{Date: "2026-01-01", Price: 1800}}}
+		return nil
+	})
+
+	// the list is encoded while a check appends to the stored history; run with -race
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			store.Update(func(d *storeData) error {
+				d.Watches["w1"].PriceHistory = append(d.Watches["w1"].PriceHistory, scraper.PricePoint{Date: time.Now().Format("2006-01-02"), Price: 1700})
+				d.Watches["w1"].LastChecked = time.Now()
+				return nil
+			})
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		handleWatch(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/watch", nil))
+	}
+	<-done
+}
@@ -0,0 +1,5 @@
+// Package telemetry reúne os logs estruturados e os traces OTLP: o logger de cada
+// requisição fica no contexto (WithLogger, LogFor) e cada etapa da análise abre o seu
+// span (StartSpan), exportado pelo Tracer quando OTEL_EXPORTER_OTLP_ENDPOINT está
+// definido.
+package telemetry
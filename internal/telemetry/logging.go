@@ -0,0 +1,46 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+/* ───── Logs estruturados ───────────────────────────────────────────── */
+
+// logLevel lê LOG_LEVEL: debug, info (padrão), warn ou error
+func logLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(os.Getenv("LOG_LEVEL"))); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// NewLogger monta o logger do servidor: JSON por padrão, para agregadores de log, ou
+// texto legível com LOG_FORMAT=text
+func NewLogger(w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: logLevel()}
+	if strings.EqualFold(os.Getenv("LOG_FORMAT"), "text") {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+type loggerKey struct{}
+
+// WithLogger guarda no contexto o logger da requisição
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LogFor devolve o logger da requisição, com o request_id, ou o logger padrão fora
+// de uma requisição (jobs em segundo plano)
+func LogFor(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
@@ -0,0 +1,44 @@
+package telemetry
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// captureLogs sends the default logger to a buffer for the rest of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(NewLogger(&buf))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func TestLogLevel(t *testing.T) {
+	cases := map[string]slog.Level{
+		"":        slog.LevelInfo,
+		"debug":   slog.LevelDebug,
+		"WARN":    slog.LevelWarn,
+		"error":   slog.LevelError,
+		"verbose": slog.LevelInfo,
+	}
+	for value, want := range cases {
+		t.Setenv("LOG_LEVEL", value)
+		if got := logLevel(); got != want {
+			t.Errorf("LOG_LEVEL=%q gave %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestLogLevelFiltersDebug(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "warn")
+	logs := captureLogs(t)
+	slog.Info("Serving cached analysis")
+	slog.Warn("Prefetch failed")
+	if out := logs.String(); strings.Contains(out, "Serving cached") || !strings.Contains(out, "Prefetch failed") {
+		t.Errorf("LOG_LEVEL=warn wrote %q", out)
+	}
+}
@@ -0,0 +1,64 @@
+// Package telemetrytest ajuda os testes dos outros pacotes a conferir os spans
+// exportados pelo telemetry.Tracer.
+package telemetrytest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Coletor OTLP para os testes ─────────────────────────────────── */
+
+// Collector instala um tracer que exporta para um endpoint OTLP de teste e devolve
+// uma função que descarrega a fila e devolve os spans recebidos até ali
+func Collector(t *testing.T) func() []telemetry.OTLPSpan {
+	t.Helper()
+	var mu sync.Mutex
+	var received []telemetry.OTLPSpan
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []telemetry.OTLPSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid OTLP body: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rs := range body.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				received = append(received, ss.Spans...)
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	prev := telemetry.Tracer
+	telemetry.Tracer = telemetry.NewSpanExporter(srv.URL+"/v1/traces", "test")
+	t.Cleanup(func() { telemetry.Tracer = prev })
+	exporter := telemetry.Tracer
+	return func() []telemetry.OTLPSpan {
+		exporter.Flush()
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]telemetry.OTLPSpan(nil), received...)
+	}
+}
+
+// SpanNamed acha o span pelo nome; nil se não houver
+func SpanNamed(spans []telemetry.OTLPSpan, name string) *telemetry.OTLPSpan {
+	for i := range spans {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+	return nil
+}
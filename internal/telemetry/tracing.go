@@ -0,0 +1,319 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* ───── Tracing (OpenTelemetry via OTLP/HTTP) ───────────────────────── */
+
+// Cada etapa da análise (scraping, geocodificação, cada busca de lugares, CSO,
+// Overpass, cada portal de comparáveis) vira um span, e cada chamada HTTP a uma fonte
+// externa um span filho, para que uma análise lenta aponte a fonte responsável. Os
+// spans vão em JSON para o coletor OTLP de OTEL_EXPORTER_OTLP_ENDPOINT (ou
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, já com o caminho); sem ele nada é registrado.
+
+// tipos de span do OTLP
+const (
+	SpanInternal = 1
+	SpanServer   = 2
+	SpanClient   = 3
+)
+
+// Span é uma operação em andamento; nil quando o tracing está desligado, e então
+// todos os métodos não fazem nada. Seguro para uso concorrente.
+type Span struct {
+	exporter *SpanExporter
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	kind     int
+	start    time.Time
+
+	mu    sync.Mutex
+	attrs []otlpKeyValue
+	err   string
+}
+
+type spanKey struct{}
+
+// SpanFrom devolve o span corrente do contexto, ou nil
+func SpanFrom(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// WithSpan põe s como o span corrente de ctx, para o trabalho que continua um span
+// aberto em outro contexto
+func WithSpan(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// WithRemoteParent continua em ctx o trace do traceparent recebido; com um
+// traceparent inválido ou vazio, ctx volta como está
+func WithRemoteParent(ctx context.Context, traceparent string) context.Context {
+	if traceID, spanID, ok := parseTraceparent(traceparent); ok {
+		return WithSpan(ctx, &Span{traceID: traceID, spanID: spanID})
+	}
+	return ctx
+}
+
+// StartSpan abre um span filho do span de ctx (ou a raiz de um trace novo) e o põe
+// no contexto devolvido; attrs são pares chave, valor. Feche com end.
+func StartSpan(ctx context.Context, name string, kind int, attrs ...interface{}) (context.Context, *Span) {
+	if Tracer == nil {
+		return ctx, nil
+	}
+	s := &Span{exporter: Tracer, name: name, kind: kind, start: time.Now()}
+	if parent := SpanFrom(ctx); parent != nil {
+		s.traceID, s.parentID = parent.traceID, parent.spanID
+	} else {
+		rand.Read(s.traceID[:])
+	}
+	rand.Read(s.spanID[:])
+	s.Set(attrs...)
+	return WithSpan(ctx, s), s
+}
+
+// Set acrescenta atributos (pares chave, valor)
+func (s *Span) Set(attrs ...interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i+1 < len(attrs); i += 2 {
+		key, _ := attrs[i].(string)
+		s.attrs = append(s.attrs, otlpKeyValue{Key: key, Value: otlpValue(attrs[i+1])})
+	}
+}
+
+// End fecha o span, marcando-o como erro se err não for nil, e o entrega ao exporter
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if err != nil {
+		s.err = err.Error()
+	}
+	record := OTLPSpan{
+		TraceID:           hex.EncodeToString(s.traceID[:]),
+		SpanID:            hex.EncodeToString(s.spanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: fmt.Sprint(s.start.UnixNano()),
+		EndTimeUnixNano:   fmt.Sprint(time.Now().UnixNano()),
+		Attributes:        s.attrs,
+	}
+	if s.parentID != [8]byte{} {
+		record.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+	if s.err != "" {
+		record.Status = &otlpStatus{Code: 2, Message: s.err}
+	}
+	s.mu.Unlock()
+	s.exporter.export(record)
+}
+
+// TraceIDString é o trace ID em hexadecimal, como vai nos logs
+func (s *Span) TraceIDString() string {
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// Traceparent é o cabeçalho W3C que leva o trace para a próxima chamada
+func (s *Span) Traceparent() string {
+	return "00-" + hex.EncodeToString(s.traceID[:]) + "-" + hex.EncodeToString(s.spanID[:]) + "-01"
+}
+
+// parseTraceparent lê o trace de quem chamou (um proxy, outra instância via
+// UPSTREAM_URL) para que os spans daqui entrem no mesmo trace
+func parseTraceparent(value string) (traceID [16]byte, spanID [8]byte, ok bool) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return traceID, spanID, false
+	}
+	if _, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil || traceID == [16]byte{} {
+		return traceID, spanID, false
+	}
+	if _, err := hex.Decode(spanID[:], []byte(parts[2])); err != nil || spanID == [8]byte{} {
+		return traceID, spanID, false
+	}
+	return traceID, spanID, true
+}
+
+/* ───── Exportação OTLP ─────────────────────────────────────────────── */
+
+type otlpKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type OTLPSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+// otlpValue converte um valor Go no AnyValue do OTLP/JSON (inteiros vão como string)
+func otlpValue(v interface{}) map[string]interface{} {
+	switch v := v.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": v}
+	case int:
+		return map[string]interface{}{"intValue": fmt.Sprint(v)}
+	case int64:
+		return map[string]interface{}{"intValue": fmt.Sprint(v)}
+	case float64:
+		return map[string]interface{}{"doubleValue": v}
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	default:
+		return map[string]interface{}{"stringValue": fmt.Sprint(v)}
+	}
+}
+
+// spanBatchSize é quantos spans vão por envio; spanQueueSize, quantos esperam na fila
+// antes de começarem a ser descartados (o coletor fora do ar não pode segurar a API)
+const (
+	spanBatchSize = 512
+	spanQueueSize = 4096
+	spanFlushTick = 5 * time.Second
+)
+
+// SpanExporter junta os spans terminados e os envia em lotes ao coletor
+type SpanExporter struct {
+	endpoint string
+	service  string
+	client   *http.Client
+	queue    chan OTLPSpan
+	flushReq chan chan struct{}
+}
+
+// Tracer é o exporter do processo; nil com o tracing desligado. Recriado em main()
+// depois do .env carregado.
+var Tracer = NewTracerFromEnv()
+
+// tracesEndpoint segue as variáveis padrão do OpenTelemetry
+func tracesEndpoint() string {
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		return strings.TrimRight(endpoint, "/") + "/v1/traces"
+	}
+	return ""
+}
+
+func NewTracerFromEnv() *SpanExporter {
+	endpoint := tracesEndpoint()
+	if endpoint == "" {
+		return nil
+	}
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "daft-scraper-api"
+	}
+	return NewSpanExporter(endpoint, service)
+}
+
+func NewSpanExporter(endpoint, service string) *SpanExporter {
+	e := &SpanExporter{
+		endpoint: endpoint,
+		service:  service,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		queue:    make(chan OTLPSpan, spanQueueSize),
+		flushReq: make(chan chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// export enfileira o span sem bloquear; com a fila cheia ele é descartado
+func (e *SpanExporter) export(s OTLPSpan) {
+	select {
+	case e.queue <- s:
+	default:
+	}
+}
+
+// Flush envia na hora o que estiver na fila e espera o envio terminar
+func (e *SpanExporter) Flush() {
+	done := make(chan struct{})
+	e.flushReq <- done
+	<-done
+}
+
+func (e *SpanExporter) run() {
+	ticker := time.NewTicker(spanFlushTick)
+	defer ticker.Stop()
+	var batch []OTLPSpan
+	for {
+		select {
+		case s := <-e.queue:
+			if batch = append(batch, s); len(batch) >= spanBatchSize {
+				e.send(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			e.send(batch)
+			batch = nil
+		case done := <-e.flushReq:
+			for len(e.queue) > 0 {
+				batch = append(batch, <-e.queue)
+			}
+			e.send(batch)
+			batch = nil
+			close(done)
+		}
+	}
+}
+
+// send faz o POST de um lote no formato OTLP/JSON; falhas só vão para o log
+func (e *SpanExporter) send(batch []OTLPSpan) {
+	if len(batch) == 0 {
+		return
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": []otlpKeyValue{{Key: "service.name", Value: otlpValue(e.service)}},
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{"name": "daft-scraper-api"},
+				"spans": batch,
+			}},
+		}},
+	})
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		slog.Warn("Could not export traces", "endpoint", e.endpoint, "spans", len(batch), "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Warn("Could not export traces", "endpoint", e.endpoint, "spans", len(batch), "status", resp.StatusCode)
+	}
+}
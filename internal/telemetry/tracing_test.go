@@ -0,0 +1,29 @@
+package telemetry
+
+import "testing"
+
+func TestTracesEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/")
+	if got := tracesEndpoint(); got != "http://collector:4318/v1/traces" {
+		t.Errorf("tracesEndpoint() = %q", got)
+	}
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://collector:4318/custom")
+	if got := tracesEndpoint(); got != "http://collector:4318/custom" {
+		t.Errorf("tracesEndpoint() = %q", got)
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	for value, ok := range map[string]bool{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01": true,
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01": false,
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01": false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7":    false,
+		"00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01": false,
+		"": false,
+	} {
+		if _, _, got := parseTraceparent(value); got != ok {
+			t.Errorf("parseTraceparent(%q) ok = %v, want %v", value, got, ok)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package value
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Acessibilidade: aluguel contra a renda do cliente ───────────── */
+
+// Com a renda líquida mensal do cliente (monthlyNetIncome, opcional), dizemos quanto
+// dela o aluguel come e quanto seria preciso ganhar para ficar abaixo de 30% e de 35%,
+// as referências usuais na Irlanda. Como o destino do trajeto, a renda é de quem
+// pergunta e o cálculo roda por requisição, depois do cache.
+
+// IncomeFromRequest lê ?monthlyNetIncome= (vale mais que o corpo); 0 quando não veio
+func IncomeFromRequest(r *http.Request, body float64) (float64, error) {
+	if raw := r.URL.Query().Get("monthlyNetIncome"); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return 0, fmt.Errorf("monthlyNetIncome must be a positive number")
+		}
+		body = v
+	}
+	if body < 0 || math.IsNaN(body) || math.IsInf(body, 0) {
+		return 0, fmt.Errorf("monthlyNetIncome must be a positive number")
+	}
+	return body, nil
+}
+
+// ApplyAffordability preenche Affordability; sem renda ou sem preço não faz nada
+func (v *Analyzer) ApplyAffordability(property *scraper.PropertyInfo, income float64) {
+	if income <= 0 {
+		return
+	}
+	var payment float64
+	switch {
+	case property.ListingType == "sale":
+		if price := scraper.ExtractPriceValue(property.RentPrice); price > 0 {
+			payment = math.Round(monthlyMortgagePayment(price, v.Rate()))
+		}
+	case property.Price != nil:
+		payment = property.Price.Monthly
+	}
+	if payment == 0 {
+		return
+	}
+
+	ratio := payment / income * 100
+	rating := "red"
+	switch {
+	case ratio <= 30:
+		rating = "green"
+	case ratio <= 35:
+		rating = "amber"
+	}
+	property.Affordability = &scraper.Affordability{
+		MonthlyNetIncome: income,
+		MonthlyPayment:   payment,
+		RentToIncome:     math.Round(ratio*10) / 10,
+		Rating:           rating,
+		IncomeFor30:      math.Ceil(payment * 100 / 30),
+		IncomeFor35:      math.Ceil(payment * 100 / 35),
+	}
+}
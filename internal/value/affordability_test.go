@@ -0,0 +1,71 @@
+package value
+
+import (
+	"math"
+	"net/http/httptest"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestApplyAffordability(t *testing.T) {
+	cases := []struct {
+		rent   float64
+		rating string
+	}{
+		{1500, "green"},
+		{1700, "amber"},
+		{2200, "red"},
+	}
+	for _, c := range cases {
+		p := scraper.PropertyInfo{ListingType: "rent", Price: &scraper.Price{Amount: c.rent, Period: "month", Monthly: c.rent}}
+		(&Analyzer{}).ApplyAffordability(&p, 5000)
+		if p.Affordability == nil || p.Affordability.Rating != c.rating {
+			t.Errorf("€%.0f on €5000: %+v", c.rent, p.Affordability)
+		}
+	}
+
+	p := scraper.PropertyInfo{ListingType: "rent", Price: &scraper.Price{Amount: 1500, Period: "month", Monthly: 1500}}
+	(&Analyzer{}).ApplyAffordability(&p, 4000)
+	a := p.Affordability
+	if a.RentToIncome != 37.5 || a.IncomeFor30 != 5000 || a.IncomeFor35 != 4286 {
+		t.Errorf("affordability = %+v", a)
+	}
+
+	// no income, or no price, means no block
+	p = scraper.PropertyInfo{ListingType: "rent", Price: &scraper.Price{Monthly: 1500}}
+	(&Analyzer{}).ApplyAffordability(&p, 0)
+	if p.Affordability != nil {
+		t.Error("no income should leave affordability out")
+	}
+	p = scraper.PropertyInfo{ListingType: "rent"}
+	(&Analyzer{}).ApplyAffordability(&p, 4000)
+	if p.Affordability != nil {
+		t.Error("no price should leave affordability out")
+	}
+
+	// a sale uses the mortgage payment at the analyzer's rate
+	sale := func(rate float64) float64 {
+		p := scraper.PropertyInfo{ListingType: "sale", RentPrice: "€400,000"}
+		(&Analyzer{MortgageRate: rate}).ApplyAffordability(&p, 6000)
+		return p.Affordability.MonthlyPayment
+	}
+	if low, high := sale(0), sale(6); low != math.Round(monthlyMortgagePayment(400000, DefaultMortgageRate)) || high <= low {
+		t.Errorf("sale payments = %v at the default rate, %v at 6%%", low, high)
+	}
+}
+
+func TestIncomeFromRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/analyze?monthlyNetIncome=4200", nil)
+	if income, err := IncomeFromRequest(r, 3000); err != nil || income != 4200 {
+		t.Errorf("got %v, %v", income, err)
+	}
+	if income, err := IncomeFromRequest(httptest.NewRequest("GET", "/analyze", nil), 3000); err != nil || income != 3000 {
+		t.Errorf("body income: got %v, %v", income, err)
+	}
+	for _, raw := range []string{"lots", "-100"} {
+		if _, err := IncomeFromRequest(httptest.NewRequest("GET", "/analyze?monthlyNetIncome="+raw, nil), 0); err == nil {
+			t.Errorf("%s: expected an error", raw)
+		}
+	}
+}
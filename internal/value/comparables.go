@@ -0,0 +1,297 @@
+package value
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+
+	"github.com/gocolly/colly/v2"
+)
+
+/* ───── Registro de portais de comparáveis ──────────────────────────── */
+
+// comparablesSource é um portal de onde extraímos imóveis comparáveis
+type comparablesSource struct {
+	Name  string
+	Fetch func(ctx context.Context, property *scraper.PropertyInfo, minPrice, maxPrice float64) ([]scraper.SimilarProperty, error)
+}
+
+// comparablesSources lista os portais consultados (em paralelo) por findSimilarProperties
+var comparablesSources = []comparablesSource{
+	{Name: "daft.ie", Fetch: fetchDaftComparables},
+	{Name: "rent.ie", Fetch: fetchRentIeComparables},
+	{Name: "myhome.ie", Fetch: fetchMyHomeComparables},
+}
+
+// maxComparables limita o total de comparáveis após a deduplicação
+const maxComparables = 15
+
+// collectComparables consulta todos os portais registrados simultaneamente e
+// devolve os resultados intercalados por portal, já deduplicados por endereço
+func collectComparables(ctx context.Context, property *scraper.PropertyInfo, minPrice, maxPrice float64) []scraper.SimilarProperty {
+	ctx = scraper.WithCrawlBudget(ctx)
+	results := make([][]scraper.SimilarProperty, len(comparablesSources))
+
+	var wg sync.WaitGroup
+	for i, src := range comparablesSources {
+		wg.Add(1)
+		go func(i int, src comparablesSource) {
+			defer wg.Done()
+			if !scraper.TakeCrawlPage(ctx) {
+				telemetry.LogFor(ctx).Warn("Crawl budget exhausted, skipping comparables source", "source", src.Name)
+				return
+			}
+			ctx, s := telemetry.StartSpan(ctx, "comparables "+src.Name, telemetry.SpanInternal)
+			found, err := src.Fetch(ctx, property, minPrice, maxPrice)
+			s.Set("comparables.results", len(found))
+			s.End(err)
+			if err != nil {
+				telemetry.LogFor(ctx).Warn("Comparables source failed", "source", src.Name, "error", err)
+				return
+			}
+			for j := range found {
+				found[j].Source = src.Name
+			}
+			telemetry.LogFor(ctx).Debug("Comparables found", "source", src.Name, "count", len(found))
+			results[i] = found
+		}(i, src)
+	}
+	wg.Wait()
+
+	// intercala os portais para que nenhum domine a média da área
+	var merged []scraper.SimilarProperty
+	for pos := 0; ; pos++ {
+		added := false
+		for _, r := range results {
+			if pos < len(r) {
+				merged = append(merged, r[pos])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+
+	merged = dedupeComparables(merged)
+	if len(merged) > maxComparables {
+		merged = merged[:maxComparables]
+	}
+	return merged
+}
+
+// dedupeComparables remove anúncios repetidos (o mesmo imóvel anunciado em mais de um portal)
+func dedupeComparables(similar []scraper.SimilarProperty) []scraper.SimilarProperty {
+	seen := make(map[string]bool, len(similar))
+	out := make([]scraper.SimilarProperty, 0, len(similar))
+	for _, s := range similar {
+		key := scraper.AddressKey(s.Address)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, s)
+	}
+	return out
+}
+
+// NewComparable monta o comparável com o preço normalizado para o mês. Os portais
+// anunciam aluguel por mês, então um preço sem período conta como mensal.
+func NewComparable(address, url string, price float64, period string) scraper.SimilarProperty {
+	if period == "" {
+		period = "month"
+	}
+	return scraper.SimilarProperty{
+		Address:       address,
+		Price:         scraper.MonthlyAmount(price, period),
+		URL:           url,
+		OriginalPrice: price,
+		Period:        period,
+	}
+}
+
+// newComparablesCollector cria o collector padrão usado pelos portais, preso a ctx
+func newComparablesCollector(ctx context.Context, domains ...string) *colly.Collector {
+	c := colly.NewCollector(
+		colly.AllowedDomains(domains...),
+		colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+	)
+	scraper.BindCollector(ctx, c)
+	scraper.DebugCollector(ctx, c)
+	scraper.LimitCrawl(c)
+
+	c.OnRequest(func(r *colly.Request) {
+		r.Headers.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
+		r.Headers.Set("Accept-Language", "en-US,en;q=0.5")
+		r.Headers.Set("DNT", "1")
+		telemetry.LogFor(ctx).Debug("Fetching comparables", "url", r.URL.String())
+	})
+
+	c.OnResponse(func(r *colly.Response) {
+		scraper.SaveDebugPage(ctx, "comparables "+r.Request.URL.Hostname(), r)
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		telemetry.LogFor(ctx).Warn("Comparables request failed", "url", r.Request.URL.String(), "status", r.StatusCode, "error", err)
+	})
+
+	return c
+}
+
+/* ───── Daft.ie ─────────────────────────────────────────────────────── */
+
+func fetchDaftComparables(ctx context.Context, property *scraper.PropertyInfo, minPrice, maxPrice float64) ([]scraper.SimilarProperty, error) {
+	suburb, county := scraper.SplitLocation(property.Address)
+	searchURL := fmt.Sprintf(
+		"https://www.daft.ie/sharing/%s-%s?rentalPrice_from=%.0f&rentalPrice_to=%.0f",
+		scraper.Slugify(suburb), scraper.Slugify(county), minPrice, maxPrice)
+
+	var similar []scraper.SimilarProperty
+	c := newComparablesCollector(ctx, "www.daft.ie", "daft.ie")
+
+	c.OnHTML("script#__NEXT_DATA__", func(e *colly.HTMLElement) {
+		type nextData struct {
+			Props struct {
+				PageProps struct {
+					Adverts []struct {
+						DisplayAddress string `json:"displayAddress"`
+						Price          struct {
+							Monthly int `json:"monthly"`
+							Weekly  int `json:"weekly"`
+						} `json:"price"`
+						AdPath string `json:"adPath"`
+					} `json:"adverts"`
+				} `json:"pageProps"`
+			} `json:"props"`
+		}
+
+		var data nextData
+		if err := json.Unmarshal([]byte(e.Text), &data); err != nil {
+			telemetry.LogFor(ctx).Warn("Could not decode __NEXT_DATA__", "error", err)
+			return
+		}
+
+		for _, ad := range data.Props.PageProps.Adverts {
+			price, period := ad.Price.Monthly, "month"
+			if price == 0 {
+				price, period = ad.Price.Weekly, "week"
+			}
+			if price == 0 {
+				continue
+			}
+
+			similar = append(similar, NewComparable(ad.DisplayAddress, "https://www.daft.ie"+ad.AdPath, float64(price), period))
+		}
+	})
+
+	// fallback simples caso JSON falhe
+	c.OnHTML("li[data-testid^='result-']", func(e *colly.HTMLElement) {
+		href := e.ChildAttr("a[href^='/share/']", "href")
+		if href == "" {
+			return
+		}
+
+		address := strings.TrimSpace(e.ChildText("div[data-tracking='srp_address'] p"))
+		if address == "" {
+			return
+		}
+
+		// Preço (ex.: "€650 per month")
+		priceText := strings.TrimSpace(e.ChildText("div[data-tracking='srp_price'] p"))
+		price := scraper.ExtractPriceValue(priceText)
+		if price == 0 {
+			return
+		}
+
+		similar = append(similar, NewComparable(address, "https://www.daft.ie"+href, price, scraper.PricePeriod(priceText)))
+	})
+
+	if err := c.Visit(searchURL); err != nil {
+		return nil, fmt.Errorf("error visiting search page: %w", err)
+	}
+	return similar, nil
+}
+
+/* ───── Rent.ie ─────────────────────────────────────────────────────── */
+
+func fetchRentIeComparables(ctx context.Context, property *scraper.PropertyInfo, minPrice, maxPrice float64) ([]scraper.SimilarProperty, error) {
+	suburb, county := scraper.SplitLocation(property.Address)
+
+	// ex.: https://www.rent.ie/rooms-to-rent/renting_dublin/rathmines/?min_price=500&max_price=800
+	section := "houses-to-let"
+	if strings.Contains(property.URL, "/share/") {
+		section = "rooms-to-rent"
+	}
+	searchURL := fmt.Sprintf("https://www.rent.ie/%s/renting_%s/", section, scraper.Slugify(county))
+	if suburb != "" {
+		searchURL += scraper.Slugify(suburb) + "/"
+	}
+	searchURL += fmt.Sprintf("?min_price=%.0f&max_price=%.0f", minPrice, maxPrice)
+
+	var similar []scraper.SimilarProperty
+	c := newComparablesCollector(ctx, "www.rent.ie", "rent.ie")
+
+	c.OnHTML("div.search_result", func(e *colly.HTMLElement) {
+		href := e.ChildAttr("div.search_result_title_box h2 a", "href")
+		address := strings.TrimSpace(e.ChildText("div.search_result_title_box h2 a"))
+		if href == "" || address == "" {
+			return
+		}
+
+		// Preço (ex.: "€1,950 monthly", "€180 weekly")
+		priceText := e.ChildText("div.search_result_title_box h4")
+		price := scraper.ExtractPriceValue(priceText)
+		if price == 0 {
+			return
+		}
+
+		similar = append(similar, NewComparable(address, e.Request.AbsoluteURL(href), price, scraper.PricePeriod(priceText)))
+	})
+
+	if err := c.Visit(searchURL); err != nil {
+		return nil, fmt.Errorf("error visiting rent.ie search page: %w", err)
+	}
+	return similar, nil
+}
+
+/* ───── MyHome.ie ───────────────────────────────────────────────────── */
+
+func fetchMyHomeComparables(ctx context.Context, property *scraper.PropertyInfo, minPrice, maxPrice float64) ([]scraper.SimilarProperty, error) {
+	suburb, county := scraper.SplitLocation(property.Address)
+
+	// ex.: https://www.myhome.ie/rentals/dublin/property-to-rent-in-rathmines?minprice=500&maxprice=800
+	searchURL := fmt.Sprintf("https://www.myhome.ie/rentals/%s/property-to-rent", scraper.Slugify(county))
+	if suburb != "" {
+		searchURL += "-in-" + scraper.Slugify(suburb)
+	}
+	searchURL += fmt.Sprintf("?minprice=%.0f&maxprice=%.0f", minPrice, maxPrice)
+
+	var similar []scraper.SimilarProperty
+	c := newComparablesCollector(ctx, "www.myhome.ie", "myhome.ie")
+
+	c.OnHTML("div[class*='PropertyListingCard']", func(e *colly.HTMLElement) {
+		href := e.ChildAttr("a[class*='PropertyListingCard__Address']", "href")
+		address := strings.TrimSpace(e.ChildText("a[class*='PropertyListingCard__Address']"))
+		if href == "" || address == "" {
+			return
+		}
+
+		priceText := e.ChildText("[class*='PropertyListingCard__Price']")
+		price := scraper.ExtractPriceValue(priceText)
+		if price == 0 {
+			return
+		}
+
+		similar = append(similar, NewComparable(address, e.Request.AbsoluteURL(href), price, scraper.PricePeriod(priceText)))
+	})
+
+	if err := c.Visit(searchURL); err != nil {
+		return nil, fmt.Errorf("error visiting myhome.ie search page: %w", err)
+	}
+	return similar, nil
+}
@@ -0,0 +1,54 @@
+package value
+
+import (
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestDedupeComparables(t *testing.T) {
+	similar := []scraper.SimilarProperty{
+		{Address: "Apt 4, Main St., Rathmines, Co. Dublin", Price: 1200, Source: "daft.ie"},
+		{Address: "apt 4 main st rathmines dublin", Price: 1250, Source: "rent.ie"},
+		{Address: "12 Harold's Cross Road, Dublin 6W", Price: 1100, Source: "myhome.ie"},
+		{Address: "", Price: 900, Source: "rent.ie"},
+	}
+
+	got := dedupeComparables(similar)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 comparables, got %d: %+v", len(got), got)
+	}
+	if got[0].Source != "daft.ie" {
+		t.Errorf("expected first occurrence to win, got source %q", got[0].Source)
+	}
+}
+
+func TestNewComparableNormalizesToMonthly(t *testing.T) {
+	weekly := NewComparable("1 Main St", "", 300, "week")
+	if weekly.Price != 1300 || weekly.OriginalPrice != 300 || weekly.Period != "week" {
+		t.Errorf("weekly comparable = %+v", weekly)
+	}
+	unknown := NewComparable("2 Main St", "", 1500, "")
+	if unknown.Price != 1500 || unknown.Period != "month" {
+		t.Errorf("a price without a period should count as monthly: %+v", unknown)
+	}
+}
+
+func TestValueAnalysisUsesMonthlyPrices(t *testing.T) {
+	// a €300 per week room against monthly comparables averaging €1,300
+	p := scraper.PropertyInfo{RentPrice: "€300"}
+	scraper.FillNumericFields(&p, "week")
+	p.ValueAnalysis.Similar = []scraper.SimilarProperty{
+		NewComparable("1 Main St", "", 1200, "month"),
+		NewComparable("2 Main St", "", 1400, "month"),
+		NewComparable("3 Main St", "", 300, "week"),
+	}
+	CalculateAreaAveragePrice(&p)
+	CalculatePriceRating(&p)
+	if p.ValueAnalysis.AreaAveragePrice != 1300 {
+		t.Errorf("area average = %v, want 1300", p.ValueAnalysis.AreaAveragePrice)
+	}
+	if p.ValueAnalysis.PriceRating != 6 {
+		t.Errorf("price rating = %d, want 6 (at the area average)", p.ValueAnalysis.PriceRating)
+	}
+}
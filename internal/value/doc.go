@@ -0,0 +1,4 @@
+// Package value faz a análise de valor do anúncio: os comparáveis dos portais, o
+// preço por m², os custos mensais, a prestação estimada, os argumentos para negociar
+// e as bolsas SEAI. O Analyzer daqui é o campo Value de enrich.Analyzer.
+package value
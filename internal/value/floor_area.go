@@ -0,0 +1,106 @@
+package value
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+// floorAreaPattern casa "85 m²", "85sqm", "85 sq. m", "915 sq ft", "85 square metres"
+var floorAreaPattern = regexp.MustCompile(`(?i)(\d{2,4}(?:[.,]\d+)?)\s*(m²|m2|sq\.?\s*m(?:etres|eters)?\b|sqm|square\s*met(?:re|er)s?|sq\.?\s*ft|sqft|square\s*f(?:ee|oo)t)`)
+
+// ParseFloorArea procura a área declarada num texto livre, convertendo pés² para m²
+func ParseFloorArea(text string) float64 {
+	for _, m := range floorAreaPattern.FindAllStringSubmatch(text, -1) {
+		value, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", "."), 64)
+		if err != nil {
+			continue
+		}
+		unit := strings.ToLower(m[2])
+		if strings.Contains(unit, "f") {
+			value *= scraper.SqFtToSqM
+		}
+		// descarta medidas de cômodos e valores absurdos para um imóvel inteiro
+		if value >= 15 && value <= 1000 {
+			return math.Round(value*10) / 10
+		}
+	}
+	return 0
+}
+
+// resolveFloorArea preenche property.FloorArea: dado estruturado > descrição > OCR da planta
+func (v *Analyzer) resolveFloorArea(ctx context.Context, property *scraper.PropertyInfo) {
+	if property.FloorArea != nil {
+		return
+	}
+	if area := ParseFloorArea(property.Description); area > 0 {
+		property.FloorArea = &scraper.FloorArea{SquareMeters: area, Source: "description"}
+		return
+	}
+	for _, planURL := range property.FloorPlans {
+		text, err := v.ocrImage(ctx, planURL)
+		if err != nil {
+			telemetry.LogFor(ctx).Warn("Floor plan OCR failed", "plan", planURL, "error", err)
+			return // OCR indisponível; não adianta tentar as outras plantas
+		}
+		if area := ParseFloorArea(text); area > 0 {
+			property.FloorArea = &scraper.FloorArea{SquareMeters: area, Source: "floorplan_ocr"}
+			return
+		}
+	}
+}
+
+// maxPlanBytes limita o download de cada planta
+const maxPlanBytes = 5 << 20
+
+// ocrImage baixa a imagem e a passa pelo tesseract do Analyzer (TESSERACT_PATH ou
+// "tesseract" no PATH)
+func (v *Analyzer) ocrImage(ctx context.Context, imageURL string) (string, error) {
+	if _, err := exec.LookPath(v.Tesseract); err != nil {
+		return "", fmt.Errorf("tesseract not available: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("floorplan returned status code: %d", resp.StatusCode)
+	}
+
+	tmp, err := os.CreateTemp("", "floorplan-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, io.LimitReader(resp.Body, maxPlanBytes)); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	tmp.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, v.Tesseract, tmp.Name(), "stdout").Output()
+	if err != nil {
+		return "", fmt.Errorf("error running tesseract: %w", err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,121 @@
+package value
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Custos de morar: energia, lixo, internet e condomínio ───────── */
+
+// O aluguel do anúncio não é o que se gasta para morar. LivingCosts estima o resto
+// do mês: energia pela faixa BER e pela área, coleta de lixo, internet e, na venda,
+// a taxa de condomínio declarada. O occupancyCost da análise de valor soma tudo ao
+// aluguel (ou à prestação). Quartos em casa compartilhada ficam de fora: as contas
+// costumam ser divididas de um jeito que o anúncio não diz.
+
+const (
+	// o BER supõe a casa toda aquecida o dia inteiro; o consumo real fica bem abaixo
+	berUsageFactor   = 0.6
+	energyStanding   = 30.0 // taxas fixas de luz e gás por mês
+	binsMonthly      = 30.0 // coleta por peso, três lixeiras
+	broadbandMonthly = 45.0
+	defaultBER       = "D1" // o estoque típico de aluguel quando o anúncio não diz
+
+	DefaultEnergyPrice = 0.20 // €/kWh
+)
+
+// energyPrice é o preço do kWh deste Analyzer
+func (v *Analyzer) energyPrice() float64 {
+	if v.EnergyPrice > 0 {
+		return v.EnergyPrice
+	}
+	return DefaultEnergyPrice
+}
+
+// billsIncludedWords casam "bills included", "utilities included", "all bills inc."
+var billsIncludedWords = []string{"bills included", "bills inc", "utilities included", "all bills"}
+
+// estimateLivingCosts monta LivingCosts; nil em casa compartilhada
+func (v *Analyzer) estimateLivingCosts(property *scraper.PropertyInfo) *scraper.LivingCosts {
+	if property.ListingType == "share" {
+		return nil
+	}
+	c := &scraper.LivingCosts{Basis: []string{}}
+
+	description := strings.ToLower(property.Description)
+	included := false
+	for _, w := range billsIncludedWords {
+		included = included || strings.Contains(description, w)
+	}
+	if included {
+		c.Basis = append(c.Basis, "The listing says bills are included")
+	} else {
+		area, areaBasis := livingArea(property)
+		ber := strings.ToUpper(strings.TrimSpace(property.BER))
+		use, ok := scraper.BEREnergyUse[ber]
+		berBasis := "BER " + ber
+		if property.BERSource == "badge" || property.BERSource == "description" {
+			berBasis += " (from the listing " + property.BERSource + ")"
+		}
+		if !ok {
+			use, berBasis = scraper.BEREnergyUse[defaultBER], "BER unknown, assumed "+defaultBER
+		}
+		price := v.energyPrice()
+		c.Energy = math.Round(area*use*berUsageFactor*price/12 + energyStanding)
+		c.Basis = append(c.Basis, fmt.Sprintf("Energy: %s, %s, €%.2f/kWh plus standing charges", berBasis, areaBasis, price))
+
+		// nos apartamentos a coleta costuma estar na taxa de condomínio
+		if !IsApartment(property) {
+			c.Bins = binsMonthly
+			c.Basis = append(c.Basis, "Bins: pay-by-weight collection")
+		}
+		c.Broadband = broadbandMonthly
+		c.Basis = append(c.Basis, "Broadband: typical fibre plan")
+	}
+
+	// no aluguel a taxa de condomínio é do proprietário
+	if property.ListingType == "sale" && property.ServiceCharge != nil {
+		c.ManagementFee = property.ServiceCharge.Monthly
+		c.Basis = append(c.Basis, "Management fee: "+property.ServiceCharge.Stated)
+	}
+	c.Total = math.Round(c.Energy + c.Bins + c.Broadband + c.ManagementFee)
+	return c
+}
+
+// livingArea é a área útil do imóvel ou, sem ela, uma estimativa pelos quartos
+func livingArea(property *scraper.PropertyInfo) (float64, string) {
+	if property.FloorArea != nil && property.FloorArea.SquareMeters > 0 {
+		return property.FloorArea.SquareMeters, fmt.Sprintf("%.0f m²", property.FloorArea.SquareMeters)
+	}
+	bedrooms := 2
+	if property.BedroomsCount != nil && *property.BedroomsCount > 0 {
+		bedrooms = *property.BedroomsCount
+	}
+	area := float64(35 + 20*bedrooms)
+	return area, fmt.Sprintf("about %.0f m² for %d bedrooms", area, bedrooms)
+}
+
+// occupancyCost é o custo total de morar por mês: o aluguel (ou a prestação à taxa rate) mais os
+// custos de LivingCosts; 0 sem preço ou sem a estimativa
+func occupancyCost(property *scraper.PropertyInfo, rate float64) float64 {
+	living := property.ValueAnalysis.LivingCosts
+	if living == nil {
+		return 0
+	}
+	var housing float64
+	switch {
+	case property.ListingType == "sale":
+		if price := scraper.ExtractPriceValue(property.RentPrice); price > 0 {
+			housing = monthlyMortgagePayment(price, rate)
+		}
+	case property.Price != nil:
+		housing = property.Price.Monthly
+	}
+	if housing == 0 {
+		return 0
+	}
+	return math.Round(housing + living.Total)
+}
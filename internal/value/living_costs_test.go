@@ -0,0 +1,65 @@
+package value
+
+import (
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestEstimateLivingCostsHouse(t *testing.T) {
+	p := scraper.PropertyInfo{
+		ListingType:  "rent",
+		PropertyType: "House",
+		BER:          "C1",
+		FloorArea:    &scraper.FloorArea{SquareMeters: 85, Source: "listing"},
+		Price:        &scraper.Price{Amount: 2000, Period: "month", Monthly: 2000},
+	}
+	c := (&Analyzer{}).estimateLivingCosts(&p)
+	// 85 m² × 162.5 kWh × 0.6 × €0.20 / 12 + €30 standing charges
+	if c.Energy != 168 || c.Bins != binsMonthly || c.Broadband != broadbandMonthly || c.Total != 243 {
+		t.Errorf("living costs = %+v", c)
+	}
+	if c.ManagementFee != 0 {
+		t.Error("tenants do not pay the management fee")
+	}
+	p.ValueAnalysis.LivingCosts = c
+	if got := occupancyCost(&p, DefaultMortgageRate); got != 2243 {
+		t.Errorf("occupancy cost = %v, want 2243", got)
+	}
+}
+
+func TestEstimateLivingCostsDefaults(t *testing.T) {
+	two := 2
+	p := scraper.PropertyInfo{ListingType: "sale", PropertyType: "Apartment", BedroomsCount: &two, RentPrice: "€350,000",
+		ServiceCharge: &scraper.ServiceCharge{Annual: 1800, Monthly: 150, Stated: "service charge €1,800 p.a."}}
+	c := (&Analyzer{}).estimateLivingCosts(&p)
+	if c.Bins != 0 || c.ManagementFee != 150 {
+		t.Errorf("apartment for sale = %+v", c)
+	}
+	if !strings.Contains(strings.Join(c.Basis, "; "), "assumed D1") || !strings.Contains(c.Basis[0], "75 m²") {
+		t.Errorf("basis = %v", c.Basis)
+	}
+	p.ValueAnalysis.LivingCosts = c
+	if got := occupancyCost(&p, DefaultMortgageRate); got <= c.Total {
+		t.Errorf("occupancy cost %v should include the mortgage payment", got)
+	}
+}
+
+func TestEstimateLivingCostsBillsIncluded(t *testing.T) {
+	p := scraper.PropertyInfo{ListingType: "rent", Description: "Lovely studio, all bills included."}
+	if c := (&Analyzer{}).estimateLivingCosts(&p); c.Total != 0 || c.Energy != 0 {
+		t.Errorf("bills included = %+v", c)
+	}
+	if c := (&Analyzer{}).estimateLivingCosts(&scraper.PropertyInfo{ListingType: "share"}); c != nil {
+		t.Errorf("share = %+v, want nil", c)
+	}
+}
+
+func TestEstimateLivingCostsNamesTheBERSource(t *testing.T) {
+	p := scraper.PropertyInfo{ListingType: "rent", Description: "Two-bed cottage, BER F, oil heating"}
+	scraper.ResolveBER(&p)
+	if c := (&Analyzer{}).estimateLivingCosts(&p); c == nil || !strings.Contains(c.Basis[0], "BER F (from the listing description)") {
+		t.Errorf("living costs = %+v", c)
+	}
+}
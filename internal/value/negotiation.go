@@ -0,0 +1,116 @@
+package value
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Argumentos para negociar o preço ────────────────────────────── */
+
+// Negotiation diz se o preço pedido está acima da mediana dos comparáveis, em quanto,
+// e junta as provas (os comparáveis mais parecidos, o tempo no mercado e as reduções
+// de preço) para o cliente levar ao corretor. Precisa de pelo menos
+// negotiationMinComparables comparáveis; com menos, a mediana não diz nada.
+
+const (
+	negotiationMinComparables = 3
+	negotiationCited          = 5
+	negotiationMargin         = 5.0 // % da mediana que ainda conta como preço de mercado
+	LongOnMarketDays          = 30  // a partir daqui o tempo no mercado vira argumento
+)
+
+// NegotiationInsight monta a seção; nil sem preço ou com poucos comparáveis. Roda
+// depois do MarketHistory da análise, cujo tempo no mercado entra como prova.
+func NegotiationInsight(property *scraper.PropertyInfo) *scraper.Negotiation {
+	asking := scraper.MonthlyPrice(property)
+	var prices []float64
+	for _, s := range property.ValueAnalysis.Similar {
+		if s.Price > 0 {
+			prices = append(prices, s.Price)
+		}
+	}
+	if asking == 0 || len(prices) < negotiationMinComparables {
+		return nil
+	}
+
+	sort.Float64s(prices)
+	median := prices[len(prices)/2]
+	if len(prices)%2 == 0 {
+		median = (prices[len(prices)/2-1] + prices[len(prices)/2]) / 2
+	}
+	pct := math.Round((asking-median)/median*1000) / 10
+
+	n := &scraper.Negotiation{
+		AskingPrice:    asking,
+		MedianPrice:    math.Round(median),
+		AboveMedianPct: pct,
+		Comparables:    citedComparables(property.ValueAnalysis.Similar, asking, pct > 0),
+		Evidence:       []string{},
+	}
+	switch {
+	case pct > negotiationMargin:
+		n.Stance = "room_to_negotiate"
+		n.Evidence = append(n.Evidence, fmt.Sprintf("Asking €%.0f is %.0f%% above the median of %d comparable listings (€%.0f)",
+			asking, pct, len(prices), median))
+	case pct < -negotiationMargin:
+		n.Stance = "below_market"
+		n.Evidence = append(n.Evidence, fmt.Sprintf("Asking €%.0f is %.0f%% below the median of %d comparable listings (€%.0f)",
+			asking, -pct, len(prices), median))
+	default:
+		n.Stance = "at_market"
+		n.Evidence = append(n.Evidence, fmt.Sprintf("Asking €%.0f is in line with the median of %d comparable listings (€%.0f)",
+			asking, len(prices), median))
+	}
+
+	longListed := false
+	if h := property.MarketHistory; h != nil && h.PropertyDaysOnMarket >= LongOnMarketDays {
+		longListed = true
+		if len(h.Relists) > 0 {
+			n.Evidence = append(n.Evidence, fmt.Sprintf("On the market for %d days across %d listings", h.PropertyDaysOnMarket, len(h.Relists)+1))
+		} else {
+			n.Evidence = append(n.Evidence, fmt.Sprintf("On the market for %d days", h.PropertyDaysOnMarket))
+		}
+	}
+	// a tabela do Daft não garante a ordem: vale o maior preço já pedido
+	highest := scraper.PricePoint{}
+	for _, p := range property.ValueAnalysis.PriceHistory {
+		if p.Price > highest.Price {
+			highest = p
+		}
+	}
+	if listed := scraper.ExtractPriceValue(property.RentPrice); listed > 0 && highest.Price > listed {
+		n.Evidence = append(n.Evidence, fmt.Sprintf("Already reduced from €%.0f (%s)", highest.Price, highest.Date))
+	}
+
+	// anúncio parado há muito tempo a preço de mercado também abre espaço
+	if n.Stance == "at_market" && longListed {
+		n.Stance = "room_to_negotiate"
+	}
+	if n.Stance == "room_to_negotiate" {
+		// a mediana, sem pedir menos de 90% do anunciado (oferta baixa demais é ignorada)
+		offer := math.Max(math.Min(median, asking*0.97), asking*0.9)
+		n.SuggestedOffer = scraper.RoundToNearest50(offer)
+	}
+	return n
+}
+
+// citedComparables escolhe os comparáveis mais próximos do preço pedido; com o preço
+// acima da mediana, só os mais baratos que ele, que são os que sustentam a proposta
+func citedComparables(similar []scraper.SimilarProperty, asking float64, cheaperOnly bool) []scraper.SimilarProperty {
+	cited := []scraper.SimilarProperty{}
+	for _, s := range similar {
+		if s.Price > 0 && (!cheaperOnly || s.Price < asking) {
+			cited = append(cited, s)
+		}
+	}
+	sort.SliceStable(cited, func(i, j int) bool {
+		return math.Abs(cited[i].Price-asking) < math.Abs(cited[j].Price-asking)
+	})
+	if len(cited) > negotiationCited {
+		cited = cited[:negotiationCited]
+	}
+	return cited
+}
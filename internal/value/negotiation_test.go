@@ -0,0 +1,61 @@
+package value
+
+import (
+	"strings"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func negotiationFixture(asking float64, comparables ...float64) scraper.PropertyInfo {
+	p := scraper.PropertyInfo{ListingType: "rent", Price: &scraper.Price{Amount: asking, Period: "month", Monthly: asking}}
+	for i, price := range comparables {
+		p.ValueAnalysis.Similar = append(p.ValueAnalysis.Similar, scraper.SimilarProperty{
+			Address: "Comparable " + string(rune('A'+i)), Price: price, URL: "https://www.daft.ie/for-rent/x/" + string(rune('1'+i)),
+		})
+	}
+	return p
+}
+
+func TestNegotiationAboveMedian(t *testing.T) {
+	p := negotiationFixture(2200, 1900, 2000, 2000, 2100, 2300)
+	p.RentPrice = "€2,200 per month"
+	p.ValueAnalysis.PriceHistory = []scraper.PricePoint{{Date: "01/03/2026", Price: 2400}, {Date: "15/03/2026", Price: 2200}}
+	n := NegotiationInsight(&p)
+	if n == nil || n.Stance != "room_to_negotiate" || n.MedianPrice != 2000 || n.AboveMedianPct != 10 {
+		t.Fatalf("negotiation = %+v", n)
+	}
+	if n.SuggestedOffer != 2000 {
+		t.Errorf("suggested offer = %v, want 2000", n.SuggestedOffer)
+	}
+	// only the cheaper comparables back the offer, closest to the asking first
+	if len(n.Comparables) != 4 || n.Comparables[0].Price != 2100 {
+		t.Errorf("cited = %+v", n.Comparables)
+	}
+	if len(n.Evidence) != 2 || !strings.Contains(n.Evidence[1], "reduced from €2400") {
+		t.Errorf("evidence = %v", n.Evidence)
+	}
+}
+
+func TestNegotiationLongListedAtMarket(t *testing.T) {
+	p := negotiationFixture(2000, 1950, 2000, 2050)
+	if n := NegotiationInsight(&p); n.Stance != "at_market" || n.SuggestedOffer != 0 {
+		t.Errorf("fresh listing at market = %+v", n)
+	}
+	p.MarketHistory = &scraper.MarketHistory{PropertyDaysOnMarket: 60, Relists: []scraper.Relist{{URL: "https://www.daft.ie/old/1"}}}
+	n := NegotiationInsight(&p)
+	if n.Stance != "room_to_negotiate" || n.SuggestedOffer != 1950 || !strings.Contains(strings.Join(n.Evidence, ";"), "60 days across 2 listings") {
+		t.Errorf("long-listed = %+v", n)
+	}
+}
+
+func TestNegotiationNeedsComparables(t *testing.T) {
+	p := negotiationFixture(2000, 1800, 1900)
+	if n := NegotiationInsight(&p); n != nil {
+		t.Errorf("two comparables = %+v, want nil", n)
+	}
+	p = negotiationFixture(1500, 1900, 2000, 2100)
+	if n := NegotiationInsight(&p); n.Stance != "below_market" || len(n.Comparables) != 3 {
+		t.Errorf("below market = %+v", n)
+	}
+}
@@ -0,0 +1,100 @@
+package value
+
+import (
+	"strings"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+/* ───── Dicas de reforma energética com subsídio SEAI (compra) ──────── */
+
+// seaiMeasure é uma linha da tabela de referência. Valores dos Individual Energy
+// Upgrade Grants da SEAI (2025) e custos típicos de mercado; conferir em seai.ie.
+type seaiMeasure struct {
+	name       string
+	minBand    int                // pior-que-ou-igual a esta faixa para sugerir (ver scraper.BERBand)
+	grant      map[string]float64 // por tipo de imóvel
+	cost       map[string][2]float64
+	annualSave [2]float64
+}
+
+var seaiMeasures = []seaiMeasure{
+	{"Attic insulation", scraper.BERBand("C3"),
+		map[string]float64{"detached": 1500, "semi": 1300, "terrace": 1200, "apartment": 800},
+		map[string][2]float64{"detached": {1800, 3000}, "semi": {1500, 2500}, "terrace": {1200, 2200}, "apartment": {900, 1600}},
+		[2]float64{150, 400}},
+	{"Heating controls", scraper.BERBand("C3"),
+		map[string]float64{"detached": 700, "semi": 700, "terrace": 700, "apartment": 700},
+		map[string][2]float64{"detached": {1200, 2500}, "semi": {1200, 2500}, "terrace": {1000, 2200}, "apartment": {900, 2000}},
+		[2]float64{100, 300}},
+	{"Cavity wall insulation", scraper.BERBand("D1"),
+		map[string]float64{"detached": 1700, "semi": 1300, "terrace": 800, "apartment": 700},
+		map[string][2]float64{"detached": {2000, 3500}, "semi": {1500, 2800}, "terrace": {1000, 2000}, "apartment": {800, 1600}},
+		[2]float64{200, 500}},
+	{"Solar PV", scraper.BERBand("D1"),
+		map[string]float64{"detached": 1800, "semi": 1800, "terrace": 1800, "apartment": 1800},
+		map[string][2]float64{"detached": {6000, 9000}, "semi": {6000, 9000}, "terrace": {5000, 8000}, "apartment": {5000, 8000}},
+		[2]float64{400, 800}},
+	{"Window replacement", scraper.BERBand("E1"),
+		map[string]float64{"detached": 4000, "semi": 3000, "terrace": 1800, "apartment": 1500},
+		map[string][2]float64{"detached": {12000, 20000}, "semi": {9000, 15000}, "terrace": {6000, 10000}, "apartment": {5000, 9000}},
+		[2]float64{150, 400}},
+	{"External wall insulation", scraper.BERBand("E1"),
+		map[string]float64{"detached": 8000, "semi": 6000, "terrace": 3500, "apartment": 3000},
+		map[string][2]float64{"detached": {25000, 40000}, "semi": {18000, 30000}, "terrace": {12000, 20000}, "apartment": {10000, 18000}},
+		[2]float64{500, 1200}},
+	{"Heat pump system", scraper.BERBand("E1"),
+		map[string]float64{"detached": 6500, "semi": 6500, "terrace": 6500, "apartment": 4500},
+		map[string][2]float64{"detached": {12000, 18000}, "semi": {11000, 16000}, "terrace": {10000, 15000}, "apartment": {8000, 12000}},
+		[2]float64{600, 1500}},
+}
+
+// seaiHouseType mapeia o tipo de imóvel do anúncio para as categorias da SEAI
+func seaiHouseType(propertyType string) string {
+	t := strings.ToLower(propertyType)
+	switch {
+	case strings.Contains(t, "apartment"), strings.Contains(t, "flat"), strings.Contains(t, "duplex"), strings.Contains(t, "studio"):
+		return "apartment"
+	case strings.Contains(t, "semi"):
+		return "semi"
+	case strings.Contains(t, "terrace"), strings.Contains(t, "townhouse"):
+		return "terrace"
+	case strings.Contains(t, "detached"), strings.Contains(t, "bungalow"):
+		return "detached"
+	default:
+		return "semi"
+	}
+}
+
+// energyUpgradeHints sugere medidas elegíveis para imóveis à venda com BER C3 ou pior
+func energyUpgradeHints(property *scraper.PropertyInfo) *scraper.EnergyUpgradeHints {
+	band := scraper.BERBand(property.BER)
+	if property.ListingType != "sale" || band < scraper.BERBand("C3") {
+		return nil
+	}
+
+	hints := &scraper.EnergyUpgradeHints{
+		BER:       strings.ToUpper(property.BER),
+		HouseType: seaiHouseType(property.PropertyType),
+		Measures:  []scraper.EnergyUpgrade{},
+		Note:      "Indicative only: grant amounts from SEAI's published rates and typical contractor prices. A technical assessment is required before works.",
+	}
+	for _, m := range seaiMeasures {
+		if band < m.minBand {
+			continue
+		}
+		cost := m.cost[hints.HouseType]
+		hints.Measures = append(hints.Measures, scraper.EnergyUpgrade{
+			Measure:         m.name,
+			Grant:           m.grant[hints.HouseType],
+			CostMin:         cost[0],
+			CostMax:         cost[1],
+			AnnualSavingMin: m.annualSave[0],
+			AnnualSavingMax: m.annualSave[1],
+		})
+		hints.TotalGrant += m.grant[hints.HouseType]
+		hints.TotalCostMin += cost[0]
+		hints.TotalCostMax += cost[1]
+	}
+	return hints
+}
@@ -0,0 +1,29 @@
+package value
+
+import (
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestEnergyUpgradeHints(t *testing.T) {
+	rental := &scraper.PropertyInfo{ListingType: "rent", BER: "G"}
+	if energyUpgradeHints(rental) != nil {
+		t.Fatalf("expected no hints for rental listings")
+	}
+	efficient := &scraper.PropertyInfo{ListingType: "sale", BER: "B2"}
+	if energyUpgradeHints(efficient) != nil {
+		t.Fatalf("expected no hints for B2")
+	}
+
+	hints := energyUpgradeHints(&scraper.PropertyInfo{ListingType: "sale", BER: "e2", PropertyType: "Terraced House"})
+	if hints == nil {
+		t.Fatalf("expected hints for E2 sale listing")
+	}
+	if hints.HouseType != "terrace" || len(hints.Measures) != len(seaiMeasures) {
+		t.Fatalf("unexpected hints: %+v", hints)
+	}
+	if hints.TotalGrant <= 0 || hints.TotalCostMin > hints.TotalCostMax {
+		t.Fatalf("unexpected totals: %+v", hints)
+	}
+}
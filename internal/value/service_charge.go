@@ -0,0 +1,181 @@
+package value
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+/* ───── Taxa de condomínio (service charge) e OMC ───────────────────── */
+
+// serviceChargePattern casa "service charge of €1,850 p.a.", "management fee: €120 per month"
+var serviceChargePattern = regexp.MustCompile(`(?i)(?:service|management)\s+(?:charges?|fees?)[^€\d]{0,30}€\s?(\d[\d,]*(?:\.\d{2})?)\s*(p\.?\s?a\.?|per\s+(?:annum|year|month)|a\s+(?:year|month)|annually|monthly|pm\b|p\.?m\.?)?`)
+
+// parseServiceCharge procura a taxa de condomínio no texto e a normaliza para valor anual
+func parseServiceCharge(text string) *scraper.ServiceCharge {
+	m := serviceChargePattern.FindStringSubmatch(text)
+	if m == nil {
+		return nil
+	}
+	value, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+	if err != nil || value <= 0 {
+		return nil
+	}
+
+	period := strings.ToLower(m[2])
+	monthly := strings.Contains(period, "month") || strings.HasPrefix(strings.ReplaceAll(period, ".", ""), "pm")
+	// sem período explícito, valores baixos são quase sempre mensais
+	if period == "" && value < 500 {
+		monthly = true
+	}
+	annual := value
+	if monthly {
+		annual = value * 12
+	}
+	return &scraper.ServiceCharge{
+		Annual:  annual,
+		Monthly: math.Round(annual/12*100) / 100,
+		Stated:  strings.TrimSpace(m[0]),
+	}
+}
+
+// IsApartment indica se o imóvel costuma ter OMC e taxa de condomínio
+func IsApartment(property *scraper.PropertyInfo) bool {
+	return seaiHouseType(property.PropertyType) == "apartment" ||
+		strings.Contains(strings.ToLower(property.Address), "apartment")
+}
+
+// resolveServiceCharge preenche property.ServiceCharge e a OMC do empreendimento
+func (v *Analyzer) resolveServiceCharge(ctx context.Context, property *scraper.PropertyInfo) {
+	if !IsApartment(property) {
+		return
+	}
+	property.ServiceCharge = parseServiceCharge(property.Description)
+
+	development := developmentName(property.Address)
+	if development == "" {
+		return
+	}
+	omc, err := v.CRO.lookup(ctx, development)
+	if err != nil {
+		telemetry.LogFor(ctx).Warn("Management company lookup failed", "development", development, "error", err)
+		return
+	}
+	if omc == nil {
+		return
+	}
+	if property.ServiceCharge == nil {
+		// OMC conhecida mas taxa não declarada: ainda vale avisar o comprador
+		property.ServiceCharge = &scraper.ServiceCharge{}
+	}
+	property.ServiceCharge.ManagementCompany = omc
+}
+
+var unitPrefix = regexp.MustCompile(`(?i)^(apt\.?|apartment|unit|flat|no\.?)?\s*\d+[a-z]?\b`)
+
+// developmentName tira o número do apartamento do endereço e devolve o nome do empreendimento
+func developmentName(addr string) string {
+	for _, part := range strings.Split(addr, ",") {
+		part = strings.TrimSpace(unitPrefix.ReplaceAllString(strings.TrimSpace(part), ""))
+		if part != "" {
+			return part
+		}
+	}
+	return ""
+}
+
+// CROClient consulta a API do CRO com as credenciais CRO_API_EMAIL e CRO_API_KEY
+type CROClient struct {
+	Email string
+	Key   string
+}
+
+// lookup busca no CRO a OMC do empreendimento. Sem credenciais, a consulta é pulada.
+func (c CROClient) lookup(ctx context.Context, development string) (*scraper.ManagementCompany, error) {
+	if c.Email == "" || c.Key == "" {
+		return nil, nil
+	}
+
+	q := url.Values{
+		"company_name": {development},
+		"searchType":   {"3"}, // contém todas as palavras
+		"format":       {"json"},
+		"max":          {"25"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://services.cro.ie/cws/companies?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.Email, c.Key)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CRO API returned status code: %d", resp.StatusCode)
+	}
+
+	var companies []struct {
+		Number int    `json:"company_num"`
+		Name   string `json:"company_name"`
+		Status string `json:"company_status_desc"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&companies); err != nil {
+		return nil, fmt.Errorf("error decoding CRO response: %w", err)
+	}
+
+	var best *scraper.ManagementCompany
+	for _, c := range companies {
+		name := strings.ToUpper(c.Name)
+		if !strings.Contains(name, "MANAGEMENT") && !strings.Contains(name, "OMC") && !strings.Contains(name, "OWNERS") {
+			continue
+		}
+		omc := &scraper.ManagementCompany{Name: c.Name, Number: strconv.Itoa(c.Number), Status: c.Status}
+		if strings.EqualFold(c.Status, "Normal") {
+			return omc, nil
+		}
+		if best == nil {
+			best = omc
+		}
+	}
+	return best, nil
+}
+
+// DefaultMortgageRate é a taxa da prestação estimada sem MORTGAGE_RATE, em % ao ano
+const DefaultMortgageRate = 4.0
+
+// monthlyMortgagePayment estima a prestação com 90% de financiamento em 30 anos, à
+// taxa rate (% ao ano; ver Analyzer.MortgageRate)
+func monthlyMortgagePayment(price, rate float64) float64 {
+	principal := price * 0.9
+	r := rate / 100 / 12
+	n := 30.0 * 12
+	return principal * r / (1 - math.Pow(1+r, -n))
+}
+
+// EffectiveMonthlyCost soma prestação estimada e taxa de condomínio. Só vale para venda:
+// no aluguel a taxa é paga pelo proprietário.
+func EffectiveMonthlyCost(property *scraper.PropertyInfo, rate float64) float64 {
+	price := scraper.ExtractPriceValue(property.RentPrice)
+	if property.ListingType != "sale" || price == 0 {
+		return 0
+	}
+	var serviceCharge float64
+	if property.ServiceCharge != nil {
+		serviceCharge = property.ServiceCharge.Monthly
+	}
+	return math.Round(monthlyMortgagePayment(price, rate) + serviceCharge)
+}
@@ -0,0 +1,35 @@
+package value
+
+import "testing"
+
+func TestParseServiceCharge(t *testing.T) {
+	cases := []struct {
+		input  string
+		annual float64
+	}{
+		{"Service charge approx. €1,850 p.a. Managed by Aramark.", 1850},
+		{"The management fee is €150 per month", 1800},
+		{"Service charges: €2100 per annum", 2100},
+		{"Service charge €120", 1440},
+		{"Bright two bed apartment", 0},
+	}
+	for _, c := range cases {
+		got := parseServiceCharge(c.input)
+		var annual float64
+		if got != nil {
+			annual = got.Annual
+		}
+		if annual != c.annual {
+			t.Errorf("parseServiceCharge(%q) annual = %v, want %v", c.input, annual, c.annual)
+		}
+	}
+}
+
+func TestDevelopmentName(t *testing.T) {
+	if got := developmentName("Apartment 12, Clarion Quay, IFSC, Dublin 1"); got != "Clarion Quay" {
+		t.Errorf("got %q", got)
+	}
+	if got := developmentName("Apt. 4B, The Maltings, Bray, Co. Wicklow"); got != "The Maltings" {
+		t.Errorf("got %q", got)
+	}
+}
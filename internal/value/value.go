@@ -0,0 +1,195 @@
+package value
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+
+	"github.com/gocolly/colly/v2"
+)
+
+/* ───── Análise de valor ────────────────────────────────────────────── */
+
+// Analyzer reúne os ajustes e os clientes da análise de valor. Fica no campo
+// Value do Analyzer, preenchido por enrich.NewAnalyzer; num Analyzer montado à mão os
+// campos zerados usam os padrões.
+type Analyzer struct {
+	MortgageRate float64   // juros anuais (%) da prestação estimada; 0 = DefaultMortgageRate
+	EnergyPrice  float64   // ENERGY_PRICE_KWH em €/kWh; 0 = DefaultEnergyPrice
+	CRO          CROClient // OMC dos empreendimentos; sem credenciais, a consulta é pulada
+	Tesseract    string    // binário do OCR das plantas
+
+	// PrivateComparables devolve os comparáveis enviados por agências na área e na
+	// faixa de preço do imóvel; nil = só os dos portais
+	PrivateComparables func(ctx context.Context, property *scraper.PropertyInfo, minPrice, maxPrice float64) []scraper.SimilarProperty
+}
+
+// Rate é a taxa da prestação estimada; sem MortgageRate, DefaultMortgageRate
+func (v *Analyzer) Rate() float64 {
+	if v.MortgageRate > 0 {
+		return v.MortgageRate
+	}
+	return DefaultMortgageRate
+}
+
+// findSimilarProperties busca comparáveis em todos os portais registrados (ver comparables.go)
+func (v *Analyzer) findSimilarProperties(ctx context.Context, property *scraper.PropertyInfo) error {
+	// a janela de preço e os comparáveis estão em valores mensais
+	basePrice := scraper.MonthlyPrice(property)
+	minPrice := scraper.RoundToNearest50(basePrice * 0.8)
+	maxPrice := scraper.RoundToNearest50(basePrice * 1.2)
+
+	if scraper.ModuleEnabled("value.comparables") {
+		property.ValueAnalysis.Similar = collectComparables(ctx, property, minPrice, maxPrice)
+	}
+
+	// comparáveis enviados por agências reforçam áreas com poucos anúncios
+	if v.PrivateComparables != nil {
+		property.ValueAnalysis.Similar = dedupeComparables(append(property.ValueAnalysis.Similar,
+			v.PrivateComparables(ctx, property, minPrice, maxPrice)...))
+	}
+
+	telemetry.LogFor(ctx).Debug("Similar properties found", "count", len(property.ValueAnalysis.Similar))
+	return nil
+}
+
+// CalculateAreaAveragePrice calcula o preço médio da área
+func CalculateAreaAveragePrice(property *scraper.PropertyInfo) {
+	if len(property.ValueAnalysis.Similar) == 0 {
+		return
+	}
+
+	var total float64
+	count := 0
+
+	// Calcular média dos preços similares
+	for _, similar := range property.ValueAnalysis.Similar {
+		if similar.Price > 0 {
+			total += similar.Price
+			count++
+		}
+	}
+
+	if count > 0 {
+		property.ValueAnalysis.AreaAveragePrice = total / float64(count)
+	}
+}
+
+// CalculatePriceRating calcula o rating de preço (1-10)
+func CalculatePriceRating(property *scraper.PropertyInfo) {
+	if property.ValueAnalysis.AreaAveragePrice == 0 {
+		return
+	}
+
+	currentPrice := scraper.MonthlyPrice(property)
+	avgPrice := property.ValueAnalysis.AreaAveragePrice
+
+	// Calcular diferença percentual do preço médio
+	priceDiff := ((avgPrice - currentPrice) / avgPrice) * 100
+
+	// Converter diferença em rating (1-10)
+	// Quanto mais barato em relação à média, maior o rating
+	switch {
+	case priceDiff >= 20: // 20% ou mais abaixo da média
+		property.ValueAnalysis.PriceRating = 10
+	case priceDiff >= 15:
+		property.ValueAnalysis.PriceRating = 9
+	case priceDiff >= 10:
+		property.ValueAnalysis.PriceRating = 8
+	case priceDiff >= 5:
+		property.ValueAnalysis.PriceRating = 7
+	case priceDiff >= 0:
+		property.ValueAnalysis.PriceRating = 6
+	case priceDiff >= -5:
+		property.ValueAnalysis.PriceRating = 5
+	case priceDiff >= -10:
+		property.ValueAnalysis.PriceRating = 4
+	case priceDiff >= -15:
+		property.ValueAnalysis.PriceRating = 3
+	case priceDiff >= -20:
+		property.ValueAnalysis.PriceRating = 2
+	default: // Mais de 20% acima da média
+		property.ValueAnalysis.PriceRating = 1
+	}
+}
+
+// getPriceHistory busca histórico de preços do imóvel
+func getPriceHistory(ctx context.Context, property *scraper.PropertyInfo) error {
+	c := colly.NewCollector(
+		colly.AllowedDomains(scraper.ScrapeDomains()...),
+		colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+	)
+	scraper.BindCollector(ctx, c)
+	scraper.DebugCollector(ctx, c)
+	scraper.LimitCrawl(c)
+
+	c.OnHTML("div[data-testid='price-history'] table", func(e *colly.HTMLElement) {
+		e.ForEach("tr", func(_ int, row *colly.HTMLElement) {
+			date := row.ChildText("td:first-child")
+			price := row.ChildText("td:last-child")
+
+			if date != "" && price != "" {
+				pricePoint := scraper.PricePoint{
+					Date:  date,
+					Price: scraper.ExtractPriceValue(price),
+				}
+				property.ValueAnalysis.PriceHistory = append(property.ValueAnalysis.PriceHistory, pricePoint)
+			}
+		})
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		telemetry.LogFor(ctx).Warn("Price history request failed", "status", r.StatusCode, "error", err)
+	})
+
+	err := c.Visit(property.URL)
+	if err != nil {
+		return fmt.Errorf("error visiting property page: %w", err)
+	}
+
+	return nil
+}
+
+// Analisar valor do imóvel
+func (v *Analyzer) Analyze(ctx context.Context, property *scraper.PropertyInfo) error {
+	// 1. Encontrar imóveis similares
+	if err := v.findSimilarProperties(ctx, property); err != nil {
+		telemetry.LogFor(ctx).Warn("Similar properties search failed", "error", err)
+	}
+
+	// 2. Calcular preço médio da área
+	CalculateAreaAveragePrice(property)
+
+	// 3. Calcular rating de preço
+	CalculatePriceRating(property)
+
+	// 4. Preço por m² (área do anúncio, da descrição ou do OCR da planta)
+	v.resolveFloorArea(ctx, property)
+	if property.FloorArea != nil && property.FloorArea.SquareMeters > 0 {
+		price := scraper.MonthlyPrice(property)
+		property.ValueAnalysis.PricePerSqm = math.Round(price/property.FloorArea.SquareMeters*100) / 100
+	}
+
+	// 5. Reformas com subsídio SEAI (imóveis à venda com BER ruim)
+	property.ValueAnalysis.EnergyUpgrades = energyUpgradeHints(property)
+
+	// 6. Taxa de condomínio e custo mensal efetivo
+	v.resolveServiceCharge(ctx, property)
+	property.ValueAnalysis.EffectiveMonthly = EffectiveMonthlyCost(property, v.Rate())
+
+	// 7. Custos de morar além do aluguel (energia pelo BER, lixo, internet, condomínio)
+	property.ValueAnalysis.LivingCosts = v.estimateLivingCosts(property)
+	property.ValueAnalysis.OccupancyCost = occupancyCost(property, v.Rate())
+
+	// 8. Buscar histórico de preços
+	if scraper.ModuleEnabled("value.price_history") {
+		if err := getPriceHistory(ctx, property); err != nil {
+			telemetry.LogFor(ctx).Warn("Price history failed", "error", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package value
+
+import (
+	"context"
+	"testing"
+
+	"daft-scraper-api/internal/scraper"
+)
+
+func TestComparablesDisabledInDeployment(t *testing.T) {
+	t.Setenv("MODULES_VALUE_COMPARABLES", "false")
+	reloadModules(t)
+	prev := comparablesSources
+	defer func() { comparablesSources = prev }()
+	called := false
+	comparablesSources = []comparablesSource{{Name: "fake",
+		Fetch: func(context.Context, *scraper.PropertyInfo, float64, float64) ([]scraper.SimilarProperty, error) {
+			called = true
+			return nil, nil
+		}}}
+
+	p := &scraper.PropertyInfo{RentPrice: "€2,000 per month"}
+	(&Analyzer{}).findSimilarProperties(context.Background(), p)
+	if called {
+		t.Error("comparables should not be scraped when value.comparables is disabled")
+	}
+}
+
+// reloadModules rereads the MODULES_* flags after t.Setenv and restores them afterwards
+func reloadModules(t *testing.T) {
+	t.Helper()
+	prev := scraper.DisabledModules
+	scraper.DisabledModules = scraper.DisabledModulesFromEnv()
+	t.Cleanup(func() { scraper.DisabledModules = prev })
+}
@@ -0,0 +1,16 @@
+//go:build lambda
+
+package main
+
+import (
+	"daft-scraper-api/internal/server"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// main atende os eventos do API Gateway com os mesmos handlers do servidor (ver
+// internal/server/lambda.go)
+func main() {
+	server.Configure()
+	lambda.Start(server.Lambda())
+}
@@ -0,0 +1,12 @@
+//go:build !lambda
+
+package main
+
+import "daft-scraper-api/internal/server"
+
+// main sobe o servidor HTTP e os jobs em segundo plano; o build com -tags lambda usa
+// o main de main_lambda.go
+func main() {
+	server.Configure()
+	server.Run()
+}
@@ -0,0 +1,66 @@
+// Package exchangehelper expõe a análise de anúncios do Daft.ie como biblioteca, para
+// programas Go que querem embutir o analisador sem subir o servidor HTTP.
+//
+// A configuração vem das mesmas variáveis de ambiente do servidor (GOOGLE_MAPS_API_KEY,
+// PLACES_PROVIDER, GEOCODERS, MODULES_*...), lidas uma vez: as MODULES_* quando o
+// pacote é importado, o resto na primeira análise. O .env e o CONFIG_FILE não são
+// carregados. Sem o Store do servidor não há cache de análises, e os módulos que
+// dependem dos anúncios já vistos (fotos repetidas, tempo no mercado, anotações)
+// ficam de fora.
+package exchangehelper
+
+import (
+	"context"
+	"sync"
+
+	"daft-scraper-api/internal/enrich"
+	"daft-scraper-api/internal/scraper"
+	"daft-scraper-api/internal/telemetry"
+)
+
+// Analysis é a resposta de /v2/analyze: o anúncio enriquecido e a análise de segurança
+type Analysis = scraper.AnalysisResponse
+
+// Options escolhe os módulos da análise, com os nomes de ?modules= e ?skip=; vazio
+// roda todos os habilitados
+type Options struct {
+	Modules []string
+	Skip    []string
+}
+
+// analyzer é o Analyzer da biblioteca, lido do ambiente na primeira análise
+var analyzer = sync.OnceValue(enrich.NewAnalyzerFromEnv)
+
+// Analyze raspa e analisa o anúncio em url. Um anúncio sem dados volta com o erro em
+// Property.Error e também como err; um nome de módulo desconhecido é um erro. Um
+// Analyzer já no contexto (enrich.WithAnalyzer) é usado no lugar do lido do ambiente.
+func Analyze(ctx context.Context, url string, opts Options) (Analysis, error) {
+	modules, err := scraper.ParseModules(opts.Modules, opts.Skip)
+	if err != nil {
+		return Analysis{}, err
+	}
+	a := enrich.AnalyzerFrom(ctx)
+	if a == nil {
+		a = analyzer()
+		ctx = enrich.WithAnalyzer(ctx, a)
+	}
+
+	var analysis Analysis
+	if analysis.Property, err = a.ScrapeDaftPropertyModules(ctx, scraper.CanonicalListingURL(url), modules); err != nil {
+		return analysis, err
+	}
+	if modules.NeedsLocation() && analysis.Property.Coordinates.Lat == 0 && analysis.Property.Coordinates.Lng == 0 {
+		if err := a.GetCoordinates(ctx, &analysis.Property); err != nil {
+			telemetry.LogFor(ctx).Warn("Geocoding failed", "url", url, "error", err)
+		}
+	}
+	if modules.Has("safety") {
+		if err := a.AnalyzeSafety(ctx, &analysis); err != nil {
+			telemetry.LogFor(ctx).Warn("Safety analysis failed", "url", url, "error", err)
+		}
+	}
+	if analysis.Property.Error != nil {
+		err = analysis.Property.Error
+	}
+	return analysis, err
+}
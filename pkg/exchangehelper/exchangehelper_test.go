@@ -0,0 +1,38 @@
+package exchangehelper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><meta property="og:title" content="1 Main Street, Dublin 1 to share on Daft.ie">`+
+			`<meta property="og:description" content="€1,800 per month, 2 Bed"></head></html>`)
+	}))
+	defer srv.Close()
+	prevTransport := http.DefaultTransport
+	http.DefaultTransport = srv.Client().Transport
+	t.Cleanup(func() { http.DefaultTransport = prevTransport })
+	t.Setenv("SCRAPE_DOMAINS", "127.0.0.1")
+
+	// photos only, so the test never reaches the geocoders
+	analysis, err := Analyze(context.Background(), srv.URL+"/for-rent/x/123", Options{Modules: []string{"photos"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.Property.Address != "1 Main Street, Dublin 1" || analysis.Property.RentPrice != "€1,800" {
+		t.Errorf("property = %+v", analysis.Property)
+	}
+}
+
+func TestAnalyzeRejectsUnknownModules(t *testing.T) {
+	_, err := Analyze(context.Background(), "https://www.daft.ie/for-rent/x/123", Options{Modules: []string{"weather"}})
+	if err == nil || !strings.Contains(err.Error(), `unknown module "weather"`) {
+		t.Errorf("err = %v", err)
+	}
+}
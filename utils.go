@@ -1,15 +0,0 @@
-package main
-
-import "math"
-
-// calculateDistance calcula a distância em km entre dois pontos usando a fórmula de Haversine
-func calculateDistance(lat1, lng1, lat2, lng2 float64) float64 {
-	const earthRadiusKm = 6371.0
-	dLat := (lat2 - lat1) * (math.Pi / 180)
-	dLng := (lng2 - lng1) * (math.Pi / 180)
-	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
-		math.Cos(lat1*(math.Pi/180))*math.Cos(lat2*(math.Pi/180))*
-			math.Sin(dLng/2)*math.Sin(dLng/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-	return earthRadiusKm * c
-}
\ No newline at end of file